@@ -14,8 +14,12 @@ import {
 import { useAddOtp, useListOtps } from "../hooks/otp";
 import { toast } from "../lib/toast";
 import { validateTOTPSecret, normalizeTOTPSecret } from "../lib/totp";
-import { encryptData } from "../lib/crypto";
-import { getSessionEncryptionKey, isWebAuthnSupported } from "../lib/webauthn";
+import { encryptData, formatSecretEnvelope } from "../lib/crypto";
+import {
+  getSessionEncryptionKey,
+  getSessionKeyVersion,
+  isWebAuthnSupported,
+} from "../lib/webauthn";
 
 import { WebAuthnRegistrationModal } from "./webauthn-registration-modal";
 
@@ -188,8 +192,11 @@ export function AddOtpModal({ isOpen, onClose }: AddOtpModalProps) {
         encryptionKey,
       );
 
-      // Format encrypted secret as ciphertext.iv.authTag for backend storage
-      const secretForBackend = `${encryptedSecret.ciphertext}.${encryptedSecret.iv}.${encryptedSecret.authTag}`;
+      // Wrap the encrypted secret in an envelope carrying the vault's current key version
+      const secretForBackend = formatSecretEnvelope(
+        encryptedSecret,
+        await getSessionKeyVersion(),
+      );
 
       const otpData = {
         active: true,
@@ -23,6 +23,7 @@ interface ContextMenuProps {
   setShowQR: (show: boolean) => void;
   setShowEdit: (show: boolean) => void;
   otpID: string;
+  revision?: number;
 }
 
 export function ContextMenu({
@@ -31,25 +32,29 @@ export function ContextMenu({
   setShowQR,
   setShowEdit,
   otpID,
+  revision,
 }: ContextMenuProps) {
   const isMobile = useMediaQuery("(max-width: 768px)");
   const inactivateOtpMutation = useInactivateOtp();
 
   const handleDelete = useCallback(() => {
-    inactivateOtpMutation.mutate(otpID, {
-      onSuccess: () => {
-        closeMenu();
-        toast.success("OTP deleted successfully");
-      },
-      onError: (error: any) => {
-        console.error("Error deleting OTP:", error);
-        const errorMessage =
-          error.response?.data?.error || "Failed to delete OTP";
+    inactivateOtpMutation.mutate(
+      { otpID, revision },
+      {
+        onSuccess: () => {
+          closeMenu();
+          toast.success("OTP deleted successfully");
+        },
+        onError: (error: any) => {
+          console.error("Error deleting OTP:", error);
+          const errorMessage =
+            error.response?.data?.error || "Failed to delete OTP";
 
-        toast.error(errorMessage);
+          toast.error(errorMessage);
+        },
       },
-    });
-  }, [otpID, inactivateOtpMutation, closeMenu]);
+    );
+  }, [otpID, revision, inactivateOtpMutation, closeMenu]);
 
   const handleEdit = useCallback(() => {
     setShowEdit(true);
@@ -15,7 +15,7 @@ import QRCode from "qrcode.react";
 import { MdSecurity, MdFingerprint, MdKey, MdOpenInNew } from "react-icons/md";
 
 import { authenticateWebAuthn, isWebAuthnSupported, getSessionEncryptionKey } from "../lib/webauthn";
-import { decryptData } from "../lib/crypto";
+import { decryptData, parseSecretEnvelope } from "../lib/crypto";
 import { toast } from "../lib/toast";
 
 interface QRModalProps {
@@ -54,22 +54,11 @@ export function QRModal({ showQR, closeQR, otp }: QRModalProps) {
       // Get the encryption key from WebAuthn session
       const encryptionKey = await getSessionEncryptionKey();
       
-      // Parse the encrypted secret (format: "ciphertext.iv.authTag")
-      const [ciphertext, iv, authTag] = otp.Secret.split(".");
-      
-      if (!ciphertext || !iv || !authTag) {
-        throw new Error("Invalid encrypted secret format");
-      }
+      // Parse the encrypted secret envelope
+      const encryptedData = parseSecretEnvelope(otp.Secret);
       
       // Decrypt the secret
-      const decrypted = await decryptData(
-        {
-          ciphertext,
-          iv,
-          authTag,
-        },
-        encryptionKey,
-      );
+      const decrypted = await decryptData(encryptedData, encryptionKey);
       
       setDecryptedSecret(decrypted);
     } catch (error) {
@@ -181,6 +181,7 @@ export function SmartOTPCard({
           activeMenu={activeMenu}
           closeMenu={closeMenu}
           otpID={otp.Id}
+          revision={otp.Revision}
           setShowEdit={setShowEditModal}
           setShowQR={setShowQRModal}
         />
@@ -24,6 +24,11 @@ interface EditOTPParams {
   otp: Partial<OTP>;
 }
 
+interface InactivateOTPParams {
+  otpID: string;
+  revision?: number;
+}
+
 export const useAddOtp = () => {
   const queryClient = useQueryClient();
 
@@ -39,12 +44,17 @@ export const useAddOtp = () => {
 export const useInactivateOtp = () => {
   const queryClient = useQueryClient();
 
-  return useMutation<unknown, Error, string>({
-    mutationFn: inactivateOtp,
+  return useMutation<unknown, Error, InactivateOTPParams>({
+    mutationFn: ({ otpID, revision }: InactivateOTPParams) =>
+      inactivateOtp(otpID, revision),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ["otps"] });
       queryClient.invalidateQueries({ queryKey: ["otpCodes"] });
     },
+    // 412: the entry changed on another device, so reload it
+    onError: () => {
+      queryClient.invalidateQueries({ queryKey: ["otps"] });
+    },
   });
 };
 
@@ -52,11 +62,16 @@ export const useEditOtp = () => {
   const queryClient = useQueryClient();
 
   return useMutation<unknown, Error, EditOTPParams>({
-    mutationFn: ({ otpID, otp }: EditOTPParams) => editOtp(otpID, otp),
+    mutationFn: ({ otpID, otp }: EditOTPParams) =>
+      editOtp(otpID, otp, otp.Revision),
     onSuccess: () => {
       queryClient.invalidateQueries({ queryKey: ["otps"] });
       queryClient.invalidateQueries({ queryKey: ["otpCodes"] });
     },
+    // 412: the entry changed on another device, so reload it
+    onError: () => {
+      queryClient.invalidateQueries({ queryKey: ["otps"] });
+    },
   });
 };
 
@@ -0,0 +1,36 @@
+import { describe, it, expect } from "vitest";
+
+import { formatSecretEnvelope, parseSecretEnvelope } from "../crypto";
+
+describe("secret envelopes", () => {
+  const data = {
+    ciphertext: "Y2lwaGVydGV4dA==",
+    iv: "AQEBAQEBAQEBAQEB",
+    authTag: "AgICAgICAgICAgICAgICAg==",
+  };
+
+  it("carries the key version", () => {
+    expect(formatSecretEnvelope(data, 3)).toBe(
+      `v1.1.3.${data.iv}.${data.authTag}.${data.ciphertext}`,
+    );
+  });
+
+  it("parses what it formats", () => {
+    expect(parseSecretEnvelope(formatSecretEnvelope(data, 2))).toEqual(data);
+  });
+
+  it("parses legacy secrets", () => {
+    expect(
+      parseSecretEnvelope(`${data.ciphertext}.${data.iv}.${data.authTag}`),
+    ).toEqual(data);
+  });
+
+  it("rejects unknown formats", () => {
+    expect(() =>
+      parseSecretEnvelope(`v2.1.1.${data.iv}.${data.authTag}.${data.ciphertext}`),
+    ).toThrow("Invalid encrypted secret format");
+    expect(() => parseSecretEnvelope("not-encrypted")).toThrow(
+      "Invalid encrypted secret format",
+    );
+  });
+});
@@ -1,4 +1,9 @@
-import axios, { AxiosError, AxiosInstance, AxiosRequestConfig } from "axios";
+import axios, {
+  AxiosError,
+  AxiosInstance,
+  AxiosRequestConfig,
+  InternalAxiosRequestConfig,
+} from "axios";
 
 import { toast } from "../toast";
 
@@ -20,6 +25,60 @@ export const setAuthManager = (manager: AuthManager) => {
   authManager = manager;
 };
 
+const REFRESH_PATH = "/api/v1/auth/refresh";
+
+// Refresh tokens are single-use: two tabs presenting the same one look like a stolen token being
+// replayed, and the server revokes the whole session. Tabs therefore take turns through a Web
+// Lock, and a tab that waited skips its refresh when another tab refreshed in the meantime.
+const REFRESH_LOCK = "2fair-token-refresh";
+const REFRESHED_AT_KEY = "2fair-token-refreshed-at";
+
+let refreshing: Promise<boolean> | null = null;
+
+const requestRefresh = async (): Promise<boolean> => {
+  try {
+    await axios.post(API_URL + REFRESH_PATH, undefined, {
+      withCredentials: true,
+    });
+    localStorage.setItem(REFRESHED_AT_KEY, String(Date.now()));
+
+    return true;
+  } catch {
+    return false;
+  }
+};
+
+const refreshedSince = (time: number) =>
+  Number(localStorage.getItem(REFRESHED_AT_KEY) || 0) > time;
+
+/**
+ * Exchanges the refresh token cookie for a new token pair. Concurrent calls in this tab share one
+ * request and calls from other tabs are serialized. Resolves to false when the session cannot be
+ * refreshed and the user has to sign in again.
+ */
+export const refreshSession = (): Promise<boolean> => {
+  if (!refreshing) {
+    const requestedAt = Date.now();
+    const refresh = () =>
+      refreshedSince(requestedAt) ? Promise.resolve(true) : requestRefresh();
+
+    refreshing = (
+      navigator.locks
+        ? navigator.locks.request(REFRESH_LOCK, refresh)
+        : refresh()
+    ).finally(() => {
+      refreshing = null;
+    });
+  }
+
+  return refreshing;
+};
+
+// Marks a request already retried after a refresh, so a second 401 signs the user out
+interface RetriableRequestConfig extends InternalAxiosRequestConfig {
+  _retried?: boolean;
+}
+
 class ApiClient {
   private client: AxiosInstance;
   private recentErrors: Set<string> = new Set();
@@ -51,7 +110,22 @@ class ApiClient {
     // Response interceptor
     this.client.interceptors.response.use(
       (response) => response,
-      (error: AxiosError<ApiErrorResponse>) => {
+      async (error: AxiosError<ApiErrorResponse>) => {
+        // Access tokens are short-lived: refresh once and retry before signing the user out
+        const request = error.config as RetriableRequestConfig | undefined;
+
+        if (
+          error.response?.status === 401 &&
+          request &&
+          !request._retried &&
+          !request.url?.endsWith(REFRESH_PATH)
+        ) {
+          request._retried = true;
+          if (await refreshSession()) {
+            return this.client(request);
+          }
+        }
+
         // Handle 401 errors by calling auth manager
         if (error.response?.status === 401) {
           if (authManager) {
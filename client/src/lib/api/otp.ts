@@ -4,12 +4,22 @@ export const addOtp = async (otp: any) => {
   return await apiClient.post("/api/v1/otp", otp);
 };
 
-export const inactivateOtp = async (otpID: string) => {
-  return await apiClient.post(`/api/v1/otp/${otpID}/inactivate`);
+// Writes are conditional on the revision the client last saw, so edits from
+// another device are never silently overwritten. "*" skips the check.
+const ifMatch = (revision?: number) => ({
+  headers: { "If-Match": revision ? `"${revision}"` : "*" },
+});
+
+export const inactivateOtp = async (otpID: string, revision?: number) => {
+  return await apiClient.post(
+    `/api/v1/otp/${otpID}/inactivate`,
+    undefined,
+    ifMatch(revision),
+  );
 };
 
-export const editOtp = async (otpID: string, otp: any) => {
-  return await apiClient.put(`/api/v1/otp/${otpID}`, otp);
+export const editOtp = async (otpID: string, otp: any, revision?: number) => {
+  return await apiClient.put(`/api/v1/otp/${otpID}`, otp, ifMatch(revision));
 };
 
 export const listOtps = async () => {
@@ -9,6 +9,54 @@ export interface EncryptedData {
   authTag: string; // Base64 encoded
 }
 
+/** Cipher suite IDs in secret envelopes */
+export const CIPHER_SUITE_AES_256_GCM = 1;
+
+/**
+ * Formats encrypted data as a secret envelope for the backend:
+ * "v1.<suite>.<keyVersion>.<iv>.<authTag>.<ciphertext>"
+ */
+export function formatSecretEnvelope(
+  data: EncryptedData,
+  keyVersion: number,
+): string {
+  return [
+    "v1",
+    CIPHER_SUITE_AES_256_GCM,
+    keyVersion,
+    data.iv,
+    data.authTag,
+    data.ciphertext,
+  ].join(".");
+}
+
+/**
+ * Parses a secret envelope returned by the backend. Secrets stored before
+ * envelopes that the server could not migrate (format 0) are returned exactly
+ * as they were sent, "<ciphertext>.<iv>.<authTag>", and are parsed as such.
+ */
+export function parseSecretEnvelope(secret: string): EncryptedData {
+  const parts = secret.split(".");
+
+  if (parts.length === 3 && parts.every((part) => part.length > 0)) {
+    const [ciphertext, iv, authTag] = parts;
+
+    return { ciphertext, iv, authTag };
+  }
+
+  if (
+    parts.length !== 6 ||
+    parts[0] !== "v1" ||
+    parts[1] !== String(CIPHER_SUITE_AES_256_GCM)
+  ) {
+    throw new Error("Invalid encrypted secret format");
+  }
+
+  const [, , , iv, authTag, ciphertext] = parts;
+
+  return { ciphertext, iv, authTag };
+}
+
 /**
  * Encrypts plaintext data using AES-GCM
  */
@@ -1,7 +1,7 @@
 import { OTP, OTPSecret } from "../types/otp";
 
 import { generateTOTPCodes as generateTOTPCodesLib, TOTPConfig } from "./totp";
-import { decryptData } from "./crypto";
+import { decryptData, parseSecretEnvelope } from "./crypto";
 import { getSessionEncryptionKey } from "./webauthn";
 
 /**
@@ -13,22 +13,11 @@ export async function generateClientTOTPCodes(otp: OTP): Promise<OTPSecret> {
     // Get session encryption key from WebAuthn
     const encryptionKey = await getSessionEncryptionKey();
 
-    // Parse the encrypted secret (format: "ciphertext.iv.authTag")
-    const [ciphertext, iv, authTag] = otp.Secret.split(".");
-
-    if (!ciphertext || !iv || !authTag) {
-      throw new Error("Invalid encrypted secret format");
-    }
+    // Parse the encrypted secret envelope
+    const encryptedData = parseSecretEnvelope(otp.Secret);
 
     // Decrypt the TOTP secret
-    const decryptedSecret = await decryptData(
-      {
-        ciphertext,
-        iv,
-        authTag,
-      },
-      encryptionKey,
-    );
+    const decryptedSecret = await decryptData(encryptedData, encryptionKey);
 
     // Create TOTP config for the otpauth library
     const totpConfig: TOTPConfig = {
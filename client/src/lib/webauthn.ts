@@ -5,6 +5,7 @@
 
 // Session-based key storage to maintain consistency
 let sessionEncryptionKey: Uint8Array | null = null;
+let sessionKeyVersion: number | null = null;
 
 export interface WebAuthnCredential {
   id: string;
@@ -589,9 +590,41 @@ export async function getSessionEncryptionKey(): Promise<Uint8Array> {
   return key;
 }
 
+/**
+ * Gets the vault's current key version, which new secret envelopes must carry.
+ * It is the highest version among the active key wraps, or 1 before any exist.
+ */
+export async function getSessionKeyVersion(): Promise<number> {
+  if (sessionKeyVersion !== null) {
+    return sessionKeyVersion;
+  }
+
+  const response = await fetch("/api/v1/vault/keys", {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Content-Type": "application/json",
+    },
+  });
+
+  if (!response.ok) {
+    throw new Error(`Failed to get vault keys: ${response.statusText}`);
+  }
+
+  const keys: { keyVersion: number; isActive: boolean }[] =
+    await response.json();
+
+  sessionKeyVersion = keys
+    .filter((key) => key.isActive)
+    .reduce((version, key) => Math.max(version, key.keyVersion), 1);
+
+  return sessionKeyVersion;
+}
+
 /**
  * Clears the session encryption key (useful for logout)
  */
 export function clearSessionEncryptionKey(): void {
   sessionEncryptionKey = null;
+  sessionKeyVersion = null;
 }
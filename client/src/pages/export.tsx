@@ -4,107 +4,48 @@ import {
   CardBody,
   CardHeader,
   Button,
-  Checkbox,
-  Divider,
   Progress,
-  Chip,
 } from "@heroui/react";
-import { MdDownload, MdStorage, MdSecurity, MdInfo } from "react-icons/md";
+import { MdDownload, MdStorage, MdSecurity } from "react-icons/md";
 import { useTranslation } from "react-i18next";
 
 import DefaultLayout from "@/layouts/default";
-import { useAuth } from "@/providers/auth-provider";
 import { useListOtps } from "@/hooks/otp";
 import { toast } from "@/lib/toast";
 import { apiClient } from "@/lib/api/client";
 import { OTP } from "@/types/otp";
 
 export default function ExportPage() {
-  const { user } = useAuth();
   const { t } = useTranslation();
   const { data: otps = [] } = useListOtps();
   
   // Type assertion for otps array
   const typedOtps = otps as OTP[];
   
-  const [exportOptions, setExportOptions] = useState({
-    includeOtps: true,
-    includeSettings: true,
-    includeMetadata: false,
-    format: "json",
-  });
-  
   const [isExporting, setIsExporting] = useState(false);
   const [exportProgress, setExportProgress] = useState(0);
 
   const handleExport = async () => {
-    if (!exportOptions.includeOtps && !exportOptions.includeSettings) {
-      toast.error(t('export.errors.selectData'));
-      return;
-    }
-
     setIsExporting(true);
     setExportProgress(0);
 
     try {
-      // Simulate progress updates
-      const progressInterval = setInterval(() => {
-        setExportProgress(prev => {
-          if (prev >= 90) {
-            clearInterval(progressInterval);
-            return prev;
+      // The server builds the versioned .2fair file; secrets stay encrypted with the vault key
+      const blob = await apiClient.get<Blob>("/api/v1/vault/export", {
+        responseType: "blob",
+        onDownloadProgress: (event) => {
+          if (event.total) {
+            setExportProgress(Math.round((event.loaded / event.total) * 100));
           }
-          return prev + 10;
-        });
-      }, 200);
-
-      // Create export data
-      const exportData: any = {
-        metadata: {
-          exported_at: new Date().toISOString(),
-          exported_by: user?.email,
-          version: "1.0",
-          app: "2FAir",
         },
-      };
-
-      if (exportOptions.includeOtps) {
-        exportData.otps = typedOtps.map((otp: OTP) => ({
-          id: otp.Id,
-          issuer: otp.Issuer,
-          label: otp.Label,
-          secret: otp.Secret,
-          period: otp.Period,
-        }));
-      }
-
-      if (exportOptions.includeSettings) {
-        // In a real app, this would fetch user settings from the API
-        exportData.settings = {
-          autoRefresh: true,
-          showNotifications: true,
-          defaultView: "grid",
-          autoBackup: false,
-        };
-      }
-
-      if (!exportOptions.includeMetadata) {
-        delete exportData.metadata;
-      }
+      });
 
-      // Complete progress
-      clearInterval(progressInterval);
       setExportProgress(100);
 
-      // Create and download file
-      const blob = new Blob([JSON.stringify(exportData, null, 2)], {
-        type: "application/json",
-      });
-      
       const url = URL.createObjectURL(blob);
       const a = document.createElement("a");
       a.href = url;
-      a.download = `2fair-backup-${new Date().toISOString().split('T')[0]}.json`;
+      a.download = `2fair-vault-${new Date().toISOString().split('T')[0]}.2fair`;
       document.body.appendChild(a);
       a.click();
       document.body.removeChild(a);
@@ -122,10 +63,7 @@ export default function ExportPage() {
 
   const handleBackupToCloud = async () => {
     try {
-      await apiClient.post("/api/v1/backup/create", {
-        include_otps: exportOptions.includeOtps,
-        include_settings: exportOptions.includeSettings,
-      });
+      await apiClient.post("/api/v1/backup/create");
       
       toast.success(t('export.success.backedUp'));
     } catch (error) {
@@ -134,20 +72,6 @@ export default function ExportPage() {
     }
   };
 
-  const formatFileSize = (bytes: number) => {
-    if (bytes === 0) return "0 Bytes";
-    const k = 1024;
-    const sizes = ["Bytes", "KB", "MB", "GB"];
-    const i = Math.floor(Math.log(bytes) / Math.log(k));
-    return parseFloat((bytes / Math.pow(k, i)).toFixed(2)) + " " + sizes[i];
-  };
-
-  const estimatedSize = JSON.stringify({
-    otps: exportOptions.includeOtps ? typedOtps : [],
-    settings: exportOptions.includeSettings ? {} : undefined,
-    metadata: exportOptions.includeMetadata ? {} : undefined,
-  }).length;
-
   return (
     <DefaultLayout>
       <section className="flex flex-col items-center justify-center px-4 sm:px-0">
@@ -159,7 +83,7 @@ export default function ExportPage() {
             </div>
             <div>
               <h1 className="text-2xl font-bold text-default-700">{t('export.title')}</h1>
-              <p className="text-small text-default-500">Download your OTP codes and settings securely</p>
+              <p className="text-small text-default-500">Download your OTP codes securely</p>
             </div>
           </div>
 
@@ -173,83 +97,19 @@ export default function ExportPage() {
                   </div>
                   <div>
                     <h2 className="text-xl font-semibold">{t('export.options.title')}</h2>
-                    <p className="text-small text-default-500">Choose what data to include in your export</p>
+                    <p className="text-small text-default-500">Every entry is exported, with its secret still encrypted</p>
                   </div>
                 </div>
               </CardHeader>
               <CardBody className="pt-0 space-y-4">
-                <div className="space-y-3">
-                  <div className="flex items-center gap-3 p-3 rounded-lg bg-default-50">
-                    <Checkbox
-                      size="sm"
-                      isSelected={exportOptions.includeOtps}
-                      onValueChange={(value) => setExportOptions({ ...exportOptions, includeOtps: value })}
-                      classNames={{
-                        wrapper: "group-data-[selected=true]:bg-success",
-                      }}
-                    />
-                    <div className="flex items-center gap-3 min-w-0 flex-1">
-                      <div className="flex items-center justify-center w-8 h-8 rounded-full bg-success/10 flex-shrink-0">
-                        <MdSecurity className="text-small text-success" />
-                      </div>
-                      <div className="min-w-0 flex-1">
-                        <p className="text-medium font-semibold">{t('export.options.otpCodes.title')}</p>
-                        <p className="text-tiny text-default-500">{t('export.options.otpCodes.description', { count: typedOtps.length })}</p>
-                      </div>
-                    </div>
-                  </div>
-                  
-                  <div className="flex items-center gap-3 p-3 rounded-lg bg-default-50">
-                    <Checkbox
-                      size="sm"
-                      isSelected={exportOptions.includeSettings}
-                      onValueChange={(value) => setExportOptions({ ...exportOptions, includeSettings: value })}
-                      classNames={{
-                        wrapper: "group-data-[selected=true]:bg-success",
-                      }}
-                    />
-                    <div className="flex items-center gap-3 min-w-0 flex-1">
-                      <div className="flex items-center justify-center w-8 h-8 rounded-full bg-warning/10 flex-shrink-0">
-                        <MdStorage className="text-small text-warning" />
-                      </div>
-                      <div className="min-w-0 flex-1">
-                        <p className="text-medium font-semibold">{t('export.options.appSettings.title')}</p>
-                        <p className="text-tiny text-default-500">Your app preferences and configuration</p>
-                      </div>
-                    </div>
+                <div className="flex items-center gap-3 p-3 rounded-lg bg-default-50">
+                  <div className="flex items-center justify-center w-8 h-8 rounded-full bg-success/10 flex-shrink-0">
+                    <MdSecurity className="text-small text-success" />
                   </div>
-                  
-                  <div className="flex items-center gap-3 p-3 rounded-lg bg-default-50">
-                    <Checkbox
-                      size="sm"
-                      isSelected={exportOptions.includeMetadata}
-                      onValueChange={(value) => setExportOptions({ ...exportOptions, includeMetadata: value })}
-                      classNames={{
-                        wrapper: "group-data-[selected=true]:bg-success",
-                      }}
-                    />
-                    <div className="flex items-center gap-3 min-w-0 flex-1">
-                      <div className="flex items-center justify-center w-8 h-8 rounded-full bg-primary/10 flex-shrink-0">
-                        <MdInfo className="text-small text-primary" />
-                      </div>
-                      <div className="min-w-0 flex-1">
-                        <p className="text-medium font-semibold">{t('export.options.metadata.title')}</p>
-                        <p className="text-tiny text-default-500">Timestamp and version information</p>
-                      </div>
-                    </div>
-                  </div>
-                </div>
-
-                <Divider className="my-3" />
-
-                <div className="flex items-center justify-between gap-3 p-3 rounded-lg bg-default-50">
                   <div className="min-w-0 flex-1">
-                    <p className="text-medium font-semibold">{t('export.options.estimatedSize.title')}</p>
-                    <p className="text-tiny text-default-500">Based on current selections</p>
+                    <p className="text-medium font-semibold">{t('export.options.otpCodes.title')}</p>
+                    <p className="text-tiny text-default-500">{t('export.options.otpCodes.description', { count: typedOtps.length })}</p>
                   </div>
-                  <Chip size="sm" variant="flat" color="primary">
-                    {formatFileSize(estimatedSize)}
-                  </Chip>
                 </div>
               </CardBody>
             </Card>
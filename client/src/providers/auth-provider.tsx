@@ -1,7 +1,7 @@
 import React, { createContext, useContext, useEffect, useState } from "react";
 import { useNavigate, useLocation } from "react-router-dom";
 
-import { setAuthManager } from "../lib/api/client";
+import { refreshSession, setAuthManager } from "../lib/api/client";
 
 interface User {
   id: string;
@@ -49,13 +49,28 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
   }, [location.pathname]);
 
-  const checkAuth = async () => {
-    try {
-      // Make API call to check auth status (cookies sent automatically)
-      const response = await fetch("/api/v1/auth/me", {
+  // fetchProfile loads the signed-in user. An expired access token is refreshed once, so a
+  // returning user stays signed in for as long as their session lasts.
+  const fetchProfile = async () => {
+    // Make API call to check auth status (cookies sent automatically)
+    const request = () =>
+      fetch("/api/v1/auth/me", {
         credentials: "include", // Include cookies
       });
 
+    const response = await request();
+
+    if (response.status === 401 && (await refreshSession())) {
+      return request();
+    }
+
+    return response;
+  };
+
+  const checkAuth = async () => {
+    try {
+      const response = await fetchProfile();
+
       if (response.ok) {
         const userData = await response.json();
 
@@ -74,9 +89,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
 
   const refreshAuth = async () => {
     try {
-      const response = await fetch("/api/v1/auth/me", {
-        credentials: "include",
-      });
+      const response = await fetchProfile();
 
       if (response.ok) {
         const userData = await response.json();
@@ -4,6 +4,7 @@ export interface OTP {
   Label: string;
   Secret: string;
   Period: number;
+  Revision?: number; // Sent back in If-Match when editing or deleting
 }
 
 export interface OTPSecret {
@@ -0,0 +1,81 @@
+// Command admin grants and revokes the admin role. It reads the same environment as the server,
+// and is how the first admin of an instance is made; later admins can be made the same way.
+//
+// Usage:
+//
+//	admin grant <email>
+//	admin revoke <email>
+//
+// Changing a role signs the user out everywhere, since their tokens carry the old role.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	appServices "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+const usage = `Usage:
+  admin grant <email>     Give the user the admin role
+  admin revoke <email>    Take the admin role away from the user
+`
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if len(os.Args) != 3 || (os.Args[1] != "grant" && os.Args[1] != "revoke") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	role := entities.UserRoleUser
+	if os.Args[1] == "grant" {
+		role = entities.UserRoleAdmin
+	}
+	if err := setRole(ctx, os.Args[2], role); err != nil {
+		slog.Error("Admin command failed", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+func setRole(ctx context.Context, email, role string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := database.NewUserRepository(db)
+	user, err := userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", email, err)
+	}
+
+	sessionService := appServices.NewSessionService(database.NewSessionRepository(db), cfg.Session.IdleTimeout, cfg.Session.AbsoluteTimeout, cfg.Session.ActivityInterval)
+	if err := appServices.NewAdminService(userRepo, sessionService).SetRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+	auditService := appServices.NewAuditService(database.NewAuditLogRepository(db))
+	if err := auditService.Record(ctx, &user.ID, entities.AuditActionAccountRoleChanged, entities.AuditResourceUser, &user.ID, map[string]string{"role": role}); err != nil {
+		return fmt.Errorf("role changed but not recorded in the audit log: %w", err)
+	}
+
+	fmt.Printf("%s (%s) is now %s\n", user.Username, user.Email, role)
+	return nil
+}
@@ -0,0 +1,75 @@
+// Command audit checks the security audit log's hash chain. It reads the same environment as the
+// server.
+//
+// Usage:
+//
+//	audit verify
+//
+// verify reads every entry from the start of the chain and prints the last one's sequence and
+// hash. Record the output somewhere outside the database: a later run whose chain no longer
+// reaches that entry, or reaches it with another hash, shows the log was cut short or rewritten.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	appServices "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+const usage = `Usage:
+  audit verify    Check the audit log's hash chain and print its last entry
+`
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if len(os.Args) != 2 || os.Args[1] != "verify" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := verify(ctx); err != nil {
+		slog.Error("Audit log verification failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func verify(ctx context.Context) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	auditService := appServices.NewAuditService(database.NewAuditLogRepository(db))
+	last, err := auditService.VerifyChain(ctx)
+	if err != nil {
+		if last != nil {
+			fmt.Printf("chain holds through entry %d\n", last.Sequence)
+		}
+		return err
+	}
+
+	if last == nil {
+		fmt.Println("audit log is empty")
+		return nil
+	}
+	fmt.Printf("%d\t%s\n", last.Sequence, hex.EncodeToString(last.Hash))
+	return nil
+}
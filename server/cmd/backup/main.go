@@ -0,0 +1,167 @@
+// Command backup takes, lists, checks, prunes and restores database backups. It reads the same
+// environment as the server, including the BACKUP_* settings, so it works on the server's backups
+// whether or not scheduled backups are enabled.
+//
+// Usage:
+//
+//	backup create
+//	backup list
+//	backup verify <name>
+//	backup prune
+//	backup restore -yes <name>
+//
+// Restore replaces every row in the database. Stop the server first, and restore with the release
+// whose migrations match the backup's schema version.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	appServices "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/backup"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+const usage = `Usage:
+  backup create              Take a backup now and apply the retention policy
+  backup list                List stored backups, newest first
+  backup verify <name>       Download a backup and check it end to end
+  backup prune               Delete backups the retention policy no longer keeps
+  backup restore -yes <name> Replace the database's content with a backup
+`
+
+func main() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1], os.Args[2:]); err != nil {
+		slog.Error("Backup command failed", "command", os.Args[1], "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	confirmed := flags.Bool("yes", false, "confirm that restore may replace the database's content")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	wantArgs := 0
+	if command == "verify" || command == "restore" {
+		wantArgs = 1
+	}
+	if flags.NArg() != wantArgs {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("%s takes %d arguments", command, wantArgs)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.ValidateBackup(); err != nil {
+		return err
+	}
+
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	archiver, store, err := backup.NewFromConfig(cfg.Backup)
+	if err != nil {
+		return err
+	}
+	backupService := appServices.NewBackupService(database.NewBackupRepository(db), archiver, store, cfg.Backup.RetentionCount, cfg.Backup.RetentionAge)
+
+	switch command {
+	case "create":
+		info, err := backupService.Create(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%d bytes\n", info.Name, info.Size)
+
+	case "list":
+		backups, err := backupService.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, info := range backups {
+			fmt.Printf("%s\t%d bytes\n", info.Name, info.Size)
+		}
+
+	case "verify":
+		manifest, err := backupService.Verify(ctx, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		printManifest(manifest)
+
+	case "prune":
+		deleted, err := backupService.Prune(ctx)
+		for _, name := range deleted {
+			fmt.Printf("deleted %s\n", name)
+		}
+		if err != nil {
+			return err
+		}
+
+	case "restore":
+		if !*confirmed {
+			return errors.New("restore replaces every row in the database; pass -yes to confirm")
+		}
+		return restore(ctx, cfg, backupService, flags.Arg(0))
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return nil
+}
+
+// restore migrates the database, so an empty database can be restored into, and then restores
+func restore(ctx context.Context, cfg *config.Config, backupService interfaces.BackupService, name string) error {
+	if err := database.RunMigrations(cfg); err != nil {
+		return err
+	}
+
+	manifest, err := backupService.Restore(ctx, name)
+	if errors.Is(err, entities.ErrDatabaseBackupSchema) {
+		return fmt.Errorf("%w; restore with the release that took the backup", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	printManifest(manifest)
+	slog.Info("Backup restored", "name", name)
+	return nil
+}
+
+func printManifest(manifest *entities.BackupManifest) {
+	fmt.Printf("taken at %s, schema version %d\n", manifest.Header.CreatedAt.Format("2006-01-02 15:04:05 MST"), manifest.Header.SchemaVersion)
+	for _, table := range manifest.Tables {
+		fmt.Printf("%s\t%d rows\n", table.Name, table.Rows)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build js && wasm
+
+// Command otpmigration-wasm exposes the Google Authenticator migration decoder to the browser.
+//
+// It registers a global decodeOTPMigration(urls: string[]) function that returns
+// {otps: TOTPConfig[]} on success or {error: string, missing?: number[]} on failure.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/otpmigration"
+)
+
+type decodeResult struct {
+	OTPs    any    `json:"otps,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Missing []int  `json:"missing,omitempty"` // Zero-based batch indices still to be scanned
+}
+
+func main() {
+	js.Global().Set("decodeOTPMigration", js.FuncOf(decodeOTPMigration))
+
+	// Keep the Go runtime alive so the exported function stays callable
+	select {}
+}
+
+func decodeOTPMigration(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeObject {
+		return toJS(decodeResult{Error: "expected an array of otpauth-migration URLs"})
+	}
+
+	batch := otpmigration.NewBatch()
+	for i := 0; i < args[0].Length(); i++ {
+		payload, err := otpmigration.ParseURL(args[0].Index(i).String())
+		if err != nil {
+			return toJS(decodeResult{Error: err.Error()})
+		}
+		if err := batch.Add(payload); err != nil {
+			return toJS(decodeResult{Error: err.Error()})
+		}
+	}
+
+	otps, err := batch.OTPs()
+	if err != nil {
+		result := decodeResult{Error: err.Error()}
+		if errors.Is(err, otpmigration.ErrIncompleteBatch) {
+			result.Missing = batch.Missing()
+		}
+		return toJS(result)
+	}
+
+	return toJS(decodeResult{OTPs: otps})
+}
+
+// toJS converts a result to a plain JavaScript object via JSON
+func toJS(result decodeResult) any {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return js.ValueOf(map[string]any{"error": err.Error()})
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
@@ -0,0 +1,103 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// adminService implements the domain admin service interface
+type adminService struct {
+	userRepo       interfaces.UserRepository
+	sessionService interfaces.SessionService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(userRepo interfaces.UserRepository, sessionService interfaces.SessionService) interfaces.AdminService {
+	return &adminService{
+		userRepo:       userRepo,
+		sessionService: sessionService,
+	}
+}
+
+// ListUsers returns a page of users, newest first
+func (s *adminService) ListUsers(ctx context.Context, filter entities.UserFilter) (*entities.UserPage, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.List(ctx, filter)
+}
+
+// GetUser returns one user
+func (s *adminService) GetUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// SuspendUser deactivates the account and revokes its tokens and sessions
+func (s *adminService) SuspendUser(ctx context.Context, adminID, userID uuid.UUID) error {
+	// An admin who suspended themselves could not undo it
+	if adminID == userID {
+		return entities.ErrCannotSuspendSelf
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Deactivate(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionService.DeleteUserSessions(ctx, userID)
+}
+
+// ReactivateUser lets a suspended user sign in again
+func (s *adminService) ReactivateUser(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.userRepo.Reactivate(ctx, userID)
+}
+
+// ForceLogout revokes every token and session the user holds
+func (s *adminService) ForceLogout(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.RevokeTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionService.DeleteUserSessions(ctx, userID)
+}
+
+// SetRole grants or revokes the admin role
+func (s *adminService) SetRole(ctx context.Context, userID uuid.UUID, role string) error {
+	if !entities.IsValidUserRole(role) {
+		return fmt.Errorf("%w: %q", entities.ErrInvalidUserRole, role)
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+
+	// Ending the sessions stops refresh tokens from renewing access under the old role
+	if err := s.userRepo.SetRole(ctx, userID, role); err != nil {
+		return err
+	}
+	return s.sessionService.DeleteUserSessions(ctx, userID)
+}
+
+// GetStats returns aggregate counts over every account
+func (s *adminService) GetStats(ctx context.Context) (*entities.UserStats, error) {
+	stats, err := s.userRepo.GetStats(ctx, time.Now().Add(-entities.UserStatsWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	stats.WindowDays = int(entities.UserStatsWindow / (24 * time.Hour))
+	return stats, nil
+}
@@ -0,0 +1,82 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+func TestSuspendUser(t *testing.T) {
+	admin := &entities.User{ID: uuid.New(), IsActive: true, Role: entities.UserRoleAdmin}
+	user := &entities.User{ID: uuid.New(), IsActive: true, Role: entities.UserRoleUser, TokenVersion: 1}
+	userRepo := newFakeUserRepository(admin, user)
+	sessions := &fakeSessionService{}
+	service := NewAdminService(userRepo, sessions)
+
+	err := service.SuspendUser(context.Background(), admin.ID, admin.ID)
+	assert.ErrorIs(t, err, entities.ErrCannotSuspendSelf)
+	assert.True(t, admin.IsActive)
+	assert.Empty(t, sessions.endedFor)
+
+	err = service.SuspendUser(context.Background(), admin.ID, uuid.New())
+	assert.ErrorIs(t, err, entities.ErrUserNotFound)
+
+	require.NoError(t, service.SuspendUser(context.Background(), admin.ID, user.ID))
+	assert.False(t, user.IsActive)
+	assert.Equal(t, 2, user.TokenVersion, "suspending revokes the user's tokens")
+	assert.Equal(t, []uuid.UUID{user.ID}, sessions.endedFor)
+
+	require.NoError(t, service.ReactivateUser(context.Background(), user.ID))
+	assert.True(t, user.IsActive)
+}
+
+func TestSetRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  string
+		error error
+	}{
+		{name: "grant admin", role: entities.UserRoleAdmin},
+		{name: "revoke admin", role: entities.UserRoleUser},
+		{name: "unknown role", role: "owner", error: entities.ErrInvalidUserRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &entities.User{ID: uuid.New(), IsActive: true, Role: entities.UserRoleUser, TokenVersion: 4}
+			sessions := &fakeSessionService{}
+			service := NewAdminService(newFakeUserRepository(user), sessions)
+
+			err := service.SetRole(context.Background(), user.ID, tt.role)
+			if tt.error != nil {
+				assert.ErrorIs(t, err, tt.error)
+				assert.Equal(t, entities.UserRoleUser, user.Role)
+				assert.Equal(t, 4, user.TokenVersion)
+				assert.Empty(t, sessions.endedFor)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.role, user.Role)
+			// Tokens carry the role, so the old ones are revoked and their sessions ended
+			assert.Equal(t, 5, user.TokenVersion)
+			assert.Equal(t, []uuid.UUID{user.ID}, sessions.endedFor)
+		})
+	}
+}
+
+func TestForceLogout(t *testing.T) {
+	user := &entities.User{ID: uuid.New(), IsActive: true, Role: entities.UserRoleUser}
+	sessions := &fakeSessionService{}
+	service := NewAdminService(newFakeUserRepository(user), sessions)
+
+	require.NoError(t, service.ForceLogout(context.Background(), user.ID))
+	assert.Equal(t, 1, user.TokenVersion)
+	assert.True(t, user.IsActive)
+	assert.Equal(t, []uuid.UUID{user.ID}, sessions.endedFor)
+}
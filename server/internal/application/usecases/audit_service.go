@@ -0,0 +1,62 @@
+package application
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// auditChainPageSize is how many entries chain verification reads at a time
+const auditChainPageSize = 1000
+
+// auditService implements the domain audit service interface
+type auditService struct {
+	auditRepo interfaces.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(auditRepo interfaces.AuditLogRepository) interfaces.AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record logs an action attributed to the client in ctx
+func (s *auditService) Record(ctx context.Context, userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, metadata map[string]string) error {
+	entry := entities.NewAuditLog(userID, action, resourceType, resourceID, metadata, entities.RequestInfoFromContext(ctx))
+	return s.auditRepo.Append(ctx, entry)
+}
+
+// ListActivity returns a page of the user's entries, newest first
+func (s *auditService) ListActivity(ctx context.Context, userID uuid.UUID, filter entities.AuditLogFilter) (*entities.AuditLogPage, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+
+	return s.auditRepo.ListByUser(ctx, userID, filter)
+}
+
+// VerifyChain reads the chain from its start and checks every entry
+func (s *auditService) VerifyChain(ctx context.Context) (*entities.AuditLog, error) {
+	var last *entities.AuditLog
+	for {
+		var afterSequence int64
+		if last != nil {
+			afterSequence = last.Sequence
+		}
+
+		entries, err := s.auditRepo.ListChain(ctx, afterSequence, auditChainPageSize)
+		if err != nil {
+			return last, err
+		}
+		last, err = entities.CheckAuditChain(last, entries)
+		if err != nil {
+			return last, err
+		}
+		if len(entries) < auditChainPageSize {
+			return last, nil
+		}
+	}
+}
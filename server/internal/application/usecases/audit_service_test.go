@@ -0,0 +1,140 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// fakeAuditLogRepository chains appended entries like the repository does under its chain lock
+type fakeAuditLogRepository struct {
+	interfaces.AuditLogRepository
+	mu      sync.Mutex
+	entries []*entities.AuditLog
+}
+
+func (r *fakeAuditLogRepository) Append(_ context.Context, entry *entities.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sequence int64
+	var prevHash []byte
+	if n := len(r.entries); n > 0 {
+		sequence, prevHash = r.entries[n-1].Sequence, r.entries[n-1].Hash
+	}
+	entry.Chain(sequence+1, prevHash)
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeAuditLogRepository) ListChain(_ context.Context, afterSequence int64, limit int) ([]*entities.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var page []*entities.AuditLog
+	for _, entry := range r.entries {
+		if entry.Sequence > afterSequence && len(page) < limit {
+			page = append(page, entry)
+		}
+	}
+	return page, nil
+}
+
+func TestRecord_AttributesRequest(t *testing.T) {
+	auditRepo := &fakeAuditLogRepository{}
+	service := NewAuditService(auditRepo)
+	userID := uuid.New()
+
+	ctx := entities.ContextWithRequestInfo(context.Background(), entities.RequestInfo{IPAddress: "203.0.113.7", UserAgent: "Firefox", RequestID: "req-1"})
+	require.NoError(t, service.Record(ctx, &userID, entities.AuditActionAuthFailed, entities.AuditResourceUser, &userID, map[string]string{"reason": "revoked"}))
+
+	require.Len(t, auditRepo.entries, 1)
+	entry := auditRepo.entries[0]
+	assert.Equal(t, &userID, entry.UserID)
+	assert.Equal(t, "203.0.113.7", entry.IPAddress)
+	assert.Equal(t, "Firefox", entry.UserAgent)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, int64(1), entry.Sequence)
+	assert.Empty(t, entry.PrevHash, "the first entry starts the chain")
+}
+
+func TestVerifyChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries int
+		tamper  func(entries []*entities.AuditLog)
+		last    int64 // Sequence of the last entry that holds
+		broken  bool
+	}{
+		{name: "empty", entries: 0},
+		{name: "intact", entries: 5, last: 5},
+		{name: "intact across pages", entries: auditChainPageSize + 1, last: auditChainPageSize + 1},
+		{name: "edited entry", entries: 5, last: 2, broken: true, tamper: func(entries []*entities.AuditLog) {
+			entries[2].Action = entities.AuditActionAccountLoggedOut
+		}},
+		{name: "removed entry", entries: 5, last: 1, broken: true, tamper: func(entries []*entities.AuditLog) {
+			entries[1] = entries[2]
+		}},
+		{name: "rehashed entry", entries: 5, last: 4, broken: true, tamper: func(entries []*entities.AuditLog) {
+			entries[3].Metadata = map[string]string{"reason": "edited"}
+			entries[3].Hash = entries[3].ComputeHash() // The next entry no longer follows it
+		}},
+		{name: "edited entry on a later page", entries: auditChainPageSize + 2, last: auditChainPageSize, broken: true, tamper: func(entries []*entities.AuditLog) {
+			entries[auditChainPageSize].UserID = nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditRepo := &fakeAuditLogRepository{}
+			service := NewAuditService(auditRepo)
+			for i := 0; i < tt.entries; i++ {
+				userID := uuid.New()
+				require.NoError(t, service.Record(context.Background(), &userID, entities.AuditActionLogin, entities.AuditResourceUser, &userID, nil))
+			}
+			if tt.tamper != nil {
+				tt.tamper(auditRepo.entries)
+			}
+
+			last, err := service.VerifyChain(context.Background())
+			if tt.broken {
+				assert.ErrorIs(t, err, entities.ErrAuditChainBroken)
+			} else {
+				require.NoError(t, err)
+			}
+			if tt.last == 0 {
+				assert.Nil(t, last)
+				return
+			}
+			require.NotNil(t, last)
+			assert.Equal(t, tt.last, last.Sequence)
+		})
+	}
+}
+
+func TestRecord_ConcurrentEntriesChain(t *testing.T) {
+	auditRepo := &fakeAuditLogRepository{}
+	service := NewAuditService(auditRepo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, service.Record(context.Background(), nil, entities.AuditActionAuthFailed, entities.AuditResourceUser, nil, map[string]string{"attempt": fmt.Sprint(i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	last, err := service.VerifyChain(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), last.Sequence)
+}
@@ -2,34 +2,47 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 type authService struct {
-	userRepo  interfaces.UserRepository
-	jwtSecret []byte
-	jwtExpiry time.Duration
-	serverURL string
+	userRepo         interfaces.UserRepository
+	sessionService   interfaces.SessionService
+	refreshTokenRepo interfaces.RefreshTokenRepository
+	refreshTokens    interfaces.RefreshTokenGenerator
+	tokenSigner      interfaces.TokenSigner
+	eventService     interfaces.VaultEventService
+	jwtExpiry        time.Duration
+	serverURL        string
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	userRepo interfaces.UserRepository,
-	jwtSecret string,
+	sessionService interfaces.SessionService,
+	refreshTokenRepo interfaces.RefreshTokenRepository,
+	refreshTokens interfaces.RefreshTokenGenerator,
+	tokenSigner interfaces.TokenSigner,
+	eventService interfaces.VaultEventService,
 	jwtExpiry time.Duration,
 	serverURL string,
 ) interfaces.AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
-		jwtExpiry: jwtExpiry,
-		serverURL: serverURL,
+		userRepo:         userRepo,
+		sessionService:   sessionService,
+		refreshTokenRepo: refreshTokenRepo,
+		refreshTokens:    refreshTokens,
+		tokenSigner:      tokenSigner,
+		eventService:     eventService,
+		jwtExpiry:        jwtExpiry,
+		serverURL:        serverURL,
 	}
 }
 
@@ -64,10 +77,15 @@ func (a *authService) RegisterOrLoginUser(ctx context.Context, oauthData *interf
 	}
 
 	if existingUser != nil {
+		// Suspended accounts cannot sign in
+		if !existingUser.IsActive {
+			return nil, entities.ErrUserSuspended
+		}
+
 		// Update last login using the entity method
 		existingUser.UpdateLastLogin()
 
-		if err := a.userRepo.Update(ctx, existingUser); err != nil {
+		if err := a.userRepo.UpdateLastLogin(ctx, existingUser.ID); err != nil {
 			return nil, fmt.Errorf("failed to update user login time: %w", err)
 		}
 
@@ -82,6 +100,7 @@ func (a *authService) RegisterOrLoginUser(ctx context.Context, oauthData *interf
 		Email:       oauthData.Email,
 		DisplayName: oauthData.DisplayName,
 		IsActive:    true,
+		Role:        entities.UserRoleUser,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 		LastLoginAt: &now,
@@ -96,32 +115,40 @@ func (a *authService) RegisterOrLoginUser(ctx context.Context, oauthData *interf
 	if err := a.userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	if err := a.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+		return nil, fmt.Errorf("failed to update user login time: %w", err)
+	}
 
 	return user, nil
 }
 
-// GenerateJWT creates a JWT token for the user
-func (a *authService) GenerateJWT(user *entities.User) (string, error) {
+// GenerateJWT creates a JWT token for the user's sign-in session
+func (a *authService) GenerateJWT(user *entities.User, sessionID uuid.UUID) (string, error) {
 	now := time.Now()
 	expiresAt := now.Add(a.jwtExpiry)
 
 	claims := &interfaces.JWTClaims{
-		UserID:    user.ID.String(),
-		Username:  user.Username,
-		Email:     user.Email,
-		IssuedAt:  now,
-		ExpiresAt: expiresAt,
+		UserID:       user.ID.String(),
+		Username:     user.Username,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		SessionID:    sessionID.String(),
+		IssuedAt:     now,
+		ExpiresAt:    expiresAt,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	tokenString, err := a.tokenSigner.SignToken(map[string]any{
+		"sub":      claims.UserID,
 		"user_id":  claims.UserID,
 		"username": claims.Username,
 		"email":    claims.Email,
+		"role":     claims.Role,
+		"tv":       claims.TokenVersion,
+		"sid":      claims.SessionID,
 		"iat":      claims.IssuedAt.Unix(),
 		"exp":      claims.ExpiresAt.Unix(),
 	})
-
-	tokenString, err := token.SignedString(a.jwtSecret)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign JWT token: %w", err)
 	}
@@ -131,24 +158,11 @@ func (a *authService) GenerateJWT(user *entities.User) (string, error) {
 
 // ValidateJWT validates and parses a JWT token
 func (a *authService) ValidateJWT(tokenString string) (*interfaces.JWTClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return a.jwtSecret, nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JWT token: %w", err)
-	}
-
-	if !token.Valid {
-		return nil, fmt.Errorf("invalid JWT token")
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, fmt.Errorf("invalid JWT claims format")
+	// HS256 tokens signed before the keyring existed fail here; clients refresh them. An expired
+	// token's signature was still checked, so its claims are parsed to name its user.
+	claims, verifyErr := a.tokenSigner.VerifyToken(tokenString)
+	if verifyErr != nil && (claims == nil || !errors.Is(verifyErr, entities.ErrTokenExpired)) {
+		return nil, fmt.Errorf("failed to parse JWT token: %w", verifyErr)
 	}
 
 	// Extract claims
@@ -177,51 +191,166 @@ func (a *authService) ValidateJWT(tokenString string) (*interfaces.JWTClaims, er
 		return nil, fmt.Errorf("invalid exp in JWT claims")
 	}
 
-	return &interfaces.JWTClaims{
-		UserID:    userID,
-		Username:  username,
-		Email:     email,
-		IssuedAt:  time.Unix(int64(iat), 0),
-		ExpiresAt: time.Unix(int64(exp), 0),
-	}, nil
+	// Tokens issued before sessions existed cannot be revoked, so they are no longer accepted
+	sessionID, ok := claims["sid"].(string)
+	if !ok || uuid.Validate(sessionID) != nil {
+		return nil, fmt.Errorf("invalid sid in JWT claims")
+	}
+
+	// Every token with a sid also carries the role and token version
+	role, ok := claims["role"].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid role in JWT claims")
+	}
+	tokenVersion, ok := claims["tv"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid tv in JWT claims")
+	}
+
+	result := &interfaces.JWTClaims{
+		UserID:       userID,
+		Username:     username,
+		Email:        email,
+		Role:         role,
+		TokenVersion: int(tokenVersion),
+		SessionID:    sessionID,
+		IssuedAt:     time.Unix(int64(iat), 0),
+		ExpiresAt:    time.Unix(int64(exp), 0),
+	}
+	if verifyErr != nil {
+		return result, fmt.Errorf("failed to parse JWT token: %w", verifyErr)
+	}
+
+	return result, nil
+}
+
+// PublicKeys returns the keys access tokens may be verified with
+func (a *authService) PublicKeys() *interfaces.JSONWebKeySet {
+	return a.tokenSigner.PublicKeys()
 }
 
-// RefreshJWT refreshes a JWT token
-func (a *authService) RefreshJWT(tokenString string) (string, error) {
-	claims, err := a.ValidateJWT(tokenString)
+// VerifyAccess checks a validated token against the current account and its session
+func (a *authService) VerifyAccess(ctx context.Context, claims *interfaces.JWTClaims) error {
+	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return "", fmt.Errorf("failed to validate existing token: %w", err)
+		return fmt.Errorf("invalid user_id in JWT claims: %w", err)
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return fmt.Errorf("invalid sid in JWT claims: %w", err)
 	}
 
-	// Check if token is expired
-	if time.Now().After(claims.ExpiresAt) {
-		return "", fmt.Errorf("token is expired")
+	user, err := a.activeUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if claims.TokenVersion != user.TokenVersion {
+		return entities.ErrTokenRevoked
 	}
 
-	// Generate new token with same claims but updated timestamps
-	now := time.Now()
-	expiresAt := now.Add(a.jwtExpiry)
+	_, err = a.sessionService.TouchSession(ctx, user.ID, sessionID)
+	return err
+}
 
-	newClaims := &interfaces.JWTClaims{
-		UserID:    claims.UserID,
-		Username:  claims.Username,
-		Email:     claims.Email,
-		IssuedAt:  now,
-		ExpiresAt: expiresAt,
+// IssueTokens issues an access token and the first refresh token of a new session
+func (a *authService) IssueTokens(ctx context.Context, user *entities.User, sessionID uuid.UUID) (*interfaces.TokenPair, error) {
+	token, hash, err := a.refreshTokens.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  newClaims.UserID,
-		"username": newClaims.Username,
-		"email":    newClaims.Email,
-		"iat":      newClaims.IssuedAt.Unix(),
-		"exp":      newClaims.ExpiresAt.Unix(),
-	})
+	refresh := entities.NewRefreshToken(user.ID, sessionID, hash, a.refreshTokens.RefreshExpiration())
+	if err := a.refreshTokenRepo.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	return a.tokenPair(user, sessionID, token, refresh)
+}
+
+// RefreshTokens exchanges a refresh token for a new pair. The access token is issued from the
+// current account, so it carries the user's current role, for the same session.
+func (a *authService) RefreshTokens(ctx context.Context, refreshToken string) (*interfaces.TokenPair, error) {
+	current, err := a.refreshTokenRepo.GetByHash(ctx, a.refreshTokens.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	// A rotated token is only presented again if it was copied, so whoever holds the family
+	// cannot be trusted; used tokens are refused even once expired
+	if current.IsUsed() {
+		return nil, a.revokeFamily(ctx, current)
+	}
+	if current.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("%w: expired", entities.ErrInvalidRefreshToken)
+	}
 
-	newTokenString, err := token.SignedString(a.jwtSecret)
+	user, err := a.activeUser(ctx, current.UserID)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign new JWT token: %w", err)
+		return nil, err
+	}
+	if _, err := a.sessionService.TouchSession(ctx, user.ID, current.SessionID); err != nil {
+		return nil, err
 	}
 
-	return newTokenString, nil
+	token, hash, err := a.refreshTokens.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	next := entities.NewRefreshToken(user.ID, current.SessionID, hash, a.refreshTokens.RefreshExpiration())
+	if err := a.refreshTokenRepo.Rotate(ctx, current, next); err != nil {
+		// Another request rotated the token first
+		if errors.Is(err, entities.ErrRefreshTokenReused) {
+			return nil, a.revokeFamily(ctx, current)
+		}
+		return nil, err
+	}
+
+	return a.tokenPair(user, current.SessionID, token, next)
+}
+
+// LookupRefreshToken returns a refresh token, used or expired
+func (a *authService) LookupRefreshToken(ctx context.Context, refreshToken string) (*entities.RefreshToken, error) {
+	return a.refreshTokenRepo.GetByHash(ctx, a.refreshTokens.HashRefreshToken(refreshToken))
+}
+
+// revokeFamily ends the session of a reused refresh token, which deletes its family and records
+// the reuse, and tells the user's devices. It returns the error to report for the reuse.
+func (a *authService) revokeFamily(ctx context.Context, reused *entities.RefreshToken) error {
+	if err := a.refreshTokenRepo.RevokeFamily(ctx, reused); err != nil {
+		return err
+	}
+	if err := a.eventService.Publish(ctx, reused.UserID, entities.VaultEventSessionRevoked, &reused.SessionID); err != nil {
+		slog.Warn("Failed to publish session revoked event", "user_id", reused.UserID, "session_id", reused.SessionID, "error", err)
+	}
+
+	return fmt.Errorf("%w: session %s revoked", entities.ErrRefreshTokenReused, reused.SessionID)
+}
+
+// tokenPair signs an access token for the session and pairs it with a stored refresh token
+func (a *authService) tokenPair(user *entities.User, sessionID uuid.UUID, refreshToken string, refresh *entities.RefreshToken) (*interfaces.TokenPair, error) {
+	accessToken, err := a.GenerateJWT(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &interfaces.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresAt:        time.Now().Add(a.jwtExpiry),
+		RefreshExpiresAt: refresh.ExpiresAt,
+		TokenType:        "Bearer",
+	}, nil
+}
+
+// activeUser loads an account that may still use its tokens
+func (a *authService) activeUser(ctx context.Context, userID uuid.UUID) (*entities.User, error) {
+	user, err := a.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, entities.ErrUserSuspended
+	}
+
+	return user, nil
 }
@@ -0,0 +1,295 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/jwt"
+)
+
+// fakeRefreshTokenRepository stores refresh tokens by hash
+type fakeRefreshTokenRepository struct {
+	interfaces.RefreshTokenRepository
+	tokens  map[string]*entities.RefreshToken
+	revoked []uuid.UUID // Sessions whose family was revoked
+	// loseRace makes Rotate behave as if another request rotated the token first
+	loseRace bool
+}
+
+func (r *fakeRefreshTokenRepository) Create(_ context.Context, token *entities.RefreshToken) error {
+	r.tokens[string(token.TokenHash)] = token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) GetByHash(_ context.Context, hash []byte) (*entities.RefreshToken, error) {
+	token, ok := r.tokens[string(hash)]
+	if !ok {
+		return nil, entities.ErrInvalidRefreshToken
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakeRefreshTokenRepository) Rotate(_ context.Context, current, next *entities.RefreshToken) error {
+	stored := r.tokens[string(current.TokenHash)]
+	if r.loseRace || stored.IsUsed() {
+		return entities.ErrRefreshTokenReused
+	}
+	now := time.Now()
+	stored.UsedAt = &now
+	r.tokens[string(next.TokenHash)] = next
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(_ context.Context, reused *entities.RefreshToken) error {
+	for hash, token := range r.tokens {
+		if token.SessionID == reused.SessionID {
+			delete(r.tokens, hash)
+		}
+	}
+	r.revoked = append(r.revoked, reused.SessionID)
+	return nil
+}
+
+// fakeSessionService accepts every session unless touchErr is set and records the users whose
+// sessions were all ended
+type fakeSessionService struct {
+	interfaces.SessionService
+	touchErr error
+	endedFor []uuid.UUID
+}
+
+func (s *fakeSessionService) DeleteUserSessions(_ context.Context, userID uuid.UUID) error {
+	s.endedFor = append(s.endedFor, userID)
+	return nil
+}
+
+func (s *fakeSessionService) TouchSession(_ context.Context, userID, sessionID uuid.UUID) (*entities.DeviceSession, error) {
+	if s.touchErr != nil {
+		return nil, s.touchErr
+	}
+	return &entities.DeviceSession{ID: sessionID, UserID: userID}, nil
+}
+
+// refreshFixture is an auth service holding one refresh token of an active user's session
+type refreshFixture struct {
+	service  interfaces.AuthService
+	tokens   *fakeRefreshTokenRepository
+	sessions *fakeSessionService
+	events   *fakeEventService
+	user     *entities.User
+	current  *entities.RefreshToken
+	token    string
+}
+
+func newRefreshFixture(t *testing.T) *refreshFixture {
+	t.Helper()
+
+	keyring, err := jwt.NewEphemeralKeyring()
+	require.NoError(t, err)
+	tokenService := jwt.NewTokenService(keyring, "2fair.test", "2fair-api", time.Hour)
+
+	user := &entities.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", IsActive: true, Role: entities.UserRoleUser, TokenVersion: 3}
+	token, hash, err := tokenService.GenerateRefreshToken()
+	require.NoError(t, err)
+	current := entities.NewRefreshToken(user.ID, uuid.New(), hash, time.Hour)
+
+	f := &refreshFixture{
+		tokens:   &fakeRefreshTokenRepository{tokens: map[string]*entities.RefreshToken{string(hash): current}},
+		sessions: &fakeSessionService{},
+		events:   &fakeEventService{},
+		user:     user,
+		current:  current,
+		token:    token,
+	}
+	f.service = NewAuthService(newFakeUserRepository(user), f.sessions, f.tokens, tokenService, tokenService, f.events, 15*time.Minute, "http://localhost")
+	return f
+}
+
+// assertRevoked checks that the session's family was revoked and its devices told
+func (f *refreshFixture) assertRevoked(t *testing.T) {
+	t.Helper()
+
+	assert.Equal(t, []uuid.UUID{f.current.SessionID}, f.tokens.revoked)
+	assert.Empty(t, f.tokens.tokens, "the whole family is deleted")
+	require.Len(t, f.events.published, 1)
+	assert.Equal(t, entities.VaultEventSessionRevoked, f.events.published[0].eventType)
+	assert.Equal(t, f.user.ID, f.events.published[0].userID)
+	assert.Equal(t, &f.current.SessionID, f.events.published[0].entityID)
+}
+
+func TestRefreshTokens_Rotates(t *testing.T) {
+	f := newRefreshFixture(t)
+
+	pair, err := f.service.RefreshTokens(context.Background(), f.token)
+	require.NoError(t, err)
+	assert.NotEqual(t, f.token, pair.RefreshToken)
+	assert.Equal(t, "Bearer", pair.TokenType)
+	assert.True(t, f.current.IsUsed(), "the presented token is marked used")
+
+	claims, err := f.service.ValidateJWT(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID.String(), claims.UserID)
+	assert.Equal(t, f.current.SessionID.String(), claims.SessionID)
+	assert.Equal(t, f.user.TokenVersion, claims.TokenVersion)
+
+	next, err := f.service.LookupRefreshToken(context.Background(), pair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.current.SessionID, next.SessionID, "the new token joins the same family")
+	assert.False(t, next.IsUsed())
+	assert.Empty(t, f.tokens.revoked)
+	assert.Empty(t, f.events.published)
+}
+
+func TestRefreshTokens_Reuse(t *testing.T) {
+	f := newRefreshFixture(t)
+
+	_, err := f.service.RefreshTokens(context.Background(), f.token)
+	require.NoError(t, err)
+
+	_, err = f.service.RefreshTokens(context.Background(), f.token)
+	assert.ErrorIs(t, err, entities.ErrRefreshTokenReused)
+	f.assertRevoked(t)
+}
+
+func TestRefreshTokens_LostRace(t *testing.T) {
+	f := newRefreshFixture(t)
+	f.tokens.loseRace = true
+
+	_, err := f.service.RefreshTokens(context.Background(), f.token)
+	assert.ErrorIs(t, err, entities.ErrRefreshTokenReused)
+	f.assertRevoked(t)
+}
+
+func TestRefreshTokens_Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *refreshFixture) string // Returns the token to present
+		error error
+	}{
+		{
+			name: "unknown",
+			setup: func(f *refreshFixture) string {
+				return "not-a-refresh-token"
+			},
+			error: entities.ErrInvalidRefreshToken,
+		},
+		{
+			name: "expired",
+			setup: func(f *refreshFixture) string {
+				f.current.ExpiresAt = time.Now().Add(-time.Minute)
+				return f.token
+			},
+			error: entities.ErrInvalidRefreshToken,
+		},
+		{
+			name: "suspended user",
+			setup: func(f *refreshFixture) string {
+				f.user.IsActive = false
+				return f.token
+			},
+			error: entities.ErrUserSuspended,
+		},
+		{
+			name: "ended session",
+			setup: func(f *refreshFixture) string {
+				f.sessions.touchErr = entities.ErrSessionExpired
+				return f.token
+			},
+			error: entities.ErrSessionExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRefreshFixture(t)
+			token := tt.setup(f)
+
+			pair, err := f.service.RefreshTokens(context.Background(), token)
+			assert.ErrorIs(t, err, tt.error)
+			assert.Nil(t, pair)
+			assert.False(t, f.current.IsUsed(), "a rejected token is not rotated")
+			assert.Empty(t, f.tokens.revoked)
+		})
+	}
+}
+
+func TestValidateJWT_Expired(t *testing.T) {
+	f := newRefreshFixture(t)
+	keyring, err := jwt.NewEphemeralKeyring()
+	require.NoError(t, err)
+	tokenService := jwt.NewTokenService(keyring, "2fair.test", "2fair-api", time.Hour)
+	expired := NewAuthService(newFakeUserRepository(f.user), f.sessions, f.tokens, tokenService, tokenService, f.events, -time.Hour, "http://localhost")
+
+	token, err := expired.GenerateJWT(f.user, f.current.SessionID)
+	require.NoError(t, err)
+
+	claims, err := expired.ValidateJWT(token)
+	assert.ErrorIs(t, err, entities.ErrTokenExpired)
+	require.NotNil(t, claims, "an expired token still names its user")
+	assert.Equal(t, f.user.ID.String(), claims.UserID)
+
+	// A token from another keyring is not expired, just invalid, and names no one
+	claims, err = f.service.ValidateJWT(token)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, entities.ErrTokenExpired)
+	assert.Nil(t, claims)
+}
+
+func TestVerifyAccess(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(f *refreshFixture, claims *interfaces.JWTClaims)
+		error error // nil when access is granted
+	}{
+		{
+			name:  "current token",
+			setup: func(*refreshFixture, *interfaces.JWTClaims) {},
+		},
+		{
+			name:  "suspended user",
+			setup: func(f *refreshFixture, _ *interfaces.JWTClaims) { f.user.IsActive = false },
+			error: entities.ErrUserSuspended,
+		},
+		{
+			name:  "revoked token version",
+			setup: func(f *refreshFixture, _ *interfaces.JWTClaims) { f.user.TokenVersion++ },
+			error: entities.ErrTokenRevoked,
+		},
+		{
+			name:  "unknown user",
+			setup: func(_ *refreshFixture, claims *interfaces.JWTClaims) { claims.UserID = uuid.NewString() },
+			error: entities.ErrUserNotFound,
+		},
+		{
+			name:  "ended session",
+			setup: func(f *refreshFixture, _ *interfaces.JWTClaims) { f.sessions.touchErr = entities.ErrSessionNotFound },
+			error: entities.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newRefreshFixture(t)
+			token, err := f.service.GenerateJWT(f.user, f.current.SessionID)
+			require.NoError(t, err)
+			claims, err := f.service.ValidateJWT(token)
+			require.NoError(t, err)
+			tt.setup(f, claims)
+
+			err = f.service.VerifyAccess(context.Background(), claims)
+			if tt.error == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.error)
+		})
+	}
+}
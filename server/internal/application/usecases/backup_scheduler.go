@@ -0,0 +1,81 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// backupRetryDelay is how long the scheduler waits after a failed backup before trying again
+const backupRetryDelay = 15 * time.Minute
+
+// BackupScheduler takes a database backup every interval. The schedule follows the newest stored
+// backup, so restarting the server neither skips nor repeats a backup.
+type BackupScheduler struct {
+	backupService interfaces.BackupService
+	interval      time.Duration
+}
+
+// NewBackupScheduler creates a scheduler that takes a backup every interval
+func NewBackupScheduler(backupService interfaces.BackupService, interval time.Duration) *BackupScheduler {
+	return &BackupScheduler{
+		backupService: backupService,
+		interval:      interval,
+	}
+}
+
+// Run takes backups when they are due until ctx is cancelled
+func (s *BackupScheduler) Run(ctx context.Context) {
+	var failedAt time.Time
+	for {
+		wait := s.untilDue(ctx)
+		if !failedAt.IsZero() {
+			wait = max(wait, time.Until(failedAt.Add(backupRetryDelay)))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		failedAt = time.Time{}
+		if !s.backup(ctx) {
+			failedAt = time.Now()
+		}
+	}
+}
+
+// untilDue returns how long until the next backup is due
+func (s *BackupScheduler) untilDue(ctx context.Context) time.Duration {
+	backups, err := s.backupService.List(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Failed to list backups", "error", err)
+		}
+		return s.interval
+	}
+	if len(backups) == 0 {
+		return 0
+	}
+
+	return max(0, time.Until(backups[0].CreatedAt.Add(s.interval)))
+}
+
+func (s *BackupScheduler) backup(ctx context.Context) bool {
+	started := time.Now()
+	info, err := s.backupService.Create(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Scheduled backup failed", "error", err)
+		}
+		return false
+	}
+
+	slog.Info("Scheduled backup completed", "name", info.Name, "size", info.Size, "duration", time.Since(started))
+	return true
+}
@@ -0,0 +1,213 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// backupService implements the domain backup service interface
+type backupService struct {
+	backupRepo     interfaces.BackupRepository
+	archiver       interfaces.BackupArchiver
+	store          interfaces.BackupStore
+	retentionCount int
+	retentionAge   time.Duration
+}
+
+// NewBackupService creates a new backup service. Pruning keeps the newest retentionCount backups
+// and those younger than retentionAge; a zero value disables either limit.
+func NewBackupService(backupRepo interfaces.BackupRepository, archiver interfaces.BackupArchiver, store interfaces.BackupStore, retentionCount int, retentionAge time.Duration) interfaces.BackupService {
+	return &backupService{
+		backupRepo:     backupRepo,
+		archiver:       archiver,
+		store:          store,
+		retentionCount: retentionCount,
+		retentionAge:   retentionAge,
+	}
+}
+
+// Create dumps the database into a sealed archive in a temporary file, checks it, uploads it,
+// checks the stored copy and then prunes old backups. Pruning only runs once the new backup is
+// known to be good, so a failing backup never costs an old one.
+func (s *backupService) Create(ctx context.Context) (*entities.BackupInfo, error) {
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	name := entities.BackupName(createdAt)
+
+	file, err := os.CreateTemp("", "2fair-backup-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	sealed, err := s.archiver.Seal(file)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := s.backupRepo.Dump(ctx, sealed, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := sealed.Close(); err != nil {
+		return nil, fmt.Errorf("failed to seal backup: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := s.check(file, manifest); err != nil {
+		return nil, fmt.Errorf("backup failed its check before upload: %w", err)
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, name, file); err != nil {
+		return nil, err
+	}
+	if _, err := s.verify(ctx, name, manifest); err != nil {
+		return nil, fmt.Errorf("stored backup failed its check: %w", err)
+	}
+
+	if _, err := s.Prune(ctx); err != nil {
+		return nil, fmt.Errorf("backup %s was stored but pruning failed: %w", name, err)
+	}
+
+	return &entities.BackupInfo{Name: name, Size: size, CreatedAt: createdAt}, nil
+}
+
+// List returns the objects in the store named like backups, newest first. A backup's time is
+// the one in its name, as stores may not keep the time it was written.
+func (s *backupService) List(ctx context.Context) ([]*entities.BackupInfo, error) {
+	objects, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]*entities.BackupInfo, 0, len(objects))
+	for _, object := range objects {
+		createdAt, ok := entities.ParseBackupName(object.Name)
+		if !ok {
+			continue
+		}
+		backups = append(backups, &entities.BackupInfo{Name: object.Name, Size: object.Size, CreatedAt: createdAt})
+	}
+	slices.SortFunc(backups, func(a, b *entities.BackupInfo) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// Verify downloads a backup and reads it to the end
+func (s *backupService) Verify(ctx context.Context, name string) (*entities.BackupManifest, error) {
+	return s.verify(ctx, name, nil)
+}
+
+// Restore loads a stored backup into the database. A backup that fails its checks part way
+// rolls the whole restore back.
+func (s *backupService) Restore(ctx context.Context, name string) (*entities.BackupManifest, error) {
+	archive, err := s.store.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	content, err := s.archiver.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	backup, err := entities.NewBackupReader(content)
+	if err != nil {
+		return nil, err
+	}
+	return s.backupRepo.Restore(ctx, backup)
+}
+
+// Prune deletes backups beyond the retention count or older than the retention age, but never
+// the newest backup
+func (s *backupService) Prune(ctx context.Context) ([]string, error) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-s.retentionAge)
+	var deleted []string
+	for i, backup := range backups {
+		tooMany := s.retentionCount > 0 && i >= s.retentionCount
+		tooOld := s.retentionAge > 0 && backup.CreatedAt.Before(cutoff)
+		if i == 0 || (!tooMany && !tooOld) {
+			continue
+		}
+
+		if err := s.store.Delete(ctx, backup.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, backup.Name)
+	}
+
+	return deleted, nil
+}
+
+// verify reads a stored backup to the end. If want is set, the backup must hold the same tables
+// and rows.
+func (s *backupService) verify(ctx context.Context, name string, want *entities.BackupManifest) (*entities.BackupManifest, error) {
+	archive, err := s.store.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return s.check(archive, want)
+}
+
+// check opens an archive and reads it to the end, which checks its encryption, compression and
+// row counts
+func (s *backupService) check(archive io.Reader, want *entities.BackupManifest) (*entities.BackupManifest, error) {
+	content, err := s.archiver.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	backup, err := entities.NewBackupReader(content)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		_, _, err := backup.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := backup.Manifest()
+	if want != nil && !sameBackupContent(manifest, want) {
+		return nil, fmt.Errorf("%w: content differs from what was written", entities.ErrInvalidDatabaseBackup)
+	}
+	return manifest, nil
+}
+
+// sameBackupContent reports whether two manifests describe the same backup
+func sameBackupContent(a, b *entities.BackupManifest) bool {
+	sameHeader := a.Header.Format == b.Header.Format && a.Header.Version == b.Header.Version &&
+		a.Header.SchemaVersion == b.Header.SchemaVersion && a.Header.CreatedAt.Equal(b.Header.CreatedAt)
+	return sameHeader && slices.EqualFunc(a.Tables, b.Tables, func(x, y *entities.BackupTable) bool {
+		return x.Name == y.Name && x.Rows == y.Rows && slices.Equal(x.Columns, y.Columns)
+	})
+}
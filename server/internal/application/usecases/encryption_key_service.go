@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// encryptionKeyService implements the domain encryption key service interface
+type encryptionKeyService struct {
+	keyRepo        interfaces.EncryptionKeyRepository
+	rotationRepo   interfaces.KeyRotationRepository
+	credentialRepo interfaces.WebAuthnCredentialRepository
+}
+
+// NewEncryptionKeyService creates a new encryption key service
+func NewEncryptionKeyService(keyRepo interfaces.EncryptionKeyRepository, rotationRepo interfaces.KeyRotationRepository, credentialRepo interfaces.WebAuthnCredentialRepository) interfaces.EncryptionKeyService {
+	return &encryptionKeyService{
+		keyRepo:        keyRepo,
+		rotationRepo:   rotationRepo,
+		credentialRepo: credentialRepo,
+	}
+}
+
+// ListKeys returns the active wraps of all of the user's credentials
+func (s *encryptionKeyService) ListKeys(ctx context.Context, userID uuid.UUID) ([]*entities.UserEncryptionKey, error) {
+	return s.keyRepo.GetAllActiveByUserID(ctx, userID)
+}
+
+// GetKey returns the active wrap held by one of the user's credentials
+func (s *encryptionKeyService) GetKey(ctx context.Context, userID uuid.UUID, credentialID uuid.UUID) (*entities.UserEncryptionKey, error) {
+	return s.keyRepo.GetActiveByCredential(ctx, userID, credentialID)
+}
+
+// AddKey stores a credential's wrap of the current key, or of the key a rotation moves to
+func (s *encryptionKeyService) AddKey(ctx context.Context, userID uuid.UUID, key *entities.UserEncryptionKey) error {
+	if err := checkCredentialOwner(ctx, s.credentialRepo, userID, key.CredentialID); err != nil {
+		return err
+	}
+
+	latest, err := s.keyRepo.GetLatestVersion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	current := max(latest, 1)
+
+	key.UserID = userID
+	switch key.KeyVersion {
+	case 0, current:
+		key.KeyVersion = current
+		key.Activate()
+	default:
+		// Only the key an ongoing rotation moves to can be wrapped ahead of time
+		rotation, err := s.rotationRepo.GetInProgress(ctx, userID)
+		if err != nil && !errors.Is(err, entities.ErrKeyRotationNotFound) {
+			return err
+		}
+		if rotation == nil || key.KeyVersion != rotation.ToVersion {
+			return fmt.Errorf("%w: key version must be %d", entities.ErrInvalidEncryptionKey, current)
+		}
+		key.Deactivate()
+	}
+
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	return s.keyRepo.Create(ctx, key)
+}
+
+// checkCredentialOwner checks that the credential belongs to the user
+func checkCredentialOwner(ctx context.Context, credentialRepo interfaces.WebAuthnCredentialRepository, userID uuid.UUID, credentialID uuid.UUID) error {
+	credentials, err := credentialRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %w", err)
+	}
+
+	for _, credential := range credentials {
+		if credential.ID == credentialID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: credential %s", entities.ErrCredentialNotFound, credentialID)
+}
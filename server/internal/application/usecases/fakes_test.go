@@ -0,0 +1,93 @@
+package application
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// fakeUserRepository holds users by ID; the methods the tests do not use are not implemented
+type fakeUserRepository struct {
+	interfaces.UserRepository
+	users map[uuid.UUID]*entities.User
+}
+
+func newFakeUserRepository(users ...*entities.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[uuid.UUID]*entities.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id uuid.UUID) (*entities.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, entities.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) Deactivate(_ context.Context, userID uuid.UUID) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return entities.ErrUserNotFound
+	}
+	user.IsActive = false
+	user.TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepository) Reactivate(_ context.Context, userID uuid.UUID) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return entities.ErrUserNotFound
+	}
+	user.IsActive = true
+	return nil
+}
+
+func (r *fakeUserRepository) RevokeTokens(_ context.Context, userID uuid.UUID) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return entities.ErrUserNotFound
+	}
+	user.TokenVersion++
+	return nil
+}
+
+func (r *fakeUserRepository) SetRole(_ context.Context, userID uuid.UUID, role string) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return entities.ErrUserNotFound
+	}
+	user.Role = role
+	user.TokenVersion++
+	return nil
+}
+
+// publishedEvent is an event a fakeEventService was asked to publish
+type publishedEvent struct {
+	userID    uuid.UUID
+	eventType string
+	entityID  *uuid.UUID
+}
+
+// fakeEventService records published events
+type fakeEventService struct {
+	interfaces.VaultEventService
+	mu        sync.Mutex
+	published []publishedEvent
+}
+
+func (s *fakeEventService) Publish(_ context.Context, userID uuid.UUID, eventType string, entityID *uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.published = append(s.published, publishedEvent{userID: userID, eventType: eventType, entityID: entityID})
+	return nil
+}
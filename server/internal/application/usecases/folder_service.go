@@ -0,0 +1,105 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// folderService implements the domain folder service interface
+type folderService struct {
+	folderRepo interfaces.FolderRepository
+}
+
+// NewFolderService creates a new folder service
+func NewFolderService(folderRepo interfaces.FolderRepository) interfaces.FolderService {
+	return &folderService{
+		folderRepo: folderRepo,
+	}
+}
+
+// CreateFolder creates a folder, at the top level when parentID is nil
+func (s *folderService) CreateFolder(ctx context.Context, userID uuid.UUID, name string, parentID *uuid.UUID) (*entities.Folder, error) {
+	parentID = topLevelAsNil(parentID)
+
+	folder := entities.NewFolder(userID, name, parentID)
+	if err := folder.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkParent(ctx, userID, parentID); err != nil {
+		return nil, err
+	}
+
+	if err := s.folderRepo.Create(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// ListFolders returns all of the user's folders with their entry counts
+func (s *folderService) ListFolders(ctx context.Context, userID uuid.UUID) ([]*entities.Folder, error) {
+	return s.folderRepo.GetByUserID(ctx, userID)
+}
+
+// UpdateFolder renames a folder and moves it under parentID (nil for the top level)
+func (s *folderService) UpdateFolder(ctx context.Context, folderID uuid.UUID, userID uuid.UUID, name string, parentID *uuid.UUID) (*entities.Folder, error) {
+	parentID = topLevelAsNil(parentID)
+
+	folder, err := s.folderRepo.GetByID(ctx, folderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	folder.Move(name, parentID)
+	if err := folder.Validate(); err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		if err := s.checkParent(ctx, userID, parentID); err != nil {
+			return nil, err
+		}
+
+		// A folder cannot move into itself or one of its own subfolders
+		cycle, err := s.folderRepo.IsInSubtree(ctx, folderID, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, fmt.Errorf("%w: a folder cannot be moved into its own subfolder", entities.ErrInvalidFolder)
+		}
+	}
+
+	if err := s.folderRepo.Update(ctx, folder); err != nil {
+		return nil, err
+	}
+
+	return folder, nil
+}
+
+// DeleteFolder deletes a folder; its entries and subfolders move up to its parent
+func (s *folderService) DeleteFolder(ctx context.Context, folderID uuid.UUID, userID uuid.UUID) error {
+	return s.folderRepo.Delete(ctx, folderID, userID)
+}
+
+// checkParent verifies the parent folder exists and belongs to the user
+func (s *folderService) checkParent(ctx context.Context, userID uuid.UUID, parentID *uuid.UUID) error {
+	if parentID == nil {
+		return nil
+	}
+
+	if _, err := s.folderRepo.GetByID(ctx, *parentID, userID); err != nil {
+		if errors.Is(err, entities.ErrFolderNotFound) {
+			return fmt.Errorf("%w: parent folder %s does not exist", entities.ErrInvalidFolder, parentID)
+		}
+		return err
+	}
+
+	return nil
+}
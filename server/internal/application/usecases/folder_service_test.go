@@ -0,0 +1,273 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// fakeFolderRepository holds the user's folders, keeping sibling names unique as the folders
+// index does
+type fakeFolderRepository struct {
+	interfaces.FolderRepository
+	folders []*entities.Folder
+}
+
+func (r *fakeFolderRepository) Create(_ context.Context, folder *entities.Folder) error {
+	if r.nameTaken(folder) {
+		return entities.ErrFolderExists
+	}
+	stored := *folder
+	r.folders = append(r.folders, &stored)
+	return nil
+}
+
+func (r *fakeFolderRepository) GetByID(_ context.Context, id uuid.UUID, userID uuid.UUID) (*entities.Folder, error) {
+	folder := r.find(id)
+	if folder == nil || folder.UserID != userID {
+		return nil, entities.ErrFolderNotFound
+	}
+	stored := *folder
+	return &stored, nil
+}
+
+func (r *fakeFolderRepository) GetByUserID(_ context.Context, _ uuid.UUID) ([]*entities.Folder, error) {
+	return r.folders, nil
+}
+
+func (r *fakeFolderRepository) IsInSubtree(_ context.Context, rootID uuid.UUID, folderID uuid.UUID) (bool, error) {
+	for id := &folderID; id != nil; {
+		if *id == rootID {
+			return true, nil
+		}
+		folder := r.find(*id)
+		if folder == nil {
+			return false, nil
+		}
+		id = folder.ParentID
+	}
+	return false, nil
+}
+
+func (r *fakeFolderRepository) Update(_ context.Context, folder *entities.Folder) error {
+	stored := r.find(folder.ID)
+	if stored == nil || stored.UserID != folder.UserID {
+		return entities.ErrFolderNotFound
+	}
+	if r.nameTaken(folder) {
+		return entities.ErrFolderExists
+	}
+	*stored = *folder
+	return nil
+}
+
+func (r *fakeFolderRepository) Delete(_ context.Context, id uuid.UUID, userID uuid.UUID) error {
+	folder := r.find(id)
+	if folder == nil || folder.UserID != userID {
+		return entities.ErrFolderNotFound
+	}
+
+	kept := r.folders[:0]
+	for _, f := range r.folders {
+		if f.ID == id {
+			continue
+		}
+		if f.ParentID != nil && *f.ParentID == id {
+			f.ParentID = folder.ParentID
+		}
+		kept = append(kept, f)
+	}
+	r.folders = kept
+	return nil
+}
+
+func (r *fakeFolderRepository) find(id uuid.UUID) *entities.Folder {
+	for _, folder := range r.folders {
+		if folder.ID == id {
+			return folder
+		}
+	}
+	return nil
+}
+
+// nameTaken reports whether another folder under the same parent has the folder's name, ignoring case
+func (r *fakeFolderRepository) nameTaken(folder *entities.Folder) bool {
+	for _, f := range r.folders {
+		if f.ID != folder.ID && f.UserID == folder.UserID && sameParent(f.ParentID, folder.ParentID) && strings.EqualFold(f.Name, folder.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// folderTree creates the folders Work, Work/Cloud and Work/Cloud/AWS for userID
+func folderTree(t *testing.T, service interfaces.FolderService, userID uuid.UUID) (work, cloud, aws *entities.Folder) {
+	t.Helper()
+
+	work, err := service.CreateFolder(context.Background(), userID, "Work", nil)
+	require.NoError(t, err)
+	cloud, err = service.CreateFolder(context.Background(), userID, "Cloud", &work.ID)
+	require.NoError(t, err)
+	aws, err = service.CreateFolder(context.Background(), userID, "AWS", &cloud.ID)
+	require.NoError(t, err)
+	return work, cloud, aws
+}
+
+func TestCreateFolder(t *testing.T) {
+	userID := uuid.New()
+	topLevel := uuid.Nil
+	missing := uuid.New()
+
+	tests := []struct {
+		name     string
+		folder   string
+		parentID func(work *entities.Folder) *uuid.UUID
+		err      error
+	}{
+		{name: "top level", folder: "Personal", parentID: func(*entities.Folder) *uuid.UUID { return nil }},
+		{name: "nil UUID is the top level", folder: "Personal", parentID: func(*entities.Folder) *uuid.UUID { return &topLevel }},
+		{name: "nested", folder: "Banking", parentID: func(work *entities.Folder) *uuid.UUID { return &work.ID }},
+		{name: "same name elsewhere", folder: "Cloud", parentID: func(*entities.Folder) *uuid.UUID { return nil }},
+		{name: "sibling name", folder: "cloud", parentID: func(work *entities.Folder) *uuid.UUID { return &work.ID }, err: entities.ErrFolderExists},
+		{name: "missing parent", folder: "Banking", parentID: func(*entities.Folder) *uuid.UUID { return &missing }, err: entities.ErrInvalidFolder},
+		{name: "blank name", folder: "   ", parentID: func(*entities.Folder) *uuid.UUID { return nil }, err: entities.ErrInvalidFolder},
+		{name: "long name", folder: strings.Repeat("a", entities.MaxFolderNameLength+1), parentID: func(*entities.Folder) *uuid.UUID { return nil }, err: entities.ErrInvalidFolder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewFolderService(&fakeFolderRepository{})
+			work, _, _ := folderTree(t, service, userID)
+
+			parentID := tt.parentID(work)
+			folder, err := service.CreateFolder(context.Background(), userID, tt.folder, parentID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, topLevelAsNil(parentID), folder.ParentID)
+		})
+	}
+}
+
+func TestCreateFolder_OtherUsersParent(t *testing.T) {
+	service := NewFolderService(&fakeFolderRepository{})
+	work, _, _ := folderTree(t, service, uuid.New())
+
+	_, err := service.CreateFolder(context.Background(), uuid.New(), "Banking", &work.ID)
+	assert.ErrorIs(t, err, entities.ErrInvalidFolder)
+}
+
+func TestUpdateFolder(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name   string
+		folder func(work, cloud, aws *entities.Folder) uuid.UUID
+		parent func(work, cloud, aws *entities.Folder) *uuid.UUID
+		err    error
+	}{
+		{
+			name:   "rename in place",
+			folder: func(_, cloud, _ *entities.Folder) uuid.UUID { return cloud.ID },
+			parent: func(work, _, _ *entities.Folder) *uuid.UUID { return &work.ID },
+		},
+		{
+			name:   "move to the top level",
+			folder: func(_, _, aws *entities.Folder) uuid.UUID { return aws.ID },
+			parent: func(_, _, _ *entities.Folder) *uuid.UUID { return nil },
+		},
+		{
+			name:   "move up",
+			folder: func(_, _, aws *entities.Folder) uuid.UUID { return aws.ID },
+			parent: func(work, _, _ *entities.Folder) *uuid.UUID { return &work.ID },
+		},
+		{
+			name:   "into itself",
+			folder: func(work, _, _ *entities.Folder) uuid.UUID { return work.ID },
+			parent: func(work, _, _ *entities.Folder) *uuid.UUID { return &work.ID },
+			err:    entities.ErrInvalidFolder,
+		},
+		{
+			name:   "into its child",
+			folder: func(work, _, _ *entities.Folder) uuid.UUID { return work.ID },
+			parent: func(_, cloud, _ *entities.Folder) *uuid.UUID { return &cloud.ID },
+			err:    entities.ErrInvalidFolder,
+		},
+		{
+			name:   "into its grandchild",
+			folder: func(work, _, _ *entities.Folder) uuid.UUID { return work.ID },
+			parent: func(_, _, aws *entities.Folder) *uuid.UUID { return &aws.ID },
+			err:    entities.ErrInvalidFolder,
+		},
+		{
+			name:   "missing folder",
+			folder: func(_, _, _ *entities.Folder) uuid.UUID { return uuid.New() },
+			parent: func(_, _, _ *entities.Folder) *uuid.UUID { return nil },
+			err:    entities.ErrFolderNotFound,
+		},
+		{
+			name:   "missing parent",
+			folder: func(_, cloud, _ *entities.Folder) uuid.UUID { return cloud.ID },
+			parent: func(_, _, _ *entities.Folder) *uuid.UUID { missing := uuid.New(); return &missing },
+			err:    entities.ErrInvalidFolder,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFolderRepository{}
+			service := NewFolderService(repo)
+			work, cloud, aws := folderTree(t, service, userID)
+			folderID, parentID := tt.folder(work, cloud, aws), tt.parent(work, cloud, aws)
+
+			folder, err := service.UpdateFolder(context.Background(), folderID, userID, "Renamed", parentID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				for _, f := range repo.folders {
+					assert.NotEqual(t, "Renamed", f.Name, "a refused move changes nothing")
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Renamed", folder.Name)
+			assert.Equal(t, parentID, folder.ParentID)
+
+			stored := repo.find(folderID)
+			assert.Equal(t, "Renamed", stored.Name)
+			assert.Equal(t, parentID, stored.ParentID)
+		})
+	}
+}
+
+func TestDeleteFolder(t *testing.T) {
+	userID := uuid.New()
+	repo := &fakeFolderRepository{}
+	service := NewFolderService(repo)
+	work, cloud, aws := folderTree(t, service, userID)
+
+	assert.ErrorIs(t, service.DeleteFolder(context.Background(), cloud.ID, uuid.New()), entities.ErrFolderNotFound, "only the owner can delete a folder")
+
+	require.NoError(t, service.DeleteFolder(context.Background(), cloud.ID, userID))
+	folders, err := service.ListFolders(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, folders, 2)
+	assert.Equal(t, &work.ID, repo.find(aws.ID).ParentID, "subfolders move up to the deleted folder's parent")
+
+	assert.ErrorIs(t, service.DeleteFolder(context.Background(), cloud.ID, userID), entities.ErrFolderNotFound)
+}
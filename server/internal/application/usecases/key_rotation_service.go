@@ -0,0 +1,164 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// keyRotationService implements the domain key rotation service interface
+type keyRotationService struct {
+	rotationRepo   interfaces.KeyRotationRepository
+	keyRepo        interfaces.EncryptionKeyRepository
+	credentialRepo interfaces.WebAuthnCredentialRepository
+}
+
+// NewKeyRotationService creates a new key rotation service
+func NewKeyRotationService(rotationRepo interfaces.KeyRotationRepository, keyRepo interfaces.EncryptionKeyRepository, credentialRepo interfaces.WebAuthnCredentialRepository) interfaces.KeyRotationService {
+	return &keyRotationService{
+		rotationRepo:   rotationRepo,
+		keyRepo:        keyRepo,
+		credentialRepo: credentialRepo,
+	}
+}
+
+// StartRotation begins a rotation from the current key version to the next one
+func (s *keyRotationService) StartRotation(ctx context.Context, userID uuid.UUID, keys []*entities.UserEncryptionKey) (*entities.KeyRotation, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: at least one wrapped key is required", entities.ErrInvalidEncryptionKey)
+	}
+
+	from, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	to := from + 1
+
+	credentials, err := s.credentialRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+	owned := make(map[uuid.UUID]bool, len(credentials))
+	for _, credential := range credentials {
+		owned[credential.ID] = true
+	}
+
+	// Each credential holds at most one wrap of the new key
+	wrapped := make(map[uuid.UUID]bool, len(keys))
+	for _, key := range keys {
+		if !owned[key.CredentialID] {
+			return nil, fmt.Errorf("%w: credential %s", entities.ErrCredentialNotFound, key.CredentialID)
+		}
+		if wrapped[key.CredentialID] {
+			return nil, fmt.Errorf("%w: credential %s has more than one wrapped key", entities.ErrInvalidEncryptionKey, key.CredentialID)
+		}
+		wrapped[key.CredentialID] = true
+
+		key.UserID = userID
+		key.KeyVersion = to
+		key.Deactivate()
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	rotation := &entities.KeyRotation{
+		UserID:      userID,
+		FromVersion: from,
+		ToVersion:   to,
+		Status:      entities.KeyRotationInProgress,
+	}
+	if err := s.rotationRepo.Create(ctx, rotation, keys); err != nil {
+		return nil, err
+	}
+
+	return rotation, nil
+}
+
+// GetRotation returns the rotation in progress with its progress
+func (s *keyRotationService) GetRotation(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error) {
+	return s.rotationRepo.GetInProgress(ctx, userID)
+}
+
+// ListPendingEntries returns up to limit entries that still need to be re-encrypted, ordered by ID
+func (s *keyRotationService) ListPendingEntries(ctx context.Context, userID uuid.UUID, limit int) ([]*entities.KeyRotationEntry, error) {
+	rotation, err := s.rotationRepo.GetInProgress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > entities.MaxKeyRotationChunkSize {
+		limit = entities.MaxKeyRotationChunkSize
+	}
+
+	return s.rotationRepo.ListPending(ctx, rotation, limit)
+}
+
+// StageEntries validates and stores a chunk of re-encrypted secrets
+func (s *keyRotationService) StageEntries(ctx context.Context, userID uuid.UUID, entries []*entities.KeyRotationEntry) (*entities.KeyRotationStageResult, error) {
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: at least one entry is required", entities.ErrInvalidTOTPSeed)
+	}
+	if len(entries) > entities.MaxKeyRotationChunkSize {
+		return nil, fmt.Errorf("%w: %d entries, limit is %d", entities.ErrOTPBatchTooLarge, len(entries), entities.MaxKeyRotationChunkSize)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(entries))
+	for i, entry := range entries {
+		if entry.ID == uuid.Nil || entry.Revision < 1 {
+			return nil, fmt.Errorf("%w: entry %d needs an id and a revision", entities.ErrInvalidTOTPSeed, i)
+		}
+		if seen[entry.ID] {
+			return nil, fmt.Errorf("%w: entry %s is staged more than once", entities.ErrInvalidTOTPSeed, entry.ID)
+		}
+		seen[entry.ID] = true
+	}
+
+	rotation, err := s.rotationRepo.GetInProgress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every secret must be encrypted with the rotation's new key
+	for _, entry := range entries {
+		if _, err := parseEntrySecret(entry.Secret, rotation.ToVersion); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", entry.ID, err)
+		}
+		entry.KeyVersion = 0
+	}
+
+	rejected, err := s.rotationRepo.Stage(ctx, rotation, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation, err = s.rotationRepo.GetInProgress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.KeyRotationStageResult{Rotation: rotation, Rejected: rejected}, nil
+}
+
+// CommitRotation swaps the staged secrets in and activates the new key
+func (s *keyRotationService) CommitRotation(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error) {
+	rotation, err := s.rotationRepo.GetInProgress(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.rotationRepo.Commit(ctx, rotation)
+}
+
+// AbortRotation discards the rotation in progress
+func (s *keyRotationService) AbortRotation(ctx context.Context, userID uuid.UUID) error {
+	rotation, err := s.rotationRepo.GetInProgress(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.rotationRepo.Abort(ctx, rotation)
+}
@@ -2,7 +2,9 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
@@ -12,51 +14,55 @@ import (
 
 // otpService implements the domain OTP service interface
 type otpService struct {
-	otpRepo       interfaces.OTPRepository
-	cryptoService interfaces.CryptoService
-	totpService   interfaces.TOTPService
+	otpRepo            interfaces.OTPRepository
+	folderRepo         interfaces.FolderRepository
+	keyRepo            interfaces.EncryptionKeyRepository
+	cryptoService      interfaces.CryptoService
+	totpService        interfaces.TOTPService
+	issuerCatalog      interfaces.IssuerCatalog
+	batchMaxOperations int
+	trashRetention     time.Duration
 }
 
-// NewOTPService creates a new OTP service
-func NewOTPService(otpRepo interfaces.OTPRepository, cryptoService interfaces.CryptoService, totpService interfaces.TOTPService) interfaces.OTPService {
+// NewOTPService creates a new OTP service. batchMaxOperations is the most operations ApplyOTPBatch accepts.
+// Trashed entries are purged after trashRetention (0 keeps them until purged by hand).
+func NewOTPService(otpRepo interfaces.OTPRepository, folderRepo interfaces.FolderRepository, keyRepo interfaces.EncryptionKeyRepository, cryptoService interfaces.CryptoService, totpService interfaces.TOTPService, issuerCatalog interfaces.IssuerCatalog, batchMaxOperations int, trashRetention time.Duration) interfaces.OTPService {
 	return &otpService{
-		otpRepo:       otpRepo,
-		cryptoService: cryptoService,
-		totpService:   totpService,
+		otpRepo:            otpRepo,
+		folderRepo:         folderRepo,
+		keyRepo:            keyRepo,
+		cryptoService:      cryptoService,
+		totpService:        totpService,
+		issuerCatalog:      issuerCatalog,
+		batchMaxOperations: batchMaxOperations,
+		trashRetention:     trashRetention,
 	}
 }
 
 // CreateOTP creates a new encrypted OTP entry
-func (s *otpService) CreateOTP(ctx context.Context, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int) (*entities.OTP, error) {
-	// Set defaults if not provided
-	if algorithm == "" {
-		algorithm = "SHA1"
-	}
-	if digits == 0 {
-		digits = 6
+func (s *otpService) CreateOTP(ctx context.Context, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int, method string, counter int64, otpType string, t0 int64, tags []string, folderID *uuid.UUID) (*entities.OTP, error) {
+	// Note: secret is already encrypted client-side, in a secret envelope for the current key
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
 	}
-	if period == 0 {
-		period = 30
+	otp, envelope, err := s.newOTPEntry(userID, issuer, label, secret, keyVersion, period, algorithm, digits, method, counter, otpType, t0)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create OTP entity
-	// Note: secret is already encrypted client-side in format "ciphertext.iv.authTag"
-	otp := entities.NewOTP(userID, issuer, label, secret, period)
-	otp.Algorithm = algorithm
-	otp.Digits = digits
-
-	// Skip validation of encrypted secret (it won't be valid base32)
-	// Only validate issuer and label which should not be empty
-	if otp.Issuer == "" || otp.Label == "" {
-		return nil, fmt.Errorf("issuer and label are required")
+	tags, err = normalizeEntryTags(tags)
+	if err != nil {
+		return nil, err
 	}
+	folderID, err = s.resolveFolder(ctx, userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	otp.Organize(tags, folderID)
 
-	// Store the already-encrypted secret directly (no double encryption)
-	// The secret comes pre-encrypted from the client in format: ciphertext.iv.authTag
-	encryptedData := []byte(secret)
-
-	// Save to repository
-	if err := s.otpRepo.Create(ctx, otp, encryptedData, 1); err != nil {
+	// Store the already-encrypted secret's parts (no double encryption)
+	if err := s.otpRepo.Create(ctx, otp, envelope); err != nil {
 		return nil, fmt.Errorf("failed to create OTP: %w", err)
 	}
 
@@ -64,6 +70,301 @@ func (s *otpService) CreateOTP(ctx context.Context, userID uuid.UUID, issuer, la
 	return otp, nil
 }
 
+// ApplyOTPBatch validates and atomically applies a batch of create, update and inactivate operations
+func (s *otpService) ApplyOTPBatch(ctx context.Context, userID uuid.UUID, operations []*entities.OTPBatchOperation) ([]*entities.OTPBatchResult, error) {
+	if len(operations) > s.batchMaxOperations {
+		return nil, fmt.Errorf("%w: %d operations, limit is %d", entities.ErrOTPBatchTooLarge, len(operations), s.batchMaxOperations)
+	}
+
+	folders, err := s.batchFolders(ctx, userID, operations)
+	if err != nil {
+		return nil, err
+	}
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate every operation up front so a bad item never reaches the database
+	results := make([]*entities.OTPBatchResult, len(operations))
+	seen := make(map[uuid.UUID]int)
+	valid := true
+	for i, op := range operations {
+		results[i] = &entities.OTPBatchResult{Index: i, Type: op.Type, ID: op.ID, Status: entities.OTPBatchStatusOK}
+
+		otp, err := s.prepareBatchOperation(userID, op, keyVersion, folders)
+		if err == nil && op.Type != entities.OTPBatchCreate {
+			if first, ok := seen[op.ID]; ok {
+				err = fmt.Errorf("%w: entry is already modified by operation %d", entities.ErrInvalidTOTPSeed, first)
+			}
+			seen[op.ID] = i
+		}
+		if err != nil {
+			results[i].Fail(err)
+			valid = false
+			continue
+		}
+
+		op.OTP = otp
+		results[i].ID = otp.ID
+	}
+
+	if !valid {
+		for _, result := range results {
+			if result.Status == entities.OTPBatchStatusOK {
+				result.Status = entities.OTPBatchStatusSkipped
+			}
+		}
+		return results, entities.ErrOTPBatchFailed
+	}
+
+	return s.otpRepo.ApplyBatch(ctx, userID, operations)
+}
+
+// batchFolders loads the IDs of the user's folders when any operation files an entry into one
+func (s *otpService) batchFolders(ctx context.Context, userID uuid.UUID, operations []*entities.OTPBatchOperation) (map[uuid.UUID]bool, error) {
+	folders := make(map[uuid.UUID]bool)
+	for _, op := range operations {
+		if op.FolderID == nil || *op.FolderID == uuid.Nil {
+			continue
+		}
+
+		existing, err := s.folderRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load folders: %w", err)
+		}
+		for _, folder := range existing {
+			folders[folder.ID] = true
+		}
+		break
+	}
+
+	return folders, nil
+}
+
+// prepareBatchOperation builds the entry a batch operation applies
+func (s *otpService) prepareBatchOperation(userID uuid.UUID, op *entities.OTPBatchOperation, keyVersion int, folders map[uuid.UUID]bool) (*entities.OTP, error) {
+	if op.Type == entities.OTPBatchCreate || op.Type == entities.OTPBatchUpdate {
+		if op.FolderID != nil && *op.FolderID != uuid.Nil && !folders[*op.FolderID] {
+			return nil, fmt.Errorf("%w: folder %s does not exist", entities.ErrInvalidTOTPSeed, op.FolderID)
+		}
+	}
+	// A change to an existing entry names the revision it is based on, so it cannot silently
+	// overwrite a change made elsewhere; 0 opts out explicitly
+	if op.Type == entities.OTPBatchUpdate || op.Type == entities.OTPBatchInactivate {
+		if op.Revision == nil || *op.Revision < 0 {
+			return nil, fmt.Errorf("%w: revision is required", entities.ErrInvalidTOTPSeed)
+		}
+	}
+
+	switch op.Type {
+	case entities.OTPBatchCreate:
+		otp, envelope, err := s.newOTPEntry(userID, op.Issuer, op.Label, op.Secret, keyVersion, op.Period, op.Algorithm, op.Digits, op.Method, op.Counter, op.OTPType, op.T0)
+		if err != nil {
+			return nil, err
+		}
+		op.Envelope = envelope
+		tags, err := normalizeEntryTags(op.Tags)
+		if err != nil {
+			return nil, err
+		}
+		otp.Organize(tags, topLevelAsNil(op.FolderID))
+		return otp, nil
+	case entities.OTPBatchUpdate:
+		if op.ID == uuid.Nil {
+			return nil, fmt.Errorf("%w: id is required", entities.ErrInvalidTOTPSeed)
+		}
+		// Method and counter are fixed at creation; metadata, the secret and code parameters change
+		otp, envelope, err := s.newOTPEntry(userID, op.Issuer, op.Label, op.Secret, keyVersion, op.Period, op.Algorithm, op.Digits, "", 0, op.OTPType, op.T0)
+		if err != nil {
+			return nil, err
+		}
+		otp.ID = op.ID
+		op.Envelope = envelope
+
+		// Nil tags are passed through so the repository keeps the stored ones
+		var tags []string
+		if op.Tags != nil {
+			if tags, err = normalizeEntryTags(op.Tags); err != nil {
+				return nil, err
+			}
+		}
+		otp.Organize(tags, topLevelAsNil(op.FolderID))
+		return otp, nil
+	case entities.OTPBatchInactivate:
+		if op.ID == uuid.Nil {
+			return nil, fmt.Errorf("%w: id is required", entities.ErrInvalidTOTPSeed)
+		}
+		return &entities.OTP{ID: op.ID, UserID: userID}, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported operation %q", entities.ErrInvalidTOTPSeed, op.Type)
+	}
+}
+
+// newOTPEntry applies defaults and validates the fields of a new entry, returning it with its
+// secret's envelope, which must be encrypted with keyVersion
+func (s *otpService) newOTPEntry(userID uuid.UUID, issuer, label, secret string, keyVersion int, period int, algorithm string, digits int, method string, counter int64, otpType string, t0 int64) (*entities.OTP, *entities.SecretEnvelope, error) {
+	method, counter, t0, err := normalizeMethod(method, counter, t0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// A known issuer supplies its defaults first, then the scheme applies its own
+	// (e.g. 5 characters for Steam) and validates the format
+	known := lookupIssuer(s.issuerCatalog, issuer)
+	params, err := normalizeCodeParams(s.totpService, known, period, algorithm, digits, otpType, t0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The encrypted secret can't be checked as base32, only as an envelope
+	if issuer == "" || label == "" {
+		return nil, nil, fmt.Errorf("%w: issuer and label are required", entities.ErrInvalidTOTPSeed)
+	}
+	envelope, err := parseEntrySecret(secret, keyVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	otp := entities.NewOTP(userID, issuer, label, secret, params.Period)
+	otp.Algorithm = params.Algorithm
+	otp.Digits = params.Digits
+	otp.Method = method
+	otp.Counter = counter
+	otp.Type = params.Type
+	otp.T0 = params.T0
+	otp.KeyVersion = keyVersion
+	otp.ApplyIssuer(known)
+
+	return otp, envelope, nil
+}
+
+// normalizeMethod defaults an entry's method to TOTP and clears the moving factor the method
+// does not use
+func normalizeMethod(method string, counter int64, t0 int64) (string, int64, int64, error) {
+	method = strings.ToUpper(method)
+	if method == "" {
+		method = entities.OTPMethodTOTP
+	}
+
+	switch method {
+	case entities.OTPMethodTOTP:
+		counter = 0
+	case entities.OTPMethodHOTP:
+		if counter < 0 {
+			return "", 0, 0, fmt.Errorf("%w: counter must not be negative", entities.ErrInvalidTOTPSeed)
+		}
+		t0 = 0
+	default:
+		return "", 0, 0, fmt.Errorf("%w: unsupported method %q", entities.ErrInvalidTOTPSeed, method)
+	}
+
+	return method, counter, t0, nil
+}
+
+// parseEntrySecret parses a client-encrypted secret and checks that it is encrypted with keyVersion
+func parseEntrySecret(secret string, keyVersion int) (*entities.SecretEnvelope, error) {
+	envelope, err := entities.ParseSecretEnvelope(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := envelope.CheckKeyVersion(keyVersion); err != nil {
+		return nil, err
+	}
+	return envelope, nil
+}
+
+// lookupIssuer resolves an issuer against the catalog; nil when it is unknown
+func lookupIssuer(catalog interfaces.IssuerCatalog, issuer string) *entities.Issuer {
+	known, ok := catalog.Lookup(issuer)
+	if !ok {
+		return nil
+	}
+	return known
+}
+
+// normalizeCodeParams applies the defaults of a known issuer (nil if unknown) and of the entry's
+// OTP scheme, and validates its code parameters
+func normalizeCodeParams(totpService interfaces.TOTPService, known *entities.Issuer, period int, algorithm string, digits int, otpType string, t0 int64) (*interfaces.OTPParams, error) {
+	params := &interfaces.OTPParams{
+		Type:      otpType,
+		Algorithm: strings.ToUpper(algorithm),
+		Digits:    digits,
+		Period:    period,
+		T0:        t0,
+	}
+
+	// Issuer defaults only fill parameters the client left out, and only for the issuer's own scheme
+	if known != nil {
+		if params.Type == "" {
+			params.Type = known.Type
+		}
+		if params.Type == known.Type || (known.Type == "" && params.Type == entities.OTPTypeStandard) {
+			if params.Algorithm == "" {
+				params.Algorithm = known.Algorithm
+			}
+			if params.Digits == 0 {
+				params.Digits = known.Digits
+			}
+			if params.Period == 0 {
+				params.Period = known.Period
+			}
+		}
+	}
+	if err := totpService.NormalizeParams(params); err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidTOTPSeed, err)
+	}
+
+	return params, nil
+}
+
+// normalizeEntryTags cleans an entry's tags, reporting problems as an invalid entry
+func normalizeEntryTags(tags []string) ([]string, error) {
+	tags, err := entities.NormalizeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", entities.ErrInvalidTOTPSeed, err)
+	}
+	return tags, nil
+}
+
+// currentKeyVersion returns the key version clients encrypt new secrets with. Users who have not
+// stored a wrapped key yet are on version 1.
+func currentKeyVersion(ctx context.Context, keyRepo interfaces.EncryptionKeyRepository, userID uuid.UUID) (int, error) {
+	version, err := keyRepo.GetLatestVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if version < 1 {
+		return 1, nil
+	}
+	return version, nil
+}
+
+// resolveFolder checks that the target folder belongs to the user. Nil and uuid.Nil both mean the top level.
+func (s *otpService) resolveFolder(ctx context.Context, userID uuid.UUID, folderID *uuid.UUID) (*uuid.UUID, error) {
+	folderID = topLevelAsNil(folderID)
+	if folderID == nil {
+		return nil, nil
+	}
+
+	if _, err := s.folderRepo.GetByID(ctx, *folderID, userID); err != nil {
+		if errors.Is(err, entities.ErrFolderNotFound) {
+			return nil, fmt.Errorf("%w: folder %s does not exist", entities.ErrFolderNotFound, folderID)
+		}
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+
+	return folderID, nil
+}
+
+// topLevelAsNil maps a uuid.Nil folder reference to nil
+func topLevelAsNil(folderID *uuid.UUID) *uuid.UUID {
+	if folderID == nil || *folderID == uuid.Nil {
+		return nil
+	}
+	return folderID
+}
+
 // GetOTP retrieves a decrypted OTP by ID
 func (s *otpService) GetOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (*entities.OTP, error) {
 	// Get OTP from repository (this should already decrypt it)
@@ -75,31 +376,91 @@ func (s *otpService) GetOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UU
 	return otp, nil
 }
 
-// ListOTPs retrieves all decrypted OTPs for a user
-func (s *otpService) ListOTPs(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error) {
+// ListOTPs retrieves one page of a user's OTPs matching the filter, with the total match count
+func (s *otpService) ListOTPs(ctx context.Context, userID uuid.UUID, filter entities.OTPFilter) (*entities.OTPPage, error) {
+	filter.Tag = strings.TrimSpace(filter.Tag)
+	filter.Query = strings.TrimSpace(filter.Query)
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+
+	// An unknown folder is reported rather than silently matching nothing
+	if filter.FolderID != nil {
+		if _, err := s.folderRepo.GetByID(ctx, *filter.FolderID, userID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Get OTPs from repository (these should already be decrypted)
-	otps, err := s.otpRepo.GetByUserID(ctx, userID)
+	page, err := s.otpRepo.List(ctx, userID, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get OTPs: %w", err)
 	}
 
-	return otps, nil
+	return page, nil
+}
+
+// RecordUse counts a use of an entry's code, for sorting by most used
+func (s *otpService) RecordUse(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error {
+	return s.otpRepo.RecordUse(ctx, otpID, userID)
+}
+
+// ListTags returns the user's tags with the number of entries carrying each
+func (s *otpService) ListTags(ctx context.Context, userID uuid.UUID) ([]*entities.TagCount, error) {
+	tags, err := s.otpRepo.ListTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// RenameTag renames a tag across all of the user's entries, merging it into an existing tag
+func (s *otpService) RenameTag(ctx context.Context, userID uuid.UUID, oldTag, newTag string) (int64, error) {
+	oldTag, err := entities.NormalizeTag(oldTag)
+	if err != nil {
+		return 0, err
+	}
+	newTag, err = entities.NormalizeTag(newTag)
+	if err != nil {
+		return 0, err
+	}
+	if oldTag == newTag {
+		return 0, fmt.Errorf("%w: new name is the same as the old one", entities.ErrInvalidTag)
+	}
+
+	updated, err := s.otpRepo.RenameTag(ctx, userID, oldTag, newTag)
+	if err != nil {
+		return 0, err
+	}
+	if updated == 0 {
+		return 0, entities.ErrTagNotFound
+	}
+
+	return updated, nil
 }
 
 // UpdateOTP updates an existing encrypted OTP entry
-func (s *otpService) UpdateOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int) (*entities.OTP, error) {
-	// Set defaults if not provided
-	if algorithm == "" {
-		algorithm = "SHA1"
+func (s *otpService) UpdateOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int, otpType string, t0 int64, tags []string, folderID *uuid.UUID, expectedRevision int64) (*entities.OTP, error) {
+	// Apply the issuer's and the scheme's defaults for anything not provided
+	known := lookupIssuer(s.issuerCatalog, issuer)
+	params, err := normalizeCodeParams(s.totpService, known, period, algorithm, digits, otpType, t0)
+	if err != nil {
+		return nil, err
 	}
-	if digits == 0 {
-		digits = 6
+
+	// The secret comes pre-encrypted from the client, in a secret envelope for the current key
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
 	}
-	if period == 0 {
-		period = 30
+	envelope, err := parseEntrySecret(secret, keyVersion)
+	if err != nil {
+		return nil, err
 	}
 
-	// First, get the existing OTP to verify ownership
+	// First, get the existing OTP to verify ownership. The revision is checked by the update
+	// itself, since the entry may change after it is read.
 	existingOTP, err := s.otpRepo.GetByID(ctx, otpID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get existing OTP: %w", err)
@@ -107,49 +468,162 @@ func (s *otpService) UpdateOTP(ctx context.Context, otpID uuid.UUID, userID uuid
 
 	// Update the OTP entity
 	existingOTP.UpdateMetadata(issuer, label)
-	existingOTP.UpdateSecret(secret, period, algorithm, digits)
+	existingOTP.UpdateSecret(secret, params.Period, params.Algorithm, params.Digits)
+	existingOTP.UpdateScheme(params.Type, params.T0)
+	existingOTP.ApplyIssuer(known)
+
+	// Tags and folder are only replaced when provided
+	if tags == nil {
+		tags = existingOTP.Tags
+	} else if tags, err = normalizeEntryTags(tags); err != nil {
+		return nil, err
+	}
+	if folderID == nil {
+		folderID = existingOTP.FolderID
+	} else if folderID, err = s.resolveFolder(ctx, userID, folderID); err != nil {
+		return nil, err
+	}
+	existingOTP.Organize(tags, folderID)
 
 	// Only validate issuer and label which should not be empty
 	if issuer == "" || label == "" {
 		return nil, fmt.Errorf("issuer and label are required")
 	}
 
-	// Store the already-encrypted secret directly (no double encryption)
-	// The secret comes pre-encrypted from the client in format: ciphertext.iv.authTag
-	encryptedData := []byte(secret)
-
-	// Update in repository
-	if err := s.otpRepo.Update(ctx, existingOTP, encryptedData, 1); err != nil {
+	// Store the already-encrypted secret's parts (no double encryption)
+	if err := s.otpRepo.Update(ctx, existingOTP, envelope, expectedRevision); err != nil {
 		return nil, fmt.Errorf("failed to update OTP: %w", err)
 	}
 
 	return existingOTP, nil
 }
 
-// DeleteOTP soft deletes an OTP entry
-func (s *otpService) DeleteOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error {
+// AdvanceCounter reserves the current counter of an HOTP entry and returns the new counter value
+func (s *otpService) AdvanceCounter(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (int64, error) {
+	counter, err := s.otpRepo.AdvanceCounter(ctx, otpID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to advance counter: %w", err)
+	}
+
+	return counter, nil
+}
+
+// ListRevisions returns an entry's past versions, newest first
+func (s *otpService) ListRevisions(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) ([]*entities.OTPRevision, error) {
+	// Verify the entry exists and belongs to the user
+	if _, err := s.otpRepo.GetByID(ctx, otpID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get OTP: %w", err)
+	}
+
+	revisions, err := s.otpRepo.ListRevisions(ctx, otpID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// RevertOTP restores an entry to one of its revisions encrypted with the current vault key
+func (s *otpService) RevertOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, revisionID uuid.UUID) (*entities.OTP, error) {
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current key version: %w", err)
+	}
+
+	otp, err := s.otpRepo.Revert(ctx, otpID, userID, revisionID, keyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revert OTP: %w", err)
+	}
+
+	return otp, nil
+}
+
+// DeleteOTP soft deletes an OTP entry, moving it to the trash
+func (s *otpService) DeleteOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, expectedRevision int64) error {
 	// Delete from repository
-	if err := s.otpRepo.Delete(ctx, otpID, userID); err != nil {
+	if err := s.otpRepo.Delete(ctx, otpID, userID, expectedRevision); err != nil {
 		return fmt.Errorf("failed to delete OTP: %w", err)
 	}
 
 	return nil
 }
 
+// ListTrash returns the user's trashed entries with the time each will be purged
+func (s *otpService) ListTrash(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error) {
+	otps, err := s.otpRepo.ListTrash(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trash: %w", err)
+	}
+
+	if s.trashRetention > 0 {
+		for _, otp := range otps {
+			if otp.DeletedAt != nil {
+				purgeAt := otp.DeletedAt.Add(s.trashRetention)
+				otp.PurgeAt = &purgeAt
+			}
+		}
+	}
+
+	return otps, nil
+}
+
+// RestoreOTP moves a trashed entry back into the vault
+func (s *otpService) RestoreOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (*entities.OTP, error) {
+	otp, err := s.otpRepo.Restore(ctx, otpID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore OTP: %w", err)
+	}
+
+	return otp, nil
+}
+
+// PurgeOTP permanently deletes a trashed entry
+func (s *otpService) PurgeOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error {
+	if err := s.otpRepo.Purge(ctx, otpID, userID); err != nil {
+		return fmt.Errorf("failed to purge OTP: %w", err)
+	}
+
+	return nil
+}
+
+// PurgeExpiredTrash permanently deletes entries that have been in the trash longer than the retention period
+func (s *otpService) PurgeExpiredTrash(ctx context.Context) (int64, error) {
+	if s.trashRetention <= 0 {
+		return 0, nil
+	}
+
+	purged, err := s.otpRepo.PurgeExpired(ctx, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+
+	return purged, nil
+}
+
 // GenerateOTPCodes generates current and next TOTP codes for all user's OTPs
 func (s *otpService) GenerateOTPCodes(ctx context.Context, userID uuid.UUID) ([]*entities.OTPCodes, error) {
 	// Get all user's OTPs
-	otps, err := s.ListOTPs(ctx, userID)
+	page, err := s.ListOTPs(ctx, userID, entities.OTPFilter{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user OTPs: %w", err)
 	}
 
 	var otpCodes []*entities.OTPCodes
-	for _, otp := range otps {
+	for _, otp := range page.Items {
+		// HOTP codes are not time-based; they are issued through AdvanceCounter
+		if otp.IsHOTP() {
+			continue
+		}
+
 		// Generate current and next codes
-		current, next, currentExpiry, nextExpiry, err := s.totpService.GenerateCodesForTime(
-			otp.Secret, otp.Algorithm, otp.Digits, otp.Period, time.Now(),
-		)
+		current, next, currentExpiry, nextExpiry, err := s.totpService.GenerateCodesForParams(&interfaces.OTPParams{
+			Type:      otp.Type,
+			Secret:    otp.Secret,
+			Algorithm: otp.Algorithm,
+			Digits:    otp.Digits,
+			Period:    otp.Period,
+			T0:        otp.T0,
+		}, time.Now())
 		if err != nil {
 			// Log error but continue with other OTPs
 			continue
@@ -0,0 +1,740 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/totp"
+)
+
+// fakeOTPRepository holds the user's entries and their revisions, and records the batches it is
+// asked to apply
+type fakeOTPRepository struct {
+	interfaces.OTPRepository
+	stored    []*entities.OTP
+	revisions []*entities.OTPRevision
+	applied   []*entities.OTPBatchOperation
+
+	revertedWith int // Key version passed to the last Revert
+
+	purgedBefore time.Time // Cutoff passed to the last PurgeExpired
+}
+
+func (r *fakeOTPRepository) Create(_ context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope) error {
+	otp.KeyVersion = envelope.KeyVersion
+	otp.Revision = 1
+	r.stored = append(r.stored, otp)
+	return nil
+}
+
+func (r *fakeOTPRepository) GetByID(_ context.Context, id uuid.UUID, userID uuid.UUID) (*entities.OTP, error) {
+	otp := r.find(id, userID, false)
+	if otp == nil {
+		return nil, entities.ErrTOTPSeedNotFound
+	}
+	stored := *otp
+	return &stored, nil
+}
+
+func (r *fakeOTPRepository) Update(_ context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope, _ int64) error {
+	stored := r.find(otp.ID, otp.UserID, false)
+	if stored == nil {
+		return entities.ErrTOTPSeedNotFound
+	}
+	r.recordRevision(stored, entities.OTPRevisionUpdate)
+	otp.KeyVersion = envelope.KeyVersion
+	otp.Revision = stored.Revision + 1
+	*stored = *otp
+	return nil
+}
+
+func (r *fakeOTPRepository) ListRevisions(_ context.Context, id uuid.UUID, _ uuid.UUID) ([]*entities.OTPRevision, error) {
+	var revisions []*entities.OTPRevision
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		if r.revisions[i].OTPID == id {
+			revisions = append(revisions, r.revisions[i])
+		}
+	}
+	return revisions, nil
+}
+
+// recordRevision keeps the entry's current version before a change replaces it
+func (r *fakeOTPRepository) recordRevision(otp *entities.OTP, reason string) {
+	r.revisions = append(r.revisions, &entities.OTPRevision{
+		ID:         uuid.New(),
+		OTPID:      otp.ID,
+		Reason:     reason,
+		Issuer:     otp.Issuer,
+		Label:      otp.Label,
+		Secret:     otp.Secret,
+		Tags:       otp.Tags,
+		FolderID:   otp.FolderID,
+		Revision:   otp.Revision,
+		KeyVersion: otp.KeyVersion,
+	})
+}
+
+func (r *fakeOTPRepository) GetByUserID(_ context.Context, _ uuid.UUID) ([]*entities.OTP, error) {
+	var active []*entities.OTP
+	for _, otp := range r.stored {
+		if otp.DeletedAt == nil {
+			active = append(active, otp)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeOTPRepository) Delete(_ context.Context, id uuid.UUID, userID uuid.UUID, expectedRevision int64) error {
+	otp := r.find(id, userID, false)
+	if otp == nil {
+		return entities.ErrTOTPSeedNotFound
+	}
+	if expectedRevision != 0 && expectedRevision != otp.Revision {
+		return entities.ErrRevisionMismatch
+	}
+	deletedAt := time.Now()
+	otp.DeletedAt = &deletedAt
+	otp.Revision++
+	return nil
+}
+
+func (r *fakeOTPRepository) ListTrash(_ context.Context, _ uuid.UUID) ([]*entities.OTP, error) {
+	var trash []*entities.OTP
+	for _, otp := range r.stored {
+		if otp.DeletedAt != nil {
+			stored := *otp
+			trash = append(trash, &stored)
+		}
+	}
+	sort.Slice(trash, func(i, j int) bool { return trash[i].DeletedAt.After(*trash[j].DeletedAt) })
+	return trash, nil
+}
+
+func (r *fakeOTPRepository) Restore(_ context.Context, id uuid.UUID, userID uuid.UUID) (*entities.OTP, error) {
+	otp := r.find(id, userID, true)
+	if otp == nil {
+		return nil, entities.ErrTOTPSeedNotFound
+	}
+	otp.DeletedAt = nil
+	otp.Revision++
+	stored := *otp
+	return &stored, nil
+}
+
+func (r *fakeOTPRepository) Purge(_ context.Context, id uuid.UUID, userID uuid.UUID) error {
+	otp := r.find(id, userID, true)
+	if otp == nil {
+		return entities.ErrTOTPSeedNotFound
+	}
+	r.remove(func(stored *entities.OTP) bool { return stored == otp })
+	return nil
+}
+
+func (r *fakeOTPRepository) PurgeExpired(_ context.Context, before time.Time) (int64, error) {
+	r.purgedBefore = before
+	return r.remove(func(otp *entities.OTP) bool { return otp.DeletedAt != nil && otp.DeletedAt.Before(before) }), nil
+}
+
+// find returns the user's active entry, or trashed entry when trashed is set
+func (r *fakeOTPRepository) find(id uuid.UUID, userID uuid.UUID, trashed bool) *entities.OTP {
+	for _, otp := range r.stored {
+		if otp.ID == id && otp.UserID == userID && (otp.DeletedAt != nil) == trashed {
+			return otp
+		}
+	}
+	return nil
+}
+
+// remove deletes the entries matching purge and returns how many there were
+func (r *fakeOTPRepository) remove(purge func(*entities.OTP) bool) int64 {
+	var kept []*entities.OTP
+	for _, otp := range r.stored {
+		if !purge(otp) {
+			kept = append(kept, otp)
+		}
+	}
+	removed := int64(len(r.stored) - len(kept))
+	r.stored = kept
+	return removed
+}
+
+func (r *fakeOTPRepository) ApplyBatch(_ context.Context, _ uuid.UUID, operations []*entities.OTPBatchOperation) ([]*entities.OTPBatchResult, error) {
+	r.applied = operations
+	results := make([]*entities.OTPBatchResult, len(operations))
+	for i, op := range operations {
+		results[i] = &entities.OTPBatchResult{Index: i, Type: op.Type, ID: op.OTP.ID, Status: entities.OTPBatchStatusOK, OTP: op.OTP}
+	}
+	return results, nil
+}
+
+func (r *fakeOTPRepository) Revert(_ context.Context, id uuid.UUID, userID uuid.UUID, revisionID uuid.UUID, keyVersion int) (*entities.OTP, error) {
+	r.revertedWith = keyVersion
+	otp := r.find(id, userID, false)
+	if otp == nil {
+		return nil, entities.ErrTOTPSeedNotFound
+	}
+	var revision *entities.OTPRevision
+	for _, rev := range r.revisions {
+		if rev.ID == revisionID && rev.OTPID == id {
+			revision = rev
+		}
+	}
+	if revision == nil {
+		return nil, entities.ErrRevisionNotFound
+	}
+	if revision.KeyVersion != keyVersion {
+		return nil, entities.ErrRevisionKeyRetired
+	}
+
+	r.recordRevision(otp, entities.OTPRevisionRevert)
+	otp.Issuer, otp.Label, otp.Secret, otp.Tags, otp.FolderID = revision.Issuer, revision.Label, revision.Secret, revision.Tags, revision.FolderID
+	otp.KeyVersion = revision.KeyVersion
+	otp.Revision++
+	reverted := *otp
+	return &reverted, nil
+}
+
+// fakeKeyRepository reports the user's current key version and active wraps
+type fakeKeyRepository struct {
+	interfaces.EncryptionKeyRepository
+	version int
+	keys    []*entities.UserEncryptionKey
+}
+
+func (r *fakeKeyRepository) GetLatestVersion(_ context.Context, _ uuid.UUID) (int, error) {
+	return r.version, nil
+}
+
+func (r *fakeKeyRepository) GetAllActiveByUserID(_ context.Context, _ uuid.UUID) ([]*entities.UserEncryptionKey, error) {
+	return r.keys, nil
+}
+
+// emptyIssuerCatalog knows no issuers
+type emptyIssuerCatalog struct {
+	interfaces.IssuerCatalog
+}
+
+func (emptyIssuerCatalog) Lookup(string) (*entities.Issuer, bool) {
+	return nil, false
+}
+
+// encryptedSecret returns a secret envelope for keyVersion
+func encryptedSecret(keyVersion int) string {
+	envelope := &entities.SecretEnvelope{
+		Format:     entities.SecretEnvelopeFormat,
+		Suite:      entities.CipherSuiteAES256GCM,
+		KeyVersion: keyVersion,
+		IV:         bytes.Repeat([]byte{1}, 12),
+		AuthTag:    bytes.Repeat([]byte{2}, 16),
+		Ciphertext: []byte("ciphertext"),
+	}
+	return envelope.String()
+}
+
+// revision returns a batch operation's base revision
+func revision(r int64) *int64 {
+	return &r
+}
+
+func TestApplyOTPBatch_Validation(t *testing.T) {
+	folder := &entities.Folder{ID: uuid.New(), Name: "Work"}
+	unknownFolder := uuid.New()
+	topLevel := uuid.Nil
+	entryID := uuid.New()
+
+	create := func() *entities.OTPBatchOperation {
+		return &entities.OTPBatchOperation{Type: entities.OTPBatchCreate, Issuer: "GitHub", Label: "work", Secret: encryptedSecret(2)}
+	}
+	update := func(id uuid.UUID, rev *int64) *entities.OTPBatchOperation {
+		return &entities.OTPBatchOperation{Type: entities.OTPBatchUpdate, ID: id, Revision: rev, Issuer: "GitHub", Label: "work", Secret: encryptedSecret(2)}
+	}
+	inactivate := func(id uuid.UUID, rev *int64) *entities.OTPBatchOperation {
+		return &entities.OTPBatchOperation{Type: entities.OTPBatchInactivate, ID: id, Revision: rev}
+	}
+	withFolder := func(op *entities.OTPBatchOperation, folderID *uuid.UUID) *entities.OTPBatchOperation {
+		op.FolderID = folderID
+		return op
+	}
+	withSecret := func(op *entities.OTPBatchOperation, secret string) *entities.OTPBatchOperation {
+		op.Secret = secret
+		return op
+	}
+
+	tests := []struct {
+		name       string
+		operations []*entities.OTPBatchOperation
+		statuses   []string // Empty when the batch reaches the repository
+		errorAt    int
+		error      string
+	}{
+		{
+			name:       "valid",
+			operations: []*entities.OTPBatchOperation{create(), update(uuid.New(), revision(3)), inactivate(uuid.New(), revision(0))},
+		},
+		{
+			name:       "known folder and top level",
+			operations: []*entities.OTPBatchOperation{withFolder(create(), &folder.ID), withFolder(update(entryID, revision(1)), &topLevel)},
+		},
+		{
+			name:       "unknown folder",
+			operations: []*entities.OTPBatchOperation{create(), withFolder(update(entryID, revision(1)), &unknownFolder)},
+			statuses:   []string{entities.OTPBatchStatusSkipped, entities.OTPBatchStatusFailed},
+			errorAt:    1,
+			error:      "does not exist",
+		},
+		{
+			name:       "duplicate ID",
+			operations: []*entities.OTPBatchOperation{update(entryID, revision(1)), create(), inactivate(entryID, revision(1))},
+			statuses:   []string{entities.OTPBatchStatusSkipped, entities.OTPBatchStatusSkipped, entities.OTPBatchStatusFailed},
+			errorAt:    2,
+			error:      "already modified by operation 0",
+		},
+		{
+			name:       "missing ID",
+			operations: []*entities.OTPBatchOperation{inactivate(uuid.Nil, revision(1))},
+			statuses:   []string{entities.OTPBatchStatusFailed},
+			error:      "id is required",
+		},
+		{
+			name:       "missing revision",
+			operations: []*entities.OTPBatchOperation{create(), update(entryID, nil)},
+			statuses:   []string{entities.OTPBatchStatusSkipped, entities.OTPBatchStatusFailed},
+			errorAt:    1,
+			error:      "revision is required",
+		},
+		{
+			name:       "stale key version",
+			operations: []*entities.OTPBatchOperation{withSecret(create(), encryptedSecret(1))},
+			statuses:   []string{entities.OTPBatchStatusFailed},
+			error:      "key version 1, expected 2",
+		},
+		{
+			name:       "unsupported operation",
+			operations: []*entities.OTPBatchOperation{{Type: "purge", ID: entryID}},
+			statuses:   []string{entities.OTPBatchStatusFailed},
+			error:      "unsupported operation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otpRepo := &fakeOTPRepository{}
+			service := NewOTPService(otpRepo, &fakeFolderRepository{folders: []*entities.Folder{folder}}, &fakeKeyRepository{version: 2},
+				nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 3, 0)
+
+			results, err := service.ApplyOTPBatch(context.Background(), uuid.New(), tt.operations)
+			if len(tt.statuses) == 0 {
+				require.NoError(t, err)
+				require.Len(t, otpRepo.applied, len(tt.operations))
+				for i, op := range otpRepo.applied {
+					require.NotNil(t, op.OTP)
+					assert.Equal(t, results[i].ID, op.OTP.ID)
+				}
+				return
+			}
+
+			assert.ErrorIs(t, err, entities.ErrOTPBatchFailed)
+			assert.Nil(t, otpRepo.applied, "an invalid batch never reaches the repository")
+			require.Len(t, results, len(tt.statuses))
+			for i, status := range tt.statuses {
+				assert.Equal(t, i, results[i].Index)
+				assert.Equal(t, status, results[i].Status, "operation %d", i)
+			}
+			assert.Contains(t, results[tt.errorAt].Error, tt.error)
+		})
+	}
+}
+
+func TestApplyOTPBatch_Limit(t *testing.T) {
+	otpRepo := &fakeOTPRepository{}
+	service := NewOTPService(otpRepo, &fakeFolderRepository{}, &fakeKeyRepository{version: 1},
+		nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 2, 0)
+
+	operations := []*entities.OTPBatchOperation{
+		{Type: entities.OTPBatchInactivate, ID: uuid.New(), Revision: revision(0)},
+		{Type: entities.OTPBatchInactivate, ID: uuid.New(), Revision: revision(0)},
+	}
+	_, err := service.ApplyOTPBatch(context.Background(), uuid.New(), operations)
+	require.NoError(t, err)
+
+	operations = append(operations, &entities.OTPBatchOperation{Type: entities.OTPBatchInactivate, ID: uuid.New(), Revision: revision(0)})
+	results, err := service.ApplyOTPBatch(context.Background(), uuid.New(), operations)
+	assert.ErrorIs(t, err, entities.ErrOTPBatchTooLarge)
+	assert.Nil(t, results)
+}
+
+func TestRevertOTP_KeyVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		latest      int // Latest stored key version; 0 before any key is stored
+		revisionKey int // Key version the revision is encrypted with
+		keyVersion  int // Current key version the revision is checked against
+		err         error
+	}{
+		{name: "no stored key", latest: 0, revisionKey: 1, keyVersion: 1},
+		{name: "rotated key", latest: 3, revisionKey: 3, keyVersion: 3},
+		{name: "retired revision", latest: 2, revisionKey: 1, keyVersion: 2, err: entities.ErrRevisionKeyRetired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			otp := &entities.OTP{ID: uuid.New(), UserID: userID, Label: "current", KeyVersion: tt.keyVersion, Revision: 2}
+			revision := &entities.OTPRevision{ID: uuid.New(), OTPID: otp.ID, Label: "past", KeyVersion: tt.revisionKey, Revision: 1}
+			otpRepo := &fakeOTPRepository{stored: []*entities.OTP{otp}, revisions: []*entities.OTPRevision{revision}}
+			service := NewOTPService(otpRepo, &fakeFolderRepository{}, &fakeKeyRepository{version: tt.latest},
+				nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 1, 0)
+
+			reverted, err := service.RevertOTP(context.Background(), otp.ID, userID, revision.ID)
+			assert.Equal(t, tt.keyVersion, otpRepo.revertedWith, "the repository checks the revision against the current key")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, reverted)
+				assert.Equal(t, "current", otp.Label, "a refused revert changes nothing")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "past", reverted.Label)
+			assert.Equal(t, tt.keyVersion, reverted.KeyVersion)
+		})
+	}
+}
+
+// revisionFixture is an entry edited twice, first to "second" and then to "third"
+type revisionFixture struct {
+	userID  uuid.UUID
+	otp     *entities.OTP
+	otpRepo *fakeOTPRepository
+	service interfaces.OTPService
+}
+
+func newRevisionFixture(t *testing.T) *revisionFixture {
+	t.Helper()
+
+	f := &revisionFixture{userID: uuid.New(), otpRepo: &fakeOTPRepository{}}
+	f.service = NewOTPService(f.otpRepo, &fakeFolderRepository{}, &fakeKeyRepository{version: 1},
+		nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 1, 0)
+
+	var err error
+	f.otp, err = f.service.CreateOTP(context.Background(), f.userID, "GitHub", "first", encryptedSecret(1), 0, "", 0, "", 0, "", 0, []string{"work"}, nil)
+	require.NoError(t, err)
+	for _, label := range []string{"second", "third"} {
+		_, err := f.service.UpdateOTP(context.Background(), f.otp.ID, f.userID, "GitHub", label, encryptedSecret(1), 0, "", 0, "", 0, nil, nil, 0)
+		require.NoError(t, err)
+	}
+	return f
+}
+
+func TestListRevisions(t *testing.T) {
+	f := newRevisionFixture(t)
+
+	revisions, err := f.service.ListRevisions(context.Background(), f.otp.ID, f.userID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "second", revisions[0].Label, "newest first")
+	assert.Equal(t, "first", revisions[1].Label)
+	for _, revision := range revisions {
+		assert.Equal(t, entities.OTPRevisionUpdate, revision.Reason)
+		assert.Equal(t, []string{"work"}, revision.Tags, "tags are kept when an update leaves them out")
+	}
+
+	_, err = f.service.ListRevisions(context.Background(), f.otp.ID, uuid.New())
+	assert.ErrorIs(t, err, entities.ErrTOTPSeedNotFound, "only the owner sees an entry's history")
+
+	require.NoError(t, f.service.DeleteOTP(context.Background(), f.otp.ID, f.userID, 0))
+	_, err = f.service.ListRevisions(context.Background(), f.otp.ID, f.userID)
+	assert.ErrorIs(t, err, entities.ErrTOTPSeedNotFound, "trashed entries must be restored first")
+}
+
+func TestRevertOTP(t *testing.T) {
+	f := newRevisionFixture(t)
+	revisions, err := f.service.ListRevisions(context.Background(), f.otp.ID, f.userID)
+	require.NoError(t, err)
+	first := revisions[1]
+
+	reverted, err := f.service.RevertOTP(context.Background(), f.otp.ID, f.userID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "first", reverted.Label)
+	assert.Equal(t, int64(4), reverted.Revision, "a revert is a new change, not a rewind")
+
+	// The replaced version is recorded, so the revert can itself be undone
+	revisions, err = f.service.ListRevisions(context.Background(), f.otp.ID, f.userID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, "third", revisions[0].Label)
+	assert.Equal(t, entities.OTPRevisionRevert, revisions[0].Reason)
+
+	reverted, err = f.service.RevertOTP(context.Background(), f.otp.ID, f.userID, revisions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "third", reverted.Label)
+}
+
+func TestRevertOTP_NotFound(t *testing.T) {
+	f := newRevisionFixture(t)
+	revisions, err := f.service.ListRevisions(context.Background(), f.otp.ID, f.userID)
+	require.NoError(t, err)
+
+	other, err := f.service.CreateOTP(context.Background(), f.userID, "GitLab", "other", encryptedSecret(1), 0, "", 0, "", 0, "", 0, nil, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		otpID      uuid.UUID
+		userID     uuid.UUID
+		revisionID uuid.UUID
+		err        error
+	}{
+		{name: "unknown revision", otpID: f.otp.ID, userID: f.userID, revisionID: uuid.New(), err: entities.ErrRevisionNotFound},
+		{name: "another entry's revision", otpID: other.ID, userID: f.userID, revisionID: revisions[0].ID, err: entities.ErrRevisionNotFound},
+		{name: "another user's entry", otpID: f.otp.ID, userID: uuid.New(), revisionID: revisions[0].ID, err: entities.ErrTOTPSeedNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reverted, err := f.service.RevertOTP(context.Background(), tt.otpID, tt.userID, tt.revisionID)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Nil(t, reverted)
+		})
+	}
+
+	current, err := f.otpRepo.GetByID(context.Background(), f.otp.ID, f.userID)
+	require.NoError(t, err)
+	assert.Equal(t, "third", current.Label)
+}
+
+func TestOTPFolder(t *testing.T) {
+	userID := uuid.New()
+	folder := &entities.Folder{ID: uuid.New(), UserID: userID, Name: "Work"}
+	othersFolder := &entities.Folder{ID: uuid.New(), UserID: uuid.New(), Name: "Work"}
+	topLevel := uuid.Nil
+	unknown := uuid.New()
+
+	tests := []struct {
+		name     string
+		folderID *uuid.UUID
+		filed    *uuid.UUID // Folder the entry ends up in
+		err      error
+	}{
+		{name: "top level", folderID: &topLevel},
+		{name: "own folder", folderID: &folder.ID, filed: &folder.ID},
+		{name: "unknown folder", folderID: &unknown, err: entities.ErrFolderNotFound},
+		{name: "another user's folder", folderID: &othersFolder.ID, err: entities.ErrFolderNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otpRepo := &fakeOTPRepository{}
+			service := NewOTPService(otpRepo, &fakeFolderRepository{folders: []*entities.Folder{folder, othersFolder}}, &fakeKeyRepository{version: 1},
+				nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 1, 0)
+
+			created, err := service.CreateOTP(context.Background(), userID, "GitHub", "alice", encryptedSecret(1), 0, "", 0, "", 0, "", 0, nil, tt.folderID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.NotErrorIs(t, err, entities.ErrInvalidTOTPSeed, "a missing folder is not an invalid entry")
+				assert.Empty(t, otpRepo.stored)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.filed, created.FolderID)
+			}
+
+			// Moving an existing entry checks the folder the same way
+			existing := &entities.OTP{ID: uuid.New(), UserID: userID, Issuer: "GitHub", Label: "bob", FolderID: &folder.ID}
+			otpRepo.stored = append(otpRepo.stored, existing)
+			updated, err := service.UpdateOTP(context.Background(), existing.ID, userID, "GitHub", "bob", encryptedSecret(1), 0, "", 0, "", 0, nil, tt.folderID, 0)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.filed, updated.FolderID)
+		})
+	}
+}
+
+// newTrashService returns an OTP service over otpRepo that purges the trash after retention
+func newTrashService(otpRepo *fakeOTPRepository, retention time.Duration) interfaces.OTPService {
+	return NewOTPService(otpRepo, &fakeFolderRepository{}, &fakeKeyRepository{version: 1},
+		nil, totp.NewTOTPService(), emptyIssuerCatalog{}, 1, retention)
+}
+
+// storedOTP returns an entry at revision 3, trashed deletedAgo ago unless deletedAgo is 0
+func storedOTP(userID uuid.UUID, deletedAgo time.Duration) *entities.OTP {
+	otp := &entities.OTP{ID: uuid.New(), UserID: userID, Issuer: "GitHub", Label: "alice", Revision: 3}
+	if deletedAgo > 0 {
+		deletedAt := time.Now().Add(-deletedAgo)
+		otp.DeletedAt = &deletedAt
+	}
+	return otp
+}
+
+func TestDeleteOTP(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name     string
+		owner    uuid.UUID
+		trashed  bool
+		revision int64
+		err      error
+	}{
+		{name: "current revision", owner: userID, revision: 3},
+		{name: "any revision", owner: userID, revision: 0},
+		{name: "stale revision", owner: userID, revision: 2, err: entities.ErrRevisionMismatch},
+		{name: "already trashed", owner: userID, trashed: true, revision: 3, err: entities.ErrTOTPSeedNotFound},
+		{name: "another user's entry", owner: uuid.New(), revision: 3, err: entities.ErrTOTPSeedNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otp := storedOTP(tt.owner, 0)
+			if tt.trashed {
+				otp = storedOTP(tt.owner, time.Hour)
+			}
+			otpRepo := &fakeOTPRepository{stored: []*entities.OTP{otp}}
+			service := newTrashService(otpRepo, 0)
+
+			err := service.DeleteOTP(context.Background(), otp.ID, userID, tt.revision)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Equal(t, tt.trashed, otp.DeletedAt != nil, "a refused delete changes nothing")
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, otp.DeletedAt, "the entry moves to the trash")
+
+			trash, err := service.ListTrash(context.Background(), userID)
+			require.NoError(t, err)
+			require.Len(t, trash, 1)
+			assert.Equal(t, otp.ID, trash[0].ID)
+		})
+	}
+}
+
+func TestRestoreAndPurgeOTP(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		trashed bool
+		owner   uuid.UUID
+		err     error
+	}{
+		{name: "trashed", trashed: true, owner: userID},
+		{name: "active", owner: userID, err: entities.ErrTOTPSeedNotFound},
+		{name: "another user's trash", trashed: true, owner: uuid.New(), err: entities.ErrTOTPSeedNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newEntry := func() *entities.OTP {
+				if tt.trashed {
+					return storedOTP(tt.owner, time.Hour)
+				}
+				return storedOTP(tt.owner, 0)
+			}
+
+			t.Run("restore", func(t *testing.T) {
+				otp := newEntry()
+				otpRepo := &fakeOTPRepository{stored: []*entities.OTP{otp}}
+
+				restored, err := newTrashService(otpRepo, 0).RestoreOTP(context.Background(), otp.ID, userID)
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+					assert.Nil(t, restored)
+					return
+				}
+				require.NoError(t, err)
+				assert.Nil(t, restored.DeletedAt)
+				assert.Greater(t, restored.Revision, int64(3), "restoring is a change devices sync")
+			})
+
+			t.Run("purge", func(t *testing.T) {
+				otp := newEntry()
+				otpRepo := &fakeOTPRepository{stored: []*entities.OTP{otp}}
+
+				err := newTrashService(otpRepo, 0).PurgeOTP(context.Background(), otp.ID, userID)
+				if tt.err != nil {
+					assert.ErrorIs(t, err, tt.err)
+					assert.Len(t, otpRepo.stored, 1, "only trashed entries can be purged")
+					return
+				}
+				require.NoError(t, err)
+				assert.Empty(t, otpRepo.stored)
+			})
+		})
+	}
+}
+
+func TestListTrash_PurgeAt(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+	}{
+		{name: "kept until purged by hand", retention: 0},
+		{name: "purged after 30 days", retention: 30 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older, newer := storedOTP(userID, 48*time.Hour), storedOTP(userID, time.Hour)
+			otpRepo := &fakeOTPRepository{stored: []*entities.OTP{older, storedOTP(userID, 0), newer}}
+
+			trash, err := newTrashService(otpRepo, tt.retention).ListTrash(context.Background(), userID)
+			require.NoError(t, err)
+			require.Len(t, trash, 2, "active entries are not listed")
+			assert.Equal(t, newer.ID, trash[0].ID, "most recently deleted first")
+			assert.Equal(t, older.ID, trash[1].ID)
+
+			for _, otp := range trash {
+				if tt.retention == 0 {
+					assert.Nil(t, otp.PurgeAt)
+					continue
+				}
+				require.NotNil(t, otp.PurgeAt)
+				assert.Equal(t, otp.DeletedAt.Add(tt.retention), *otp.PurgeAt)
+			}
+		})
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		purged    int64
+	}{
+		{name: "kept until purged by hand", retention: 0, purged: 0},
+		{name: "one day", retention: 24 * time.Hour, purged: 1},
+		{name: "one week", retention: 7 * 24 * time.Hour, purged: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otpRepo := &fakeOTPRepository{stored: []*entities.OTP{storedOTP(userID, 48*time.Hour), storedOTP(userID, time.Hour), storedOTP(userID, 0)}}
+
+			purged, err := newTrashService(otpRepo, tt.retention).PurgeExpiredTrash(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.purged, purged)
+			assert.Len(t, otpRepo.stored, 3-int(tt.purged))
+
+			if tt.retention == 0 {
+				assert.True(t, otpRepo.purgedBefore.IsZero(), "nothing is purged without a retention period")
+				return
+			}
+			assert.WithinDuration(t, time.Now().Add(-tt.retention), otpRepo.purgedBefore, time.Minute)
+		})
+	}
+}
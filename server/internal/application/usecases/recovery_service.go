@@ -0,0 +1,187 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// recoveryService implements the domain recovery service interface
+type recoveryService struct {
+	kitRepo        interfaces.RecoveryKitRepository
+	keyRepo        interfaces.EncryptionKeyRepository
+	credentialRepo interfaces.WebAuthnCredentialRepository
+	cryptoService  interfaces.CryptoService
+	maxAttempts    int
+	lockout        time.Duration
+}
+
+// NewRecoveryService creates a new recovery service. A kit locks for lockout after maxAttempts
+// failed redemptions in a row.
+func NewRecoveryService(kitRepo interfaces.RecoveryKitRepository, keyRepo interfaces.EncryptionKeyRepository, credentialRepo interfaces.WebAuthnCredentialRepository, cryptoService interfaces.CryptoService, maxAttempts int, lockout time.Duration) interfaces.RecoveryService {
+	return &recoveryService{
+		kitRepo:        kitRepo,
+		keyRepo:        keyRepo,
+		credentialRepo: credentialRepo,
+		cryptoService:  cryptoService,
+		maxAttempts:    maxAttempts,
+		lockout:        lockout,
+	}
+}
+
+// GetKit returns the user's usable kit
+func (s *recoveryService) GetKit(ctx context.Context, userID uuid.UUID) (*entities.RecoveryKit, error) {
+	kit, err := s.kitRepo.GetActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// An expired lock is cleared by the next attempt; don't report it meanwhile
+	if !kit.IsLocked(time.Now()) {
+		kit.LockedUntil = nil
+	}
+
+	return kit, nil
+}
+
+// CreateKit stores the user's first kit
+func (s *recoveryService) CreateKit(ctx context.Context, userID uuid.UUID, code string, encryptedBackup []byte) (*entities.RecoveryKit, error) {
+	kit, err := s.newKit(ctx, userID, code, encryptedBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.kitRepo.Create(ctx, kit); err != nil {
+		return nil, err
+	}
+
+	return kit, nil
+}
+
+// RegenerateKit replaces the user's kit with a new one
+func (s *recoveryService) RegenerateKit(ctx context.Context, userID uuid.UUID, code string, encryptedBackup []byte) (*entities.RecoveryKit, error) {
+	kit, err := s.newKit(ctx, userID, code, encryptedBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.kitRepo.Replace(ctx, kit); err != nil {
+		return nil, err
+	}
+
+	return kit, nil
+}
+
+// RedeemKit checks a recovery code and returns the wrapped key of the user's kit
+func (s *recoveryService) RedeemKit(ctx context.Context, userID uuid.UUID, code string) (*entities.RecoveryRedemption, error) {
+	kit, err := s.verify(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.RecoveryRedemption{
+		KitID:           kit.ID,
+		KeyVersion:      kit.KeyVersion,
+		EncryptedBackup: kit.EncryptedBackup,
+	}, nil
+}
+
+// Reregister stores a new passkey's wrap of the vault key and swaps the spent kit for a new one
+func (s *recoveryService) Reregister(ctx context.Context, userID uuid.UUID, code string, key *entities.UserEncryptionKey, newCode string, newEncryptedBackup []byte) (*entities.RecoveryReregistration, error) {
+	if err := checkCredentialOwner(ctx, s.credentialRepo, userID, key.CredentialID); err != nil {
+		return nil, err
+	}
+
+	replacement, err := s.newKit(ctx, userID, newCode, newEncryptedBackup)
+	if err != nil {
+		return nil, err
+	}
+
+	kit, err := s.verify(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// The new passkey wraps the key the kit unlocked
+	key.UserID = userID
+	key.KeyVersion = kit.KeyVersion
+	key.Activate()
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.kitRepo.Redeem(ctx, kit, key, replacement); err != nil {
+		return nil, err
+	}
+
+	return &entities.RecoveryReregistration{Key: key, Kit: replacement}, nil
+}
+
+// newKit validates a recovery code and wrapped key and builds a kit for the current key version
+func (s *recoveryService) newKit(ctx context.Context, userID uuid.UUID, code string, encryptedBackup []byte) (*entities.RecoveryKit, error) {
+	if err := entities.ValidateRecoveryCode(code); err != nil {
+		return nil, err
+	}
+
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.cryptoService.HashSecret([]byte(code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+	}
+
+	kit := entities.NewRecoveryKit(userID, keyVersion, encryptedBackup, hash)
+	if err := kit.Validate(); err != nil {
+		return nil, err
+	}
+
+	return kit, nil
+}
+
+// verify checks a recovery code against the user's kit. Every attempt counts against the kit's
+// limit until the right code clears it.
+func (s *recoveryService) verify(ctx context.Context, userID uuid.UUID, code string) (*entities.RecoveryKit, error) {
+	if err := entities.ValidateRecoveryCode(code); err != nil {
+		return nil, err
+	}
+
+	kit, err := s.kitRepo.GetActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A kit made before a key rotation wraps a key the vault no longer uses
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	if kit.KeyVersion != keyVersion {
+		return nil, fmt.Errorf("%w: the kit wraps key version %d but the vault is on %d", entities.ErrInvalidBackup, kit.KeyVersion, keyVersion)
+	}
+
+	kit, err = s.kitRepo.RecordAttempt(ctx, kit, s.maxAttempts, time.Now().Add(s.lockout))
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.cryptoService.VerifySecret([]byte(code), kit.CodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify recovery code: %w", err)
+	}
+	if !ok {
+		return nil, entities.ErrRecoveryFailed
+	}
+
+	if err := s.kitRepo.ResetAttempts(ctx, kit); err != nil {
+		return nil, err
+	}
+
+	return kit, nil
+}
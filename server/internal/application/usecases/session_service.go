@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// sessionService implements the domain session service interface
+type sessionService struct {
+	sessionRepo      interfaces.SessionRepository
+	idleTimeout      time.Duration
+	absoluteTimeout  time.Duration
+	activityInterval time.Duration
+}
+
+// NewSessionService creates a new session service. Sessions end after idleTimeout without use
+// and absoluteTimeout after sign-in; activity is written at most once per activityInterval.
+func NewSessionService(sessionRepo interfaces.SessionRepository, idleTimeout, absoluteTimeout, activityInterval time.Duration) interfaces.SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		idleTimeout:      idleTimeout,
+		absoluteTimeout:  absoluteTimeout,
+		activityInterval: activityInterval,
+	}
+}
+
+// CreateSession starts a session for the client described by the request info in ctx
+func (s *sessionService) CreateSession(ctx context.Context, userID uuid.UUID) (*entities.DeviceSession, error) {
+	// Sign-ins are rare enough to clear out the user's timed-out sessions on
+	if err := s.sessionRepo.DeleteExpired(ctx, userID, time.Now().Add(-s.idleTimeout)); err != nil {
+		return nil, err
+	}
+
+	session := entities.NewDeviceSession(userID, entities.RequestInfoFromContext(ctx), s.absoluteTimeout)
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	session.SetIdleExpiry(s.idleTimeout)
+
+	return session, nil
+}
+
+// GetSession returns a usable session of the user
+func (s *sessionService) GetSession(ctx context.Context, userID, sessionID uuid.UUID) (*entities.DeviceSession, error) {
+	session, err := s.sessionRepo.GetByID(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.CheckActive(time.Now(), s.idleTimeout); err != nil {
+		return nil, err
+	}
+	session.SetIdleExpiry(s.idleTimeout)
+
+	return session, nil
+}
+
+// TouchSession checks the session and records activity on it
+func (s *sessionService) TouchSession(ctx context.Context, userID, sessionID uuid.UUID) (*entities.DeviceSession, error) {
+	session, err := s.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Writing on every request would cost a row update each; activity only needs to be
+	// accurate to well within the idle timeout
+	now := time.Now()
+	if now.Sub(session.LastActivityAt) < s.activityInterval {
+		return session, nil
+	}
+
+	ipAddress := entities.RequestInfoFromContext(ctx).IPAddress
+	if err := s.sessionRepo.Touch(ctx, userID, sessionID, ipAddress); err != nil {
+		return nil, fmt.Errorf("failed to record session activity: %w", err)
+	}
+	session.LastActivityAt = now
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
+	}
+	session.SetIdleExpiry(s.idleTimeout)
+
+	return session, nil
+}
+
+// ListSessions returns the user's usable sessions, most recently active first
+func (s *sessionService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.DeviceSession, error) {
+	sessions, err := s.sessionRepo.List(ctx, userID, time.Now().Add(-s.idleTimeout))
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.SetIdleExpiry(s.idleTimeout)
+	}
+
+	return sessions, nil
+}
+
+// RenameSession changes a session's device name
+func (s *sessionService) RenameSession(ctx context.Context, userID, sessionID uuid.UUID, name string) (*entities.DeviceSession, error) {
+	name, err := entities.ValidateSessionName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Rename(ctx, userID, sessionID, name); err != nil {
+		return nil, err
+	}
+
+	return s.GetSession(ctx, userID, sessionID)
+}
+
+// DeleteSession revokes one session
+func (s *sessionService) DeleteSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	return s.sessionRepo.Delete(ctx, userID, sessionID)
+}
+
+// DeleteOtherSessions revokes every session of the user except keep
+func (s *sessionService) DeleteOtherSessions(ctx context.Context, userID, keep uuid.UUID) ([]uuid.UUID, error) {
+	return s.sessionRepo.DeleteOthers(ctx, userID, keep)
+}
+
+// DeleteUserSessions revokes every session of the user
+func (s *sessionService) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.sessionRepo.DeleteAll(ctx, userID)
+	return err
+}
@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// fakeSessionRepository stores sessions by ID and counts activity writes
+type fakeSessionRepository struct {
+	interfaces.SessionRepository
+	sessions map[uuid.UUID]*entities.DeviceSession
+	touches  int
+}
+
+func (r *fakeSessionRepository) GetByID(_ context.Context, userID, id uuid.UUID) (*entities.DeviceSession, error) {
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID {
+		return nil, entities.ErrSessionNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepository) Touch(_ context.Context, userID, id uuid.UUID, ipAddress string) error {
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID {
+		return entities.ErrSessionNotFound
+	}
+	r.touches++
+	session.LastActivityAt = time.Now()
+	if ipAddress != "" {
+		session.IPAddress = ipAddress
+	}
+	return nil
+}
+
+func (r *fakeSessionRepository) DeleteOthers(_ context.Context, userID, keep uuid.UUID) ([]uuid.UUID, error) {
+	deleted := []uuid.UUID{}
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != keep {
+			delete(r.sessions, id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+// storeSession adds a session of userID last used idle ago and signed in age ago
+func (r *fakeSessionRepository) storeSession(userID uuid.UUID, idle, age time.Duration) *entities.DeviceSession {
+	now := time.Now()
+	session := &entities.DeviceSession{
+		ID:             uuid.New(),
+		UserID:         userID,
+		LastActivityAt: now.Add(-idle),
+		CreatedAt:      now.Add(-age),
+		ExpiresAt:      now.Add(-age).Add(30 * 24 * time.Hour),
+	}
+	r.sessions[session.ID] = session
+	return session
+}
+
+func newSessionFixture() (*fakeSessionRepository, interfaces.SessionService) {
+	repo := &fakeSessionRepository{sessions: make(map[uuid.UUID]*entities.DeviceSession)}
+	// Idle for a week or signed in for thirty days ends a session; activity is written every minute
+	return repo, NewSessionService(repo, 7*24*time.Hour, 30*24*time.Hour, time.Minute)
+}
+
+func TestGetSession_Expiry(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name  string
+		idle  time.Duration
+		age   time.Duration
+		error error // nil when the session is usable
+	}{
+		{name: "active", idle: time.Hour, age: 24 * time.Hour},
+		{name: "idle", idle: 8 * 24 * time.Hour, age: 10 * 24 * time.Hour, error: entities.ErrSessionExpired},
+		{name: "absolute expiry", idle: time.Minute, age: 31 * 24 * time.Hour, error: entities.ErrSessionExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, service := newSessionFixture()
+			stored := repo.storeSession(userID, tt.idle, tt.age)
+
+			session, err := service.GetSession(context.Background(), userID, stored.ID)
+			if tt.error != nil {
+				assert.ErrorIs(t, err, tt.error)
+				assert.Nil(t, session)
+
+				_, err = service.TouchSession(context.Background(), userID, stored.ID)
+				assert.ErrorIs(t, err, tt.error)
+				assert.Zero(t, repo.touches, "an ended session is not kept alive")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.False(t, session.IdleExpiresAt.After(session.ExpiresAt))
+			assert.Equal(t, stored.LastActivityAt.Add(7*24*time.Hour), session.IdleExpiresAt)
+		})
+	}
+
+	_, service := newSessionFixture()
+	_, err := service.GetSession(context.Background(), userID, uuid.New())
+	assert.ErrorIs(t, err, entities.ErrSessionNotFound)
+}
+
+func TestTouchSession_ActivityInterval(t *testing.T) {
+	userID := uuid.New()
+	repo, service := newSessionFixture()
+	recent := repo.storeSession(userID, 10*time.Second, time.Hour)
+	stale := repo.storeSession(userID, 2*time.Minute, time.Hour)
+
+	// Activity within the interval is not written again
+	_, err := service.TouchSession(context.Background(), userID, recent.ID)
+	require.NoError(t, err)
+	assert.Zero(t, repo.touches)
+
+	ctx := entities.ContextWithRequestInfo(context.Background(), entities.RequestInfo{IPAddress: "203.0.113.7"})
+	session, err := service.TouchSession(ctx, userID, stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.touches)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.WithinDuration(t, time.Now(), session.LastActivityAt, time.Second)
+
+	// The write just made starts a new interval
+	_, err = service.TouchSession(ctx, userID, stale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.touches)
+}
+
+func TestDeleteOtherSessions(t *testing.T) {
+	userID := uuid.New()
+	repo, service := newSessionFixture()
+	current := repo.storeSession(userID, 0, time.Hour)
+	other := repo.storeSession(userID, time.Hour, 2*time.Hour)
+	someoneElse := repo.storeSession(uuid.New(), 0, time.Hour)
+
+	deleted, err := service.DeleteOtherSessions(context.Background(), userID, current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{other.ID}, deleted)
+
+	_, err = service.GetSession(context.Background(), userID, current.ID)
+	assert.NoError(t, err, "the current session is kept")
+	_, err = service.GetSession(context.Background(), userID, other.ID)
+	assert.ErrorIs(t, err, entities.ErrSessionNotFound)
+	assert.Contains(t, repo.sessions, someoneElse.ID, "other users' sessions are untouched")
+}
@@ -0,0 +1,222 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// syncService implements the domain sync service interface
+type syncService struct {
+	syncRepo      interfaces.SyncRepository
+	keyRepo       interfaces.EncryptionKeyRepository
+	totpService   interfaces.TOTPService
+	issuerCatalog interfaces.IssuerCatalog
+	maxChanges    int
+}
+
+// NewSyncService creates a new sync service. maxChanges is the most changes PushChanges accepts.
+func NewSyncService(syncRepo interfaces.SyncRepository, keyRepo interfaces.EncryptionKeyRepository, totpService interfaces.TOTPService, issuerCatalog interfaces.IssuerCatalog, maxChanges int) interfaces.SyncService {
+	return &syncService{
+		syncRepo:      syncRepo,
+		keyRepo:       keyRepo,
+		totpService:   totpService,
+		issuerCatalog: issuerCatalog,
+		maxChanges:    maxChanges,
+	}
+}
+
+// Sync returns the changes since cursor and records the device's sync
+func (s *syncService) Sync(ctx context.Context, userID uuid.UUID, fingerprint, deviceName, cursor string) (*entities.SyncChanges, error) {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if fingerprint == "" || len(fingerprint) > entities.MaxDeviceFingerprintLength {
+		return nil, fmt.Errorf("%w: fingerprint must be 1 to %d characters", entities.ErrInvalidDevice, entities.MaxDeviceFingerprintLength)
+	}
+	deviceName = strings.TrimSpace(deviceName)
+	if len(deviceName) > entities.MaxDeviceFingerprintLength {
+		return nil, fmt.Errorf("%w: name must be at most %d characters", entities.ErrInvalidDevice, entities.MaxDeviceFingerprintLength)
+	}
+
+	changes, err := s.syncRepo.Changes(ctx, userID, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a sync that produced a cursor counts as the device's last sync
+	if _, err := s.syncRepo.TouchDevice(ctx, userID, fingerprint, deviceName); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// ListDevices returns the devices that have synced the user's vault
+func (s *syncService) ListDevices(ctx context.Context, userID uuid.UUID) ([]*entities.SyncDevice, error) {
+	return s.syncRepo.ListDevices(ctx, userID)
+}
+
+// PushChanges validates and applies offline edits one at a time, recording conflicts for those
+// that diverged
+func (s *syncService) PushChanges(ctx context.Context, userID uuid.UUID, changes []*entities.SyncChange) ([]*entities.SyncChangeResult, error) {
+	if len(changes) > s.maxChanges {
+		return nil, fmt.Errorf("%w: %d changes, limit is %d", entities.ErrOTPBatchTooLarge, len(changes), s.maxChanges)
+	}
+
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*entities.SyncChangeResult, len(changes))
+	seen := make(map[uuid.UUID]int)
+	for i, change := range changes {
+		result := &entities.SyncChangeResult{Index: i, Type: change.Type, ID: change.ID}
+		results[i] = result
+
+		err := s.prepareChange(change, keyVersion)
+		if err == nil {
+			// A second change to the same entry would conflict with the first
+			if first, ok := seen[change.ID]; ok {
+				err = fmt.Errorf("%w: entry is already changed by change %d", entities.ErrInvalidOperation, first)
+			}
+			seen[change.ID] = i
+		}
+		if err != nil {
+			result.Fail(err)
+			continue
+		}
+
+		// Changes are independent: one that fails does not hold back the others
+		otp, conflict, err := s.syncRepo.ApplyChange(ctx, userID, change)
+		switch {
+		case err != nil:
+			result.Fail(err)
+		case conflict != nil:
+			result.Status = entities.SyncChangeConflict
+			result.Conflict = conflict
+		default:
+			result.Status = entities.SyncChangeApplied
+			result.OTP = otp
+		}
+	}
+
+	return results, nil
+}
+
+// prepareChange validates a pushed change and builds the version it applies
+func (s *syncService) prepareChange(change *entities.SyncChange, keyVersion int) error {
+	if change.ID == uuid.Nil {
+		return fmt.Errorf("%w: id is required", entities.ErrInvalidOperation)
+	}
+	if change.BaseRevision < 1 {
+		return fmt.Errorf("%w: base revision is required", entities.ErrInvalidOperation)
+	}
+
+	switch change.Type {
+	case entities.SyncChangeUpdate:
+		return s.normalizeVersion(change.Version, keyVersion)
+	case entities.SyncChangeDelete:
+		change.Version = &entities.OTPVersion{Deleted: true}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported change %q", entities.ErrInvalidOperation, change.Type)
+	}
+}
+
+// normalizeVersion applies the issuer's and the scheme's defaults to an entry version and
+// validates it. The secret stays encrypted; only its envelope is checked, which must name
+// keyVersion, the user's current key version, and agree with the version's own if it has one.
+// A version encrypted before a key rotation committed must be re-encrypted by the device.
+func (s *syncService) normalizeVersion(version *entities.OTPVersion, keyVersion int) error {
+	if version == nil {
+		return fmt.Errorf("%w: entry is required", entities.ErrInvalidTOTPSeed)
+	}
+	if version.Issuer == "" || version.Label == "" {
+		return fmt.Errorf("%w: issuer and label are required", entities.ErrInvalidTOTPSeed)
+	}
+	envelope, err := parseEntrySecret(version.Secret, keyVersion)
+	if err != nil {
+		return err
+	}
+	if version.KeyVersion != 0 {
+		if err := envelope.CheckKeyVersion(version.KeyVersion); err != nil {
+			return err
+		}
+	}
+
+	known := lookupIssuer(s.issuerCatalog, version.Issuer)
+	params, err := normalizeCodeParams(s.totpService, known, version.Period, version.Algorithm, version.Digits, version.Type, version.T0)
+	if err != nil {
+		return err
+	}
+	tags, err := normalizeEntryTags(version.Tags)
+	if err != nil {
+		return err
+	}
+
+	version.Deleted = false
+	version.Revision = 0
+	version.Algorithm = params.Algorithm
+	version.Digits = params.Digits
+	version.Period = params.Period
+	version.Type = params.Type
+	version.T0 = params.T0
+	version.Tags = tags
+	version.FolderID = topLevelAsNil(version.FolderID)
+	version.KeyVersion = envelope.KeyVersion
+	version.IconURL = ""
+	if known != nil {
+		version.Issuer = known.Name
+		version.IconURL = known.IconURL
+	}
+
+	return nil
+}
+
+// ListConflicts returns the user's unresolved conflicts, oldest first
+func (s *syncService) ListConflicts(ctx context.Context, userID uuid.UUID) ([]*entities.SyncConflict, error) {
+	conflicts, err := s.syncRepo.ListConflicts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conflicts: %w", err)
+	}
+
+	return conflicts, nil
+}
+
+// ResolveConflict settles a conflict by keeping the server version, applying the client version,
+// or applying a merged version
+func (s *syncService) ResolveConflict(ctx context.Context, userID uuid.UUID, conflictID uuid.UUID, resolution *entities.SyncResolution) (*entities.SyncConflict, *entities.OTP, error) {
+	if resolution.ExpectedRevision < 0 {
+		return nil, nil, fmt.Errorf("%w: revision must not be negative", entities.ErrInvalidOperation)
+	}
+
+	switch resolution.Choice {
+	case entities.SyncResolutionServer:
+		// The chosen side is already stored with the conflict
+		resolution.Version = nil
+	case entities.SyncResolutionClient:
+		// So is the client's, but its secret may predate a key rotation
+		keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		resolution.Version = nil
+		resolution.KeyVersion = keyVersion
+	case entities.SyncResolutionMerged:
+		keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.normalizeVersion(resolution.Version, keyVersion); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported resolution %q", entities.ErrInvalidOperation, resolution.Choice)
+	}
+
+	return s.syncRepo.ResolveConflict(ctx, userID, conflictID, resolution)
+}
@@ -0,0 +1,167 @@
+package application
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/totp"
+)
+
+// fakeSyncRepository applies every change, records the resolutions it is given and the devices
+// that synced, and answers every sync with changes
+type fakeSyncRepository struct {
+	interfaces.SyncRepository
+	applied    []*entities.SyncChange
+	resolution *entities.SyncResolution
+	touched    []*entities.SyncDevice
+	changes    *entities.SyncChanges
+	changesErr error
+	cursor     string // Cursor passed to the last Changes
+}
+
+func (r *fakeSyncRepository) Changes(_ context.Context, _ uuid.UUID, cursor string) (*entities.SyncChanges, error) {
+	r.cursor = cursor
+	if r.changesErr != nil {
+		return nil, r.changesErr
+	}
+	return r.changes, nil
+}
+
+func (r *fakeSyncRepository) TouchDevice(_ context.Context, _ uuid.UUID, fingerprint, name string) (*entities.SyncDevice, error) {
+	device := &entities.SyncDevice{ID: uuid.New(), Fingerprint: fingerprint, Name: name}
+	r.touched = append(r.touched, device)
+	return device, nil
+}
+
+func (r *fakeSyncRepository) ApplyChange(_ context.Context, userID uuid.UUID, change *entities.SyncChange) (*entities.OTP, *entities.SyncConflict, error) {
+	r.applied = append(r.applied, change)
+	return &entities.OTP{ID: change.ID, UserID: userID, Revision: change.BaseRevision + 1}, nil, nil
+}
+
+func (r *fakeSyncRepository) ResolveConflict(_ context.Context, _ uuid.UUID, conflictID uuid.UUID, resolution *entities.SyncResolution) (*entities.SyncConflict, *entities.OTP, error) {
+	r.resolution = resolution
+	return &entities.SyncConflict{ID: conflictID}, &entities.OTP{}, nil
+}
+
+// syncVersion returns an entry version encrypted with keyVersion
+func syncVersion(keyVersion int) *entities.OTPVersion {
+	return &entities.OTPVersion{Issuer: "GitHub", Label: "work", Secret: encryptedSecret(keyVersion)}
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name        string
+		fingerprint string
+		deviceName  string
+		err         error
+		device      *entities.SyncDevice // Device recorded when the sync succeeds
+	}{
+		{name: "trimmed", fingerprint: "  laptop ", deviceName: " Work laptop ", device: &entities.SyncDevice{Fingerprint: "laptop", Name: "Work laptop"}},
+		{name: "unnamed", fingerprint: "laptop", device: &entities.SyncDevice{Fingerprint: "laptop"}},
+		{name: "blank fingerprint", fingerprint: "   ", err: entities.ErrInvalidDevice},
+		{name: "long fingerprint", fingerprint: strings.Repeat("f", entities.MaxDeviceFingerprintLength+1), err: entities.ErrInvalidDevice},
+		{name: "long name", fingerprint: "laptop", deviceName: strings.Repeat("n", entities.MaxDeviceFingerprintLength+1), err: entities.ErrInvalidDevice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncRepo := &fakeSyncRepository{changes: &entities.SyncChanges{Cursor: "next"}}
+			service := NewSyncService(syncRepo, &fakeKeyRepository{}, totp.NewTOTPService(), emptyIssuerCatalog{}, 10)
+
+			changes, err := service.Sync(context.Background(), uuid.New(), tt.fingerprint, tt.deviceName, "last")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, changes)
+				assert.Empty(t, syncRepo.touched)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "next", changes.Cursor)
+			assert.Equal(t, "last", syncRepo.cursor)
+			require.Len(t, syncRepo.touched, 1)
+			assert.Equal(t, tt.device.Fingerprint, syncRepo.touched[0].Fingerprint)
+			assert.Equal(t, tt.device.Name, syncRepo.touched[0].Name)
+		})
+	}
+}
+
+func TestSync_InvalidCursor(t *testing.T) {
+	syncRepo := &fakeSyncRepository{changesErr: entities.ErrInvalidCursor}
+	service := NewSyncService(syncRepo, &fakeKeyRepository{}, totp.NewTOTPService(), emptyIssuerCatalog{}, 10)
+
+	changes, err := service.Sync(context.Background(), uuid.New(), "laptop", "", "stale")
+	assert.ErrorIs(t, err, entities.ErrInvalidCursor)
+	assert.Nil(t, changes)
+	assert.Empty(t, syncRepo.touched, "only a sync that produced a cursor counts as the device's last sync")
+}
+
+func TestPushChanges_KeyVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version *entities.OTPVersion
+		error   string // Empty when the change is applied
+	}{
+		{name: "current key", version: syncVersion(2)},
+		{name: "current key named", version: func() *entities.OTPVersion { v := syncVersion(2); v.KeyVersion = 2; return v }()},
+		{name: "encrypted before rotation", version: syncVersion(1), error: "key version 1, expected 2"},
+		{name: "named version disagrees", version: func() *entities.OTPVersion { v := syncVersion(2); v.KeyVersion = 1; return v }(), error: "key version 2, expected 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syncRepo := &fakeSyncRepository{}
+			service := NewSyncService(syncRepo, &fakeKeyRepository{version: 2}, totp.NewTOTPService(), emptyIssuerCatalog{}, 10)
+
+			change := &entities.SyncChange{Type: entities.SyncChangeUpdate, ID: uuid.New(), BaseRevision: 3, Version: tt.version}
+			results, err := service.PushChanges(context.Background(), uuid.New(), []*entities.SyncChange{change})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+
+			if tt.error == "" {
+				assert.Equal(t, entities.SyncChangeApplied, results[0].Status)
+				require.Len(t, syncRepo.applied, 1)
+				assert.Equal(t, 2, syncRepo.applied[0].Version.KeyVersion)
+				return
+			}
+			assert.Equal(t, entities.SyncChangeFailed, results[0].Status)
+			assert.Contains(t, results[0].Error, tt.error)
+			assert.Empty(t, syncRepo.applied, "a change with a stale secret never reaches the repository")
+		})
+	}
+}
+
+func TestResolveConflict_KeyVersion(t *testing.T) {
+	t.Run("client choice carries the current key", func(t *testing.T) {
+		syncRepo := &fakeSyncRepository{}
+		service := NewSyncService(syncRepo, &fakeKeyRepository{version: 3}, totp.NewTOTPService(), emptyIssuerCatalog{}, 10)
+
+		_, _, err := service.ResolveConflict(context.Background(), uuid.New(), uuid.New(),
+			&entities.SyncResolution{Choice: entities.SyncResolutionClient, Version: syncVersion(1)})
+		require.NoError(t, err)
+		require.NotNil(t, syncRepo.resolution)
+		assert.Equal(t, 3, syncRepo.resolution.KeyVersion)
+		assert.Nil(t, syncRepo.resolution.Version, "the client version stored with the conflict is applied")
+	})
+
+	t.Run("merged version must use the current key", func(t *testing.T) {
+		syncRepo := &fakeSyncRepository{}
+		service := NewSyncService(syncRepo, &fakeKeyRepository{version: 3}, totp.NewTOTPService(), emptyIssuerCatalog{}, 10)
+
+		_, _, err := service.ResolveConflict(context.Background(), uuid.New(), uuid.New(),
+			&entities.SyncResolution{Choice: entities.SyncResolutionMerged, Version: syncVersion(2)})
+		assert.ErrorIs(t, err, entities.ErrInvalidTOTPSeed)
+		assert.Nil(t, syncRepo.resolution)
+
+		_, _, err = service.ResolveConflict(context.Background(), uuid.New(), uuid.New(),
+			&entities.SyncResolution{Choice: entities.SyncResolutionMerged, Version: syncVersion(3)})
+		require.NoError(t, err)
+		assert.Equal(t, 3, syncRepo.resolution.Version.KeyVersion)
+	})
+}
@@ -0,0 +1,53 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// TrashPurger periodically deletes trashed vault entries whose retention period has passed
+type TrashPurger struct {
+	otpService interfaces.OTPService
+	interval   time.Duration
+}
+
+// NewTrashPurger creates a purger that runs every interval
+func NewTrashPurger(otpService interfaces.OTPService, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		otpService: otpService,
+		interval:   interval,
+	}
+}
+
+// Run purges expired entries immediately and then on every interval until ctx is cancelled
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.otpService.PurgeExpiredTrash(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("Failed to purge expired trash", "error", err)
+		}
+		return
+	}
+
+	if purged > 0 {
+		slog.Info("Purged expired trash entries", "count", purged)
+	}
+}
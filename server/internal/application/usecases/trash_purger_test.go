@@ -0,0 +1,45 @@
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// countingOTPService counts expired trash purges, cancelling the purger after the last one wanted
+type countingOTPService struct {
+	interfaces.OTPService
+	purges int
+	stopAt int
+	cancel context.CancelFunc
+}
+
+func (s *countingOTPService) PurgeExpiredTrash(context.Context) (int64, error) {
+	s.purges++
+	if s.purges == s.stopAt {
+		s.cancel()
+	}
+	return 1, nil
+}
+
+func TestTrashPurger_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	service := &countingOTPService{stopAt: 3, cancel: cancel}
+
+	done := make(chan struct{})
+	go func() {
+		NewTrashPurger(service, time.Millisecond).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the purger did not stop when its context was cancelled")
+	}
+	assert.Equal(t, 3, service.purges, "purges immediately and then on every interval")
+}
@@ -0,0 +1,186 @@
+package application
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+const (
+	// vaultEventPageSize is how many events a subscriber reads at a time
+	vaultEventPageSize = 100
+
+	// vaultEventPollInterval is how often subscribers check for events without being woken, which
+	// catches anything committed while the listener was reconnecting
+	vaultEventPollInterval = 30 * time.Second
+
+	// vaultEventRetryDelay is how long the hub waits before listening again after the listener fails
+	vaultEventRetryDelay = 5 * time.Second
+)
+
+// VaultEventHub fans vault events out to the devices connected to this server. Writers on any
+// replica bump the user's sync sequence, the database notifies every listening hub, and the hub
+// wakes that user's subscribers to read what is new.
+type VaultEventHub struct {
+	repo     interfaces.VaultEventRepository
+	listener interfaces.VaultEventListener
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*vaultEventSubscriber]struct{}
+	stopped     chan struct{} // Closed when Run returns, ending every subscription
+}
+
+type vaultEventSubscriber struct {
+	wake chan struct{}
+}
+
+// NewVaultEventHub creates a hub that learns of new events through listener
+func NewVaultEventHub(repo interfaces.VaultEventRepository, listener interfaces.VaultEventListener) *VaultEventHub {
+	return &VaultEventHub{
+		repo:        repo,
+		listener:    listener,
+		subscribers: make(map[uuid.UUID]map[*vaultEventSubscriber]struct{}),
+		stopped:     make(chan struct{}),
+	}
+}
+
+// Run listens for event notifications until ctx is cancelled, then ends every subscription
+func (h *VaultEventHub) Run(ctx context.Context) {
+	defer close(h.stopped)
+
+	for {
+		err := h.listener.Listen(ctx, h.wake)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("Vault event listener failed", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(vaultEventRetryDelay):
+		}
+	}
+}
+
+// Publish records an event that is not an entry change
+func (h *VaultEventHub) Publish(ctx context.Context, userID uuid.UUID, eventType string, entityID *uuid.UUID) error {
+	return h.repo.Create(ctx, userID, eventType, entityID)
+}
+
+// Subscribe streams the user's events after cursor, or from now on when cursor is empty
+func (h *VaultEventHub) Subscribe(ctx context.Context, userID uuid.UUID, deviceID, cursor string) (<-chan *entities.VaultEvent, error) {
+	// Register before the first read so no notification falls between the two
+	sub := &vaultEventSubscriber{wake: make(chan struct{}, 1)}
+	h.add(userID, sub)
+
+	if cursor == "" {
+		head, err := h.repo.Head(ctx, userID)
+		if err != nil {
+			h.remove(userID, sub)
+			return nil, err
+		}
+		cursor = head
+	}
+
+	// Read the backlog now so a bad cursor is reported to the caller
+	events, err := h.repo.ListSince(ctx, userID, cursor, vaultEventPageSize)
+	if err != nil {
+		h.remove(userID, sub)
+		return nil, err
+	}
+
+	out := make(chan *entities.VaultEvent)
+	go h.stream(ctx, userID, deviceID, cursor, events, sub, out)
+
+	return out, nil
+}
+
+// stream sends events to out, reading more each time the subscriber is woken
+func (h *VaultEventHub) stream(ctx context.Context, userID uuid.UUID, deviceID, cursor string, events []*entities.VaultEvent, sub *vaultEventSubscriber, out chan<- *entities.VaultEvent) {
+	defer close(out)
+	defer h.remove(userID, sub)
+
+	poll := time.NewTicker(vaultEventPollInterval)
+	defer poll.Stop()
+
+	for {
+		for _, event := range events {
+			cursor = event.Cursor
+
+			// The device that made a change already knows about it, but a revoked session is
+			// still sent, since it may be the one this stream belongs to
+			if deviceID != "" && event.DeviceID == deviceID && event.Type != entities.VaultEventSessionRevoked {
+				continue
+			}
+
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			case <-h.stopped:
+				return
+			}
+		}
+
+		if len(events) < vaultEventPageSize {
+			select {
+			case <-sub.wake:
+			case <-poll.C:
+			case <-ctx.Done():
+				return
+			case <-h.stopped:
+				return
+			}
+		}
+
+		var err error
+		events, err = h.repo.ListSince(ctx, userID, cursor, vaultEventPageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to read vault events", "user_id", userID, "error", err)
+			}
+			return
+		}
+	}
+}
+
+// wake tells the user's subscribers that new events were committed
+func (h *VaultEventHub) wake(userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[userID] {
+		// A pending wake already covers this one
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (h *VaultEventHub) add(userID uuid.UUID, sub *vaultEventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*vaultEventSubscriber]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+}
+
+func (h *VaultEventHub) remove(userID uuid.UUID, sub *vaultEventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.subscribers[userID], sub)
+	if len(h.subscribers[userID]) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
@@ -0,0 +1,212 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/google/uuid"
+)
+
+// vaultExportService implements the domain vault export service interface
+type vaultExportService struct {
+	otpRepo        interfaces.OTPRepository
+	folderRepo     interfaces.FolderRepository
+	keyRepo        interfaces.EncryptionKeyRepository
+	credentialRepo interfaces.WebAuthnCredentialRepository
+	rotationRepo   interfaces.KeyRotationRepository
+	importRepo     interfaces.VaultImportRepository
+	totpService    interfaces.TOTPService
+}
+
+// NewVaultExportService creates a new vault export service
+func NewVaultExportService(otpRepo interfaces.OTPRepository, folderRepo interfaces.FolderRepository, keyRepo interfaces.EncryptionKeyRepository, credentialRepo interfaces.WebAuthnCredentialRepository, rotationRepo interfaces.KeyRotationRepository, importRepo interfaces.VaultImportRepository, totpService interfaces.TOTPService) interfaces.VaultExportService {
+	return &vaultExportService{
+		otpRepo:        otpRepo,
+		folderRepo:     folderRepo,
+		keyRepo:        keyRepo,
+		credentialRepo: credentialRepo,
+		rotationRepo:   rotationRepo,
+		importRepo:     importRepo,
+		totpService:    totpService,
+	}
+}
+
+// Export writes the user's vault to w as a .2fair file
+func (s *vaultExportService) Export(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	keyVersion, err := currentKeyVersion(ctx, s.keyRepo, userID)
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.keyRepo.GetAllActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	folders, err := s.folderRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	otps, err := s.otpRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	content := &entities.VaultExportContent{
+		Keys:    make([]*entities.VaultExportKey, 0, len(keys)),
+		Folders: make([]*entities.VaultExportFolder, 0, len(folders)),
+		Entries: make([]*entities.VaultExportEntry, 0, len(otps)),
+	}
+	for _, key := range keys {
+		if key.KeyVersion != keyVersion {
+			continue
+		}
+		content.Keys = append(content.Keys, &entities.VaultExportKey{
+			CredentialID: key.CredentialID,
+			KeyVersion:   key.KeyVersion,
+			WrappedDEK:   key.WrappedDEK,
+			Salt:         key.Salt,
+		})
+	}
+	for _, folder := range folders {
+		content.Folders = append(content.Folders, &entities.VaultExportFolder{
+			ID:       folder.ID,
+			ParentID: folder.ParentID,
+			Name:     folder.Name,
+		})
+	}
+	for _, otp := range otps {
+		// Nothing is written until every secret is known to restore
+		if _, err := parseEntrySecret(otp.Secret, keyVersion); err != nil {
+			return fmt.Errorf("entry %s: %w", otp.ID, err)
+		}
+		content.Entries = append(content.Entries, &entities.VaultExportEntry{
+			ID:        otp.ID,
+			Issuer:    otp.Issuer,
+			IconURL:   otp.IconURL,
+			Label:     otp.Label,
+			Secret:    otp.Secret,
+			Algorithm: otp.Algorithm,
+			Digits:    otp.Digits,
+			Period:    otp.Period,
+			Method:    otp.Method,
+			Counter:   otp.Counter,
+			Type:      otp.Type,
+			T0:        otp.T0,
+			Tags:      otp.Tags,
+			FolderID:  otp.FolderID,
+		})
+	}
+
+	return entities.WriteVaultExport(w, content, keyVersion, time.Now())
+}
+
+// Import restores a verified export into the user's vault
+func (s *vaultExportService) Import(ctx context.Context, userID uuid.UUID, export *entities.VaultExport, mode string) (*entities.VaultImportResult, error) {
+	if mode == "" {
+		mode = entities.VaultImportMerge
+	}
+	if !entities.IsValidVaultImportMode(mode) {
+		return nil, fmt.Errorf("%w: unknown import mode %q", entities.ErrInvalidVaultExport, mode)
+	}
+
+	// Entries restored mid-rotation would miss being re-encrypted
+	if _, err := s.rotationRepo.GetInProgress(ctx, userID); err == nil {
+		return nil, entities.ErrKeyRotationInProgress
+	} else if !errors.Is(err, entities.ErrKeyRotationNotFound) {
+		return nil, err
+	}
+
+	keys, err := s.keysToRestore(ctx, userID, export)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range export.Content.Entries {
+		if err := s.normalizeEntry(entry); err != nil {
+			return nil, fmt.Errorf("entry %s: %w", entry.ID, err)
+		}
+	}
+
+	return s.importRepo.Import(ctx, userID, &entities.VaultImport{
+		Mode:    mode,
+		Folders: export.Content.FoldersParentsFirst(),
+		Entries: export.Content.Entries,
+		Keys:    keys,
+	})
+}
+
+// keysToRestore checks that the export is encrypted with the vault's key and returns the file's
+// wraps for the user's passkeys that hold none yet. A vault without keys takes the file's key
+// version, as long as one of the user's passkeys can unlock it.
+func (s *vaultExportService) keysToRestore(ctx context.Context, userID uuid.UUID, export *entities.VaultExport) ([]*entities.UserEncryptionKey, error) {
+	active, err := s.keyRepo.GetAllActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyVersion := 0
+	held := make(map[uuid.UUID]bool, len(active))
+	for _, key := range active {
+		held[key.CredentialID] = true
+		keyVersion = max(keyVersion, key.KeyVersion)
+	}
+	if keyVersion > 0 && keyVersion != export.Manifest.KeyVersion {
+		return nil, fmt.Errorf("%w: the file is encrypted with key version %d and the vault with %d", entities.ErrVaultKeyMismatch, export.Manifest.KeyVersion, keyVersion)
+	}
+
+	credentials, err := s.credentialRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	owned := make(map[uuid.UUID]bool, len(credentials))
+	for _, credential := range credentials {
+		owned[credential.ID] = true
+	}
+
+	var keys []*entities.UserEncryptionKey
+	for _, key := range export.Content.Keys {
+		if owned[key.CredentialID] && !held[key.CredentialID] {
+			keys = append(keys, entities.NewUserEncryptionKey(userID, key.CredentialID, key.KeyVersion, key.WrappedDEK, key.Salt))
+		}
+	}
+	if keyVersion == 0 && len(keys) == 0 {
+		return nil, fmt.Errorf("%w: none of your passkeys holds a key in the file", entities.ErrVaultKeyMismatch)
+	}
+
+	return keys, nil
+}
+
+// normalizeEntry validates an exported entry's fields as a new entry's are and applies their defaults
+func (s *vaultExportService) normalizeEntry(entry *entities.VaultExportEntry) error {
+	if entry.Issuer == "" || entry.Label == "" {
+		return fmt.Errorf("%w: issuer and label are required", entities.ErrInvalidTOTPSeed)
+	}
+
+	method, counter, t0, err := normalizeMethod(entry.Method, entry.Counter, entry.T0)
+	if err != nil {
+		return err
+	}
+	params, err := normalizeCodeParams(s.totpService, nil, entry.Period, entry.Algorithm, entry.Digits, entry.Type, t0)
+	if err != nil {
+		return err
+	}
+	tags, err := normalizeEntryTags(entry.Tags)
+	if err != nil {
+		return err
+	}
+
+	entry.Method = method
+	entry.Counter = counter
+	entry.Type = params.Type
+	entry.Algorithm = params.Algorithm
+	entry.Digits = params.Digits
+	entry.Period = params.Period
+	entry.T0 = params.T0
+	entry.Tags = tags
+	return nil
+}
@@ -0,0 +1,290 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/totp"
+)
+
+// fakeCredentialRepository holds the user's passkeys
+type fakeCredentialRepository struct {
+	interfaces.WebAuthnCredentialRepository
+	credentials []*entities.WebAuthnCredential
+}
+
+func (r *fakeCredentialRepository) GetByUserID(_ context.Context, _ uuid.UUID) ([]*entities.WebAuthnCredential, error) {
+	return r.credentials, nil
+}
+
+// fakeRotationRepository reports whether a key rotation is in progress
+type fakeRotationRepository struct {
+	interfaces.KeyRotationRepository
+	inProgress bool
+}
+
+func (r *fakeRotationRepository) GetInProgress(_ context.Context, userID uuid.UUID) (*entities.KeyRotation, error) {
+	if !r.inProgress {
+		return nil, entities.ErrKeyRotationNotFound
+	}
+	return &entities.KeyRotation{UserID: userID}, nil
+}
+
+// fakeVaultImportRepository records the import it is asked to restore
+type fakeVaultImportRepository struct {
+	interfaces.VaultImportRepository
+	imported *entities.VaultImport
+}
+
+func (r *fakeVaultImportRepository) Import(_ context.Context, _ uuid.UUID, vault *entities.VaultImport) (*entities.VaultImportResult, error) {
+	r.imported = vault
+	return &entities.VaultImportResult{Mode: vault.Mode, Created: len(vault.Entries), KeysRestored: len(vault.Keys)}, nil
+}
+
+// exportFixture is a vault export service over in-memory repositories
+type exportFixture struct {
+	otps        *fakeOTPRepository
+	folders     *fakeFolderRepository
+	keys        *fakeKeyRepository
+	credentials *fakeCredentialRepository
+	rotations   *fakeRotationRepository
+	imports     *fakeVaultImportRepository
+	service     interfaces.VaultExportService
+}
+
+func newExportFixture() *exportFixture {
+	f := &exportFixture{
+		otps:        &fakeOTPRepository{},
+		folders:     &fakeFolderRepository{},
+		keys:        &fakeKeyRepository{},
+		credentials: &fakeCredentialRepository{},
+		rotations:   &fakeRotationRepository{},
+		imports:     &fakeVaultImportRepository{},
+	}
+	f.service = NewVaultExportService(f.otps, f.folders, f.keys, f.credentials, f.rotations, f.imports, totp.NewTOTPService())
+	return f
+}
+
+// wrap returns a wrap of the vault key for a passkey
+func wrap(userID, credentialID uuid.UUID, keyVersion int) *entities.UserEncryptionKey {
+	return entities.NewUserEncryptionKey(userID, credentialID, keyVersion, []byte("wrapped"), []byte("salt"))
+}
+
+// testExport returns a verified export encrypted with keyVersion, holding wraps for credentialIDs
+// and an entry in a nested folder
+func testExport(t *testing.T, keyVersion int, credentialIDs ...uuid.UUID) *entities.VaultExport {
+	t.Helper()
+
+	parent := &entities.VaultExportFolder{ID: uuid.New(), Name: "Work"}
+	child := &entities.VaultExportFolder{ID: uuid.New(), ParentID: &parent.ID, Name: "Cloud"}
+	content := &entities.VaultExportContent{
+		// Children listed first must still be restored after their parents
+		Folders: []*entities.VaultExportFolder{child, parent},
+		Entries: []*entities.VaultExportEntry{{
+			ID:       uuid.New(),
+			Issuer:   "GitHub",
+			Label:    "alice",
+			Secret:   encryptedSecret(keyVersion),
+			Method:   "totp",
+			Tags:     []string{" work", "work", ""},
+			FolderID: &child.ID,
+		}},
+	}
+	for _, credentialID := range credentialIDs {
+		content.Keys = append(content.Keys, &entities.VaultExportKey{CredentialID: credentialID, KeyVersion: keyVersion, WrappedDEK: []byte("wrapped"), Salt: []byte("salt")})
+	}
+
+	var file bytes.Buffer
+	require.NoError(t, entities.WriteVaultExport(&file, content, keyVersion, time.Now()))
+	export, err := entities.ReadVaultExport(&file)
+	require.NoError(t, err)
+	return export
+}
+
+func TestExport(t *testing.T) {
+	f := newExportFixture()
+	userID, current, retired := uuid.New(), uuid.New(), uuid.New()
+	folder := &entities.Folder{ID: uuid.New(), Name: "Work"}
+
+	f.keys.version = 2
+	f.keys.keys = []*entities.UserEncryptionKey{wrap(userID, current, 2), wrap(userID, retired, 1)}
+	f.folders.folders = []*entities.Folder{folder}
+	f.otps.stored = []*entities.OTP{{ID: uuid.New(), Issuer: "GitHub", Label: "alice", Secret: encryptedSecret(2), Period: 30, Algorithm: "SHA1", Digits: 6,
+		Method: entities.OTPMethodTOTP, Type: entities.OTPTypeStandard, Tags: []string{}, FolderID: &folder.ID}}
+
+	var file bytes.Buffer
+	require.NoError(t, f.service.Export(context.Background(), userID, &file))
+
+	export, err := entities.ReadVaultExport(&file)
+	require.NoError(t, err, "the file verifies")
+	assert.Equal(t, 2, export.Manifest.KeyVersion)
+	require.Len(t, export.Content.Keys, 1, "only wraps of the current key are exported")
+	assert.Equal(t, current, export.Content.Keys[0].CredentialID)
+	require.Len(t, export.Content.Folders, 1)
+	assert.Equal(t, folder.ID, export.Content.Folders[0].ID)
+	require.Len(t, export.Content.Entries, 1)
+	assert.Equal(t, f.otps.stored[0].Secret, export.Content.Entries[0].Secret)
+	assert.Equal(t, &folder.ID, export.Content.Entries[0].FolderID)
+}
+
+func TestExport_StaleSecret(t *testing.T) {
+	f := newExportFixture()
+	f.keys.version = 2
+	f.otps.stored = []*entities.OTP{
+		{ID: uuid.New(), Issuer: "GitHub", Label: "alice", Secret: encryptedSecret(2)},
+		{ID: uuid.New(), Issuer: "GitLab", Label: "alice", Secret: encryptedSecret(1)},
+	}
+
+	var file bytes.Buffer
+	err := f.service.Export(context.Background(), uuid.New(), &file)
+	assert.ErrorIs(t, err, entities.ErrInvalidTOTPSeed)
+	assert.Zero(t, file.Len(), "nothing is written for a vault that would not restore")
+}
+
+func TestImport_Keys(t *testing.T) {
+	userID := uuid.New()
+	held, unwrapped, foreign := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		vaultKeys   []*entities.UserEncryptionKey // The vault's active wraps
+		fileVersion int
+		fileKeys    []uuid.UUID // Passkeys the file holds wraps for
+		restored    []uuid.UUID // Passkeys whose wraps are restored
+		mismatch    bool
+	}{
+		{
+			name:        "same key version",
+			vaultKeys:   []*entities.UserEncryptionKey{wrap(userID, held, 2)},
+			fileVersion: 2,
+			fileKeys:    []uuid.UUID{held, unwrapped, foreign},
+			restored:    []uuid.UUID{unwrapped},
+		},
+		{
+			name:        "same key version without wraps",
+			vaultKeys:   []*entities.UserEncryptionKey{wrap(userID, held, 2)},
+			fileVersion: 2,
+		},
+		{
+			name:        "older file",
+			vaultKeys:   []*entities.UserEncryptionKey{wrap(userID, held, 2)},
+			fileVersion: 1,
+			fileKeys:    []uuid.UUID{held},
+			mismatch:    true,
+		},
+		{
+			name:        "newer file",
+			vaultKeys:   []*entities.UserEncryptionKey{wrap(userID, held, 2)},
+			fileVersion: 3,
+			fileKeys:    []uuid.UUID{held},
+			mismatch:    true,
+		},
+		{
+			name:        "vault without keys",
+			fileVersion: 4,
+			fileKeys:    []uuid.UUID{held, foreign},
+			restored:    []uuid.UUID{held},
+		},
+		{
+			name:        "vault without keys and no passkey in the file",
+			fileVersion: 4,
+			fileKeys:    []uuid.UUID{foreign},
+			mismatch:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newExportFixture()
+			f.keys.keys = tt.vaultKeys
+			f.credentials.credentials = []*entities.WebAuthnCredential{{ID: held, UserID: userID}, {ID: unwrapped, UserID: userID}}
+
+			result, err := f.service.Import(context.Background(), userID, testExport(t, tt.fileVersion, tt.fileKeys...), entities.VaultImportMerge)
+			if tt.mismatch {
+				assert.ErrorIs(t, err, entities.ErrVaultKeyMismatch)
+				assert.Nil(t, f.imports.imported, "nothing is restored")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, len(tt.restored), result.KeysRestored)
+
+			var restored []uuid.UUID
+			for _, key := range f.imports.imported.Keys {
+				assert.Equal(t, userID, key.UserID)
+				assert.Equal(t, tt.fileVersion, key.KeyVersion)
+				restored = append(restored, key.CredentialID)
+			}
+			assert.Equal(t, tt.restored, restored)
+		})
+	}
+}
+
+func TestImport_Modes(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		inProgress bool
+		imported   string // Mode the repository is asked to restore with; empty when refused
+		err        error
+	}{
+		{name: "default", mode: "", imported: entities.VaultImportMerge},
+		{name: "merge", mode: entities.VaultImportMerge, imported: entities.VaultImportMerge},
+		{name: "replace", mode: entities.VaultImportReplace, imported: entities.VaultImportReplace},
+		{name: "unknown", mode: "overwrite", err: entities.ErrInvalidVaultExport},
+		{name: "during key rotation", mode: entities.VaultImportReplace, inProgress: true, err: entities.ErrKeyRotationInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newExportFixture()
+			userID, credentialID := uuid.New(), uuid.New()
+			f.keys.keys = []*entities.UserEncryptionKey{wrap(userID, credentialID, 1)}
+			f.rotations.inProgress = tt.inProgress
+
+			export := testExport(t, 1, credentialID)
+			result, err := f.service.Import(context.Background(), userID, export, tt.mode)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, f.imports.imported)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.imported, result.Mode)
+
+			vault := f.imports.imported
+			require.Len(t, vault.Folders, 2)
+			assert.Equal(t, "Work", vault.Folders[0].Name, "parents are restored first")
+			assert.Equal(t, "Cloud", vault.Folders[1].Name)
+
+			// Entries are normalized as new entries are
+			require.Len(t, vault.Entries, 1)
+			entry := vault.Entries[0]
+			assert.Equal(t, entities.OTPMethodTOTP, entry.Method)
+			assert.Equal(t, "SHA1", entry.Algorithm)
+			assert.Equal(t, 6, entry.Digits)
+			assert.Equal(t, 30, entry.Period)
+			assert.Equal(t, []string{"work"}, entry.Tags)
+		})
+	}
+}
+
+func TestImport_InvalidEntry(t *testing.T) {
+	f := newExportFixture()
+	userID, credentialID := uuid.New(), uuid.New()
+	f.keys.keys = []*entities.UserEncryptionKey{wrap(userID, credentialID, 1)}
+
+	export := testExport(t, 1, credentialID)
+	export.Content.Entries[0].Digits = 12
+
+	_, err := f.service.Import(context.Background(), userID, export, entities.VaultImportMerge)
+	assert.ErrorIs(t, err, entities.ErrInvalidTOTPSeed)
+	assert.Nil(t, f.imports.imported)
+}
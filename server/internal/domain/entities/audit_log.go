@@ -0,0 +1,247 @@
+package entities
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Audit log actions, grouped into categories by the prefix before the dot
+const (
+	AuditActionLogin              = "auth.login"           // Signed in through an OAuth callback
+	AuditActionAuthFailed         = "auth.failed"          // An OAuth callback, passkey assertion, token refresh or access token failed
+	AuditActionTokenRefreshed     = "auth.token_refreshed" // Exchanged a refresh token for new tokens
+	AuditActionRefreshReused      = "auth.refresh_reused"  // A rotated refresh token was presented again; its session was revoked
+	AuditActionPasskeyVerified    = "passkey.verified"     // Unlocked the vault with a passkey
+	AuditActionPasskeyRegistered  = "passkey.registered"
+	AuditActionPasskeyDeleted     = "passkey.deleted"
+	AuditActionEntryCreated       = "entry.created"
+	AuditActionEntryUpdated       = "entry.updated"     // Includes entries restored from the trash
+	AuditActionEntryInactivated   = "entry.inactivated" // Moved to the trash or permanently deleted
+	AuditActionAccountSuspended   = "account.suspended" // By an admin
+	AuditActionAccountReactivated = "account.reactivated"
+	AuditActionAccountLoggedOut   = "account.logged_out" // An admin revoked every token
+	AuditActionAccountRoleChanged = "account.role_changed"
+	AuditActionSessionRevoked     = "session.revoked" // Signed out, or signed another device out
+)
+
+// Resources an audit log entry can be about
+const (
+	AuditResourceUser       = "user"
+	AuditResourceCredential = "credential"
+	AuditResourceEntry      = "entry"
+	AuditResourceSession    = "session"
+)
+
+// AuditActionForOperation returns the action a sync operation is audited as
+func AuditActionForOperation(operation string) string {
+	switch operation {
+	case SyncOperationCreate:
+		return AuditActionEntryCreated
+	case SyncOperationDelete, SyncOperationPurge:
+		return AuditActionEntryInactivated
+	default:
+		return AuditActionEntryUpdated
+	}
+}
+
+// Limits on client-supplied request details kept in the log
+const (
+	MaxAuditUserAgentLength = 512
+	MaxAuditRequestIDLength = 128
+)
+
+// Activity listing page sizes
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// auditHashDomain separates audit log hashes from any other SHA-256 use
+const auditHashDomain = "2fair-audit-log-v1"
+
+// AuditLog is one entry in the security audit log. Entries form a hash chain in sequence order:
+// Hash covers the entry's content and PrevHash, the hash of the entry before it, so changing,
+// reordering or removing an entry breaks the chain from that entry on.
+type AuditLog struct {
+	ID           uuid.UUID         `json:"id"`
+	Sequence     int64             `json:"sequence"`
+	UserID       *uuid.UUID        `json:"userId,omitempty"` // Absent for failures that name no user
+	Action       string            `json:"action"`
+	ResourceType string            `json:"resourceType"`
+	ResourceID   *uuid.UUID        `json:"resourceId,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	IPAddress    string            `json:"ipAddress,omitempty"`
+	UserAgent    string            `json:"userAgent,omitempty"`
+	RequestID    string            `json:"requestId,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	PrevHash     []byte            `json:"-"`
+	Hash         []byte            `json:"-"`
+}
+
+// NewAuditLog creates an unchained entry for an action, attributed to the client in info. Request
+// details are normalized to what the database stores, so the entry hashes the same once read back.
+func NewAuditLog(userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, metadata map[string]string, info RequestInfo) *AuditLog {
+	entry := &AuditLog{
+		ID:           uuid.New(),
+		UserID:       userID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Metadata:     metadata,
+		UserAgent:    auditText(info.UserAgent, MaxAuditUserAgentLength),
+		RequestID:    auditText(info.RequestID, MaxAuditRequestIDLength),
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if addr, err := netip.ParseAddr(info.IPAddress); err == nil {
+		entry.IPAddress = addr.WithZone("").String()
+	}
+	if len(entry.Metadata) == 0 {
+		entry.Metadata = nil
+	}
+
+	return entry
+}
+
+// Chain places the entry after the entry with prevHash, or at the start of the chain when
+// prevHash is empty, and computes its hash
+func (l *AuditLog) Chain(sequence int64, prevHash []byte) {
+	l.Sequence = sequence
+	l.PrevHash = prevHash
+	l.Hash = l.ComputeHash()
+}
+
+// ComputeHash returns the hash of the entry's content and previous hash. Every field is length
+// prefixed, so no two different entries encode the same.
+func (l *AuditLog) ComputeHash() []byte {
+	var buf bytes.Buffer
+	field := func(value []byte) {
+		binary.Write(&buf, binary.BigEndian, uint32(len(value)))
+		buf.Write(value)
+	}
+	optionalUUID := func(id *uuid.UUID) {
+		if id == nil {
+			field(nil)
+			return
+		}
+		field(id[:])
+	}
+
+	field([]byte(auditHashDomain))
+	binary.Write(&buf, binary.BigEndian, l.Sequence)
+	field(l.PrevHash)
+	field(l.ID[:])
+	optionalUUID(l.UserID)
+	field([]byte(l.Action))
+	field([]byte(l.ResourceType))
+	optionalUUID(l.ResourceID)
+	metadata, _ := json.Marshal(l.Metadata) // Map keys marshal sorted
+	field(metadata)
+	field([]byte(l.IPAddress))
+	field([]byte(l.UserAgent))
+	field([]byte(l.RequestID))
+	binary.Write(&buf, binary.BigEndian, l.CreatedAt.UnixMicro())
+
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:]
+}
+
+// CheckAuditChain checks entries in sequence order, each following the one before it and the
+// first following prev, or starting the chain when prev is nil. Returns the last entry checked
+// and ErrAuditChainBroken naming the first entry that does not hold.
+func CheckAuditChain(prev *AuditLog, entries []*AuditLog) (*AuditLog, error) {
+	for _, entry := range entries {
+		wantSequence, wantPrevHash := int64(1), []byte(nil)
+		if prev != nil {
+			wantSequence, wantPrevHash = prev.Sequence+1, prev.Hash
+		}
+
+		switch {
+		case entry.Sequence != wantSequence:
+			return prev, fmt.Errorf("%w: expected entry %d, found %d", ErrAuditChainBroken, wantSequence, entry.Sequence)
+		case !bytes.Equal(entry.PrevHash, wantPrevHash):
+			return prev, fmt.Errorf("%w: entry %d does not follow the entry before it", ErrAuditChainBroken, entry.Sequence)
+		case !bytes.Equal(entry.Hash, entry.ComputeHash()):
+			return prev, fmt.Errorf("%w: entry %d does not match its hash", ErrAuditChainBroken, entry.Sequence)
+		}
+		prev = entry
+	}
+
+	return prev, nil
+}
+
+// AuditLogFilter narrows and pages a user's activity listing; zero values match everything
+type AuditLogFilter struct {
+	Action string     // An action, or a category such as "auth" matching every action in it
+	Since  *time.Time // Entries at or after this time
+	Until  *time.Time // Entries before this time
+	Cursor string     // Opaque cursor returned with the previous page
+	Limit  int        // Page size; 0 for the default
+}
+
+// Normalize applies the default page size and validates the filter
+func (f *AuditLogFilter) Normalize() error {
+	if f.Action != "" && !isAuditActionOrCategory(f.Action) {
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidAuditFilter, f.Action)
+	}
+	if f.Since != nil && f.Until != nil && !f.Since.Before(*f.Until) {
+		return fmt.Errorf("%w: since must be before until", ErrInvalidAuditFilter)
+	}
+
+	switch {
+	case f.Limit < 0:
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidAuditFilter)
+	case f.Limit == 0:
+		f.Limit = DefaultAuditPageSize
+	case f.Limit > MaxAuditPageSize:
+		f.Limit = MaxAuditPageSize
+	}
+	return nil
+}
+
+// AuditLogPage is one page of a user's activity, newest first
+type AuditLogPage struct {
+	Items      []*AuditLog
+	NextCursor string // Empty on the last page
+}
+
+// auditActions lists every action, for validating filters
+var auditActions = []string{
+	AuditActionLogin, AuditActionAuthFailed, AuditActionTokenRefreshed, AuditActionRefreshReused,
+	AuditActionPasskeyVerified, AuditActionPasskeyRegistered, AuditActionPasskeyDeleted,
+	AuditActionEntryCreated, AuditActionEntryUpdated, AuditActionEntryInactivated,
+	AuditActionAccountSuspended, AuditActionAccountReactivated, AuditActionAccountLoggedOut, AuditActionAccountRoleChanged,
+	AuditActionSessionRevoked,
+}
+
+// isAuditActionOrCategory reports whether value names an action or an action category
+func isAuditActionOrCategory(value string) bool {
+	for _, action := range auditActions {
+		category, _, _ := strings.Cut(action, ".")
+		if value == action || value == category {
+			return true
+		}
+	}
+	return false
+}
+
+// auditText makes client-supplied text storable: valid UTF-8 without NUL bytes, at most max bytes
+// long and not cut inside a character
+func auditText(s string, max int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, ""), "\x00", "")
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
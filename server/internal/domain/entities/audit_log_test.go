@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuditChain builds a chain of three entries
+func testAuditChain() []*AuditLog {
+	userID := uuid.New()
+	info := RequestInfo{IPAddress: "203.0.113.7", UserAgent: "Firefox", RequestID: "req-1"}
+
+	entries := []*AuditLog{
+		NewAuditLog(&userID, AuditActionLogin, AuditResourceUser, &userID, map[string]string{"provider": "github"}, info),
+		NewAuditLog(nil, AuditActionAuthFailed, AuditResourceUser, nil, map[string]string{"method": "oauth"}, info),
+		NewAuditLog(&userID, AuditActionEntryCreated, AuditResourceEntry, nil, nil, info),
+	}
+	var prevHash []byte
+	for i, entry := range entries {
+		entry.Chain(int64(i+1), prevHash)
+		prevHash = entry.Hash
+	}
+	return entries
+}
+
+func TestCheckAuditChain_Valid(t *testing.T) {
+	entries := testAuditChain()
+
+	last, err := CheckAuditChain(nil, entries)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), last.Sequence)
+
+	// A chain checked in pages continues from the last entry of the previous page
+	last, err = CheckAuditChain(entries[0], entries[1:])
+	require.NoError(t, err)
+	assert.Same(t, entries[2], last)
+}
+
+func TestCheckAuditChain_DetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]*AuditLog) []*AuditLog
+	}{
+		{"action changed", func(e []*AuditLog) []*AuditLog { e[1].Action = AuditActionLogin; return e }},
+		{"user changed", func(e []*AuditLog) []*AuditLog { other := uuid.New(); e[0].UserID = &other; return e }},
+		{"metadata changed", func(e []*AuditLog) []*AuditLog { e[0].Metadata["provider"] = "google"; return e }},
+		{"time changed", func(e []*AuditLog) []*AuditLog { e[2].CreatedAt = e[2].CreatedAt.Add(time.Second); return e }},
+		{"entry removed", func(e []*AuditLog) []*AuditLog { return []*AuditLog{e[0], e[2]} }},
+		{"first entry removed", func(e []*AuditLog) []*AuditLog { return e[1:] }},
+		{"entries swapped", func(e []*AuditLog) []*AuditLog { return []*AuditLog{e[0], e[2], e[1]} }},
+		{"entry rehashed", func(e []*AuditLog) []*AuditLog {
+			e[1].IPAddress = "198.51.100.1"
+			e[1].Chain(e[1].Sequence, e[1].PrevHash)
+			return e
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CheckAuditChain(nil, tt.tamper(testAuditChain()))
+			assert.ErrorIs(t, err, ErrAuditChainBroken)
+		})
+	}
+}
+
+func TestNewAuditLog_NormalizesRequestInfo(t *testing.T) {
+	entry := NewAuditLog(nil, AuditActionAuthFailed, AuditResourceUser, nil, map[string]string{}, RequestInfo{
+		IPAddress: "not an address",
+		UserAgent: strings.Repeat("é", MaxAuditUserAgentLength) + "\x00",
+		RequestID: "req\xff-1",
+	})
+
+	assert.Empty(t, entry.IPAddress)
+	assert.Nil(t, entry.Metadata)
+	assert.LessOrEqual(t, len(entry.UserAgent), MaxAuditUserAgentLength)
+	assert.True(t, strings.HasSuffix(entry.UserAgent, "é"), "truncation must not split a character")
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, entry.CreatedAt, entry.CreatedAt.Truncate(time.Microsecond))
+}
+
+func TestAuditLogFilter_Normalize(t *testing.T) {
+	filter := AuditLogFilter{Action: "passkey"}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, DefaultAuditPageSize, filter.Limit)
+
+	filter = AuditLogFilter{Action: AuditActionEntryUpdated, Limit: 10_000}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, MaxAuditPageSize, filter.Limit)
+
+	since := time.Now()
+	for _, invalid := range []AuditLogFilter{
+		{Action: "auth.%"},
+		{Action: "vault"},
+		{Limit: -1},
+		{Since: &since, Until: &since},
+	} {
+		assert.ErrorIs(t, invalid.Normalize(), ErrInvalidAuditFilter)
+	}
+}
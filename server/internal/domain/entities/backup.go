@@ -0,0 +1,288 @@
+package entities
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Backup file format. Inside its compression and encryption, a backup is a header line, one section
+// per table and a trailer line. A section is a line naming the table and its columns, then the
+// table's rows in PostgreSQL's COPY text format, then a `\.` line. COPY escapes backslashes, so no
+// row can be mistaken for the end of a section. The trailer repeats every table with its row count.
+const (
+	BackupFormat    = "2fair-backup"
+	BackupVersion   = 1
+	BackupExtension = ".2fairbak"
+)
+
+// Backup names are the time the backup was taken, so sorting them sorts the backups by age
+const (
+	backupNamePrefix     = "2fair-"
+	backupNameTimeLayout = "20060102T150405Z"
+)
+
+// backupEndOfData ends a table's rows
+var backupEndOfData = []byte("\\.\n")
+
+// BackupHeader opens a backup
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int64     `json:"schemaVersion"` // Migration version of the database the backup was taken from
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// BackupTable is a table in a backup
+type BackupTable struct {
+	Name    string   `json:"table"`
+	Columns []string `json:"columns"`
+	Rows    int64    `json:"rows"`
+}
+
+// BackupManifest describes a backup's content
+type BackupManifest struct {
+	Header BackupHeader
+	Tables []*BackupTable
+}
+
+// BackupInfo is a backup held in a backup store
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BackupName names the backup taken at createdAt
+func BackupName(createdAt time.Time) string {
+	return backupNamePrefix + createdAt.UTC().Format(backupNameTimeLayout) + BackupExtension
+}
+
+// ParseBackupName returns the time a backup was taken from its name. Objects in a backup store
+// that are not named like a backup are left alone.
+func ParseBackupName(name string) (time.Time, bool) {
+	stamp, ok := strings.CutPrefix(name, backupNamePrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, ok = strings.CutSuffix(stamp, BackupExtension)
+	if !ok {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(backupNameTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// backupLine is a header, section or trailer line
+type backupLine struct {
+	Table   string         `json:"table,omitempty"`
+	Columns []string       `json:"columns,omitempty"`
+	Tables  []*BackupTable `json:"tables,omitempty"`
+}
+
+// BackupWriter writes a backup one table at a time
+type BackupWriter struct {
+	out      *bufio.Writer
+	manifest *BackupManifest
+}
+
+// NewBackupWriter starts a backup taken at createdAt from a database at schemaVersion
+func NewBackupWriter(w io.Writer, schemaVersion int64, createdAt time.Time) (*BackupWriter, error) {
+	bw := &BackupWriter{
+		out: bufio.NewWriter(w),
+		manifest: &BackupManifest{
+			Header: BackupHeader{
+				Format:        BackupFormat,
+				Version:       BackupVersion,
+				SchemaVersion: schemaVersion,
+				CreatedAt:     createdAt.UTC(),
+			},
+		},
+	}
+	if err := bw.writeLine(bw.manifest.Header); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// WriteTable writes a table section. copyRows writes the table's rows in COPY text format and
+// returns how many it wrote.
+func (bw *BackupWriter) WriteTable(name string, columns []string, copyRows func(w io.Writer) (int64, error)) error {
+	if err := bw.writeLine(backupLine{Table: name, Columns: columns}); err != nil {
+		return err
+	}
+	rows, err := copyRows(bw.out)
+	if err != nil {
+		return fmt.Errorf("failed to copy table %s: %w", name, err)
+	}
+	if _, err := bw.out.Write(backupEndOfData); err != nil {
+		return err
+	}
+
+	bw.manifest.Tables = append(bw.manifest.Tables, &BackupTable{Name: name, Columns: columns, Rows: rows})
+	return nil
+}
+
+// Close writes the trailer and returns what the backup holds
+func (bw *BackupWriter) Close() (*BackupManifest, error) {
+	if err := bw.writeLine(backupLine{Tables: bw.manifest.Tables}); err != nil {
+		return nil, err
+	}
+	if err := bw.out.Flush(); err != nil {
+		return nil, err
+	}
+	return bw.manifest, nil
+}
+
+func (bw *BackupWriter) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := bw.out.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// BackupReader reads a backup one table at a time. Row counts are checked against the trailer, so
+// a backup that reads to the end without error is complete.
+type BackupReader struct {
+	in       *bufio.Reader
+	header   BackupHeader
+	tables   []*BackupTable
+	section  *backupSection
+	manifest *BackupManifest
+}
+
+// NewBackupReader reads a backup's header
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	br := &BackupReader{in: bufio.NewReader(r)}
+	line, err := br.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(line, &br.header); err != nil {
+		return nil, fmt.Errorf("%w: invalid header: %v", ErrInvalidDatabaseBackup, err)
+	}
+	if br.header.Format != BackupFormat || br.header.Version != BackupVersion {
+		return nil, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalidDatabaseBackup, br.header.Format, br.header.Version)
+	}
+	return br, nil
+}
+
+// Header returns the backup's header
+func (br *BackupReader) Header() BackupHeader {
+	return br.header
+}
+
+// Next returns the next table and a reader of its rows in COPY text format. Rows not read are
+// skipped. After the last table it checks the trailer and returns io.EOF.
+func (br *BackupReader) Next() (*BackupTable, io.Reader, error) {
+	if br.manifest != nil {
+		return nil, nil, io.EOF
+	}
+	if br.section != nil {
+		if _, err := io.Copy(io.Discard, br.section); err != nil {
+			return nil, nil, err
+		}
+		br.section = nil
+	}
+
+	data, err := br.readLine()
+	if err != nil {
+		return nil, nil, err
+	}
+	var line backupLine
+	if err := json.Unmarshal(data, &line); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid section: %v", ErrInvalidDatabaseBackup, err)
+	}
+
+	if line.Table == "" {
+		if err := br.checkTrailer(line.Tables); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, io.EOF
+	}
+	if len(line.Columns) == 0 {
+		return nil, nil, fmt.Errorf("%w: table %s has no columns", ErrInvalidDatabaseBackup, line.Table)
+	}
+
+	table := &BackupTable{Name: line.Table, Columns: line.Columns}
+	br.tables = append(br.tables, table)
+	br.section = &backupSection{in: br.in, table: table}
+	return table, br.section, nil
+}
+
+// Manifest returns what the backup holds once Next has returned io.EOF
+func (br *BackupReader) Manifest() *BackupManifest {
+	return br.manifest
+}
+
+func (br *BackupReader) checkTrailer(tables []*BackupTable) error {
+	equal := slices.EqualFunc(br.tables, tables, func(read, listed *BackupTable) bool {
+		return read.Name == listed.Name && read.Rows == listed.Rows && slices.Equal(read.Columns, listed.Columns)
+	})
+	if !equal {
+		return fmt.Errorf("%w: tables do not match the trailer", ErrInvalidDatabaseBackup)
+	}
+	if _, err := br.in.ReadByte(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected data after the trailer", ErrInvalidDatabaseBackup)
+	}
+
+	br.manifest = &BackupManifest{Header: br.header, Tables: br.tables}
+	return nil
+}
+
+func (br *BackupReader) readLine() ([]byte, error) {
+	line, err := br.in.ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: unexpected end of backup", ErrInvalidDatabaseBackup)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// backupSection reads a table's rows up to the end of its section, counting them
+type backupSection struct {
+	in      *bufio.Reader
+	table   *BackupTable
+	pending []byte
+	done    bool
+}
+
+func (s *backupSection) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		line, err := s.in.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("%w: table %s ends unexpectedly", ErrInvalidDatabaseBackup, s.table.Name)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if bytes.Equal(line, backupEndOfData) {
+			s.done = true
+			return 0, io.EOF
+		}
+		s.table.Rows++
+		s.pending = line
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
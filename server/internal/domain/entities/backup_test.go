@@ -0,0 +1,125 @@
+package entities
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestBackup writes a backup with a users table of two rows and an empty folders table
+func writeTestBackup(t *testing.T) string {
+	var buf bytes.Buffer
+	bw, err := NewBackupWriter(&buf, 17, time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	require.NoError(t, bw.WriteTable("users", []string{"id", "email"}, func(w io.Writer) (int64, error) {
+		_, err := io.WriteString(w, "1\ta@example.com\n2\t\\\\.\n")
+		return 2, err
+	}))
+	require.NoError(t, bw.WriteTable("folders", []string{"id"}, func(w io.Writer) (int64, error) {
+		return 0, nil
+	}))
+
+	manifest, err := bw.Close()
+	require.NoError(t, err)
+	require.Len(t, manifest.Tables, 2)
+	return buf.String()
+}
+
+func readTestBackup(backup string) (*BackupManifest, map[string]string, error) {
+	br, err := NewBackupReader(strings.NewReader(backup))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make(map[string]string)
+	for {
+		table, data, err := br.Next()
+		if err == io.EOF {
+			return br.Manifest(), rows, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		content, err := io.ReadAll(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows[table.Name] = string(content)
+	}
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	manifest, rows, err := readTestBackup(writeTestBackup(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(17), manifest.Header.SchemaVersion)
+	require.Len(t, manifest.Tables, 2)
+	assert.Equal(t, "users", manifest.Tables[0].Name)
+	assert.Equal(t, []string{"id", "email"}, manifest.Tables[0].Columns)
+	assert.Equal(t, int64(2), manifest.Tables[0].Rows)
+	assert.Equal(t, int64(0), manifest.Tables[1].Rows)
+
+	// An escaped backslash before a dot is a row, not the end of the table
+	assert.Equal(t, "1\ta@example.com\n2\t\\\\.\n", rows["users"])
+	assert.Empty(t, rows["folders"])
+}
+
+func TestBackupReader_SkipsUnreadRows(t *testing.T) {
+	br, err := NewBackupReader(strings.NewReader(writeTestBackup(t)))
+	require.NoError(t, err)
+
+	for {
+		_, _, err := br.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(2), br.Manifest().Tables[0].Rows)
+}
+
+func TestBackupReader_Invalid(t *testing.T) {
+	valid := writeTestBackup(t)
+	trailer := valid[strings.LastIndex(valid[:len(valid)-1], "\n")+1:]
+
+	tests := []struct {
+		name   string
+		backup string
+	}{
+		{"empty", ""},
+		{"not a backup", "{\"format\":\"other\",\"version\":1}\n"},
+		{"truncated in a table", valid[:strings.Index(valid, "2\t")]},
+		{"missing trailer", strings.TrimSuffix(valid, trailer)},
+		{"row added", strings.Replace(valid, "2\t", "3\tx\n2\t", 1)},
+		{"row removed", strings.Replace(valid, "1\ta@example.com\n", "", 1)},
+		{"trailing data", valid + "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readTestBackup(tt.backup)
+			assert.ErrorIs(t, err, ErrInvalidDatabaseBackup)
+		})
+	}
+}
+
+func TestBackupName(t *testing.T) {
+	createdAt := time.Date(2026, 10, 16, 3, 4, 5, 0, time.UTC)
+	name := BackupName(createdAt)
+	assert.Equal(t, "2fair-20261016T030405Z"+BackupExtension, name)
+
+	parsed, ok := ParseBackupName(name)
+	require.True(t, ok)
+	assert.True(t, createdAt.Equal(parsed))
+
+	for _, other := range []string{"notes.txt", "2fair-latest" + BackupExtension, "2fair-20261016T030405Z.tar"} {
+		_, ok := ParseBackupName(other)
+		assert.False(t, ok, other)
+	}
+}
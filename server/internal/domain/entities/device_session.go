@@ -1,65 +1,113 @@
 package entities
 
 import (
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
-// DeviceSession represents a user session on a specific device
+// MaxSessionNameLength bounds a session's device name
+const MaxSessionNameLength = 255
+
+// DeviceSession is one sign-in on a device. Every token names the session it was issued for, and
+// stops working once the session is revoked or times out.
 type DeviceSession struct {
 	ID             uuid.UUID `json:"id" db:"id"`
-	UserID         uuid.UUID `json:"user_id" db:"user_id"`
-	SessionToken   string    `json:"session_token" db:"session_token"`
-	DeviceInfo     string    `json:"device_info" db:"device_info"`
-	IPAddress      string    `json:"ip_address" db:"ip_address"`
-	UserAgent      string    `json:"user_agent" db:"user_agent"`
-	IsActive       bool      `json:"is_active" db:"is_active"`
-	LastActivityAt time.Time `json:"last_activity_at" db:"last_activity_at"`
-	ExpiresAt      time.Time `json:"expires_at" db:"expires_at"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	UserID         uuid.UUID `json:"userId" db:"user_id"`
+	DeviceID       string    `json:"deviceId,omitempty" db:"device_id"` // The X-Device-ID the session signed in with, if any
+	DeviceName     string    `json:"deviceName" db:"device_name"`       // Guessed from the user agent until the user renames it
+	IPAddress      string    `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent      string    `json:"userAgent,omitempty" db:"user_agent"`
+	Current        bool      `json:"current" db:"-"` // Whether this is the session making the request
+	LastActivityAt time.Time `json:"lastActivityAt" db:"last_activity_at"`
+	IdleExpiresAt  time.Time `json:"idleExpiresAt" db:"-"` // When the session times out unless used before
+	ExpiresAt      time.Time `json:"expiresAt" db:"expires_at"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
 }
 
-// IsExpired checks if the session has expired
-func (ds *DeviceSession) IsExpired() bool {
-	return time.Now().After(ds.ExpiresAt)
+// NewDeviceSession creates a session for a sign-in from the client described by info, ending
+// after lifetime at the latest. The ID is assigned when the session is stored.
+func NewDeviceSession(userID uuid.UUID, info RequestInfo, lifetime time.Duration) *DeviceSession {
+	now := time.Now()
+	return &DeviceSession{
+		UserID:         userID,
+		DeviceID:       info.DeviceID,
+		DeviceName:     deviceNameFromUserAgent(info.UserAgent),
+		IPAddress:      info.IPAddress,
+		UserAgent:      auditText(info.UserAgent, MaxAuditUserAgentLength),
+		LastActivityAt: now,
+		ExpiresAt:      now.Add(lifetime),
+		CreatedAt:      now,
+	}
 }
 
-// IsValid checks if the session is valid (active and not expired)
-func (ds *DeviceSession) IsValid() bool {
-	return ds.IsActive && !ds.IsExpired()
+// CheckActive reports why the session can no longer be used at now, or nil if it can
+func (ds *DeviceSession) CheckActive(now time.Time, idleTimeout time.Duration) error {
+	if !now.Before(ds.ExpiresAt) {
+		return fmt.Errorf("%w: signed in too long ago", ErrSessionExpired)
+	}
+	if !now.Before(ds.LastActivityAt.Add(idleTimeout)) {
+		return fmt.Errorf("%w: idle too long", ErrSessionExpired)
+	}
+	return nil
 }
 
-// Validate performs basic validation on the device session
-func (ds *DeviceSession) Validate() error {
-	if ds.UserID == uuid.Nil {
-		return ErrInvalidDevice
+// SetIdleExpiry fills in IdleExpiresAt, which is never later than ExpiresAt
+func (ds *DeviceSession) SetIdleExpiry(idleTimeout time.Duration) {
+	ds.IdleExpiresAt = ds.LastActivityAt.Add(idleTimeout)
+	if ds.IdleExpiresAt.After(ds.ExpiresAt) {
+		ds.IdleExpiresAt = ds.ExpiresAt
 	}
+}
 
-	if ds.SessionToken == "" {
-		return ErrInvalidDevice
+// ValidateSessionName trims a device name chosen by the user and checks it
+func ValidateSessionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: name must not be empty", ErrInvalidSessionName)
 	}
-
-	if ds.DeviceInfo == "" {
-		return ErrInvalidDevice
+	if !utf8.ValidString(name) || strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("%w: name must be valid text", ErrInvalidSessionName)
 	}
-
-	if ds.ExpiresAt.Before(time.Now()) {
-		return ErrDeviceExpired
+	if len(name) > MaxSessionNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d bytes", ErrInvalidSessionName, MaxSessionNameLength)
 	}
-
-	return nil
+	return name, nil
 }
 
-// UpdateActivity updates the last activity timestamp
-func (ds *DeviceSession) UpdateActivity() {
-	ds.LastActivityAt = time.Now()
-	ds.UpdatedAt = time.Now()
+// deviceNameFromUserAgent guesses a readable name such as "Firefox on Linux". Order matters:
+// Edge and Opera also claim to be Chrome, and Chrome claims to be Safari; likewise Android claims
+// to be Linux and iOS to be macOS.
+func deviceNameFromUserAgent(userAgent string) string {
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	})
+	platform := firstMatch(userAgent, [][2]string{
+		{"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Android", "Android"}, {"CrOS", "ChromeOS"},
+		{"Windows", "Windows"}, {"Macintosh", "macOS"}, {"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
 }
 
-// Deactivate marks the session as inactive
-func (ds *DeviceSession) Deactivate() {
-	ds.IsActive = false
-	ds.UpdatedAt = time.Now()
+// firstMatch returns the name paired with the first marker found in s
+func firstMatch(s string, markers [][2]string) string {
+	for _, marker := range markers {
+		if strings.Contains(s, marker[0]) {
+			return marker[1]
+		}
+	}
+	return ""
 }
@@ -0,0 +1,83 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDeviceSession(t *testing.T) {
+	userID := uuid.New()
+	info := RequestInfo{
+		DeviceID:  "laptop",
+		IPAddress: "203.0.113.7",
+		UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0",
+	}
+
+	session := NewDeviceSession(userID, info, 24*time.Hour)
+
+	assert.Equal(t, userID, session.UserID)
+	assert.Equal(t, "laptop", session.DeviceID)
+	assert.Equal(t, "Firefox on Linux", session.DeviceName)
+	assert.Equal(t, "203.0.113.7", session.IPAddress)
+	assert.Equal(t, session.LastActivityAt.Add(24*time.Hour), session.ExpiresAt)
+}
+
+func TestDeviceNameFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36 Edg/130.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36", "Chrome on macOS"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 18_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.0 Mobile/15E148 Safari/604.1", "Safari on iPhone"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.5.0", "Unknown device"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, deviceNameFromUserAgent(tt.userAgent))
+		})
+	}
+}
+
+func TestDeviceSessionCheckActive(t *testing.T) {
+	now := time.Now()
+	session := &DeviceSession{
+		LastActivityAt: now.Add(-time.Hour),
+		ExpiresAt:      now.Add(time.Hour),
+	}
+
+	assert.NoError(t, session.CheckActive(now, 2*time.Hour))
+	assert.ErrorIs(t, session.CheckActive(now, time.Hour), ErrSessionExpired, "idle for the whole timeout")
+	assert.ErrorIs(t, session.CheckActive(now.Add(time.Hour), 24*time.Hour), ErrSessionExpired, "past the absolute timeout")
+}
+
+func TestDeviceSessionSetIdleExpiry(t *testing.T) {
+	now := time.Now()
+	session := &DeviceSession{LastActivityAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	session.SetIdleExpiry(30 * time.Minute)
+	assert.Equal(t, now.Add(30*time.Minute), session.IdleExpiresAt)
+
+	// The idle timeout never outlasts the absolute one
+	session.SetIdleExpiry(2 * time.Hour)
+	assert.Equal(t, session.ExpiresAt, session.IdleExpiresAt)
+}
+
+func TestValidateSessionName(t *testing.T) {
+	name, err := ValidateSessionName("  Work laptop ")
+	require.NoError(t, err)
+	assert.Equal(t, "Work laptop", name)
+
+	for _, invalid := range []string{"", "   ", "bad\x00name", "\xff", strings.Repeat("a", MaxSessionNameLength+1)} {
+		_, err := ValidateSessionName(invalid)
+		assert.ErrorIs(t, err, ErrInvalidSessionName, "%q", invalid)
+	}
+}
@@ -6,33 +6,36 @@ import (
 	"github.com/google/uuid"
 )
 
-// UserEncryptionKey represents a wrapped Data Encryption Key (DEK) for a user
+// UserEncryptionKey represents a wrapped Data Encryption Key (DEK) for a user. Each of the user's
+// credentials holds its own wrap of the same DEK.
 type UserEncryptionKey struct {
-	ID         uuid.UUID `json:"id" db:"id"`
-	UserID     uuid.UUID `json:"userId" db:"user_id"`
-	KeyVersion int       `json:"keyVersion" db:"key_version"`
-	WrappedDEK []byte    `json:"wrappedDEK" db:"wrapped_dek"` // DEK encrypted with KEK from WebAuthn PRF
-	Salt       []byte    `json:"salt" db:"salt"`              // Salt used in HKDF for KEK->DEK derivation
-	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
-	IsActive   bool      `json:"isActive" db:"is_active"`
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"userId" db:"user_id"`
+	CredentialID uuid.UUID `json:"credentialId" db:"webauthn_credential_id"` // The credential whose KEK wraps the DEK
+	KeyVersion   int       `json:"keyVersion" db:"key_version"`
+	WrappedDEK   []byte    `json:"wrappedDEK" db:"wrapped_dek"` // DEK encrypted with KEK from WebAuthn PRF
+	Salt         []byte    `json:"salt" db:"salt"`              // Salt used in HKDF for KEK->DEK derivation
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	IsActive     bool      `json:"isActive" db:"is_active"`
 }
 
 // NewUserEncryptionKey creates a new user encryption key
-func NewUserEncryptionKey(userID uuid.UUID, keyVersion int, wrappedDEK, salt []byte) *UserEncryptionKey {
+func NewUserEncryptionKey(userID, credentialID uuid.UUID, keyVersion int, wrappedDEK, salt []byte) *UserEncryptionKey {
 	return &UserEncryptionKey{
-		ID:         uuid.New(),
-		UserID:     userID,
-		KeyVersion: keyVersion,
-		WrappedDEK: wrappedDEK,
-		Salt:       salt,
-		CreatedAt:  time.Now(),
-		IsActive:   true,
+		ID:           uuid.New(),
+		UserID:       userID,
+		CredentialID: credentialID,
+		KeyVersion:   keyVersion,
+		WrappedDEK:   wrappedDEK,
+		Salt:         salt,
+		CreatedAt:    time.Now(),
+		IsActive:     true,
 	}
 }
 
 // Validate validates the encryption key entity
 func (k *UserEncryptionKey) Validate() error {
-	if k.UserID == uuid.Nil {
+	if k.UserID == uuid.Nil || k.CredentialID == uuid.Nil {
 		return ErrInvalidEncryptionKey
 	}
 	if k.KeyVersion < 1 {
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserEncryptionKeyValidate(t *testing.T) {
+	userID, credentialID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		key     *UserEncryptionKey
+		wantErr bool
+	}{
+		{"valid", NewUserEncryptionKey(userID, credentialID, 1, []byte("wrapped"), []byte("salt")), false},
+		{"missing credential", NewUserEncryptionKey(userID, uuid.Nil, 1, []byte("wrapped"), []byte("salt")), true},
+		{"missing version", NewUserEncryptionKey(userID, credentialID, 0, []byte("wrapped"), []byte("salt")), true},
+		{"missing wrap", NewUserEncryptionKey(userID, credentialID, 1, nil, []byte("salt")), true},
+		{"missing salt", NewUserEncryptionKey(userID, credentialID, 1, []byte("wrapped"), nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.key.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidEncryptionKey)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -11,6 +11,16 @@ var (
 	ErrUserAlreadyExists  = errors.New("user already exists")
 )
 
+// Account access errors
+var (
+	ErrUserSuspended     = errors.New("account suspended")
+	ErrTokenRevoked      = errors.New("token revoked")
+	ErrTokenExpired      = errors.New("token expired")
+	ErrInvalidUserFilter = errors.New("invalid user filter")
+	ErrInvalidUserRole   = errors.New("invalid user role")
+	ErrCannotSuspendSelf = errors.New("admins cannot suspend their own account")
+)
+
 // WebAuthn credential errors
 var (
 	ErrInvalidCredential    = errors.New("invalid webauthn credential")
@@ -25,14 +35,46 @@ var (
 	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
 	ErrKeyNotFound          = errors.New("encryption key not found")
 	ErrKeyExpired           = errors.New("encryption key expired")
+	ErrKeyExists            = errors.New("credential already holds a wrapped key")
+	ErrLastKeyCredential    = errors.New("credential holds the last wrapped vault key")
+)
+
+// Key rotation errors
+var (
+	ErrKeyRotationNotFound   = errors.New("no key rotation in progress")
+	ErrKeyRotationInProgress = errors.New("a key rotation is already in progress")
+	ErrKeyRotationIncomplete = errors.New("entries are still encrypted with the old key")
 )
 
 // TOTP seed errors
 var (
-	ErrInvalidTOTPSeed  = errors.New("invalid TOTP seed")
-	ErrTOTPSeedNotFound = errors.New("TOTP seed not found")
-	ErrEncryptionFailed = errors.New("encryption failed")
-	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrInvalidTOTPSeed    = errors.New("invalid TOTP seed")
+	ErrTOTPSeedNotFound   = errors.New("TOTP seed not found")
+	ErrEncryptionFailed   = errors.New("encryption failed")
+	ErrDecryptionFailed   = errors.New("decryption failed")
+	ErrInvalidOTPAuthURL  = errors.New("invalid otpauth URL")
+	ErrNotHOTP            = errors.New("OTP entry is not counter-based")
+	ErrOTPBatchFailed     = errors.New("OTP batch failed")
+	ErrOTPBatchTooLarge   = errors.New("OTP batch exceeds the maximum number of operations")
+	ErrUnsupportedOTPType = errors.New("unsupported OTP type")
+	ErrRevisionNotFound   = errors.New("revision not found")
+	ErrRevisionMismatch   = errors.New("entry has changed since the given revision")
+	ErrRevisionKeyRetired = errors.New("revision is encrypted with a retired vault key")
+)
+
+// Tag and folder errors
+var (
+	ErrInvalidTag     = errors.New("invalid tag")
+	ErrTagNotFound    = errors.New("tag not found")
+	ErrInvalidFolder  = errors.New("invalid folder")
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrFolderExists   = errors.New("folder already exists")
+)
+
+// Listing errors
+var (
+	ErrInvalidOTPFilter = errors.New("invalid listing parameters")
+	ErrInvalidCursor    = errors.New("invalid cursor")
 )
 
 // Device session errors
@@ -43,11 +85,26 @@ var (
 	ErrDeviceNotActive = errors.New("device not active")
 )
 
+// Sign-in session errors
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrInvalidSessionName = errors.New("invalid session name")
+)
+
+// Refresh token errors
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
 // Sync operation errors
 var (
 	ErrSyncConflict     = errors.New("sync conflict")
 	ErrInvalidOperation = errors.New("invalid sync operation")
 	ErrSyncFailed       = errors.New("synchronization failed")
+	ErrConflictNotFound = errors.New("sync conflict not found")
+	ErrConflictResolved = errors.New("sync conflict already resolved")
 )
 
 // Backup and recovery errors
@@ -56,4 +113,25 @@ var (
 	ErrBackupNotFound    = errors.New("backup not found")
 	ErrRecoveryFailed    = errors.New("recovery failed")
 	ErrInvalidPassphrase = errors.New("invalid passphrase")
+	ErrRecoveryKitExists = errors.New("a recovery kit already exists")
+	ErrRecoveryLocked    = errors.New("too many failed recovery attempts")
+)
+
+// Vault export errors
+var (
+	ErrInvalidVaultExport = errors.New("invalid vault export")
+	ErrVaultKeyMismatch   = errors.New("vault export is not encrypted with the vault's key")
+)
+
+// Database backup errors
+var (
+	ErrInvalidDatabaseBackup  = errors.New("invalid database backup")
+	ErrDatabaseBackupNotFound = errors.New("database backup not found")
+	ErrDatabaseBackupSchema   = errors.New("database backup was taken at a different schema version")
+)
+
+// Audit log errors
+var (
+	ErrInvalidAuditFilter = errors.New("invalid audit log filter")
+	ErrAuditChainBroken   = errors.New("audit log chain is broken")
 )
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxFolderNameLength is the longest folder name accepted
+const MaxFolderNameLength = 100
+
+// Folder groups vault entries. Folders nest through ParentID; a nil parent is the top level.
+type Folder struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"userId" db:"user_id"`
+	ParentID   *uuid.UUID `json:"parentId" db:"parent_id"`
+	Name       string     `json:"name" db:"name"`
+	EntryCount int64      `json:"entryCount" db:"-"` // Active entries directly in this folder
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// NewFolder creates a new folder
+func NewFolder(userID uuid.UUID, name string, parentID *uuid.UUID) *Folder {
+	now := time.Now()
+	return &Folder{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ParentID:  parentID,
+		Name:      strings.TrimSpace(name),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Validate validates the folder
+func (f *Folder) Validate() error {
+	if f.UserID == uuid.Nil {
+		return ErrInvalidFolder
+	}
+	if f.Name == "" || len(f.Name) > MaxFolderNameLength {
+		return ErrInvalidFolder
+	}
+	if f.ParentID != nil && *f.ParentID == f.ID {
+		return ErrInvalidFolder
+	}
+	return nil
+}
+
+// Move renames the folder and places it under a new parent
+func (f *Folder) Move(name string, parentID *uuid.UUID) {
+	f.Name = strings.TrimSpace(name)
+	f.ParentID = parentID
+	f.UpdatedAt = time.Now()
+}
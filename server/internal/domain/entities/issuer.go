@@ -0,0 +1,13 @@
+package entities
+
+// Issuer is a known service in the bundled issuer catalog
+type Issuer struct {
+	Name      string   `json:"name"`    // Canonical display name
+	Aliases   []string `json:"aliases"` // Other names the service is known by
+	Domains   []string `json:"domains"`
+	IconURL   string   `json:"iconUrl"`
+	Type      string   `json:"type,omitempty"` // OTP type when not standard, e.g. steam
+	Digits    int      `json:"digits"`
+	Period    int      `json:"period"`
+	Algorithm string   `json:"algorithm"`
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Key rotation statuses
+const (
+	KeyRotationInProgress = "in_progress"
+	KeyRotationCompleted  = "completed"
+	KeyRotationAborted    = "aborted"
+)
+
+// MaxKeyRotationChunkSize is the most entries listed or staged at once during a rotation
+const MaxKeyRotationChunkSize = 100
+
+// KeyRotation moves a user's vault from one key version to the next. A device re-encrypts every
+// entry still on an older version and stages the results; committing swaps them all in at once and
+// makes the new key the active one. Staged entries survive a crash, so a device picks up where it
+// left off by listing what is still pending.
+type KeyRotation struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"userId" db:"user_id"`
+	FromVersion int        `json:"fromVersion" db:"from_version"`
+	ToVersion   int        `json:"toVersion" db:"to_version"`
+	Status      string     `json:"status" db:"status"`
+	Total       int64      `json:"total" db:"-"`  // Entries below the target version
+	Staged      int64      `json:"staged" db:"-"` // Entries with a ciphertext staged from their current revision
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"` // When an entry was last staged
+	CompletedAt *time.Time `json:"completedAt,omitempty" db:"completed_at"`
+}
+
+// Remaining returns how many entries still need to be re-encrypted
+func (r *KeyRotation) Remaining() int64 {
+	return r.Total - r.Staged
+}
+
+// KeyRotationEntry is an entry's secret during a rotation: as stored when listed for
+// re-encryption, and re-encrypted with the new key when staged. Revision ties the two together,
+// so a ciphertext made from an outdated version is rejected.
+type KeyRotationEntry struct {
+	ID         uuid.UUID `json:"id"`
+	Revision   int64     `json:"revision"`
+	KeyVersion int       `json:"keyVersion,omitempty"` // The version Secret is encrypted with; only set when listed
+	Secret     string    `json:"secret"`               // Client-encrypted secret envelope
+}
+
+// KeyRotationStageResult reports a staged chunk: the rotation's progress afterwards and the
+// entries that were not staged because they changed or are no longer pending
+type KeyRotationStageResult struct {
+	Rotation *KeyRotation `json:"rotation"`
+	Rejected []uuid.UUID  `json:"rejected"`
+}
@@ -0,0 +1,12 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotationRemaining(t *testing.T) {
+	rotation := &KeyRotation{Total: 5, Staged: 2}
+	assert.Equal(t, int64(3), rotation.Remaining())
+}
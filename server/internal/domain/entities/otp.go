@@ -1,24 +1,127 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// OTP represents a TOTP token entry in the vault
+// OTP methods
+const (
+	OTPMethodTOTP = "TOTP" // Time-based (RFC 6238)
+	OTPMethodHOTP = "HOTP" // Counter-based (RFC 4226)
+)
+
+// OTP types select the scheme that turns the HMAC of the moving factor into a code
+const (
+	OTPTypeStandard = "standard" // Decimal codes per RFC 4226/6238
+	OTPTypeSteam    = "steam"    // Steam Guard: 5 characters from a 26-letter alphabet
+	OTPTypeYandex   = "yandex"   // Yandex.Key: 8 letters, key derived from a PIN
+)
+
+// OTP represents a TOTP or HOTP token entry in the vault
 type OTP struct {
-	ID        uuid.UUID `json:"Id" db:"id"` // Frontend expects "Id"
-	UserID    uuid.UUID `json:"userId" db:"user_id"`
-	Issuer    string    `json:"Issuer" db:"issuer"`         // Frontend expects "Issuer"
-	Label     string    `json:"Label" db:"account_name"`    // Frontend expects "Label"
-	Secret    string    `json:"Secret" db:"-"`              // Frontend expects "Secret", never stored in DB
-	Period    int       `json:"Period" db:"-"`              // Frontend expects "Period", stored in encrypted data
-	Algorithm string    `json:"algorithm,omitempty" db:"-"` // Stored in encrypted data
-	Digits    int       `json:"digits,omitempty" db:"-"`    // Stored in encrypted data
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
-	IsActive  bool      `json:"isActive" db:"-"` // Computed from encrypted_totp_seeds table
+	ID         uuid.UUID  `json:"Id" db:"id"` // Frontend expects "Id"
+	UserID     uuid.UUID  `json:"userId" db:"user_id"`
+	Issuer     string     `json:"Issuer" db:"issuer"`              // Frontend expects "Issuer"
+	IconURL    string     `json:"IconUrl,omitempty" db:"icon_url"` // Set from the issuer catalog
+	Label      string     `json:"Label" db:"account_name"`         // Frontend expects "Label"
+	Secret     string     `json:"Secret" db:"-"`                   // Frontend expects "Secret", never stored in DB
+	Period     int        `json:"Period" db:"-"`                   // Frontend expects "Period", stored in encrypted data
+	Algorithm  string     `json:"algorithm,omitempty" db:"-"`      // Stored in encrypted data
+	Digits     int        `json:"digits,omitempty" db:"-"`         // Stored in encrypted data
+	Method     string     `json:"Method" db:"method"`              // TOTP or HOTP
+	Counter    int64      `json:"Counter" db:"counter"`            // Next HOTP counter value, coordinated server-side
+	Type       string     `json:"Type" db:"otp_type"`              // Code scheme: standard, steam or yandex
+	T0         int64      `json:"T0" db:"t0"`                      // Unix time TOTP steps are counted from (RFC 6238 T0)
+	Tags       []string   `json:"Tags" db:"tags"`
+	FolderID   *uuid.UUID `json:"FolderId" db:"folder_id"`     // nil when the entry is not in a folder
+	UseCount   int64      `json:"UseCount" db:"-"`             // Codes used, reported by clients; only set by listings
+	LastUsedAt *time.Time `json:"LastUsedAt,omitempty" db:"-"` // Only set by listings
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	Revision   int64      `json:"Revision" db:"revision"`              // Incremented by every change; served as the entry's ETag
+	KeyVersion int        `json:"KeyVersion" db:"key_version"`         // Version of the vault key Secret is encrypted with
+	IsActive   bool       `json:"isActive" db:"-"`                     // Computed from encrypted_totp_seeds table
+	DeletedAt  *time.Time `json:"DeletedAt,omitempty" db:"deleted_at"` // When the entry was moved to the trash
+	PurgeAt    *time.Time `json:"PurgeAt,omitempty" db:"-"`            // When a trashed entry will be permanently deleted
+}
+
+// OTP listing sort keys
+const (
+	OTPSortRelevance = "relevance" // Best fuzzy match first; the default when searching
+	OTPSortCreated   = "created"   // Newest first; the default otherwise
+	OTPSortUpdated   = "updated"   // Most recently changed first
+	OTPSortIssuer    = "issuer"    // A to Z
+	OTPSortLabel     = "label"     // A to Z
+	OTPSortMostUsed  = "most-used" // Most used first; pages shift as uses are recorded
+)
+
+// OTP listing orders
+const (
+	OTPOrderAsc  = "asc"
+	OTPOrderDesc = "desc"
+)
+
+// MaxOTPPageSize is the largest page a listing returns
+const MaxOTPPageSize = 500
+
+// OTPFilter narrows, orders and pages an entry listing; zero values match everything
+type OTPFilter struct {
+	Tag      string
+	FolderID *uuid.UUID // Includes entries in the folder's subfolders
+	Query    string     // Fuzzy match over issuer, label and tags
+	Sort     string     // One of the OTPSort keys
+	Order    string     // OTPOrderAsc or OTPOrderDesc; defaults to the sort key's natural order
+	Cursor   string     // Opaque cursor returned with the previous page
+	Limit    int        // Page size; 0 returns every match
+}
+
+// OTPPage is one page of an entry listing
+type OTPPage struct {
+	Items      []*OTP
+	NextCursor string // Empty on the last page
+	Total      int64  // Entries matching the filter across all pages
+}
+
+// Normalize applies the default sort, order and page size and validates them
+func (f *OTPFilter) Normalize() error {
+	if f.Sort == "" {
+		f.Sort = OTPSortCreated
+		if f.Query != "" {
+			f.Sort = OTPSortRelevance
+		}
+	}
+
+	switch f.Sort {
+	case OTPSortIssuer, OTPSortLabel:
+		if f.Order == "" {
+			f.Order = OTPOrderAsc
+		}
+	case OTPSortRelevance, OTPSortCreated, OTPSortUpdated, OTPSortMostUsed:
+		if f.Order == "" {
+			f.Order = OTPOrderDesc
+		}
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidOTPFilter, f.Sort)
+	}
+
+	if f.Order != OTPOrderAsc && f.Order != OTPOrderDesc {
+		return fmt.Errorf("%w: order must be %q or %q", ErrInvalidOTPFilter, OTPOrderAsc, OTPOrderDesc)
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidOTPFilter)
+	}
+	if f.Limit > MaxOTPPageSize {
+		f.Limit = MaxOTPPageSize
+	}
+	return nil
+}
+
+// Descending reports whether the listing runs in descending order
+func (f OTPFilter) Descending() bool {
+	return f.Order == OTPOrderDesc
 }
 
 // OTPCodes represents the current and next TOTP codes
@@ -41,6 +144,9 @@ func NewOTP(userID uuid.UUID, issuer, label, secret string, period int) *OTP {
 		Period:    period,
 		Algorithm: "SHA1", // Default algorithm
 		Digits:    6,      // Default digits
+		Method:    OTPMethodTOTP,
+		Type:      OTPTypeStandard,
+		Tags:      []string{},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		IsActive:  true,
@@ -64,9 +170,23 @@ func (o *OTP) Validate() error {
 	if o.Period <= 0 {
 		return ErrInvalidTOTPSeed
 	}
+	if o.Method != OTPMethodTOTP && o.Method != OTPMethodHOTP {
+		return ErrInvalidTOTPSeed
+	}
+	if o.Counter < 0 {
+		return ErrInvalidTOTPSeed
+	}
+	if o.Type == "" {
+		return ErrInvalidTOTPSeed
+	}
 	return nil
 }
 
+// IsHOTP reports whether the entry is counter-based
+func (o *OTP) IsHOTP() bool {
+	return o.Method == OTPMethodHOTP
+}
+
 // UpdateSecret updates the secret and related fields
 func (o *OTP) UpdateSecret(secret string, period int, algorithm string, digits int) {
 	o.Secret = secret
@@ -80,6 +200,30 @@ func (o *OTP) UpdateSecret(secret string, period int, algorithm string, digits i
 	o.UpdatedAt = time.Now()
 }
 
+// UpdateScheme updates the code scheme and TOTP start time
+func (o *OTP) UpdateScheme(otpType string, t0 int64) {
+	o.Type = otpType
+	o.T0 = t0
+	o.UpdatedAt = time.Now()
+}
+
+// Organize replaces the entry's tags and folder
+func (o *OTP) Organize(tags []string, folderID *uuid.UUID) {
+	o.Tags = tags
+	o.FolderID = folderID
+	o.UpdatedAt = time.Now()
+}
+
+// ApplyIssuer stores the canonical name and icon of a catalog issuer; nil clears the icon
+func (o *OTP) ApplyIssuer(issuer *Issuer) {
+	if issuer == nil {
+		o.IconURL = ""
+		return
+	}
+	o.Issuer = issuer.Name
+	o.IconURL = issuer.IconURL
+}
+
 // UpdateMetadata updates the issuer and label
 func (o *OTP) UpdateMetadata(issuer, label string) {
 	o.Issuer = issuer
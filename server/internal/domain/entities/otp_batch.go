@@ -0,0 +1,71 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// OTP batch operation types
+const (
+	OTPBatchCreate     = "create"
+	OTPBatchUpdate     = "update"
+	OTPBatchInactivate = "inactivate"
+)
+
+// OTP batch result statuses
+const (
+	OTPBatchStatusOK      = "ok"
+	OTPBatchStatusFailed  = "failed"
+	OTPBatchStatusSkipped = "skipped" // Not applied because another operation in the batch failed
+
+	// Failed because the entry has changed since the revision the operation is based on
+	OTPBatchStatusPreconditionFailed = "precondition_failed"
+)
+
+// OTPBatchOperation is a single create, update or inactivate request within an atomic batch
+type OTPBatchOperation struct {
+	Type      string
+	ID        uuid.UUID // Required for update and inactivate
+	Revision  *int64    // Required for update and inactivate: the revision the change is based on; 0 skips the check
+	Issuer    string
+	Label     string
+	Secret    string // Client-encrypted, in the secret envelope wire format
+	Period    int
+	Algorithm string
+	Digits    int
+	Method    string
+	Counter   int64
+	OTPType   string
+	T0        int64
+
+	// On update a nil Tags or FolderID keeps the current value; a FolderID of uuid.Nil
+	// moves the entry to the top level
+	Tags     []string
+	FolderID *uuid.UUID
+
+	// OTP is the validated entry the operation applies and Envelope its parsed secret, filled in
+	// by the service
+	OTP      *OTP
+	Envelope *SecretEnvelope
+}
+
+// OTPBatchResult reports the outcome of a single batch operation
+type OTPBatchResult struct {
+	Index  int       `json:"index"`
+	Type   string    `json:"type"`
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	OTP    *OTP      `json:"otp,omitempty"`
+}
+
+// Fail marks the result as failed with the given error
+func (r *OTPBatchResult) Fail(err error) {
+	r.Status = OTPBatchStatusFailed
+	if errors.Is(err, ErrRevisionMismatch) {
+		r.Status = OTPBatchStatusPreconditionFailed
+	}
+	r.Error = err.Error()
+	r.OTP = nil
+}
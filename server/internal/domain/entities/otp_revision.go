@@ -0,0 +1,48 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OTP revision reasons: the change that replaced the recorded version
+const (
+	OTPRevisionUpdate      = "update"
+	OTPRevisionRevert      = "revert"
+	OTPRevisionSync        = "sync"         // A change pushed by a device that was offline
+	OTPRevisionResolve     = "resolve"      // The version chosen to resolve a sync conflict
+	OTPRevisionImport      = "import"       // The version restored from a vault export
+	OTPRevisionKeyRotation = "key_rotation" // The version a vault key rotation re-encrypted
+)
+
+// OTPRevision is a past version of a vault entry, recorded just before a change replaced it.
+// Revisions are append-only, so a bad edit can always be reverted.
+type OTPRevision struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	OTPID     uuid.UUID  `json:"otpId" db:"seed_id"`
+	Reason    string     `json:"reason" db:"reason"`
+	Issuer    string     `json:"issuer" db:"service_name"`
+	IconURL   string     `json:"iconUrl,omitempty" db:"icon_url"`
+	Label     string     `json:"label" db:"account_identifier"`
+	Secret    string     `json:"secret" db:"encrypted_secret"` // Encrypted client-side, as stored at the time
+	Algorithm string     `json:"algorithm" db:"algorithm"`
+	Digits    int        `json:"digits" db:"digits"`
+	Period    int        `json:"period" db:"period"`
+	Method    string     `json:"method" db:"method"`
+	Counter   int64      `json:"counter" db:"counter"` // Informational; reverting never rewinds an HOTP counter
+	Type      string     `json:"type" db:"otp_type"`
+	T0        int64      `json:"t0" db:"t0"`
+	Tags      []string   `json:"tags" db:"tags"`
+	FolderID  *uuid.UUID `json:"folderId" db:"folder_id"`
+
+	// The device and request that made the change
+	DeviceID  string `json:"deviceId,omitempty" db:"device_id"`
+	IPAddress string `json:"ipAddress,omitempty" db:"ip_address"`
+	UserAgent string `json:"userAgent,omitempty" db:"user_agent"`
+	RequestID string `json:"requestId,omitempty" db:"request_id"`
+
+	Revision   int64     `json:"revision" db:"revision"`      // The entry's revision counter at this version
+	KeyVersion int       `json:"keyVersion" db:"key_version"` // Version of the vault key Secret is encrypted with
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`   // When this version was replaced
+}
@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTPFilter_Normalize(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        OTPFilter
+		expectedSort  string
+		expectedOrder string
+		expectedLimit int
+	}{
+		{
+			name:          "defaults to newest first",
+			filter:        OTPFilter{},
+			expectedSort:  OTPSortCreated,
+			expectedOrder: OTPOrderDesc,
+		},
+		{
+			name:          "search defaults to relevance",
+			filter:        OTPFilter{Query: "git"},
+			expectedSort:  OTPSortRelevance,
+			expectedOrder: OTPOrderDesc,
+		},
+		{
+			name:          "text sorts default to ascending",
+			filter:        OTPFilter{Sort: OTPSortIssuer},
+			expectedSort:  OTPSortIssuer,
+			expectedOrder: OTPOrderAsc,
+		},
+		{
+			name:          "explicit order is kept",
+			filter:        OTPFilter{Sort: OTPSortMostUsed, Order: OTPOrderAsc},
+			expectedSort:  OTPSortMostUsed,
+			expectedOrder: OTPOrderAsc,
+		},
+		{
+			name:          "limit is capped",
+			filter:        OTPFilter{Limit: MaxOTPPageSize + 1},
+			expectedSort:  OTPSortCreated,
+			expectedOrder: OTPOrderDesc,
+			expectedLimit: MaxOTPPageSize,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			require.NoError(t, filter.Normalize())
+			assert.Equal(t, tt.expectedSort, filter.Sort)
+			assert.Equal(t, tt.expectedOrder, filter.Order)
+			assert.Equal(t, tt.expectedLimit, filter.Limit)
+		})
+	}
+}
+
+func TestOTPFilter_Normalize_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter OTPFilter
+	}{
+		{
+			name:   "unknown sort",
+			filter: OTPFilter{Sort: "popularity"},
+		},
+		{
+			name:   "unknown order",
+			filter: OTPFilter{Order: "up"},
+		},
+		{
+			name:   "negative limit",
+			filter: OTPFilter{Limit: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.filter.Normalize(), ErrInvalidOTPFilter)
+		})
+	}
+}
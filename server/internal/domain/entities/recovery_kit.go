@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Recovery kit limits
+const (
+	MinRecoveryCodeLength = 16   // Characters; the code is derived from a high-entropy secret
+	MaxRecoveryCodeLength = 256  // Characters
+	MaxRecoveryBackupSize = 4096 // Bytes of wrapped key material
+)
+
+// RecoveryKit lets a user unlock the vault again after losing every passkey. The device generates a
+// recovery secret the user keeps offline and derives two values from it: a key that wraps the DEK and
+// a recovery code that proves knowledge of the secret. The server stores the wrap and an Argon2id hash
+// of the code and hands the wrap back only to someone presenting the code, so it never sees the DEK
+// or anything that unwraps it. A user has at most one usable kit, and it is spent once a new passkey
+// has been registered with it.
+type RecoveryKit struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	UserID          uuid.UUID  `json:"userId" db:"user_id"`
+	KeyVersion      int        `json:"keyVersion" db:"key_version"`             // The key version of the wrapped DEK
+	EncryptedBackup []byte     `json:"-" db:"encrypted_backup_data"`            // DEK wrapped with the key derived from the recovery secret
+	CodeHash        []byte     `json:"-" db:"recovery_code_hash"`               // Argon2id hash of the recovery code
+	FailedAttempts  int        `json:"-" db:"failed_attempts"`                  // Failed redemptions since the last success or lock
+	LockedUntil     *time.Time `json:"lockedUntil,omitempty" db:"locked_until"` // Redemption is refused until then
+	CreatedAt       time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NewRecoveryKit creates a new recovery kit
+func NewRecoveryKit(userID uuid.UUID, keyVersion int, encryptedBackup, codeHash []byte) *RecoveryKit {
+	return &RecoveryKit{
+		ID:              uuid.New(),
+		UserID:          userID,
+		KeyVersion:      keyVersion,
+		EncryptedBackup: encryptedBackup,
+		CodeHash:        codeHash,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// Validate validates the recovery kit entity
+func (k *RecoveryKit) Validate() error {
+	if k.UserID == uuid.Nil || k.KeyVersion < 1 {
+		return ErrInvalidBackup
+	}
+	if len(k.EncryptedBackup) == 0 || len(k.EncryptedBackup) > MaxRecoveryBackupSize {
+		return fmt.Errorf("%w: the wrapped key must be 1 to %d bytes", ErrInvalidBackup, MaxRecoveryBackupSize)
+	}
+	if len(k.CodeHash) == 0 {
+		return ErrInvalidBackup
+	}
+	return nil
+}
+
+// IsLocked reports whether redemption is refused at the given time
+func (k *RecoveryKit) IsLocked(now time.Time) bool {
+	return k.LockedUntil != nil && now.Before(*k.LockedUntil)
+}
+
+// ValidateRecoveryCode checks that a recovery code is long enough to resist guessing
+func ValidateRecoveryCode(code string) error {
+	if len(code) < MinRecoveryCodeLength || len(code) > MaxRecoveryCodeLength {
+		return fmt.Errorf("%w: the recovery code must be %d to %d characters", ErrInvalidBackup, MinRecoveryCodeLength, MaxRecoveryCodeLength)
+	}
+	return nil
+}
+
+// RecoveryRedemption is what a valid recovery code unlocks: the DEK wrapped with the key derived from
+// the recovery secret
+type RecoveryRedemption struct {
+	KitID           uuid.UUID `json:"kitId"`
+	KeyVersion      int       `json:"keyVersion"`
+	EncryptedBackup []byte    `json:"encryptedBackup"`
+}
+
+// RecoveryReregistration reports a completed recovery: the new passkey's wrap of the vault key and
+// the kit that replaces the spent one
+type RecoveryReregistration struct {
+	Key *UserEncryptionKey `json:"key"`
+	Kit *RecoveryKit       `json:"kit"`
+}
@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryKitValidate(t *testing.T) {
+	userID := uuid.New()
+	hash := []byte("$argon2id$...")
+
+	tests := []struct {
+		name    string
+		kit     *RecoveryKit
+		wantErr bool
+	}{
+		{"valid", NewRecoveryKit(userID, 1, []byte("wrapped"), hash), false},
+		{"missing user", NewRecoveryKit(uuid.Nil, 1, []byte("wrapped"), hash), true},
+		{"missing version", NewRecoveryKit(userID, 0, []byte("wrapped"), hash), true},
+		{"missing wrap", NewRecoveryKit(userID, 1, nil, hash), true},
+		{"oversized wrap", NewRecoveryKit(userID, 1, make([]byte, MaxRecoveryBackupSize+1), hash), true},
+		{"missing hash", NewRecoveryKit(userID, 1, []byte("wrapped"), nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kit.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidBackup)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRecoveryKitIsLocked(t *testing.T) {
+	now := time.Now()
+	kit := NewRecoveryKit(uuid.New(), 1, []byte("wrapped"), []byte("hash"))
+	assert.False(t, kit.IsLocked(now))
+
+	until := now.Add(time.Minute)
+	kit.LockedUntil = &until
+	assert.True(t, kit.IsLocked(now))
+	assert.False(t, kit.IsLocked(until))
+}
+
+func TestValidateRecoveryCode(t *testing.T) {
+	assert.NoError(t, ValidateRecoveryCode(strings.Repeat("a", MinRecoveryCodeLength)))
+	assert.NoError(t, ValidateRecoveryCode(strings.Repeat("a", MaxRecoveryCodeLength)))
+	assert.ErrorIs(t, ValidateRecoveryCode(strings.Repeat("a", MinRecoveryCodeLength-1)), ErrInvalidBackup)
+	assert.ErrorIs(t, ValidateRecoveryCode(strings.Repeat("a", MaxRecoveryCodeLength+1)), ErrInvalidBackup)
+}
@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a stored opaque refresh token. Only its hash is kept. Each refresh rotates the
+// token, so the tokens issued for one session form a family; a rotated token presented again
+// means the family leaked, and the session is revoked.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"userId" db:"user_id"`
+	SessionID uuid.UUID  `json:"sessionId" db:"session_id"` // The family the token belongs to
+	TokenHash []byte     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expiresAt" db:"expires_at"`
+	UsedAt    *time.Time `json:"usedAt,omitempty" db:"used_at"` // When the token was rotated
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+}
+
+// NewRefreshToken creates a token of the session's family stored under hash, usable for
+// lifetime. The ID is assigned when the token is stored.
+func NewRefreshToken(userID, sessionID uuid.UUID, hash []byte, lifetime time.Duration) *RefreshToken {
+	now := time.Now()
+	return &RefreshToken{
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(lifetime),
+		CreatedAt: now,
+	}
+}
+
+// IsUsed reports whether the token was already rotated
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsExpired reports whether the token can no longer be used at now
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenLifecycle(t *testing.T) {
+	userID, sessionID := uuid.New(), uuid.New()
+	token := NewRefreshToken(userID, sessionID, []byte("hash"), time.Hour)
+
+	assert.Equal(t, userID, token.UserID)
+	assert.Equal(t, sessionID, token.SessionID)
+	assert.False(t, token.IsUsed())
+	assert.False(t, token.IsExpired(time.Now()))
+	assert.True(t, token.IsExpired(token.ExpiresAt), "expires at ExpiresAt itself")
+
+	usedAt := time.Now()
+	token.UsedAt = &usedAt
+	assert.True(t, token.IsUsed())
+}
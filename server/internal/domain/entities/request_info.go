@@ -0,0 +1,25 @@
+package entities
+
+import "context"
+
+// RequestInfo identifies the client a change came from. It travels in the request context so
+// that history, sync and audit records can name the device and request behind each change.
+type RequestInfo struct {
+	DeviceID  string // Stable device identifier sent in the X-Device-ID header
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type requestInfoKey struct{}
+
+// ContextWithRequestInfo returns a copy of ctx carrying info
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info carried by ctx, or the zero value if there is none
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
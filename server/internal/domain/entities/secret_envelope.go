@@ -0,0 +1,143 @@
+package entities
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SecretEnvelopeFormat is the version of the envelope layout clients send and receive
+const SecretEnvelopeFormat = 1
+
+// MaxSecretCiphertextSize bounds an envelope's ciphertext; an encrypted OTP secret is far smaller
+const MaxSecretCiphertextSize = 1024
+
+// CipherSuite identifies the algorithm a secret is encrypted with
+type CipherSuite int
+
+// Cipher suites. IDs are stored, so they are never reused.
+const (
+	CipherSuiteAES256GCM CipherSuite = 1 // AES-256-GCM with a 96-bit IV and a 128-bit tag
+)
+
+// cipherSuiteSpec holds the sizes an envelope's parts must have under a suite
+type cipherSuiteSpec struct {
+	IVSize      int
+	AuthTagSize int
+}
+
+var cipherSuites = map[CipherSuite]cipherSuiteSpec{
+	CipherSuiteAES256GCM: {IVSize: 12, AuthTagSize: 16},
+}
+
+// IsValid checks that the suite is one the server knows
+func (s CipherSuite) IsValid() bool {
+	_, ok := cipherSuites[s]
+	return ok
+}
+
+// SecretEnvelope is a client-encrypted secret with everything needed to decrypt it but the key.
+// On the wire it is "v1.<suite>.<keyVersion>.<iv>.<authTag>.<ciphertext>" with the binary parts
+// standard base64 encoded; the server stores each part in its own column.
+type SecretEnvelope struct {
+	Format     int
+	Suite      CipherSuite
+	KeyVersion int
+	IV         []byte
+	AuthTag    []byte
+	Ciphertext []byte
+}
+
+// ParseSecretEnvelope decodes and validates an envelope in its wire format
+func ParseSecretEnvelope(secret string) (*SecretEnvelope, error) {
+	parts := strings.Split(secret, ".")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("%w: secret must be v1.suite.keyVersion.iv.authTag.ciphertext", ErrInvalidTOTPSeed)
+	}
+	if parts[0] != "v"+strconv.Itoa(SecretEnvelopeFormat) {
+		return nil, fmt.Errorf("%w: unsupported secret format %q", ErrInvalidTOTPSeed, parts[0])
+	}
+
+	suite, err := parseEnvelopeNumber(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cipher suite", ErrInvalidTOTPSeed)
+	}
+	keyVersion, err := parseEnvelopeNumber(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid key version", ErrInvalidTOTPSeed)
+	}
+
+	decoded := make([][]byte, 3)
+	for i, part := range parts[3:] {
+		data, err := base64.StdEncoding.Strict().DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: secret is not base64 encoded", ErrInvalidTOTPSeed)
+		}
+		decoded[i] = data
+	}
+
+	envelope := &SecretEnvelope{
+		Format:     SecretEnvelopeFormat,
+		Suite:      CipherSuite(suite),
+		KeyVersion: keyVersion,
+		IV:         decoded[0],
+		AuthTag:    decoded[1],
+		Ciphertext: decoded[2],
+	}
+	if err := envelope.Validate(); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// parseEnvelopeNumber parses a positive number written without sign or leading zeros
+func parseEnvelopeNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || strconv.Itoa(n) != s {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// Validate checks the envelope's format and suite and the sizes of its parts
+func (e *SecretEnvelope) Validate() error {
+	if e.Format != SecretEnvelopeFormat {
+		return fmt.Errorf("%w: unsupported secret format %d", ErrInvalidTOTPSeed, e.Format)
+	}
+	spec, ok := cipherSuites[e.Suite]
+	if !ok {
+		return fmt.Errorf("%w: unsupported cipher suite %d", ErrInvalidTOTPSeed, e.Suite)
+	}
+	if e.KeyVersion < 1 {
+		return fmt.Errorf("%w: invalid key version", ErrInvalidTOTPSeed)
+	}
+	if len(e.IV) != spec.IVSize || len(e.AuthTag) != spec.AuthTagSize {
+		return fmt.Errorf("%w: cipher suite %d needs a %d-byte IV and a %d-byte auth tag", ErrInvalidTOTPSeed, e.Suite, spec.IVSize, spec.AuthTagSize)
+	}
+	if len(e.Ciphertext) == 0 || len(e.Ciphertext) > MaxSecretCiphertextSize {
+		return fmt.Errorf("%w: ciphertext must be 1 to %d bytes", ErrInvalidTOTPSeed, MaxSecretCiphertextSize)
+	}
+	return nil
+}
+
+// CheckKeyVersion checks that the envelope is encrypted with the expected version of the vault key
+func (e *SecretEnvelope) CheckKeyVersion(keyVersion int) error {
+	if e.KeyVersion != keyVersion {
+		return fmt.Errorf("%w: secret is encrypted with key version %d, expected %d", ErrInvalidTOTPSeed, e.KeyVersion, keyVersion)
+	}
+	return nil
+}
+
+// String encodes the envelope in its wire format
+func (e *SecretEnvelope) String() string {
+	return strings.Join([]string{
+		"v" + strconv.Itoa(e.Format),
+		strconv.Itoa(int(e.Suite)),
+		strconv.Itoa(e.KeyVersion),
+		base64.StdEncoding.EncodeToString(e.IV),
+		base64.StdEncoding.EncodeToString(e.AuthTag),
+		base64.StdEncoding.EncodeToString(e.Ciphertext),
+	}, ".")
+}
@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretEnvelope(t *testing.T) {
+	valid := &SecretEnvelope{
+		Format:     SecretEnvelopeFormat,
+		Suite:      CipherSuiteAES256GCM,
+		KeyVersion: 2,
+		IV:         bytes.Repeat([]byte{1}, 12),
+		AuthTag:    bytes.Repeat([]byte{2}, 16),
+		Ciphertext: []byte("ciphertext"),
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		parsed, err := ParseSecretEnvelope(valid.String())
+		require.NoError(t, err)
+		assert.Equal(t, valid, parsed)
+	})
+
+	modified := func(modify func(e *SecretEnvelope)) string {
+		e := *valid
+		modify(&e)
+		return e.String()
+	}
+	wire := strings.Split(valid.String(), ".")
+	replaced := func(i int, part string) string {
+		parts := append([]string(nil), wire...)
+		parts[i] = part
+		return strings.Join(parts, ".")
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"empty", ""},
+		{"legacy format", "Y2lwaGVy.AQEBAQEBAQEBAQEB.AgICAgICAgICAgICAgICAg=="},
+		{"unknown format", replaced(0, "v2")},
+		{"unknown suite", replaced(1, "9")},
+		{"suite with leading zero", replaced(1, "01")},
+		{"zero key version", replaced(2, "0")},
+		{"signed key version", replaced(2, "+2")},
+		{"not base64", replaced(5, "!!!")},
+		{"unpadded base64", replaced(5, strings.TrimRight(wire[5], "="))},
+		{"empty ciphertext", modified(func(e *SecretEnvelope) { e.Ciphertext = nil })},
+		{"oversized ciphertext", modified(func(e *SecretEnvelope) { e.Ciphertext = make([]byte, MaxSecretCiphertextSize+1) })},
+		{"short IV", modified(func(e *SecretEnvelope) { e.IV = e.IV[:8] })},
+		{"short auth tag", modified(func(e *SecretEnvelope) { e.AuthTag = e.AuthTag[:12] })},
+		{"extra part", valid.String() + ".AA=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSecretEnvelope(tt.secret)
+			assert.ErrorIs(t, err, ErrInvalidTOTPSeed)
+		})
+	}
+}
+
+func TestSecretEnvelope_CheckKeyVersion(t *testing.T) {
+	envelope := &SecretEnvelope{KeyVersion: 2}
+
+	assert.NoError(t, envelope.CheckKeyVersion(2))
+	assert.ErrorIs(t, envelope.CheckKeyVersion(3), ErrInvalidTOTPSeed)
+}
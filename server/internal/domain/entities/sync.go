@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxDeviceFingerprintLength is the longest device fingerprint accepted
+const MaxDeviceFingerprintLength = 255
+
+// Sync operation types, recorded for every change to a vault entry
+const (
+	SyncOperationCreate  = "create"
+	SyncOperationUpdate  = "update"
+	SyncOperationDelete  = "delete"  // Moved to the trash
+	SyncOperationRestore = "restore" // Moved back out of the trash
+	SyncOperationPurge   = "purge"   // Permanently deleted
+)
+
+// SyncDevice is a device that syncs the vault, identified by a fingerprint the device generates
+type SyncDevice struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Fingerprint string    `json:"fingerprint" db:"device_fingerprint"`
+	Name        string    `json:"name,omitempty" db:"device_name"`
+	LastSyncAt  time.Time `json:"lastSyncAt" db:"last_sync_at"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// OTPTombstone tells a device to drop an entry it holds: the entry was moved to the trash or
+// permanently deleted since the device last synced
+type OTPTombstone struct {
+	ID        uuid.UUID  `json:"id"`
+	Revision  int64      `json:"revision,omitempty"`  // Unset once the entry is purged
+	DeletedAt *time.Time `json:"deletedAt,omitempty"` // Unset once the entry is purged
+	Purged    bool       `json:"purged"`
+}
+
+// SyncChanges is the set of changes to a user's vault between two sync cursors. Each entry
+// appears at most once, in its current state; a device applies Created and Updated as upserts.
+type SyncChanges struct {
+	Created []*OTP          `json:"created"`
+	Updated []*OTP          `json:"updated"`
+	Deleted []*OTPTombstone `json:"deleted"`
+	Cursor  string          `json:"cursor"` // Send back on the next sync to receive only later changes
+	Full    bool            `json:"full"`   // True when no cursor was given and Created holds the whole vault
+}
@@ -0,0 +1,136 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sync change types a device can push
+const (
+	SyncChangeUpdate = "update"
+	SyncChangeDelete = "delete" // Move to the trash
+)
+
+// Sync change result statuses
+const (
+	SyncChangeApplied  = "applied"
+	SyncChangeConflict = "conflict" // Recorded as a conflict; nothing was changed
+	SyncChangeFailed   = "failed"
+)
+
+// Sync conflict statuses
+const (
+	SyncConflictOpen     = "open"
+	SyncConflictResolved = "resolved"
+)
+
+// Sync conflict resolutions
+const (
+	SyncResolutionServer = "server" // Keep the version on the server
+	SyncResolutionClient = "client" // Apply the version the device pushed
+	SyncResolutionMerged = "merged" // Apply a version the device merged from both
+)
+
+// OTPVersion is the content of a vault entry as one side of a conflict sees it: its encrypted
+// secret and metadata, or its deletion. The server compares versions but never decrypts them.
+type OTPVersion struct {
+	Deleted    bool       `json:"deleted"`
+	Revision   int64      `json:"revision,omitempty"` // The entry's revision; only set on server versions
+	Issuer     string     `json:"issuer,omitempty"`
+	IconURL    string     `json:"iconUrl,omitempty"`
+	Label      string     `json:"label,omitempty"`
+	Secret     string     `json:"secret,omitempty"`     // Client-encrypted secret envelope
+	KeyVersion int        `json:"keyVersion,omitempty"` // Version of the vault key Secret is encrypted with; 0 means the current key
+	Algorithm  string     `json:"algorithm,omitempty"`
+	Digits     int        `json:"digits,omitempty"`
+	Period     int        `json:"period,omitempty"`
+	Type       string     `json:"type,omitempty"`
+	T0         int64      `json:"t0,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	FolderID   *uuid.UUID `json:"folderId,omitempty"`
+}
+
+// SameContent reports whether two versions hold the same entry, ignoring their revisions.
+// Two devices that made the same change have not diverged.
+func (v *OTPVersion) SameContent(other *OTPVersion) bool {
+	if v.Deleted || other.Deleted {
+		return v.Deleted == other.Deleted
+	}
+
+	sameFolder := (v.FolderID == nil && other.FolderID == nil) ||
+		(v.FolderID != nil && other.FolderID != nil && *v.FolderID == *other.FolderID)
+
+	return sameFolder &&
+		v.Issuer == other.Issuer &&
+		v.Label == other.Label &&
+		v.Secret == other.Secret &&
+		v.Algorithm == other.Algorithm &&
+		v.Digits == other.Digits &&
+		v.Period == other.Period &&
+		v.Type == other.Type &&
+		v.T0 == other.T0 &&
+		slices.Equal(v.Tags, other.Tags)
+}
+
+// SyncChange is an edit a device made offline, pushed together with the revision of the entry
+// it was made against
+type SyncChange struct {
+	Type         string
+	ID           uuid.UUID
+	BaseRevision int64
+
+	// Version is the entry after the change, validated by the service. Deletes carry a deleted version.
+	Version *OTPVersion
+}
+
+// SyncChangeResult reports the outcome of a single pushed change
+type SyncChangeResult struct {
+	Index    int           `json:"index"`
+	Type     string        `json:"type"`
+	ID       uuid.UUID     `json:"id"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	OTP      *OTP          `json:"otp,omitempty"`      // The entry after an applied update
+	Conflict *SyncConflict `json:"conflict,omitempty"` // Set when the status is conflict
+}
+
+// Fail marks the result as failed with the given error
+func (r *SyncChangeResult) Fail(err error) {
+	r.Status = SyncChangeFailed
+	r.Error = err.Error()
+	r.OTP = nil
+	r.Conflict = nil
+}
+
+// SyncConflict records a change pushed against a revision the entry has since moved past. Both
+// versions are kept until the user picks one or merges them on a device.
+type SyncConflict struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	OTPID        uuid.UUID  `json:"otpId" db:"seed_id"`
+	DeviceID     string     `json:"deviceId,omitempty" db:"device_id"` // The device that pushed the change
+	BaseRevision int64      `json:"baseRevision" db:"base_revision"`   // The revision the change was made against
+	Server       OTPVersion `json:"server" db:"server_version"`        // The entry when the change arrived
+	Client       OTPVersion `json:"client" db:"client_version"`        // The change the device pushed
+	Status       string     `json:"status" db:"status"`
+	Resolution   string     `json:"resolution,omitempty" db:"resolution"`
+	CreatedAt    time.Time  `json:"createdAt" db:"created_at"`
+	ResolvedAt   *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+// SyncResolution settles a conflict
+type SyncResolution struct {
+	Choice string // One of the SyncResolution values
+
+	// Version is applied for the client and merged choices, validated by the service
+	Version *OTPVersion
+
+	// ExpectedRevision is the entry revision the resolution was decided against; 0 means the
+	// server version recorded in the conflict
+	ExpectedRevision int64
+
+	// KeyVersion is the user's current vault key version, filled in by the service for the client
+	// choice; a client version encrypted with another key cannot be applied
+	KeyVersion int
+}
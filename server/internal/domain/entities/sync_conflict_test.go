@@ -0,0 +1,88 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTPVersionSameContent(t *testing.T) {
+	folderID := uuid.New()
+	base := func() *OTPVersion {
+		return &OTPVersion{
+			Revision:  3,
+			Issuer:    "GitHub",
+			Label:     "alice",
+			Secret:    "ciphertext.iv.authTag",
+			Algorithm: "SHA1",
+			Digits:    6,
+			Period:    30,
+			Type:      OTPTypeStandard,
+			Tags:      []string{"work"},
+			FolderID:  &folderID,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(v *OTPVersion)
+		expected bool
+	}{
+		{
+			name:     "identical",
+			modify:   func(v *OTPVersion) {},
+			expected: true,
+		},
+		{
+			name:     "revision is ignored",
+			modify:   func(v *OTPVersion) { v.Revision = 7 },
+			expected: true,
+		},
+		{
+			name:     "same folder by value",
+			modify:   func(v *OTPVersion) { id := folderID; v.FolderID = &id },
+			expected: true,
+		},
+		{
+			name:     "different ciphertext",
+			modify:   func(v *OTPVersion) { v.Secret = "other.iv.authTag" },
+			expected: false,
+		},
+		{
+			name:     "different label",
+			modify:   func(v *OTPVersion) { v.Label = "bob" },
+			expected: false,
+		},
+		{
+			name:     "different tag order",
+			modify:   func(v *OTPVersion) { v.Tags = []string{"work", "personal"} },
+			expected: false,
+		},
+		{
+			name:     "moved to the top level",
+			modify:   func(v *OTPVersion) { v.FolderID = nil },
+			expected: false,
+		},
+		{
+			name:     "deleted on one side",
+			modify:   func(v *OTPVersion) { v.Deleted = true },
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := base()
+			tt.modify(other)
+			assert.Equal(t, tt.expected, base().SameContent(other))
+			assert.Equal(t, tt.expected, other.SameContent(base()))
+		})
+	}
+
+	t.Run("deleted on both sides", func(t *testing.T) {
+		deleted := base()
+		deleted.Deleted = true
+		assert.True(t, deleted.SameContent(&OTPVersion{Deleted: true}))
+	})
+}
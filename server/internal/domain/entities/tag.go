@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tag limits per vault entry
+const (
+	MaxTagsPerEntry = 20
+	MaxTagLength    = 50
+)
+
+// TagCount is a tag together with the number of active entries carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// NormalizeTag trims a tag and checks its length
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", fmt.Errorf("%w: tag must not be empty", ErrInvalidTag)
+	}
+	if len(tag) > MaxTagLength {
+		return "", fmt.Errorf("%w: tag %q exceeds %d characters", ErrInvalidTag, tag, MaxTagLength)
+	}
+	return tag, nil
+}
+
+// NormalizeTags trims and deduplicates tags, keeping their order. Empty tags are dropped.
+func NormalizeTags(tags []string) ([]string, error) {
+	clean := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			clean = append(clean, tag)
+			seen[tag] = true
+		}
+	}
+
+	if len(clean) > MaxTagsPerEntry {
+		return nil, fmt.Errorf("%w: at most %d tags per entry", ErrInvalidTag, MaxTagsPerEntry)
+	}
+	return clean, nil
+}
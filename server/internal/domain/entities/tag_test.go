@@ -0,0 +1,85 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+	}{
+		{
+			name:     "nil tags",
+			tags:     nil,
+			expected: []string{},
+		},
+		{
+			name:     "trims whitespace",
+			tags:     []string{"  work ", "personal"},
+			expected: []string{"work", "personal"},
+		},
+		{
+			name:     "drops empty tags",
+			tags:     []string{"", "work", "   "},
+			expected: []string{"work"},
+		},
+		{
+			name:     "deduplicates keeping first occurrence",
+			tags:     []string{"work", "finance", " work"},
+			expected: []string{"work", "finance"},
+		},
+		{
+			name:     "case is significant",
+			tags:     []string{"Work", "work"},
+			expected: []string{"Work", "work"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := NormalizeTags(tt.tags)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, tags)
+		})
+	}
+}
+
+func TestNormalizeTags_Invalid(t *testing.T) {
+	tooMany := make([]string, MaxTagsPerEntry+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag-%d", i)
+	}
+
+	tests := []struct {
+		name string
+		tags []string
+	}{
+		{
+			name: "too many tags",
+			tags: tooMany,
+		},
+		{
+			name: "tag too long",
+			tags: []string{strings.Repeat("a", MaxTagLength+1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NormalizeTags(tt.tags)
+			assert.ErrorIs(t, err, ErrInvalidTag)
+		})
+	}
+}
+
+func TestNormalizeTag_Empty(t *testing.T) {
+	_, err := NormalizeTag("   ")
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
@@ -15,10 +15,11 @@ type EncryptedTOTPSeed struct {
 	UserID     uuid.UUID `json:"userId" db:"user_id"`
 	KeyVersion int       `json:"keyVersion" db:"key_version"`
 
-	// Encrypted payload (AES-GCM)
-	Ciphertext []byte `json:"ciphertext" db:"ciphertext"` // AES-GCM encrypted TOTP seed + metadata
-	IV         []byte `json:"iv" db:"iv"`                 // AES-GCM initialization vector (96 bits)
-	AuthTag    []byte `json:"authTag" db:"auth_tag"`      // AES-GCM authentication tag
+	// Encrypted payload, as taken from a secret envelope
+	CipherSuite CipherSuite `json:"cipherSuite" db:"cipher_suite"`
+	Ciphertext  []byte      `json:"ciphertext" db:"encrypted_secret"` // Encrypted TOTP seed + metadata
+	IV          []byte      `json:"iv" db:"secret_iv"`                // Initialization vector, sized by the suite
+	AuthTag     []byte      `json:"authTag" db:"secret_tag"`          // Authentication tag, sized by the suite
 
 	// Searchable metadata (never encrypted for UX)
 	Issuer      string         `json:"issuer" db:"issuer" validate:"required,max=255"`
@@ -30,25 +31,20 @@ type EncryptedTOTPSeed struct {
 	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
 	SyncedAt  time.Time `json:"syncedAt" db:"synced_at"`
-}
-
-// EncryptedData represents the encrypted components of a TOTP seed
-type EncryptedData struct {
-	Ciphertext []byte `json:"ciphertext"`
-	IV         []byte `json:"iv"`
-	AuthTag    []byte `json:"authTag"`
+	Revision  int64     `json:"revision" db:"revision"`
 }
 
 // NewEncryptedTOTPSeed creates a new encrypted TOTP seed
-func NewEncryptedTOTPSeed(userID uuid.UUID, keyVersion int, issuer, accountName string, encryptedData EncryptedData) *EncryptedTOTPSeed {
+func NewEncryptedTOTPSeed(userID uuid.UUID, issuer, accountName string, envelope *SecretEnvelope) *EncryptedTOTPSeed {
 	now := time.Now()
 	return &EncryptedTOTPSeed{
 		ID:          uuid.New(),
 		UserID:      userID,
-		KeyVersion:  keyVersion,
-		Ciphertext:  encryptedData.Ciphertext,
-		IV:          encryptedData.IV,
-		AuthTag:     encryptedData.AuthTag,
+		KeyVersion:  envelope.KeyVersion,
+		CipherSuite: envelope.Suite,
+		Ciphertext:  envelope.Ciphertext,
+		IV:          envelope.IV,
+		AuthTag:     envelope.AuthTag,
 		Issuer:      issuer,
 		AccountName: accountName,
 		Tags:        pq.StringArray{},
@@ -63,17 +59,8 @@ func (e *EncryptedTOTPSeed) Validate() error {
 	if e.UserID == uuid.Nil {
 		return ErrInvalidTOTPSeed
 	}
-	if e.KeyVersion < 1 {
-		return ErrInvalidTOTPSeed
-	}
-	if len(e.Ciphertext) == 0 {
-		return ErrInvalidTOTPSeed
-	}
-	if len(e.IV) != 12 { // AES-GCM IV should be 96 bits (12 bytes)
-		return ErrInvalidTOTPSeed
-	}
-	if len(e.AuthTag) != 16 { // AES-GCM auth tag should be 128 bits (16 bytes)
-		return ErrInvalidTOTPSeed
+	if err := e.Envelope().Validate(); err != nil {
+		return err
 	}
 	if strings.TrimSpace(e.Issuer) == "" {
 		return ErrInvalidTOTPSeed
@@ -84,12 +71,13 @@ func (e *EncryptedTOTPSeed) Validate() error {
 	return nil
 }
 
-// UpdateEncryption updates the encrypted data for the TOTP seed
-func (e *EncryptedTOTPSeed) UpdateEncryption(encryptedData EncryptedData, keyVersion int) {
-	e.Ciphertext = encryptedData.Ciphertext
-	e.IV = encryptedData.IV
-	e.AuthTag = encryptedData.AuthTag
-	e.KeyVersion = keyVersion
+// UpdateEncryption replaces the TOTP seed's encrypted payload
+func (e *EncryptedTOTPSeed) UpdateEncryption(envelope *SecretEnvelope) {
+	e.KeyVersion = envelope.KeyVersion
+	e.CipherSuite = envelope.Suite
+	e.Ciphertext = envelope.Ciphertext
+	e.IV = envelope.IV
+	e.AuthTag = envelope.AuthTag
 	e.UpdatedAt = time.Now()
 }
 
@@ -102,12 +90,15 @@ func (e *EncryptedTOTPSeed) UpdateMetadata(issuer, accountName string, iconURL *
 	e.UpdatedAt = time.Now()
 }
 
-// GetEncryptedData returns the encrypted data components
-func (e *EncryptedTOTPSeed) GetEncryptedData() EncryptedData {
-	return EncryptedData{
-		Ciphertext: e.Ciphertext,
+// Envelope returns the encrypted payload as a secret envelope
+func (e *EncryptedTOTPSeed) Envelope() *SecretEnvelope {
+	return &SecretEnvelope{
+		Format:     SecretEnvelopeFormat,
+		Suite:      e.CipherSuite,
+		KeyVersion: e.KeyVersion,
 		IV:         e.IV,
 		AuthTag:    e.AuthTag,
+		Ciphertext: e.Ciphertext,
 	}
 }
 
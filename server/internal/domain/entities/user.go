@@ -1,11 +1,33 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// User roles
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin" // Manages other accounts through the admin API
+)
+
+// User account statuses, for filtering the admin user listing
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+)
+
+// Admin user listing page sizes
+const (
+	DefaultUserPageSize = 50
+	MaxUserPageSize     = 200
+)
+
+// UserStatsWindow is the period the admin stats count new and signed-in users over
+const UserStatsWindow = 30 * 24 * time.Hour
+
 // User represents a user in the system
 type User struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
@@ -16,6 +38,37 @@ type User struct {
 	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
 	LastLoginAt *time.Time `json:"lastLoginAt,omitempty" db:"last_login_at"`
 	IsActive    bool       `json:"isActive" db:"is_active"`
+	Role        string     `json:"role" db:"role"`
+	// TokenVersion is carried by every token issued to the user; bumping it revokes them all
+	TokenVersion int `json:"-" db:"token_version"`
+}
+
+// UserFilter narrows and pages the admin user listing; zero values match everything
+type UserFilter struct {
+	Query  string // Case-insensitive substring of the username, email or display name
+	Role   string // One of the UserRole values
+	Status string // UserStatusActive or UserStatusSuspended
+	Cursor string // Opaque cursor returned with the previous page
+	Limit  int    // Page size; 0 means DefaultUserPageSize
+}
+
+// UserPage is one page of the admin user listing
+type UserPage struct {
+	Items      []*User
+	NextCursor string // Empty on the last page
+}
+
+// UserStats are the aggregate counts shown to admins
+type UserStats struct {
+	TotalUsers     int64 `json:"totalUsers"`
+	ActiveUsers    int64 `json:"activeUsers"`
+	SuspendedUsers int64 `json:"suspendedUsers"`
+	AdminUsers     int64 `json:"adminUsers"`
+	NewUsers       int64 `json:"newUsers"`      // Registered within the window
+	SignedInUsers  int64 `json:"signedInUsers"` // Signed in within the window
+	VaultEntries   int64 `json:"vaultEntries"`  // Entries outside the trash
+	Passkeys       int64 `json:"passkeys"`
+	WindowDays     int   `json:"windowDays"`
 }
 
 // NewUser creates a new user with default values
@@ -28,9 +81,40 @@ func NewUser(username, email, displayName string) *User {
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		IsActive:    true,
+		Role:        UserRoleUser,
 	}
 }
 
+// IsAdmin reports whether the user holds the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == UserRoleAdmin
+}
+
+// IsValidUserRole reports whether role is a known user role
+func IsValidUserRole(role string) bool {
+	return role == UserRoleUser || role == UserRoleAdmin
+}
+
+// Normalize applies the default page size and validates the filter
+func (f *UserFilter) Normalize() error {
+	if f.Role != "" && !IsValidUserRole(f.Role) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidUserFilter, f.Role)
+	}
+	if f.Status != "" && f.Status != UserStatusActive && f.Status != UserStatusSuspended {
+		return fmt.Errorf("%w: status must be %q or %q", ErrInvalidUserFilter, UserStatusActive, UserStatusSuspended)
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidUserFilter)
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultUserPageSize
+	}
+	if f.Limit > MaxUserPageSize {
+		f.Limit = MaxUserPageSize
+	}
+	return nil
+}
+
 // Validate validates the user entity
 func (u *User) Validate() error {
 	// Basic validation - in production, use a proper validation library
@@ -21,6 +21,8 @@ func TestNewUser(t *testing.T) {
 	assert.Equal(t, email, user.Email)
 	assert.Equal(t, displayName, user.DisplayName)
 	assert.True(t, user.IsActive)
+	assert.Equal(t, UserRoleUser, user.Role)
+	assert.False(t, user.IsAdmin())
 	assert.False(t, user.CreatedAt.IsZero())
 	assert.False(t, user.UpdatedAt.IsZero())
 	assert.Nil(t, user.LastLoginAt)
@@ -179,3 +181,21 @@ func TestUser_FieldValidation_AfterManualUpdate(t *testing.T) {
 	// Will return the first error encountered (username)
 	assert.Equal(t, ErrInvalidUsername, err)
 }
+
+func TestUserFilter_Normalize(t *testing.T) {
+	filter := UserFilter{Role: UserRoleAdmin, Status: UserStatusSuspended}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, DefaultUserPageSize, filter.Limit)
+
+	filter = UserFilter{Query: "alice", Limit: 10_000}
+	require.NoError(t, filter.Normalize())
+	assert.Equal(t, MaxUserPageSize, filter.Limit)
+
+	for _, invalid := range []UserFilter{
+		{Role: "owner"},
+		{Status: "deleted"},
+		{Limit: -1},
+	} {
+		assert.ErrorIs(t, invalid.Normalize(), ErrInvalidUserFilter)
+	}
+}
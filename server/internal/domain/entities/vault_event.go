@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vault event types pushed to a user's connected devices
+const (
+	VaultEventEntryCreated     = "entry.created"
+	VaultEventEntryUpdated     = "entry.updated"     // Includes entries restored from the trash
+	VaultEventEntryInactivated = "entry.inactivated" // Moved to the trash or permanently deleted
+	VaultEventCredentialAdded  = "credential.added"
+	VaultEventSessionRevoked   = "session.revoked"
+	VaultEventKeyRotated       = "key.rotated" // The vault moved to a new key version
+)
+
+// VaultEventTypeForOperation returns the event a sync operation is pushed as
+func VaultEventTypeForOperation(operation string) string {
+	switch operation {
+	case SyncOperationCreate:
+		return VaultEventEntryCreated
+	case SyncOperationDelete, SyncOperationPurge:
+		return VaultEventEntryInactivated
+	default:
+		return VaultEventEntryUpdated
+	}
+}
+
+// VaultEvent tells a device that something in the user's vault or account changed. Events carry no
+// entry content: a device fetches entry changes through delta sync.
+type VaultEvent struct {
+	Cursor    string     `json:"cursor"` // Resumes the event stream right after this event
+	Type      string     `json:"type"`
+	Operation string     `json:"operation,omitempty"` // The sync operation behind an entry event
+	EntityID  *uuid.UUID `json:"entityId,omitempty"`  // The entry or credential the event is about
+	Revision  int64      `json:"revision,omitempty"`  // The entry's revision after the change
+	DeviceID  string     `json:"deviceId,omitempty"`  // The device that made the change
+	CreatedAt time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultEventTypeForOperation(t *testing.T) {
+	tests := []struct {
+		operation string
+		expected  string
+	}{
+		{SyncOperationCreate, VaultEventEntryCreated},
+		{SyncOperationUpdate, VaultEventEntryUpdated},
+		{SyncOperationRestore, VaultEventEntryUpdated},
+		{SyncOperationDelete, VaultEventEntryInactivated},
+		{SyncOperationPurge, VaultEventEntryInactivated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.operation, func(t *testing.T) {
+			assert.Equal(t, tt.expected, VaultEventTypeForOperation(tt.operation))
+		})
+	}
+}
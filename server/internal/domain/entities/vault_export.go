@@ -0,0 +1,318 @@
+package entities
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vault export file format. A .2fair file is a JSON object holding the vault's content and a
+// manifest describing it; the manifest comes last so it can carry the hash of the content written
+// before it.
+const (
+	VaultExportFormat    = "2fair"
+	VaultExportVersion   = 1
+	VaultExportExtension = ".2fair"
+)
+
+// vaultExportHashPrefix names the algorithm of a manifest's content hash
+const vaultExportHashPrefix = "sha256:"
+
+// Vault import modes
+const (
+	VaultImportMerge   = "merge"   // Add the file's entries the vault does not have; keep everything else
+	VaultImportReplace = "replace" // Make the vault's entries those of the file; others move to the trash
+)
+
+// VaultExportManifest describes a vault export. ContentHash is the SHA-256 of the content exactly
+// as written in the file.
+type VaultExportManifest struct {
+	Format      string    `json:"format"`
+	Version     int       `json:"version"`
+	ExportedAt  time.Time `json:"exportedAt"`
+	KeyVersion  int       `json:"keyVersion"` // Version of the vault key every secret is encrypted with
+	Keys        int       `json:"keys"`
+	Folders     int       `json:"folders"`
+	Entries     int       `json:"entries"`
+	ContentHash string    `json:"contentHash"` // "sha256:" followed by the hex digest
+}
+
+// VaultExportContent is everything a vault export carries. Secrets stay encrypted with the vault
+// key and the vault key stays wrapped by the user's passkeys, so the file is useless without them.
+type VaultExportContent struct {
+	Keys    []*VaultExportKey    `json:"keys"`
+	Folders []*VaultExportFolder `json:"folders"`
+	Entries []*VaultExportEntry  `json:"entries"`
+}
+
+// VaultExportKey is one passkey's wrap of the vault key
+type VaultExportKey struct {
+	CredentialID uuid.UUID `json:"credentialId"`
+	KeyVersion   int       `json:"keyVersion"`
+	WrappedDEK   []byte    `json:"wrappedDEK"`
+	Salt         []byte    `json:"salt"`
+}
+
+// VaultExportFolder is a folder; ParentID refers to another folder in the same file
+type VaultExportFolder struct {
+	ID       uuid.UUID  `json:"id"`
+	ParentID *uuid.UUID `json:"parentId,omitempty"`
+	Name     string     `json:"name"`
+}
+
+// VaultExportEntry is a vault entry; FolderID refers to a folder in the same file
+type VaultExportEntry struct {
+	ID        uuid.UUID  `json:"id"`
+	Issuer    string     `json:"issuer"`
+	IconURL   string     `json:"iconUrl,omitempty"`
+	Label     string     `json:"label"`
+	Secret    string     `json:"secret"` // Client-encrypted secret envelope
+	Algorithm string     `json:"algorithm"`
+	Digits    int        `json:"digits"`
+	Period    int        `json:"period"`
+	Method    string     `json:"method"`
+	Counter   int64      `json:"counter"`
+	Type      string     `json:"type"`
+	T0        int64      `json:"t0"`
+	Tags      []string   `json:"tags"`
+	FolderID  *uuid.UUID `json:"folderId,omitempty"`
+}
+
+// VaultExport is a parsed and verified export file
+type VaultExport struct {
+	Manifest VaultExportManifest
+	Content  VaultExportContent
+}
+
+// VaultImport is a verified export prepared for restoring into a user's vault
+type VaultImport struct {
+	Mode    string
+	Folders []*VaultExportFolder // Parents before their children
+	Entries []*VaultExportEntry
+	Keys    []*UserEncryptionKey // Wraps for the user's passkeys that hold none yet
+}
+
+// VaultImportResult reports what an import changed
+type VaultImportResult struct {
+	Mode           string `json:"mode"`
+	Created        int    `json:"created"`  // Entries added to the vault
+	Replaced       int    `json:"replaced"` // Entries overwritten with the file's version; replace only
+	Skipped        int    `json:"skipped"`  // Entries the vault already had; merge only
+	Trashed        int    `json:"trashed"`  // Entries missing from the file moved to the trash; replace only
+	FoldersCreated int    `json:"foldersCreated"`
+	KeysRestored   int    `json:"keysRestored"`
+}
+
+// IsValidVaultImportMode checks that mode is one of the import modes
+func IsValidVaultImportMode(mode string) bool {
+	return mode == VaultImportMerge || mode == VaultImportReplace
+}
+
+// WriteVaultExport streams an export of content to w. The content is hashed as it is written and
+// the manifest follows it, so a truncated file never verifies.
+func WriteVaultExport(w io.Writer, content *VaultExportContent, keyVersion int, exportedAt time.Time) error {
+	out := bufio.NewWriter(w)
+	ew := &vaultExportWriter{out: out}
+
+	ew.writeString(`{"content":`)
+	ew.hash = sha256.New()
+	ew.writeString(`{"keys":`)
+	ew.encode(nonNilSlice(content.Keys))
+	ew.writeString(`,"folders":`)
+	ew.encode(nonNilSlice(content.Folders))
+	ew.writeString(`,"entries":[`)
+	for i, entry := range content.Entries {
+		if i > 0 {
+			ew.writeString(",")
+		}
+		ew.encode(entry)
+	}
+	ew.writeString("]}")
+	sum := ew.hash.Sum(nil)
+	ew.hash = nil
+
+	ew.writeString(`,"manifest":`)
+	ew.encode(&VaultExportManifest{
+		Format:      VaultExportFormat,
+		Version:     VaultExportVersion,
+		ExportedAt:  exportedAt.UTC(),
+		KeyVersion:  keyVersion,
+		Keys:        len(content.Keys),
+		Folders:     len(content.Folders),
+		Entries:     len(content.Entries),
+		ContentHash: vaultExportHashPrefix + hex.EncodeToString(sum),
+	})
+	ew.writeString("}\n")
+
+	if ew.err != nil {
+		return ew.err
+	}
+	return out.Flush()
+}
+
+// vaultExportWriter writes an export, hashing what is written while hash is set. The first error
+// stops all further writes.
+type vaultExportWriter struct {
+	out  *bufio.Writer
+	hash hash.Hash
+	err  error
+}
+
+func (w *vaultExportWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	if w.hash != nil {
+		w.hash.Write(data)
+	}
+	_, w.err = w.out.Write(data)
+}
+
+func (w *vaultExportWriter) writeString(s string) {
+	w.write([]byte(s))
+}
+
+func (w *vaultExportWriter) encode(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		if w.err == nil {
+			w.err = err
+		}
+		return
+	}
+	w.write(data)
+}
+
+// nonNilSlice makes an empty list encode as [] rather than null
+func nonNilSlice[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// ReadVaultExport parses an export file, verifies its content hash and validates it
+func ReadVaultExport(r io.Reader) (*VaultExport, error) {
+	var file struct {
+		Content  json.RawMessage      `json:"content"`
+		Manifest *VaultExportManifest `json:"manifest"`
+	}
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidVaultExport, err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: unexpected data after the export", ErrInvalidVaultExport)
+	}
+	if file.Manifest == nil || len(file.Content) == 0 {
+		return nil, fmt.Errorf("%w: content and manifest are required", ErrInvalidVaultExport)
+	}
+
+	manifest := file.Manifest
+	if manifest.Format != VaultExportFormat || manifest.Version != VaultExportVersion {
+		return nil, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalidVaultExport, manifest.Format, manifest.Version)
+	}
+	sum := sha256.Sum256(file.Content)
+	if manifest.ContentHash != vaultExportHashPrefix+hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("%w: content does not match the manifest's hash", ErrInvalidVaultExport)
+	}
+
+	export := &VaultExport{Manifest: *manifest}
+	if err := json.Unmarshal(file.Content, &export.Content); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVaultExport, err)
+	}
+	if err := export.Validate(); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// Validate checks the export against its manifest: every key and secret is for the manifest's key
+// version, IDs are unique and folder references stay within the file without cycles
+func (e *VaultExport) Validate() error {
+	m, c := &e.Manifest, &e.Content
+	if m.KeyVersion < 1 {
+		return fmt.Errorf("%w: invalid key version", ErrInvalidVaultExport)
+	}
+	if m.Keys != len(c.Keys) || m.Folders != len(c.Folders) || m.Entries != len(c.Entries) {
+		return fmt.Errorf("%w: content does not match the manifest's counts", ErrInvalidVaultExport)
+	}
+
+	credentials := make(map[uuid.UUID]bool, len(c.Keys))
+	for i, key := range c.Keys {
+		if key.CredentialID == uuid.Nil || credentials[key.CredentialID] || key.KeyVersion != m.KeyVersion || len(key.WrappedDEK) == 0 || len(key.Salt) == 0 {
+			return fmt.Errorf("%w: invalid key %d", ErrInvalidVaultExport, i)
+		}
+		credentials[key.CredentialID] = true
+	}
+
+	folders := make(map[uuid.UUID]*VaultExportFolder, len(c.Folders))
+	for _, folder := range c.Folders {
+		name := strings.TrimSpace(folder.Name)
+		if folder.ID == uuid.Nil || folders[folder.ID] != nil || name == "" || len(name) > MaxFolderNameLength {
+			return fmt.Errorf("%w: invalid folder %s", ErrInvalidVaultExport, folder.ID)
+		}
+		folder.Name = name
+		folders[folder.ID] = folder
+	}
+	for _, folder := range c.Folders {
+		// A chain of parents longer than the number of folders has a cycle
+		parent := folder.ParentID
+		for depth := 0; parent != nil; depth++ {
+			next, ok := folders[*parent]
+			if !ok || depth == len(folders) {
+				return fmt.Errorf("%w: folder %s has an invalid parent", ErrInvalidVaultExport, folder.ID)
+			}
+			parent = next.ParentID
+		}
+	}
+
+	entries := make(map[uuid.UUID]bool, len(c.Entries))
+	for _, entry := range c.Entries {
+		if entry.ID == uuid.Nil || entries[entry.ID] {
+			return fmt.Errorf("%w: duplicate or missing entry ID %s", ErrInvalidVaultExport, entry.ID)
+		}
+		entries[entry.ID] = true
+
+		envelope, err := ParseSecretEnvelope(entry.Secret)
+		if err == nil {
+			err = envelope.CheckKeyVersion(m.KeyVersion)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: entry %s: %w", ErrInvalidVaultExport, entry.ID, err)
+		}
+		if entry.FolderID != nil && folders[*entry.FolderID] == nil {
+			return fmt.Errorf("%w: entry %s is in a folder that is not in the file", ErrInvalidVaultExport, entry.ID)
+		}
+	}
+
+	return nil
+}
+
+// FoldersParentsFirst orders the content's folders so every folder follows its parent. The folders
+// must have been validated.
+func (c *VaultExportContent) FoldersParentsFirst() []*VaultExportFolder {
+	children := make(map[uuid.UUID][]*VaultExportFolder, len(c.Folders))
+	var ordered []*VaultExportFolder
+	for _, folder := range c.Folders {
+		if folder.ParentID == nil {
+			ordered = append(ordered, folder)
+		} else {
+			children[*folder.ParentID] = append(children[*folder.ParentID], folder)
+		}
+	}
+	for i := 0; i < len(ordered); i++ {
+		ordered = append(ordered, children[ordered[i].ID]...)
+	}
+	return ordered
+}
@@ -0,0 +1,99 @@
+package entities
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVaultExportContent() *VaultExportContent {
+	envelope := &SecretEnvelope{
+		Format:     SecretEnvelopeFormat,
+		Suite:      CipherSuiteAES256GCM,
+		KeyVersion: 2,
+		IV:         bytes.Repeat([]byte{1}, 12),
+		AuthTag:    bytes.Repeat([]byte{2}, 16),
+		Ciphertext: []byte("ciphertext"),
+	}
+	parentID := uuid.New()
+	childID := uuid.New()
+
+	return &VaultExportContent{
+		Keys: []*VaultExportKey{
+			{CredentialID: uuid.New(), KeyVersion: 2, WrappedDEK: []byte("wrapped"), Salt: []byte("salt")},
+		},
+		// The child comes first to check that imports reorder folders
+		Folders: []*VaultExportFolder{
+			{ID: childID, ParentID: &parentID, Name: "Work"},
+			{ID: parentID, Name: "Accounts"},
+		},
+		Entries: []*VaultExportEntry{
+			{ID: uuid.New(), Issuer: "GitHub", Label: "user", Secret: envelope.String(), Method: OTPMethodTOTP, Type: OTPTypeStandard, Tags: []string{"dev"}, FolderID: &childID},
+			{ID: uuid.New(), Issuer: "GitLab", Label: "user", Secret: envelope.String(), Method: OTPMethodTOTP, Type: OTPTypeStandard},
+		},
+	}
+}
+
+func writeTestVaultExport(t *testing.T, content *VaultExportContent) string {
+	var buf bytes.Buffer
+	require.NoError(t, WriteVaultExport(&buf, content, 2, time.Now()))
+	return buf.String()
+}
+
+func TestVaultExport_RoundTrip(t *testing.T) {
+	content := testVaultExportContent()
+
+	export, err := ReadVaultExport(strings.NewReader(writeTestVaultExport(t, content)))
+	require.NoError(t, err)
+
+	assert.Equal(t, VaultExportFormat, export.Manifest.Format)
+	assert.Equal(t, 2, export.Manifest.KeyVersion)
+	assert.Equal(t, 2, export.Manifest.Entries)
+	assert.Equal(t, *content, export.Content)
+
+	ordered := export.Content.FoldersParentsFirst()
+	require.Len(t, ordered, 2)
+	assert.Equal(t, "Accounts", ordered[0].Name)
+	assert.Equal(t, "Work", ordered[1].Name)
+}
+
+func TestReadVaultExport_Invalid(t *testing.T) {
+	valid := writeTestVaultExport(t, testVaultExportContent())
+
+	withContent := func(modify func(c *VaultExportContent)) string {
+		content := testVaultExportContent()
+		modify(content)
+		return writeTestVaultExport(t, content)
+	}
+	cycle := func(c *VaultExportContent) {
+		c.Folders[1].ParentID = &c.Folders[0].ID
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"not JSON", "not a vault"},
+		{"truncated", valid[:len(valid)/2]},
+		{"missing manifest", valid[:strings.Index(valid, `,"manifest"`)] + "}"},
+		{"tampered content", strings.Replace(valid, "GitHub", "GitHug", 1)},
+		{"trailing data", valid + "{}"},
+		{"wrong key version", withContent(func(c *VaultExportContent) { c.Keys[0].KeyVersion = 1 })},
+		{"corrupted secret", withContent(func(c *VaultExportContent) { c.Entries[0].Secret = "ciphertext.iv.authTag" })},
+		{"duplicate entry", withContent(func(c *VaultExportContent) { c.Entries[1].ID = c.Entries[0].ID })},
+		{"unknown folder", withContent(func(c *VaultExportContent) { id := uuid.New(); c.Entries[0].FolderID = &id })},
+		{"folder cycle", withContent(cycle)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadVaultExport(strings.NewReader(tt.file))
+			assert.ErrorIs(t, err, ErrInvalidVaultExport)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// AdminService lets admins manage other accounts. Suspending, logging out and changing the role of
+// a user revoke every token and session they hold, so the change applies to their next request.
+type AdminService interface {
+	// ListUsers returns a page of users, newest first.
+	// Returns entities.ErrInvalidUserFilter or entities.ErrInvalidCursor for a bad filter.
+	ListUsers(ctx context.Context, filter entities.UserFilter) (*entities.UserPage, error)
+
+	// GetUser returns one user, or entities.ErrUserNotFound
+	GetUser(ctx context.Context, userID uuid.UUID) (*entities.User, error)
+
+	// SuspendUser deactivates the account and revokes its tokens and sessions. Admins cannot suspend
+	// themselves (entities.ErrCannotSuspendSelf).
+	SuspendUser(ctx context.Context, adminID, userID uuid.UUID) error
+
+	// ReactivateUser lets a suspended user sign in again
+	ReactivateUser(ctx context.Context, userID uuid.UUID) error
+
+	// ForceLogout revokes every token and session the user holds
+	ForceLogout(ctx context.Context, userID uuid.UUID) error
+
+	// SetRole grants or revokes the admin role.
+	// Returns entities.ErrInvalidUserRole for an unknown role.
+	SetRole(ctx context.Context, userID uuid.UUID, role string) error
+
+	// GetStats returns aggregate counts over every account
+	GetStats(ctx context.Context) (*entities.UserStats, error)
+}
@@ -0,0 +1,37 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// AuditService records security-relevant actions in a tamper-evident log and shows users their
+// own activity. Vault entry changes are recorded by the repositories that make them, in the same
+// transaction; this service records the rest.
+type AuditService interface {
+	// Record logs an action attributed to the client in ctx. userID is nil for failures that
+	// name no user.
+	Record(ctx context.Context, userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, metadata map[string]string) error
+
+	// ListActivity returns a page of the user's entries, newest first.
+	// Returns entities.ErrInvalidAuditFilter or entities.ErrInvalidCursor for a bad filter.
+	ListActivity(ctx context.Context, userID uuid.UUID, filter entities.AuditLogFilter) (*entities.AuditLogPage, error)
+
+	// VerifyChain checks the whole chain and returns its last entry, nil for an empty log.
+	// Returns entities.ErrAuditChainBroken at the first entry that does not hold.
+	VerifyChain(ctx context.Context) (*entities.AuditLog, error)
+}
+
+// AuditLogRepository defines the interface for audit log data access
+type AuditLogRepository interface {
+	// Append chains the entry after the newest one and stores it
+	Append(ctx context.Context, entry *entities.AuditLog) error
+
+	// ListByUser retrieves a page of the user's entries, newest first
+	ListByUser(ctx context.Context, userID uuid.UUID, filter entities.AuditLogFilter) (*entities.AuditLogPage, error)
+
+	// ListChain retrieves up to limit entries after the given sequence, in chain order
+	ListChain(ctx context.Context, afterSequence int64, limit int) ([]*entities.AuditLog, error)
+}
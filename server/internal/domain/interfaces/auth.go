@@ -8,6 +8,7 @@ import (
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
 )
 
 // OAuthProvider represents OAuth provider information
@@ -22,11 +23,67 @@ type OAuthProvider struct {
 
 // JWTClaims represents JWT token claims
 type JWTClaims struct {
-	UserID    string    `json:"user_id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	IssuedAt  time.Time `json:"iat"`
-	ExpiresAt time.Time `json:"exp"`
+	UserID       string    `json:"user_id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	TokenVersion int       `json:"tv"`  // The user's token version when the token was issued
+	SessionID    string    `json:"sid"` // The sign-in session the token was issued for
+	IssuedAt     time.Time `json:"iat"`
+	ExpiresAt    time.Time `json:"exp"`
+}
+
+// TokenPair is a short-lived access token and the opaque refresh token that renews it
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`         // When the access token expires
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"` // When the refresh token expires unless used
+	TokenType        string    `json:"token_type"`
+}
+
+// JSONWebKey is the public half of a token signing key, in the form published in the JWKS
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y,omitempty"` // EC keys only
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JSONWebKeySet is the set of public keys access tokens may be verified with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// TokenSigner signs access tokens with the server's keyring and verifies them against it. Tokens
+// are signed asymmetrically, so anyone holding the published keys can verify them.
+type TokenSigner interface {
+	// SignToken signs claims with the active key, adding the issuer, audience and not-before
+	// claims and naming the key in the kid header
+	SignToken(claims map[string]any) (string, error)
+
+	// VerifyToken checks a token's signature against the key its kid names, and its issuer,
+	// audience and validity window, and returns its claims. An expired token's claims are
+	// returned with entities.ErrTokenExpired, as its signature is only checked first.
+	VerifyToken(token string) (map[string]any, error)
+
+	// PublicKeys returns every key tokens are currently accepted from
+	PublicKeys() *JSONWebKeySet
+}
+
+// RefreshTokenGenerator creates opaque refresh tokens. Only their hashes are stored.
+type RefreshTokenGenerator interface {
+	// GenerateRefreshToken returns a new random token and the hash it is stored under
+	GenerateRefreshToken() (token string, hash []byte, err error)
+
+	// HashRefreshToken returns the hash a presented token is looked up by
+	HashRefreshToken(token string) []byte
+
+	// RefreshExpiration is how long a refresh token stays usable
+	RefreshExpiration() time.Duration
 }
 
 // AuthService handles OAuth authentication and JWT token management
@@ -38,10 +95,33 @@ type AuthService interface {
 	// User registration/login from OAuth
 	RegisterOrLoginUser(ctx context.Context, oauthData *OAuthProvider) (*entities.User, error)
 
-	// JWT token management
-	GenerateJWT(user *entities.User) (string, error)
+	// JWT token management. Tokens are issued for a sign-in session, created by SessionService.
+	GenerateJWT(user *entities.User, sessionID uuid.UUID) (string, error)
+
+	// ValidateJWT verifies a token and returns its claims. An expired token's claims are returned
+	// with an error wrapping entities.ErrTokenExpired, to name its user; they grant no access.
 	ValidateJWT(token string) (*JWTClaims, error)
-	RefreshJWT(token string) (string, error)
+
+	// PublicKeys returns the keys access tokens may be verified with, for the JWKS endpoint
+	PublicKeys() *JSONWebKeySet
+
+	// IssueTokens issues an access token and the first refresh token of a new session
+	IssueTokens(ctx context.Context, user *entities.User, sessionID uuid.UUID) (*TokenPair, error)
+
+	// RefreshTokens exchanges a refresh token for a new pair, rotating the refresh token. Returns
+	// entities.ErrInvalidRefreshToken for an unknown or expired token, and
+	// entities.ErrRefreshTokenReused after revoking the session when the token was already used.
+	RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error)
+
+	// LookupRefreshToken returns a refresh token, used or expired, so that signing out can end its
+	// session after the access token has expired. Returns entities.ErrInvalidRefreshToken if unknown.
+	LookupRefreshToken(ctx context.Context, refreshToken string) (*entities.RefreshToken, error)
+
+	// VerifyAccess checks a validated token against the current account and its session: it fails
+	// with ErrUserSuspended for a suspended account, ErrTokenRevoked for a revoked token and
+	// ErrSessionNotFound or ErrSessionExpired once the session is revoked or timed out. It records
+	// the request as activity on the session.
+	VerifyAccess(ctx context.Context, claims *JWTClaims) error
 }
 
 // WebAuthnCredentialCreation represents credential creation data
@@ -71,14 +151,36 @@ type WebAuthnService interface {
 
 	// Credential management
 	GetUserCredentials(ctx context.Context, userID string) ([]*entities.WebAuthnCredential, error)
-	DeleteCredential(ctx context.Context, userID string, credentialID []byte) error
+	DeleteCredential(ctx context.Context, userID string, id uuid.UUID) error
 }
 
-// SessionService handles session management
+// SessionService manages sign-in sessions. A session is created at each sign-in and ends when it
+// is revoked, when it goes unused for the idle timeout, or at the absolute timeout after sign-in.
 type SessionService interface {
-	CreateSession(ctx context.Context, userID string, deviceInfo string) (*entities.DeviceSession, error)
-	GetSession(ctx context.Context, sessionID string) (*entities.DeviceSession, error)
-	UpdateSession(ctx context.Context, sessionID string, lastActivityAt time.Time) error
-	DeleteSession(ctx context.Context, sessionID string) error
-	DeleteUserSessions(ctx context.Context, userID string) error
+	// CreateSession starts a session for the client described by the request info in ctx
+	CreateSession(ctx context.Context, userID uuid.UUID) (*entities.DeviceSession, error)
+
+	// GetSession returns a usable session of the user.
+	// Returns entities.ErrSessionNotFound or entities.ErrSessionExpired otherwise.
+	GetSession(ctx context.Context, userID, sessionID uuid.UUID) (*entities.DeviceSession, error)
+
+	// TouchSession checks the session like GetSession and records activity on it. Activity is
+	// written at most once per activity interval, so it is cheap to call on every request.
+	TouchSession(ctx context.Context, userID, sessionID uuid.UUID) (*entities.DeviceSession, error)
+
+	// ListSessions returns the user's usable sessions, most recently active first
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*entities.DeviceSession, error)
+
+	// RenameSession changes a session's device name.
+	// Returns entities.ErrInvalidSessionName or entities.ErrSessionNotFound.
+	RenameSession(ctx context.Context, userID, sessionID uuid.UUID, name string) (*entities.DeviceSession, error)
+
+	// DeleteSession revokes one session, or returns entities.ErrSessionNotFound
+	DeleteSession(ctx context.Context, userID, sessionID uuid.UUID) error
+
+	// DeleteOtherSessions revokes every session of the user except keep, returning the revoked IDs
+	DeleteOtherSessions(ctx context.Context, userID, keep uuid.UUID) ([]uuid.UUID, error)
+
+	// DeleteUserSessions revokes every session of the user
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
 }
@@ -0,0 +1,66 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+// BackupService takes, checks and restores backups of the whole database
+type BackupService interface {
+	// Create takes a backup, stores it, checks the stored copy and applies the retention policy
+	Create(ctx context.Context) (*entities.BackupInfo, error)
+
+	// List returns the stored backups, newest first
+	List(ctx context.Context) ([]*entities.BackupInfo, error)
+
+	// Verify reads a stored backup to the end, checking its encryption and content.
+	// Returns entities.ErrDatabaseBackupNotFound if there is no backup with that name.
+	Verify(ctx context.Context, name string) (*entities.BackupManifest, error)
+
+	// Restore replaces the content of every table with a stored backup's in one transaction.
+	// Returns entities.ErrDatabaseBackupSchema if the backup was taken at another migration version.
+	Restore(ctx context.Context, name string) (*entities.BackupManifest, error)
+
+	// Prune deletes the backups the retention policy no longer keeps and returns their names
+	Prune(ctx context.Context) ([]string, error)
+}
+
+// BackupRepository reads and writes the whole database as table data
+type BackupRepository interface {
+	// Dump writes a backup of every table from one consistent snapshot
+	Dump(ctx context.Context, w io.Writer, createdAt time.Time) (*entities.BackupManifest, error)
+
+	// Restore empties every table and loads the backup's rows in one transaction
+	Restore(ctx context.Context, backup *entities.BackupReader) (*entities.BackupManifest, error)
+}
+
+// BackupArchiver compresses and encrypts backups with the server's backup key
+type BackupArchiver interface {
+	// Seal returns a writer that archives what is written to it into w. The archive is only
+	// complete once the writer is closed.
+	Seal(w io.Writer) (io.WriteCloser, error)
+
+	// Open returns a reader of an archive's content. Reads fail with
+	// entities.ErrInvalidDatabaseBackup once the archive turns out to be altered, truncated or
+	// sealed with another key.
+	Open(r io.Reader) (io.ReadCloser, error)
+}
+
+// BackupStore holds backup archives in a local directory or an S3-compatible bucket
+type BackupStore interface {
+	// Put stores an archive under name, replacing any archive of that name
+	Put(ctx context.Context, name string, archive io.ReadSeeker) error
+
+	// Open reads the archive stored under name.
+	// Returns entities.ErrDatabaseBackupNotFound if there is none.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// List returns every object in the store. CreatedAt is when the object was stored.
+	List(ctx context.Context) ([]*entities.BackupInfo, error)
+
+	// Delete removes the archive stored under name
+	Delete(ctx context.Context, name string) error
+}
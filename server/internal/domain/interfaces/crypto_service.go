@@ -16,4 +16,10 @@ type CryptoService interface {
 
 	// GenerateRandomSalt generates a random 16-byte salt
 	GenerateRandomSalt() ([]byte, error)
+
+	// HashSecret hashes a secret such as a recovery code using Argon2id with a random salt
+	HashSecret(secret []byte) ([]byte, error)
+
+	// VerifySecret reports whether a secret matches a hash produced by HashSecret
+	VerifySecret(secret []byte, hash []byte) (bool, error)
 }
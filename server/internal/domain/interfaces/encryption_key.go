@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// EncryptionKeyService manages the wrapped copies of a user's vault key. The vault is encrypted with
+// a random DEK; each passkey holds its own wrap of it, made on the device with a KEK derived from the
+// passkey's PRF output, so any of them can unlock the vault. The server never sees the DEK or a KEK.
+type EncryptionKeyService interface {
+	// ListKeys returns the active wraps of all of the user's credentials
+	ListKeys(ctx context.Context, userID uuid.UUID) ([]*entities.UserEncryptionKey, error)
+
+	// GetKey returns the active wrap held by one of the user's credentials
+	GetKey(ctx context.Context, userID uuid.UUID, credentialID uuid.UUID) (*entities.UserEncryptionKey, error)
+
+	// AddKey stores a wrap for a credential that does not hold one yet. Without a key version it
+	// wraps the current key, or starts key version 1 when the user has no wrap at all. During a key
+	// rotation it may also wrap the new key, which stays inactive until the rotation commits.
+	AddKey(ctx context.Context, userID uuid.UUID, key *entities.UserEncryptionKey) error
+}
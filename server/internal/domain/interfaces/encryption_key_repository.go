@@ -9,12 +9,19 @@ import (
 
 // EncryptionKeyRepository defines the interface for user encryption key data access
 type EncryptionKeyRepository interface {
-	// Create creates a new user encryption key
+	// Create creates a new user encryption key.
+	// Returns entities.ErrKeyExists if the credential already holds a wrap of that version.
 	Create(ctx context.Context, key *entities.UserEncryptionKey) error
 
 	// GetActiveByUserID retrieves the active encryption key for a user
 	GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*entities.UserEncryptionKey, error)
 
+	// GetActiveByCredential retrieves the active wrap held by one of the user's credentials
+	GetActiveByCredential(ctx context.Context, userID uuid.UUID, credentialID uuid.UUID) (*entities.UserEncryptionKey, error)
+
+	// GetAllActiveByUserID retrieves the active wraps of all of the user's credentials
+	GetAllActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.UserEncryptionKey, error)
+
 	// GetByUserIDAndVersion retrieves an encryption key by user ID and version
 	GetByUserIDAndVersion(ctx context.Context, userID uuid.UUID, version int) (*entities.UserEncryptionKey, error)
 
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// FolderService manages the nested folders vault entries are organized in
+type FolderService interface {
+	// CreateFolder creates a folder, at the top level when parentID is nil
+	CreateFolder(ctx context.Context, userID uuid.UUID, name string, parentID *uuid.UUID) (*entities.Folder, error)
+
+	// ListFolders returns all of the user's folders with their entry counts
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]*entities.Folder, error)
+
+	// UpdateFolder renames a folder and moves it under parentID (nil for the top level)
+	UpdateFolder(ctx context.Context, folderID uuid.UUID, userID uuid.UUID, name string, parentID *uuid.UUID) (*entities.Folder, error)
+
+	// DeleteFolder deletes a folder; its entries and subfolders move up to its parent
+	DeleteFolder(ctx context.Context, folderID uuid.UUID, userID uuid.UUID) error
+}
@@ -0,0 +1,29 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// FolderRepository defines the interface for folder data access
+type FolderRepository interface {
+	// Create creates a new folder
+	Create(ctx context.Context, folder *entities.Folder) error
+
+	// GetByID retrieves a folder owned by the user
+	GetByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.Folder, error)
+
+	// GetByUserID retrieves all of the user's folders with their entry counts
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.Folder, error)
+
+	// IsInSubtree reports whether folderID is rootID itself or one of its descendants
+	IsInSubtree(ctx context.Context, rootID uuid.UUID, folderID uuid.UUID) (bool, error)
+
+	// Update saves a folder's name and parent
+	Update(ctx context.Context, folder *entities.Folder) error
+
+	// Delete deletes a folder after moving its entries and subfolders to its parent
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+}
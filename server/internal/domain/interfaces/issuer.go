@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+// IssuerCatalog resolves issuer names against a bundled, offline catalog of known services
+type IssuerCatalog interface {
+	// Lookup resolves an issuer name, alias or domain to its catalog entry.
+	// Matching ignores case, spaces and punctuation.
+	Lookup(name string) (*entities.Issuer, bool)
+
+	// Search returns up to limit entries matching the query, best matches first
+	Search(query string, limit int) []*entities.Issuer
+
+	// Icon returns a bundled icon asset and its content type
+	Icon(file string) ([]byte, string, bool)
+}
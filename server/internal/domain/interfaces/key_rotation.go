@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// KeyRotationService moves a user's vault to a new encryption key. The server never sees either
+// key: a device re-encrypts each entry and the server swaps the results in atomically.
+type KeyRotationService interface {
+	// StartRotation begins a rotation to the next key version. keys holds the new DEK wrapped for
+	// each of the user's credentials; they stay inactive until the rotation commits.
+	StartRotation(ctx context.Context, userID uuid.UUID, keys []*entities.UserEncryptionKey) (*entities.KeyRotation, error)
+
+	// GetRotation returns the rotation in progress with its progress
+	GetRotation(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error)
+
+	// ListPendingEntries returns up to limit entries that still need to be re-encrypted
+	ListPendingEntries(ctx context.Context, userID uuid.UUID, limit int) ([]*entities.KeyRotationEntry, error)
+
+	// StageEntries stores re-encrypted secrets until the rotation commits
+	StageEntries(ctx context.Context, userID uuid.UUID, entries []*entities.KeyRotationEntry) (*entities.KeyRotationStageResult, error)
+
+	// CommitRotation swaps every staged secret in and activates the new key, or returns
+	// entities.ErrKeyRotationIncomplete while any entry is still pending
+	CommitRotation(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error)
+
+	// AbortRotation discards the rotation, its staged secrets and the new key
+	AbortRotation(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,34 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// KeyRotationRepository defines the interface for key rotation data access. Entries are pending
+// while they are encrypted with a version below the rotation's target and have nothing staged
+// from their current revision, trashed entries included.
+type KeyRotationRepository interface {
+	// Create starts a rotation and stores the new version's keys inactive.
+	// Returns entities.ErrKeyRotationInProgress if the user already has one in progress.
+	Create(ctx context.Context, rotation *entities.KeyRotation, keys []*entities.UserEncryptionKey) error
+
+	// GetInProgress retrieves the user's rotation in progress with its progress counts
+	GetInProgress(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error)
+
+	// ListPending retrieves up to limit pending entries with their current secrets
+	ListPending(ctx context.Context, rotation *entities.KeyRotation, limit int) ([]*entities.KeyRotationEntry, error)
+
+	// Stage stores re-encrypted secrets, replacing earlier ones, and returns the IDs of entries
+	// that were not staged because they are not pending or changed since the given revision
+	Stage(ctx context.Context, rotation *entities.KeyRotation, entries []*entities.KeyRotationEntry) ([]uuid.UUID, error)
+
+	// Commit swaps the staged secrets in, activates the new keys and deactivates the older ones in
+	// one transaction. Returns entities.ErrKeyRotationIncomplete while any entry is still pending.
+	Commit(ctx context.Context, rotation *entities.KeyRotation) (*entities.KeyRotation, error)
+
+	// Abort discards the rotation's staged secrets and inactive keys
+	Abort(ctx context.Context, rotation *entities.KeyRotation) error
+}
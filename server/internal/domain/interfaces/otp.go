@@ -9,20 +9,63 @@ import (
 
 // OTPService handles encrypted TOTP operations
 type OTPService interface {
-	// CreateOTP creates a new encrypted OTP entry
-	CreateOTP(ctx context.Context, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int) (*entities.OTP, error)
+	// CreateOTP creates a new encrypted OTP entry. Counter is only meaningful for HOTP entries and
+	// t0 only for TOTP entries; otpType selects the code scheme and defaults to "standard". Returns
+	// entities.ErrFolderNotFound if folderID is not one of the user's folders.
+	CreateOTP(ctx context.Context, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int, method string, counter int64, otpType string, t0 int64, tags []string, folderID *uuid.UUID) (*entities.OTP, error)
+
+	// ApplyOTPBatch atomically applies a batch of create, update and inactivate operations.
+	// On failure nothing is persisted and the per-item results explain which operation failed.
+	ApplyOTPBatch(ctx context.Context, userID uuid.UUID, operations []*entities.OTPBatchOperation) ([]*entities.OTPBatchResult, error)
 
 	// GetOTP retrieves a decrypted OTP by ID
 	GetOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (*entities.OTP, error)
 
-	// ListOTPs retrieves all decrypted OTPs for a user
-	ListOTPs(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error)
+	// ListOTPs retrieves one page of a user's OTPs matching the filter, with the total match count
+	ListOTPs(ctx context.Context, userID uuid.UUID, filter entities.OTPFilter) (*entities.OTPPage, error)
+
+	// ListTags returns the user's tags with the number of entries carrying each
+	ListTags(ctx context.Context, userID uuid.UUID) ([]*entities.TagCount, error)
+
+	// RenameTag renames a tag across all of the user's entries, merging it into an existing tag
+	RenameTag(ctx context.Context, userID uuid.UUID, oldTag, newTag string) (int64, error)
+
+	// UpdateOTP updates an existing encrypted OTP entry, replacing all of its code parameters.
+	// Nil tags or folderID keep the entry's current value; a folderID of uuid.Nil moves it to the top level,
+	// and one that is not the user's folder returns entities.ErrFolderNotFound.
+	// A non-zero expectedRevision rejects the update with entities.ErrRevisionMismatch if the entry has
+	// changed since that revision.
+	UpdateOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int, otpType string, t0 int64, tags []string, folderID *uuid.UUID, expectedRevision int64) (*entities.OTP, error)
+
+	// RecordUse counts a use of an entry's code, for sorting by most used
+	RecordUse(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error
+
+	// AdvanceCounter reserves the current counter of an HOTP entry and returns the new counter value
+	AdvanceCounter(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (int64, error)
+
+	// ListRevisions returns an entry's past versions, newest first
+	ListRevisions(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) ([]*entities.OTPRevision, error)
+
+	// RevertOTP restores an entry to one of its revisions; the current version is kept as a new revision.
+	// Revisions encrypted with an older vault key are refused with entities.ErrRevisionKeyRetired.
+	RevertOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, revisionID uuid.UUID) (*entities.OTP, error)
+
+	// DeleteOTP soft deletes an OTP entry, moving it to the trash. A non-zero expectedRevision
+	// makes the delete conditional like UpdateOTP.
+	DeleteOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, expectedRevision int64) error
+
+	// ListTrash returns the user's trashed entries with the time each will be purged
+	ListTrash(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error)
+
+	// RestoreOTP moves a trashed entry back into the vault
+	RestoreOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) (*entities.OTP, error)
 
-	// UpdateOTP updates an existing encrypted OTP entry
-	UpdateOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID, issuer, label, secret string, period int, algorithm string, digits int) (*entities.OTP, error)
+	// PurgeOTP permanently deletes a trashed entry
+	PurgeOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error
 
-	// DeleteOTP soft deletes an OTP entry
-	DeleteOTP(ctx context.Context, otpID uuid.UUID, userID uuid.UUID) error
+	// PurgeExpiredTrash permanently deletes entries that have been in the trash longer than
+	// the retention period and returns how many were deleted
+	PurgeExpiredTrash(ctx context.Context) (int64, error)
 
 	// GenerateOTPCodes generates current and next TOTP codes for all user's OTPs
 	GenerateOTPCodes(ctx context.Context, userID uuid.UUID) ([]*entities.OTPCodes, error)
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/google/uuid"
@@ -9,8 +10,8 @@ import (
 
 // OTPRepository defines the interface for encrypted OTP data access
 type OTPRepository interface {
-	// Create creates a new encrypted OTP entry
-	Create(ctx context.Context, otp *entities.OTP, encryptedData []byte, keyVersion int) error
+	// Create creates a new encrypted OTP entry, storing the envelope's parts and key version
+	Create(ctx context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope) error
 
 	// GetByID retrieves a decrypted OTP by ID
 	GetByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.OTP, error)
@@ -18,12 +19,64 @@ type OTPRepository interface {
 	// GetByUserID retrieves all decrypted OTPs for a user
 	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error)
 
-	// Update updates an existing encrypted OTP entry
-	Update(ctx context.Context, otp *entities.OTP, encryptedData []byte, keyVersion int) error
+	// List retrieves one page of a user's OTPs matching a normalized filter.
+	// A cursor that cannot be decoded or belongs to another sort order returns entities.ErrInvalidCursor.
+	// Pages sorted by use count are not stable: an entry used between pages can be skipped or repeated.
+	List(ctx context.Context, userID uuid.UUID, filter entities.OTPFilter) (*entities.OTPPage, error)
 
-	// Delete soft deletes an OTP entry (marks as inactive)
-	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	// ListTags returns every tag in use on the user's active entries with its entry count
+	ListTags(ctx context.Context, userID uuid.UUID) ([]*entities.TagCount, error)
 
-	// GetEncryptedData retrieves the raw encrypted data for an OTP
-	GetEncryptedData(ctx context.Context, id uuid.UUID, userID uuid.UUID) ([]byte, int, error)
+	// RenameTag renames a tag on all of the user's entries, merging it into newTag if that
+	// tag already exists, and returns the number of entries changed
+	RenameTag(ctx context.Context, userID uuid.UUID, oldTag, newTag string) (int64, error)
+
+	// Update updates an existing encrypted OTP entry, recording the version it replaces as a revision.
+	// A non-zero expectedRevision makes the update only apply if the stored entry is still at that
+	// revision, checked atomically; otherwise entities.ErrRevisionMismatch is returned. Returns
+	// entities.ErrTOTPSeedNotFound if the entry is not active.
+	Update(ctx context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope, expectedRevision int64) error
+
+	// AdvanceCounter atomically increments an HOTP entry's counter under a row lock and returns the new value
+	AdvanceCounter(ctx context.Context, id uuid.UUID, userID uuid.UUID) (int64, error)
+
+	// RecordUse counts a use of an entry's code, for sorting by most used
+	RecordUse(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+
+	// ApplyBatch applies create, update and inactivate operations in a single transaction.
+	// Updates and inactivations with a base revision are checked like Update, failing with
+	// entities.ErrRevisionMismatch. If any operation fails nothing is persisted and
+	// entities.ErrOTPBatchFailed is returned with the results.
+	ApplyBatch(ctx context.Context, userID uuid.UUID, operations []*entities.OTPBatchOperation) ([]*entities.OTPBatchResult, error)
+
+	// ListRevisions retrieves an entry's past versions, newest first
+	ListRevisions(ctx context.Context, id uuid.UUID, userID uuid.UUID) ([]*entities.OTPRevision, error)
+
+	// Revert restores an entry's secret and metadata from one of its revisions, recording the current
+	// version as a revision first. Returns entities.ErrTOTPSeedNotFound if the entry is not active,
+	// entities.ErrRevisionNotFound if the revision does not belong to it and
+	// entities.ErrRevisionKeyRetired if its secret is not encrypted with keyVersion, the current key.
+	Revert(ctx context.Context, id uuid.UUID, userID uuid.UUID, revisionID uuid.UUID, keyVersion int) (*entities.OTP, error)
+
+	// Delete soft deletes an OTP entry (marks as inactive), moving it to the trash. A non-zero
+	// expectedRevision makes the delete conditional like Update.
+	Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, expectedRevision int64) error
+
+	// ListTrash retrieves the user's trashed OTPs, most recently deleted first
+	ListTrash(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error)
+
+	// Restore moves a trashed OTP back into the vault.
+	// Returns entities.ErrTOTPSeedNotFound if the entry is not in the trash.
+	Restore(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.OTP, error)
+
+	// Purge permanently deletes a trashed OTP.
+	// Returns entities.ErrTOTPSeedNotFound if the entry is not in the trash.
+	Purge(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+
+	// PurgeExpired permanently deletes every entry trashed before the given time and returns how many were deleted
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+
+	// GetEncryptedData retrieves the secret envelope of an OTP.
+	// Returns entities.ErrInvalidTOTPSeed for a secret stored before envelopes that could not be migrated.
+	GetEncryptedData(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.SecretEnvelope, error)
 }
@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// RecoveryService manages recovery kits, the way back into the vault after every passkey is lost.
+// A device wraps the vault key with a key derived from a recovery secret the user keeps offline and
+// uploads the wrap with a recovery code derived from the same secret; the server only stores a hash
+// of the code. Redemption attempts are rate limited per kit.
+type RecoveryService interface {
+	// GetKit returns the user's usable kit without its wrapped key
+	GetKit(ctx context.Context, userID uuid.UUID) (*entities.RecoveryKit, error)
+
+	// CreateKit stores the user's first kit for the current key version.
+	// Returns entities.ErrRecoveryKitExists if the user already has one.
+	CreateKit(ctx context.Context, userID uuid.UUID, code string, encryptedBackup []byte) (*entities.RecoveryKit, error)
+
+	// RegenerateKit replaces the user's kit, invalidating the old one
+	RegenerateKit(ctx context.Context, userID uuid.UUID, code string, encryptedBackup []byte) (*entities.RecoveryKit, error)
+
+	// RedeemKit checks a recovery code and returns the wrapped key it unlocks. The kit stays usable
+	// until a new passkey is registered with it.
+	RedeemKit(ctx context.Context, userID uuid.UUID, code string) (*entities.RecoveryRedemption, error)
+
+	// Reregister completes a recovery: it checks the code again, stores a newly registered passkey's
+	// wrap of the vault key and replaces the spent kit with a new one
+	Reregister(ctx context.Context, userID uuid.UUID, code string, key *entities.UserEncryptionKey, newCode string, newEncryptedBackup []byte) (*entities.RecoveryReregistration, error)
+}
@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// RecoveryKitRepository defines the interface for recovery kit data access. Only a user's usable
+// kit is visible; spent and replaced kits are kept but never returned.
+type RecoveryKitRepository interface {
+	// Create stores a kit.
+	// Returns entities.ErrRecoveryKitExists if the user already has a usable kit.
+	Create(ctx context.Context, kit *entities.RecoveryKit) error
+
+	// GetActive retrieves the user's usable kit
+	GetActive(ctx context.Context, userID uuid.UUID) (*entities.RecoveryKit, error)
+
+	// Replace retires the user's usable kit, if any, and stores kit in one transaction
+	Replace(ctx context.Context, kit *entities.RecoveryKit) error
+
+	// RecordAttempt counts a redemption attempt before the code is checked. The attempt that reaches
+	// maxAttempts locks the kit until lockedUntil. Returns entities.ErrRecoveryLocked while the kit
+	// is locked and the kit with its updated count otherwise.
+	RecordAttempt(ctx context.Context, kit *entities.RecoveryKit, maxAttempts int, lockedUntil time.Time) (*entities.RecoveryKit, error)
+
+	// ResetAttempts clears the kit's attempt count and lock
+	ResetAttempts(ctx context.Context, kit *entities.RecoveryKit) error
+
+	// Redeem spends the kit, stores the new passkey's wrap and the replacement kit in one
+	// transaction. Returns entities.ErrBackupNotFound if the kit was already spent.
+	Redeem(ctx context.Context, kit *entities.RecoveryKit, key *entities.UserEncryptionKey, replacement *entities.RecoveryKit) error
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+// RefreshTokenRepository defines the interface for refresh token data access. Tokens are looked
+// up by hash and belong to the family of their session; revoking the session deletes them.
+type RefreshTokenRepository interface {
+	// Create stores a token and fills in its ID
+	Create(ctx context.Context, token *entities.RefreshToken) error
+
+	// GetByHash retrieves the token stored under hash, or entities.ErrInvalidRefreshToken
+	GetByHash(ctx context.Context, hash []byte) (*entities.RefreshToken, error)
+
+	// Rotate marks current used and stores next in its family, in one transaction. Returns
+	// entities.ErrRefreshTokenReused if current was already used, including by a concurrent rotation.
+	Rotate(ctx context.Context, current, next *entities.RefreshToken) error
+
+	// RevokeFamily deletes the session of a reused token, and with it every token of the family,
+	// and records the reuse in the audit log in the same transaction
+	RevokeFamily(ctx context.Context, reused *entities.RefreshToken) error
+}
@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// SessionRepository defines the interface for sign-in session data access. Every lookup is
+// scoped to the owning user; revoked sessions are deleted.
+type SessionRepository interface {
+	// Create stores a session and fills in its ID
+	Create(ctx context.Context, session *entities.DeviceSession) error
+
+	// GetByID retrieves one of the user's sessions, or entities.ErrSessionNotFound
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*entities.DeviceSession, error)
+
+	// List returns the user's sessions that have not expired and were active after idleCutoff,
+	// most recently active first
+	List(ctx context.Context, userID uuid.UUID, idleCutoff time.Time) ([]*entities.DeviceSession, error)
+
+	// Touch records activity on a session now, from ipAddress
+	Touch(ctx context.Context, userID, id uuid.UUID, ipAddress string) error
+
+	// Rename changes a session's device name.
+	// Returns entities.ErrSessionNotFound if the user has no such session.
+	Rename(ctx context.Context, userID, id uuid.UUID, name string) error
+
+	// Delete revokes one session.
+	// Returns entities.ErrSessionNotFound if the user has no such session.
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+
+	// DeleteOthers revokes every session of the user except keep, returning the revoked IDs
+	DeleteOthers(ctx context.Context, userID, keep uuid.UUID) ([]uuid.UUID, error)
+
+	// DeleteAll revokes every session of the user, returning the revoked IDs
+	DeleteAll(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+
+	// DeleteExpired removes the user's sessions that expired or were last active before idleCutoff
+	DeleteExpired(ctx context.Context, userID uuid.UUID, idleCutoff time.Time) error
+}
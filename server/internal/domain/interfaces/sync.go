@@ -0,0 +1,30 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// SyncService lets devices keep a local copy of the vault up to date by fetching only what changed
+type SyncService interface {
+	// Sync returns the changes since cursor for the device identified by fingerprint, or the whole
+	// vault when cursor is empty, and records the device's sync
+	Sync(ctx context.Context, userID uuid.UUID, fingerprint, deviceName, cursor string) (*entities.SyncChanges, error)
+
+	// ListDevices returns the devices that have synced the user's vault
+	ListDevices(ctx context.Context, userID uuid.UUID) ([]*entities.SyncDevice, error)
+
+	// PushChanges applies edits a device made offline, each against the revision it was based on.
+	// Changes are applied independently; one that diverged from a concurrent change is stored as a
+	// conflict instead of overwriting it.
+	PushChanges(ctx context.Context, userID uuid.UUID, changes []*entities.SyncChange) ([]*entities.SyncChangeResult, error)
+
+	// ListConflicts returns the user's unresolved conflicts, oldest first
+	ListConflicts(ctx context.Context, userID uuid.UUID) ([]*entities.SyncConflict, error)
+
+	// ResolveConflict settles a conflict by keeping the server version, applying the client version,
+	// or applying a version the device merged from both
+	ResolveConflict(ctx context.Context, userID uuid.UUID, conflictID uuid.UUID, resolution *entities.SyncResolution) (*entities.SyncConflict, *entities.OTP, error)
+}
@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// SyncRepository defines the interface for delta sync data access. The operations it reads are
+// recorded by the OTP and folder repositories in the same transaction as each change.
+type SyncRepository interface {
+	// Changes returns the changes to the user's vault after cursor, collapsed to one per entry, and
+	// the cursor to send next time. An empty cursor returns every active entry as created.
+	// A cursor that cannot be decoded or is ahead of the vault returns entities.ErrInvalidCursor.
+	Changes(ctx context.Context, userID uuid.UUID, cursor string) (*entities.SyncChanges, error)
+
+	// TouchDevice records that a device synced, registering it on first use.
+	// An empty name keeps the device's current name.
+	TouchDevice(ctx context.Context, userID uuid.UUID, fingerprint, name string) (*entities.SyncDevice, error)
+
+	// ListDevices retrieves the user's syncing devices, most recently synced first
+	ListDevices(ctx context.Context, userID uuid.UUID) ([]*entities.SyncDevice, error)
+
+	// ApplyChange applies a change pushed by a device if the entry is still at the change's base
+	// revision, returning the entry afterwards (nil once deleted). If the entry has diverged since, both
+	// versions are stored as a conflict and returned instead, and the entry is left as it is. Returns
+	// entities.ErrTOTPSeedNotFound if the entry is gone or an update targets a trashed entry.
+	ApplyChange(ctx context.Context, userID uuid.UUID, change *entities.SyncChange) (*entities.OTP, *entities.SyncConflict, error)
+
+	// ListConflicts retrieves the user's open conflicts, oldest first
+	ListConflicts(ctx context.Context, userID uuid.UUID) ([]*entities.SyncConflict, error)
+
+	// ResolveConflict settles an open conflict and applies the chosen version, returning the closed
+	// conflict and the entry afterwards (nil once deleted). Returns entities.ErrConflictNotFound,
+	// entities.ErrConflictResolved, or entities.ErrRevisionMismatch if the entry changed after the
+	// resolution was decided.
+	ResolveConflict(ctx context.Context, userID uuid.UUID, conflictID uuid.UUID, resolution *entities.SyncResolution) (*entities.SyncConflict, *entities.OTP, error)
+}
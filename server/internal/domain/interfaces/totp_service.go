@@ -4,16 +4,59 @@ import "time"
 
 // TOTPConfig represents TOTP configuration parameters
 type TOTPConfig struct {
+	Type        string `json:"type"` // "totp" or "hotp"
 	Secret      string `json:"secret"`
 	Issuer      string `json:"issuer"`
 	AccountName string `json:"accountName"`
 	Algorithm   string `json:"algorithm"`
 	Digits      int    `json:"digits"`
 	Period      int    `json:"period"`
+	Counter     uint64 `json:"counter"`
+	Scheme      string `json:"scheme,omitempty"` // Non-standard code scheme, e.g. "steam"
+	Image       string `json:"image,omitempty"`
 }
 
-// TOTPService handles TOTP code generation
+// OTPParams describes how the codes of a single entry are derived
+type OTPParams struct {
+	Type      string // Scheme name; empty selects the standard RFC 4226/6238 scheme
+	Secret    string // Base32-encoded shared secret
+	Algorithm string
+	Digits    int
+	Period    int    // Step size in seconds for time-based codes
+	T0        int64  // Unix time time steps are counted from (RFC 6238 T0)
+	PIN       string // Yandex.Key PIN; only ever supplied by the client
+}
+
+// OTPScheme turns the HMAC of a moving factor into a code for one OTP variant
+type OTPScheme interface {
+	// Name is the OTP type stored on vault entries
+	Name() string
+
+	// Normalize applies the scheme's defaults and rejects parameters it cannot generate codes for.
+	// It must not depend on the secret, which the server only sees encrypted.
+	Normalize(params *OTPParams) error
+
+	// Generate derives the code for a moving factor: a time step for TOTP or a counter for HOTP
+	Generate(params *OTPParams, movingFactor uint64) (string, error)
+}
+
+// TOTPService handles TOTP and HOTP code generation
 type TOTPService interface {
+	// RegisterScheme adds a code scheme; names must be unique
+	RegisterScheme(scheme OTPScheme) error
+
+	// Schemes returns the names of all registered schemes
+	Schemes() []string
+
+	// NormalizeParams applies the defaults of the params' scheme and validates them
+	NormalizeParams(params *OTPParams) error
+
+	// GenerateCodesForParams generates current and next time-based codes for any registered scheme
+	GenerateCodesForParams(params *OTPParams, timestamp time.Time) (string, string, time.Time, time.Time, error)
+
+	// GenerateCounterCodeForParams generates a counter-based code for any registered scheme
+	GenerateCounterCodeForParams(params *OTPParams, counter uint64) (string, error)
+
 	// GenerateCodesForTime generates current and next TOTP codes for a specific time
 	GenerateCodesForTime(secret string, algorithm string, digits int, period int, timestamp time.Time) (string, string, time.Time, time.Time, error)
 
@@ -23,9 +66,19 @@ type TOTPService interface {
 	// ValidateCode validates a TOTP code against the expected value with time tolerance
 	ValidateCode(secret string, algorithm string, digits int, period int, code string, tolerance int) (bool, error)
 
+	// GenerateHOTPCode generates a single HOTP code for a specific counter value
+	GenerateHOTPCode(secret string, algorithm string, digits int, counter uint64) (string, error)
+
+	// ValidateHOTPCode validates an HOTP code within a look-ahead window and returns the next counter on success
+	ValidateHOTPCode(secret string, algorithm string, digits int, counter uint64, code string, lookAhead int) (bool, uint64, error)
+
 	// ParseOTPAuthURL parses an otpauth:// URL and extracts TOTP configuration
 	ParseOTPAuthURL(url string) (*TOTPConfig, error)
 
+	// ParseOTPAuthParams parses an otpauth:// URL whose secret was stripped client-side.
+	// URLs that still carry a secret parameter are rejected.
+	ParseOTPAuthParams(url string) (*TOTPConfig, error)
+
 	// GenerateOTPAuthURL generates an otpauth:// URL from TOTP configuration
 	GenerateOTPAuthURL(config *TOTPConfig) string
 }
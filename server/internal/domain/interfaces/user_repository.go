@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/google/uuid"
@@ -27,9 +28,24 @@ type UserRepository interface {
 	// UpdateLastLogin updates the user's last login timestamp
 	UpdateLastLogin(ctx context.Context, userID uuid.UUID) error
 
-	// Deactivate deactivates a user account
+	// Deactivate deactivates a user account and revokes its tokens
 	Deactivate(ctx context.Context, userID uuid.UUID) error
 
+	// Reactivate reactivates a deactivated user account
+	Reactivate(ctx context.Context, userID uuid.UUID) error
+
+	// RevokeTokens invalidates every token issued to the user so far
+	RevokeTokens(ctx context.Context, userID uuid.UUID) error
+
+	// SetRole changes the user's role and revokes their tokens
+	SetRole(ctx context.Context, userID uuid.UUID, role string) error
+
+	// List retrieves a page of users matching a normalized filter, newest first
+	List(ctx context.Context, filter entities.UserFilter) (*entities.UserPage, error)
+
+	// GetStats computes aggregate counts, counting new and signed-in users from since
+	GetStats(ctx context.Context, since time.Time) (*entities.UserStats, error)
+
 	// Exists checks if a user exists by email or username
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
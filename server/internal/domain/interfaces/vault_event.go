@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// VaultEventService pushes changes to a user's vault and account to their connected devices
+type VaultEventService interface {
+	// Publish records an event that is not an entry change; entry changes are published by the
+	// repositories that make them
+	Publish(ctx context.Context, userID uuid.UUID, eventType string, entityID *uuid.UUID) error
+
+	// Subscribe streams the user's events after cursor, or from now on when cursor is empty,
+	// skipping events made by deviceID other than session revocations. The channel is closed
+	// when ctx ends, the service stops or reading events fails; the device then resubscribes
+	// from the last cursor it received. Returns entities.ErrInvalidCursor for a cursor that
+	// cannot be decoded or is ahead of the vault.
+	Subscribe(ctx context.Context, userID uuid.UUID, deviceID, cursor string) (<-chan *entities.VaultEvent, error)
+}
@@ -0,0 +1,29 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// VaultEventRepository defines the interface for vault event data access. Events share the
+// delta sync sequence, so their cursors have the same format as sync cursors.
+type VaultEventRepository interface {
+	// Create records an event for the user, attributed to the device in the request context
+	Create(ctx context.Context, userID uuid.UUID, eventType string, entityID *uuid.UUID) error
+
+	// ListSince retrieves up to limit of the user's events after cursor, oldest first.
+	// Returns entities.ErrInvalidCursor for a cursor that cannot be decoded or is ahead of the vault.
+	ListSince(ctx context.Context, userID uuid.UUID, cursor string, limit int) ([]*entities.VaultEvent, error)
+
+	// Head returns the cursor of the user's latest event
+	Head(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+// VaultEventListener receives notice of new vault events from every server replica
+type VaultEventListener interface {
+	// Listen calls notify with the user whenever new events are committed for them, until ctx is
+	// cancelled or the connection fails. Notices may be coalesced, so notify reads everything new.
+	Listen(ctx context.Context, notify func(userID uuid.UUID)) error
+}
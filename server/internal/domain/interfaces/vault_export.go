@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+	"io"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// VaultExportService exports a vault to a portable .2fair file and restores such files. The file
+// holds the encrypted entries, the wrapped vault keys and the metadata; the server never decrypts
+// any of it, so the file is useless without one of the user's passkeys.
+type VaultExportService interface {
+	// Export writes the user's active entries, folders and current wrapped keys to w. Returns
+	// entities.ErrInvalidTOTPSeed before writing anything if an entry's secret is not a valid
+	// envelope for the current key version.
+	Export(ctx context.Context, userID uuid.UUID, w io.Writer) error
+
+	// Import restores a verified export with merge or replace semantics. The file must be
+	// encrypted with the vault's current key version; a vault without keys takes the file's, and
+	// then at least one of the user's passkeys must hold a wrap in the file. Wraps for passkeys that
+	// hold none yet are restored. Returns entities.ErrVaultKeyMismatch otherwise and
+	// entities.ErrKeyRotationInProgress during a key rotation.
+	Import(ctx context.Context, userID uuid.UUID, export *entities.VaultExport, mode string) (*entities.VaultImportResult, error)
+}
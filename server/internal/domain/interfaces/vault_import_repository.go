@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// VaultImportRepository defines the interface for restoring vault exports
+type VaultImportRepository interface {
+	// Import restores a prepared export into the user's vault in one transaction. Folders are
+	// matched to the vault's by name under the same parent and created when missing. An entry keeps
+	// its ID unless another user's entry has it. Entries the vault already has, trashed ones
+	// included, are skipped when merging and overwritten when replacing, recording a revision;
+	// replacing also moves the vault's other active entries to the trash.
+	Import(ctx context.Context, userID uuid.UUID, vault *entities.VaultImport) (*entities.VaultImportResult, error)
+}
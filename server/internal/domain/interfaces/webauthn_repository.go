@@ -33,6 +33,10 @@ type WebAuthnCredentialRepository interface {
 	// Delete deletes a credential
 	Delete(ctx context.Context, credentialID []byte, userID uuid.UUID) error
 
+	// DeleteByID deletes a credential by its ID. Returns entities.ErrLastKeyCredential if it holds
+	// the user's last active wrap of the vault key, which would lock the user out of the vault.
+	DeleteByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+
 	// ExistsByCredentialID checks if a credential exists by credential ID
 	ExistsByCredentialID(ctx context.Context, credentialID []byte) (bool, error)
 }
@@ -0,0 +1,268 @@
+package backup
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// Archive layout. A header names the format and the key, then the gzip-compressed backup follows
+// in chunks sealed with AES-256-GCM. Each chunk's nonce is the header's random prefix, the chunk's
+// position and whether it is the last one, and every chunk authenticates the header, so chunks can
+// be neither reordered, dropped, nor cut off at the end without the archive failing to open.
+const (
+	archiveMagic       = "2FAIRBAK"
+	archiveVersion     = 1
+	archiveKeyIDSize   = 8
+	archivePrefixSize  = 7
+	archiveHeaderSize  = len(archiveMagic) + 1 + archiveKeyIDSize + archivePrefixSize
+	archiveChunkSize   = 64 * 1024
+	archiveKeySize     = 32
+	archiveKeyIDDomain = "2fair-backup-key-id"
+)
+
+type archiver struct {
+	aead  cipher.AEAD
+	keyID []byte
+}
+
+// NewArchiver creates an archiver that seals backups with a 32-byte key
+func NewArchiver(key []byte) (interfaces.BackupArchiver, error) {
+	if len(key) != archiveKeySize {
+		return nil, fmt.Errorf("backup key must be %d bytes", archiveKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	// The key ID tells a wrong key from a corrupted archive without revealing the key
+	sum := sha256.Sum256(append([]byte(archiveKeyIDDomain), key...))
+	return &archiver{aead: aead, keyID: sum[:archiveKeyIDSize]}, nil
+}
+
+// Seal returns a writer that compresses and encrypts what is written to it into w
+func (a *archiver) Seal(w io.Writer) (io.WriteCloser, error) {
+	header := make([]byte, 0, archiveHeaderSize)
+	header = append(header, archiveMagic...)
+	header = append(header, archiveVersion)
+	header = append(header, a.keyID...)
+	prefix := make([]byte, archivePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+	header = append(header, prefix...)
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	sealer := &chunkSealer{aead: a.aead, out: w, header: header, buf: make([]byte, 0, archiveChunkSize)}
+	return &sealWriter{gzip: gzip.NewWriter(sealer), sealer: sealer}, nil
+}
+
+// Open returns a reader that decrypts and decompresses the archive read from r
+func (a *archiver) Open(r io.Reader) (io.ReadCloser, error) {
+	header := make([]byte, archiveHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, truncatedError(err)
+	}
+	if string(header[:len(archiveMagic)]) != archiveMagic || header[len(archiveMagic)] != archiveVersion {
+		return nil, fmt.Errorf("%w: not a backup archive", entities.ErrInvalidDatabaseBackup)
+	}
+	keyID := header[len(archiveMagic)+1 : len(archiveMagic)+1+archiveKeyIDSize]
+	if string(keyID) != string(a.keyID) {
+		return nil, fmt.Errorf("%w: archive was sealed with another key", entities.ErrInvalidDatabaseBackup)
+	}
+
+	opener := &chunkOpener{aead: a.aead, in: bufio.NewReader(r), header: header}
+	content, err := gzip.NewReader(opener)
+	if err != nil {
+		return nil, archiveError(err)
+	}
+	return &openReader{gzip: content}, nil
+}
+
+// chunkNonce is the nonce of the chunk at index
+func chunkNonce(header []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, 12)
+	nonce = append(nonce, header[archiveHeaderSize-archivePrefixSize:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, index)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// chunkSealer encrypts what is written to it one chunk at a time
+type chunkSealer struct {
+	aead   cipher.AEAD
+	out    io.Writer
+	header []byte
+	buf    []byte
+	index  uint32
+}
+
+func (s *chunkSealer) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+n]
+		p = p[n:]
+		written += n
+
+		// A full chunk is only sealed once more data follows, so the last chunk is never empty
+		// unless the archive is
+		if len(s.buf) == cap(s.buf) && len(p) > 0 {
+			if err := s.seal(false); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (s *chunkSealer) seal(last bool) error {
+	if s.index == ^uint32(0) {
+		return errors.New("backup archive is too large")
+	}
+	sealed := s.aead.Seal(nil, chunkNonce(s.header, s.index, last), s.buf, s.header)
+	s.index++
+	s.buf = s.buf[:0]
+
+	if err := binary.Write(s.out, binary.BigEndian, uint32(len(sealed))); err != nil {
+		return err
+	}
+	_, err := s.out.Write(sealed)
+	return err
+}
+
+// sealWriter compresses into a chunkSealer and seals the last chunk on Close
+type sealWriter struct {
+	gzip   *gzip.Writer
+	sealer *chunkSealer
+}
+
+func (w *sealWriter) Write(p []byte) (int, error) {
+	return w.gzip.Write(p)
+}
+
+func (w *sealWriter) Close() error {
+	if err := w.gzip.Close(); err != nil {
+		return err
+	}
+	return w.sealer.seal(true)
+}
+
+// chunkOpener decrypts chunks as they are read, failing unless the last one is marked as such
+type chunkOpener struct {
+	aead    cipher.AEAD
+	in      *bufio.Reader
+	header  []byte
+	index   uint32
+	pending []byte
+	done    bool
+}
+
+func (o *chunkOpener) Read(p []byte) (int, error) {
+	for len(o.pending) == 0 {
+		if o.done {
+			return 0, io.EOF
+		}
+		if err := o.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, o.pending)
+	o.pending = o.pending[n:]
+	return n, nil
+}
+
+func (o *chunkOpener) open() error {
+	var size uint32
+	if err := binary.Read(o.in, binary.BigEndian, &size); err != nil {
+		return truncatedError(err)
+	}
+	if size > archiveChunkSize+uint32(o.aead.Overhead()) {
+		return fmt.Errorf("%w: chunk %d is too large", entities.ErrInvalidDatabaseBackup, o.index)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(o.in, sealed); err != nil {
+		return truncatedError(err)
+	}
+
+	// Only the last chunk opens with the last-chunk nonce
+	last := false
+	plain, err := o.aead.Open(nil, chunkNonce(o.header, o.index, false), sealed, o.header)
+	if err != nil {
+		plain, err = o.aead.Open(nil, chunkNonce(o.header, o.index, true), sealed, o.header)
+		last = true
+	}
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d failed authentication", entities.ErrInvalidDatabaseBackup, o.index)
+	}
+	o.index++
+	o.pending = plain
+
+	if last {
+		if _, err := o.in.ReadByte(); err == nil {
+			return fmt.Errorf("%w: unexpected data after the last chunk", entities.ErrInvalidDatabaseBackup)
+		} else if !errors.Is(err, io.EOF) {
+			return err
+		}
+		o.done = true
+	}
+	return nil
+}
+
+// openReader decompresses an opened archive
+type openReader struct {
+	gzip *gzip.Reader
+}
+
+func (r *openReader) Read(p []byte) (int, error) {
+	n, err := r.gzip.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, archiveError(err)
+	}
+	return n, err
+}
+
+func (r *openReader) Close() error {
+	return r.gzip.Close()
+}
+
+// truncatedError marks running out of data as an invalid backup; other read errors pass through
+func truncatedError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: archive is truncated", entities.ErrInvalidDatabaseBackup)
+	}
+	return err
+}
+
+// archiveError marks a decompression error as an invalid backup. Decryption and read errors pass
+// through as they are.
+func archiveError(err error) error {
+	var corrupt flate.CorruptInputError
+	if errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &corrupt) {
+		return fmt.Errorf("%w: %w", entities.ErrInvalidDatabaseBackup, err)
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+func testArchiver(t *testing.T, fill byte) interfaces.BackupArchiver {
+	archiver, err := NewArchiver(bytes.Repeat([]byte{fill}, archiveKeySize))
+	require.NoError(t, err)
+	return archiver
+}
+
+// sealTestArchive seals content that does not compress, so it spans several chunks
+func sealTestArchive(t *testing.T, archiver interfaces.BackupArchiver) ([]byte, []byte) {
+	content := make([]byte, 3*archiveChunkSize+100)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	var buf bytes.Buffer
+	sealed, err := archiver.Seal(&buf)
+	require.NoError(t, err)
+	_, err = sealed.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, sealed.Close())
+
+	return content, buf.Bytes()
+}
+
+func openTestArchive(archiver interfaces.BackupArchiver, archive []byte) ([]byte, error) {
+	content, err := archiver.Open(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	return io.ReadAll(content)
+}
+
+func TestArchiver_RoundTrip(t *testing.T) {
+	archiver := testArchiver(t, 1)
+	content, archive := sealTestArchive(t, archiver)
+
+	assert.False(t, bytes.Contains(archive, content[:64]), "archive must not hold the content in the clear")
+
+	opened, err := openTestArchive(archiver, archive)
+	require.NoError(t, err)
+	assert.Equal(t, content, opened)
+}
+
+func TestArchiver_EmptyContent(t *testing.T) {
+	archiver := testArchiver(t, 1)
+
+	var buf bytes.Buffer
+	sealed, err := archiver.Seal(&buf)
+	require.NoError(t, err)
+	require.NoError(t, sealed.Close())
+
+	opened, err := openTestArchive(archiver, buf.Bytes())
+	require.NoError(t, err)
+	assert.Empty(t, opened)
+}
+
+func TestArchiver_Invalid(t *testing.T) {
+	archiver := testArchiver(t, 1)
+	_, archive := sealTestArchive(t, archiver)
+
+	flipped := bytes.Clone(archive)
+	flipped[archiveHeaderSize+100] ^= 1
+
+	// The second chunk swapped with the first
+	firstEnd := archiveHeaderSize + 4 + archiveChunkSize + 16
+	secondEnd := firstEnd + 4 + archiveChunkSize + 16
+	reordered := append(append(append(bytes.Clone(archive[:archiveHeaderSize]), archive[firstEnd:secondEnd]...), archive[archiveHeaderSize:firstEnd]...), archive[secondEnd:]...)
+
+	tests := []struct {
+		name     string
+		archiver interfaces.BackupArchiver
+		archive  []byte
+	}{
+		{"wrong key", testArchiver(t, 2), archive},
+		{"not an archive", archiver, []byte("PGDMP not a backup archive at all")},
+		{"header only", archiver, archive[:archiveHeaderSize]},
+		{"truncated mid-chunk", archiver, archive[:len(archive)/2]},
+		{"last chunk dropped", archiver, archive[:secondEnd+4+archiveChunkSize+16]},
+		{"flipped bit", archiver, flipped},
+		{"chunks reordered", archiver, reordered},
+		{"trailing data", archiver, append(bytes.Clone(archive), 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := openTestArchive(tt.archiver, tt.archive)
+			assert.ErrorIs(t, err, entities.ErrInvalidDatabaseBackup)
+		})
+	}
+}
+
+func TestNewArchiver_KeySize(t *testing.T) {
+	_, err := NewArchiver(make([]byte, 16))
+	assert.Error(t, err)
+}
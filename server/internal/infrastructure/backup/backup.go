@@ -0,0 +1,37 @@
+// Package backup seals database backups with the server's backup key and keeps them in a local
+// directory or an S3-compatible bucket.
+package backup
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+)
+
+// NewFromConfig creates the archiver and store a validated backup configuration describes
+func NewFromConfig(cfg config.BackupConfig) (interfaces.BackupArchiver, interfaces.BackupStore, error) {
+	key, err := base64.StdEncoding.DecodeString(cfg.EncryptionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("BACKUP_ENCRYPTION_KEY must be base64: %w", err)
+	}
+	archiver, err := NewArchiver(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid BACKUP_ENCRYPTION_KEY: %w", err)
+	}
+
+	var store interfaces.BackupStore
+	switch cfg.Target {
+	case config.BackupTargetS3:
+		store, err = NewS3Store(cfg.S3, http.DefaultClient)
+	default:
+		store, err = NewLocalStore(cfg.LocalDir)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return archiver, store, nil
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// localTempPrefix marks archives still being written; List skips them
+const localTempPrefix = ".tmp-"
+
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store that keeps archives in dir, creating it if needed
+func NewLocalStore(dir string) (interfaces.BackupStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &localStore{dir: dir}, nil
+}
+
+// Put writes the archive to a temporary file and renames it into place, so a crash never leaves
+// a partial archive under the final name
+func (s *localStore) Put(ctx context.Context, name string, archive io.ReadSeeker) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, localTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, archive); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync backup file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close backup file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store backup file: %w", err)
+	}
+	return nil
+}
+
+// Open opens a stored archive
+func (s *localStore) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, entities.ErrDatabaseBackupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	return file, nil
+}
+
+// List returns the files in the directory
+func (s *localStore) List(ctx context.Context) ([]*entities.BackupInfo, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup directory: %w", err)
+	}
+
+	var backups []*entities.BackupInfo
+	for _, entry := range dirEntries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), localTempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Deleted while listing
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup file: %w", err)
+		}
+		backups = append(backups, &entities.BackupInfo{
+			Name:      entry.Name(),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	return backups, nil
+}
+
+// Delete removes a stored archive; removing one that is already gone succeeds
+func (s *localStore) Delete(ctx context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete backup file: %w", err)
+	}
+	return nil
+}
+
+// path resolves a name to a file directly in the directory
+func (s *localStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." || strings.HasPrefix(name, localTempPrefix) {
+		return "", fmt.Errorf("invalid backup name %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
@@ -0,0 +1,55 @@
+package backup
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+func TestLocalStore_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(filepath.Join(dir, "backups"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	name := "2fair-20261016T030000Z.2fairbak"
+	require.NoError(t, store.Put(ctx, name, strings.NewReader("archive")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "backups", localTempPrefix+"leftover"), []byte("partial"), 0o600))
+
+	listed, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1, "temporary files are not listed")
+	assert.Equal(t, name, listed[0].Name)
+	assert.Equal(t, int64(len("archive")), listed[0].Size)
+
+	archive, err := store.Open(ctx, name)
+	require.NoError(t, err)
+	content, err := io.ReadAll(archive)
+	archive.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(content))
+
+	require.NoError(t, store.Delete(ctx, name))
+	require.NoError(t, store.Delete(ctx, name), "deleting a missing backup is not an error")
+	_, err = store.Open(ctx, name)
+	assert.ErrorIs(t, err, entities.ErrDatabaseBackupNotFound)
+}
+
+func TestLocalStore_RejectsPaths(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, name := range []string{"", ".", "..", "../outside", "nested/backup", localTempPrefix + "x"} {
+		_, err := store.Open(context.Background(), name)
+		assert.Error(t, err, name)
+		assert.NotErrorIs(t, err, entities.ErrDatabaseBackupNotFound, name)
+	}
+}
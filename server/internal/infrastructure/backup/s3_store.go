@@ -0,0 +1,311 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+)
+
+// Signature Version 4 constants for S3
+const (
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	s3Service       = "s3"
+	s3DateLayout    = "20060102T150405Z"
+	s3EmptyBodyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type s3Store struct {
+	config   config.BackupS3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store creates a store that keeps archives in an S3-compatible bucket, such as AWS S3 or MinIO.
+// Requests are signed with Signature Version 4.
+func NewS3Store(cfg config.BackupS3Config, client *http.Client) (interfaces.BackupStore, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	return &s3Store{
+		config:   cfg,
+		endpoint: u,
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+// Put uploads an archive in one request. The archive's hash is signed, so S3 rejects an upload
+// that arrives altered.
+func (s *s3Store) Put(ctx context.Context, name string, archive io.ReadSeeker) error {
+	size, err := archive.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, archive); err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(s.config.Prefix+name, nil), io.NopCloser(archive))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.do(req, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return fmt.Errorf("failed to upload backup: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Open downloads an archive
+func (s *s3Store) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(s.config.Prefix+name, nil), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, s3EmptyBodyHash)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// s3ListResult is a page of a ListObjectsV2 response
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// List returns the objects under the prefix, following continuation tokens
+func (s *s3Store) List(ctx context.Context) ([]*entities.BackupInfo, error) {
+	var backups []*entities.BackupInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.config.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL("", query), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req, s3EmptyBodyHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backups: %w", err)
+		}
+		var page s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode backup listing: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			name := strings.TrimPrefix(object.Key, s.config.Prefix)
+			if name == "" || strings.Contains(name, "/") {
+				continue // Not directly under the prefix
+			}
+			backups = append(backups, &entities.BackupInfo{
+				Name:      name,
+				Size:      object.Size,
+				CreatedAt: object.LastModified,
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return backups, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Delete removes an archive; S3 reports success for an object that is already gone
+func (s *s3Store) Delete(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(s.config.Prefix+name, nil), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, s3EmptyBodyHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete backup: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// objectURL is the URL of a key in the bucket, or of the bucket itself for an empty key
+func (s *s3Store) objectURL(key string, query url.Values) string {
+	u := *s.endpoint
+	path := u.Path
+	if s.config.PathStyle {
+		path += "/" + s3Escape(s.config.Bucket, false)
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+	}
+	path += "/" + s3Escape(key, true)
+
+	u.RawPath = path
+	u.Path, _ = url.PathUnescape(path)
+	u.RawQuery = s3CanonicalQuery(query)
+	return u.String()
+}
+
+// s3Error is the body of an S3 error response
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do signs and sends a request, turning error responses into errors
+func (s *s3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.sign(req, payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	var body s3Error
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body)
+	if resp.StatusCode == http.StatusNotFound && body.Code != "NoSuchBucket" {
+		return nil, entities.ErrDatabaseBackupNotFound
+	}
+	if body.Code != "" {
+		return nil, fmt.Errorf("S3 returned %d %s: %s", resp.StatusCode, body.Code, body.Message)
+	}
+	return nil, fmt.Errorf("S3 returned %d", resp.StatusCode)
+}
+
+// sign adds a Signature Version 4 Authorization header covering the host, every header already
+// set on the request and the payload hash
+func (s *s3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(s3DateLayout)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := s3Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.config.SecretAccessKey)
+	for _, part := range []string{date, s.config.Region, s3Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery encodes a query sorted by key, as it is both sent and signed
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(key, false)+"="+s3Escape(value, false))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and slashes if keepSlash is set
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
@@ -15,10 +16,13 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Session  SessionConfig
 	WebAuthn WebAuthnConfig
 	OAuth    OAuthConfig
 	Security SecurityConfig
 	Frontend FrontendConfig
+	Vault    VaultConfig
+	Backup   BackupConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -48,13 +52,21 @@ type DatabaseConfig struct {
 
 // JWTConfig holds JWT-related configuration
 type JWTConfig struct {
-	SigningKey     string
-	ExpirationTime time.Duration
-	RefreshTime    time.Duration
+	KeysDir        string        // Directory of PEM signing keys, each named <kid>.pem
+	ActiveKeyID    string        // Key new tokens are signed with; may be empty when KeysDir holds one key
+	ExpirationTime time.Duration // Lifetime of an access token
+	RefreshTime    time.Duration // Lifetime of a refresh token; each refresh issues a new one
 	Issuer         string
 	Audience       string
 }
 
+// SessionConfig holds sign-in session timeouts
+type SessionConfig struct {
+	IdleTimeout      time.Duration // A session ends once unused for this long
+	AbsoluteTimeout  time.Duration // A session ends this long after sign-in, however much it is used
+	ActivityInterval time.Duration // Activity on a session is written at most this often
+}
+
 // WebAuthnConfig holds WebAuthn-related configuration
 type WebAuthnConfig struct {
 	RPDisplayName string
@@ -94,6 +106,46 @@ type FrontendConfig struct {
 	URL string
 }
 
+// VaultConfig holds limits for vault (OTP) operations
+type VaultConfig struct {
+	BatchMaxOperations  int           // Maximum operations per POST /api/v1/otp/batch request
+	BatchMaxBodyBytes   int64         // Maximum request body size for batch requests
+	TrashRetention      time.Duration // How long deleted entries stay in the trash; 0 keeps them until purged by hand
+	TrashPurgeInterval  time.Duration // How often expired trash entries are purged
+	RecoveryMaxAttempts int           // Failed recovery code attempts in a row before a recovery kit locks
+	RecoveryLockout     time.Duration // How long a recovery kit stays locked
+	ImportMaxBodyBytes  int64         // Maximum size of a vault export file accepted by POST /api/v1/vault/import
+}
+
+// Backup targets
+const (
+	BackupTargetLocal = "local"
+	BackupTargetS3    = "s3"
+)
+
+// BackupConfig holds configuration for database backups
+type BackupConfig struct {
+	Enabled        bool          // Take backups on a schedule while the server runs
+	Interval       time.Duration // Time between scheduled backups
+	EncryptionKey  string        // Base64-encoded 32-byte key archives are encrypted with
+	Target         string        // Where archives are kept: local or s3
+	LocalDir       string        // Directory archives are kept in for the local target
+	RetentionCount int           // Number of newest backups kept; 0 keeps any number
+	RetentionAge   time.Duration // Backups older than this are deleted; 0 keeps them regardless of age
+	S3             BackupS3Config
+}
+
+// BackupS3Config holds the bucket backups are kept in for the s3 target
+type BackupS3Config struct {
+	Endpoint        string // Base URL of the S3 API; empty means AWS in Region
+	Region          string
+	Bucket          string
+	Prefix          string // Prepended to every archive name, e.g. "2fair/"
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool // Address the bucket in the path rather than the host name, as MinIO expects
+}
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists (for development)
@@ -122,12 +174,18 @@ func Load() (*Config, error) {
 			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 1*time.Minute),
 		},
 		JWT: JWTConfig{
-			SigningKey:     getEnv("JWT_SIGNING_KEY", ""),
-			ExpirationTime: getEnvAsDuration("JWT_EXPIRATION_TIME", 1*time.Hour),
-			RefreshTime:    getEnvAsDuration("JWT_REFRESH_TIME", 24*time.Hour),
+			KeysDir:        getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID:    getEnv("JWT_ACTIVE_KEY_ID", ""),
+			ExpirationTime: getEnvAsDuration("JWT_EXPIRATION_TIME", 15*time.Minute),
+			RefreshTime:    getEnvAsDuration("JWT_REFRESH_TIME", 7*24*time.Hour),
 			Issuer:         getEnv("JWT_ISSUER", "2fair.dev"),
 			Audience:       getEnv("JWT_AUDIENCE", "2fair.dev"),
 		},
+		Session: SessionConfig{
+			IdleTimeout:      getEnvAsDuration("SESSION_IDLE_TIMEOUT", 7*24*time.Hour),
+			AbsoluteTimeout:  getEnvAsDuration("SESSION_ABSOLUTE_TIMEOUT", 30*24*time.Hour),
+			ActivityInterval: getEnvAsDuration("SESSION_ACTIVITY_INTERVAL", 1*time.Minute),
+		},
 		WebAuthn: WebAuthnConfig{
 			RPDisplayName: getEnv("WEBAUTHN_RP_DISPLAY_NAME", "2FAir"),
 			RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
@@ -168,6 +226,33 @@ func Load() (*Config, error) {
 		Frontend: FrontendConfig{
 			URL: getEnv("FRONTEND_URL", "http://localhost:5173"),
 		},
+		Vault: VaultConfig{
+			BatchMaxOperations:  getEnvAsInt("VAULT_BATCH_MAX_OPERATIONS", 500),
+			BatchMaxBodyBytes:   int64(getEnvAsInt("VAULT_BATCH_MAX_BODY_BYTES", 2<<20)), // 2MB
+			TrashRetention:      getEnvAsDuration("VAULT_TRASH_RETENTION", 30*24*time.Hour),
+			TrashPurgeInterval:  getEnvAsDuration("VAULT_TRASH_PURGE_INTERVAL", 1*time.Hour),
+			RecoveryMaxAttempts: getEnvAsInt("VAULT_RECOVERY_MAX_ATTEMPTS", 5),
+			RecoveryLockout:     getEnvAsDuration("VAULT_RECOVERY_LOCKOUT", 15*time.Minute),
+			ImportMaxBodyBytes:  int64(getEnvAsInt("VAULT_IMPORT_MAX_BODY_BYTES", 10<<20)), // 10MB
+		},
+		Backup: BackupConfig{
+			Enabled:        getEnvAsBool("BACKUP_ENABLED", false),
+			Interval:       getEnvAsDuration("BACKUP_INTERVAL", 24*time.Hour),
+			EncryptionKey:  getEnv("BACKUP_ENCRYPTION_KEY", ""),
+			Target:         getEnv("BACKUP_TARGET", BackupTargetLocal),
+			LocalDir:       getEnv("BACKUP_LOCAL_DIR", "backups"),
+			RetentionCount: getEnvAsInt("BACKUP_RETENTION_COUNT", 7),
+			RetentionAge:   getEnvAsDuration("BACKUP_RETENTION_AGE", 0),
+			S3: BackupS3Config{
+				Endpoint:        getEnv("BACKUP_S3_ENDPOINT", ""),
+				Region:          getEnv("BACKUP_S3_REGION", "us-east-1"),
+				Bucket:          getEnv("BACKUP_S3_BUCKET", ""),
+				Prefix:          getEnv("BACKUP_S3_PREFIX", ""),
+				AccessKeyID:     getEnv("BACKUP_S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("BACKUP_S3_SECRET_ACCESS_KEY", ""),
+				PathStyle:       getEnvAsBool("BACKUP_S3_PATH_STYLE", false),
+			},
+		},
 	}
 
 	// Validate required configuration
@@ -180,10 +265,6 @@ func Load() (*Config, error) {
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
-	if c.JWT.SigningKey == "" {
-		return fmt.Errorf("JWT_SIGNING_KEY is required")
-	}
-
 	if c.Database.Password == "" && c.Server.Environment == "production" {
 		return fmt.Errorf("DB_PASSWORD is required in production")
 	}
@@ -196,6 +277,62 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("WEBAUTHN_RP_ORIGINS is required")
 	}
 
+	// Without keys the server signs with a temporary key, which only a single development
+	// instance can live with
+	if c.JWT.KeysDir == "" && c.Server.Environment == "production" {
+		return fmt.Errorf("JWT_KEYS_DIR is required in production")
+	}
+
+	if c.JWT.Issuer == "" || c.JWT.Audience == "" {
+		return fmt.Errorf("JWT_ISSUER and JWT_AUDIENCE are required")
+	}
+
+	if c.JWT.ExpirationTime <= 0 || c.JWT.RefreshTime <= c.JWT.ExpirationTime {
+		return fmt.Errorf("JWT_EXPIRATION_TIME must be positive and shorter than JWT_REFRESH_TIME")
+	}
+
+	if c.Session.IdleTimeout <= 0 {
+		return fmt.Errorf("SESSION_IDLE_TIMEOUT must be positive")
+	}
+
+	if c.Session.AbsoluteTimeout <= 0 {
+		return fmt.Errorf("SESSION_ABSOLUTE_TIMEOUT must be positive")
+	}
+
+	if c.Session.ActivityInterval <= 0 || c.Session.ActivityInterval >= c.Session.IdleTimeout {
+		return fmt.Errorf("SESSION_ACTIVITY_INTERVAL must be positive and shorter than SESSION_IDLE_TIMEOUT")
+	}
+
+	if c.Vault.BatchMaxOperations < 1 {
+		return fmt.Errorf("VAULT_BATCH_MAX_OPERATIONS must be at least 1")
+	}
+
+	if c.Vault.TrashRetention < 0 {
+		return fmt.Errorf("VAULT_TRASH_RETENTION must not be negative")
+	}
+
+	if c.Vault.TrashPurgeInterval <= 0 {
+		return fmt.Errorf("VAULT_TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	if c.Vault.RecoveryMaxAttempts < 1 {
+		return fmt.Errorf("VAULT_RECOVERY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if c.Vault.RecoveryLockout <= 0 {
+		return fmt.Errorf("VAULT_RECOVERY_LOCKOUT must be positive")
+	}
+
+	if c.Vault.ImportMaxBodyBytes <= 0 {
+		return fmt.Errorf("VAULT_IMPORT_MAX_BODY_BYTES must be positive")
+	}
+
+	if c.Backup.Enabled {
+		if err := c.ValidateBackup(); err != nil {
+			return err
+		}
+	}
+
 	// Validate OAuth configuration
 	if c.OAuth.SessionSecret == "" {
 		return fmt.Errorf("OAUTH_SESSION_SECRET is required")
@@ -217,6 +354,48 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// ValidateBackup validates the backup configuration. It is checked at startup when scheduled
+// backups are enabled, and by the backup command before it runs.
+func (c *Config) ValidateBackup() error {
+	if c.Backup.EncryptionKey == "" {
+		return fmt.Errorf("BACKUP_ENCRYPTION_KEY is required")
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(c.Backup.EncryptionKey); err != nil || len(key) != 32 {
+		return fmt.Errorf("BACKUP_ENCRYPTION_KEY must be 32 bytes encoded as base64")
+	}
+
+	if c.Backup.Interval <= 0 {
+		return fmt.Errorf("BACKUP_INTERVAL must be positive")
+	}
+
+	if c.Backup.RetentionCount < 0 {
+		return fmt.Errorf("BACKUP_RETENTION_COUNT must not be negative")
+	}
+
+	if c.Backup.RetentionAge < 0 {
+		return fmt.Errorf("BACKUP_RETENTION_AGE must not be negative")
+	}
+
+	switch c.Backup.Target {
+	case BackupTargetLocal:
+		if c.Backup.LocalDir == "" {
+			return fmt.Errorf("BACKUP_LOCAL_DIR is required for the local backup target")
+		}
+	case BackupTargetS3:
+		if c.Backup.S3.Bucket == "" || c.Backup.S3.Region == "" {
+			return fmt.Errorf("BACKUP_S3_BUCKET and BACKUP_S3_REGION are required for the s3 backup target")
+		}
+		if c.Backup.S3.AccessKeyID == "" || c.Backup.S3.SecretAccessKey == "" {
+			return fmt.Errorf("BACKUP_S3_ACCESS_KEY_ID and BACKUP_S3_SECRET_ACCESS_KEY are required for the s3 backup target")
+		}
+	default:
+		return fmt.Errorf("BACKUP_TARGET must be %s or %s", BackupTargetLocal, BackupTargetS3)
+	}
+
+	return nil
+}
+
 // GetDatabaseURL returns the PostgreSQL connection URL
 func (c *Config) GetDatabaseURL() string {
 	return fmt.Sprintf(
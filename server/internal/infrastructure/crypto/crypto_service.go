@@ -5,14 +5,26 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 	"io"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// Argon2id parameters for HashSecret (RFC 9106, second recommended option)
+const (
+	argon2Time    = 3
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
 type cryptoService struct{}
 
 // NewCryptoService creates a new crypto service
@@ -102,6 +114,56 @@ func (c *cryptoService) GenerateRandomSalt() ([]byte, error) {
 	return salt, nil
 }
 
+// HashSecret hashes a secret using Argon2id with a random salt. The hash is encoded in the PHC
+// string format with its parameters and salt, so hashes stay verifiable if the parameters change.
+func (c *cryptoService) HashSecret(secret []byte) ([]byte, error) {
+	salt, err := c.GenerateRandomSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := argon2.IDKey(secret, salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	)
+
+	return []byte(encoded), nil
+}
+
+// VerifySecret reports whether a secret matches a hash produced by HashSecret
+func (c *cryptoService) VerifySecret(secret []byte, encoded []byte) (bool, error) {
+	parts := strings.Split(string(encoded), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return false, fmt.Errorf("unsupported hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2 version")
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("invalid salt: %w", err)
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return false, fmt.Errorf("invalid hash")
+	}
+
+	computed := argon2.IDKey(secret, salt, time, memory, threads, uint32(len(hash)))
+
+	return subtle.ConstantTimeCompare(hash, computed) == 1, nil
+}
+
 // EncryptedData represents encrypted data with metadata
 type EncryptedData struct {
 	Ciphertext []byte `json:"ciphertext"`
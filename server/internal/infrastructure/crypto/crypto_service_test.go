@@ -177,3 +177,46 @@ func TestCryptoService_TamperedData(t *testing.T) {
 		t.Error("Expected decryption to fail with tampered nonce")
 	}
 }
+
+func TestCryptoService_HashSecret(t *testing.T) {
+	crypto := NewCryptoService()
+
+	secret := []byte("recovery-code-0123456789")
+
+	hash1, err := crypto.HashSecret(secret)
+	if err != nil {
+		t.Fatalf("Hashing failed: %v", err)
+	}
+	hash2, err := crypto.HashSecret(secret)
+	if err != nil {
+		t.Fatalf("Hashing failed: %v", err)
+	}
+
+	// Verify the salt makes every hash unique
+	if bytes.Equal(hash1, hash2) {
+		t.Error("Hashes of the same secret should differ")
+	}
+
+	// Verify the right secret matches
+	ok, err := crypto.VerifySecret(secret, hash1)
+	if err != nil {
+		t.Fatalf("Verification failed: %v", err)
+	}
+	if !ok {
+		t.Error("Expected the secret to match its hash")
+	}
+
+	// Verify a wrong secret does not
+	ok, err = crypto.VerifySecret([]byte("recovery-code-9876543210"), hash1)
+	if err != nil {
+		t.Fatalf("Verification failed: %v", err)
+	}
+	if ok {
+		t.Error("Expected a wrong secret not to match")
+	}
+
+	// Verify a malformed hash is rejected
+	if _, err := crypto.VerifySecret(secret, []byte("not-a-hash")); err == nil {
+		t.Error("Expected an error for a malformed hash")
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type auditLogRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(database *DB) interfaces.AuditLogRepository {
+	return &auditLogRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Append chains the entry after the newest one and stores it
+func (r *auditLogRepository) Append(ctx context.Context, entry *entities.AuditLog) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		return appendAuditLogs(ctx, r.queries.WithTx(tx), entry)
+	})
+}
+
+// ListByUser retrieves a page of the user's entries, newest first
+func (r *auditLogRepository) ListByUser(ctx context.Context, userID uuid.UUID, filter entities.AuditLogFilter) (*entities.AuditLogPage, error) {
+	params := db.GetAuditLogsByUserIDParams{
+		UserID:    convertUUIDToPG(userID),
+		Action:    pgtype.Text{String: filter.Action, Valid: filter.Action != ""},
+		PageLimit: int32(filter.Limit + 1), // One extra row tells whether there is a next page
+	}
+	if filter.Cursor != "" {
+		beforeSequence, err := decodeSyncCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.BeforeSequence = pgtype.Int8{Int64: beforeSequence, Valid: true}
+	}
+	if filter.Since != nil {
+		params.Since = pgtype.Timestamptz{Time: *filter.Since, Valid: true}
+	}
+	if filter.Until != nil {
+		params.Until = pgtype.Timestamptz{Time: *filter.Until, Valid: true}
+	}
+
+	rows, err := r.queries.GetAuditLogsByUserID(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	page := &entities.AuditLogPage{Items: make([]*entities.AuditLog, 0, len(rows))}
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		page.NextCursor = encodeSyncCursor(rows[len(rows)-1].Sequence.Int64)
+	}
+	for _, row := range rows {
+		entry, err := convertToAuditLog(row)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, entry)
+	}
+
+	return page, nil
+}
+
+// ListChain retrieves up to limit entries after the given sequence, in chain order
+func (r *auditLogRepository) ListChain(ctx context.Context, afterSequence int64, limit int) ([]*entities.AuditLog, error) {
+	rows, err := r.queries.GetAuditLogChain(ctx, db.GetAuditLogChainParams{
+		Sequence: pgtype.Int8{Int64: afterSequence, Valid: true},
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log chain: %w", err)
+	}
+
+	entries := make([]*entities.AuditLog, 0, len(rows))
+	for _, row := range rows {
+		entry, err := convertToAuditLog(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// appendAuditLogs chains entries after the newest one, in order, and stores them. The chain stays
+// locked until the transaction ends, so like recordSyncOperations it belongs at the end of its
+// transaction.
+func appendAuditLogs(ctx context.Context, queries *db.Queries, entries ...*entities.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := queries.LockAuditChain(ctx); err != nil {
+		return fmt.Errorf("failed to lock audit log chain: %w", err)
+	}
+	head, err := queries.GetAuditChainHead(ctx)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get audit log chain head: %w", err)
+	}
+
+	sequence, prevHash := head.Sequence.Int64, head.Hash
+	for _, entry := range entries {
+		sequence++
+		entry.Chain(sequence, prevHash)
+		prevHash = entry.Hash
+
+		params := db.CreateAuditLogParams{
+			ID:           convertUUIDToPG(entry.ID),
+			UserID:       convertOptionalUUIDToPG(entry.UserID),
+			Action:       entry.Action,
+			ResourceType: entry.ResourceType,
+			ResourceID:   convertOptionalUUIDToPG(entry.ResourceID),
+			UserAgent:    pgtype.Text{String: entry.UserAgent, Valid: entry.UserAgent != ""},
+			RequestID:    pgtype.Text{String: entry.RequestID, Valid: entry.RequestID != ""},
+			Timestamp:    pgtype.Timestamptz{Time: entry.CreatedAt, Valid: true},
+			Sequence:     pgtype.Int8{Int64: entry.Sequence, Valid: true},
+			PrevHash:     entry.PrevHash,
+			Hash:         entry.Hash,
+		}
+		if entry.Metadata != nil {
+			params.Metadata, _ = json.Marshal(entry.Metadata)
+		}
+		if addr, err := netip.ParseAddr(entry.IPAddress); err == nil {
+			params.IpAddress = &addr
+		}
+
+		if _, err := queries.CreateAuditLog(ctx, params); err != nil {
+			return fmt.Errorf("failed to create audit log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// auditEntryChanges builds the audit entries for an operation on the user's entries
+func auditEntryChanges(ctx context.Context, userID pgtype.UUID, operation string, ids []pgtype.UUID) []*entities.AuditLog {
+	info := entities.RequestInfoFromContext(ctx)
+	user := convertPGUUID(userID)
+
+	entries := make([]*entities.AuditLog, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, entities.NewAuditLog(&user, entities.AuditActionForOperation(operation),
+			entities.AuditResourceEntry, convertPGUUIDToOptional(id), map[string]string{"operation": operation}, info))
+	}
+	return entries
+}
+
+// convertToAuditLog converts a database audit log to a domain audit log
+func convertToAuditLog(row db.AuditLog) (*entities.AuditLog, error) {
+	entry := &entities.AuditLog{
+		ID:           convertPGUUID(row.ID),
+		Sequence:     row.Sequence.Int64,
+		UserID:       convertPGUUIDToOptional(row.UserID),
+		Action:       row.Action,
+		ResourceType: row.ResourceType,
+		ResourceID:   convertPGUUIDToOptional(row.ResourceID),
+		UserAgent:    row.UserAgent.String,
+		RequestID:    row.RequestID.String,
+		CreatedAt:    convertPGTimestamp(row.Timestamp).UTC(),
+		PrevHash:     row.PrevHash,
+		Hash:         row.Hash,
+	}
+	if len(row.Metadata) > 0 {
+		if err := json.Unmarshal(row.Metadata, &entry.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode audit log metadata: %w", err)
+		}
+	}
+	if row.IpAddress != nil {
+		entry.IPAddress = row.IpAddress.String()
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,273 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// gooseVersionTable records applied migrations. It is not backed up; a backup records the version
+// instead and only restores into a database migrated to the same version.
+const gooseVersionTable = "goose_db_version"
+
+// Catalog queries. Backups cover every table in the schema, so a new migration needs no change here.
+const (
+	backupTablesQuery = `SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind = 'r' AND c.relname <> $1`
+
+	backupForeignKeysQuery = `SELECT child.relname, parent.relname FROM pg_constraint con
+		JOIN pg_class child ON child.oid = con.conrelid
+		JOIN pg_class parent ON parent.oid = con.confrelid
+		JOIN pg_namespace n ON n.oid = child.relnamespace
+		WHERE con.contype = 'f' AND n.nspname = current_schema()`
+
+	// Generated columns are computed again on restore, so they are left out
+	backupColumnsQuery = `SELECT attname FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+		ORDER BY attnum`
+
+	backupSchemaVersionQuery = `SELECT version_id, is_applied FROM ` + gooseVersionTable + ` ORDER BY id DESC`
+)
+
+type backupRepository struct {
+	db *DB
+}
+
+// NewBackupRepository creates a new backup repository
+func NewBackupRepository(database *DB) interfaces.BackupRepository {
+	return &backupRepository{
+		db: database,
+	}
+}
+
+// Dump copies every table from one repeatable-read snapshot, referenced tables first
+func (r *backupRepository) Dump(ctx context.Context, w io.Writer, createdAt time.Time) (*entities.BackupManifest, error) {
+	tx, err := r.db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	schemaVersion, err := backupSchemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := backupTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	backup, err := entities.NewBackupWriter(w, schemaVersion, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		columns, err := backupColumns(ctx, tx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		sql := fmt.Sprintf("COPY %s (%s) TO STDOUT", quoteIdentifier(table), quoteIdentifiers(columns))
+		err = backup.WriteTable(table, columns, func(w io.Writer) (int64, error) {
+			tag, err := tx.Conn().PgConn().CopyTo(ctx, w, sql)
+			return tag.RowsAffected(), err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return backup.Close()
+}
+
+// Restore truncates every table and copies the backup's rows in, all in one transaction. The
+// backup must hold exactly the tables and columns of the current schema.
+func (r *backupRepository) Restore(ctx context.Context, backup *entities.BackupReader) (*entities.BackupManifest, error) {
+	var manifest *entities.BackupManifest
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		schemaVersion, err := backupSchemaVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if backup.Header().SchemaVersion != schemaVersion {
+			return fmt.Errorf("%w: the backup is at version %d and the database at %d", entities.ErrDatabaseBackupSchema, backup.Header().SchemaVersion, schemaVersion)
+		}
+
+		tables, err := backupTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if len(tables) > 0 {
+			if _, err := tx.Exec(ctx, "TRUNCATE "+quoteIdentifiers(tables)); err != nil {
+				return fmt.Errorf("failed to truncate tables: %w", err)
+			}
+		}
+
+		pending := make(map[string]bool, len(tables))
+		for _, table := range tables {
+			pending[table] = true
+		}
+		for {
+			table, rows, err := backup.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			if !pending[table.Name] {
+				return fmt.Errorf("%w: table %s is not in the schema or appears twice", entities.ErrDatabaseBackupSchema, table.Name)
+			}
+			delete(pending, table.Name)
+			columns, err := backupColumns(ctx, tx, table.Name)
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(slices.Sorted(slices.Values(columns)), slices.Sorted(slices.Values(table.Columns))) {
+				return fmt.Errorf("%w: the columns of table %s differ", entities.ErrDatabaseBackupSchema, table.Name)
+			}
+
+			sql := fmt.Sprintf("COPY %s (%s) FROM STDIN", quoteIdentifier(table.Name), quoteIdentifiers(table.Columns))
+			if _, err := tx.Conn().PgConn().CopyFrom(ctx, rows, sql); err != nil {
+				return fmt.Errorf("failed to restore table %s: %w", table.Name, err)
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%w: the backup is missing %d tables", entities.ErrDatabaseBackupSchema, len(pending))
+		}
+
+		manifest = backup.Manifest()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// backupSchemaVersion is the current migration version, worked out the way goose does: the newest
+// applied version that was not rolled back later
+func backupSchemaVersion(ctx context.Context, tx pgx.Tx) (int64, error) {
+	rows, err := tx.Query(ctx, backupSchemaVersionQuery)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	defer rows.Close()
+
+	rolledBack := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var applied bool
+		if err := rows.Scan(&version, &applied); err != nil {
+			return 0, fmt.Errorf("failed to scan schema version: %w", err)
+		}
+		if !applied {
+			rolledBack[version] = true
+		} else if !rolledBack[version] {
+			return version, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return 0, nil
+}
+
+// backupTables lists the tables of the schema so that every table comes after the tables its
+// foreign keys reference
+func backupTables(ctx context.Context, tx pgx.Tx) ([]string, error) {
+	rows, err := tx.Query(ctx, backupTablesQuery, gooseVersionTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	rows, err = tx.Query(ctx, backupForeignKeysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+	parents := make(map[string][]string, len(names))
+	for rows.Next() {
+		var child, parent string
+		if err := rows.Scan(&child, &parent); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign key: %w", err)
+		}
+		if child != parent {
+			parents[child] = append(parents[child], parent)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list foreign keys: %w", err)
+	}
+
+	// Take the tables whose parents are all placed, in name order so backups are reproducible
+	slices.Sort(names)
+	ordered := make([]string, 0, len(names))
+	placed := make(map[string]bool, len(names))
+	for len(ordered) < len(names) {
+		progress := false
+		for _, name := range names {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for _, parent := range parents[name] {
+				ready = ready && placed[parent]
+			}
+			if ready {
+				ordered = append(ordered, name)
+				placed[name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, errors.New("foreign keys between tables form a cycle")
+		}
+	}
+
+	return ordered, nil
+}
+
+// backupColumns lists a table's stored columns in order
+func backupColumns(ctx context.Context, tx pgx.Tx, table string) ([]string, error) {
+	rows, err := tx.Query(ctx, backupColumnsQuery, quoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	columns, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+func quoteIdentifier(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type encryptionKeyRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewEncryptionKeyRepository creates a new encryption key repository
+func NewEncryptionKeyRepository(database *DB) interfaces.EncryptionKeyRepository {
+	return &encryptionKeyRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create stores a wrapped DEK
+func (r *encryptionKeyRepository) Create(ctx context.Context, key *entities.UserEncryptionKey) error {
+	return createEncryptionKey(ctx, r.queries, key)
+}
+
+// GetActiveByCredential retrieves the active wrap held by a credential
+func (r *encryptionKeyRepository) GetActiveByCredential(ctx context.Context, userID uuid.UUID, credentialID uuid.UUID) (*entities.UserEncryptionKey, error) {
+	row, err := r.queries.GetActiveUserEncryptionKeyByCredential(ctx, db.GetActiveUserEncryptionKeyByCredentialParams{
+		UserID:               convertUUIDToPG(userID),
+		WebauthnCredentialID: convertUUIDToPG(credentialID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get encryption key: %w", err)
+	}
+
+	return convertToUserEncryptionKey(row), nil
+}
+
+// GetAllActiveByUserID retrieves the active wraps of all of the user's credentials, oldest first
+func (r *encryptionKeyRepository) GetAllActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.UserEncryptionKey, error) {
+	rows, err := r.queries.GetActiveUserEncryptionKeys(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption keys: %w", err)
+	}
+
+	keys := make([]*entities.UserEncryptionKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, convertToUserEncryptionKey(row))
+	}
+
+	return keys, nil
+}
+
+// GetActiveByUserID retrieves the user's active key with the highest version
+func (r *encryptionKeyRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID) (*entities.UserEncryptionKey, error) {
+	row, err := r.queries.GetActiveUserEncryptionKey(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get active encryption key: %w", err)
+	}
+
+	return convertToUserEncryptionKey(row), nil
+}
+
+// GetByUserIDAndVersion retrieves a key of the given version
+func (r *encryptionKeyRepository) GetByUserIDAndVersion(ctx context.Context, userID uuid.UUID, version int) (*entities.UserEncryptionKey, error) {
+	row, err := r.queries.GetUserEncryptionKeyByVersion(ctx, db.GetUserEncryptionKeyByVersionParams{
+		UserID:     convertUUIDToPG(userID),
+		KeyVersion: int32(version),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get encryption key: %w", err)
+	}
+
+	return convertToUserEncryptionKey(row), nil
+}
+
+// GetAllByUserID retrieves all of the user's keys, newest version first
+func (r *encryptionKeyRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.UserEncryptionKey, error) {
+	rows, err := r.queries.GetUserEncryptionKeys(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption keys: %w", err)
+	}
+
+	keys := make([]*entities.UserEncryptionKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, convertToUserEncryptionKey(row))
+	}
+
+	return keys, nil
+}
+
+// Update replaces a key's wrap, salt and active flag
+func (r *encryptionKeyRepository) Update(ctx context.Context, key *entities.UserEncryptionKey) error {
+	updated, err := r.queries.UpdateUserEncryptionKey(ctx, db.UpdateUserEncryptionKeyParams{
+		ID:           convertUUIDToPG(key.ID),
+		UserID:       convertUUIDToPG(key.UserID),
+		EncryptedDek: key.WrappedDEK,
+		Salt:         key.Salt,
+		IsActive:     key.IsActive,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update encryption key: %w", err)
+	}
+	if updated == 0 {
+		return entities.ErrKeyNotFound
+	}
+
+	return nil
+}
+
+// DeactivateOldKeys deactivates the user's keys below currentVersion
+func (r *encryptionKeyRepository) DeactivateOldKeys(ctx context.Context, userID uuid.UUID, currentVersion int) error {
+	if err := r.queries.DeactivateOldUserEncryptionKeys(ctx, db.DeactivateOldUserEncryptionKeysParams{
+		UserID:     convertUUIDToPG(userID),
+		KeyVersion: int32(currentVersion),
+	}); err != nil {
+		return fmt.Errorf("failed to deactivate old encryption keys: %w", err)
+	}
+
+	return nil
+}
+
+// RotateKey stores an active key and deactivates the older versions in one transaction
+func (r *encryptionKeyRepository) RotateKey(ctx context.Context, newKey *entities.UserEncryptionKey) error {
+	newKey.Activate()
+
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := createEncryptionKey(ctx, queries, newKey); err != nil {
+			return err
+		}
+
+		if err := queries.DeactivateOldUserEncryptionKeys(ctx, db.DeactivateOldUserEncryptionKeysParams{
+			UserID:     convertUUIDToPG(newKey.UserID),
+			KeyVersion: int32(newKey.KeyVersion),
+		}); err != nil {
+			return fmt.Errorf("failed to deactivate old encryption keys: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// GetLatestVersion returns the highest active key version, or 0 when the user has no key yet
+func (r *encryptionKeyRepository) GetLatestVersion(ctx context.Context, userID uuid.UUID) (int, error) {
+	version, err := r.queries.GetLatestUserEncryptionKeyVersion(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest key version: %w", err)
+	}
+
+	return int(version), nil
+}
+
+// createEncryptionKey stores a key and fills in its generated ID and creation time
+func createEncryptionKey(ctx context.Context, queries *db.Queries, key *entities.UserEncryptionKey) error {
+	row, err := queries.CreateUserEncryptionKey(ctx, db.CreateUserEncryptionKeyParams{
+		UserID:               convertUUIDToPG(key.UserID),
+		WebauthnCredentialID: convertUUIDToPG(key.CredentialID),
+		EncryptedDek:         key.WrappedDEK,
+		KeyVersion:           int32(key.KeyVersion),
+		Salt:                 key.Salt,
+		IsActive:             key.IsActive,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return entities.ErrKeyExists
+		}
+		return fmt.Errorf("failed to create encryption key: %w", err)
+	}
+
+	key.ID = convertPGUUID(row.ID)
+	key.CreatedAt = convertPGTimestamp(row.CreatedAt)
+
+	return nil
+}
+
+// convertToUserEncryptionKey converts a database key to a domain encryption key
+func convertToUserEncryptionKey(row db.UserEncryptionKey) *entities.UserEncryptionKey {
+	return &entities.UserEncryptionKey{
+		ID:           convertPGUUID(row.ID),
+		UserID:       convertPGUUID(row.UserID),
+		CredentialID: convertPGUUID(row.WebauthnCredentialID),
+		KeyVersion:   int(row.KeyVersion),
+		WrappedDEK:   row.EncryptedDek,
+		Salt:         row.Salt,
+		CreatedAt:    convertPGTimestamp(row.CreatedAt),
+		IsActive:     row.IsActive,
+	}
+}
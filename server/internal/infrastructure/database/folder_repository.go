@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+type folderRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewFolderRepository creates a new folder repository
+func NewFolderRepository(database *DB) interfaces.FolderRepository {
+	return &folderRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create creates a new folder
+func (r *folderRepository) Create(ctx context.Context, folder *entities.Folder) error {
+	row, err := r.queries.CreateFolder(ctx, db.CreateFolderParams{
+		UserID:   convertUUIDToPG(folder.UserID),
+		ParentID: convertOptionalUUIDToPG(folder.ParentID),
+		Name:     folder.Name,
+	})
+	if err != nil {
+		return convertFolderError(err, "failed to create folder")
+	}
+
+	folder.ID = convertPGUUID(row.ID)
+	folder.CreatedAt = convertPGTimestamp(row.CreatedAt)
+	folder.UpdatedAt = convertPGTimestamp(row.UpdatedAt)
+	return nil
+}
+
+// GetByID retrieves a folder owned by the user
+func (r *folderRepository) GetByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.Folder, error) {
+	row, err := r.queries.GetFolderByID(ctx, db.GetFolderByIDParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrFolderNotFound
+		}
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+
+	return convertDBFolderToEntity(row), nil
+}
+
+// GetByUserID retrieves all of the user's folders with their entry counts
+func (r *folderRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.Folder, error) {
+	rows, err := r.queries.ListFoldersByUser(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+
+	folders := make([]*entities.Folder, 0, len(rows))
+	for _, row := range rows {
+		folder := convertDBFolderToEntity(db.Folder{
+			ID:        row.ID,
+			UserID:    row.UserID,
+			ParentID:  row.ParentID,
+			Name:      row.Name,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		})
+		folder.EntryCount = row.EntryCount
+		folders = append(folders, folder)
+	}
+
+	return folders, nil
+}
+
+// IsInSubtree reports whether folderID is rootID itself or one of its descendants
+func (r *folderRepository) IsInSubtree(ctx context.Context, rootID uuid.UUID, folderID uuid.UUID) (bool, error) {
+	inSubtree, err := r.queries.IsFolderInSubtree(ctx, db.IsFolderInSubtreeParams{
+		RootID:   convertUUIDToPG(rootID),
+		FolderID: convertUUIDToPG(folderID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to walk folder tree: %w", err)
+	}
+
+	return inSubtree, nil
+}
+
+// Update saves a folder's name and parent
+func (r *folderRepository) Update(ctx context.Context, folder *entities.Folder) error {
+	row, err := r.queries.UpdateFolder(ctx, db.UpdateFolderParams{
+		ID:       convertUUIDToPG(folder.ID),
+		UserID:   convertUUIDToPG(folder.UserID),
+		Name:     folder.Name,
+		ParentID: convertOptionalUUIDToPG(folder.ParentID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.ErrFolderNotFound
+		}
+		return convertFolderError(err, "failed to update folder")
+	}
+
+	folder.UpdatedAt = convertPGTimestamp(row.UpdatedAt)
+	return nil
+}
+
+// Delete deletes a folder after moving its entries and subfolders to its parent
+func (r *folderRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		folder, err := queries.GetFolderByID(ctx, db.GetFolderByIDParams{
+			ID:     convertUUIDToPG(id),
+			UserID: convertUUIDToPG(userID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrFolderNotFound
+			}
+			return fmt.Errorf("failed to get folder: %w", err)
+		}
+
+		moved, err := queries.MoveTOTPSeedsToFolder(ctx, db.MoveTOTPSeedsToFolderParams{
+			ToFolderID:   folder.ParentID,
+			UserID:       folder.UserID,
+			FromFolderID: folder.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to move folder entries: %w", err)
+		}
+
+		if err := queries.ReparentFolders(ctx, db.ReparentFoldersParams{
+			ToParentID:   folder.ParentID,
+			UserID:       folder.UserID,
+			FromParentID: folder.ID,
+		}); err != nil {
+			return convertFolderError(err, "failed to move subfolders")
+		}
+
+		if _, err := queries.DeleteFolder(ctx, db.DeleteFolderParams{
+			ID:     folder.ID,
+			UserID: folder.UserID,
+		}); err != nil {
+			return fmt.Errorf("failed to delete folder: %w", err)
+		}
+
+		return recordSyncOperationsPG(ctx, queries, folder.UserID, entities.SyncOperationUpdate, moved)
+	})
+}
+
+// convertFolderError maps sibling name collisions to entities.ErrFolderExists
+func convertFolderError(err error, message string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return entities.ErrFolderExists
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+func convertDBFolderToEntity(row db.Folder) *entities.Folder {
+	return &entities.Folder{
+		ID:        convertPGUUID(row.ID),
+		UserID:    convertPGUUID(row.UserID),
+		ParentID:  convertPGUUIDToOptional(row.ParentID),
+		Name:      row.Name,
+		CreatedAt: convertPGTimestamp(row.CreatedAt),
+		UpdatedAt: convertPGTimestamp(row.UpdatedAt),
+	}
+}
+
+// convertOptionalUUIDToPG maps nil to SQL NULL
+func convertOptionalUUIDToPG(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return convertUUIDToPG(*id)
+}
+
+// convertPGUUIDToOptional maps SQL NULL to nil
+func convertPGUUIDToOptional(pgID pgtype.UUID) *uuid.UUID {
+	if !pgID.Valid {
+		return nil
+	}
+	id := uuid.UUID(pgID.Bytes)
+	return &id
+}
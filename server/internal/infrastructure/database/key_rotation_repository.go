@@ -0,0 +1,333 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type keyRotationRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewKeyRotationRepository creates a new key rotation repository
+func NewKeyRotationRepository(database *DB) interfaces.KeyRotationRepository {
+	return &keyRotationRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create starts a rotation and stores the new version's keys inactive
+func (r *keyRotationRepository) Create(ctx context.Context, rotation *entities.KeyRotation, keys []*entities.UserEncryptionKey) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		row, err := queries.CreateKeyRotation(ctx, db.CreateKeyRotationParams{
+			UserID:      convertUUIDToPG(rotation.UserID),
+			FromVersion: int32(rotation.FromVersion),
+			ToVersion:   int32(rotation.ToVersion),
+		})
+		if err != nil {
+			// Only one rotation per user may be in progress
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return entities.ErrKeyRotationInProgress
+			}
+			return fmt.Errorf("failed to create key rotation: %w", err)
+		}
+
+		for _, key := range keys {
+			key.Deactivate()
+			if err := createEncryptionKey(ctx, queries, key); err != nil {
+				return err
+			}
+		}
+
+		*rotation = *convertToKeyRotation(row)
+		return r.loadProgress(ctx, queries, rotation)
+	})
+}
+
+// GetInProgress retrieves the user's rotation in progress with its progress counts
+func (r *keyRotationRepository) GetInProgress(ctx context.Context, userID uuid.UUID) (*entities.KeyRotation, error) {
+	row, err := r.queries.GetInProgressKeyRotation(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrKeyRotationNotFound
+		}
+		return nil, fmt.Errorf("failed to get key rotation: %w", err)
+	}
+
+	rotation := convertToKeyRotation(row)
+	if err := r.loadProgress(ctx, r.queries, rotation); err != nil {
+		return nil, err
+	}
+
+	return rotation, nil
+}
+
+// ListPending retrieves up to limit pending entries, ordered by ID
+func (r *keyRotationRepository) ListPending(ctx context.Context, rotation *entities.KeyRotation, limit int) ([]*entities.KeyRotationEntry, error) {
+	rows, err := r.queries.ListPendingKeyRotationEntries(ctx, db.ListPendingKeyRotationEntriesParams{
+		RotationID: convertUUIDToPG(rotation.ID),
+		UserID:     convertUUIDToPG(rotation.UserID),
+		ToVersion:  int32(rotation.ToVersion),
+		MaxEntries: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending key rotation entries: %w", err)
+	}
+
+	entries := make([]*entities.KeyRotationEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, &entities.KeyRotationEntry{
+			ID:         convertPGUUID(row.ID),
+			Revision:   row.Revision,
+			KeyVersion: int(row.KeyVersion),
+			Secret:     formatSecret(row.SecretFormat, row.CipherSuite, row.KeyVersion, row.SecretIv, row.SecretTag, row.EncryptedSecret),
+		})
+	}
+
+	return entries, nil
+}
+
+// Stage stores re-encrypted secrets under the rotation's lock
+func (r *keyRotationRepository) Stage(ctx context.Context, rotation *entities.KeyRotation, entries []*entities.KeyRotationEntry) ([]uuid.UUID, error) {
+	rejected := []uuid.UUID{}
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := lockKeyRotation(ctx, queries, rotation); err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			secret, err := parseSecretColumns(entry.Secret)
+			if err != nil {
+				return fmt.Errorf("entry %s: %w", entry.ID, err)
+			}
+
+			staged, err := queries.StageKeyRotationEntry(ctx, db.StageKeyRotationEntryParams{
+				RotationID:      convertUUIDToPG(rotation.ID),
+				EncryptedSecret: secret.Ciphertext,
+				SecretFormat:    secret.Format,
+				CipherSuite:     secret.Suite,
+				SecretIv:        secret.IV,
+				SecretTag:       secret.Tag,
+				SeedID:          convertUUIDToPG(entry.ID),
+				UserID:          convertUUIDToPG(rotation.UserID),
+				SeedRevision:    entry.Revision,
+				ToVersion:       int32(rotation.ToVersion),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to stage key rotation entry: %w", err)
+			}
+			if staged == 0 {
+				rejected = append(rejected, entry.ID)
+			}
+		}
+
+		if err := queries.TouchKeyRotation(ctx, convertUUIDToPG(rotation.ID)); err != nil {
+			return fmt.Errorf("failed to update key rotation: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, nil
+}
+
+// Commit swaps the staged secrets in and activates the new keys in one transaction. Entries
+// staged from an outdated revision are left alone, so they keep the rotation incomplete.
+func (r *keyRotationRepository) Commit(ctx context.Context, rotation *entities.KeyRotation) (*entities.KeyRotation, error) {
+	var committed *entities.KeyRotation
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+		userID := convertUUIDToPG(rotation.UserID)
+		rotationID := convertUUIDToPG(rotation.ID)
+		toVersion := int32(rotation.ToVersion)
+
+		if err := lockKeyRotation(ctx, queries, rotation); err != nil {
+			return err
+		}
+
+		// The server cannot check the re-encrypted secrets, so the versions they replace are kept
+		// as revisions: a client that still holds the old key can recover an entry rotated with a
+		// bad key from them. They stay encrypted with the old key, so they cannot be reverted to.
+		current, err := queries.ListCurrentKeyRotationEntries(ctx, db.ListCurrentKeyRotationEntriesParams{
+			RotationID: rotationID,
+			UserID:     userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list key rotation entries: %w", err)
+		}
+		if len(current) > 0 {
+			// Trashed entries have no revisions, so none may be recorded
+			if err := createRevisions(ctx, queries, rotation.UserID, entities.OTPRevisionKeyRotation, convertPGUUIDs(current)...); err != nil && !errors.Is(err, entities.ErrTOTPSeedNotFound) {
+				return err
+			}
+		}
+
+		applied, err := queries.ApplyKeyRotationEntries(ctx, db.ApplyKeyRotationEntriesParams{
+			ToVersion:  toVersion,
+			RotationID: rotationID,
+			UserID:     userID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply key rotation entries: %w", err)
+		}
+
+		pending, err := queries.CountPendingKeyRotationEntries(ctx, db.CountPendingKeyRotationEntriesParams{
+			UserID:     userID,
+			KeyVersion: toVersion,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to count pending key rotation entries: %w", err)
+		}
+		if pending > 0 {
+			return fmt.Errorf("%w: %d entries pending", entities.ErrKeyRotationIncomplete, pending)
+		}
+
+		if err := queries.ActivateUserEncryptionKeys(ctx, db.ActivateUserEncryptionKeysParams{
+			UserID:     userID,
+			KeyVersion: toVersion,
+		}); err != nil {
+			return fmt.Errorf("failed to activate encryption keys: %w", err)
+		}
+		// Old keys stay stored so revisions encrypted with them can still be read
+		if err := queries.DeactivateOldUserEncryptionKeys(ctx, db.DeactivateOldUserEncryptionKeysParams{
+			UserID:     userID,
+			KeyVersion: toVersion,
+		}); err != nil {
+			return fmt.Errorf("failed to deactivate old encryption keys: %w", err)
+		}
+
+		row, err := queries.FinishKeyRotation(ctx, db.FinishKeyRotationParams{
+			ID:     rotationID,
+			Status: entities.KeyRotationCompleted,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to complete key rotation: %w", err)
+		}
+		if err := queries.DeleteKeyRotationEntries(ctx, rotationID); err != nil {
+			return fmt.Errorf("failed to delete key rotation entries: %w", err)
+		}
+
+		committed = convertToKeyRotation(row)
+		committed.Total = int64(len(applied))
+		committed.Staged = int64(len(applied))
+
+		// Trashed entries are not in the devices' copies of the vault
+		updated := make([]pgtype.UUID, 0, len(applied))
+		for _, entry := range applied {
+			if entry.IsActive.Bool {
+				updated = append(updated, entry.ID)
+			}
+		}
+
+		return recordSyncOperationsPG(ctx, queries, userID, entities.SyncOperationUpdate, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return committed, nil
+}
+
+// Abort discards the rotation's staged secrets and the keys it would have activated
+func (r *keyRotationRepository) Abort(ctx context.Context, rotation *entities.KeyRotation) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+		rotationID := convertUUIDToPG(rotation.ID)
+
+		if err := lockKeyRotation(ctx, queries, rotation); err != nil {
+			return err
+		}
+
+		if err := queries.DeleteKeyRotationEntries(ctx, rotationID); err != nil {
+			return fmt.Errorf("failed to delete key rotation entries: %w", err)
+		}
+		if err := queries.DeleteInactiveUserEncryptionKeys(ctx, db.DeleteInactiveUserEncryptionKeysParams{
+			UserID:     convertUUIDToPG(rotation.UserID),
+			KeyVersion: int32(rotation.ToVersion),
+		}); err != nil {
+			return fmt.Errorf("failed to delete encryption keys: %w", err)
+		}
+		if _, err := queries.FinishKeyRotation(ctx, db.FinishKeyRotationParams{
+			ID:     rotationID,
+			Status: entities.KeyRotationAborted,
+		}); err != nil {
+			return fmt.Errorf("failed to abort key rotation: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// loadProgress counts the rotation's total and staged entries
+func (r *keyRotationRepository) loadProgress(ctx context.Context, queries *db.Queries, rotation *entities.KeyRotation) error {
+	progress, err := queries.GetKeyRotationProgress(ctx, db.GetKeyRotationProgressParams{
+		RotationID: convertUUIDToPG(rotation.ID),
+		UserID:     convertUUIDToPG(rotation.UserID),
+		ToVersion:  int32(rotation.ToVersion),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get key rotation progress: %w", err)
+	}
+
+	rotation.Total = progress.TotalEntries
+	rotation.Staged = progress.StagedEntries
+
+	return nil
+}
+
+// lockKeyRotation locks the user's rotation in progress until the transaction ends and checks it
+// is still the given one
+func lockKeyRotation(ctx context.Context, queries *db.Queries, rotation *entities.KeyRotation) error {
+	row, err := queries.GetInProgressKeyRotationForUpdate(ctx, convertUUIDToPG(rotation.UserID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entities.ErrKeyRotationNotFound
+		}
+		return fmt.Errorf("failed to lock key rotation: %w", err)
+	}
+	if convertPGUUID(row.ID) != rotation.ID {
+		return entities.ErrKeyRotationNotFound
+	}
+
+	return nil
+}
+
+// convertToKeyRotation converts a database key rotation to a domain key rotation
+func convertToKeyRotation(row db.KeyRotation) *entities.KeyRotation {
+	rotation := &entities.KeyRotation{
+		ID:          convertPGUUID(row.ID),
+		UserID:      convertPGUUID(row.UserID),
+		FromVersion: int(row.FromVersion),
+		ToVersion:   int(row.ToVersion),
+		Status:      row.Status,
+		CreatedAt:   convertPGTimestamp(row.CreatedAt),
+		UpdatedAt:   convertPGTimestamp(row.UpdatedAt),
+	}
+	if row.CompletedAt.Valid {
+		rotation.CompletedAt = &row.CompletedAt.Time
+	}
+
+	return rotation
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Add counter-based (HOTP) entries alongside time-based (TOTP) ones.
+-- The counter lives server-side so that two devices never issue the same code.
+ALTER TABLE encrypted_totp_seeds ADD COLUMN method VARCHAR(10) NOT NULL DEFAULT 'TOTP';
+ALTER TABLE encrypted_totp_seeds ADD COLUMN counter BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE encrypted_totp_seeds ADD CONSTRAINT chk_encrypted_totp_seeds_method
+    CHECK (method IN ('TOTP', 'HOTP'));
+ALTER TABLE encrypted_totp_seeds ADD CONSTRAINT chk_encrypted_totp_seeds_counter
+    CHECK (counter >= 0);
+
+-- +goose Down
+ALTER TABLE encrypted_totp_seeds DROP CONSTRAINT IF EXISTS chk_encrypted_totp_seeds_counter;
+ALTER TABLE encrypted_totp_seeds DROP CONSTRAINT IF EXISTS chk_encrypted_totp_seeds_method;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS counter;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS method;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Non-standard code schemes (Steam Guard, Yandex.Key) and a custom RFC 6238 T0.
+-- Digits are unconstrained in the schema; each scheme validates its own format.
+ALTER TABLE encrypted_totp_seeds ADD COLUMN otp_type VARCHAR(20) NOT NULL DEFAULT 'standard';
+ALTER TABLE encrypted_totp_seeds ADD COLUMN t0 BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE encrypted_totp_seeds ADD CONSTRAINT chk_encrypted_totp_seeds_t0
+    CHECK (t0 >= 0);
+
+-- +goose Down
+ALTER TABLE encrypted_totp_seeds DROP CONSTRAINT IF EXISTS chk_encrypted_totp_seeds_t0;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS t0;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS otp_type;
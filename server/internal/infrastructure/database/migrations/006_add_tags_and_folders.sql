@@ -0,0 +1,45 @@
+-- +goose Up
+-- Organize vault entries with free-form tags and nested folders
+
+CREATE TABLE folders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    parent_id UUID,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT fk_folders_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_folders_parent_id
+        FOREIGN KEY (parent_id)
+        REFERENCES folders(id)
+        ON DELETE CASCADE,
+    CONSTRAINT chk_folders_parent_not_self
+        CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+-- Sibling folder names are unique per user, ignoring case
+CREATE UNIQUE INDEX idx_folders_user_parent_name
+    ON folders (user_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), LOWER(name));
+CREATE INDEX idx_folders_parent_id ON folders(parent_id);
+
+ALTER TABLE encrypted_totp_seeds ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE encrypted_totp_seeds ADD COLUMN folder_id UUID;
+ALTER TABLE encrypted_totp_seeds ADD CONSTRAINT fk_encrypted_totp_seeds_folder_id
+    FOREIGN KEY (folder_id)
+    REFERENCES folders(id)
+    ON DELETE SET NULL;
+
+CREATE INDEX idx_encrypted_totp_seeds_tags ON encrypted_totp_seeds USING GIN (tags);
+CREATE INDEX idx_encrypted_totp_seeds_folder_id ON encrypted_totp_seeds(folder_id) WHERE folder_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_encrypted_totp_seeds_folder_id;
+DROP INDEX IF EXISTS idx_encrypted_totp_seeds_tags;
+ALTER TABLE encrypted_totp_seeds DROP CONSTRAINT IF EXISTS fk_encrypted_totp_seeds_folder_id;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS folder_id;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS tags;
+DROP TABLE IF EXISTS folders;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Fuzzy vault search and per-entry usage counts for sorting by most used.
+-- Listings are always scoped to one user, so idx_encrypted_totp_seeds_user_id narrows the rows
+-- before any trigram matching; no trigram index is needed at vault sizes.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Usage lives apart from the entry so recording a use does not bump updated_at
+CREATE TABLE totp_seed_usage (
+    seed_id UUID PRIMARY KEY,
+    use_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_totp_seed_usage_seed_id
+        FOREIGN KEY (seed_id)
+        REFERENCES encrypted_totp_seeds(id)
+        ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS totp_seed_usage;
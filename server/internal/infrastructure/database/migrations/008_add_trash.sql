@@ -0,0 +1,13 @@
+-- +goose Up
+-- Inactivated entries stay in a trash bin until restored, permanently deleted, or purged
+-- once they are older than the configured retention period.
+ALTER TABLE encrypted_totp_seeds ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Entries inactivated before the trash existed start their retention period from their last update
+UPDATE encrypted_totp_seeds SET deleted_at = updated_at WHERE is_active = FALSE;
+
+CREATE INDEX idx_encrypted_totp_seeds_deleted_at ON encrypted_totp_seeds(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_encrypted_totp_seeds_deleted_at;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,59 @@
+-- +goose Up
+-- Append-only history of vault entries. Before an entry is changed, its previous ciphertext and
+-- metadata are copied here together with the device and request that made the change.
+
+CREATE TABLE totp_seed_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    seed_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    account_identifier VARCHAR(255) NOT NULL,
+    encrypted_secret BYTEA NOT NULL,
+    algorithm VARCHAR(10) NOT NULL,
+    digits INTEGER NOT NULL,
+    period INTEGER NOT NULL,
+    issuer VARCHAR(255),
+    icon_url VARCHAR(500),
+    method VARCHAR(10) NOT NULL,
+    counter BIGINT NOT NULL,
+    otp_type VARCHAR(20) NOT NULL,
+    t0 BIGINT NOT NULL,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    folder_id UUID, -- Not a foreign key: the folder may be deleted later
+    device_id VARCHAR(255),
+    ip_address INET,
+    user_agent TEXT,
+    request_id TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_totp_seed_revisions_seed_id
+        FOREIGN KEY (seed_id)
+        REFERENCES encrypted_totp_seeds(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_totp_seed_revisions_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_totp_seed_revisions_seed_id ON totp_seed_revisions(seed_id, created_at DESC);
+
+-- Revisions are never edited; rows only go away when their entry is purged
+-- +goose StatementBegin
+CREATE FUNCTION reject_totp_seed_revision_update() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'totp_seed_revisions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_totp_seed_revisions_append_only
+    BEFORE UPDATE ON totp_seed_revisions
+    FOR EACH ROW EXECUTE FUNCTION reject_totp_seed_revision_update();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_totp_seed_revisions_append_only ON totp_seed_revisions;
+DROP FUNCTION IF EXISTS reject_totp_seed_revision_update();
+DROP INDEX IF EXISTS idx_totp_seed_revisions_seed_id;
+DROP TABLE IF EXISTS totp_seed_revisions;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Per-entry revision counter for optimistic concurrency. Every change to an entry increments it;
+-- clients send the revision they last saw (as an ETag in If-Match) and stale writes are rejected.
+ALTER TABLE encrypted_totp_seeds ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+
+-- The revision each history snapshot had; 0 for snapshots taken before the counter existed
+ALTER TABLE totp_seed_revisions ADD COLUMN revision BIGINT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS revision;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS revision;
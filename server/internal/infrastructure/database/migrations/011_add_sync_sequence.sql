@@ -0,0 +1,47 @@
+-- +goose Up
+-- Delta sync. Every change to a vault entry is logged in sync_operations under a per-user sequence
+-- number; devices remember the last number they saw and ask for everything after it.
+
+-- The last sequence number handed out per user. Writers bump it under a row lock as the last step
+-- of their transaction, so a user's operations commit in sequence order and a reader that sees
+-- last_seq = N also sees every operation up to N.
+CREATE TABLE sync_sequences (
+    user_id UUID PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+
+    CONSTRAINT fk_sync_sequences_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+ALTER TABLE sync_operations ADD COLUMN seq BIGINT;
+
+UPDATE sync_operations so
+SET seq = numbered.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY timestamp, id) AS seq
+    FROM sync_operations
+) numbered
+WHERE so.id = numbered.id;
+
+INSERT INTO sync_sequences (user_id, last_seq)
+SELECT user_id, MAX(seq) FROM sync_operations GROUP BY user_id;
+
+ALTER TABLE sync_operations ALTER COLUMN seq SET NOT NULL;
+CREATE UNIQUE INDEX idx_sync_operations_user_seq ON sync_operations(user_id, seq);
+
+-- One session per device; CreateDeviceSession upserts on this pair
+DELETE FROM device_sessions ds
+USING device_sessions newer
+WHERE ds.user_id = newer.user_id
+    AND ds.device_fingerprint = newer.device_fingerprint
+    AND (COALESCE(ds.last_sync_at, '-infinity'), ds.id) < (COALESCE(newer.last_sync_at, '-infinity'), newer.id);
+
+CREATE UNIQUE INDEX idx_device_sessions_user_fingerprint ON device_sessions(user_id, device_fingerprint);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_device_sessions_user_fingerprint;
+DROP INDEX IF EXISTS idx_sync_operations_user_seq;
+ALTER TABLE sync_operations DROP COLUMN IF EXISTS seq;
+DROP TABLE IF EXISTS sync_sequences;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Conflicts between offline edits. A device pushes each change with the revision it was made
+-- against; when the entry has moved past that revision, both versions are kept here instead of
+-- one overwriting the other. Versions hold ciphertext only, so resolving never needs plaintext.
+
+CREATE TABLE sync_conflicts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    seed_id UUID NOT NULL,
+    device_id VARCHAR(255),
+    base_revision BIGINT NOT NULL,
+    server_version JSONB NOT NULL,
+    client_version JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    resolution VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_sync_conflicts_seed_id
+        FOREIGN KEY (seed_id)
+        REFERENCES encrypted_totp_seeds(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_sync_conflicts_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_sync_conflicts_user_open ON sync_conflicts(user_id, created_at) WHERE status = 'open';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sync_conflicts_user_open;
+DROP TABLE IF EXISTS sync_conflicts;
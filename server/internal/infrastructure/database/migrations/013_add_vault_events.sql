@@ -0,0 +1,40 @@
+-- +goose Up
+-- Real-time change notifications. Entry changes are already logged in sync_operations; events that
+-- are not entry changes (a passkey added, a session revoked) are logged here under the same per-user
+-- sequence, so one position resumes a device's event stream across both.
+
+CREATE TABLE vault_events (
+    user_id UUID NOT NULL,
+    seq BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    entity_id UUID,
+    device_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, seq),
+    CONSTRAINT fk_vault_events_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- Every write that logs an operation or event bumps the user's sequence, so a notification here
+-- reaches every server replica listening on the channel. Postgres delivers it on commit and folds
+-- duplicates within a transaction, so a batch of changes wakes listeners once.
+-- +goose StatementBegin
+CREATE FUNCTION notify_vault_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('vault_events', NEW.user_id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_sync_sequences_notify
+    AFTER INSERT OR UPDATE OF last_seq ON sync_sequences
+    FOR EACH ROW EXECUTE FUNCTION notify_vault_event();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS trg_sync_sequences_notify ON sync_sequences;
+DROP FUNCTION IF EXISTS notify_vault_event();
+DROP TABLE IF EXISTS vault_events;
@@ -0,0 +1,62 @@
+-- +goose Up
+-- Vault key rotation. Every entry records the key version its secret is encrypted with. A device
+-- rotates the vault key by re-encrypting every entry still on the old version; the new ciphertexts
+-- are staged until all of them are in and then swapped in together.
+
+ALTER TABLE encrypted_totp_seeds ADD COLUMN key_version INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX idx_encrypted_totp_seeds_user_key_version ON encrypted_totp_seeds(user_id, key_version);
+
+-- Reverting an entry brings back the key version its old ciphertext was encrypted with
+ALTER TABLE totp_seed_revisions ADD COLUMN key_version INTEGER NOT NULL DEFAULT 1;
+
+-- Wraps of the new version are stored inactive when a rotation starts and activated on commit
+ALTER TABLE user_encryption_keys ADD COLUMN salt BYTEA;
+ALTER TABLE user_encryption_keys ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE key_rotations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    from_version INTEGER NOT NULL,
+    to_version INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT fk_key_rotations_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+-- At most one rotation in progress per user
+CREATE UNIQUE INDEX idx_key_rotations_user_in_progress ON key_rotations(user_id) WHERE status = 'in_progress';
+
+-- Re-encrypted secrets waiting for the commit, each tied to the entry revision it was made from
+CREATE TABLE key_rotation_entries (
+    rotation_id UUID NOT NULL,
+    seed_id UUID NOT NULL,
+    seed_revision BIGINT NOT NULL,
+    encrypted_secret BYTEA NOT NULL,
+    staged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (rotation_id, seed_id),
+    CONSTRAINT fk_key_rotation_entries_rotation_id
+        FOREIGN KEY (rotation_id)
+        REFERENCES key_rotations(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_key_rotation_entries_seed_id
+        FOREIGN KEY (seed_id)
+        REFERENCES encrypted_totp_seeds(id)
+        ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS key_rotation_entries;
+DROP INDEX IF EXISTS idx_key_rotations_user_in_progress;
+DROP TABLE IF EXISTS key_rotations;
+ALTER TABLE user_encryption_keys DROP COLUMN IF EXISTS is_active;
+ALTER TABLE user_encryption_keys DROP COLUMN IF EXISTS salt;
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS key_version;
+DROP INDEX IF EXISTS idx_encrypted_totp_seeds_user_key_version;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS key_version;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Envelope encryption: the vault is encrypted with a random DEK and every passkey holds its own wrap
+-- of it, so any of the user's passkeys can unlock the vault. A credential holds at most one wrap per
+-- key version.
+CREATE UNIQUE INDEX idx_user_encryption_keys_credential_version ON user_encryption_keys(webauthn_credential_id, key_version);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_encryption_keys_credential_version;
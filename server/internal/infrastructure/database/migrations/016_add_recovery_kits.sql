@@ -0,0 +1,30 @@
+-- +goose Up
+-- Recovery kits. A device wraps the vault key with a key derived from a recovery secret the user
+-- keeps offline; the server stores the wrap and an Argon2id hash of the code derived from the same
+-- secret, so the vault can be unlocked again after every passkey is lost.
+ALTER TABLE backup_recovery_codes ADD COLUMN key_version INTEGER NOT NULL DEFAULT 1;
+
+-- Redemption attempts: failures are counted per kit and lock it for a while once they reach the limit
+ALTER TABLE backup_recovery_codes ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE backup_recovery_codes ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
+
+-- At most one usable kit per user; retire all but the newest before enforcing it
+UPDATE backup_recovery_codes c
+SET is_used = TRUE, used_at = NOW()
+WHERE COALESCE(c.is_used, FALSE) = FALSE
+    AND EXISTS (
+        SELECT 1 FROM backup_recovery_codes n
+        WHERE n.user_id = c.user_id
+            AND COALESCE(n.is_used, FALSE) = FALSE
+            AND (n.created_at, n.id) > (c.created_at, c.id)
+    );
+UPDATE backup_recovery_codes SET is_used = FALSE WHERE is_used IS NULL;
+ALTER TABLE backup_recovery_codes ALTER COLUMN is_used SET NOT NULL;
+CREATE UNIQUE INDEX idx_backup_recovery_codes_user_active ON backup_recovery_codes(user_id) WHERE is_used = FALSE;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_backup_recovery_codes_user_active;
+ALTER TABLE backup_recovery_codes ALTER COLUMN is_used DROP NOT NULL;
+ALTER TABLE backup_recovery_codes DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE backup_recovery_codes DROP COLUMN IF EXISTS failed_attempts;
+ALTER TABLE backup_recovery_codes DROP COLUMN IF EXISTS key_version;
@@ -0,0 +1,100 @@
+-- +goose Up
+-- Secret envelopes. Clients send encrypted secrets as "v1.<suite>.<keyVersion>.<iv>.<authTag>.<ciphertext>";
+-- the parts are stored in their own columns, with encrypted_secret holding only the ciphertext and
+-- key_version the key version. secret_format 0 marks a secret stored before envelopes that could not
+-- be migrated; it is kept and returned exactly as it was sent.
+ALTER TABLE encrypted_totp_seeds ADD COLUMN secret_format SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE encrypted_totp_seeds ADD COLUMN cipher_suite SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE encrypted_totp_seeds ADD COLUMN secret_iv BYTEA;
+ALTER TABLE encrypted_totp_seeds ADD COLUMN secret_tag BYTEA;
+
+ALTER TABLE totp_seed_revisions ADD COLUMN secret_format SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE totp_seed_revisions ADD COLUMN cipher_suite SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE totp_seed_revisions ADD COLUMN secret_iv BYTEA;
+ALTER TABLE totp_seed_revisions ADD COLUMN secret_tag BYTEA;
+
+ALTER TABLE key_rotation_entries ADD COLUMN secret_format SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE key_rotation_entries ADD COLUMN cipher_suite SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE key_rotation_entries ADD COLUMN secret_iv BYTEA;
+ALTER TABLE key_rotation_entries ADD COLUMN secret_tag BYTEA;
+
+-- Split the old "ciphertext.iv.authTag" strings: AES-256-GCM with a 12-byte IV and a 16-byte tag
+UPDATE encrypted_totp_seeds
+SET secret_format = 1,
+    cipher_suite = 1,
+    secret_iv = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 2), 'base64'),
+    secret_tag = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 3), 'base64'),
+    encrypted_secret = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 1), 'base64')
+WHERE ENCODE(encrypted_secret, 'escape') ~ '^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{4}|[A-Za-z0-9+/]{3}=|[A-Za-z0-9+/]{2}==)\.[A-Za-z0-9+/]{16}\.[A-Za-z0-9+/]{22}==$';
+
+UPDATE totp_seed_revisions
+SET secret_format = 1,
+    cipher_suite = 1,
+    secret_iv = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 2), 'base64'),
+    secret_tag = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 3), 'base64'),
+    encrypted_secret = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 1), 'base64')
+WHERE ENCODE(encrypted_secret, 'escape') ~ '^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{4}|[A-Za-z0-9+/]{3}=|[A-Za-z0-9+/]{2}==)\.[A-Za-z0-9+/]{16}\.[A-Za-z0-9+/]{22}==$';
+
+-- Staged rotation entries were checked when staged, so they always split
+UPDATE key_rotation_entries
+SET secret_format = 1,
+    cipher_suite = 1,
+    secret_iv = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 2), 'base64'),
+    secret_tag = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 3), 'base64'),
+    encrypted_secret = DECODE(SPLIT_PART(CONVERT_FROM(encrypted_secret, 'UTF8'), '.', 1), 'base64')
+WHERE ENCODE(encrypted_secret, 'escape') ~ '^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{4}|[A-Za-z0-9+/]{3}=|[A-Za-z0-9+/]{2}==)\.[A-Za-z0-9+/]{16}\.[A-Za-z0-9+/]{22}==$';
+DELETE FROM key_rotation_entries WHERE secret_format = 0;
+
+-- Every new secret is an envelope; only migrated leftovers may lack its parts
+ALTER TABLE encrypted_totp_seeds ALTER COLUMN secret_format DROP DEFAULT;
+ALTER TABLE encrypted_totp_seeds ALTER COLUMN cipher_suite DROP DEFAULT;
+ALTER TABLE encrypted_totp_seeds ADD CONSTRAINT chk_encrypted_totp_seeds_secret_envelope CHECK (
+    secret_format = 0
+    OR (cipher_suite > 0 AND secret_iv IS NOT NULL AND secret_tag IS NOT NULL AND LENGTH(encrypted_secret) > 0)
+);
+
+ALTER TABLE totp_seed_revisions ALTER COLUMN secret_format DROP DEFAULT;
+ALTER TABLE totp_seed_revisions ALTER COLUMN cipher_suite DROP DEFAULT;
+
+ALTER TABLE key_rotation_entries ALTER COLUMN secret_format DROP DEFAULT;
+ALTER TABLE key_rotation_entries ALTER COLUMN cipher_suite DROP DEFAULT;
+ALTER TABLE key_rotation_entries ADD CONSTRAINT chk_key_rotation_entries_secret_envelope CHECK (
+    secret_format > 0 AND cipher_suite > 0 AND secret_iv IS NOT NULL AND secret_tag IS NOT NULL AND LENGTH(encrypted_secret) > 0
+);
+
+-- +goose Down
+-- Join envelopes back into "ciphertext.iv.authTag" strings; ENCODE wraps base64 every 76 characters
+UPDATE key_rotation_entries
+SET encrypted_secret = CONVERT_TO(
+    REPLACE(ENCODE(encrypted_secret, 'base64'), E'\n', '') || '.' || ENCODE(secret_iv, 'base64') || '.' || ENCODE(secret_tag, 'base64'),
+    'UTF8')
+WHERE secret_format > 0;
+
+UPDATE totp_seed_revisions
+SET encrypted_secret = CONVERT_TO(
+    REPLACE(ENCODE(encrypted_secret, 'base64'), E'\n', '') || '.' || ENCODE(secret_iv, 'base64') || '.' || ENCODE(secret_tag, 'base64'),
+    'UTF8')
+WHERE secret_format > 0;
+
+UPDATE encrypted_totp_seeds
+SET encrypted_secret = CONVERT_TO(
+    REPLACE(ENCODE(encrypted_secret, 'base64'), E'\n', '') || '.' || ENCODE(secret_iv, 'base64') || '.' || ENCODE(secret_tag, 'base64'),
+    'UTF8')
+WHERE secret_format > 0;
+
+ALTER TABLE key_rotation_entries DROP CONSTRAINT IF EXISTS chk_key_rotation_entries_secret_envelope;
+ALTER TABLE key_rotation_entries DROP COLUMN IF EXISTS secret_tag;
+ALTER TABLE key_rotation_entries DROP COLUMN IF EXISTS secret_iv;
+ALTER TABLE key_rotation_entries DROP COLUMN IF EXISTS cipher_suite;
+ALTER TABLE key_rotation_entries DROP COLUMN IF EXISTS secret_format;
+
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS secret_tag;
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS secret_iv;
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS cipher_suite;
+ALTER TABLE totp_seed_revisions DROP COLUMN IF EXISTS secret_format;
+
+ALTER TABLE encrypted_totp_seeds DROP CONSTRAINT IF EXISTS chk_encrypted_totp_seeds_secret_envelope;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS secret_tag;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS secret_iv;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS cipher_suite;
+ALTER TABLE encrypted_totp_seeds DROP COLUMN IF EXISTS secret_format;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Tamper-evident audit log. Entries form one hash chain in sequence order: each entry's hash covers
+-- its content and the previous entry's hash, so editing, reordering or removing an entry breaks the
+-- chain from that entry on. Rows written before this migration have no sequence and stay outside it.
+ALTER TABLE audit_logs ADD COLUMN sequence BIGINT;
+ALTER TABLE audit_logs ADD COLUMN request_id VARCHAR(128);
+ALTER TABLE audit_logs ADD COLUMN prev_hash BYTEA;
+ALTER TABLE audit_logs ADD COLUMN hash BYTEA;
+
+CREATE UNIQUE INDEX idx_audit_logs_sequence ON audit_logs(sequence);
+CREATE INDEX idx_audit_logs_user_sequence ON audit_logs(user_id, sequence);
+
+-- Entries outlive the users they name: clearing user_id when a user is deleted would break the chain
+ALTER TABLE audit_logs DROP CONSTRAINT fk_audit_logs_user_id;
+
+-- +goose Down
+UPDATE audit_logs SET user_id = NULL WHERE user_id IS NOT NULL AND user_id NOT IN (SELECT id FROM users);
+ALTER TABLE audit_logs ADD CONSTRAINT fk_audit_logs_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+DROP INDEX IF EXISTS idx_audit_logs_user_sequence;
+DROP INDEX IF EXISTS idx_audit_logs_sequence;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS request_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS sequence;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- User roles and token revocation. Admins manage other accounts through the admin API; the first
+-- admin is granted with the admin command. Every issued token carries the user's token version,
+-- and bumping it (suspension, role change or forced logout) invalidates all of them at once.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD CONSTRAINT chk_users_role CHECK (role IN ('user', 'admin'));
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+-- The admin user listing pages newest first
+CREATE INDEX idx_users_created_at ON users(created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_created_at;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
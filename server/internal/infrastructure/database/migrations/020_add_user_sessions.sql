@@ -0,0 +1,26 @@
+-- +goose Up
+-- Sign-in sessions. Every token names the session it was issued for, and a request is only
+-- authenticated while that session exists and has timed out neither for being idle nor for its
+-- age. Revoking a session deletes it. (device_sessions tracks sync devices, not sign-ins.)
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    device_id VARCHAR(255),
+    device_name VARCHAR(255) NOT NULL,
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_activity_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    CONSTRAINT fk_user_sessions_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_sessions_user_activity ON user_sessions(user_id, last_activity_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_sessions_user_activity;
+DROP TABLE IF EXISTS user_sessions;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Refresh tokens. Only a hash of each opaque token is stored. A refresh rotates the token: the
+-- presented one is marked used and a new one issued for the same session, so the tokens of a
+-- session form one family. Presenting a used token again revokes the session, which deletes the
+-- whole family with it.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    session_id UUID NOT NULL,
+    token_hash BYTEA NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_refresh_tokens_user_id
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_refresh_tokens_session_id
+        FOREIGN KEY (session_id)
+        REFERENCES user_sessions(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_refresh_tokens_session_id;
+DROP TABLE IF EXISTS refresh_tokens;
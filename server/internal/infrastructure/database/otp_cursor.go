@@ -0,0 +1,44 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// otpCursor is the keyset position after the last entry of a page. Clients treat the encoded
+// form as opaque; it records the sort order so it cannot be replayed against another one.
+type otpCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	Text       string    `json:"t,omitempty"`
+	Num        int64     `json:"n,omitempty"`
+	ID         uuid.UUID `json:"i"`
+}
+
+// encodeOTPCursor serializes a cursor as URL-safe base64 JSON
+func encodeOTPCursor(cursor otpCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeOTPCursor parses a cursor produced by encodeOTPCursor
+func decodeOTPCursor(encoded string) (otpCursor, error) {
+	var cursor otpCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if cursor.ID == uuid.Nil {
+		return cursor, fmt.Errorf("%w: missing position", entities.ErrInvalidCursor)
+	}
+
+	return cursor, nil
+}
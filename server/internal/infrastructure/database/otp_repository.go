@@ -1,14 +1,19 @@
 package database
 
 import (
+	"bytes"
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"net/netip"
+	"slices"
+	"time"
 
 	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -28,30 +33,55 @@ func NewOTPRepository(database *DB, cryptoService interfaces.CryptoService) inte
 }
 
 // Create creates a new encrypted OTP entry
-func (r *otpRepository) Create(ctx context.Context, otp *entities.OTP, encryptedData []byte, keyVersion int) error {
-	// Use the pre-encrypted data directly (client-side encrypted: ciphertext.iv.authTag)
+func (r *otpRepository) Create(ctx context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope) error {
+	// The client-encrypted secret is stored as its envelope's parts
+	secret := toSecretColumns(envelope)
 	params := db.CreateEncryptedTOTPSeedParams{
 		UserID:            pgtype.UUID{Bytes: otp.UserID, Valid: true},
-		ServiceName:       otp.Issuer,    // Map Issuer to ServiceName
-		AccountIdentifier: otp.Label,     // Map Label to AccountIdentifier
-		EncryptedSecret:   encryptedData, // Store the client-encrypted data directly
+		ServiceName:       otp.Issuer, // Map Issuer to ServiceName
+		AccountIdentifier: otp.Label,  // Map Label to AccountIdentifier
+		EncryptedSecret:   secret.Ciphertext,
 		Algorithm:         otp.Algorithm,
 		Digits:            int32(otp.Digits),
 		Period:            int32(otp.Period),
 		Issuer:            pgtype.Text{String: otp.Issuer, Valid: true},
-		IconUrl:           pgtype.Text{},
+		IconUrl:           pgtype.Text{String: otp.IconURL, Valid: otp.IconURL != ""},
 		IsActive:          pgtype.Bool{Bool: true, Valid: true},
+		Method:            otp.Method,
+		Counter:           otp.Counter,
+		OtpType:           otp.Type,
+		T0:                otp.T0,
+		Tags:              nonNilTags(otp.Tags),
+		FolderID:          convertOptionalUUIDToPG(otp.FolderID),
+		KeyVersion:        int32(envelope.KeyVersion),
+		SecretFormat:      secret.Format,
+		CipherSuite:       secret.Suite,
+		SecretIv:          secret.IV,
+		SecretTag:         secret.Tag,
 	}
 
-	seed, err := r.queries.CreateEncryptedTOTPSeed(ctx, params)
+	var seed db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		var err error
+		seed, err = queries.CreateEncryptedTOTPSeed(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create encrypted TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, otp.UserID, entities.SyncOperationCreate, convertPGUUID(seed.ID))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create encrypted TOTP seed: %w", err)
+		return err
 	}
 
-	// Update the OTP entity with the generated ID and timestamps
+	// Update the OTP entity with the generated ID, timestamps and revision
 	otp.ID = uuid.UUID(seed.ID.Bytes)
 	otp.CreatedAt = seed.CreatedAt.Time
 	otp.UpdatedAt = seed.UpdatedAt.Time
+	otp.Revision = seed.Revision
+	otp.KeyVersion = int(seed.KeyVersion)
 
 	return nil
 }
@@ -65,13 +95,13 @@ func (r *otpRepository) GetByID(ctx context.Context, id uuid.UUID, userID uuid.U
 
 	seed, err := r.queries.GetEncryptedTOTPSeedByID(ctx, params)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, entities.ErrTOTPSeedNotFound
 		}
 		return nil, fmt.Errorf("failed to get encrypted TOTP seed: %w", err)
 	}
 
-	return r.convertToOTP(seed)
+	return convertToOTP(seed)
 }
 
 // GetByUserID retrieves all decrypted OTPs for a user
@@ -83,7 +113,7 @@ func (r *otpRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*e
 
 	otps := make([]*entities.OTP, 0, len(seeds))
 	for _, seed := range seeds {
-		otp, err := r.convertToOTP(seed)
+		otp, err := convertToOTP(seed)
 		if err != nil {
 			// Log error but continue with other OTPs
 			continue
@@ -94,50 +124,795 @@ func (r *otpRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*e
 	return otps, nil
 }
 
-// Update updates an existing encrypted OTP entry
-func (r *otpRepository) Update(ctx context.Context, otp *entities.OTP, encryptedData []byte, keyVersion int) error {
-	// Use the pre-encrypted data directly (client-side encrypted: ciphertext.iv.authTag)
+// List retrieves one page of a user's OTPs matching the filter. The filter must be normalized.
+func (r *otpRepository) List(ctx context.Context, userID uuid.UUID, filter entities.OTPFilter) (*entities.OTPPage, error) {
+	params := db.ListEncryptedTOTPSeedsParams{
+		FolderID:   convertOptionalUUIDToPG(filter.FolderID),
+		UserID:     pgtype.UUID{Bytes: userID, Valid: true},
+		Tag:        pgtype.Text{String: filter.Tag, Valid: filter.Tag != ""},
+		Query:      pgtype.Text{String: filter.Query, Valid: filter.Query != ""},
+		Sort:       filter.Sort,
+		Descending: filter.Descending(),
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeOTPCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != filter.Sort || cursor.Descending != filter.Descending() {
+			return nil, fmt.Errorf("%w: cursor belongs to a different sort order", entities.ErrInvalidCursor)
+		}
+		params.AfterID = pgtype.UUID{Bytes: cursor.ID, Valid: true}
+		params.AfterText = cursor.Text
+		params.AfterNum = cursor.Num
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	if filter.Limit > 0 {
+		params.PageLimit = pgtype.Int4{Int32: int32(filter.Limit + 1), Valid: true}
+	}
+
+	rows, err := r.queries.ListEncryptedTOTPSeeds(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list encrypted TOTP seeds: %w", err)
+	}
+
+	page := &entities.OTPPage{Items: make([]*entities.OTP, 0, len(rows))}
+	if filter.Limit > 0 && len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeOTPCursor(otpCursor{
+			Sort:       filter.Sort,
+			Descending: filter.Descending(),
+			Text:       last.SortText,
+			Num:        last.SortNum,
+			ID:         uuid.UUID(last.ID.Bytes),
+		})
+	}
+
+	for _, row := range rows {
+		otp, err := convertToOTP(db.EncryptedTotpSeed{
+			ID:                row.ID,
+			UserID:            row.UserID,
+			ServiceName:       row.ServiceName,
+			AccountIdentifier: row.AccountIdentifier,
+			EncryptedSecret:   row.EncryptedSecret,
+			Algorithm:         row.Algorithm,
+			Digits:            row.Digits,
+			Period:            row.Period,
+			Issuer:            row.Issuer,
+			IconUrl:           row.IconUrl,
+			IsActive:          row.IsActive,
+			CreatedAt:         row.CreatedAt,
+			UpdatedAt:         row.UpdatedAt,
+			Method:            row.Method,
+			Counter:           row.Counter,
+			OtpType:           row.OtpType,
+			T0:                row.T0,
+			Tags:              row.Tags,
+			FolderID:          row.FolderID,
+			DeletedAt:         row.DeletedAt,
+			Revision:          row.Revision,
+			KeyVersion:        row.KeyVersion,
+			SecretFormat:      row.SecretFormat,
+			CipherSuite:       row.CipherSuite,
+			SecretIv:          row.SecretIv,
+			SecretTag:         row.SecretTag,
+		})
+		if err != nil {
+			continue
+		}
+		otp.UseCount = row.UseCount
+		if row.LastUsedAt.Valid {
+			otp.LastUsedAt = &row.LastUsedAt.Time
+		}
+		page.Items = append(page.Items, otp)
+	}
+
+	page.Total, err = r.count(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// count returns the number of entries matching the filter across all pages
+func (r *otpRepository) count(ctx context.Context, userID uuid.UUID, filter entities.OTPFilter) (int64, error) {
+	var (
+		total int64
+		err   error
+	)
+	if filter.Tag == "" && filter.FolderID == nil && filter.Query == "" {
+		total, err = r.queries.GetTOTPSeedsCountByUser(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	} else {
+		total, err = r.queries.CountEncryptedTOTPSeeds(ctx, db.CountEncryptedTOTPSeedsParams{
+			FolderID: convertOptionalUUIDToPG(filter.FolderID),
+			UserID:   pgtype.UUID{Bytes: userID, Valid: true},
+			Tag:      pgtype.Text{String: filter.Tag, Valid: filter.Tag != ""},
+			Query:    pgtype.Text{String: filter.Query, Valid: filter.Query != ""},
+		})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count encrypted TOTP seeds: %w", err)
+	}
+
+	return total, nil
+}
+
+// RecordUse counts a use of an entry's code
+func (r *otpRepository) RecordUse(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	recorded, err := r.queries.RecordTOTPSeedUse(ctx, db.RecordTOTPSeedUseParams{
+		ID:     pgtype.UUID{Bytes: id, Valid: true},
+		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record TOTP seed use: %w", err)
+	}
+	if recorded == 0 {
+		return entities.ErrTOTPSeedNotFound
+	}
+
+	return nil
+}
+
+// ListTags returns every tag in use on the user's active entries with its entry count
+func (r *otpRepository) ListTags(ctx context.Context, userID uuid.UUID) ([]*entities.TagCount, error) {
+	rows, err := r.queries.ListTagCountsByUser(ctx, pgtype.UUID{Bytes: userID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	tags := make([]*entities.TagCount, 0, len(rows))
+	for _, row := range rows {
+		tags = append(tags, &entities.TagCount{Tag: row.Tag, Count: row.Count})
+	}
+
+	return tags, nil
+}
+
+// RenameTag renames a tag on all of the user's entries and returns the number of entries changed
+func (r *otpRepository) RenameTag(ctx context.Context, userID uuid.UUID, oldTag, newTag string) (int64, error) {
+	var updated []pgtype.UUID
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		var err error
+		updated, err = queries.RenameTOTPSeedTag(ctx, db.RenameTOTPSeedTagParams{
+			OldTag: oldTag,
+			NewTag: newTag,
+			UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rename tag: %w", err)
+		}
+
+		return recordSyncOperationsPG(ctx, queries, convertUUIDToPG(userID), entities.SyncOperationUpdate, updated)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(updated)), nil
+}
+
+// Update updates an existing encrypted OTP entry, recording the version it replaces as a revision
+func (r *otpRepository) Update(ctx context.Context, otp *entities.OTP, envelope *entities.SecretEnvelope, expectedRevision int64) error {
+	// The client-encrypted secret is stored as its envelope's parts
+	secret := toSecretColumns(envelope)
 	params := db.UpdateEncryptedTOTPSeedParams{
 		ID:                pgtype.UUID{Bytes: otp.ID, Valid: true},
 		UserID:            pgtype.UUID{Bytes: otp.UserID, Valid: true},
 		ServiceName:       pgtype.Text{String: otp.Issuer, Valid: true},
 		AccountIdentifier: pgtype.Text{String: otp.Label, Valid: true},
-		EncryptedSecret:   encryptedData, // Store the client-encrypted data directly
+		EncryptedSecret:   secret.Ciphertext,
 		Algorithm:         pgtype.Text{String: otp.Algorithm, Valid: true},
 		Digits:            pgtype.Int4{Int32: int32(otp.Digits), Valid: true},
 		Period:            pgtype.Int4{Int32: int32(otp.Period), Valid: true},
 		Issuer:            pgtype.Text{String: otp.Issuer, Valid: true},
-		IconUrl:           pgtype.Text{},
+		IconUrl:           pgtype.Text{String: otp.IconURL, Valid: true}, // Cleared when the issuer leaves the catalog
+		OtpType:           pgtype.Text{String: otp.Type, Valid: true},
+		T0:                pgtype.Int8{Int64: otp.T0, Valid: true},
+		Tags:              nonNilTags(otp.Tags),
+		SetFolder:         true,
+		FolderID:          convertOptionalUUIDToPG(otp.FolderID),
+		KeyVersion:        pgtype.Int4{Int32: int32(envelope.KeyVersion), Valid: true},
+		SecretFormat:      secret.formatArg(),
+		CipherSuite:       secret.suiteArg(),
+		SecretIv:          secret.IV,
+		SecretTag:         secret.Tag,
+		ExpectedRevision:  pgtype.Int8{Int64: expectedRevision, Valid: expectedRevision > 0},
 	}
 
-	seed, err := r.queries.UpdateEncryptedTOTPSeed(ctx, params)
+	var seed db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := createRevisions(ctx, queries, otp.UserID, entities.OTPRevisionUpdate, otp.ID); err != nil {
+			return err
+		}
+
+		var err error
+		seed, err = queries.UpdateEncryptedTOTPSeed(ctx, params)
+		if err != nil {
+			// The entry exists and is locked by the snapshot, so only the revision can differ
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrRevisionMismatch
+			}
+			return fmt.Errorf("failed to update encrypted TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, otp.UserID, entities.SyncOperationUpdate, otp.ID)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update encrypted TOTP seed: %w", err)
+		return err
 	}
 
-	// Update the OTP entity timestamps
+	// Update the OTP entity timestamps and revision
 	otp.UpdatedAt = seed.UpdatedAt.Time
+	otp.Revision = seed.Revision
+	otp.KeyVersion = int(seed.KeyVersion)
 
 	return nil
 }
 
-// Delete soft deletes an OTP entry (marks as inactive)
-func (r *otpRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
-	params := db.DeleteEncryptedTOTPSeedParams{
-		ID:     pgtype.UUID{Bytes: id, Valid: true},
+// AdvanceCounter atomically increments an HOTP entry's counter under a row lock and returns the new value
+func (r *otpRepository) AdvanceCounter(ctx context.Context, id uuid.UUID, userID uuid.UUID) (int64, error) {
+	var counter int64
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		// Lock the row so concurrent devices are serialized on the same entry
+		seed, err := queries.GetEncryptedTOTPSeedByIDForUpdate(ctx, db.GetEncryptedTOTPSeedByIDForUpdateParams{
+			ID:     pgtype.UUID{Bytes: id, Valid: true},
+			UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrTOTPSeedNotFound
+			}
+			return fmt.Errorf("failed to lock encrypted TOTP seed: %w", err)
+		}
+
+		if seed.Method != entities.OTPMethodHOTP {
+			return entities.ErrNotHOTP
+		}
+
+		counter, err = queries.IncrementTOTPSeedCounter(ctx, db.IncrementTOTPSeedCounterParams{
+			ID:     seed.ID,
+			UserID: seed.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to increment HOTP counter: %w", err)
+		}
+
+		// Reserving an HOTP code is a use of the entry
+		if _, err := queries.RecordTOTPSeedUse(ctx, db.RecordTOTPSeedUseParams{
+			ID:     seed.ID,
+			UserID: seed.UserID,
+		}); err != nil {
+			return fmt.Errorf("failed to record TOTP seed use: %w", err)
+		}
+
+		// Other devices need the new counter to stay in step
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationUpdate, id)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return counter, nil
+}
+
+// ApplyBatch applies create, update and inactivate operations in a single transaction.
+// Creates are streamed with COPY, updates and inactivations are pipelined as pgx batches.
+func (r *otpRepository) ApplyBatch(ctx context.Context, userID uuid.UUID, operations []*entities.OTPBatchOperation) ([]*entities.OTPBatchResult, error) {
+	results := make([]*entities.OTPBatchResult, len(operations))
+	var creates, updates, inactivates []int
+	for i, op := range operations {
+		results[i] = &entities.OTPBatchResult{Index: i, Type: op.Type, ID: op.OTP.ID, Status: entities.OTPBatchStatusOK}
+
+		switch op.Type {
+		case entities.OTPBatchCreate:
+			creates = append(creates, i)
+		case entities.OTPBatchUpdate:
+			updates = append(updates, i)
+		case entities.OTPBatchInactivate:
+			inactivates = append(inactivates, i)
+		default:
+			return nil, fmt.Errorf("unsupported batch operation %q", op.Type)
+		}
+	}
+
+	userUUID := pgtype.UUID{Bytes: userID, Valid: true}
+	failed := false
+
+	// fail records an item failure. Once the transaction is aborted, later errors are
+	// consequences of the first one, so those items are reported as skipped instead.
+	fail := func(index int, err error) {
+		if failed {
+			results[index].Status = entities.OTPBatchStatusSkipped
+			return
+		}
+		results[index].Fail(err)
+		failed = true
+	}
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if len(creates) > 0 {
+			rows := make([]db.CreateEncryptedTOTPSeedsParams, 0, len(creates))
+			for _, i := range creates {
+				otp := operations[i].OTP
+				secret := toSecretColumns(operations[i].Envelope)
+				rows = append(rows, db.CreateEncryptedTOTPSeedsParams{
+					ID:                pgtype.UUID{Bytes: otp.ID, Valid: true},
+					UserID:            userUUID,
+					ServiceName:       otp.Issuer,
+					AccountIdentifier: otp.Label,
+					EncryptedSecret:   secret.Ciphertext,
+					Algorithm:         otp.Algorithm,
+					Digits:            int32(otp.Digits),
+					Period:            int32(otp.Period),
+					Issuer:            pgtype.Text{String: otp.Issuer, Valid: true},
+					IconUrl:           pgtype.Text{String: otp.IconURL, Valid: otp.IconURL != ""},
+					IsActive:          pgtype.Bool{Bool: true, Valid: true},
+					Method:            otp.Method,
+					Counter:           otp.Counter,
+					OtpType:           otp.Type,
+					T0:                otp.T0,
+					Tags:              nonNilTags(otp.Tags),
+					FolderID:          convertOptionalUUIDToPG(otp.FolderID),
+					KeyVersion:        int32(otp.KeyVersion),
+					SecretFormat:      secret.Format,
+					CipherSuite:       secret.Suite,
+					SecretIv:          secret.IV,
+					SecretTag:         secret.Tag,
+				})
+			}
+
+			// COPY is all-or-nothing, so a failure cannot be attributed to a single row
+			if _, err := queries.CreateEncryptedTOTPSeeds(ctx, rows); err != nil {
+				for _, i := range creates {
+					results[i].Fail(fmt.Errorf("failed to create encrypted TOTP seed: %w", err))
+				}
+				return entities.ErrOTPBatchFailed
+			}
+
+			for _, i := range creates {
+				results[i].OTP = operations[i].OTP
+			}
+		}
+
+		if len(updates) > 0 {
+			ids := make([]uuid.UUID, 0, len(updates))
+			for _, i := range updates {
+				ids = append(ids, operations[i].OTP.ID)
+			}
+			// Entries that are not found are reported by the update itself
+			if err := createRevisions(ctx, queries, userID, entities.OTPRevisionUpdate, ids...); err != nil && !errors.Is(err, entities.ErrTOTPSeedNotFound) {
+				return err
+			}
+		}
+
+		// A conditional update or inactivation that matches no row either names a missing entry
+		// or one that has moved past the operation's revision; the active entries tell them apart
+		var conditional []uuid.UUID
+		for _, op := range operations {
+			if op.Type != entities.OTPBatchCreate && expectedRevisionArg(op.Revision).Valid {
+				conditional = append(conditional, op.OTP.ID)
+			}
+		}
+		active, err := activeEntries(ctx, queries, userID, conditional)
+		if err != nil {
+			return err
+		}
+		missing := func(index int) error {
+			if active[operations[index].OTP.ID] {
+				return entities.ErrRevisionMismatch
+			}
+			return entities.ErrTOTPSeedNotFound
+		}
+
+		if len(updates) > 0 {
+
+			params := make([]db.UpdateEncryptedTOTPSeedsBatchParams, 0, len(updates))
+			for _, i := range updates {
+				otp := operations[i].OTP
+				secret := toSecretColumns(operations[i].Envelope)
+				params = append(params, db.UpdateEncryptedTOTPSeedsBatchParams{
+					ID:                pgtype.UUID{Bytes: otp.ID, Valid: true},
+					UserID:            userUUID,
+					ServiceName:       pgtype.Text{String: otp.Issuer, Valid: true},
+					AccountIdentifier: pgtype.Text{String: otp.Label, Valid: true},
+					EncryptedSecret:   secret.Ciphertext,
+					Algorithm:         pgtype.Text{String: otp.Algorithm, Valid: true},
+					Digits:            pgtype.Int4{Int32: int32(otp.Digits), Valid: true},
+					Period:            pgtype.Int4{Int32: int32(otp.Period), Valid: true},
+					Issuer:            pgtype.Text{String: otp.Issuer, Valid: true},
+					IconUrl:           pgtype.Text{String: otp.IconURL, Valid: true},
+					OtpType:           pgtype.Text{String: otp.Type, Valid: true},
+					T0:                pgtype.Int8{Int64: otp.T0, Valid: true},
+					Tags:              otp.Tags, // nil keeps the current tags
+					SetFolder:         operations[i].FolderID != nil,
+					FolderID:          convertOptionalUUIDToPG(otp.FolderID),
+					KeyVersion:        pgtype.Int4{Int32: int32(otp.KeyVersion), Valid: true},
+					SecretFormat:      secret.formatArg(),
+					CipherSuite:       secret.suiteArg(),
+					SecretIv:          secret.IV,
+					SecretTag:         secret.Tag,
+					ExpectedRevision:  expectedRevisionArg(operations[i].Revision),
+				})
+			}
+
+			queries.UpdateEncryptedTOTPSeedsBatch(ctx, params).QueryRow(func(t int, seed db.EncryptedTotpSeed, err error) {
+				index := updates[t]
+				if err != nil {
+					if errors.Is(err, pgx.ErrNoRows) {
+						err = missing(index)
+					}
+					fail(index, err)
+					return
+				}
+
+				otp, err := convertToOTP(seed)
+				if err != nil {
+					fail(index, err)
+					return
+				}
+				results[index].OTP = otp
+			})
+		}
+
+		if len(inactivates) > 0 && !failed {
+			params := make([]db.DeleteEncryptedTOTPSeedsBatchParams, 0, len(inactivates))
+			for _, i := range inactivates {
+				params = append(params, db.DeleteEncryptedTOTPSeedsBatchParams{
+					ID:               pgtype.UUID{Bytes: operations[i].OTP.ID, Valid: true},
+					UserID:           userUUID,
+					ExpectedRevision: expectedRevisionArg(operations[i].Revision),
+				})
+			}
+
+			queries.DeleteEncryptedTOTPSeedsBatch(ctx, params).QueryRow(func(t int, _ pgtype.UUID, err error) {
+				if err != nil {
+					if errors.Is(err, pgx.ErrNoRows) {
+						err = missing(inactivates[t])
+					}
+					fail(inactivates[t], err)
+				}
+			})
+		}
+
+		if failed {
+			return entities.ErrOTPBatchFailed
+		}
+
+		for _, group := range []struct {
+			operation string
+			indexes   []int
+		}{
+			{entities.SyncOperationCreate, creates},
+			{entities.SyncOperationUpdate, updates},
+			{entities.SyncOperationDelete, inactivates},
+		} {
+			ids := make([]uuid.UUID, 0, len(group.indexes))
+			for _, i := range group.indexes {
+				ids = append(ids, operations[i].OTP.ID)
+			}
+			if err := recordSyncOperations(ctx, queries, userID, group.operation, ids...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, entities.ErrOTPBatchFailed) {
+			return nil, fmt.Errorf("failed to apply OTP batch: %w", err)
+		}
+
+		// Nothing was persisted; everything that did not fail itself was rolled back
+		for _, result := range results {
+			if result.Status == entities.OTPBatchStatusOK {
+				result.Status = entities.OTPBatchStatusSkipped
+				result.OTP = nil
+			}
+		}
+		return results, err
+	}
+
+	return results, nil
+}
+
+// activeEntries returns which of ids are active entries of the user
+func activeEntries(ctx context.Context, queries *db.Queries, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	active := make(map[uuid.UUID]bool, len(ids))
+	if len(ids) == 0 {
+		return active, nil
+	}
+
+	pgIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		pgIDs = append(pgIDs, pgtype.UUID{Bytes: id, Valid: true})
+	}
+	seeds, err := queries.GetEncryptedTOTPSeedsByIDs(ctx, db.GetEncryptedTOTPSeedsByIDsParams{
 		UserID: pgtype.UUID{Bytes: userID, Valid: true},
+		Ids:    pgIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+	}
+	for _, seed := range seeds {
+		if seed.IsActive.Bool {
+			active[uuid.UUID(seed.ID.Bytes)] = true
+		}
+	}
+
+	return active, nil
+}
+
+// expectedRevisionArg converts a batch operation's base revision to a query argument; NULL skips
+// the check
+func expectedRevisionArg(revision *int64) pgtype.Int8 {
+	if revision == nil || *revision <= 0 {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *revision, Valid: true}
+}
+
+// Delete soft deletes an OTP entry (marks as inactive), moving it to the trash
+func (r *otpRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID, expectedRevision int64) error {
+	params := db.DeleteEncryptedTOTPSeedParams{
+		ID:               pgtype.UUID{Bytes: id, Valid: true},
+		UserID:           pgtype.UUID{Bytes: userID, Valid: true},
+		ExpectedRevision: pgtype.Int8{Int64: expectedRevision, Valid: expectedRevision > 0},
+	}
+
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		var err error
+		deleted, err = queries.DeleteEncryptedTOTPSeed(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to delete encrypted TOTP seed: %w", err)
+		}
+		if deleted == 0 {
+			return nil
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationDelete, id)
+	})
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		return nil
+	}
+
+	// Nothing matched: tell a missing entry apart from a stale revision
+	if _, err := r.GetByID(ctx, id, userID); err != nil {
+		return err
+	}
+	return entities.ErrRevisionMismatch
+}
+
+// ListRevisions retrieves an entry's past versions, newest first
+func (r *otpRepository) ListRevisions(ctx context.Context, id uuid.UUID, userID uuid.UUID) ([]*entities.OTPRevision, error) {
+	rows, err := r.queries.ListTOTPSeedRevisions(ctx, db.ListTOTPSeedRevisionsParams{
+		SeedID: convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TOTP seed revisions: %w", err)
+	}
+
+	revisions := make([]*entities.OTPRevision, 0, len(rows))
+	for _, row := range rows {
+		revisions = append(revisions, convertToOTPRevision(row))
+	}
+
+	return revisions, nil
+}
+
+// Revert restores an entry to one of its revisions, recording the current version as a revision first
+func (r *otpRepository) Revert(ctx context.Context, id uuid.UUID, userID uuid.UUID, revisionID uuid.UUID, keyVersion int) (*entities.OTP, error) {
+	var seed db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := createRevisions(ctx, queries, userID, entities.OTPRevisionRevert, id); err != nil {
+			return err
+		}
+
+		// Revisions are not re-encrypted when the key rotates, so older ones are unreadable
+		revision, err := queries.GetTOTPSeedRevision(ctx, db.GetTOTPSeedRevisionParams{
+			ID:     convertUUIDToPG(revisionID),
+			SeedID: convertUUIDToPG(id),
+			UserID: convertUUIDToPG(userID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrRevisionNotFound
+			}
+			return fmt.Errorf("failed to get TOTP seed revision: %w", err)
+		}
+		if int(revision.KeyVersion) != keyVersion {
+			return fmt.Errorf("%w: revision is encrypted with key version %d, current is %d",
+				entities.ErrRevisionKeyRetired, revision.KeyVersion, keyVersion)
+		}
+
+		seed, err = queries.RevertTOTPSeedToRevision(ctx, db.RevertTOTPSeedToRevisionParams{
+			RevisionID: convertUUIDToPG(revisionID),
+			ID:         convertUUIDToPG(id),
+			UserID:     convertUUIDToPG(userID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrRevisionNotFound
+			}
+			return fmt.Errorf("failed to revert TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationUpdate, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToOTP(seed)
+}
+
+// createRevisions records the current version of active entries, attributed to the device and
+// request in ctx, and locks them until the transaction ends. Returns entities.ErrTOTPSeedNotFound
+// if none of the entries is active.
+func createRevisions(ctx context.Context, queries *db.Queries, userID uuid.UUID, reason string, ids ...uuid.UUID) error {
+	info := entities.RequestInfoFromContext(ctx)
+
+	seedIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		seedIDs = append(seedIDs, convertUUIDToPG(id))
+	}
+
+	params := db.CreateTOTPSeedRevisionsParams{
+		Reason:    reason,
+		DeviceID:  pgtype.Text{String: info.DeviceID, Valid: info.DeviceID != ""},
+		UserAgent: pgtype.Text{String: info.UserAgent, Valid: info.UserAgent != ""},
+		RequestID: pgtype.Text{String: info.RequestID, Valid: info.RequestID != ""},
+		UserID:    convertUUIDToPG(userID),
+		SeedIds:   seedIDs,
+	}
+	if addr, err := netip.ParseAddr(info.IPAddress); err == nil {
+		params.IpAddress = &addr
 	}
 
-	err := r.queries.DeleteEncryptedTOTPSeed(ctx, params)
+	recorded, err := queries.CreateTOTPSeedRevisions(ctx, params)
 	if err != nil {
-		return fmt.Errorf("failed to delete encrypted TOTP seed: %w", err)
+		return fmt.Errorf("failed to record TOTP seed revision: %w", err)
+	}
+	if recorded == 0 {
+		return entities.ErrTOTPSeedNotFound
 	}
 
 	return nil
 }
 
-// GetEncryptedData retrieves the raw encrypted data for an OTP
-func (r *otpRepository) GetEncryptedData(ctx context.Context, id uuid.UUID, userID uuid.UUID) ([]byte, int, error) {
+// ListTrash retrieves the user's trashed OTPs, most recently deleted first
+func (r *otpRepository) ListTrash(ctx context.Context, userID uuid.UUID) ([]*entities.OTP, error) {
+	seeds, err := r.queries.ListTrashedTOTPSeeds(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed TOTP seeds: %w", err)
+	}
+
+	otps := make([]*entities.OTP, 0, len(seeds))
+	for _, seed := range seeds {
+		otp, err := convertToOTP(seed)
+		if err != nil {
+			continue
+		}
+		otps = append(otps, otp)
+	}
+
+	return otps, nil
+}
+
+// Restore moves a trashed OTP back into the vault
+func (r *otpRepository) Restore(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.OTP, error) {
+	params := db.RestoreTOTPSeedParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	}
+
+	var seed db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		var err error
+		seed, err = queries.RestoreTOTPSeed(ctx, params)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrTOTPSeedNotFound
+			}
+			return fmt.Errorf("failed to restore TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationRestore, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToOTP(seed)
+}
+
+// Purge permanently deletes a trashed OTP
+func (r *otpRepository) Purge(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	params := db.PurgeTOTPSeedParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	}
+
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		deleted, err := queries.PurgeTOTPSeed(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to purge TOTP seed: %w", err)
+		}
+		if deleted == 0 {
+			return entities.ErrTOTPSeedNotFound
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationPurge, id)
+	})
+}
+
+// PurgeExpired permanently deletes every entry trashed before the given time
+func (r *otpRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		rows, err := queries.PurgeExpiredTOTPSeeds(ctx, pgtype.Timestamptz{Time: before, Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to purge expired TOTP seeds: %w", err)
+		}
+		deleted = int64(len(rows))
+
+		byUser := make(map[uuid.UUID][]pgtype.UUID)
+		for _, row := range rows {
+			userID := convertPGUUID(row.UserID)
+			byUser[userID] = append(byUser[userID], row.ID)
+		}
+
+		// Lock sync sequences in a fixed order so concurrent purgers cannot deadlock
+		userIDs := make([]uuid.UUID, 0, len(byUser))
+		for userID := range byUser {
+			userIDs = append(userIDs, userID)
+		}
+		slices.SortFunc(userIDs, func(a, b uuid.UUID) int {
+			return bytes.Compare(a[:], b[:])
+		})
+
+		for _, userID := range userIDs {
+			if err := recordSyncOperationsPG(ctx, queries, convertUUIDToPG(userID), entities.SyncOperationPurge, byUser[userID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// GetEncryptedData retrieves the secret envelope of an OTP
+func (r *otpRepository) GetEncryptedData(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.SecretEnvelope, error) {
 	params := db.GetEncryptedTOTPSeedByIDParams{
 		ID:     pgtype.UUID{Bytes: id, Valid: true},
 		UserID: pgtype.UUID{Bytes: userID, Valid: true},
@@ -145,34 +920,86 @@ func (r *otpRepository) GetEncryptedData(ctx context.Context, id uuid.UUID, user
 
 	seed, err := r.queries.GetEncryptedTOTPSeedByID(ctx, params)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, 0, entities.ErrTOTPSeedNotFound
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrTOTPSeedNotFound
 		}
-		return nil, 0, fmt.Errorf("failed to get encrypted TOTP seed: %w", err)
+		return nil, fmt.Errorf("failed to get encrypted TOTP seed: %w", err)
 	}
 
-	return seed.EncryptedSecret, 1, nil // Return keyVersion as 1 for now
+	return toSecretEnvelope(seed.SecretFormat, seed.CipherSuite, seed.KeyVersion, seed.SecretIv, seed.SecretTag, seed.EncryptedSecret)
 }
 
 // convertToOTP converts a database EncryptedTOTPSeed to a domain OTP entity
-func (r *otpRepository) convertToOTP(seed db.EncryptedTotpSeed) (*entities.OTP, error) {
-	// Return the encrypted secret exactly as stored (ciphertext.iv.authTag format)
-	// No processing needed - the client will handle decryption
-	secretForClient := string(seed.EncryptedSecret)
+func convertToOTP(seed db.EncryptedTotpSeed) (*entities.OTP, error) {
+	// Return the encrypted secret as an envelope; the client will handle decryption
+	secretForClient := formatSecret(seed.SecretFormat, seed.CipherSuite, seed.KeyVersion, seed.SecretIv, seed.SecretTag, seed.EncryptedSecret)
 
 	otp := &entities.OTP{
-		ID:        uuid.UUID(seed.ID.Bytes),
-		UserID:    uuid.UUID(seed.UserID.Bytes),
-		Issuer:    seed.ServiceName,       // Map ServiceName back to Issuer
-		Label:     seed.AccountIdentifier, // Map AccountIdentifier back to Label
-		Secret:    secretForClient,        // Return encrypted secret in original format
-		Period:    int(seed.Period),
-		Algorithm: seed.Algorithm,
-		Digits:    int(seed.Digits),
-		CreatedAt: seed.CreatedAt.Time,
-		UpdatedAt: seed.UpdatedAt.Time,
-		IsActive:  seed.IsActive.Bool,
+		ID:         uuid.UUID(seed.ID.Bytes),
+		UserID:     uuid.UUID(seed.UserID.Bytes),
+		Issuer:     seed.ServiceName, // Map ServiceName back to Issuer
+		IconURL:    seed.IconUrl.String,
+		Label:      seed.AccountIdentifier, // Map AccountIdentifier back to Label
+		Secret:     secretForClient,
+		Period:     int(seed.Period),
+		Algorithm:  seed.Algorithm,
+		Digits:     int(seed.Digits),
+		Method:     seed.Method,
+		Counter:    seed.Counter,
+		Type:       seed.OtpType,
+		T0:         seed.T0,
+		Tags:       nonNilTags(seed.Tags),
+		FolderID:   convertPGUUIDToOptional(seed.FolderID),
+		CreatedAt:  seed.CreatedAt.Time,
+		UpdatedAt:  seed.UpdatedAt.Time,
+		Revision:   seed.Revision,
+		KeyVersion: int(seed.KeyVersion),
+		IsActive:   seed.IsActive.Bool,
+	}
+	if seed.DeletedAt.Valid {
+		otp.DeletedAt = &seed.DeletedAt.Time
 	}
 
 	return otp, nil
 }
+
+// convertToOTPRevision converts a database revision to a domain OTP revision
+func convertToOTPRevision(row db.TotpSeedRevision) *entities.OTPRevision {
+	revision := &entities.OTPRevision{
+		ID:         convertPGUUID(row.ID),
+		OTPID:      convertPGUUID(row.SeedID),
+		Reason:     row.Reason,
+		Issuer:     row.ServiceName,
+		IconURL:    row.IconUrl.String,
+		Label:      row.AccountIdentifier,
+		Secret:     formatSecret(row.SecretFormat, row.CipherSuite, row.KeyVersion, row.SecretIv, row.SecretTag, row.EncryptedSecret),
+		Algorithm:  row.Algorithm,
+		Digits:     int(row.Digits),
+		Period:     int(row.Period),
+		Method:     row.Method,
+		Counter:    row.Counter,
+		Type:       row.OtpType,
+		T0:         row.T0,
+		Tags:       nonNilTags(row.Tags),
+		FolderID:   convertPGUUIDToOptional(row.FolderID),
+		DeviceID:   row.DeviceID.String,
+		UserAgent:  row.UserAgent.String,
+		RequestID:  row.RequestID.String,
+		Revision:   row.Revision,
+		KeyVersion: int(row.KeyVersion),
+		CreatedAt:  convertPGTimestamp(row.CreatedAt),
+	}
+	if row.IpAddress != nil {
+		revision.IPAddress = row.IpAddress.String()
+	}
+
+	return revision
+}
+
+// nonNilTags returns an empty slice for nil so tags are stored and serialized as an empty array
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -1,6 +1,6 @@
 -- name: CreateBackupRecoveryCode :one
-INSERT INTO backup_recovery_codes (user_id, encrypted_backup_data, recovery_code_hash)
-VALUES ($1, $2, $3)
+INSERT INTO backup_recovery_codes (user_id, encrypted_backup_data, recovery_code_hash, key_version)
+VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetActiveBackupRecoveryCode :one
@@ -13,24 +13,72 @@ LIMIT 1;
 SELECT * FROM backup_recovery_codes
 WHERE id = $1 AND user_id = $2 AND is_used = FALSE;
 
--- name: UseBackupRecoveryCode :exec
+-- name: InvalidateBackupRecoveryCodes :exec
+-- Retires the user's usable kit when it is replaced
 UPDATE backup_recovery_codes
 SET used_at = NOW(), is_used = TRUE
+WHERE user_id = $1 AND is_used = FALSE;
+
+-- name: RecordBackupRecoveryAttempt :one
+-- Counts a redemption attempt against an unlocked kit before its code is checked, so parallel
+-- guesses cannot outrun the limit. The attempt that reaches the limit locks the kit until
+-- locked_until and starts the count over.
+UPDATE backup_recovery_codes
+SET failed_attempts = CASE WHEN failed_attempts + 1 >= sqlc.arg('max_attempts')::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= sqlc.arg('max_attempts')::int THEN sqlc.arg('locked_until')::timestamptz ELSE NULL END
+WHERE id = sqlc.arg('id') AND user_id = sqlc.arg('user_id') AND is_used = FALSE
+    AND (locked_until IS NULL OR locked_until <= NOW())
+RETURNING *;
+
+-- name: ResetBackupRecoveryAttempts :exec
+-- Clears the attempt count and lock once the right code is presented
+UPDATE backup_recovery_codes
+SET failed_attempts = 0, locked_until = NULL
 WHERE id = $1 AND user_id = $2;
 
+-- name: UseBackupRecoveryCode :execrows
+UPDATE backup_recovery_codes
+SET used_at = NOW(), is_used = TRUE
+WHERE id = $1 AND user_id = $2 AND is_used = FALSE;
+
 -- name: CreateAuditLog :one
 INSERT INTO audit_logs (
-    user_id, action, resource_type, resource_id,
-    metadata, ip_address, user_agent
+    id, user_id, action, resource_type, resource_id,
+    metadata, ip_address, user_agent, request_id,
+    timestamp, sequence, prev_hash, hash
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 RETURNING *;
 
+-- name: LockAuditChain :exec
+-- Serializes appends to the audit chain until the transaction ends
+SELECT pg_advisory_xact_lock(hashtext('audit_logs'));
+
+-- name: GetAuditChainHead :one
+SELECT sequence, hash FROM audit_logs
+WHERE sequence IS NOT NULL
+ORDER BY sequence DESC
+LIMIT 1;
+
+-- name: GetAuditLogChain :many
+-- Walks the chain in order, for verification
+SELECT * FROM audit_logs
+WHERE sequence > $1
+ORDER BY sequence
+LIMIT $2;
+
 -- name: GetAuditLogsByUserID :many
+-- Pages a user's activity newest first, before the cursor's sequence when one is given. The action
+-- filter matches an action or, given a category such as 'auth', every action in it.
 SELECT * FROM audit_logs
-WHERE user_id = $1
-ORDER BY timestamp DESC
-LIMIT $2 OFFSET $3;
+WHERE user_id = sqlc.arg('user_id')
+    AND sequence IS NOT NULL
+    AND (sqlc.narg('before_sequence')::bigint IS NULL OR sequence < sqlc.narg('before_sequence')::bigint)
+    AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action')::text OR action LIKE sqlc.narg('action')::text || '.%')
+    AND (sqlc.narg('since')::timestamptz IS NULL OR timestamp >= sqlc.narg('since')::timestamptz)
+    AND (sqlc.narg('until')::timestamptz IS NULL OR timestamp < sqlc.narg('until')::timestamptz)
+ORDER BY sequence DESC
+LIMIT sqlc.arg('page_limit');
 
 -- name: GetAuditLogsByAction :many
 SELECT * FROM audit_logs
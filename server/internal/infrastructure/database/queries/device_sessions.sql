@@ -5,7 +5,7 @@ INSERT INTO device_sessions (
 VALUES ($1, $2, $3)
 ON CONFLICT (user_id, device_fingerprint)
 DO UPDATE SET
-    device_name = EXCLUDED.device_name,
+    device_name = COALESCE(EXCLUDED.device_name, device_sessions.device_name),
     last_sync_at = NOW()
 RETURNING *;
 
@@ -23,20 +23,39 @@ UPDATE device_sessions
 SET last_sync_at = NOW()
 WHERE user_id = $1 AND device_fingerprint = $2;
 
--- name: CreateSyncOperation :one
+-- name: CreateTOTPSeedSyncOperations :execrows
+-- Logs one operation per entry under consecutive sequence numbers. Allocating them row-locks the
+-- user's sequence until the transaction ends, so this must be the transaction's last write.
+WITH allocated AS (
+    INSERT INTO sync_sequences (user_id, last_seq)
+    VALUES (sqlc.arg('user_id'), CARDINALITY(sqlc.arg('entity_ids')::uuid[]))
+    ON CONFLICT (user_id) DO UPDATE
+    SET last_seq = sync_sequences.last_seq + EXCLUDED.last_seq
+    RETURNING last_seq
+)
 INSERT INTO sync_operations (
     user_id, operation_type, entity_type, entity_id,
-    operation_data, device_fingerprint
+    operation_data, device_fingerprint, seq
 )
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING *;
+SELECT sqlc.arg('user_id'), sqlc.arg('operation_type'), 'totp_seed', e.id,
+    JSONB_BUILD_OBJECT('revision', s.revision), sqlc.arg('device_fingerprint'),
+    a.last_seq - CARDINALITY(sqlc.arg('entity_ids')::uuid[]) + e.ord
+FROM allocated a
+CROSS JOIN UNNEST(sqlc.arg('entity_ids')::uuid[]) WITH ORDINALITY AS e(id, ord)
+LEFT JOIN encrypted_totp_seeds s ON s.id = e.id;
 
 -- name: GetSyncOperationsSince :many
 SELECT so.*, ds.device_name
 FROM sync_operations so
 LEFT JOIN device_sessions ds ON so.device_fingerprint = ds.device_fingerprint AND so.user_id = ds.user_id
-WHERE so.user_id = $1 AND so.timestamp > $2
-ORDER BY so.timestamp ASC;
+WHERE so.user_id = sqlc.arg('user_id') AND so.seq > sqlc.arg('after_seq') AND so.seq <= sqlc.arg('until_seq')
+ORDER BY so.seq ASC;
+
+-- name: GetSyncSequence :one
+SELECT COALESCE(
+    (SELECT last_seq FROM sync_sequences WHERE user_id = $1),
+    0
+)::bigint AS last_seq;
 
 -- name: GetLatestSyncTimestamp :one
 SELECT COALESCE(MAX(timestamp), NOW()) as latest_timestamp
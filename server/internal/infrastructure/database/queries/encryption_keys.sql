@@ -1,11 +1,11 @@
 -- name: CreateUserEncryptionKey :one
-INSERT INTO user_encryption_keys (user_id, webauthn_credential_id, encrypted_dek, key_version)
-VALUES ($1, $2, $3, $4)
+INSERT INTO user_encryption_keys (user_id, webauthn_credential_id, encrypted_dek, key_version, salt, is_active)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetActiveUserEncryptionKey :one
 SELECT * FROM user_encryption_keys
-WHERE user_id = $1
+WHERE user_id = $1 AND is_active = TRUE
 ORDER BY key_version DESC
 LIMIT 1;
 
@@ -22,4 +22,46 @@ ORDER BY key_version DESC;
 SELECT * FROM user_encryption_keys
 WHERE user_id = $1 AND webauthn_credential_id = $2
 ORDER BY key_version DESC
-LIMIT 1; 
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetActiveUserEncryptionKeys :many
+SELECT * FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
+ORDER BY created_at;
+
+-- name: GetActiveUserEncryptionKeyByCredential :one
+SELECT * FROM user_encryption_keys
+WHERE user_id = $1 AND webauthn_credential_id = $2 AND is_active = TRUE
+ORDER BY key_version DESC
+LIMIT 1;
+
+-- name: LockActiveUserEncryptionKeyCredentials :many
+-- Locks the user's active wraps so concurrent credential deletions cannot remove the last one
+SELECT webauthn_credential_id FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
+FOR UPDATE;
+
+-- name: GetLatestUserEncryptionKeyVersion :one
+-- 0 when the user has no active key yet
+SELECT COALESCE(MAX(key_version), 0)::int AS key_version FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE;
+
+-- name: UpdateUserEncryptionKey :execrows
+UPDATE user_encryption_keys
+SET encrypted_dek = $3, salt = $4, is_active = $5
+WHERE id = $1 AND user_id = $2;
+
+-- name: ActivateUserEncryptionKeys :exec
+UPDATE user_encryption_keys
+SET is_active = TRUE
+WHERE user_id = $1 AND key_version = $2;
+
+-- name: DeactivateOldUserEncryptionKeys :exec
+UPDATE user_encryption_keys
+SET is_active = FALSE
+WHERE user_id = $1 AND key_version < $2;
+
+-- name: DeleteInactiveUserEncryptionKeys :exec
+-- Discards the wraps of a key version that never became active
+DELETE FROM user_encryption_keys
+WHERE user_id = $1 AND key_version = $2 AND is_active = FALSE;
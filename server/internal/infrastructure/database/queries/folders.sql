@@ -0,0 +1,44 @@
+-- name: CreateFolder :one
+INSERT INTO folders (user_id, parent_id, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetFolderByID :one
+SELECT * FROM folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListFoldersByUser :many
+SELECT f.id, f.user_id, f.parent_id, f.name, f.created_at, f.updated_at,
+    (SELECT COUNT(*) FROM encrypted_totp_seeds s
+     WHERE s.folder_id = f.id AND s.is_active = TRUE) AS entry_count
+FROM folders f
+WHERE f.user_id = $1
+ORDER BY f.name;
+
+-- name: UpdateFolder :one
+UPDATE folders
+SET name = $3, parent_id = $4, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: IsFolderInSubtree :one
+-- Reports whether folder_id is root_id itself or one of its descendants
+WITH RECURSIVE subtree AS (
+    SELECT f.id FROM folders f
+    WHERE f.id = sqlc.arg('root_id')
+    UNION ALL
+    SELECT child.id FROM folders child
+    JOIN subtree st ON child.parent_id = st.id
+)
+SELECT EXISTS (
+    SELECT 1 FROM subtree WHERE subtree.id = sqlc.arg('folder_id')
+)::bool AS in_subtree;
+
+-- name: ReparentFolders :exec
+UPDATE folders
+SET parent_id = sqlc.narg('to_parent_id'), updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND parent_id = sqlc.arg('from_parent_id');
+
+-- name: DeleteFolder :execrows
+DELETE FROM folders
+WHERE id = $1 AND user_id = $2;
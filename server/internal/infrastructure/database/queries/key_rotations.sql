@@ -0,0 +1,94 @@
+-- name: CreateKeyRotation :one
+INSERT INTO key_rotations (user_id, from_version, to_version)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetInProgressKeyRotation :one
+SELECT * FROM key_rotations
+WHERE user_id = $1 AND status = 'in_progress';
+
+-- name: GetInProgressKeyRotationForUpdate :one
+-- Serializes staging, commit and abort of the user's rotation
+SELECT * FROM key_rotations
+WHERE user_id = $1 AND status = 'in_progress'
+FOR UPDATE;
+
+-- name: GetKeyRotationProgress :one
+-- Counts the entries below the target version, trashed ones included, and how many of them have a
+-- ciphertext staged from their current revision
+SELECT COUNT(*) AS total_entries, COUNT(e.seed_id) AS staged_entries
+FROM encrypted_totp_seeds s
+LEFT JOIN key_rotation_entries e
+    ON e.rotation_id = sqlc.arg('rotation_id') AND e.seed_id = s.id AND e.seed_revision = s.revision
+WHERE s.user_id = sqlc.arg('user_id') AND s.key_version < sqlc.arg('to_version');
+
+-- name: ListPendingKeyRotationEntries :many
+-- Entries below the target version with nothing staged from their current revision. An entry
+-- changed after it was staged is pending again.
+SELECT s.id, s.revision, s.key_version, s.encrypted_secret, s.secret_format, s.cipher_suite, s.secret_iv, s.secret_tag
+FROM encrypted_totp_seeds s
+LEFT JOIN key_rotation_entries e
+    ON e.rotation_id = sqlc.arg('rotation_id') AND e.seed_id = s.id AND e.seed_revision = s.revision
+WHERE s.user_id = sqlc.arg('user_id') AND s.key_version < sqlc.arg('to_version') AND e.seed_id IS NULL
+ORDER BY s.id
+LIMIT sqlc.arg('max_entries');
+
+-- name: StageKeyRotationEntry :execrows
+-- Stages a re-encrypted secret only while the entry is still at the revision it was made from
+INSERT INTO key_rotation_entries (rotation_id, seed_id, seed_revision, encrypted_secret, secret_format, cipher_suite, secret_iv, secret_tag)
+SELECT sqlc.arg('rotation_id'), s.id, s.revision, sqlc.arg('encrypted_secret'),
+    sqlc.arg('secret_format'), sqlc.arg('cipher_suite'), sqlc.arg('secret_iv'), sqlc.arg('secret_tag')
+FROM encrypted_totp_seeds s
+WHERE s.id = sqlc.arg('seed_id') AND s.user_id = sqlc.arg('user_id')
+    AND s.revision = sqlc.arg('seed_revision') AND s.key_version < sqlc.arg('to_version')
+ON CONFLICT (rotation_id, seed_id) DO UPDATE
+SET seed_revision = EXCLUDED.seed_revision,
+    encrypted_secret = EXCLUDED.encrypted_secret,
+    secret_format = EXCLUDED.secret_format,
+    cipher_suite = EXCLUDED.cipher_suite,
+    secret_iv = EXCLUDED.secret_iv,
+    secret_tag = EXCLUDED.secret_tag,
+    staged_at = NOW();
+
+-- name: TouchKeyRotation :exec
+UPDATE key_rotations
+SET updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListCurrentKeyRotationEntries :many
+-- The entries whose staged secret is still current, which committing the rotation swaps in
+SELECT s.id FROM encrypted_totp_seeds s
+JOIN key_rotation_entries e ON e.seed_id = s.id AND e.seed_revision = s.revision
+WHERE e.rotation_id = sqlc.arg('rotation_id') AND s.user_id = sqlc.arg('user_id')
+ORDER BY s.id;
+
+-- name: ApplyKeyRotationEntries :many
+-- Swaps in the staged secrets that are still current. The versions they replace are recorded as
+-- revisions first, since the server cannot check the new ciphertext.
+UPDATE encrypted_totp_seeds s
+SET encrypted_secret = e.encrypted_secret,
+    secret_format = e.secret_format,
+    cipher_suite = e.cipher_suite,
+    secret_iv = e.secret_iv,
+    secret_tag = e.secret_tag,
+    key_version = sqlc.arg('to_version'),
+    revision = s.revision + 1,
+    updated_at = NOW()
+FROM key_rotation_entries e
+WHERE e.rotation_id = sqlc.arg('rotation_id') AND e.seed_id = s.id AND e.seed_revision = s.revision
+    AND s.user_id = sqlc.arg('user_id')
+RETURNING s.id, s.is_active;
+
+-- name: CountPendingKeyRotationEntries :one
+SELECT COUNT(*) FROM encrypted_totp_seeds
+WHERE user_id = $1 AND key_version < $2;
+
+-- name: FinishKeyRotation :one
+UPDATE key_rotations
+SET status = $2, updated_at = NOW(), completed_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteKeyRotationEntries :exec
+DELETE FROM key_rotation_entries
+WHERE rotation_id = $1;
@@ -0,0 +1,22 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (
+    user_id, session_id, token_hash, expires_at
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetRefreshTokenByHash :one
+SELECT * FROM refresh_tokens
+WHERE token_hash = $1;
+
+-- name: UseRefreshToken :execrows
+-- Marks a token rotated. Only the first of concurrent uses updates the row.
+UPDATE refresh_tokens
+SET used_at = NOW()
+WHERE id = $1 AND used_at IS NULL;
+
+-- name: DeleteExpiredRefreshTokens :exec
+-- Expired tokens are refused whether used or not, so they no longer need keeping
+DELETE FROM refresh_tokens
+WHERE session_id = $1 AND expires_at <= NOW();
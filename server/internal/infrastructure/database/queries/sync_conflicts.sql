@@ -0,0 +1,22 @@
+-- name: CreateSyncConflict :one
+INSERT INTO sync_conflicts (
+    user_id, seed_id, device_id, base_revision, server_version, client_version
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListOpenSyncConflicts :many
+SELECT * FROM sync_conflicts
+WHERE user_id = $1 AND status = 'open'
+ORDER BY created_at ASC, id ASC;
+
+-- name: GetSyncConflictForUpdate :one
+SELECT * FROM sync_conflicts
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
+-- name: ResolveSyncConflict :one
+UPDATE sync_conflicts
+SET status = 'resolved', resolution = $3, resolved_at = NOW()
+WHERE id = $1 AND user_id = $2 AND status = 'open'
+RETURNING *;
@@ -0,0 +1,54 @@
+-- name: CreateTOTPSeedRevisions :execrows
+-- Snapshots the current version of active entries before they change, locking the rows until
+-- the change commits so concurrent writers each record the version they replaced
+INSERT INTO totp_seed_revisions (
+    seed_id, user_id, reason, service_name, account_identifier, encrypted_secret,
+    algorithm, digits, period, issuer, icon_url, method, counter, otp_type, t0, tags, folder_id,
+    device_id, ip_address, user_agent, request_id, revision, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag
+)
+SELECT s.id, s.user_id, sqlc.arg('reason'), s.service_name, s.account_identifier, s.encrypted_secret,
+    s.algorithm, s.digits, s.period, s.issuer, s.icon_url, s.method, s.counter, s.otp_type, s.t0, s.tags, s.folder_id,
+    sqlc.narg('device_id'), sqlc.narg('ip_address'), sqlc.narg('user_agent'), sqlc.narg('request_id'), s.revision, s.key_version,
+    s.secret_format, s.cipher_suite, s.secret_iv, s.secret_tag
+FROM encrypted_totp_seeds s
+WHERE s.user_id = sqlc.arg('user_id') AND s.id = ANY(sqlc.arg('seed_ids')::uuid[]) AND s.is_active = TRUE
+ORDER BY s.id
+FOR UPDATE OF s;
+
+-- name: GetTOTPSeedRevision :one
+SELECT * FROM totp_seed_revisions
+WHERE id = $1 AND seed_id = $2 AND user_id = $3;
+
+-- name: ListTOTPSeedRevisions :many
+SELECT * FROM totp_seed_revisions
+WHERE seed_id = $1 AND user_id = $2
+ORDER BY created_at DESC, id DESC;
+
+-- name: RevertTOTPSeedToRevision :one
+-- Restores an entry's ciphertext and metadata from one of its revisions. The HOTP counter is
+-- never rewound, and a folder that has since been deleted reverts to the top level.
+UPDATE encrypted_totp_seeds s
+SET service_name = r.service_name,
+    account_identifier = r.account_identifier,
+    encrypted_secret = r.encrypted_secret,
+    algorithm = r.algorithm,
+    digits = r.digits,
+    period = r.period,
+    issuer = r.issuer,
+    icon_url = r.icon_url,
+    otp_type = r.otp_type,
+    t0 = r.t0,
+    tags = r.tags,
+    folder_id = (SELECT f.id FROM folders f WHERE f.id = r.folder_id AND f.user_id = s.user_id),
+    key_version = r.key_version,
+    secret_format = r.secret_format,
+    cipher_suite = r.cipher_suite,
+    secret_iv = r.secret_iv,
+    secret_tag = r.secret_tag,
+    revision = s.revision + 1,
+    updated_at = NOW()
+FROM totp_seed_revisions r
+WHERE r.id = sqlc.arg('revision_id') AND r.seed_id = s.id
+    AND s.id = sqlc.arg('id') AND s.user_id = sqlc.arg('user_id') AND s.is_active = TRUE
+RETURNING s.*;
@@ -1,26 +1,139 @@
 -- name: CreateEncryptedTOTPSeed :one
 INSERT INTO encrypted_totp_seeds (
     user_id, service_name, account_identifier, encrypted_secret,
-    algorithm, digits, period, issuer, icon_url, is_active
+    algorithm, digits, period, issuer, icon_url, is_active,
+    method, counter, otp_type, t0, tags, folder_id, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
 RETURNING *;
 
 -- name: GetEncryptedTOTPSeedByID :one
 SELECT * FROM encrypted_totp_seeds
 WHERE id = $1 AND user_id = $2 AND is_active = TRUE;
 
+-- name: GetEncryptedTOTPSeedByIDForUpdate :one
+SELECT * FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+FOR UPDATE;
+
+-- name: GetEncryptedTOTPSeedForSync :one
+-- Locks an entry, trashed or not, while a pushed change or conflict resolution is checked against it
+SELECT * FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2
+FOR UPDATE;
+
 -- name: GetEncryptedTOTPSeedsByUserID :many
 SELECT * FROM encrypted_totp_seeds
 WHERE user_id = $1 AND is_active = TRUE
 ORDER BY created_at DESC;
 
+-- name: ListEncryptedTOTPSeeds :many
+-- One page of entries, optionally filtered by tag, by folder (including its subfolders) and by a
+-- pg_trgm fuzzy query over issuer, label and tags. Rows are keyed by (sort_text, sort_num, id):
+-- text sorts leave sort_num at 0 and numeric sorts leave sort_text empty, and the key of the last
+-- row is the cursor for the next page. A NULL page_limit returns every remaining row.
+-- The most-used key reads the live use_count, so uses recorded between pages can move an entry
+-- across the cursor and skip or repeat it.
+WITH RECURSIVE folder_tree AS (
+    SELECT f.id FROM folders f
+    WHERE f.id = sqlc.narg('folder_id') AND f.user_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT child.id FROM folders child
+    JOIN folder_tree ft ON child.parent_id = ft.id
+),
+candidates AS (
+    SELECT s.*,
+        COALESCE(u.use_count, 0)::bigint AS use_count,
+        u.last_used_at,
+        LOWER(CONCAT_WS(' ', s.service_name, s.account_identifier, ARRAY_TO_STRING(s.tags, ' '))) AS haystack
+    FROM encrypted_totp_seeds s
+    LEFT JOIN totp_seed_usage u ON u.seed_id = s.id
+    WHERE s.user_id = sqlc.arg('user_id') AND s.is_active = TRUE
+        AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag')::text = ANY(s.tags))
+        AND (sqlc.narg('folder_id')::uuid IS NULL OR s.folder_id IN (SELECT id FROM folder_tree))
+),
+matches AS (
+    SELECT c.*,
+        CASE WHEN sqlc.narg('query')::text IS NULL THEN 0
+            ELSE WORD_SIMILARITY(LOWER(sqlc.narg('query')::text), c.haystack)
+                + CASE WHEN STRPOS(c.haystack, LOWER(sqlc.narg('query')::text)) > 0 THEN 1 ELSE 0 END
+        END AS score
+    FROM candidates c
+    WHERE sqlc.narg('query')::text IS NULL
+        OR STRPOS(c.haystack, LOWER(sqlc.narg('query')::text)) > 0
+        OR LOWER(sqlc.narg('query')::text) <% c.haystack
+),
+keyed AS (
+    SELECT m.*,
+        (CASE sqlc.arg('sort')::text
+            WHEN 'issuer' THEN LOWER(m.service_name)
+            WHEN 'label' THEN LOWER(m.account_identifier)
+            ELSE ''
+        END)::text AS sort_text,
+        (CASE sqlc.arg('sort')::text
+            WHEN 'created' THEN COALESCE(EXTRACT(EPOCH FROM m.created_at) * 1000000, 0)::bigint
+            WHEN 'updated' THEN COALESCE(EXTRACT(EPOCH FROM m.updated_at) * 1000000, 0)::bigint
+            WHEN 'most-used' THEN m.use_count
+            WHEN 'relevance' THEN (m.score * 1000000)::bigint
+            ELSE 0
+        END)::bigint AS sort_num
+    FROM matches m
+)
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag, use_count, last_used_at, sort_text, sort_num
+FROM keyed
+WHERE sqlc.narg('after_id')::uuid IS NULL
+    OR (NOT sqlc.arg('descending')::bool
+        AND (sort_text, sort_num, id) > (sqlc.arg('after_text')::text, sqlc.arg('after_num')::bigint, sqlc.narg('after_id')::uuid))
+    OR (sqlc.arg('descending')::bool
+        AND (sort_text, sort_num, id) < (sqlc.arg('after_text')::text, sqlc.arg('after_num')::bigint, sqlc.narg('after_id')::uuid))
+ORDER BY
+    CASE WHEN NOT sqlc.arg('descending')::bool THEN sort_text END ASC,
+    CASE WHEN NOT sqlc.arg('descending')::bool THEN sort_num END ASC,
+    CASE WHEN NOT sqlc.arg('descending')::bool THEN id END ASC,
+    CASE WHEN sqlc.arg('descending')::bool THEN sort_text END DESC,
+    CASE WHEN sqlc.arg('descending')::bool THEN sort_num END DESC,
+    CASE WHEN sqlc.arg('descending')::bool THEN id END DESC
+LIMIT sqlc.narg('page_limit');
+
+-- name: CountEncryptedTOTPSeeds :one
+-- Counts the entries ListEncryptedTOTPSeeds matches across all pages
+WITH RECURSIVE folder_tree AS (
+    SELECT f.id FROM folders f
+    WHERE f.id = sqlc.narg('folder_id') AND f.user_id = sqlc.arg('user_id')
+    UNION ALL
+    SELECT child.id FROM folders child
+    JOIN folder_tree ft ON child.parent_id = ft.id
+),
+candidates AS (
+    SELECT s.*,
+        COALESCE(u.use_count, 0)::bigint AS use_count,
+        u.last_used_at,
+        LOWER(CONCAT_WS(' ', s.service_name, s.account_identifier, ARRAY_TO_STRING(s.tags, ' '))) AS haystack
+    FROM encrypted_totp_seeds s
+    LEFT JOIN totp_seed_usage u ON u.seed_id = s.id
+    WHERE s.user_id = sqlc.arg('user_id') AND s.is_active = TRUE
+        AND (sqlc.narg('tag')::text IS NULL OR sqlc.narg('tag')::text = ANY(s.tags))
+        AND (sqlc.narg('folder_id')::uuid IS NULL OR s.folder_id IN (SELECT id FROM folder_tree))
+)
+SELECT COUNT(*) FROM candidates c
+    WHERE sqlc.narg('query')::text IS NULL
+        OR STRPOS(c.haystack, LOWER(sqlc.narg('query')::text)) > 0
+        OR LOWER(sqlc.narg('query')::text) <% c.haystack;
+
+-- name: GetEncryptedTOTPSeedsByIDs :many
+-- Includes trashed entries, so callers can tell them apart from purged ones
+SELECT * FROM encrypted_totp_seeds
+WHERE user_id = sqlc.arg('user_id') AND id = ANY(sqlc.arg('ids')::uuid[]);
+
 -- name: GetEncryptedTOTPSeedsByUserIDSince :many
 SELECT * FROM encrypted_totp_seeds
 WHERE user_id = $1 AND updated_at > $2 AND is_active = TRUE
 ORDER BY updated_at ASC;
 
 -- name: UpdateEncryptedTOTPSeed :one
+-- A non-NULL expected_revision makes the update conditional on the entry still being at that revision
 UPDATE encrypted_totp_seeds
 SET service_name = COALESCE(sqlc.narg('service_name'), service_name),
     account_identifier = COALESCE(sqlc.narg('account_identifier'), account_identifier),
@@ -30,14 +143,82 @@ SET service_name = COALESCE(sqlc.narg('service_name'), service_name),
     period = COALESCE(sqlc.narg('period'), period),
     issuer = COALESCE(sqlc.narg('issuer'), issuer),
     icon_url = COALESCE(sqlc.narg('icon_url'), icon_url),
+    otp_type = COALESCE(sqlc.narg('otp_type'), otp_type),
+    t0 = COALESCE(sqlc.narg('t0'), t0),
+    tags = COALESCE(sqlc.narg('tags'), tags),
+    folder_id = CASE WHEN sqlc.arg('set_folder')::bool THEN sqlc.narg('folder_id') ELSE folder_id END,
+    key_version = COALESCE(sqlc.narg('key_version'), key_version),
+    secret_format = COALESCE(sqlc.narg('secret_format'), secret_format),
+    cipher_suite = COALESCE(sqlc.narg('cipher_suite'), cipher_suite),
+    secret_iv = COALESCE(sqlc.narg('secret_iv'), secret_iv),
+    secret_tag = COALESCE(sqlc.narg('secret_tag'), secret_tag),
+    revision = revision + 1,
     updated_at = NOW()
 WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+    AND (sqlc.narg('expected_revision')::bigint IS NULL OR revision = sqlc.narg('expected_revision')::bigint)
 RETURNING *;
 
--- name: DeleteEncryptedTOTPSeed :exec
+-- name: ApplyTOTPSeedVersion :one
+-- Replaces an entry's ciphertext and metadata with a version pushed by a device or chosen to resolve
+-- a conflict, moving it out of the trash if needed. The HOTP method and counter are kept, and a
+-- folder that no longer exists puts the entry at the top level.
+UPDATE encrypted_totp_seeds s
+SET service_name = sqlc.arg('service_name'),
+    account_identifier = sqlc.arg('account_identifier'),
+    encrypted_secret = sqlc.arg('encrypted_secret'),
+    algorithm = sqlc.arg('algorithm'),
+    digits = sqlc.arg('digits'),
+    period = sqlc.arg('period'),
+    issuer = sqlc.arg('service_name'),
+    icon_url = sqlc.narg('icon_url'),
+    otp_type = sqlc.arg('otp_type'),
+    t0 = sqlc.arg('t0'),
+    tags = sqlc.arg('tags'),
+    folder_id = (SELECT f.id FROM folders f WHERE f.id = sqlc.narg('folder_id') AND f.user_id = s.user_id),
+    key_version = sqlc.arg('key_version'),
+    secret_format = sqlc.arg('secret_format'),
+    cipher_suite = sqlc.arg('cipher_suite'),
+    secret_iv = sqlc.arg('secret_iv'),
+    secret_tag = sqlc.arg('secret_tag'),
+    is_active = TRUE,
+    deleted_at = NULL,
+    revision = s.revision + 1,
+    updated_at = NOW()
+WHERE s.id = sqlc.arg('id') AND s.user_id = sqlc.arg('user_id') AND s.revision = sqlc.arg('expected_revision')
+RETURNING s.*;
+
+-- name: DeleteEncryptedTOTPSeed :execrows
+-- A non-NULL expected_revision makes the delete conditional on the entry still being at that revision
 UPDATE encrypted_totp_seeds
-SET is_active = FALSE, updated_at = NOW()
-WHERE id = $1 AND user_id = $2;
+SET is_active = FALSE, deleted_at = NOW(), revision = revision + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+    AND (sqlc.narg('expected_revision')::bigint IS NULL OR revision = sqlc.narg('expected_revision')::bigint);
+
+-- name: ListTakenTOTPSeedIDs :many
+-- Entry IDs are global, so an import checks which of its IDs any user already has
+SELECT id FROM encrypted_totp_seeds
+WHERE id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: ListTrashedTOTPSeeds :many
+SELECT * FROM encrypted_totp_seeds
+WHERE user_id = $1 AND is_active = FALSE
+ORDER BY deleted_at DESC;
+
+-- name: RestoreTOTPSeed :one
+UPDATE encrypted_totp_seeds
+SET is_active = TRUE, deleted_at = NULL, revision = revision + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = FALSE
+RETURNING *;
+
+-- name: PurgeTOTPSeed :execrows
+-- Permanently deletes an entry; only entries already in the trash can be purged
+DELETE FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = FALSE;
+
+-- name: PurgeExpiredTOTPSeeds :many
+DELETE FROM encrypted_totp_seeds
+WHERE is_active = FALSE AND deleted_at < $1
+RETURNING id, user_id;
 
 -- name: SearchEncryptedTOTPSeeds :many
 SELECT * FROM encrypted_totp_seeds
@@ -49,11 +230,108 @@ WHERE user_id = $1 AND is_active = TRUE
     )
 ORDER BY created_at DESC;
 
+-- name: IncrementTOTPSeedCounter :one
+UPDATE encrypted_totp_seeds
+SET counter = counter + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+RETURNING counter;
+
+-- name: RecordTOTPSeedUse :execrows
+INSERT INTO totp_seed_usage (seed_id, use_count, last_used_at)
+SELECT id, 1, NOW() FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+ON CONFLICT (seed_id) DO UPDATE
+SET use_count = totp_seed_usage.use_count + 1, last_used_at = NOW();
+
 -- name: UpdateTOTPSeedSyncTimestamp :exec
 UPDATE encrypted_totp_seeds
 SET updated_at = NOW()
 WHERE id = $1 AND user_id = $2;
 
+-- name: ListTagCountsByUser :many
+SELECT tag::text AS tag, COUNT(*) AS count
+FROM encrypted_totp_seeds, UNNEST(tags) AS tag
+WHERE user_id = $1 AND is_active = TRUE
+GROUP BY tag
+ORDER BY tag;
+
+-- name: RenameTOTPSeedTag :many
+-- Renaming onto an existing tag merges the two; duplicates are dropped keeping the first position
+UPDATE encrypted_totp_seeds
+SET tags = ARRAY(
+        SELECT t FROM (
+            SELECT DISTINCT ON (u.t) u.t, u.ord
+            FROM UNNEST(ARRAY_REPLACE(tags, sqlc.arg('old_tag')::text, sqlc.arg('new_tag')::text)) WITH ORDINALITY AS u(t, ord)
+            ORDER BY u.t, u.ord
+        ) deduped
+        ORDER BY deduped.ord
+    ),
+    revision = revision + 1,
+    updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND is_active = TRUE AND sqlc.arg('old_tag')::text = ANY(tags)
+RETURNING id;
+
+-- name: MoveTOTPSeedsToFolder :many
+UPDATE encrypted_totp_seeds
+SET folder_id = sqlc.narg('to_folder_id'), revision = revision + 1, updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND folder_id = sqlc.arg('from_folder_id')
+RETURNING id;
+
 -- name: GetTOTPSeedsCountByUser :one
 SELECT COUNT(*) FROM encrypted_totp_seeds
-WHERE user_id = $1 AND is_active = TRUE; 
\ No newline at end of file
+WHERE user_id = $1 AND is_active = TRUE; 
+
+-- name: CreateEncryptedTOTPSeeds :copyfrom
+INSERT INTO encrypted_totp_seeds (
+    id, user_id, service_name, account_identifier, encrypted_secret,
+    algorithm, digits, period, issuer, icon_url, is_active,
+    method, counter, otp_type, t0, tags, folder_id, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22);
+
+-- name: UpdateEncryptedTOTPSeedsBatch :batchone
+-- A non-NULL expected_revision makes each update conditional on the entry still being at that revision
+UPDATE encrypted_totp_seeds
+SET service_name = COALESCE(sqlc.narg('service_name'), service_name),
+    account_identifier = COALESCE(sqlc.narg('account_identifier'), account_identifier),
+    encrypted_secret = COALESCE(sqlc.narg('encrypted_secret'), encrypted_secret),
+    algorithm = COALESCE(sqlc.narg('algorithm'), algorithm),
+    digits = COALESCE(sqlc.narg('digits'), digits),
+    period = COALESCE(sqlc.narg('period'), period),
+    issuer = COALESCE(sqlc.narg('issuer'), issuer),
+    icon_url = COALESCE(sqlc.narg('icon_url'), icon_url),
+    otp_type = COALESCE(sqlc.narg('otp_type'), otp_type),
+    t0 = COALESCE(sqlc.narg('t0'), t0),
+    tags = COALESCE(sqlc.narg('tags'), tags),
+    folder_id = CASE WHEN sqlc.arg('set_folder')::bool THEN sqlc.narg('folder_id') ELSE folder_id END,
+    key_version = COALESCE(sqlc.narg('key_version'), key_version),
+    secret_format = COALESCE(sqlc.narg('secret_format'), secret_format),
+    cipher_suite = COALESCE(sqlc.narg('cipher_suite'), cipher_suite),
+    secret_iv = COALESCE(sqlc.narg('secret_iv'), secret_iv),
+    secret_tag = COALESCE(sqlc.narg('secret_tag'), secret_tag),
+    revision = revision + 1,
+    updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+    AND (sqlc.narg('expected_revision')::bigint IS NULL OR revision = sqlc.narg('expected_revision')::bigint)
+RETURNING *;
+
+-- name: DeleteEncryptedTOTPSeedsBatch :batchone
+-- A non-NULL expected_revision makes each delete conditional on the entry still being at that revision
+UPDATE encrypted_totp_seeds
+SET is_active = FALSE, deleted_at = NOW(), revision = revision + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+    AND (sqlc.narg('expected_revision')::bigint IS NULL OR revision = sqlc.narg('expected_revision')::bigint)
+RETURNING id;
+
+-- name: GetEncryptedTOTPSeedsByKeyVersion :many
+-- Includes trashed entries, which are encrypted like any other
+SELECT * FROM encrypted_totp_seeds
+WHERE user_id = $1 AND key_version = $2
+ORDER BY created_at ASC;
+
+-- name: UpdateTOTPSeedKeyVersion :many
+UPDATE encrypted_totp_seeds
+SET key_version = sqlc.arg('new_version'), revision = revision + 1, updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id') AND key_version = sqlc.arg('old_version')
+RETURNING id, is_active;
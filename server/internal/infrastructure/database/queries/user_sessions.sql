@@ -0,0 +1,46 @@
+-- name: CreateUserSession :one
+INSERT INTO user_sessions (
+    user_id, device_id, device_name, ip_address, user_agent, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetUserSession :one
+SELECT * FROM user_sessions
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListUserSessions :many
+-- The user's live sessions, most recently active first
+SELECT * FROM user_sessions
+WHERE user_id = $1 AND expires_at > NOW() AND last_activity_at > sqlc.arg('idle_cutoff')::timestamptz
+ORDER BY last_activity_at DESC;
+
+-- name: TouchUserSession :exec
+UPDATE user_sessions
+SET last_activity_at = NOW(), ip_address = $3
+WHERE id = $1 AND user_id = $2;
+
+-- name: RenameUserSession :execrows
+UPDATE user_sessions
+SET device_name = $3
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM user_sessions
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteOtherUserSessions :many
+DELETE FROM user_sessions
+WHERE user_id = $1 AND id <> $2
+RETURNING id;
+
+-- name: DeleteAllUserSessions :many
+DELETE FROM user_sessions
+WHERE user_id = $1
+RETURNING id;
+
+-- name: DeleteExpiredUserSessions :exec
+-- Drops the user's sessions that timed out, which no token can use any more
+DELETE FROM user_sessions
+WHERE user_id = $1 AND (expires_at <= NOW() OR last_activity_at <= sqlc.arg('idle_cutoff')::timestamptz);
@@ -30,8 +30,25 @@ SET last_login_at = NOW(), updated_at = NOW()
 WHERE id = $1;
 
 -- name: DeactivateUser :exec
+-- Suspends the account and revokes its tokens, so reactivating it does not revive them
 UPDATE users 
-SET is_active = FALSE, updated_at = NOW()
+SET is_active = FALSE, token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ReactivateUser :exec
+UPDATE users 
+SET is_active = TRUE, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RevokeUserTokens :exec
+UPDATE users 
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetUserRole :exec
+-- Changes the role and revokes the user's tokens, which carry the old one
+UPDATE users 
+SET role = $2, token_version = token_version + 1, updated_at = NOW()
 WHERE id = $1;
 
 -- name: DeleteUser :exec
@@ -44,4 +61,30 @@ ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
 
 -- name: CountUsers :one
-SELECT COUNT(*) FROM users; 
\ No newline at end of file
+SELECT COUNT(*) FROM users;
+
+-- name: SearchUsers :many
+-- One page of users, newest first, optionally filtered by a case-insensitive substring of the
+-- username, email or display name, by role and by status. Rows are keyed by (created_at, id), and
+-- the key of the last row is the cursor for the next page.
+SELECT * FROM users
+WHERE (sqlc.narg('query')::text IS NULL
+        OR STRPOS(LOWER(CONCAT_WS(' ', username, email, display_name)), LOWER(sqlc.narg('query')::text)) > 0)
+    AND (sqlc.narg('role')::text IS NULL OR role = sqlc.narg('role')::text)
+    AND (sqlc.narg('is_active')::boolean IS NULL OR COALESCE(is_active, FALSE) = sqlc.narg('is_active')::boolean)
+    AND (sqlc.narg('before_created_at')::timestamptz IS NULL
+        OR (created_at, id) < (sqlc.narg('before_created_at')::timestamptz, sqlc.narg('before_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: GetUserStats :one
+-- Aggregate counts for the admin dashboard; new and signed-in users are counted from since
+SELECT
+    COUNT(*) AS total_users,
+    COUNT(*) FILTER (WHERE COALESCE(is_active, FALSE)) AS active_users,
+    COUNT(*) FILTER (WHERE role = 'admin') AS admin_users,
+    COUNT(*) FILTER (WHERE created_at >= sqlc.arg('since')::timestamptz) AS new_users,
+    COUNT(*) FILTER (WHERE last_login_at >= sqlc.arg('since')::timestamptz) AS signed_in_users,
+    (SELECT COUNT(*) FROM encrypted_totp_seeds WHERE is_active = TRUE) AS vault_entries,
+    (SELECT COUNT(*) FROM webauthn_credentials) AS passkeys
+FROM users;
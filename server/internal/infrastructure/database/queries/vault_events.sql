@@ -0,0 +1,32 @@
+-- name: CreateVaultEvent :exec
+-- Logs an event under the next sequence number. Like CreateTOTPSeedSyncOperations it row-locks the
+-- user's sequence until the transaction ends, so this must be the transaction's last write.
+WITH allocated AS (
+    INSERT INTO sync_sequences (user_id, last_seq)
+    VALUES (sqlc.arg('user_id'), 1)
+    ON CONFLICT (user_id) DO UPDATE
+    SET last_seq = sync_sequences.last_seq + 1
+    RETURNING last_seq
+)
+INSERT INTO vault_events (user_id, seq, event_type, entity_id, device_id)
+SELECT sqlc.arg('user_id'), a.last_seq, sqlc.arg('event_type'), sqlc.narg('entity_id'), sqlc.narg('device_id')
+FROM allocated a;
+
+-- name: GetVaultEventsSince :many
+-- Entry changes come from the sync log and carry its operation; other events carry their type
+SELECT seq, operation, event_type, entity_id, device_id, revision, created_at
+FROM (
+    SELECT so.seq, so.operation_type AS operation, NULL::varchar AS event_type, so.entity_id,
+        so.device_fingerprint AS device_id, (so.operation_data->>'revision')::bigint AS revision,
+        so.timestamp AS created_at
+    FROM sync_operations so
+    WHERE so.user_id = sqlc.arg('user_id') AND so.seq > sqlc.arg('after_seq')
+    UNION ALL
+    SELECT ve.seq, NULL::varchar AS operation, ve.event_type, ve.entity_id,
+        ve.device_id, NULL::bigint AS revision,
+        ve.created_at
+    FROM vault_events ve
+    WHERE ve.user_id = sqlc.arg('user_id') AND ve.seq > sqlc.arg('after_seq')
+) events
+ORDER BY seq ASC
+LIMIT sqlc.arg('max_events');
@@ -34,4 +34,8 @@ WHERE credential_id = $1;
 
 -- name: DeleteWebAuthnCredential :exec
 DELETE FROM webauthn_credentials
-WHERE credential_id = $1 AND user_id = $2; 
\ No newline at end of file
+WHERE credential_id = $1 AND user_id = $2;
+
+-- name: DeleteWebAuthnCredentialByUUID :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2;
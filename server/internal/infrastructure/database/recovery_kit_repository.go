@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type recoveryKitRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewRecoveryKitRepository creates a new recovery kit repository
+func NewRecoveryKitRepository(database *DB) interfaces.RecoveryKitRepository {
+	return &recoveryKitRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create stores a kit for a user without a usable one
+func (r *recoveryKitRepository) Create(ctx context.Context, kit *entities.RecoveryKit) error {
+	return createRecoveryKit(ctx, r.queries, kit)
+}
+
+// GetActive retrieves the user's usable kit
+func (r *recoveryKitRepository) GetActive(ctx context.Context, userID uuid.UUID) (*entities.RecoveryKit, error) {
+	row, err := r.queries.GetActiveBackupRecoveryCode(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrBackupNotFound
+		}
+		return nil, fmt.Errorf("failed to get recovery kit: %w", err)
+	}
+
+	return convertToRecoveryKit(row), nil
+}
+
+// Replace retires the user's usable kit and stores the new one in one transaction
+func (r *recoveryKitRepository) Replace(ctx context.Context, kit *entities.RecoveryKit) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := queries.InvalidateBackupRecoveryCodes(ctx, convertUUIDToPG(kit.UserID)); err != nil {
+			return fmt.Errorf("failed to invalidate recovery kit: %w", err)
+		}
+
+		return createRecoveryKit(ctx, queries, kit)
+	})
+}
+
+// RecordAttempt counts a redemption attempt against an unlocked kit
+func (r *recoveryKitRepository) RecordAttempt(ctx context.Context, kit *entities.RecoveryKit, maxAttempts int, lockedUntil time.Time) (*entities.RecoveryKit, error) {
+	row, err := r.queries.RecordBackupRecoveryAttempt(ctx, db.RecordBackupRecoveryAttemptParams{
+		MaxAttempts: int32(maxAttempts),
+		LockedUntil: pgtype.Timestamptz{Time: lockedUntil, Valid: true},
+		ID:          convertUUIDToPG(kit.ID),
+		UserID:      convertUUIDToPG(kit.UserID),
+	})
+	if err == nil {
+		return convertToRecoveryKit(row), nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to record recovery attempt: %w", err)
+	}
+
+	// Nothing was counted: the kit is either locked or no longer usable
+	current, err := r.queries.GetBackupRecoveryCodeByID(ctx, db.GetBackupRecoveryCodeByIDParams{
+		ID:     convertUUIDToPG(kit.ID),
+		UserID: convertUUIDToPG(kit.UserID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrBackupNotFound
+		}
+		return nil, fmt.Errorf("failed to get recovery kit: %w", err)
+	}
+	if current.LockedUntil.Valid {
+		return nil, fmt.Errorf("%w: try again after %s", entities.ErrRecoveryLocked, current.LockedUntil.Time.UTC().Format(time.RFC3339))
+	}
+
+	return nil, entities.ErrRecoveryLocked
+}
+
+// ResetAttempts clears the kit's attempt count and lock
+func (r *recoveryKitRepository) ResetAttempts(ctx context.Context, kit *entities.RecoveryKit) error {
+	if err := r.queries.ResetBackupRecoveryAttempts(ctx, db.ResetBackupRecoveryAttemptsParams{
+		ID:     convertUUIDToPG(kit.ID),
+		UserID: convertUUIDToPG(kit.UserID),
+	}); err != nil {
+		return fmt.Errorf("failed to reset recovery attempts: %w", err)
+	}
+
+	kit.FailedAttempts = 0
+	kit.LockedUntil = nil
+
+	return nil
+}
+
+// Redeem spends the kit and stores the new passkey's wrap and the replacement kit in one transaction
+func (r *recoveryKitRepository) Redeem(ctx context.Context, kit *entities.RecoveryKit, key *entities.UserEncryptionKey, replacement *entities.RecoveryKit) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		// Only one redemption of a kit can succeed
+		used, err := queries.UseBackupRecoveryCode(ctx, db.UseBackupRecoveryCodeParams{
+			ID:     convertUUIDToPG(kit.ID),
+			UserID: convertUUIDToPG(kit.UserID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to spend recovery kit: %w", err)
+		}
+		if used == 0 {
+			return entities.ErrBackupNotFound
+		}
+
+		if err := createEncryptionKey(ctx, queries, key); err != nil {
+			return err
+		}
+
+		return createRecoveryKit(ctx, queries, replacement)
+	})
+}
+
+// createRecoveryKit stores a kit and fills in its generated ID and creation time
+func createRecoveryKit(ctx context.Context, queries *db.Queries, kit *entities.RecoveryKit) error {
+	row, err := queries.CreateBackupRecoveryCode(ctx, db.CreateBackupRecoveryCodeParams{
+		UserID:              convertUUIDToPG(kit.UserID),
+		EncryptedBackupData: kit.EncryptedBackup,
+		RecoveryCodeHash:    kit.CodeHash,
+		KeyVersion:          int32(kit.KeyVersion),
+	})
+	if err != nil {
+		// A user has at most one usable kit
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return entities.ErrRecoveryKitExists
+		}
+		return fmt.Errorf("failed to create recovery kit: %w", err)
+	}
+
+	kit.ID = convertPGUUID(row.ID)
+	kit.CreatedAt = convertPGTimestamp(row.CreatedAt)
+
+	return nil
+}
+
+// convertToRecoveryKit converts a database recovery code to a domain recovery kit
+func convertToRecoveryKit(row db.BackupRecoveryCode) *entities.RecoveryKit {
+	kit := &entities.RecoveryKit{
+		ID:              convertPGUUID(row.ID),
+		UserID:          convertPGUUID(row.UserID),
+		KeyVersion:      int(row.KeyVersion),
+		EncryptedBackup: row.EncryptedBackupData,
+		CodeHash:        row.RecoveryCodeHash,
+		FailedAttempts:  int(row.FailedAttempts),
+		CreatedAt:       convertPGTimestamp(row.CreatedAt),
+	}
+	if row.LockedUntil.Valid {
+		kit.LockedUntil = &row.LockedUntil.Time
+	}
+
+	return kit
+}
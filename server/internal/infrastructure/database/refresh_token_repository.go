@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type refreshTokenRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(database *DB) interfaces.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create stores a token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entities.RefreshToken) error {
+	return createRefreshToken(ctx, r.queries, token)
+}
+
+// GetByHash retrieves the token stored under hash
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, hash []byte) (*entities.RefreshToken, error) {
+	row, err := r.queries.GetRefreshTokenByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return convertToRefreshToken(row), nil
+}
+
+// Rotate marks current used and stores next in its family in one transaction
+func (r *refreshTokenRepository) Rotate(ctx context.Context, current, next *entities.RefreshToken) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		// Only one use of a token can rotate it
+		used, err := queries.UseRefreshToken(ctx, convertUUIDToPG(current.ID))
+		if err != nil {
+			return fmt.Errorf("failed to use refresh token: %w", err)
+		}
+		if used == 0 {
+			return entities.ErrRefreshTokenReused
+		}
+
+		if err := queries.DeleteExpiredRefreshTokens(ctx, convertUUIDToPG(current.SessionID)); err != nil {
+			return fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+		}
+
+		next.UserID = current.UserID
+		next.SessionID = current.SessionID
+		return createRefreshToken(ctx, queries, next)
+	})
+}
+
+// RevokeFamily deletes the reused token's session and records the reuse
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, reused *entities.RefreshToken) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		// The session may already be gone when the family is revoked twice; the reuse is still
+		// worth recording
+		if _, err := queries.DeleteUserSession(ctx, db.DeleteUserSessionParams{
+			ID:     convertUUIDToPG(reused.SessionID),
+			UserID: convertUUIDToPG(reused.UserID),
+		}); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		entry := entities.NewAuditLog(&reused.UserID, entities.AuditActionRefreshReused, entities.AuditResourceSession,
+			&reused.SessionID, map[string]string{"token_id": reused.ID.String()}, entities.RequestInfoFromContext(ctx))
+		return appendAuditLogs(ctx, queries, entry)
+	})
+}
+
+// createRefreshToken stores a token and fills in its generated ID and creation time
+func createRefreshToken(ctx context.Context, queries *db.Queries, token *entities.RefreshToken) error {
+	row, err := queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:    convertUUIDToPG(token.UserID),
+		SessionID: convertUUIDToPG(token.SessionID),
+		TokenHash: token.TokenHash,
+		ExpiresAt: pgtype.Timestamptz{Time: token.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	token.ID = convertPGUUID(row.ID)
+	token.CreatedAt = convertPGTimestamp(row.CreatedAt)
+
+	return nil
+}
+
+// convertToRefreshToken converts a database refresh token to a domain refresh token
+func convertToRefreshToken(row db.RefreshToken) *entities.RefreshToken {
+	token := &entities.RefreshToken{
+		ID:        convertPGUUID(row.ID),
+		UserID:    convertPGUUID(row.UserID),
+		SessionID: convertPGUUID(row.SessionID),
+		TokenHash: row.TokenHash,
+		ExpiresAt: convertPGTimestamp(row.ExpiresAt),
+		CreatedAt: convertPGTimestamp(row.CreatedAt),
+	}
+	if row.UsedAt.Valid {
+		token.UsedAt = &row.UsedAt.Time
+	}
+
+	return token
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+// legacySecretFormat marks a secret stored before envelopes that the migration could not split.
+// Its encrypted_secret column holds the string the client sent.
+const legacySecretFormat = 0
+
+// secretColumns holds a secret envelope's parts as they are stored. The key version has a column
+// of its own that predates envelopes.
+type secretColumns struct {
+	Format     int16
+	Suite      int16
+	IV         []byte
+	Tag        []byte
+	Ciphertext []byte
+}
+
+// toSecretColumns splits an envelope into its columns
+func toSecretColumns(envelope *entities.SecretEnvelope) secretColumns {
+	return secretColumns{
+		Format:     int16(envelope.Format),
+		Suite:      int16(envelope.Suite),
+		IV:         envelope.IV,
+		Tag:        envelope.AuthTag,
+		Ciphertext: envelope.Ciphertext,
+	}
+}
+
+// parseSecretColumns parses a client-encrypted secret in the envelope wire format into its columns
+func parseSecretColumns(secret string) (secretColumns, error) {
+	envelope, err := entities.ParseSecretEnvelope(secret)
+	if err != nil {
+		return secretColumns{}, err
+	}
+	return toSecretColumns(envelope), nil
+}
+
+// formatArg returns the format as an update argument
+func (c secretColumns) formatArg() pgtype.Int2 {
+	return pgtype.Int2{Int16: c.Format, Valid: true}
+}
+
+// suiteArg returns the cipher suite as an update argument
+func (c secretColumns) suiteArg() pgtype.Int2 {
+	return pgtype.Int2{Int16: c.Suite, Valid: true}
+}
+
+// toSecretEnvelope joins stored columns back into an envelope and checks it
+func toSecretEnvelope(format, suite int16, keyVersion int32, iv, tag, ciphertext []byte) (*entities.SecretEnvelope, error) {
+	if format == legacySecretFormat {
+		return nil, fmt.Errorf("%w: secret was stored before envelopes and could not be migrated", entities.ErrInvalidTOTPSeed)
+	}
+
+	envelope := storedEnvelope(format, suite, keyVersion, iv, tag, ciphertext)
+	if err := envelope.Validate(); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// formatSecret joins stored columns back into the wire format clients read. A legacy secret is
+// returned exactly as it was sent.
+func formatSecret(format, suite int16, keyVersion int32, iv, tag, ciphertext []byte) string {
+	if format == legacySecretFormat {
+		return string(ciphertext)
+	}
+	return storedEnvelope(format, suite, keyVersion, iv, tag, ciphertext).String()
+}
+
+func storedEnvelope(format, suite int16, keyVersion int32, iv, tag, ciphertext []byte) *entities.SecretEnvelope {
+	return &entities.SecretEnvelope{
+		Format:     int(format),
+		Suite:      entities.CipherSuite(suite),
+		KeyVersion: int(keyVersion),
+		IV:         iv,
+		AuthTag:    tag,
+		Ciphertext: ciphertext,
+	}
+}
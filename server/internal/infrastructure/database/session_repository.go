@@ -0,0 +1,204 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type sessionRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewSessionRepository creates a new sign-in session repository
+func NewSessionRepository(database *DB) interfaces.SessionRepository {
+	return &sessionRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create stores a session and fills in its ID and timestamps
+func (r *sessionRepository) Create(ctx context.Context, session *entities.DeviceSession) error {
+	row, err := r.queries.CreateUserSession(ctx, db.CreateUserSessionParams{
+		UserID:     convertUUIDToPG(session.UserID),
+		DeviceID:   pgtype.Text{String: session.DeviceID, Valid: session.DeviceID != ""},
+		DeviceName: session.DeviceName,
+		IpAddress:  parseSessionIP(session.IPAddress),
+		UserAgent:  pgtype.Text{String: session.UserAgent, Valid: session.UserAgent != ""},
+		ExpiresAt:  pgtype.Timestamptz{Time: session.ExpiresAt, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.ID = convertPGUUID(row.ID)
+	session.CreatedAt = convertPGTimestamp(row.CreatedAt)
+	session.LastActivityAt = convertPGTimestamp(row.LastActivityAt)
+
+	return nil
+}
+
+// GetByID retrieves one of the user's sessions
+func (r *sessionRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*entities.DeviceSession, error) {
+	row, err := r.queries.GetUserSession(ctx, db.GetUserSessionParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return convertToDeviceSession(row), nil
+}
+
+// List returns the user's live sessions, most recently active first
+func (r *sessionRepository) List(ctx context.Context, userID uuid.UUID, idleCutoff time.Time) ([]*entities.DeviceSession, error) {
+	rows, err := r.queries.ListUserSessions(ctx, db.ListUserSessionsParams{
+		UserID:     convertUUIDToPG(userID),
+		IdleCutoff: pgtype.Timestamptz{Time: idleCutoff, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]*entities.DeviceSession, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, convertToDeviceSession(row))
+	}
+
+	return sessions, nil
+}
+
+// Touch records activity on a session
+func (r *sessionRepository) Touch(ctx context.Context, userID, id uuid.UUID, ipAddress string) error {
+	if err := r.queries.TouchUserSession(ctx, db.TouchUserSessionParams{
+		ID:        convertUUIDToPG(id),
+		UserID:    convertUUIDToPG(userID),
+		IpAddress: parseSessionIP(ipAddress),
+	}); err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+// Rename changes a session's device name
+func (r *sessionRepository) Rename(ctx context.Context, userID, id uuid.UUID, name string) error {
+	rows, err := r.queries.RenameUserSession(ctx, db.RenameUserSessionParams{
+		ID:         convertUUIDToPG(id),
+		UserID:     convertUUIDToPG(userID),
+		DeviceName: name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename session: %w", err)
+	}
+	if rows == 0 {
+		return entities.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// Delete revokes one session
+func (r *sessionRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	rows, err := r.queries.DeleteUserSession(ctx, db.DeleteUserSessionParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if rows == 0 {
+		return entities.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteOthers revokes every session of the user except keep
+func (r *sessionRepository) DeleteOthers(ctx context.Context, userID, keep uuid.UUID) ([]uuid.UUID, error) {
+	ids, err := r.queries.DeleteOtherUserSessions(ctx, db.DeleteOtherUserSessionsParams{
+		UserID: convertUUIDToPG(userID),
+		ID:     convertUUIDToPG(keep),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return convertPGUUIDs(ids), nil
+}
+
+// DeleteAll revokes every session of the user
+func (r *sessionRepository) DeleteAll(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	ids, err := r.queries.DeleteAllUserSessions(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	return convertPGUUIDs(ids), nil
+}
+
+// DeleteExpired removes the user's timed-out sessions
+func (r *sessionRepository) DeleteExpired(ctx context.Context, userID uuid.UUID, idleCutoff time.Time) error {
+	if err := r.queries.DeleteExpiredUserSessions(ctx, db.DeleteExpiredUserSessionsParams{
+		UserID:     convertUUIDToPG(userID),
+		IdleCutoff: pgtype.Timestamptz{Time: idleCutoff, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return nil
+}
+
+// parseSessionIP converts a client address to its column value, NULL if it does not parse
+func parseSessionIP(ipAddress string) *netip.Addr {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return nil
+	}
+	addr = addr.WithZone("")
+	return &addr
+}
+
+// convertPGUUIDs converts a list of database UUIDs
+func convertPGUUIDs(ids []pgtype.UUID) []uuid.UUID {
+	converted := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		converted = append(converted, convertPGUUID(id))
+	}
+	return converted
+}
+
+// convertToDeviceSession converts a database session to a domain session
+func convertToDeviceSession(row db.UserSession) *entities.DeviceSession {
+	session := &entities.DeviceSession{
+		ID:             convertPGUUID(row.ID),
+		UserID:         convertPGUUID(row.UserID),
+		DeviceID:       row.DeviceID.String,
+		DeviceName:     row.DeviceName,
+		UserAgent:      row.UserAgent.String,
+		LastActivityAt: convertPGTimestamp(row.LastActivityAt),
+		ExpiresAt:      convertPGTimestamp(row.ExpiresAt),
+		CreatedAt:      convertPGTimestamp(row.CreatedAt),
+	}
+	if row.IpAddress != nil {
+		session.IPAddress = row.IpAddress.String()
+	}
+
+	return session
+}
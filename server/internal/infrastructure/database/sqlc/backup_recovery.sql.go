@@ -14,25 +14,33 @@ import (
 
 const createAuditLog = `-- name: CreateAuditLog :one
 INSERT INTO audit_logs (
-    user_id, action, resource_type, resource_id,
-    metadata, ip_address, user_agent
+    id, user_id, action, resource_type, resource_id,
+    metadata, ip_address, user_agent, request_id,
+    timestamp, sequence, prev_hash, hash
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp, sequence, request_id, prev_hash, hash
 `
 
 type CreateAuditLogParams struct {
-	UserID       pgtype.UUID `json:"user_id"`
-	Action       string      `json:"action"`
-	ResourceType string      `json:"resource_type"`
-	ResourceID   pgtype.UUID `json:"resource_id"`
-	Metadata     []byte      `json:"metadata"`
-	IpAddress    *netip.Addr `json:"ip_address"`
-	UserAgent    pgtype.Text `json:"user_agent"`
+	ID           pgtype.UUID        `json:"id"`
+	UserID       pgtype.UUID        `json:"user_id"`
+	Action       string             `json:"action"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   pgtype.UUID        `json:"resource_id"`
+	Metadata     []byte             `json:"metadata"`
+	IpAddress    *netip.Addr        `json:"ip_address"`
+	UserAgent    pgtype.Text        `json:"user_agent"`
+	RequestID    pgtype.Text        `json:"request_id"`
+	Timestamp    pgtype.Timestamptz `json:"timestamp"`
+	Sequence     pgtype.Int8        `json:"sequence"`
+	PrevHash     []byte             `json:"prev_hash"`
+	Hash         []byte             `json:"hash"`
 }
 
 func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error) {
 	row := q.db.QueryRow(ctx, createAuditLog,
+		arg.ID,
 		arg.UserID,
 		arg.Action,
 		arg.ResourceType,
@@ -40,6 +48,11 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 		arg.Metadata,
 		arg.IpAddress,
 		arg.UserAgent,
+		arg.RequestID,
+		arg.Timestamp,
+		arg.Sequence,
+		arg.PrevHash,
+		arg.Hash,
 	)
 	var i AuditLog
 	err := row.Scan(
@@ -52,24 +65,34 @@ func (q *Queries) CreateAuditLog(ctx context.Context, arg CreateAuditLogParams)
 		&i.IpAddress,
 		&i.UserAgent,
 		&i.Timestamp,
+		&i.Sequence,
+		&i.RequestID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
 const createBackupRecoveryCode = `-- name: CreateBackupRecoveryCode :one
-INSERT INTO backup_recovery_codes (user_id, encrypted_backup_data, recovery_code_hash)
-VALUES ($1, $2, $3)
-RETURNING id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at
+INSERT INTO backup_recovery_codes (user_id, encrypted_backup_data, recovery_code_hash, key_version)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at, key_version, failed_attempts, locked_until
 `
 
 type CreateBackupRecoveryCodeParams struct {
 	UserID              pgtype.UUID `json:"user_id"`
 	EncryptedBackupData []byte      `json:"encrypted_backup_data"`
 	RecoveryCodeHash    []byte      `json:"recovery_code_hash"`
+	KeyVersion          int32       `json:"key_version"`
 }
 
 func (q *Queries) CreateBackupRecoveryCode(ctx context.Context, arg CreateBackupRecoveryCodeParams) (BackupRecoveryCode, error) {
-	row := q.db.QueryRow(ctx, createBackupRecoveryCode, arg.UserID, arg.EncryptedBackupData, arg.RecoveryCodeHash)
+	row := q.db.QueryRow(ctx, createBackupRecoveryCode,
+		arg.UserID,
+		arg.EncryptedBackupData,
+		arg.RecoveryCodeHash,
+		arg.KeyVersion,
+	)
 	var i BackupRecoveryCode
 	err := row.Scan(
 		&i.ID,
@@ -79,12 +102,15 @@ func (q *Queries) CreateBackupRecoveryCode(ctx context.Context, arg CreateBackup
 		&i.IsUsed,
 		&i.CreatedAt,
 		&i.UsedAt,
+		&i.KeyVersion,
+		&i.FailedAttempts,
+		&i.LockedUntil,
 	)
 	return i, err
 }
 
 const getActiveBackupRecoveryCode = `-- name: GetActiveBackupRecoveryCode :one
-SELECT id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at FROM backup_recovery_codes
+SELECT id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at, key_version, failed_attempts, locked_until FROM backup_recovery_codes
 WHERE user_id = $1 AND is_used = FALSE
 ORDER BY created_at DESC
 LIMIT 1
@@ -101,12 +127,81 @@ func (q *Queries) GetActiveBackupRecoveryCode(ctx context.Context, userID pgtype
 		&i.IsUsed,
 		&i.CreatedAt,
 		&i.UsedAt,
+		&i.KeyVersion,
+		&i.FailedAttempts,
+		&i.LockedUntil,
 	)
 	return i, err
 }
 
+const getAuditChainHead = `-- name: GetAuditChainHead :one
+SELECT sequence, hash FROM audit_logs
+WHERE sequence IS NOT NULL
+ORDER BY sequence DESC
+LIMIT 1
+`
+
+type GetAuditChainHeadRow struct {
+	Sequence pgtype.Int8 `json:"sequence"`
+	Hash     []byte      `json:"hash"`
+}
+
+func (q *Queries) GetAuditChainHead(ctx context.Context) (GetAuditChainHeadRow, error) {
+	row := q.db.QueryRow(ctx, getAuditChainHead)
+	var i GetAuditChainHeadRow
+	err := row.Scan(&i.Sequence, &i.Hash)
+	return i, err
+}
+
+const getAuditLogChain = `-- name: GetAuditLogChain :many
+SELECT id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp, sequence, request_id, prev_hash, hash FROM audit_logs
+WHERE sequence > $1
+ORDER BY sequence
+LIMIT $2
+`
+
+type GetAuditLogChainParams struct {
+	Sequence pgtype.Int8 `json:"sequence"`
+	Limit    int32       `json:"limit"`
+}
+
+// Walks the chain in order, for verification
+func (q *Queries) GetAuditLogChain(ctx context.Context, arg GetAuditLogChainParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, getAuditLogChain, arg.Sequence, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Metadata,
+			&i.IpAddress,
+			&i.UserAgent,
+			&i.Timestamp,
+			&i.Sequence,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAuditLogsByAction = `-- name: GetAuditLogsByAction :many
-SELECT id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp FROM audit_logs
+SELECT id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp, sequence, request_id, prev_hash, hash FROM audit_logs
 WHERE action = $1
     AND timestamp >= $2
     AND timestamp <= $3
@@ -147,6 +242,10 @@ func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByAc
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.Timestamp,
+			&i.Sequence,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -159,20 +258,37 @@ func (q *Queries) GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByAc
 }
 
 const getAuditLogsByUserID = `-- name: GetAuditLogsByUserID :many
-SELECT id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp FROM audit_logs
+SELECT id, user_id, action, resource_type, resource_id, metadata, ip_address, user_agent, timestamp, sequence, request_id, prev_hash, hash FROM audit_logs
 WHERE user_id = $1
-ORDER BY timestamp DESC
-LIMIT $2 OFFSET $3
+    AND sequence IS NOT NULL
+    AND ($2::bigint IS NULL OR sequence < $2::bigint)
+    AND ($3::text IS NULL OR action = $3::text OR action LIKE $3::text || '.%')
+    AND ($4::timestamptz IS NULL OR timestamp >= $4::timestamptz)
+    AND ($5::timestamptz IS NULL OR timestamp < $5::timestamptz)
+ORDER BY sequence DESC
+LIMIT $6
 `
 
 type GetAuditLogsByUserIDParams struct {
-	UserID pgtype.UUID `json:"user_id"`
-	Limit  int32       `json:"limit"`
-	Offset int32       `json:"offset"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	BeforeSequence pgtype.Int8        `json:"before_sequence"`
+	Action         pgtype.Text        `json:"action"`
+	Since          pgtype.Timestamptz `json:"since"`
+	Until          pgtype.Timestamptz `json:"until"`
+	PageLimit      int32              `json:"page_limit"`
 }
 
+// Pages a user's activity newest first, before the cursor's sequence when one is given. The action
+// filter matches an action or, given a category such as 'auth', every action in it.
 func (q *Queries) GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUserIDParams) ([]AuditLog, error) {
-	rows, err := q.db.Query(ctx, getAuditLogsByUserID, arg.UserID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getAuditLogsByUserID,
+		arg.UserID,
+		arg.BeforeSequence,
+		arg.Action,
+		arg.Since,
+		arg.Until,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +306,10 @@ func (q *Queries) GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUs
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.Timestamp,
+			&i.Sequence,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -202,7 +322,7 @@ func (q *Queries) GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUs
 }
 
 const getBackupRecoveryCodeByID = `-- name: GetBackupRecoveryCodeByID :one
-SELECT id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at FROM backup_recovery_codes
+SELECT id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at, key_version, failed_attempts, locked_until FROM backup_recovery_codes
 WHERE id = $1 AND user_id = $2 AND is_used = FALSE
 `
 
@@ -222,12 +342,15 @@ func (q *Queries) GetBackupRecoveryCodeByID(ctx context.Context, arg GetBackupRe
 		&i.IsUsed,
 		&i.CreatedAt,
 		&i.UsedAt,
+		&i.KeyVersion,
+		&i.FailedAttempts,
+		&i.LockedUntil,
 	)
 	return i, err
 }
 
 const getRecentAuditLogs = `-- name: GetRecentAuditLogs :many
-SELECT al.id, al.user_id, al.action, al.resource_type, al.resource_id, al.metadata, al.ip_address, al.user_agent, al.timestamp, u.username, u.email
+SELECT al.id, al.user_id, al.action, al.resource_type, al.resource_id, al.metadata, al.ip_address, al.user_agent, al.timestamp, al.sequence, al.request_id, al.prev_hash, al.hash, u.username, u.email
 FROM audit_logs al
 LEFT JOIN users u ON al.user_id = u.id
 WHERE al.timestamp >= $1
@@ -251,6 +374,10 @@ type GetRecentAuditLogsRow struct {
 	IpAddress    *netip.Addr        `json:"ip_address"`
 	UserAgent    pgtype.Text        `json:"user_agent"`
 	Timestamp    pgtype.Timestamptz `json:"timestamp"`
+	Sequence     pgtype.Int8        `json:"sequence"`
+	RequestID    pgtype.Text        `json:"request_id"`
+	PrevHash     []byte             `json:"prev_hash"`
+	Hash         []byte             `json:"hash"`
 	Username     pgtype.Text        `json:"username"`
 	Email        pgtype.Text        `json:"email"`
 }
@@ -274,6 +401,10 @@ func (q *Queries) GetRecentAuditLogs(ctx context.Context, arg GetRecentAuditLogs
 			&i.IpAddress,
 			&i.UserAgent,
 			&i.Timestamp,
+			&i.Sequence,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
 			&i.Username,
 			&i.Email,
 		); err != nil {
@@ -287,18 +418,102 @@ func (q *Queries) GetRecentAuditLogs(ctx context.Context, arg GetRecentAuditLogs
 	return items, nil
 }
 
-const useBackupRecoveryCode = `-- name: UseBackupRecoveryCode :exec
+const invalidateBackupRecoveryCodes = `-- name: InvalidateBackupRecoveryCodes :exec
 UPDATE backup_recovery_codes
 SET used_at = NOW(), is_used = TRUE
+WHERE user_id = $1 AND is_used = FALSE
+`
+
+// Retires the user's usable kit when it is replaced
+func (q *Queries) InvalidateBackupRecoveryCodes(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, invalidateBackupRecoveryCodes, userID)
+	return err
+}
+
+const lockAuditChain = `-- name: LockAuditChain :exec
+SELECT pg_advisory_xact_lock(hashtext('audit_logs'))
+`
+
+// Serializes appends to the audit chain until the transaction ends
+func (q *Queries) LockAuditChain(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, lockAuditChain)
+	return err
+}
+
+const recordBackupRecoveryAttempt = `-- name: RecordBackupRecoveryAttempt :one
+UPDATE backup_recovery_codes
+SET failed_attempts = CASE WHEN failed_attempts + 1 >= $1::int THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= $1::int THEN $2::timestamptz ELSE NULL END
+WHERE id = $3 AND user_id = $4 AND is_used = FALSE
+    AND (locked_until IS NULL OR locked_until <= NOW())
+RETURNING id, user_id, encrypted_backup_data, recovery_code_hash, is_used, created_at, used_at, key_version, failed_attempts, locked_until
+`
+
+type RecordBackupRecoveryAttemptParams struct {
+	MaxAttempts int32              `json:"max_attempts"`
+	LockedUntil pgtype.Timestamptz `json:"locked_until"`
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+}
+
+// Counts a redemption attempt against an unlocked kit before its code is checked, so parallel
+// guesses cannot outrun the limit. The attempt that reaches the limit locks the kit until
+// locked_until and starts the count over.
+func (q *Queries) RecordBackupRecoveryAttempt(ctx context.Context, arg RecordBackupRecoveryAttemptParams) (BackupRecoveryCode, error) {
+	row := q.db.QueryRow(ctx, recordBackupRecoveryAttempt,
+		arg.MaxAttempts,
+		arg.LockedUntil,
+		arg.ID,
+		arg.UserID,
+	)
+	var i BackupRecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.EncryptedBackupData,
+		&i.RecoveryCodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.UsedAt,
+		&i.KeyVersion,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
+const resetBackupRecoveryAttempts = `-- name: ResetBackupRecoveryAttempts :exec
+UPDATE backup_recovery_codes
+SET failed_attempts = 0, locked_until = NULL
 WHERE id = $1 AND user_id = $2
 `
 
-type UseBackupRecoveryCodeParams struct {
+type ResetBackupRecoveryAttemptsParams struct {
 	ID     pgtype.UUID `json:"id"`
 	UserID pgtype.UUID `json:"user_id"`
 }
 
-func (q *Queries) UseBackupRecoveryCode(ctx context.Context, arg UseBackupRecoveryCodeParams) error {
-	_, err := q.db.Exec(ctx, useBackupRecoveryCode, arg.ID, arg.UserID)
+// Clears the attempt count and lock once the right code is presented
+func (q *Queries) ResetBackupRecoveryAttempts(ctx context.Context, arg ResetBackupRecoveryAttemptsParams) error {
+	_, err := q.db.Exec(ctx, resetBackupRecoveryAttempts, arg.ID, arg.UserID)
 	return err
 }
+
+const useBackupRecoveryCode = `-- name: UseBackupRecoveryCode :execrows
+UPDATE backup_recovery_codes
+SET used_at = NOW(), is_used = TRUE
+WHERE id = $1 AND user_id = $2 AND is_used = FALSE
+`
+
+type UseBackupRecoveryCodeParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) UseBackupRecoveryCode(ctx context.Context, arg UseBackupRecoveryCodeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, useBackupRecoveryCode, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -18,7 +18,7 @@ INSERT INTO device_sessions (
 VALUES ($1, $2, $3)
 ON CONFLICT (user_id, device_fingerprint)
 DO UPDATE SET
-    device_name = EXCLUDED.device_name,
+    device_name = COALESCE(EXCLUDED.device_name, device_sessions.device_name),
     last_sync_at = NOW()
 RETURNING id, user_id, device_fingerprint, device_name, last_sync_at, created_at
 `
@@ -43,45 +43,46 @@ func (q *Queries) CreateDeviceSession(ctx context.Context, arg CreateDeviceSessi
 	return i, err
 }
 
-const createSyncOperation = `-- name: CreateSyncOperation :one
+const createTOTPSeedSyncOperations = `-- name: CreateTOTPSeedSyncOperations :execrows
+WITH allocated AS (
+    INSERT INTO sync_sequences (user_id, last_seq)
+    VALUES ($1, CARDINALITY($2::uuid[]))
+    ON CONFLICT (user_id) DO UPDATE
+    SET last_seq = sync_sequences.last_seq + EXCLUDED.last_seq
+    RETURNING last_seq
+)
 INSERT INTO sync_operations (
     user_id, operation_type, entity_type, entity_id,
-    operation_data, device_fingerprint
+    operation_data, device_fingerprint, seq
 )
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, operation_type, entity_type, entity_id, operation_data, device_fingerprint, timestamp
+SELECT $1, $3, 'totp_seed', e.id,
+    JSONB_BUILD_OBJECT('revision', s.revision), $4,
+    a.last_seq - CARDINALITY($2::uuid[]) + e.ord
+FROM allocated a
+CROSS JOIN UNNEST($2::uuid[]) WITH ORDINALITY AS e(id, ord)
+LEFT JOIN encrypted_totp_seeds s ON s.id = e.id
 `
 
-type CreateSyncOperationParams struct {
-	UserID            pgtype.UUID `json:"user_id"`
-	OperationType     string      `json:"operation_type"`
-	EntityType        string      `json:"entity_type"`
-	EntityID          pgtype.UUID `json:"entity_id"`
-	OperationData     []byte      `json:"operation_data"`
-	DeviceFingerprint string      `json:"device_fingerprint"`
+type CreateTOTPSeedSyncOperationsParams struct {
+	UserID            pgtype.UUID   `json:"user_id"`
+	EntityIds         []pgtype.UUID `json:"entity_ids"`
+	OperationType     string        `json:"operation_type"`
+	DeviceFingerprint string        `json:"device_fingerprint"`
 }
 
-func (q *Queries) CreateSyncOperation(ctx context.Context, arg CreateSyncOperationParams) (SyncOperation, error) {
-	row := q.db.QueryRow(ctx, createSyncOperation,
+// Logs one operation per entry under consecutive sequence numbers. Allocating them row-locks the
+// user's sequence until the transaction ends, so this must be the transaction's last write.
+func (q *Queries) CreateTOTPSeedSyncOperations(ctx context.Context, arg CreateTOTPSeedSyncOperationsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createTOTPSeedSyncOperations,
 		arg.UserID,
+		arg.EntityIds,
 		arg.OperationType,
-		arg.EntityType,
-		arg.EntityID,
-		arg.OperationData,
 		arg.DeviceFingerprint,
 	)
-	var i SyncOperation
-	err := row.Scan(
-		&i.ID,
-		&i.UserID,
-		&i.OperationType,
-		&i.EntityType,
-		&i.EntityID,
-		&i.OperationData,
-		&i.DeviceFingerprint,
-		&i.Timestamp,
-	)
-	return i, err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getActiveDeviceSessionsByUserID = `-- name: GetActiveDeviceSessionsByUserID :many
@@ -155,16 +156,17 @@ func (q *Queries) GetLatestSyncTimestamp(ctx context.Context, userID pgtype.UUID
 }
 
 const getSyncOperationsSince = `-- name: GetSyncOperationsSince :many
-SELECT so.id, so.user_id, so.operation_type, so.entity_type, so.entity_id, so.operation_data, so.device_fingerprint, so.timestamp, ds.device_name
+SELECT so.id, so.user_id, so.operation_type, so.entity_type, so.entity_id, so.operation_data, so.device_fingerprint, so.timestamp, so.seq, ds.device_name
 FROM sync_operations so
 LEFT JOIN device_sessions ds ON so.device_fingerprint = ds.device_fingerprint AND so.user_id = ds.user_id
-WHERE so.user_id = $1 AND so.timestamp > $2
-ORDER BY so.timestamp ASC
+WHERE so.user_id = $1 AND so.seq > $2 AND so.seq <= $3
+ORDER BY so.seq ASC
 `
 
 type GetSyncOperationsSinceParams struct {
-	UserID    pgtype.UUID        `json:"user_id"`
-	Timestamp pgtype.Timestamptz `json:"timestamp"`
+	UserID   pgtype.UUID `json:"user_id"`
+	AfterSeq int64       `json:"after_seq"`
+	UntilSeq int64       `json:"until_seq"`
 }
 
 type GetSyncOperationsSinceRow struct {
@@ -176,11 +178,12 @@ type GetSyncOperationsSinceRow struct {
 	OperationData     []byte             `json:"operation_data"`
 	DeviceFingerprint string             `json:"device_fingerprint"`
 	Timestamp         pgtype.Timestamptz `json:"timestamp"`
+	Seq               int64              `json:"seq"`
 	DeviceName        pgtype.Text        `json:"device_name"`
 }
 
 func (q *Queries) GetSyncOperationsSince(ctx context.Context, arg GetSyncOperationsSinceParams) ([]GetSyncOperationsSinceRow, error) {
-	rows, err := q.db.Query(ctx, getSyncOperationsSince, arg.UserID, arg.Timestamp)
+	rows, err := q.db.Query(ctx, getSyncOperationsSince, arg.UserID, arg.AfterSeq, arg.UntilSeq)
 	if err != nil {
 		return nil, err
 	}
@@ -197,6 +200,7 @@ func (q *Queries) GetSyncOperationsSince(ctx context.Context, arg GetSyncOperati
 			&i.OperationData,
 			&i.DeviceFingerprint,
 			&i.Timestamp,
+			&i.Seq,
 			&i.DeviceName,
 		); err != nil {
 			return nil, err
@@ -209,6 +213,20 @@ func (q *Queries) GetSyncOperationsSince(ctx context.Context, arg GetSyncOperati
 	return items, nil
 }
 
+const getSyncSequence = `-- name: GetSyncSequence :one
+SELECT COALESCE(
+    (SELECT last_seq FROM sync_sequences WHERE user_id = $1),
+    0
+)::bigint AS last_seq
+`
+
+func (q *Queries) GetSyncSequence(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getSyncSequence, userID)
+	var last_seq int64
+	err := row.Scan(&last_seq)
+	return last_seq, err
+}
+
 const updateDeviceSessionLastSync = `-- name: UpdateDeviceSessionLastSync :exec
 UPDATE device_sessions
 SET last_sync_at = NOW()
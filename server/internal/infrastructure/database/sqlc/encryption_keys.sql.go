@@ -11,10 +11,26 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const activateUserEncryptionKeys = `-- name: ActivateUserEncryptionKeys :exec
+UPDATE user_encryption_keys
+SET is_active = TRUE
+WHERE user_id = $1 AND key_version = $2
+`
+
+type ActivateUserEncryptionKeysParams struct {
+	UserID     pgtype.UUID `json:"user_id"`
+	KeyVersion int32       `json:"key_version"`
+}
+
+func (q *Queries) ActivateUserEncryptionKeys(ctx context.Context, arg ActivateUserEncryptionKeysParams) error {
+	_, err := q.db.Exec(ctx, activateUserEncryptionKeys, arg.UserID, arg.KeyVersion)
+	return err
+}
+
 const createUserEncryptionKey = `-- name: CreateUserEncryptionKey :one
-INSERT INTO user_encryption_keys (user_id, webauthn_credential_id, encrypted_dek, key_version)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at
+INSERT INTO user_encryption_keys (user_id, webauthn_credential_id, encrypted_dek, key_version, salt, is_active)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active
 `
 
 type CreateUserEncryptionKeyParams struct {
@@ -22,6 +38,8 @@ type CreateUserEncryptionKeyParams struct {
 	WebauthnCredentialID pgtype.UUID `json:"webauthn_credential_id"`
 	EncryptedDek         []byte      `json:"encrypted_dek"`
 	KeyVersion           int32       `json:"key_version"`
+	Salt                 []byte      `json:"salt"`
+	IsActive             bool        `json:"is_active"`
 }
 
 func (q *Queries) CreateUserEncryptionKey(ctx context.Context, arg CreateUserEncryptionKeyParams) (UserEncryptionKey, error) {
@@ -30,6 +48,8 @@ func (q *Queries) CreateUserEncryptionKey(ctx context.Context, arg CreateUserEnc
 		arg.WebauthnCredentialID,
 		arg.EncryptedDek,
 		arg.KeyVersion,
+		arg.Salt,
+		arg.IsActive,
 	)
 	var i UserEncryptionKey
 	err := row.Scan(
@@ -39,13 +59,47 @@ func (q *Queries) CreateUserEncryptionKey(ctx context.Context, arg CreateUserEnc
 		&i.EncryptedDek,
 		&i.KeyVersion,
 		&i.CreatedAt,
+		&i.Salt,
+		&i.IsActive,
 	)
 	return i, err
 }
 
+const deactivateOldUserEncryptionKeys = `-- name: DeactivateOldUserEncryptionKeys :exec
+UPDATE user_encryption_keys
+SET is_active = FALSE
+WHERE user_id = $1 AND key_version < $2
+`
+
+type DeactivateOldUserEncryptionKeysParams struct {
+	UserID     pgtype.UUID `json:"user_id"`
+	KeyVersion int32       `json:"key_version"`
+}
+
+func (q *Queries) DeactivateOldUserEncryptionKeys(ctx context.Context, arg DeactivateOldUserEncryptionKeysParams) error {
+	_, err := q.db.Exec(ctx, deactivateOldUserEncryptionKeys, arg.UserID, arg.KeyVersion)
+	return err
+}
+
+const deleteInactiveUserEncryptionKeys = `-- name: DeleteInactiveUserEncryptionKeys :exec
+DELETE FROM user_encryption_keys
+WHERE user_id = $1 AND key_version = $2 AND is_active = FALSE
+`
+
+type DeleteInactiveUserEncryptionKeysParams struct {
+	UserID     pgtype.UUID `json:"user_id"`
+	KeyVersion int32       `json:"key_version"`
+}
+
+// Discards the wraps of a key version that never became active
+func (q *Queries) DeleteInactiveUserEncryptionKeys(ctx context.Context, arg DeleteInactiveUserEncryptionKeysParams) error {
+	_, err := q.db.Exec(ctx, deleteInactiveUserEncryptionKeys, arg.UserID, arg.KeyVersion)
+	return err
+}
+
 const getActiveUserEncryptionKey = `-- name: GetActiveUserEncryptionKey :one
-SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at FROM user_encryption_keys
-WHERE user_id = $1
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
 ORDER BY key_version DESC
 LIMIT 1
 `
@@ -60,12 +114,90 @@ func (q *Queries) GetActiveUserEncryptionKey(ctx context.Context, userID pgtype.
 		&i.EncryptedDek,
 		&i.KeyVersion,
 		&i.CreatedAt,
+		&i.Salt,
+		&i.IsActive,
 	)
 	return i, err
 }
 
+const getActiveUserEncryptionKeyByCredential = `-- name: GetActiveUserEncryptionKeyByCredential :one
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
+WHERE user_id = $1 AND webauthn_credential_id = $2 AND is_active = TRUE
+ORDER BY key_version DESC
+LIMIT 1
+`
+
+type GetActiveUserEncryptionKeyByCredentialParams struct {
+	UserID               pgtype.UUID `json:"user_id"`
+	WebauthnCredentialID pgtype.UUID `json:"webauthn_credential_id"`
+}
+
+func (q *Queries) GetActiveUserEncryptionKeyByCredential(ctx context.Context, arg GetActiveUserEncryptionKeyByCredentialParams) (UserEncryptionKey, error) {
+	row := q.db.QueryRow(ctx, getActiveUserEncryptionKeyByCredential, arg.UserID, arg.WebauthnCredentialID)
+	var i UserEncryptionKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.WebauthnCredentialID,
+		&i.EncryptedDek,
+		&i.KeyVersion,
+		&i.CreatedAt,
+		&i.Salt,
+		&i.IsActive,
+	)
+	return i, err
+}
+
+const getActiveUserEncryptionKeys = `-- name: GetActiveUserEncryptionKeys :many
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
+ORDER BY created_at
+`
+
+func (q *Queries) GetActiveUserEncryptionKeys(ctx context.Context, userID pgtype.UUID) ([]UserEncryptionKey, error) {
+	rows, err := q.db.Query(ctx, getActiveUserEncryptionKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserEncryptionKey{}
+	for rows.Next() {
+		var i UserEncryptionKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.WebauthnCredentialID,
+			&i.EncryptedDek,
+			&i.KeyVersion,
+			&i.CreatedAt,
+			&i.Salt,
+			&i.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestUserEncryptionKeyVersion = `-- name: GetLatestUserEncryptionKeyVersion :one
+SELECT COALESCE(MAX(key_version), 0)::int AS key_version FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
+`
+
+// 0 when the user has no active key yet
+func (q *Queries) GetLatestUserEncryptionKeyVersion(ctx context.Context, userID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, getLatestUserEncryptionKeyVersion, userID)
+	var key_version int32
+	err := row.Scan(&key_version)
+	return key_version, err
+}
+
 const getUserEncryptionKeyByCredential = `-- name: GetUserEncryptionKeyByCredential :one
-SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at FROM user_encryption_keys
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
 WHERE user_id = $1 AND webauthn_credential_id = $2
 ORDER BY key_version DESC
 LIMIT 1
@@ -86,12 +218,14 @@ func (q *Queries) GetUserEncryptionKeyByCredential(ctx context.Context, arg GetU
 		&i.EncryptedDek,
 		&i.KeyVersion,
 		&i.CreatedAt,
+		&i.Salt,
+		&i.IsActive,
 	)
 	return i, err
 }
 
 const getUserEncryptionKeyByVersion = `-- name: GetUserEncryptionKeyByVersion :one
-SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at FROM user_encryption_keys
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
 WHERE user_id = $1 AND key_version = $2
 `
 
@@ -110,12 +244,14 @@ func (q *Queries) GetUserEncryptionKeyByVersion(ctx context.Context, arg GetUser
 		&i.EncryptedDek,
 		&i.KeyVersion,
 		&i.CreatedAt,
+		&i.Salt,
+		&i.IsActive,
 	)
 	return i, err
 }
 
 const getUserEncryptionKeys = `-- name: GetUserEncryptionKeys :many
-SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at FROM user_encryption_keys
+SELECT id, user_id, webauthn_credential_id, encrypted_dek, key_version, created_at, salt, is_active FROM user_encryption_keys
 WHERE user_id = $1
 ORDER BY key_version DESC
 `
@@ -136,6 +272,8 @@ func (q *Queries) GetUserEncryptionKeys(ctx context.Context, userID pgtype.UUID)
 			&i.EncryptedDek,
 			&i.KeyVersion,
 			&i.CreatedAt,
+			&i.Salt,
+			&i.IsActive,
 		); err != nil {
 			return nil, err
 		}
@@ -146,3 +284,58 @@ func (q *Queries) GetUserEncryptionKeys(ctx context.Context, userID pgtype.UUID)
 	}
 	return items, nil
 }
+
+const lockActiveUserEncryptionKeyCredentials = `-- name: LockActiveUserEncryptionKeyCredentials :many
+SELECT webauthn_credential_id FROM user_encryption_keys
+WHERE user_id = $1 AND is_active = TRUE
+FOR UPDATE
+`
+
+// Locks the user's active wraps so concurrent credential deletions cannot remove the last one
+func (q *Queries) LockActiveUserEncryptionKeyCredentials(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, lockActiveUserEncryptionKeyCredentials, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var webauthn_credential_id pgtype.UUID
+		if err := rows.Scan(&webauthn_credential_id); err != nil {
+			return nil, err
+		}
+		items = append(items, webauthn_credential_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateUserEncryptionKey = `-- name: UpdateUserEncryptionKey :execrows
+UPDATE user_encryption_keys
+SET encrypted_dek = $3, salt = $4, is_active = $5
+WHERE id = $1 AND user_id = $2
+`
+
+type UpdateUserEncryptionKeyParams struct {
+	ID           pgtype.UUID `json:"id"`
+	UserID       pgtype.UUID `json:"user_id"`
+	EncryptedDek []byte      `json:"encrypted_dek"`
+	Salt         []byte      `json:"salt"`
+	IsActive     bool        `json:"is_active"`
+}
+
+func (q *Queries) UpdateUserEncryptionKey(ctx context.Context, arg UpdateUserEncryptionKeyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserEncryptionKey,
+		arg.ID,
+		arg.UserID,
+		arg.EncryptedDek,
+		arg.Salt,
+		arg.IsActive,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
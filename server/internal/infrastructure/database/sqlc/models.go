@@ -21,6 +21,10 @@ type AuditLog struct {
 	IpAddress    *netip.Addr        `json:"ip_address"`
 	UserAgent    pgtype.Text        `json:"user_agent"`
 	Timestamp    pgtype.Timestamptz `json:"timestamp"`
+	Sequence     pgtype.Int8        `json:"sequence"`
+	RequestID    pgtype.Text        `json:"request_id"`
+	PrevHash     []byte             `json:"prev_hash"`
+	Hash         []byte             `json:"hash"`
 }
 
 type BackupRecoveryCode struct {
@@ -28,9 +32,12 @@ type BackupRecoveryCode struct {
 	UserID              pgtype.UUID        `json:"user_id"`
 	EncryptedBackupData []byte             `json:"encrypted_backup_data"`
 	RecoveryCodeHash    []byte             `json:"recovery_code_hash"`
-	IsUsed              pgtype.Bool        `json:"is_used"`
+	IsUsed              bool               `json:"is_used"`
 	CreatedAt           pgtype.Timestamptz `json:"created_at"`
 	UsedAt              pgtype.Timestamptz `json:"used_at"`
+	KeyVersion          int32              `json:"key_version"`
+	FailedAttempts      int32              `json:"failed_attempts"`
+	LockedUntil         pgtype.Timestamptz `json:"locked_until"`
 }
 
 type DeviceSession struct {
@@ -56,6 +63,51 @@ type EncryptedTotpSeed struct {
 	IsActive          pgtype.Bool        `json:"is_active"`
 	CreatedAt         pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	Method            string             `json:"method"`
+	Counter           int64              `json:"counter"`
+	OtpType           string             `json:"otp_type"`
+	T0                int64              `json:"t0"`
+	Tags              []string           `json:"tags"`
+	FolderID          pgtype.UUID        `json:"folder_id"`
+	DeletedAt         pgtype.Timestamptz `json:"deleted_at"`
+	Revision          int64              `json:"revision"`
+	KeyVersion        int32              `json:"key_version"`
+	SecretFormat      int16              `json:"secret_format"`
+	CipherSuite       int16              `json:"cipher_suite"`
+	SecretIv          []byte             `json:"secret_iv"`
+	SecretTag         []byte             `json:"secret_tag"`
+}
+
+type Folder struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	ParentID  pgtype.UUID        `json:"parent_id"`
+	Name      string             `json:"name"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type KeyRotation struct {
+	ID          pgtype.UUID        `json:"id"`
+	UserID      pgtype.UUID        `json:"user_id"`
+	FromVersion int32              `json:"from_version"`
+	ToVersion   int32              `json:"to_version"`
+	Status      string             `json:"status"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+}
+
+type KeyRotationEntry struct {
+	RotationID      pgtype.UUID        `json:"rotation_id"`
+	SeedID          pgtype.UUID        `json:"seed_id"`
+	SeedRevision    int64              `json:"seed_revision"`
+	EncryptedSecret []byte             `json:"encrypted_secret"`
+	StagedAt        pgtype.Timestamptz `json:"staged_at"`
+	SecretFormat    int16              `json:"secret_format"`
+	CipherSuite     int16              `json:"cipher_suite"`
+	SecretIv        []byte             `json:"secret_iv"`
+	SecretTag       []byte             `json:"secret_tag"`
 }
 
 type LinkingCode struct {
@@ -69,6 +121,30 @@ type LinkingCode struct {
 	UpdatedAt time.Time          `json:"updated_at"`
 }
 
+type RefreshToken struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	SessionID pgtype.UUID        `json:"session_id"`
+	TokenHash []byte             `json:"token_hash"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type SyncConflict struct {
+	ID            pgtype.UUID        `json:"id"`
+	UserID        pgtype.UUID        `json:"user_id"`
+	SeedID        pgtype.UUID        `json:"seed_id"`
+	DeviceID      pgtype.Text        `json:"device_id"`
+	BaseRevision  int64              `json:"base_revision"`
+	ServerVersion []byte             `json:"server_version"`
+	ClientVersion []byte             `json:"client_version"`
+	Status        string             `json:"status"`
+	Resolution    pgtype.Text        `json:"resolution"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	ResolvedAt    pgtype.Timestamptz `json:"resolved_at"`
+}
+
 type SyncOperation struct {
 	ID                pgtype.UUID        `json:"id"`
 	UserID            pgtype.UUID        `json:"user_id"`
@@ -78,17 +154,63 @@ type SyncOperation struct {
 	OperationData     []byte             `json:"operation_data"`
 	DeviceFingerprint string             `json:"device_fingerprint"`
 	Timestamp         pgtype.Timestamptz `json:"timestamp"`
+	Seq               int64              `json:"seq"`
+}
+
+type SyncSequence struct {
+	UserID  pgtype.UUID `json:"user_id"`
+	LastSeq int64       `json:"last_seq"`
+}
+
+type TotpSeedRevision struct {
+	ID                pgtype.UUID        `json:"id"`
+	SeedID            pgtype.UUID        `json:"seed_id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	Reason            string             `json:"reason"`
+	ServiceName       string             `json:"service_name"`
+	AccountIdentifier string             `json:"account_identifier"`
+	EncryptedSecret   []byte             `json:"encrypted_secret"`
+	Algorithm         string             `json:"algorithm"`
+	Digits            int32              `json:"digits"`
+	Period            int32              `json:"period"`
+	Issuer            pgtype.Text        `json:"issuer"`
+	IconUrl           pgtype.Text        `json:"icon_url"`
+	Method            string             `json:"method"`
+	Counter           int64              `json:"counter"`
+	OtpType           string             `json:"otp_type"`
+	T0                int64              `json:"t0"`
+	Tags              []string           `json:"tags"`
+	FolderID          pgtype.UUID        `json:"folder_id"`
+	DeviceID          pgtype.Text        `json:"device_id"`
+	IpAddress         *netip.Addr        `json:"ip_address"`
+	UserAgent         pgtype.Text        `json:"user_agent"`
+	RequestID         pgtype.Text        `json:"request_id"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	Revision          int64              `json:"revision"`
+	KeyVersion        int32              `json:"key_version"`
+	SecretFormat      int16              `json:"secret_format"`
+	CipherSuite       int16              `json:"cipher_suite"`
+	SecretIv          []byte             `json:"secret_iv"`
+	SecretTag         []byte             `json:"secret_tag"`
+}
+
+type TotpSeedUsage struct {
+	SeedID     pgtype.UUID        `json:"seed_id"`
+	UseCount   int64              `json:"use_count"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
 }
 
 type User struct {
-	ID          pgtype.UUID        `json:"id"`
-	Username    string             `json:"username"`
-	Email       string             `json:"email"`
-	DisplayName string             `json:"display_name"`
-	CreatedAt   pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
-	LastLoginAt pgtype.Timestamptz `json:"last_login_at"`
-	IsActive    pgtype.Bool        `json:"is_active"`
+	ID           pgtype.UUID        `json:"id"`
+	Username     string             `json:"username"`
+	Email        string             `json:"email"`
+	DisplayName  string             `json:"display_name"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	LastLoginAt  pgtype.Timestamptz `json:"last_login_at"`
+	IsActive     pgtype.Bool        `json:"is_active"`
+	Role         string             `json:"role"`
+	TokenVersion int32              `json:"token_version"`
 }
 
 type UserEncryptionKey struct {
@@ -98,6 +220,29 @@ type UserEncryptionKey struct {
 	EncryptedDek         []byte             `json:"encrypted_dek"`
 	KeyVersion           int32              `json:"key_version"`
 	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	Salt                 []byte             `json:"salt"`
+	IsActive             bool               `json:"is_active"`
+}
+
+type UserSession struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	DeviceID       pgtype.Text        `json:"device_id"`
+	DeviceName     string             `json:"device_name"`
+	IpAddress      *netip.Addr        `json:"ip_address"`
+	UserAgent      pgtype.Text        `json:"user_agent"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	LastActivityAt pgtype.Timestamptz `json:"last_activity_at"`
+	ExpiresAt      pgtype.Timestamptz `json:"expires_at"`
+}
+
+type VaultEvent struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Seq       int64              `json:"seq"`
+	EventType string             `json:"event_type"`
+	EntityID  pgtype.UUID        `json:"entity_id"`
+	DeviceID  pgtype.Text        `json:"device_id"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type WebauthnCredential struct {
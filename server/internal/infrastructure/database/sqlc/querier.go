@@ -11,32 +11,102 @@ import (
 )
 
 type Querier interface {
+	ActivateUserEncryptionKeys(ctx context.Context, arg ActivateUserEncryptionKeysParams) error
+	// Swaps in the staged secrets that are still current. The versions they replace are recorded as
+	// revisions first, since the server cannot check the new ciphertext.
+	ApplyKeyRotationEntries(ctx context.Context, arg ApplyKeyRotationEntriesParams) ([]ApplyKeyRotationEntriesRow, error)
+	// Replaces an entry's ciphertext and metadata with a version pushed by a device or chosen to resolve
+	// a conflict, moving it out of the trash if needed. The HOTP method and counter are kept, and a
+	// folder that no longer exists puts the entry at the top level.
+	ApplyTOTPSeedVersion(ctx context.Context, arg ApplyTOTPSeedVersionParams) (EncryptedTotpSeed, error)
+	// Counts the entries ListEncryptedTOTPSeeds matches across all pages
+	CountEncryptedTOTPSeeds(ctx context.Context, arg CountEncryptedTOTPSeedsParams) (int64, error)
+	CountPendingKeyRotationEntries(ctx context.Context, arg CountPendingKeyRotationEntriesParams) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
 	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
 	CreateBackupRecoveryCode(ctx context.Context, arg CreateBackupRecoveryCodeParams) (BackupRecoveryCode, error)
 	CreateDeviceSession(ctx context.Context, arg CreateDeviceSessionParams) (DeviceSession, error)
 	CreateEncryptedTOTPSeed(ctx context.Context, arg CreateEncryptedTOTPSeedParams) (EncryptedTotpSeed, error)
-	CreateSyncOperation(ctx context.Context, arg CreateSyncOperationParams) (SyncOperation, error)
+	CreateEncryptedTOTPSeeds(ctx context.Context, arg []CreateEncryptedTOTPSeedsParams) (int64, error)
+	CreateFolder(ctx context.Context, arg CreateFolderParams) (Folder, error)
+	CreateKeyRotation(ctx context.Context, arg CreateKeyRotationParams) (KeyRotation, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	CreateSyncConflict(ctx context.Context, arg CreateSyncConflictParams) (SyncConflict, error)
+	// Snapshots the current version of active entries before they change, locking the rows until
+	// the change commits so concurrent writers each record the version they replaced
+	CreateTOTPSeedRevisions(ctx context.Context, arg CreateTOTPSeedRevisionsParams) (int64, error)
+	// Logs one operation per entry under consecutive sequence numbers. Allocating them row-locks the
+	// user's sequence until the transaction ends, so this must be the transaction's last write.
+	CreateTOTPSeedSyncOperations(ctx context.Context, arg CreateTOTPSeedSyncOperationsParams) (int64, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateUserEncryptionKey(ctx context.Context, arg CreateUserEncryptionKeyParams) (UserEncryptionKey, error)
+	CreateUserSession(ctx context.Context, arg CreateUserSessionParams) (UserSession, error)
+	// Logs an event under the next sequence number. Like CreateTOTPSeedSyncOperations it row-locks the
+	// user's sequence until the transaction ends, so this must be the transaction's last write.
+	CreateVaultEvent(ctx context.Context, arg CreateVaultEventParams) error
 	CreateWebAuthnCredential(ctx context.Context, arg CreateWebAuthnCredentialParams) (WebauthnCredential, error)
+	DeactivateOldUserEncryptionKeys(ctx context.Context, arg DeactivateOldUserEncryptionKeysParams) error
+	// Suspends the account and revokes its tokens, so reactivating it does not revive them
 	DeactivateUser(ctx context.Context, id pgtype.UUID) error
-	DeleteEncryptedTOTPSeed(ctx context.Context, arg DeleteEncryptedTOTPSeedParams) error
+	DeleteAllUserSessions(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error)
+	// A non-NULL expected_revision makes the delete conditional on the entry still being at that revision
+	DeleteEncryptedTOTPSeed(ctx context.Context, arg DeleteEncryptedTOTPSeedParams) (int64, error)
+	// A non-NULL expected_revision makes each delete conditional on the entry still being at that revision
+	DeleteEncryptedTOTPSeedsBatch(ctx context.Context, arg []DeleteEncryptedTOTPSeedsBatchParams) *DeleteEncryptedTOTPSeedsBatchBatchResults
+	// Expired tokens are refused whether used or not, so they no longer need keeping
+	DeleteExpiredRefreshTokens(ctx context.Context, sessionID pgtype.UUID) error
+	// Drops the user's sessions that timed out, which no token can use any more
+	DeleteExpiredUserSessions(ctx context.Context, arg DeleteExpiredUserSessionsParams) error
+	DeleteFolder(ctx context.Context, arg DeleteFolderParams) (int64, error)
+	// Discards the wraps of a key version that never became active
+	DeleteInactiveUserEncryptionKeys(ctx context.Context, arg DeleteInactiveUserEncryptionKeysParams) error
+	DeleteKeyRotationEntries(ctx context.Context, rotationID pgtype.UUID) error
+	DeleteOtherUserSessions(ctx context.Context, arg DeleteOtherUserSessionsParams) ([]pgtype.UUID, error)
 	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error)
 	DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAuthnCredentialParams) error
+	DeleteWebAuthnCredentialByUUID(ctx context.Context, arg DeleteWebAuthnCredentialByUUIDParams) (int64, error)
+	FinishKeyRotation(ctx context.Context, arg FinishKeyRotationParams) (KeyRotation, error)
 	GetActiveBackupRecoveryCode(ctx context.Context, userID pgtype.UUID) (BackupRecoveryCode, error)
 	GetActiveDeviceSessionsByUserID(ctx context.Context, userID pgtype.UUID) ([]DeviceSession, error)
 	GetActiveUserEncryptionKey(ctx context.Context, userID pgtype.UUID) (UserEncryptionKey, error)
+	GetActiveUserEncryptionKeyByCredential(ctx context.Context, arg GetActiveUserEncryptionKeyByCredentialParams) (UserEncryptionKey, error)
+	GetActiveUserEncryptionKeys(ctx context.Context, userID pgtype.UUID) ([]UserEncryptionKey, error)
+	GetAuditChainHead(ctx context.Context) (GetAuditChainHeadRow, error)
+	// Walks the chain in order, for verification
+	GetAuditLogChain(ctx context.Context, arg GetAuditLogChainParams) ([]AuditLog, error)
 	GetAuditLogsByAction(ctx context.Context, arg GetAuditLogsByActionParams) ([]AuditLog, error)
+	// Pages a user's activity newest first, before the cursor's sequence when one is given. The action
+	// filter matches an action or, given a category such as 'auth', every action in it.
 	GetAuditLogsByUserID(ctx context.Context, arg GetAuditLogsByUserIDParams) ([]AuditLog, error)
 	GetBackupRecoveryCodeByID(ctx context.Context, arg GetBackupRecoveryCodeByIDParams) (BackupRecoveryCode, error)
 	GetDeviceSession(ctx context.Context, arg GetDeviceSessionParams) (DeviceSession, error)
 	GetEncryptedTOTPSeedByID(ctx context.Context, arg GetEncryptedTOTPSeedByIDParams) (EncryptedTotpSeed, error)
+	GetEncryptedTOTPSeedByIDForUpdate(ctx context.Context, arg GetEncryptedTOTPSeedByIDForUpdateParams) (EncryptedTotpSeed, error)
+	// Locks an entry, trashed or not, while a pushed change or conflict resolution is checked against it
+	GetEncryptedTOTPSeedForSync(ctx context.Context, arg GetEncryptedTOTPSeedForSyncParams) (EncryptedTotpSeed, error)
+	// Includes trashed entries, so callers can tell them apart from purged ones
+	GetEncryptedTOTPSeedsByIDs(ctx context.Context, arg GetEncryptedTOTPSeedsByIDsParams) ([]EncryptedTotpSeed, error)
+	// Includes trashed entries, which are encrypted like any other
+	GetEncryptedTOTPSeedsByKeyVersion(ctx context.Context, arg GetEncryptedTOTPSeedsByKeyVersionParams) ([]EncryptedTotpSeed, error)
 	GetEncryptedTOTPSeedsByUserID(ctx context.Context, userID pgtype.UUID) ([]EncryptedTotpSeed, error)
 	GetEncryptedTOTPSeedsByUserIDSince(ctx context.Context, arg GetEncryptedTOTPSeedsByUserIDSinceParams) ([]EncryptedTotpSeed, error)
+	GetFolderByID(ctx context.Context, arg GetFolderByIDParams) (Folder, error)
+	GetInProgressKeyRotation(ctx context.Context, userID pgtype.UUID) (KeyRotation, error)
+	// Serializes staging, commit and abort of the user's rotation
+	GetInProgressKeyRotationForUpdate(ctx context.Context, userID pgtype.UUID) (KeyRotation, error)
+	// Counts the entries below the target version, trashed ones included, and how many of them have a
+	// ciphertext staged from their current revision
+	GetKeyRotationProgress(ctx context.Context, arg GetKeyRotationProgressParams) (GetKeyRotationProgressRow, error)
 	GetLatestSyncTimestamp(ctx context.Context, userID pgtype.UUID) (interface{}, error)
+	// 0 when the user has no active key yet
+	GetLatestUserEncryptionKeyVersion(ctx context.Context, userID pgtype.UUID) (int32, error)
 	GetRecentAuditLogs(ctx context.Context, arg GetRecentAuditLogsParams) ([]GetRecentAuditLogsRow, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash []byte) (RefreshToken, error)
+	GetSyncConflictForUpdate(ctx context.Context, arg GetSyncConflictForUpdateParams) (SyncConflict, error)
 	GetSyncOperationsSince(ctx context.Context, arg GetSyncOperationsSinceParams) ([]GetSyncOperationsSinceRow, error)
+	GetSyncSequence(ctx context.Context, userID pgtype.UUID) (int64, error)
+	GetTOTPSeedRevision(ctx context.Context, arg GetTOTPSeedRevisionParams) (TotpSeedRevision, error)
 	GetTOTPSeedsCountByUser(ctx context.Context, userID pgtype.UUID) (int64, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
@@ -44,19 +114,92 @@ type Querier interface {
 	GetUserEncryptionKeyByCredential(ctx context.Context, arg GetUserEncryptionKeyByCredentialParams) (UserEncryptionKey, error)
 	GetUserEncryptionKeyByVersion(ctx context.Context, arg GetUserEncryptionKeyByVersionParams) (UserEncryptionKey, error)
 	GetUserEncryptionKeys(ctx context.Context, userID pgtype.UUID) ([]UserEncryptionKey, error)
+	GetUserSession(ctx context.Context, arg GetUserSessionParams) (UserSession, error)
+	// Aggregate counts for the admin dashboard; new and signed-in users are counted from since
+	GetUserStats(ctx context.Context, since pgtype.Timestamptz) (GetUserStatsRow, error)
+	// Entry changes come from the sync log and carry its operation; other events carry their type
+	GetVaultEventsSince(ctx context.Context, arg GetVaultEventsSinceParams) ([]GetVaultEventsSinceRow, error)
 	GetWebAuthnCredentialByID(ctx context.Context, credentialID []byte) (WebauthnCredential, error)
 	GetWebAuthnCredentialsByUserID(ctx context.Context, userID pgtype.UUID) ([]WebauthnCredential, error)
+	IncrementTOTPSeedCounter(ctx context.Context, arg IncrementTOTPSeedCounterParams) (int64, error)
+	// Retires the user's usable kit when it is replaced
+	InvalidateBackupRecoveryCodes(ctx context.Context, userID pgtype.UUID) error
+	// Reports whether folder_id is root_id itself or one of its descendants
+	IsFolderInSubtree(ctx context.Context, arg IsFolderInSubtreeParams) (bool, error)
+	// The entries whose staged secret is still current, which committing the rotation swaps in
+	ListCurrentKeyRotationEntries(ctx context.Context, arg ListCurrentKeyRotationEntriesParams) ([]pgtype.UUID, error)
+	// One page of entries, optionally filtered by tag, by folder (including its subfolders) and by a
+	// pg_trgm fuzzy query over issuer, label and tags. Rows are keyed by (sort_text, sort_num, id):
+	// text sorts leave sort_num at 0 and numeric sorts leave sort_text empty, and the key of the last
+	// row is the cursor for the next page. A NULL page_limit returns every remaining row.
+	ListEncryptedTOTPSeeds(ctx context.Context, arg ListEncryptedTOTPSeedsParams) ([]ListEncryptedTOTPSeedsRow, error)
+	ListFoldersByUser(ctx context.Context, userID pgtype.UUID) ([]ListFoldersByUserRow, error)
+	ListOpenSyncConflicts(ctx context.Context, userID pgtype.UUID) ([]SyncConflict, error)
+	// Entries below the target version with nothing staged from their current revision. An entry
+	// changed after it was staged is pending again.
+	ListPendingKeyRotationEntries(ctx context.Context, arg ListPendingKeyRotationEntriesParams) ([]ListPendingKeyRotationEntriesRow, error)
+	ListTagCountsByUser(ctx context.Context, userID pgtype.UUID) ([]ListTagCountsByUserRow, error)
+	// Entry IDs are global, so an import checks which of its IDs any user already has
+	ListTakenTOTPSeedIDs(ctx context.Context, ids []pgtype.UUID) ([]pgtype.UUID, error)
+	ListTOTPSeedRevisions(ctx context.Context, arg ListTOTPSeedRevisionsParams) ([]TotpSeedRevision, error)
+	ListTrashedTOTPSeeds(ctx context.Context, userID pgtype.UUID) ([]EncryptedTotpSeed, error)
+	// The user's live sessions, most recently active first
+	ListUserSessions(ctx context.Context, arg ListUserSessionsParams) ([]UserSession, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
+	// Locks the user's active wraps so concurrent credential deletions cannot remove the last one
+	LockActiveUserEncryptionKeyCredentials(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error)
+	// Serializes appends to the audit chain until the transaction ends
+	LockAuditChain(ctx context.Context) error
+	MoveTOTPSeedsToFolder(ctx context.Context, arg MoveTOTPSeedsToFolderParams) ([]pgtype.UUID, error)
+	PurgeExpiredTOTPSeeds(ctx context.Context, deletedAt pgtype.Timestamptz) ([]PurgeExpiredTOTPSeedsRow, error)
+	// Permanently deletes an entry; only entries already in the trash can be purged
+	PurgeTOTPSeed(ctx context.Context, arg PurgeTOTPSeedParams) (int64, error)
+	ReactivateUser(ctx context.Context, id pgtype.UUID) error
+	// Counts a redemption attempt against an unlocked kit before its code is checked, so parallel
+	// guesses cannot outrun the limit. The attempt that reaches the limit locks the kit until
+	// locked_until and starts the count over.
+	RecordBackupRecoveryAttempt(ctx context.Context, arg RecordBackupRecoveryAttemptParams) (BackupRecoveryCode, error)
+	RecordTOTPSeedUse(ctx context.Context, arg RecordTOTPSeedUseParams) (int64, error)
+	// Renaming onto an existing tag merges the two; duplicates are dropped keeping the first position
+	RenameTOTPSeedTag(ctx context.Context, arg RenameTOTPSeedTagParams) ([]pgtype.UUID, error)
+	RenameUserSession(ctx context.Context, arg RenameUserSessionParams) (int64, error)
+	ReparentFolders(ctx context.Context, arg ReparentFoldersParams) error
+	// Clears the attempt count and lock once the right code is presented
+	ResetBackupRecoveryAttempts(ctx context.Context, arg ResetBackupRecoveryAttemptsParams) error
+	RestoreTOTPSeed(ctx context.Context, arg RestoreTOTPSeedParams) (EncryptedTotpSeed, error)
+	ResolveSyncConflict(ctx context.Context, arg ResolveSyncConflictParams) (SyncConflict, error)
+	// Restores an entry's ciphertext and metadata from one of its revisions. The HOTP counter is
+	// never rewound, and a folder that has since been deleted reverts to the top level.
+	RevertTOTPSeedToRevision(ctx context.Context, arg RevertTOTPSeedToRevisionParams) (EncryptedTotpSeed, error)
+	RevokeUserTokens(ctx context.Context, id pgtype.UUID) error
 	SearchEncryptedTOTPSeeds(ctx context.Context, arg SearchEncryptedTOTPSeedsParams) ([]EncryptedTotpSeed, error)
+	// One page of users, newest first, optionally filtered by a case-insensitive substring of the
+	// username, email or display name, by role and by status. Rows are keyed by (created_at, id), and
+	// the key of the last row is the cursor for the next page.
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
+	// Changes the role and revokes the user's tokens, which carry the old one
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) error
+	// Stages a re-encrypted secret only while the entry is still at the revision it was made from
+	StageKeyRotationEntry(ctx context.Context, arg StageKeyRotationEntryParams) (int64, error)
+	TouchKeyRotation(ctx context.Context, id pgtype.UUID) error
+	TouchUserSession(ctx context.Context, arg TouchUserSessionParams) error
 	UpdateDeviceSessionLastSync(ctx context.Context, arg UpdateDeviceSessionLastSyncParams) error
+	// A non-NULL expected_revision makes the update conditional on the entry still being at that revision
 	UpdateEncryptedTOTPSeed(ctx context.Context, arg UpdateEncryptedTOTPSeedParams) (EncryptedTotpSeed, error)
+	// A non-NULL expected_revision makes each update conditional on the entry still being at that revision
+	UpdateEncryptedTOTPSeedsBatch(ctx context.Context, arg []UpdateEncryptedTOTPSeedsBatchParams) *UpdateEncryptedTOTPSeedsBatchBatchResults
+	UpdateFolder(ctx context.Context, arg UpdateFolderParams) (Folder, error)
+	UpdateTOTPSeedKeyVersion(ctx context.Context, arg UpdateTOTPSeedKeyVersionParams) ([]UpdateTOTPSeedKeyVersionRow, error)
 	UpdateTOTPSeedSyncTimestamp(ctx context.Context, arg UpdateTOTPSeedSyncTimestampParams) error
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserEncryptionKey(ctx context.Context, arg UpdateUserEncryptionKeyParams) (int64, error)
 	UpdateUserLastLogin(ctx context.Context, id pgtype.UUID) error
 	UpdateWebAuthnCredentialCloneWarning(ctx context.Context, arg UpdateWebAuthnCredentialCloneWarningParams) error
 	UpdateWebAuthnCredentialLastUsed(ctx context.Context, credentialID []byte) error
 	UpdateWebAuthnCredentialSignCount(ctx context.Context, arg UpdateWebAuthnCredentialSignCountParams) error
-	UseBackupRecoveryCode(ctx context.Context, arg UseBackupRecoveryCodeParams) error
+	UseBackupRecoveryCode(ctx context.Context, arg UseBackupRecoveryCodeParams) (int64, error)
+	// Marks a token rotated. Only the first of concurrent uses updates the row.
+	UseRefreshToken(ctx context.Context, id pgtype.UUID) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
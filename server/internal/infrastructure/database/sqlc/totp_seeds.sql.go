@@ -11,13 +11,166 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const applyTOTPSeedVersion = `-- name: ApplyTOTPSeedVersion :one
+UPDATE encrypted_totp_seeds s
+SET service_name = $1,
+    account_identifier = $2,
+    encrypted_secret = $3,
+    algorithm = $4,
+    digits = $5,
+    period = $6,
+    issuer = $1,
+    icon_url = $7,
+    otp_type = $8,
+    t0 = $9,
+    tags = $10,
+    folder_id = (SELECT f.id FROM folders f WHERE f.id = $11 AND f.user_id = s.user_id),
+    key_version = $12,
+    secret_format = $13,
+    cipher_suite = $14,
+    secret_iv = $15,
+    secret_tag = $16,
+    is_active = TRUE,
+    deleted_at = NULL,
+    revision = s.revision + 1,
+    updated_at = NOW()
+WHERE s.id = $17 AND s.user_id = $18 AND s.revision = $19
+RETURNING s.id, s.user_id, s.service_name, s.account_identifier, s.encrypted_secret, s.algorithm, s.digits, s.period, s.issuer, s.icon_url, s.is_active, s.created_at, s.updated_at, s.method, s.counter, s.otp_type, s.t0, s.tags, s.folder_id, s.deleted_at, s.revision, s.key_version, s.secret_format, s.cipher_suite, s.secret_iv, s.secret_tag
+`
+
+type ApplyTOTPSeedVersionParams struct {
+	ServiceName       string      `json:"service_name"`
+	AccountIdentifier string      `json:"account_identifier"`
+	EncryptedSecret   []byte      `json:"encrypted_secret"`
+	Algorithm         string      `json:"algorithm"`
+	Digits            int32       `json:"digits"`
+	Period            int32       `json:"period"`
+	IconUrl           pgtype.Text `json:"icon_url"`
+	OtpType           string      `json:"otp_type"`
+	T0                int64       `json:"t0"`
+	Tags              []string    `json:"tags"`
+	FolderID          pgtype.UUID `json:"folder_id"`
+	KeyVersion        int32       `json:"key_version"`
+	SecretFormat      int16       `json:"secret_format"`
+	CipherSuite       int16       `json:"cipher_suite"`
+	SecretIv          []byte      `json:"secret_iv"`
+	SecretTag         []byte      `json:"secret_tag"`
+	ID                pgtype.UUID `json:"id"`
+	UserID            pgtype.UUID `json:"user_id"`
+	ExpectedRevision  int64       `json:"expected_revision"`
+}
+
+// Replaces an entry's ciphertext and metadata with a version pushed by a device or chosen to resolve
+// a conflict, moving it out of the trash if needed. The HOTP method and counter are kept, and a
+// folder that no longer exists puts the entry at the top level.
+func (q *Queries) ApplyTOTPSeedVersion(ctx context.Context, arg ApplyTOTPSeedVersionParams) (EncryptedTotpSeed, error) {
+	row := q.db.QueryRow(ctx, applyTOTPSeedVersion,
+		arg.ServiceName,
+		arg.AccountIdentifier,
+		arg.EncryptedSecret,
+		arg.Algorithm,
+		arg.Digits,
+		arg.Period,
+		arg.IconUrl,
+		arg.OtpType,
+		arg.T0,
+		arg.Tags,
+		arg.FolderID,
+		arg.KeyVersion,
+		arg.SecretFormat,
+		arg.CipherSuite,
+		arg.SecretIv,
+		arg.SecretTag,
+		arg.ID,
+		arg.UserID,
+		arg.ExpectedRevision,
+	)
+	var i EncryptedTotpSeed
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ServiceName,
+		&i.AccountIdentifier,
+		&i.EncryptedSecret,
+		&i.Algorithm,
+		&i.Digits,
+		&i.Period,
+		&i.Issuer,
+		&i.IconUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
+	)
+	return i, err
+}
+
+const countEncryptedTOTPSeeds = `-- name: CountEncryptedTOTPSeeds :one
+WITH RECURSIVE folder_tree AS (
+    SELECT f.id FROM folders f
+    WHERE f.id = $1 AND f.user_id = $2
+    UNION ALL
+    SELECT child.id FROM folders child
+    JOIN folder_tree ft ON child.parent_id = ft.id
+),
+candidates AS (
+    SELECT s.*,
+        COALESCE(u.use_count, 0)::bigint AS use_count,
+        u.last_used_at,
+        LOWER(CONCAT_WS(' ', s.service_name, s.account_identifier, ARRAY_TO_STRING(s.tags, ' '))) AS haystack
+    FROM encrypted_totp_seeds s
+    LEFT JOIN totp_seed_usage u ON u.seed_id = s.id
+    WHERE s.user_id = $2 AND s.is_active = TRUE
+        AND ($3::text IS NULL OR $3::text = ANY(s.tags))
+        AND ($1::uuid IS NULL OR s.folder_id IN (SELECT id FROM folder_tree))
+)
+SELECT COUNT(*) FROM candidates c
+    WHERE $4::text IS NULL
+        OR STRPOS(c.haystack, LOWER($4::text)) > 0
+        OR LOWER($4::text) <% c.haystack
+`
+
+type CountEncryptedTOTPSeedsParams struct {
+	FolderID pgtype.UUID `json:"folder_id"`
+	UserID   pgtype.UUID `json:"user_id"`
+	Tag      pgtype.Text `json:"tag"`
+	Query    pgtype.Text `json:"query"`
+}
+
+// Counts the entries ListEncryptedTOTPSeeds matches across all pages
+func (q *Queries) CountEncryptedTOTPSeeds(ctx context.Context, arg CountEncryptedTOTPSeedsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countEncryptedTOTPSeeds,
+		arg.FolderID,
+		arg.UserID,
+		arg.Tag,
+		arg.Query,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createEncryptedTOTPSeed = `-- name: CreateEncryptedTOTPSeed :one
 INSERT INTO encrypted_totp_seeds (
     user_id, service_name, account_identifier, encrypted_secret,
-    algorithm, digits, period, issuer, icon_url, is_active
+    algorithm, digits, period, issuer, icon_url, is_active,
+    method, counter, otp_type, t0, tags, folder_id, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+RETURNING id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag
 `
 
 type CreateEncryptedTOTPSeedParams struct {
@@ -31,6 +184,17 @@ type CreateEncryptedTOTPSeedParams struct {
 	Issuer            pgtype.Text `json:"issuer"`
 	IconUrl           pgtype.Text `json:"icon_url"`
 	IsActive          pgtype.Bool `json:"is_active"`
+	Method            string      `json:"method"`
+	Counter           int64       `json:"counter"`
+	OtpType           string      `json:"otp_type"`
+	T0                int64       `json:"t0"`
+	Tags              []string    `json:"tags"`
+	FolderID          pgtype.UUID `json:"folder_id"`
+	KeyVersion        int32       `json:"key_version"`
+	SecretFormat      int16       `json:"secret_format"`
+	CipherSuite       int16       `json:"cipher_suite"`
+	SecretIv          []byte      `json:"secret_iv"`
+	SecretTag         []byte      `json:"secret_tag"`
 }
 
 func (q *Queries) CreateEncryptedTOTPSeed(ctx context.Context, arg CreateEncryptedTOTPSeedParams) (EncryptedTotpSeed, error) {
@@ -45,6 +209,17 @@ func (q *Queries) CreateEncryptedTOTPSeed(ctx context.Context, arg CreateEncrypt
 		arg.Issuer,
 		arg.IconUrl,
 		arg.IsActive,
+		arg.Method,
+		arg.Counter,
+		arg.OtpType,
+		arg.T0,
+		arg.Tags,
+		arg.FolderID,
+		arg.KeyVersion,
+		arg.SecretFormat,
+		arg.CipherSuite,
+		arg.SecretIv,
+		arg.SecretTag,
 	)
 	var i EncryptedTotpSeed
 	err := row.Scan(
@@ -61,28 +236,72 @@ func (q *Queries) CreateEncryptedTOTPSeed(ctx context.Context, arg CreateEncrypt
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
 	)
 	return i, err
 }
 
-const deleteEncryptedTOTPSeed = `-- name: DeleteEncryptedTOTPSeed :exec
+type CreateEncryptedTOTPSeedsParams struct {
+	ID                pgtype.UUID `json:"id"`
+	UserID            pgtype.UUID `json:"user_id"`
+	ServiceName       string      `json:"service_name"`
+	AccountIdentifier string      `json:"account_identifier"`
+	EncryptedSecret   []byte      `json:"encrypted_secret"`
+	Algorithm         string      `json:"algorithm"`
+	Digits            int32       `json:"digits"`
+	Period            int32       `json:"period"`
+	Issuer            pgtype.Text `json:"issuer"`
+	IconUrl           pgtype.Text `json:"icon_url"`
+	IsActive          pgtype.Bool `json:"is_active"`
+	Method            string      `json:"method"`
+	Counter           int64       `json:"counter"`
+	OtpType           string      `json:"otp_type"`
+	T0                int64       `json:"t0"`
+	Tags              []string    `json:"tags"`
+	FolderID          pgtype.UUID `json:"folder_id"`
+	KeyVersion        int32       `json:"key_version"`
+	SecretFormat      int16       `json:"secret_format"`
+	CipherSuite       int16       `json:"cipher_suite"`
+	SecretIv          []byte      `json:"secret_iv"`
+	SecretTag         []byte      `json:"secret_tag"`
+}
+
+const deleteEncryptedTOTPSeed = `-- name: DeleteEncryptedTOTPSeed :execrows
 UPDATE encrypted_totp_seeds
-SET is_active = FALSE, updated_at = NOW()
-WHERE id = $1 AND user_id = $2
+SET is_active = FALSE, deleted_at = NOW(), revision = revision + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+    AND ($3::bigint IS NULL OR revision = $3::bigint)
 `
 
 type DeleteEncryptedTOTPSeedParams struct {
-	ID     pgtype.UUID `json:"id"`
-	UserID pgtype.UUID `json:"user_id"`
+	ID               pgtype.UUID `json:"id"`
+	UserID           pgtype.UUID `json:"user_id"`
+	ExpectedRevision pgtype.Int8 `json:"expected_revision"`
 }
 
-func (q *Queries) DeleteEncryptedTOTPSeed(ctx context.Context, arg DeleteEncryptedTOTPSeedParams) error {
-	_, err := q.db.Exec(ctx, deleteEncryptedTOTPSeed, arg.ID, arg.UserID)
-	return err
+// A non-NULL expected_revision makes the delete conditional on the entry still being at that revision
+func (q *Queries) DeleteEncryptedTOTPSeed(ctx context.Context, arg DeleteEncryptedTOTPSeedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteEncryptedTOTPSeed, arg.ID, arg.UserID, arg.ExpectedRevision)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getEncryptedTOTPSeedByID = `-- name: GetEncryptedTOTPSeedByID :one
-SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at FROM encrypted_totp_seeds
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
 WHERE id = $1 AND user_id = $2 AND is_active = TRUE
 `
 
@@ -108,12 +327,233 @@ func (q *Queries) GetEncryptedTOTPSeedByID(ctx context.Context, arg GetEncrypted
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
+	)
+	return i, err
+}
+
+const getEncryptedTOTPSeedByIDForUpdate = `-- name: GetEncryptedTOTPSeedByIDForUpdate :one
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+FOR UPDATE
+`
+
+type GetEncryptedTOTPSeedByIDForUpdateParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) GetEncryptedTOTPSeedByIDForUpdate(ctx context.Context, arg GetEncryptedTOTPSeedByIDForUpdateParams) (EncryptedTotpSeed, error) {
+	row := q.db.QueryRow(ctx, getEncryptedTOTPSeedByIDForUpdate, arg.ID, arg.UserID)
+	var i EncryptedTotpSeed
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ServiceName,
+		&i.AccountIdentifier,
+		&i.EncryptedSecret,
+		&i.Algorithm,
+		&i.Digits,
+		&i.Period,
+		&i.Issuer,
+		&i.IconUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
+	)
+	return i, err
+}
+
+const getEncryptedTOTPSeedForSync = `-- name: GetEncryptedTOTPSeedForSync :one
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2
+FOR UPDATE
+`
+
+type GetEncryptedTOTPSeedForSyncParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// Locks an entry, trashed or not, while a pushed change or conflict resolution is checked against it
+func (q *Queries) GetEncryptedTOTPSeedForSync(ctx context.Context, arg GetEncryptedTOTPSeedForSyncParams) (EncryptedTotpSeed, error) {
+	row := q.db.QueryRow(ctx, getEncryptedTOTPSeedForSync, arg.ID, arg.UserID)
+	var i EncryptedTotpSeed
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ServiceName,
+		&i.AccountIdentifier,
+		&i.EncryptedSecret,
+		&i.Algorithm,
+		&i.Digits,
+		&i.Period,
+		&i.Issuer,
+		&i.IconUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
 	)
 	return i, err
 }
 
+const getEncryptedTOTPSeedsByIDs = `-- name: GetEncryptedTOTPSeedsByIDs :many
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
+WHERE user_id = $1 AND id = ANY($2::uuid[])
+`
+
+type GetEncryptedTOTPSeedsByIDsParams struct {
+	UserID pgtype.UUID   `json:"user_id"`
+	Ids    []pgtype.UUID `json:"ids"`
+}
+
+// Includes trashed entries, so callers can tell them apart from purged ones
+func (q *Queries) GetEncryptedTOTPSeedsByIDs(ctx context.Context, arg GetEncryptedTOTPSeedsByIDsParams) ([]EncryptedTotpSeed, error) {
+	rows, err := q.db.Query(ctx, getEncryptedTOTPSeedsByIDs, arg.UserID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EncryptedTotpSeed{}
+	for rows.Next() {
+		var i EncryptedTotpSeed
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ServiceName,
+			&i.AccountIdentifier,
+			&i.EncryptedSecret,
+			&i.Algorithm,
+			&i.Digits,
+			&i.Period,
+			&i.Issuer,
+			&i.IconUrl,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEncryptedTOTPSeedsByKeyVersion = `-- name: GetEncryptedTOTPSeedsByKeyVersion :many
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
+WHERE user_id = $1 AND key_version = $2
+ORDER BY created_at ASC
+`
+
+type GetEncryptedTOTPSeedsByKeyVersionParams struct {
+	UserID     pgtype.UUID `json:"user_id"`
+	KeyVersion int32       `json:"key_version"`
+}
+
+// Includes trashed entries, which are encrypted like any other
+func (q *Queries) GetEncryptedTOTPSeedsByKeyVersion(ctx context.Context, arg GetEncryptedTOTPSeedsByKeyVersionParams) ([]EncryptedTotpSeed, error) {
+	rows, err := q.db.Query(ctx, getEncryptedTOTPSeedsByKeyVersion, arg.UserID, arg.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EncryptedTotpSeed{}
+	for rows.Next() {
+		var i EncryptedTotpSeed
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ServiceName,
+			&i.AccountIdentifier,
+			&i.EncryptedSecret,
+			&i.Algorithm,
+			&i.Digits,
+			&i.Period,
+			&i.Issuer,
+			&i.IconUrl,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getEncryptedTOTPSeedsByUserID = `-- name: GetEncryptedTOTPSeedsByUserID :many
-SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at FROM encrypted_totp_seeds
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
 WHERE user_id = $1 AND is_active = TRUE
 ORDER BY created_at DESC
 `
@@ -141,6 +581,19 @@ func (q *Queries) GetEncryptedTOTPSeedsByUserID(ctx context.Context, userID pgty
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
 		); err != nil {
 			return nil, err
 		}
@@ -153,7 +606,7 @@ func (q *Queries) GetEncryptedTOTPSeedsByUserID(ctx context.Context, userID pgty
 }
 
 const getEncryptedTOTPSeedsByUserIDSince = `-- name: GetEncryptedTOTPSeedsByUserIDSince :many
-SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at FROM encrypted_totp_seeds
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
 WHERE user_id = $1 AND updated_at > $2 AND is_active = TRUE
 ORDER BY updated_at ASC
 `
@@ -186,6 +639,19 @@ func (q *Queries) GetEncryptedTOTPSeedsByUserIDSince(ctx context.Context, arg Ge
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
 		); err != nil {
 			return nil, err
 		}
@@ -209,8 +675,510 @@ func (q *Queries) GetTOTPSeedsCountByUser(ctx context.Context, userID pgtype.UUI
 	return count, err
 }
 
+const incrementTOTPSeedCounter = `-- name: IncrementTOTPSeedCounter :one
+UPDATE encrypted_totp_seeds
+SET counter = counter + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+RETURNING counter
+`
+
+type IncrementTOTPSeedCounterParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) IncrementTOTPSeedCounter(ctx context.Context, arg IncrementTOTPSeedCounterParams) (int64, error) {
+	row := q.db.QueryRow(ctx, incrementTOTPSeedCounter, arg.ID, arg.UserID)
+	var counter int64
+	err := row.Scan(&counter)
+	return counter, err
+}
+
+const listEncryptedTOTPSeeds = `-- name: ListEncryptedTOTPSeeds :many
+WITH RECURSIVE folder_tree AS (
+    SELECT f.id FROM folders f
+    WHERE f.id = $1 AND f.user_id = $2
+    UNION ALL
+    SELECT child.id FROM folders child
+    JOIN folder_tree ft ON child.parent_id = ft.id
+),
+candidates AS (
+    SELECT s.*,
+        COALESCE(u.use_count, 0)::bigint AS use_count,
+        u.last_used_at,
+        LOWER(CONCAT_WS(' ', s.service_name, s.account_identifier, ARRAY_TO_STRING(s.tags, ' '))) AS haystack
+    FROM encrypted_totp_seeds s
+    LEFT JOIN totp_seed_usage u ON u.seed_id = s.id
+    WHERE s.user_id = $2 AND s.is_active = TRUE
+        AND ($3::text IS NULL OR $3::text = ANY(s.tags))
+        AND ($1::uuid IS NULL OR s.folder_id IN (SELECT id FROM folder_tree))
+),
+matches AS (
+    SELECT c.*,
+        CASE WHEN $4::text IS NULL THEN 0
+            ELSE WORD_SIMILARITY(LOWER($4::text), c.haystack)
+                + CASE WHEN STRPOS(c.haystack, LOWER($4::text)) > 0 THEN 1 ELSE 0 END
+        END AS score
+    FROM candidates c
+    WHERE $4::text IS NULL
+        OR STRPOS(c.haystack, LOWER($4::text)) > 0
+        OR LOWER($4::text) <% c.haystack
+),
+keyed AS (
+    SELECT m.*,
+        (CASE $5::text
+            WHEN 'issuer' THEN LOWER(m.service_name)
+            WHEN 'label' THEN LOWER(m.account_identifier)
+            ELSE ''
+        END)::text AS sort_text,
+        (CASE $5::text
+            WHEN 'created' THEN COALESCE(EXTRACT(EPOCH FROM m.created_at) * 1000000, 0)::bigint
+            WHEN 'updated' THEN COALESCE(EXTRACT(EPOCH FROM m.updated_at) * 1000000, 0)::bigint
+            WHEN 'most-used' THEN m.use_count
+            WHEN 'relevance' THEN (m.score * 1000000)::bigint
+            ELSE 0
+        END)::bigint AS sort_num
+    FROM matches m
+)
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version,
+    secret_format, cipher_suite, secret_iv, secret_tag, use_count, last_used_at, sort_text, sort_num
+FROM keyed
+WHERE $6::uuid IS NULL
+    OR (NOT $7::bool
+        AND (sort_text, sort_num, id) > ($8::text, $9::bigint, $6::uuid))
+    OR ($7::bool
+        AND (sort_text, sort_num, id) < ($8::text, $9::bigint, $6::uuid))
+ORDER BY
+    CASE WHEN NOT $7::bool THEN sort_text END ASC,
+    CASE WHEN NOT $7::bool THEN sort_num END ASC,
+    CASE WHEN NOT $7::bool THEN id END ASC,
+    CASE WHEN $7::bool THEN sort_text END DESC,
+    CASE WHEN $7::bool THEN sort_num END DESC,
+    CASE WHEN $7::bool THEN id END DESC
+LIMIT $10
+`
+
+type ListEncryptedTOTPSeedsParams struct {
+	FolderID   pgtype.UUID `json:"folder_id"`
+	UserID     pgtype.UUID `json:"user_id"`
+	Tag        pgtype.Text `json:"tag"`
+	Query      pgtype.Text `json:"query"`
+	Sort       string      `json:"sort"`
+	AfterID    pgtype.UUID `json:"after_id"`
+	Descending bool        `json:"descending"`
+	AfterText  string      `json:"after_text"`
+	AfterNum   int64       `json:"after_num"`
+	PageLimit  pgtype.Int4 `json:"page_limit"`
+}
+
+type ListEncryptedTOTPSeedsRow struct {
+	ID                pgtype.UUID        `json:"id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	ServiceName       string             `json:"service_name"`
+	AccountIdentifier string             `json:"account_identifier"`
+	EncryptedSecret   []byte             `json:"encrypted_secret"`
+	Algorithm         string             `json:"algorithm"`
+	Digits            int32              `json:"digits"`
+	Period            int32              `json:"period"`
+	Issuer            pgtype.Text        `json:"issuer"`
+	IconUrl           pgtype.Text        `json:"icon_url"`
+	IsActive          pgtype.Bool        `json:"is_active"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	Method            string             `json:"method"`
+	Counter           int64              `json:"counter"`
+	OtpType           string             `json:"otp_type"`
+	T0                int64              `json:"t0"`
+	Tags              []string           `json:"tags"`
+	FolderID          pgtype.UUID        `json:"folder_id"`
+	DeletedAt         pgtype.Timestamptz `json:"deleted_at"`
+	Revision          int64              `json:"revision"`
+	KeyVersion        int32              `json:"key_version"`
+	SecretFormat      int16              `json:"secret_format"`
+	CipherSuite       int16              `json:"cipher_suite"`
+	SecretIv          []byte             `json:"secret_iv"`
+	SecretTag         []byte             `json:"secret_tag"`
+	UseCount          int64              `json:"use_count"`
+	LastUsedAt        pgtype.Timestamptz `json:"last_used_at"`
+	SortText          string             `json:"sort_text"`
+	SortNum           int64              `json:"sort_num"`
+}
+
+// One page of entries, optionally filtered by tag, by folder (including its subfolders) and by a
+// pg_trgm fuzzy query over issuer, label and tags. Rows are keyed by (sort_text, sort_num, id):
+// text sorts leave sort_num at 0 and numeric sorts leave sort_text empty, and the key of the last
+// row is the cursor for the next page. A NULL page_limit returns every remaining row.
+// The most-used key reads the live use_count, so uses recorded between pages can move an entry
+// across the cursor and skip or repeat it.
+func (q *Queries) ListEncryptedTOTPSeeds(ctx context.Context, arg ListEncryptedTOTPSeedsParams) ([]ListEncryptedTOTPSeedsRow, error) {
+	rows, err := q.db.Query(ctx, listEncryptedTOTPSeeds,
+		arg.FolderID,
+		arg.UserID,
+		arg.Tag,
+		arg.Query,
+		arg.Sort,
+		arg.AfterID,
+		arg.Descending,
+		arg.AfterText,
+		arg.AfterNum,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListEncryptedTOTPSeedsRow{}
+	for rows.Next() {
+		var i ListEncryptedTOTPSeedsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ServiceName,
+			&i.AccountIdentifier,
+			&i.EncryptedSecret,
+			&i.Algorithm,
+			&i.Digits,
+			&i.Period,
+			&i.Issuer,
+			&i.IconUrl,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
+			&i.UseCount,
+			&i.LastUsedAt,
+			&i.SortText,
+			&i.SortNum,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTagCountsByUser = `-- name: ListTagCountsByUser :many
+SELECT tag::text AS tag, COUNT(*) AS count
+FROM encrypted_totp_seeds, UNNEST(tags) AS tag
+WHERE user_id = $1 AND is_active = TRUE
+GROUP BY tag
+ORDER BY tag
+`
+
+type ListTagCountsByUserRow struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+func (q *Queries) ListTagCountsByUser(ctx context.Context, userID pgtype.UUID) ([]ListTagCountsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listTagCountsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTagCountsByUserRow{}
+	for rows.Next() {
+		var i ListTagCountsByUserRow
+		if err := rows.Scan(&i.Tag, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTakenTOTPSeedIDs = `-- name: ListTakenTOTPSeedIDs :many
+SELECT id FROM encrypted_totp_seeds
+WHERE id = ANY($1::uuid[])
+`
+
+// Entry IDs are global, so an import checks which of its IDs any user already has
+func (q *Queries) ListTakenTOTPSeedIDs(ctx context.Context, ids []pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listTakenTOTPSeedIDs, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTrashedTOTPSeeds = `-- name: ListTrashedTOTPSeeds :many
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
+WHERE user_id = $1 AND is_active = FALSE
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListTrashedTOTPSeeds(ctx context.Context, userID pgtype.UUID) ([]EncryptedTotpSeed, error) {
+	rows, err := q.db.Query(ctx, listTrashedTOTPSeeds, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []EncryptedTotpSeed{}
+	for rows.Next() {
+		var i EncryptedTotpSeed
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ServiceName,
+			&i.AccountIdentifier,
+			&i.EncryptedSecret,
+			&i.Algorithm,
+			&i.Digits,
+			&i.Period,
+			&i.Issuer,
+			&i.IconUrl,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveTOTPSeedsToFolder = `-- name: MoveTOTPSeedsToFolder :many
+UPDATE encrypted_totp_seeds
+SET folder_id = $1, revision = revision + 1, updated_at = NOW()
+WHERE user_id = $2 AND folder_id = $3
+RETURNING id
+`
+
+type MoveTOTPSeedsToFolderParams struct {
+	ToFolderID   pgtype.UUID `json:"to_folder_id"`
+	UserID       pgtype.UUID `json:"user_id"`
+	FromFolderID pgtype.UUID `json:"from_folder_id"`
+}
+
+func (q *Queries) MoveTOTPSeedsToFolder(ctx context.Context, arg MoveTOTPSeedsToFolderParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, moveTOTPSeedsToFolder, arg.ToFolderID, arg.UserID, arg.FromFolderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeExpiredTOTPSeeds = `-- name: PurgeExpiredTOTPSeeds :many
+DELETE FROM encrypted_totp_seeds
+WHERE is_active = FALSE AND deleted_at < $1
+RETURNING id, user_id
+`
+
+type PurgeExpiredTOTPSeedsRow struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) PurgeExpiredTOTPSeeds(ctx context.Context, deletedAt pgtype.Timestamptz) ([]PurgeExpiredTOTPSeedsRow, error) {
+	rows, err := q.db.Query(ctx, purgeExpiredTOTPSeeds, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PurgeExpiredTOTPSeedsRow{}
+	for rows.Next() {
+		var i PurgeExpiredTOTPSeedsRow
+		if err := rows.Scan(&i.ID, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeTOTPSeed = `-- name: PurgeTOTPSeed :execrows
+DELETE FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = FALSE
+`
+
+type PurgeTOTPSeedParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// Permanently deletes an entry; only entries already in the trash can be purged
+func (q *Queries) PurgeTOTPSeed(ctx context.Context, arg PurgeTOTPSeedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeTOTPSeed, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordTOTPSeedUse = `-- name: RecordTOTPSeedUse :execrows
+INSERT INTO totp_seed_usage (seed_id, use_count, last_used_at)
+SELECT id, 1, NOW() FROM encrypted_totp_seeds
+WHERE id = $1 AND user_id = $2 AND is_active = TRUE
+ON CONFLICT (seed_id) DO UPDATE
+SET use_count = totp_seed_usage.use_count + 1, last_used_at = NOW()
+`
+
+type RecordTOTPSeedUseParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) RecordTOTPSeedUse(ctx context.Context, arg RecordTOTPSeedUseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordTOTPSeedUse, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const renameTOTPSeedTag = `-- name: RenameTOTPSeedTag :many
+UPDATE encrypted_totp_seeds
+SET tags = ARRAY(
+        SELECT t FROM (
+            SELECT DISTINCT ON (u.t) u.t, u.ord
+            FROM UNNEST(ARRAY_REPLACE(tags, $1::text, $2::text)) WITH ORDINALITY AS u(t, ord)
+            ORDER BY u.t, u.ord
+        ) deduped
+        ORDER BY deduped.ord
+    ),
+    revision = revision + 1,
+    updated_at = NOW()
+WHERE user_id = $3 AND is_active = TRUE AND $1::text = ANY(tags)
+RETURNING id
+`
+
+type RenameTOTPSeedTagParams struct {
+	OldTag string      `json:"old_tag"`
+	NewTag string      `json:"new_tag"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+// Renaming onto an existing tag merges the two; duplicates are dropped keeping the first position
+func (q *Queries) RenameTOTPSeedTag(ctx context.Context, arg RenameTOTPSeedTagParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, renameTOTPSeedTag, arg.OldTag, arg.NewTag, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []pgtype.UUID{}
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restoreTOTPSeed = `-- name: RestoreTOTPSeed :one
+UPDATE encrypted_totp_seeds
+SET is_active = TRUE, deleted_at = NULL, revision = revision + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND is_active = FALSE
+RETURNING id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag
+`
+
+type RestoreTOTPSeedParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) RestoreTOTPSeed(ctx context.Context, arg RestoreTOTPSeedParams) (EncryptedTotpSeed, error) {
+	row := q.db.QueryRow(ctx, restoreTOTPSeed, arg.ID, arg.UserID)
+	var i EncryptedTotpSeed
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ServiceName,
+		&i.AccountIdentifier,
+		&i.EncryptedSecret,
+		&i.Algorithm,
+		&i.Digits,
+		&i.Period,
+		&i.Issuer,
+		&i.IconUrl,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
+	)
+	return i, err
+}
+
 const searchEncryptedTOTPSeeds = `-- name: SearchEncryptedTOTPSeeds :many
-SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at FROM encrypted_totp_seeds
+SELECT id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag FROM encrypted_totp_seeds
 WHERE user_id = $1 AND is_active = TRUE
     AND (
         issuer ILIKE '%' || $2 || '%'
@@ -248,6 +1216,19 @@ func (q *Queries) SearchEncryptedTOTPSeeds(ctx context.Context, arg SearchEncryp
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Method,
+			&i.Counter,
+			&i.OtpType,
+			&i.T0,
+			&i.Tags,
+			&i.FolderID,
+			&i.DeletedAt,
+			&i.Revision,
+			&i.KeyVersion,
+			&i.SecretFormat,
+			&i.CipherSuite,
+			&i.SecretIv,
+			&i.SecretTag,
 		); err != nil {
 			return nil, err
 		}
@@ -269,9 +1250,20 @@ SET service_name = COALESCE($3, service_name),
     period = COALESCE($8, period),
     issuer = COALESCE($9, issuer),
     icon_url = COALESCE($10, icon_url),
+    otp_type = COALESCE($11, otp_type),
+    t0 = COALESCE($12, t0),
+    tags = COALESCE($13, tags),
+    folder_id = CASE WHEN $14::bool THEN $15 ELSE folder_id END,
+    key_version = COALESCE($16, key_version),
+    secret_format = COALESCE($17, secret_format),
+    cipher_suite = COALESCE($18, cipher_suite),
+    secret_iv = COALESCE($19, secret_iv),
+    secret_tag = COALESCE($20, secret_tag),
+    revision = revision + 1,
     updated_at = NOW()
 WHERE id = $1 AND user_id = $2 AND is_active = TRUE
-RETURNING id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at
+    AND ($21::bigint IS NULL OR revision = $21::bigint)
+RETURNING id, user_id, service_name, account_identifier, encrypted_secret, algorithm, digits, period, issuer, icon_url, is_active, created_at, updated_at, method, counter, otp_type, t0, tags, folder_id, deleted_at, revision, key_version, secret_format, cipher_suite, secret_iv, secret_tag
 `
 
 type UpdateEncryptedTOTPSeedParams struct {
@@ -285,8 +1277,20 @@ type UpdateEncryptedTOTPSeedParams struct {
 	Period            pgtype.Int4 `json:"period"`
 	Issuer            pgtype.Text `json:"issuer"`
 	IconUrl           pgtype.Text `json:"icon_url"`
+	OtpType           pgtype.Text `json:"otp_type"`
+	T0                pgtype.Int8 `json:"t0"`
+	Tags              []string    `json:"tags"`
+	SetFolder         bool        `json:"set_folder"`
+	FolderID          pgtype.UUID `json:"folder_id"`
+	KeyVersion        pgtype.Int4 `json:"key_version"`
+	SecretFormat      pgtype.Int2 `json:"secret_format"`
+	CipherSuite       pgtype.Int2 `json:"cipher_suite"`
+	SecretIv          []byte      `json:"secret_iv"`
+	SecretTag         []byte      `json:"secret_tag"`
+	ExpectedRevision  pgtype.Int8 `json:"expected_revision"`
 }
 
+// A non-NULL expected_revision makes the update conditional on the entry still being at that revision
 func (q *Queries) UpdateEncryptedTOTPSeed(ctx context.Context, arg UpdateEncryptedTOTPSeedParams) (EncryptedTotpSeed, error) {
 	row := q.db.QueryRow(ctx, updateEncryptedTOTPSeed,
 		arg.ID,
@@ -299,6 +1303,17 @@ func (q *Queries) UpdateEncryptedTOTPSeed(ctx context.Context, arg UpdateEncrypt
 		arg.Period,
 		arg.Issuer,
 		arg.IconUrl,
+		arg.OtpType,
+		arg.T0,
+		arg.Tags,
+		arg.SetFolder,
+		arg.FolderID,
+		arg.KeyVersion,
+		arg.SecretFormat,
+		arg.CipherSuite,
+		arg.SecretIv,
+		arg.SecretTag,
+		arg.ExpectedRevision,
 	)
 	var i EncryptedTotpSeed
 	err := row.Scan(
@@ -315,10 +1330,61 @@ func (q *Queries) UpdateEncryptedTOTPSeed(ctx context.Context, arg UpdateEncrypt
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Method,
+		&i.Counter,
+		&i.OtpType,
+		&i.T0,
+		&i.Tags,
+		&i.FolderID,
+		&i.DeletedAt,
+		&i.Revision,
+		&i.KeyVersion,
+		&i.SecretFormat,
+		&i.CipherSuite,
+		&i.SecretIv,
+		&i.SecretTag,
 	)
 	return i, err
 }
 
+const updateTOTPSeedKeyVersion = `-- name: UpdateTOTPSeedKeyVersion :many
+UPDATE encrypted_totp_seeds
+SET key_version = $1, revision = revision + 1, updated_at = NOW()
+WHERE user_id = $2 AND key_version = $3
+RETURNING id, is_active
+`
+
+type UpdateTOTPSeedKeyVersionParams struct {
+	NewVersion int32       `json:"new_version"`
+	UserID     pgtype.UUID `json:"user_id"`
+	OldVersion int32       `json:"old_version"`
+}
+
+type UpdateTOTPSeedKeyVersionRow struct {
+	ID       pgtype.UUID `json:"id"`
+	IsActive pgtype.Bool `json:"is_active"`
+}
+
+func (q *Queries) UpdateTOTPSeedKeyVersion(ctx context.Context, arg UpdateTOTPSeedKeyVersionParams) ([]UpdateTOTPSeedKeyVersionRow, error) {
+	rows, err := q.db.Query(ctx, updateTOTPSeedKeyVersion, arg.NewVersion, arg.UserID, arg.OldVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UpdateTOTPSeedKeyVersionRow{}
+	for rows.Next() {
+		var i UpdateTOTPSeedKeyVersionRow
+		if err := rows.Scan(&i.ID, &i.IsActive); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateTOTPSeedSyncTimestamp = `-- name: UpdateTOTPSeedSyncTimestamp :exec
 UPDATE encrypted_totp_seeds
 SET updated_at = NOW()
@@ -28,7 +28,7 @@ INSERT INTO users (
 ) VALUES (
     $1, $2, $3
 )
-RETURNING id, username, email, display_name, created_at, updated_at, last_login_at, is_active
+RETURNING id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version
 `
 
 type CreateUserParams struct {
@@ -49,16 +49,19 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.LastLoginAt,
 		&i.IsActive,
+		&i.Role,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const deactivateUser = `-- name: DeactivateUser :exec
 UPDATE users 
-SET is_active = FALSE, updated_at = NOW()
+SET is_active = FALSE, token_version = token_version + 1, updated_at = NOW()
 WHERE id = $1
 `
 
+// Suspends the account and revokes its tokens, so reactivating it does not revive them
 func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) error {
 	_, err := q.db.Exec(ctx, deactivateUser, id)
 	return err
@@ -75,7 +78,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active FROM users 
+SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version FROM users 
 WHERE email = $1
 `
 
@@ -91,12 +94,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.LastLoginAt,
 		&i.IsActive,
+		&i.Role,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active FROM users 
+SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version FROM users 
 WHERE id = $1
 `
 
@@ -112,12 +117,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error)
 		&i.UpdatedAt,
 		&i.LastLoginAt,
 		&i.IsActive,
+		&i.Role,
+		&i.TokenVersion,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active FROM users 
+SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version FROM users 
 WHERE username = $1
 `
 
@@ -133,12 +140,52 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.UpdatedAt,
 		&i.LastLoginAt,
 		&i.IsActive,
+		&i.Role,
+		&i.TokenVersion,
+	)
+	return i, err
+}
+
+const getUserStats = `-- name: GetUserStats :one
+SELECT
+    COUNT(*) AS total_users,
+    COUNT(*) FILTER (WHERE COALESCE(is_active, FALSE)) AS active_users,
+    COUNT(*) FILTER (WHERE role = 'admin') AS admin_users,
+    COUNT(*) FILTER (WHERE created_at >= $1::timestamptz) AS new_users,
+    COUNT(*) FILTER (WHERE last_login_at >= $1::timestamptz) AS signed_in_users,
+    (SELECT COUNT(*) FROM encrypted_totp_seeds WHERE is_active = TRUE) AS vault_entries,
+    (SELECT COUNT(*) FROM webauthn_credentials) AS passkeys
+FROM users
+`
+
+type GetUserStatsRow struct {
+	TotalUsers    int64 `json:"total_users"`
+	ActiveUsers   int64 `json:"active_users"`
+	AdminUsers    int64 `json:"admin_users"`
+	NewUsers      int64 `json:"new_users"`
+	SignedInUsers int64 `json:"signed_in_users"`
+	VaultEntries  int64 `json:"vault_entries"`
+	Passkeys      int64 `json:"passkeys"`
+}
+
+// Aggregate counts for the admin dashboard; new and signed-in users are counted from since
+func (q *Queries) GetUserStats(ctx context.Context, since pgtype.Timestamptz) (GetUserStatsRow, error) {
+	row := q.db.QueryRow(ctx, getUserStats, since)
+	var i GetUserStatsRow
+	err := row.Scan(
+		&i.TotalUsers,
+		&i.ActiveUsers,
+		&i.AdminUsers,
+		&i.NewUsers,
+		&i.SignedInUsers,
+		&i.VaultEntries,
+		&i.Passkeys,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active FROM users 
+SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version FROM users 
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
 `
@@ -166,6 +213,92 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.UpdatedAt,
 			&i.LastLoginAt,
 			&i.IsActive,
+			&i.Role,
+			&i.TokenVersion,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reactivateUser = `-- name: ReactivateUser :exec
+UPDATE users 
+SET is_active = TRUE, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, reactivateUser, id)
+	return err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE users 
+SET token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, revokeUserTokens, id)
+	return err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version FROM users
+WHERE ($1::text IS NULL
+        OR STRPOS(LOWER(CONCAT_WS(' ', username, email, display_name)), LOWER($1::text)) > 0)
+    AND ($2::text IS NULL OR role = $2::text)
+    AND ($3::boolean IS NULL OR COALESCE(is_active, FALSE) = $3::boolean)
+    AND ($4::timestamptz IS NULL
+        OR (created_at, id) < ($4::timestamptz, $5::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type SearchUsersParams struct {
+	Query           pgtype.Text        `json:"query"`
+	Role            pgtype.Text        `json:"role"`
+	IsActive        pgtype.Bool        `json:"is_active"`
+	BeforeCreatedAt pgtype.Timestamptz `json:"before_created_at"`
+	BeforeID        pgtype.UUID        `json:"before_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+// One page of users, newest first, optionally filtered by a case-insensitive substring of the
+// username, email or display name, by role and by status. Rows are keyed by (created_at, id), and
+// the key of the last row is the cursor for the next page.
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.Query,
+		arg.Role,
+		arg.IsActive,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.DisplayName,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastLoginAt,
+			&i.IsActive,
+			&i.Role,
+			&i.TokenVersion,
 		); err != nil {
 			return nil, err
 		}
@@ -177,11 +310,28 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 	return items, nil
 }
 
+const setUserRole = `-- name: SetUserRole :exec
+UPDATE users 
+SET role = $2, token_version = token_version + 1, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   pgtype.UUID `json:"id"`
+	Role string      `json:"role"`
+}
+
+// Changes the role and revokes the user's tokens, which carry the old one
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) error {
+	_, err := q.db.Exec(ctx, setUserRole, arg.ID, arg.Role)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users 
 SET username = $2, email = $3, display_name = $4, updated_at = NOW()
 WHERE id = $1
-RETURNING id, username, email, display_name, created_at, updated_at, last_login_at, is_active
+RETURNING id, username, email, display_name, created_at, updated_at, last_login_at, is_active, role, token_version
 `
 
 type UpdateUserParams struct {
@@ -208,6 +358,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.LastLoginAt,
 		&i.IsActive,
+		&i.Role,
+		&i.TokenVersion,
 	)
 	return i, err
 }
@@ -94,6 +94,24 @@ func (q *Queries) DeleteWebAuthnCredential(ctx context.Context, arg DeleteWebAut
 	return err
 }
 
+const deleteWebAuthnCredentialByUUID = `-- name: DeleteWebAuthnCredentialByUUID :execrows
+DELETE FROM webauthn_credentials
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteWebAuthnCredentialByUUIDParams struct {
+	ID     pgtype.UUID `json:"id"`
+	UserID pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) DeleteWebAuthnCredentialByUUID(ctx context.Context, arg DeleteWebAuthnCredentialByUUIDParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebAuthnCredentialByUUID, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getWebAuthnCredentialByID = `-- name: GetWebAuthnCredentialByID :one
 SELECT id, user_id, credential_id, public_key, attestation_type, transport, flags, authenticator, device_name, created_at, last_used_at, aaguid, clone_warning, sign_count, attachment, backup_eligible, backup_state FROM webauthn_credentials
 WHERE credential_id = $1
@@ -0,0 +1,550 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type syncRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewSyncRepository creates a new sync repository
+func NewSyncRepository(database *DB) interfaces.SyncRepository {
+	return &syncRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Changes returns the changes to the user's vault after cursor, collapsed to one per entry
+func (r *syncRepository) Changes(ctx context.Context, userID uuid.UUID, cursor string) (*entities.SyncChanges, error) {
+	userUUID := convertUUIDToPG(userID)
+
+	var afterSeq int64
+	if cursor != "" {
+		var err error
+		afterSeq, err = decodeSyncCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the sequence before anything else: every operation up to it has committed, so entries
+	// read afterwards are at least that new. Later changes are sent again on the next sync.
+	untilSeq, err := r.queries.GetSyncSequence(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync sequence: %w", err)
+	}
+	if afterSeq > untilSeq {
+		return nil, fmt.Errorf("%w: cursor is ahead of the vault", entities.ErrInvalidCursor)
+	}
+
+	changes := &entities.SyncChanges{
+		Created: []*entities.OTP{},
+		Updated: []*entities.OTP{},
+		Deleted: []*entities.OTPTombstone{},
+		Cursor:  encodeSyncCursor(untilSeq),
+		Full:    cursor == "",
+	}
+
+	if cursor == "" {
+		seeds, err := r.queries.GetEncryptedTOTPSeedsByUserID(ctx, userUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+		}
+		for _, seed := range seeds {
+			otp, err := convertToOTP(seed)
+			if err != nil {
+				continue
+			}
+			changes.Created = append(changes.Created, otp)
+		}
+		return changes, nil
+	}
+
+	if afterSeq == untilSeq {
+		return changes, nil
+	}
+
+	ops, err := r.queries.GetSyncOperationsSince(ctx, db.GetSyncOperationsSinceParams{
+		UserID:   userUUID,
+		AfterSeq: afterSeq,
+		UntilSeq: untilSeq,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync operations: %w", err)
+	}
+
+	// The first operation on an entry tells whether the device already holds it: entries created
+	// or restored since the cursor are new to it, anything else it has a copy of
+	var ids []pgtype.UUID
+	newToDevice := make(map[uuid.UUID]bool)
+	for _, op := range ops {
+		id := convertPGUUID(op.EntityID)
+		if _, seen := newToDevice[id]; seen {
+			continue
+		}
+		newToDevice[id] = op.OperationType == entities.SyncOperationCreate || op.OperationType == entities.SyncOperationRestore
+		ids = append(ids, op.EntityID)
+	}
+
+	seeds, err := r.queries.GetEncryptedTOTPSeedsByIDs(ctx, db.GetEncryptedTOTPSeedsByIDsParams{
+		UserID: userUUID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+	}
+	current := make(map[uuid.UUID]db.EncryptedTotpSeed, len(seeds))
+	for _, seed := range seeds {
+		current[convertPGUUID(seed.ID)] = seed
+	}
+
+	for _, pgID := range ids {
+		id := convertPGUUID(pgID)
+		seed, exists := current[id]
+
+		switch {
+		case exists && seed.IsActive.Bool:
+			otp, err := convertToOTP(seed)
+			if err != nil {
+				continue
+			}
+			if newToDevice[id] {
+				changes.Created = append(changes.Created, otp)
+			} else {
+				changes.Updated = append(changes.Updated, otp)
+			}
+		case newToDevice[id]:
+			// Created and removed again since the cursor; the device never saw it
+		case exists:
+			tombstone := &entities.OTPTombstone{ID: id, Revision: seed.Revision}
+			if seed.DeletedAt.Valid {
+				tombstone.DeletedAt = &seed.DeletedAt.Time
+			}
+			changes.Deleted = append(changes.Deleted, tombstone)
+		default:
+			changes.Deleted = append(changes.Deleted, &entities.OTPTombstone{ID: id, Purged: true})
+		}
+	}
+
+	return changes, nil
+}
+
+// TouchDevice records that a device synced, registering it on first use
+func (r *syncRepository) TouchDevice(ctx context.Context, userID uuid.UUID, fingerprint, name string) (*entities.SyncDevice, error) {
+	row, err := r.queries.CreateDeviceSession(ctx, db.CreateDeviceSessionParams{
+		UserID:            convertUUIDToPG(userID),
+		DeviceFingerprint: fingerprint,
+		DeviceName:        pgtype.Text{String: name, Valid: name != ""},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record device sync: %w", err)
+	}
+
+	return convertToSyncDevice(row), nil
+}
+
+// ListDevices retrieves the user's syncing devices, most recently synced first
+func (r *syncRepository) ListDevices(ctx context.Context, userID uuid.UUID) ([]*entities.SyncDevice, error) {
+	rows, err := r.queries.GetActiveDeviceSessionsByUserID(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+
+	devices := make([]*entities.SyncDevice, 0, len(rows))
+	for _, row := range rows {
+		devices = append(devices, convertToSyncDevice(row))
+	}
+
+	return devices, nil
+}
+
+// ApplyChange applies a pushed change if the entry is still at the change's base revision, and
+// records a conflict otherwise
+func (r *syncRepository) ApplyChange(ctx context.Context, userID uuid.UUID, change *entities.SyncChange) (*entities.OTP, *entities.SyncConflict, error) {
+	var (
+		otp      *entities.OTP
+		conflict *entities.SyncConflict
+	)
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		seed, err := lockSeedForSync(ctx, queries, change.ID, userID)
+		if err != nil {
+			return err
+		}
+		if change.BaseRevision > seed.Revision {
+			return fmt.Errorf("%w: base revision %d is ahead of the entry", entities.ErrInvalidOperation, change.BaseRevision)
+		}
+
+		if change.BaseRevision < seed.Revision {
+			server := convertToOTPVersion(seed)
+
+			// Devices that made the same change have not diverged
+			if change.Version.SameContent(server) {
+				if seed.IsActive.Bool {
+					otp, err = convertToOTP(seed)
+				}
+				return err
+			}
+
+			conflict, err = createSyncConflict(ctx, queries, userID, change, server)
+			return err
+		}
+
+		// Trashed entries are restored, not edited
+		if !seed.IsActive.Bool {
+			return entities.ErrTOTPSeedNotFound
+		}
+
+		otp, err = applyOTPVersion(ctx, queries, userID, seed, change.Version, entities.OTPRevisionSync)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return otp, conflict, nil
+}
+
+// ListConflicts retrieves the user's open conflicts, oldest first
+func (r *syncRepository) ListConflicts(ctx context.Context, userID uuid.UUID) ([]*entities.SyncConflict, error) {
+	rows, err := r.queries.ListOpenSyncConflicts(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync conflicts: %w", err)
+	}
+
+	conflicts := make([]*entities.SyncConflict, 0, len(rows))
+	for _, row := range rows {
+		conflict, err := convertToSyncConflict(row)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, nil
+}
+
+// ResolveConflict settles an open conflict and applies the chosen version
+func (r *syncRepository) ResolveConflict(ctx context.Context, userID uuid.UUID, conflictID uuid.UUID, resolution *entities.SyncResolution) (*entities.SyncConflict, *entities.OTP, error) {
+	var (
+		conflict *entities.SyncConflict
+		otp      *entities.OTP
+	)
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		row, err := queries.GetSyncConflictForUpdate(ctx, db.GetSyncConflictForUpdateParams{
+			ID:     convertUUIDToPG(conflictID),
+			UserID: convertUUIDToPG(userID),
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrConflictNotFound
+			}
+			return fmt.Errorf("failed to get sync conflict: %w", err)
+		}
+		if row.Status != entities.SyncConflictOpen {
+			return entities.ErrConflictResolved
+		}
+		open, err := convertToSyncConflict(row)
+		if err != nil {
+			return err
+		}
+
+		// Conflicts are deleted with their entry, so the entry still exists
+		seed, err := lockSeedForSync(ctx, queries, open.OTPID, userID)
+		if err != nil {
+			return err
+		}
+
+		if resolution.Choice == entities.SyncResolutionServer {
+			if seed.IsActive.Bool {
+				if otp, err = convertToOTP(seed); err != nil {
+					return err
+				}
+			}
+		} else {
+			expected := resolution.ExpectedRevision
+			if expected == 0 {
+				expected = open.Server.Revision
+			}
+			if seed.Revision != expected {
+				return entities.ErrRevisionMismatch
+			}
+
+			version := resolution.Version
+			if resolution.Choice == entities.SyncResolutionClient {
+				// A secret pushed before a key rotation committed is unreadable with the current
+				// key; the device re-encrypts it and resolves with a merged version instead
+				if !open.Client.Deleted && open.Client.KeyVersion != resolution.KeyVersion {
+					return fmt.Errorf("%w: the client version is encrypted with key version %d, current is %d; resolve with a merged version",
+						entities.ErrInvalidOperation, open.Client.KeyVersion, resolution.KeyVersion)
+				}
+				version = &open.Client
+			}
+			if otp, err = applyOTPVersion(ctx, queries, userID, seed, version, entities.OTPRevisionResolve); err != nil {
+				return err
+			}
+		}
+
+		resolved, err := queries.ResolveSyncConflict(ctx, db.ResolveSyncConflictParams{
+			ID:         row.ID,
+			UserID:     row.UserID,
+			Resolution: pgtype.Text{String: resolution.Choice, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to resolve sync conflict: %w", err)
+		}
+
+		conflict, err = convertToSyncConflict(resolved)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conflict, otp, nil
+}
+
+// lockSeedForSync locks an entry, trashed or not, until the transaction ends
+func lockSeedForSync(ctx context.Context, queries *db.Queries, id uuid.UUID, userID uuid.UUID) (db.EncryptedTotpSeed, error) {
+	seed, err := queries.GetEncryptedTOTPSeedForSync(ctx, db.GetEncryptedTOTPSeedForSyncParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return seed, entities.ErrTOTPSeedNotFound
+		}
+		return seed, fmt.Errorf("failed to lock encrypted TOTP seed: %w", err)
+	}
+
+	return seed, nil
+}
+
+// applyOTPVersion replaces a locked entry with a version, or moves it to the trash for a deleted
+// version, and returns the entry afterwards (nil once deleted). An active entry's current version
+// is recorded as a revision first; a trashed entry is restored.
+func applyOTPVersion(ctx context.Context, queries *db.Queries, userID uuid.UUID, seed db.EncryptedTotpSeed, version *entities.OTPVersion, reason string) (*entities.OTP, error) {
+	id := convertPGUUID(seed.ID)
+
+	if version.Deleted {
+		if !seed.IsActive.Bool {
+			return nil, nil // Already in the trash
+		}
+		if _, err := queries.DeleteEncryptedTOTPSeed(ctx, db.DeleteEncryptedTOTPSeedParams{
+			ID:               seed.ID,
+			UserID:           seed.UserID,
+			ExpectedRevision: pgtype.Int8{Int64: seed.Revision, Valid: true},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to delete encrypted TOTP seed: %w", err)
+		}
+		return nil, recordSyncOperations(ctx, queries, userID, entities.SyncOperationDelete, id)
+	}
+
+	// The secret's envelope names the key version it is encrypted with
+	envelope, err := entities.ParseSecretEnvelope(version.Secret)
+	if err != nil {
+		return nil, err
+	}
+	secret := toSecretColumns(envelope)
+
+	operation := entities.SyncOperationRestore
+	if seed.IsActive.Bool {
+		operation = entities.SyncOperationUpdate
+		if err := createRevisions(ctx, queries, userID, reason, id); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := queries.ApplyTOTPSeedVersion(ctx, db.ApplyTOTPSeedVersionParams{
+		ServiceName:       version.Issuer,
+		AccountIdentifier: version.Label,
+		EncryptedSecret:   secret.Ciphertext,
+		Algorithm:         version.Algorithm,
+		Digits:            int32(version.Digits),
+		Period:            int32(version.Period),
+		IconUrl:           pgtype.Text{String: version.IconURL, Valid: version.IconURL != ""},
+		OtpType:           version.Type,
+		T0:                version.T0,
+		Tags:              nonNilTags(version.Tags),
+		FolderID:          convertOptionalUUIDToPG(version.FolderID),
+		KeyVersion:        int32(envelope.KeyVersion),
+		SecretFormat:      secret.Format,
+		CipherSuite:       secret.Suite,
+		SecretIv:          secret.IV,
+		SecretTag:         secret.Tag,
+		ID:                seed.ID,
+		UserID:            seed.UserID,
+		ExpectedRevision:  seed.Revision,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply TOTP seed version: %w", err)
+	}
+
+	if err := recordSyncOperations(ctx, queries, userID, operation, id); err != nil {
+		return nil, err
+	}
+
+	return convertToOTP(updated)
+}
+
+// createSyncConflict stores a diverged change next to the server version it diverged from,
+// attributed to the device in ctx
+func createSyncConflict(ctx context.Context, queries *db.Queries, userID uuid.UUID, change *entities.SyncChange, server *entities.OTPVersion) (*entities.SyncConflict, error) {
+	serverData, err := json.Marshal(server)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode server version: %w", err)
+	}
+	clientData, err := json.Marshal(change.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode client version: %w", err)
+	}
+
+	deviceID := entities.RequestInfoFromContext(ctx).DeviceID
+	row, err := queries.CreateSyncConflict(ctx, db.CreateSyncConflictParams{
+		UserID:        convertUUIDToPG(userID),
+		SeedID:        convertUUIDToPG(change.ID),
+		DeviceID:      pgtype.Text{String: deviceID, Valid: deviceID != ""},
+		BaseRevision:  change.BaseRevision,
+		ServerVersion: serverData,
+		ClientVersion: clientData,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sync conflict: %w", err)
+	}
+
+	return convertToSyncConflict(row)
+}
+
+// recordSyncOperations logs a sync operation and an audit log entry for each entry, attributed
+// to the device and request in ctx. It locks the user's sync sequence and the audit log chain
+// until the transaction ends, so it must come after the transaction's other writes.
+func recordSyncOperations(ctx context.Context, queries *db.Queries, userID uuid.UUID, operation string, ids ...uuid.UUID) error {
+	entityIDs := make([]pgtype.UUID, 0, len(ids))
+	for _, id := range ids {
+		entityIDs = append(entityIDs, convertUUIDToPG(id))
+	}
+
+	return recordSyncOperationsPG(ctx, queries, convertUUIDToPG(userID), operation, entityIDs)
+}
+
+// recordSyncOperationsPG is recordSyncOperations for IDs returned by a query
+func recordSyncOperationsPG(ctx context.Context, queries *db.Queries, userID pgtype.UUID, operation string, ids []pgtype.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := queries.CreateTOTPSeedSyncOperations(ctx, db.CreateTOTPSeedSyncOperationsParams{
+		UserID:            userID,
+		EntityIds:         ids,
+		OperationType:     operation,
+		DeviceFingerprint: entities.RequestInfoFromContext(ctx).DeviceID,
+	}); err != nil {
+		return fmt.Errorf("failed to record sync operation: %w", err)
+	}
+
+	return appendAuditLogs(ctx, queries, auditEntryChanges(ctx, userID, operation, ids)...)
+}
+
+// convertToSyncDevice converts a database device session to a domain sync device
+func convertToSyncDevice(row db.DeviceSession) *entities.SyncDevice {
+	return &entities.SyncDevice{
+		ID:          convertPGUUID(row.ID),
+		Fingerprint: row.DeviceFingerprint,
+		Name:        row.DeviceName.String,
+		LastSyncAt:  convertPGTimestamp(row.LastSyncAt),
+		CreatedAt:   convertPGTimestamp(row.CreatedAt),
+	}
+}
+
+// convertToOTPVersion captures an entry's current content as a conflict version. Trashed entries
+// keep their content so the user can still see what was deleted.
+func convertToOTPVersion(seed db.EncryptedTotpSeed) *entities.OTPVersion {
+	return &entities.OTPVersion{
+		Deleted:    !seed.IsActive.Bool,
+		Revision:   seed.Revision,
+		Issuer:     seed.ServiceName,
+		IconURL:    seed.IconUrl.String,
+		Label:      seed.AccountIdentifier,
+		Secret:     formatSecret(seed.SecretFormat, seed.CipherSuite, seed.KeyVersion, seed.SecretIv, seed.SecretTag, seed.EncryptedSecret),
+		Algorithm:  seed.Algorithm,
+		Digits:     int(seed.Digits),
+		Period:     int(seed.Period),
+		Type:       seed.OtpType,
+		T0:         seed.T0,
+		Tags:       nonNilTags(seed.Tags),
+		FolderID:   convertPGUUIDToOptional(seed.FolderID),
+		KeyVersion: int(seed.KeyVersion),
+	}
+}
+
+// convertToSyncConflict converts a database sync conflict to a domain sync conflict
+func convertToSyncConflict(row db.SyncConflict) (*entities.SyncConflict, error) {
+	conflict := &entities.SyncConflict{
+		ID:           convertPGUUID(row.ID),
+		OTPID:        convertPGUUID(row.SeedID),
+		DeviceID:     row.DeviceID.String,
+		BaseRevision: row.BaseRevision,
+		Status:       row.Status,
+		Resolution:   row.Resolution.String,
+		CreatedAt:    convertPGTimestamp(row.CreatedAt),
+	}
+	if err := json.Unmarshal(row.ServerVersion, &conflict.Server); err != nil {
+		return nil, fmt.Errorf("failed to decode server version: %w", err)
+	}
+	if err := json.Unmarshal(row.ClientVersion, &conflict.Client); err != nil {
+		return nil, fmt.Errorf("failed to decode client version: %w", err)
+	}
+	if row.ResolvedAt.Valid {
+		conflict.ResolvedAt = &row.ResolvedAt.Time
+	}
+
+	return conflict, nil
+}
+
+// syncCursor is the last sync sequence number a device has seen. Clients treat the encoded form
+// as opaque.
+type syncCursor struct {
+	Seq int64 `json:"q"`
+}
+
+// encodeSyncCursor serializes a sequence number as URL-safe base64 JSON
+func encodeSyncCursor(seq int64) string {
+	data, _ := json.Marshal(syncCursor{Seq: seq})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSyncCursor parses a cursor produced by encodeSyncCursor
+func decodeSyncCursor(encoded string) (int64, error) {
+	var cursor syncCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return 0, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if cursor.Seq < 0 {
+		return 0, fmt.Errorf("%w: negative position", entities.ErrInvalidCursor)
+	}
+
+	return cursor.Seq, nil
+}
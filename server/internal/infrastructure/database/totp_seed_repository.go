@@ -0,0 +1,325 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+// totpSeedRepository exposes vault entries as their encrypted parts. It shares the table, the
+// revision history and the sync log with the OTP repository.
+type totpSeedRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewTOTPSeedRepository creates a new TOTP seed repository
+func NewTOTPSeedRepository(database *DB) interfaces.TOTPSeedRepository {
+	return &totpSeedRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create stores a new entry with the code parameters' defaults
+func (r *totpSeedRepository) Create(ctx context.Context, seed *entities.EncryptedTOTPSeed) error {
+	if err := seed.Validate(); err != nil {
+		return err
+	}
+
+	params := db.CreateEncryptedTOTPSeedParams{
+		UserID:            convertUUIDToPG(seed.UserID),
+		ServiceName:       seed.Issuer,
+		AccountIdentifier: seed.AccountName,
+		EncryptedSecret:   seed.Ciphertext,
+		Algorithm:         "SHA1",
+		Digits:            6,
+		Period:            30,
+		Issuer:            pgtype.Text{String: seed.Issuer, Valid: true},
+		IconUrl:           convertOptionalStringToPG(seed.IconURL),
+		IsActive:          pgtype.Bool{Bool: true, Valid: true},
+		Method:            entities.OTPMethodTOTP,
+		OtpType:           entities.OTPTypeStandard,
+		Tags:              nonNilTags(seed.Tags),
+		KeyVersion:        int32(seed.KeyVersion),
+		SecretFormat:      entities.SecretEnvelopeFormat,
+		CipherSuite:       int16(seed.CipherSuite),
+		SecretIv:          seed.IV,
+		SecretTag:         seed.AuthTag,
+	}
+
+	var row db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		var err error
+		row, err = queries.CreateEncryptedTOTPSeed(ctx, params)
+		if err != nil {
+			return fmt.Errorf("failed to create encrypted TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, seed.UserID, entities.SyncOperationCreate, convertPGUUID(row.ID))
+	})
+	if err != nil {
+		return err
+	}
+
+	seed.ID = convertPGUUID(row.ID)
+	seed.CreatedAt = convertPGTimestamp(row.CreatedAt)
+	seed.UpdatedAt = convertPGTimestamp(row.UpdatedAt)
+	seed.SyncedAt = seed.UpdatedAt
+	seed.Revision = row.Revision
+
+	return nil
+}
+
+// GetByID retrieves an active entry
+func (r *totpSeedRepository) GetByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*entities.EncryptedTOTPSeed, error) {
+	row, err := r.queries.GetEncryptedTOTPSeedByID(ctx, db.GetEncryptedTOTPSeedByIDParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, entities.ErrTOTPSeedNotFound
+		}
+		return nil, fmt.Errorf("failed to get encrypted TOTP seed: %w", err)
+	}
+
+	return convertToEncryptedTOTPSeed(row)
+}
+
+// GetAllByUserID retrieves the user's active entries, newest first
+func (r *totpSeedRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.EncryptedTOTPSeed, error) {
+	rows, err := r.queries.GetEncryptedTOTPSeedsByUserID(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+	}
+
+	return convertToEncryptedTOTPSeeds(rows)
+}
+
+// GetByUserIDSince retrieves the user's active entries changed after since, oldest change first
+func (r *totpSeedRepository) GetByUserIDSince(ctx context.Context, userID uuid.UUID, since time.Time) ([]*entities.EncryptedTOTPSeed, error) {
+	rows, err := r.queries.GetEncryptedTOTPSeedsByUserIDSince(ctx, db.GetEncryptedTOTPSeedsByUserIDSinceParams{
+		UserID:    convertUUIDToPG(userID),
+		UpdatedAt: pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+	}
+
+	return convertToEncryptedTOTPSeeds(rows)
+}
+
+// Update replaces an entry's secret and metadata, recording the version it replaces as a
+// revision. A non-zero Revision makes the update conditional on the entry still being at it.
+func (r *totpSeedRepository) Update(ctx context.Context, seed *entities.EncryptedTOTPSeed) error {
+	if err := seed.Validate(); err != nil {
+		return err
+	}
+
+	iconURL := ""
+	if seed.IconURL != nil {
+		iconURL = *seed.IconURL
+	}
+	params := db.UpdateEncryptedTOTPSeedParams{
+		ID:                convertUUIDToPG(seed.ID),
+		UserID:            convertUUIDToPG(seed.UserID),
+		ServiceName:       pgtype.Text{String: seed.Issuer, Valid: true},
+		AccountIdentifier: pgtype.Text{String: seed.AccountName, Valid: true},
+		EncryptedSecret:   seed.Ciphertext,
+		Issuer:            pgtype.Text{String: seed.Issuer, Valid: true},
+		IconUrl:           pgtype.Text{String: iconURL, Valid: true},
+		Tags:              nonNilTags(seed.Tags),
+		KeyVersion:        pgtype.Int4{Int32: int32(seed.KeyVersion), Valid: true},
+		SecretFormat:      pgtype.Int2{Int16: entities.SecretEnvelopeFormat, Valid: true},
+		CipherSuite:       pgtype.Int2{Int16: int16(seed.CipherSuite), Valid: true},
+		SecretIv:          seed.IV,
+		SecretTag:         seed.AuthTag,
+		ExpectedRevision:  pgtype.Int8{Int64: seed.Revision, Valid: seed.Revision > 0},
+	}
+
+	var row db.EncryptedTotpSeed
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		if err := createRevisions(ctx, queries, seed.UserID, entities.OTPRevisionUpdate, seed.ID); err != nil {
+			return err
+		}
+
+		var err error
+		row, err = queries.UpdateEncryptedTOTPSeed(ctx, params)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entities.ErrRevisionMismatch
+			}
+			return fmt.Errorf("failed to update encrypted TOTP seed: %w", err)
+		}
+
+		return recordSyncOperations(ctx, queries, seed.UserID, entities.SyncOperationUpdate, seed.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	seed.UpdatedAt = convertPGTimestamp(row.UpdatedAt)
+	seed.Revision = row.Revision
+
+	return nil
+}
+
+// Delete moves an entry to the trash
+func (r *totpSeedRepository) Delete(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		deleted, err := queries.DeleteEncryptedTOTPSeed(ctx, db.DeleteEncryptedTOTPSeedParams{
+			ID:     convertUUIDToPG(id),
+			UserID: convertUUIDToPG(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete encrypted TOTP seed: %w", err)
+		}
+		if deleted == 0 {
+			return entities.ErrTOTPSeedNotFound
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationDelete, id)
+	})
+}
+
+// Search retrieves the user's active entries whose issuer or account name contains query
+func (r *totpSeedRepository) Search(ctx context.Context, userID uuid.UUID, query string) ([]*entities.EncryptedTOTPSeed, error) {
+	rows, err := r.queries.SearchEncryptedTOTPSeeds(ctx, db.SearchEncryptedTOTPSeedsParams{
+		UserID:  convertUUIDToPG(userID),
+		Column2: pgtype.Text{String: query, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search encrypted TOTP seeds: %w", err)
+	}
+
+	return convertToEncryptedTOTPSeeds(rows)
+}
+
+// UpdateSyncTimestamp marks an entry as changed now
+func (r *totpSeedRepository) UpdateSyncTimestamp(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	if err := r.queries.UpdateTOTPSeedSyncTimestamp(ctx, db.UpdateTOTPSeedSyncTimestampParams{
+		ID:     convertUUIDToPG(id),
+		UserID: convertUUIDToPG(userID),
+	}); err != nil {
+		return fmt.Errorf("failed to update sync timestamp: %w", err)
+	}
+
+	return nil
+}
+
+// GetCount returns the number of the user's active entries
+func (r *totpSeedRepository) GetCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := r.queries.GetTOTPSeedsCountByUser(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count encrypted TOTP seeds: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetByKeyVersion retrieves the user's entries encrypted with keyVersion, trashed ones included
+func (r *totpSeedRepository) GetByKeyVersion(ctx context.Context, userID uuid.UUID, keyVersion int) ([]*entities.EncryptedTOTPSeed, error) {
+	rows, err := r.queries.GetEncryptedTOTPSeedsByKeyVersion(ctx, db.GetEncryptedTOTPSeedsByKeyVersionParams{
+		UserID:     convertUUIDToPG(userID),
+		KeyVersion: int32(keyVersion),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+	}
+
+	return convertToEncryptedTOTPSeeds(rows)
+}
+
+// UpdateKeyVersion relabels the user's entries from oldVersion to newVersion without touching
+// their secrets, for a key that was rewrapped rather than replaced
+func (r *totpSeedRepository) UpdateKeyVersion(ctx context.Context, userID uuid.UUID, oldVersion, newVersion int) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		rows, err := queries.UpdateTOTPSeedKeyVersion(ctx, db.UpdateTOTPSeedKeyVersionParams{
+			NewVersion: int32(newVersion),
+			UserID:     convertUUIDToPG(userID),
+			OldVersion: int32(oldVersion),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update key version: %w", err)
+		}
+
+		// Trashed entries are not in the devices' copies of the vault
+		updated := make([]pgtype.UUID, 0, len(rows))
+		for _, row := range rows {
+			if row.IsActive.Bool {
+				updated = append(updated, row.ID)
+			}
+		}
+
+		return recordSyncOperationsPG(ctx, queries, convertUUIDToPG(userID), entities.SyncOperationUpdate, updated)
+	})
+}
+
+// convertToEncryptedTOTPSeed converts a database entry to its encrypted parts
+func convertToEncryptedTOTPSeed(row db.EncryptedTotpSeed) (*entities.EncryptedTOTPSeed, error) {
+	envelope, err := toSecretEnvelope(row.SecretFormat, row.CipherSuite, row.KeyVersion, row.SecretIv, row.SecretTag, row.EncryptedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("entry %s: %w", convertPGUUID(row.ID), err)
+	}
+
+	seed := &entities.EncryptedTOTPSeed{
+		ID:          convertPGUUID(row.ID),
+		UserID:      convertPGUUID(row.UserID),
+		KeyVersion:  envelope.KeyVersion,
+		CipherSuite: envelope.Suite,
+		Ciphertext:  envelope.Ciphertext,
+		IV:          envelope.IV,
+		AuthTag:     envelope.AuthTag,
+		Issuer:      row.ServiceName,
+		AccountName: row.AccountIdentifier,
+		Tags:        nonNilTags(row.Tags),
+		CreatedAt:   convertPGTimestamp(row.CreatedAt),
+		UpdatedAt:   convertPGTimestamp(row.UpdatedAt),
+		SyncedAt:    convertPGTimestamp(row.UpdatedAt),
+		Revision:    row.Revision,
+	}
+	if row.IconUrl.Valid && row.IconUrl.String != "" {
+		seed.IconURL = &row.IconUrl.String
+	}
+
+	return seed, nil
+}
+
+func convertToEncryptedTOTPSeeds(rows []db.EncryptedTotpSeed) ([]*entities.EncryptedTOTPSeed, error) {
+	seeds := make([]*entities.EncryptedTOTPSeed, 0, len(rows))
+	for _, row := range rows {
+		seed, err := convertToEncryptedTOTPSeed(row)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, seed)
+	}
+
+	return seeds, nil
+}
+
+// convertOptionalStringToPG converts an optional string to a nullable text
+func convertOptionalStringToPG(s *string) pgtype.Text {
+	if s == nil || *s == "" {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -46,6 +48,8 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 	user.CreatedAt = convertPGTimestamp(dbUser.CreatedAt)
 	user.UpdatedAt = convertPGTimestamp(dbUser.UpdatedAt)
 	user.IsActive = convertPGBool(dbUser.IsActive)
+	user.Role = dbUser.Role
+	user.TokenVersion = int(dbUser.TokenVersion)
 
 	return nil
 }
@@ -164,8 +168,123 @@ func (r *UserRepository) ExistsByUsername(ctx context.Context, username string)
 	return true, nil
 }
 
-// Note: List and Count methods are not implemented yet as the SQLC queries are not generated
-// These will be added in a future iteration when we have the proper queries
+// Reactivate marks a deactivated user as active again
+func (r *UserRepository) Reactivate(ctx context.Context, userID uuid.UUID) error {
+	if err := r.queries.ReactivateUser(ctx, convertUUIDToPG(userID)); err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeTokens bumps the user's token version, invalidating every token issued so far
+func (r *UserRepository) RevokeTokens(ctx context.Context, userID uuid.UUID) error {
+	if err := r.queries.RevokeUserTokens(ctx, convertUUIDToPG(userID)); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// SetRole changes the user's role and revokes their tokens
+func (r *UserRepository) SetRole(ctx context.Context, userID uuid.UUID, role string) error {
+	err := r.queries.SetUserRole(ctx, db.SetUserRoleParams{
+		ID:   convertUUIDToPG(userID),
+		Role: role,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+
+	return nil
+}
+
+// List retrieves a page of users matching a normalized filter, newest first
+func (r *UserRepository) List(ctx context.Context, filter entities.UserFilter) (*entities.UserPage, error) {
+	params := db.SearchUsersParams{
+		Query:     pgtype.Text{String: filter.Query, Valid: filter.Query != ""},
+		Role:      pgtype.Text{String: filter.Role, Valid: filter.Role != ""},
+		IsActive:  pgtype.Bool{Bool: filter.Status == entities.UserStatusActive, Valid: filter.Status != ""},
+		PageLimit: int32(filter.Limit + 1), // One extra row tells whether there is a next page
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeUserCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.BeforeCreatedAt = pgtype.Timestamptz{Time: time.UnixMicro(cursor.CreatedAt), Valid: true}
+		params.BeforeID = convertUUIDToPG(cursor.ID)
+	}
+
+	rows, err := r.queries.SearchUsers(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	page := &entities.UserPage{Items: make([]*entities.User, 0, len(rows))}
+	if len(rows) > filter.Limit {
+		rows = rows[:filter.Limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = encodeUserCursor(userCursor{
+			CreatedAt: convertPGTimestamp(last.CreatedAt).UnixMicro(),
+			ID:        convertPGUUID(last.ID),
+		})
+	}
+	for _, row := range rows {
+		page.Items = append(page.Items, convertDBUserToEntity(row))
+	}
+
+	return page, nil
+}
+
+// GetStats computes aggregate counts, counting new and signed-in users from since
+func (r *UserRepository) GetStats(ctx context.Context, since time.Time) (*entities.UserStats, error) {
+	row, err := r.queries.GetUserStats(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", err)
+	}
+
+	return &entities.UserStats{
+		TotalUsers:     row.TotalUsers,
+		ActiveUsers:    row.ActiveUsers,
+		SuspendedUsers: row.TotalUsers - row.ActiveUsers,
+		AdminUsers:     row.AdminUsers,
+		NewUsers:       row.NewUsers,
+		SignedInUsers:  row.SignedInUsers,
+		VaultEntries:   row.VaultEntries,
+		Passkeys:       row.Passkeys,
+	}, nil
+}
+
+// userCursor is the keyset position after the last user of a page
+type userCursor struct {
+	CreatedAt int64     `json:"c"` // Unix microseconds, the precision PostgreSQL stores
+	ID        uuid.UUID `json:"i"`
+}
+
+// encodeUserCursor serializes a cursor as URL-safe base64 JSON
+func encodeUserCursor(cursor userCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeUserCursor parses a cursor produced by encodeUserCursor
+func decodeUserCursor(encoded string) (userCursor, error) {
+	var cursor userCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: %v", entities.ErrInvalidCursor, err)
+	}
+	if cursor.ID == uuid.Nil {
+		return cursor, fmt.Errorf("%w: missing position", entities.ErrInvalidCursor)
+	}
+
+	return cursor, nil
+}
 
 // Helper functions to convert between pgtype and Go types
 
@@ -199,13 +318,15 @@ func convertPGBool(pgBool pgtype.Bool) bool {
 
 func convertDBUserToEntity(dbUser db.User) *entities.User {
 	user := &entities.User{
-		ID:          convertPGUUID(dbUser.ID),
-		Username:    dbUser.Username,
-		Email:       dbUser.Email,
-		DisplayName: dbUser.DisplayName,
-		CreatedAt:   convertPGTimestamp(dbUser.CreatedAt),
-		UpdatedAt:   convertPGTimestamp(dbUser.UpdatedAt),
-		IsActive:    convertPGBool(dbUser.IsActive),
+		ID:           convertPGUUID(dbUser.ID),
+		Username:     dbUser.Username,
+		Email:        dbUser.Email,
+		DisplayName:  dbUser.DisplayName,
+		CreatedAt:    convertPGTimestamp(dbUser.CreatedAt),
+		UpdatedAt:    convertPGTimestamp(dbUser.UpdatedAt),
+		IsActive:     convertPGBool(dbUser.IsActive),
+		Role:         dbUser.Role,
+		TokenVersion: int(dbUser.TokenVersion),
 	}
 
 	// Handle nullable last login timestamp
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// vaultEventChannel is the channel notify_vault_event() notifies on
+const vaultEventChannel = "vault_events"
+
+type vaultEventListener struct {
+	db *DB
+}
+
+// NewVaultEventListener creates a listener for vault event notifications
+func NewVaultEventListener(database *DB) interfaces.VaultEventListener {
+	return &vaultEventListener{
+		db: database,
+	}
+}
+
+// Listen holds a dedicated connection listening on the vault events channel
+func (l *vaultEventListener) Listen(ctx context.Context, notify func(userID uuid.UUID)) error {
+	pooled, err := l.db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// Take the connection out of the pool so it never goes back still listening
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+vaultEventChannel); err != nil {
+		return fmt.Errorf("failed to listen for vault events: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for vault events: %w", err)
+		}
+
+		userID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			slog.Warn("Ignoring malformed vault event notification", "payload", notification.Payload)
+			continue
+		}
+
+		notify(userID)
+	}
+}
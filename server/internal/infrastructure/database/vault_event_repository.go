@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type vaultEventRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewVaultEventRepository creates a new vault event repository
+func NewVaultEventRepository(database *DB) interfaces.VaultEventRepository {
+	return &vaultEventRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Create records an event for the user
+func (r *vaultEventRepository) Create(ctx context.Context, userID uuid.UUID, eventType string, entityID *uuid.UUID) error {
+	return recordVaultEvent(ctx, r.queries, userID, eventType, entityID)
+}
+
+// ListSince retrieves up to limit of the user's events after cursor, oldest first
+func (r *vaultEventRepository) ListSince(ctx context.Context, userID uuid.UUID, cursor string, limit int) ([]*entities.VaultEvent, error) {
+	userUUID := convertUUIDToPG(userID)
+
+	var afterSeq int64
+	if cursor != "" {
+		var err error
+		afterSeq, err = decodeSyncCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := r.queries.GetVaultEventsSince(ctx, db.GetVaultEventsSinceParams{
+		UserID:    userUUID,
+		AfterSeq:  afterSeq,
+		MaxEvents: int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault events: %w", err)
+	}
+
+	// Only a cursor with nothing after it can be ahead of the vault
+	if len(rows) == 0 {
+		lastSeq, err := r.queries.GetSyncSequence(ctx, userUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sync sequence: %w", err)
+		}
+		if afterSeq > lastSeq {
+			return nil, fmt.Errorf("%w: cursor is ahead of the vault", entities.ErrInvalidCursor)
+		}
+	}
+
+	events := make([]*entities.VaultEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, convertToVaultEvent(row))
+	}
+
+	return events, nil
+}
+
+// Head returns the cursor of the user's latest event
+func (r *vaultEventRepository) Head(ctx context.Context, userID uuid.UUID) (string, error) {
+	lastSeq, err := r.queries.GetSyncSequence(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get sync sequence: %w", err)
+	}
+
+	return encodeSyncCursor(lastSeq), nil
+}
+
+// recordVaultEvent logs an event that is not an entry change, attributed to the device in ctx.
+// Like recordSyncOperations it must be the last write of its transaction.
+func recordVaultEvent(ctx context.Context, queries *db.Queries, userID uuid.UUID, eventType string, entityID *uuid.UUID) error {
+	deviceID := entities.RequestInfoFromContext(ctx).DeviceID
+
+	if err := queries.CreateVaultEvent(ctx, db.CreateVaultEventParams{
+		UserID:    convertUUIDToPG(userID),
+		EventType: eventType,
+		EntityID:  convertOptionalUUIDToPG(entityID),
+		DeviceID:  pgtype.Text{String: deviceID, Valid: deviceID != ""},
+	}); err != nil {
+		return fmt.Errorf("failed to record vault event: %w", err)
+	}
+
+	return nil
+}
+
+// convertToVaultEvent converts a logged sync operation or event to a domain vault event
+func convertToVaultEvent(row db.GetVaultEventsSinceRow) *entities.VaultEvent {
+	event := &entities.VaultEvent{
+		Cursor:    encodeSyncCursor(row.Seq),
+		Type:      row.EventType.String,
+		EntityID:  convertPGUUIDToOptional(row.EntityID),
+		Revision:  row.Revision.Int64,
+		DeviceID:  row.DeviceID.String,
+		CreatedAt: convertPGTimestamp(row.CreatedAt),
+	}
+
+	if row.Operation.Valid {
+		event.Type = entities.VaultEventTypeForOperation(row.Operation.String)
+		event.Operation = row.Operation.String
+	}
+
+	return event
+}
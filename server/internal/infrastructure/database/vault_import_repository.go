@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
+)
+
+type vaultImportRepository struct {
+	db      *DB
+	queries *db.Queries
+}
+
+// NewVaultImportRepository creates a new vault import repository
+func NewVaultImportRepository(database *DB) interfaces.VaultImportRepository {
+	return &vaultImportRepository{
+		db:      database,
+		queries: db.New(database.Pool),
+	}
+}
+
+// Import restores a prepared export into the user's vault in one transaction
+func (r *vaultImportRepository) Import(ctx context.Context, userID uuid.UUID, vault *entities.VaultImport) (*entities.VaultImportResult, error) {
+	result := &entities.VaultImportResult{Mode: vault.Mode}
+
+	err := r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		for _, key := range vault.Keys {
+			if err := createEncryptionKey(ctx, queries, key); err != nil {
+				return err
+			}
+			result.KeysRestored++
+		}
+
+		folderIDs, err := importFolders(ctx, queries, userID, vault.Folders, result)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]pgtype.UUID, 0, len(vault.Entries))
+		for _, entry := range vault.Entries {
+			ids = append(ids, convertUUIDToPG(entry.ID))
+		}
+		owned, err := queries.GetEncryptedTOTPSeedsByIDs(ctx, db.GetEncryptedTOTPSeedsByIDsParams{
+			UserID: convertUUIDToPG(userID),
+			Ids:    ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+		}
+		existing := make(map[uuid.UUID]bool, len(owned))
+		for _, seed := range owned {
+			existing[convertPGUUID(seed.ID)] = true
+		}
+		takenIDs, err := queries.ListTakenTOTPSeedIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to check TOTP seed IDs: %w", err)
+		}
+		taken := make(map[uuid.UUID]bool, len(takenIDs))
+		for _, id := range takenIDs {
+			taken[convertPGUUID(id)] = true
+		}
+
+		kept := make(map[uuid.UUID]bool, len(vault.Entries))
+		var rows []db.CreateEncryptedTOTPSeedsParams
+		var created []uuid.UUID
+		for _, entry := range vault.Entries {
+			var folderID *uuid.UUID
+			if entry.FolderID != nil {
+				id := folderIDs[*entry.FolderID]
+				folderID = &id
+			}
+
+			if existing[entry.ID] {
+				kept[entry.ID] = true
+				if vault.Mode == entities.VaultImportMerge {
+					result.Skipped++
+					continue
+				}
+
+				seed, err := lockSeedForSync(ctx, queries, entry.ID, userID)
+				if err != nil {
+					return err
+				}
+				if _, err := applyOTPVersion(ctx, queries, userID, seed, importedVersion(entry, folderID), entities.OTPRevisionImport); err != nil {
+					return fmt.Errorf("entry %s: %w", entry.ID, err)
+				}
+				result.Replaced++
+				continue
+			}
+
+			// Another user's entry has the ID, so this one gets a new ID
+			id := entry.ID
+			if taken[id] {
+				id = uuid.New()
+			}
+			kept[id] = true
+
+			envelope, err := entities.ParseSecretEnvelope(entry.Secret)
+			if err != nil {
+				return fmt.Errorf("entry %s: %w", entry.ID, err)
+			}
+			secret := toSecretColumns(envelope)
+			rows = append(rows, db.CreateEncryptedTOTPSeedsParams{
+				ID:                convertUUIDToPG(id),
+				UserID:            convertUUIDToPG(userID),
+				ServiceName:       entry.Issuer,
+				AccountIdentifier: entry.Label,
+				EncryptedSecret:   secret.Ciphertext,
+				Algorithm:         entry.Algorithm,
+				Digits:            int32(entry.Digits),
+				Period:            int32(entry.Period),
+				Issuer:            pgtype.Text{String: entry.Issuer, Valid: true},
+				IconUrl:           pgtype.Text{String: entry.IconURL, Valid: entry.IconURL != ""},
+				IsActive:          pgtype.Bool{Bool: true, Valid: true},
+				Method:            entry.Method,
+				Counter:           entry.Counter,
+				OtpType:           entry.Type,
+				T0:                entry.T0,
+				Tags:              nonNilTags(entry.Tags),
+				FolderID:          convertOptionalUUIDToPG(folderID),
+				KeyVersion:        int32(envelope.KeyVersion),
+				SecretFormat:      secret.Format,
+				CipherSuite:       secret.Suite,
+				SecretIv:          secret.IV,
+				SecretTag:         secret.Tag,
+			})
+			created = append(created, id)
+		}
+
+		if vault.Mode == entities.VaultImportReplace {
+			active, err := queries.GetEncryptedTOTPSeedsByUserID(ctx, convertUUIDToPG(userID))
+			if err != nil {
+				return fmt.Errorf("failed to get encrypted TOTP seeds: %w", err)
+			}
+			for _, row := range active {
+				id := convertPGUUID(row.ID)
+				if kept[id] {
+					continue
+				}
+				seed, err := lockSeedForSync(ctx, queries, id, userID)
+				if err != nil {
+					return err
+				}
+				if _, err := applyOTPVersion(ctx, queries, userID, seed, &entities.OTPVersion{Deleted: true}, entities.OTPRevisionImport); err != nil {
+					return err
+				}
+				result.Trashed++
+			}
+		}
+
+		if len(rows) > 0 {
+			if _, err := queries.CreateEncryptedTOTPSeeds(ctx, rows); err != nil {
+				return fmt.Errorf("failed to create encrypted TOTP seeds: %w", err)
+			}
+			result.Created = len(rows)
+		}
+
+		return recordSyncOperations(ctx, queries, userID, entities.SyncOperationCreate, created...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importFolders finds each of the file's folders in the vault by its name under the same parent,
+// creating the missing ones, and returns the vault folder ID of each file folder ID. Folders must
+// come parents first.
+func importFolders(ctx context.Context, queries *db.Queries, userID uuid.UUID, folders []*entities.VaultExportFolder, result *entities.VaultImportResult) (map[uuid.UUID]uuid.UUID, error) {
+	// Sibling names are unique ignoring case, as the folders index enforces
+	type siblingName struct {
+		parentID uuid.UUID
+		name     string
+	}
+
+	rows, err := queries.ListFoldersByUser(ctx, convertUUIDToPG(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	existing := make(map[siblingName]uuid.UUID, len(rows))
+	for _, row := range rows {
+		existing[siblingName{convertPGUUID(row.ParentID), strings.ToLower(row.Name)}] = convertPGUUID(row.ID)
+	}
+
+	mapped := make(map[uuid.UUID]uuid.UUID, len(folders))
+	for _, folder := range folders {
+		var parentID *uuid.UUID
+		if folder.ParentID != nil {
+			id := mapped[*folder.ParentID]
+			parentID = &id
+		}
+
+		key := siblingName{name: strings.ToLower(folder.Name)}
+		if parentID != nil {
+			key.parentID = *parentID
+		}
+		if id, ok := existing[key]; ok {
+			mapped[folder.ID] = id
+			continue
+		}
+
+		row, err := queries.CreateFolder(ctx, db.CreateFolderParams{
+			UserID:   convertUUIDToPG(userID),
+			ParentID: convertOptionalUUIDToPG(parentID),
+			Name:     folder.Name,
+		})
+		if err != nil {
+			return nil, convertFolderError(err, "failed to create folder")
+		}
+		id := convertPGUUID(row.ID)
+		existing[key] = id
+		mapped[folder.ID] = id
+		result.FoldersCreated++
+	}
+
+	return mapped, nil
+}
+
+// importedVersion is an exported entry as the version that overwrites the vault's copy
+func importedVersion(entry *entities.VaultExportEntry, folderID *uuid.UUID) *entities.OTPVersion {
+	return &entities.OTPVersion{
+		Issuer:    entry.Issuer,
+		IconURL:   entry.IconURL,
+		Label:     entry.Label,
+		Secret:    entry.Secret,
+		Algorithm: entry.Algorithm,
+		Digits:    entry.Digits,
+		Period:    entry.Period,
+		Type:      entry.Type,
+		T0:        entry.T0,
+		Tags:      entry.Tags,
+		FolderID:  folderID,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 	db "github.com/bug-breeder/2fair/server/internal/infrastructure/database/sqlc"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -62,8 +63,17 @@ func (r *webAuthnCredentialRepository) Create(ctx context.Context, credential *e
 		BackupState:     credential.BackupState,
 	}
 
-	_, err := r.queries.CreateWebAuthnCredential(ctx, params)
-	return err
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		created, err := queries.CreateWebAuthnCredential(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		createdID := convertPGUUID(created.ID)
+		return recordVaultEvent(ctx, queries, credential.UserID, entities.VaultEventCredentialAdded, &createdID)
+	})
 }
 
 // GetByID retrieves a WebAuthn credential by credential ID
@@ -131,6 +141,44 @@ func (r *webAuthnCredentialRepository) Delete(ctx context.Context, credentialID
 	return nil
 }
 
+// DeleteByID deletes a credential unless it holds the user's last active wrap of the vault key
+func (r *webAuthnCredentialRepository) DeleteByID(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	return r.db.WithTransaction(ctx, func(tx pgx.Tx) error {
+		queries := r.queries.WithTx(tx)
+
+		wrapped, err := queries.LockActiveUserEncryptionKeyCredentials(ctx, convertUUIDToPG(userID))
+		if err != nil {
+			return fmt.Errorf("failed to lock encryption keys: %w", err)
+		}
+
+		// A credential without an active wrap can always go; one with the only wrap cannot
+		holdsWrap, othersHoldWrap := false, false
+		for _, credentialID := range wrapped {
+			if convertPGUUID(credentialID) == id {
+				holdsWrap = true
+			} else {
+				othersHoldWrap = true
+			}
+		}
+		if holdsWrap && !othersHoldWrap {
+			return entities.ErrLastKeyCredential
+		}
+
+		deleted, err := queries.DeleteWebAuthnCredentialByUUID(ctx, db.DeleteWebAuthnCredentialByUUIDParams{
+			ID:     convertUUIDToPG(id),
+			UserID: convertUUIDToPG(userID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete WebAuthn credential: %w", err)
+		}
+		if deleted == 0 {
+			return entities.ErrCredentialNotFound
+		}
+
+		return nil
+	})
+}
+
 // ExistsByCredentialID checks if a credential exists by credential ID
 func (r *webAuthnCredentialRepository) ExistsByCredentialID(ctx context.Context, credentialID []byte) (bool, error) {
 	_, err := r.queries.GetWebAuthnCredentialByID(ctx, credentialID)
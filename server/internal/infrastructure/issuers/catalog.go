@@ -0,0 +1,172 @@
+// Package issuers provides the bundled, offline catalog of known OTP issuers.
+package issuers
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+//go:embed catalog.json icons/*.svg
+var assets embed.FS
+
+// catalogEntry is an issuer as stored in catalog.json
+type catalogEntry struct {
+	Name      string   `json:"name"`
+	Aliases   []string `json:"aliases"`
+	Domains   []string `json:"domains"`
+	Icon      string   `json:"icon"` // File name under icons/
+	Type      string   `json:"type"`
+	Digits    int      `json:"digits"`
+	Period    int      `json:"period"`
+	Algorithm string   `json:"algorithm"`
+}
+
+type catalog struct {
+	issuers []*entities.Issuer
+	keys    map[string]*entities.Issuer // Matching keys of names, aliases and domains
+	icons   map[string]bool
+}
+
+// NewCatalog loads the bundled catalog. Icon URLs are iconBaseURL followed by the icon file name.
+func NewCatalog(iconBaseURL string) (interfaces.IssuerCatalog, error) {
+	data, err := assets.ReadFile("catalog.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read issuer catalog: %w", err)
+	}
+
+	var entries []catalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse issuer catalog: %w", err)
+	}
+
+	c := &catalog{
+		keys:  make(map[string]*entities.Issuer),
+		icons: make(map[string]bool),
+	}
+	for _, entry := range entries {
+		if _, err := assets.ReadFile(path.Join("icons", entry.Icon)); err != nil {
+			return nil, fmt.Errorf("issuer %q: missing icon %q", entry.Name, entry.Icon)
+		}
+		c.icons[entry.Icon] = true
+
+		issuer := &entities.Issuer{
+			Name:      entry.Name,
+			Aliases:   entry.Aliases,
+			Domains:   entry.Domains,
+			IconURL:   iconBaseURL + entry.Icon,
+			Type:      entry.Type,
+			Digits:    entry.Digits,
+			Period:    entry.Period,
+			Algorithm: entry.Algorithm,
+		}
+		c.issuers = append(c.issuers, issuer)
+
+		for _, name := range issuerNames(issuer) {
+			key := matchKey(name)
+			if existing, ok := c.keys[key]; ok && existing != issuer {
+				return nil, fmt.Errorf("issuers %q and %q both match %q", existing.Name, issuer.Name, name)
+			}
+			c.keys[key] = issuer
+		}
+	}
+
+	sort.Slice(c.issuers, func(i, j int) bool {
+		return strings.ToLower(c.issuers[i].Name) < strings.ToLower(c.issuers[j].Name)
+	})
+
+	return c, nil
+}
+
+// Lookup resolves an issuer name, alias or domain to its catalog entry
+func (c *catalog) Lookup(name string) (*entities.Issuer, bool) {
+	name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "www.")
+	if name == "" {
+		return nil, false
+	}
+
+	issuer, ok := c.keys[matchKey(name)]
+	return issuer, ok
+}
+
+// Search returns up to limit entries matching the query, best matches first: exact matches,
+// then prefix matches, then substring matches, each in name order
+func (c *catalog) Search(query string, limit int) []*entities.Issuer {
+	query = matchKey(query)
+
+	type match struct {
+		issuer *entities.Issuer
+		rank   int
+	}
+	var matches []match
+	for _, issuer := range c.issuers {
+		rank := 0
+		for _, name := range issuerNames(issuer) {
+			key := matchKey(name)
+			switch {
+			case key == query:
+				rank = max(rank, 3)
+			case strings.HasPrefix(key, query):
+				rank = max(rank, 2)
+			case strings.Contains(key, query):
+				rank = max(rank, 1)
+			}
+		}
+		if rank > 0 {
+			matches = append(matches, match{issuer: issuer, rank: rank})
+		}
+	}
+
+	// The catalog is in name order, so a stable sort keeps ties alphabetical
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].rank > matches[j].rank
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	results := make([]*entities.Issuer, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, m.issuer)
+	}
+	return results
+}
+
+// Icon returns a bundled icon asset and its content type
+func (c *catalog) Icon(file string) ([]byte, string, bool) {
+	if !c.icons[file] {
+		return nil, "", false
+	}
+
+	data, err := assets.ReadFile(path.Join("icons", file))
+	if err != nil {
+		return nil, "", false
+	}
+	return data, "image/svg+xml", true
+}
+
+// issuerNames returns every name an issuer can be matched by
+func issuerNames(issuer *entities.Issuer) []string {
+	names := make([]string, 0, 1+len(issuer.Aliases)+len(issuer.Domains))
+	names = append(names, issuer.Name)
+	names = append(names, issuer.Aliases...)
+	return append(names, issuer.Domains...)
+}
+
+// matchKey folds a name for matching: lowercase letters and digits only
+func matchKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package issuers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIconBaseURL = "/api/v1/issuers/icons/"
+
+func TestCatalog_Lookup(t *testing.T) {
+	catalog, err := NewCatalog(testIconBaseURL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "canonical name", input: "GitHub", expected: "GitHub"},
+		{name: "case and spacing", input: "  git hub ", expected: "GitHub"},
+		{name: "alias", input: "Twitter", expected: "X"},
+		{name: "domain", input: "github.com", expected: "GitHub"},
+		{name: "www domain", input: "www.dropbox.com", expected: "Dropbox"},
+		{name: "punctuation", input: "battle net", expected: "Battle.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, ok := catalog.Lookup(tt.input)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, issuer.Name)
+		})
+	}
+
+	_, ok := catalog.Lookup("Some Internal Tool")
+	assert.False(t, ok)
+	_, ok = catalog.Lookup("")
+	assert.False(t, ok)
+}
+
+func TestCatalog_LookupDefaults(t *testing.T) {
+	catalog, err := NewCatalog(testIconBaseURL)
+	require.NoError(t, err)
+
+	github, ok := catalog.Lookup("github")
+	require.True(t, ok)
+	assert.Equal(t, testIconBaseURL+"github.svg", github.IconURL)
+	assert.Equal(t, "SHA1", github.Algorithm)
+	assert.Equal(t, 6, github.Digits)
+	assert.Equal(t, 30, github.Period)
+	assert.Empty(t, github.Type)
+
+	steam, ok := catalog.Lookup("steampowered.com")
+	require.True(t, ok)
+	assert.Equal(t, "steam", steam.Type)
+	assert.Equal(t, 5, steam.Digits)
+}
+
+func TestCatalog_Search(t *testing.T) {
+	catalog, err := NewCatalog(testIconBaseURL)
+	require.NoError(t, err)
+
+	// Prefix matches come first, then substring matches
+	results := catalog.Search("git", 10)
+	require.Len(t, results, 3)
+	assert.Equal(t, "GitHub", results[0].Name)
+	assert.Equal(t, "GitLab", results[1].Name)
+	assert.Equal(t, "DigitalOcean", results[2].Name)
+
+	// Exact matches rank ahead of prefix matches
+	results = catalog.Search("amazon", 10)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "Amazon", results[0].Name)
+
+	results = catalog.Search("hub", 10)
+	names := make([]string, 0, len(results))
+	for _, issuer := range results {
+		names = append(names, issuer.Name)
+	}
+	assert.Contains(t, names, "GitHub")
+	assert.Contains(t, names, "Docker Hub")
+
+	assert.Len(t, catalog.Search("", 5), 5)
+}
+
+func TestCatalog_Icon(t *testing.T) {
+	catalog, err := NewCatalog(testIconBaseURL)
+	require.NoError(t, err)
+
+	data, contentType, ok := catalog.Icon("github.svg")
+	require.True(t, ok)
+	assert.Equal(t, "image/svg+xml", contentType)
+	assert.Contains(t, string(data), "<svg")
+
+	_, _, ok = catalog.Icon("../catalog.json")
+	assert.False(t, ok)
+}
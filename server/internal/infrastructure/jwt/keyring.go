@@ -0,0 +1,237 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// Algorithms a token signing key can use
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmES256 = "ES256"
+)
+
+// keyFileExt is the extension of the key files LoadKeyring reads; the rest of the name is the kid
+const keyFileExt = ".pem"
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrUnsupportedKey = errors.New("unsupported signing key: use an Ed25519 or ECDSA P-256 key")
+)
+
+// Key is a token signing key. Its ID is published in the JWKS and named in the kid header of
+// every token it signs.
+type Key struct {
+	ID        string
+	Algorithm string
+	private   crypto.Signer
+}
+
+// GenerateKey creates a random key for algorithm
+func GenerateKey(id, algorithm string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	return &Key{ID: id, Algorithm: algorithm, private: private}, nil
+}
+
+// ParseKey reads a PEM private key: a PKCS #8 Ed25519 or P-256 key, or a SEC 1 P-256 key. The
+// algorithm follows from the key type.
+func ParseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", id)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", id, err)
+	}
+
+	switch private := parsed.(type) {
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Algorithm: AlgorithmEdDSA, private: private}, nil
+	case *ecdsa.PrivateKey:
+		if private.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+		}
+		return &Key{ID: id, Algorithm: AlgorithmES256, private: private}, nil
+	default:
+		return nil, fmt.Errorf("signing key %s: %w", id, ErrUnsupportedKey)
+	}
+}
+
+// JSONWebKey returns the public half of the key
+func (k *Key) JSONWebKey() interfaces.JSONWebKey {
+	jwk := interfaces.JSONWebKey{KeyID: k.ID, Algorithm: k.Algorithm, Use: "sig"}
+
+	switch public := k.private.Public().(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	case *ecdsa.PublicKey:
+		// The uncompressed point is 0x04 followed by the fixed-width coordinates
+		point, _ := public.ECDH()
+		raw := point.Bytes()[1:]
+		jwk.KeyType = "EC"
+		jwk.Curve = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(raw[:len(raw)/2])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(raw[len(raw)/2:])
+	}
+
+	return jwk
+}
+
+// method returns the JWT signing method of the key's algorithm
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmES256 {
+		return jwt.SigningMethodES256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// Keyring holds the key new tokens are signed with and every key tokens are accepted from.
+// Rotating keys overlaps their validity: a new key is added, and published, before it becomes
+// active, and the old one stays until the tokens it signed have expired.
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeyring creates a keyring of keys that signs with the key activeID names
+func NewKeyring(activeID string, keys ...*Key) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing key has no ID")
+		}
+		if _, ok := keyring.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key ID: %s", key.ID)
+		}
+		keyring.keys[key.ID] = key
+	}
+
+	active, ok := keyring.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q: %w", activeID, ErrUnknownKey)
+	}
+	keyring.active = active
+
+	return keyring, nil
+}
+
+// LoadKeyring reads every <kid>.pem file in dir. activeID may be left empty when dir holds a
+// single key.
+func LoadKeyring(dir, activeID string) (*Keyring, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing keys: %w", err)
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyFileExt {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		key, err := ParseKey(strings.TrimSuffix(entry.Name(), keyFileExt), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no %s signing keys in %s", keyFileExt, dir)
+	}
+	if activeID == "" {
+		if len(keys) > 1 {
+			return nil, errors.New("JWT_ACTIVE_KEY_ID is required when there is more than one signing key")
+		}
+		activeID = keys[0].ID
+	}
+
+	return NewKeyring(activeID, keys...)
+}
+
+// NewEphemeralKeyring creates a keyring of one random EdDSA key. Tokens it signs stop verifying
+// when the process exits, and other instances cannot verify them at all.
+func NewEphemeralKeyring() (*Keyring, error) {
+	key, err := GenerateKey("ephemeral-"+uuid.NewString(), AlgorithmEdDSA)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyring(key.ID, key)
+}
+
+// Active returns the key new tokens are signed with
+func (r *Keyring) Active() *Key {
+	return r.active
+}
+
+// Key returns the key a kid names
+func (r *Keyring) Key(id string) (*Key, bool) {
+	key, ok := r.keys[id]
+	return key, ok
+}
+
+// JWKS returns the public halves of every key, the active one first
+func (r *Keyring) JWKS() *interfaces.JSONWebKeySet {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		if id != r.active.ID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	set := &interfaces.JSONWebKeySet{Keys: []interfaces.JSONWebKey{r.active.JSONWebKey()}}
+	for _, id := range ids {
+		set.Keys = append(set.Keys, r.keys[id].JSONWebKey())
+	}
+
+	return set
+}
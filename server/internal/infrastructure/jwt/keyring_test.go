@@ -0,0 +1,136 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey stores key in dir the way LoadKeyring reads it
+func writeKey(t *testing.T, dir string, key *Key) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key.private)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, key.ID+keyFileExt), data, 0o600))
+}
+
+func TestParseKey(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	key, err := ParseKey("ed", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmEdDSA, key.Algorithm)
+
+	// openssl ecparam -genkey writes SEC 1 keys
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	key, err = ParseKey("ec", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmES256, key.Algorithm)
+
+	// Only P-256 is accepted for ES256
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKCS8PrivateKey(p384Key)
+	require.NoError(t, err)
+
+	_, err = ParseKey("p384", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+
+	_, err = ParseKey("garbage", []byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestKeyJSONWebKey(t *testing.T) {
+	edKey, err := GenerateKey("ed", AlgorithmEdDSA)
+	require.NoError(t, err)
+
+	jwk := edKey.JSONWebKey()
+	assert.Equal(t, "OKP", jwk.KeyType)
+	assert.Equal(t, "Ed25519", jwk.Curve)
+	assert.Equal(t, "ed", jwk.KeyID)
+	assert.Equal(t, "sig", jwk.Use)
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(edKey.private.Public().(ed25519.PublicKey)), x)
+
+	ecKey, err := GenerateKey("ec", AlgorithmES256)
+	require.NoError(t, err)
+
+	jwk = ecKey.JSONWebKey()
+	assert.Equal(t, "EC", jwk.KeyType)
+	assert.Equal(t, "P-256", jwk.Curve)
+	assert.Equal(t, AlgorithmES256, jwk.Algorithm)
+	public := ecKey.private.Public().(*ecdsa.PublicKey)
+	x, err = base64.RawURLEncoding.DecodeString(jwk.X)
+	require.NoError(t, err)
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	require.NoError(t, err)
+	assert.Len(t, x, 32)
+	assert.Len(t, y, 32)
+	assert.Zero(t, public.X.Cmp(new(big.Int).SetBytes(x)))
+	assert.Zero(t, public.Y.Cmp(new(big.Int).SetBytes(y)))
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	current, err := GenerateKey("2026-09", AlgorithmEdDSA)
+	require.NoError(t, err)
+	writeKey(t, dir, current)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("not a key"), 0o600))
+
+	// A single key is active without naming it
+	keyring, err := LoadKeyring(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09", keyring.Active().ID)
+
+	next, err := GenerateKey("2026-10", AlgorithmES256)
+	require.NoError(t, err)
+	writeKey(t, dir, next)
+
+	_, err = LoadKeyring(dir, "")
+	assert.Error(t, err, "the active key must be named once there are several")
+
+	_, err = LoadKeyring(dir, "2026-11")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// The next key is published before it becomes active
+	keyring, err = LoadKeyring(dir, "2026-09")
+	require.NoError(t, err)
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2026-09", jwks.Keys[0].KeyID, "the active key is listed first")
+	assert.Equal(t, "2026-10", jwks.Keys[1].KeyID)
+
+	_, err = LoadKeyring(t.TempDir(), "")
+	assert.Error(t, err, "an empty directory has no keys")
+}
+
+func TestNewKeyring_DuplicateID(t *testing.T) {
+	first, err := GenerateKey("same", AlgorithmEdDSA)
+	require.NoError(t, err)
+	second, err := GenerateKey("same", AlgorithmES256)
+	require.NoError(t, err)
+
+	_, err = NewKeyring("same", first, second)
+	assert.Error(t, err)
+}
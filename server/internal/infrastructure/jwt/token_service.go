@@ -1,161 +1,143 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
-	"github.com/google/uuid"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 )
 
 var (
 	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrExpiredToken = entities.ErrTokenExpired
 	ErrTokenClaims  = errors.New("invalid token claims")
 )
 
-// TokenClaims represents the JWT claims structure
-type TokenClaims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	jwt.StandardClaims
-}
+const (
+	// refreshTokenBytes is the amount of randomness in a refresh token
+	refreshTokenBytes = 32
+
+	// clockSkew is how far the clock of a service verifying a token may be from ours
+	clockSkew = 30 * time.Second
+)
 
-// TokenService handles JWT token operations
+// TokenService signs and verifies access tokens with a keyring and issues opaque refresh tokens
 type TokenService struct {
-	signingKey        []byte
+	keyring           *Keyring
 	issuer            string
-	expiration        time.Duration
+	audience          string
 	refreshExpiration time.Duration
 }
 
 // NewTokenService creates a new TokenService instance
-func NewTokenService(signingKey string, issuer string, expiration, refreshExpiration time.Duration) *TokenService {
+func NewTokenService(keyring *Keyring, issuer, audience string, refreshExpiration time.Duration) *TokenService {
 	return &TokenService{
-		signingKey:        []byte(signingKey),
+		keyring:           keyring,
 		issuer:            issuer,
-		expiration:        expiration,
+		audience:          audience,
 		refreshExpiration: refreshExpiration,
 	}
 }
 
-// GenerateToken creates a new access token for the user
-func (ts *TokenService) GenerateToken(userID uuid.UUID, username, email string) (string, error) {
-	now := time.Now()
-	claims := &TokenClaims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		StandardClaims: jwt.StandardClaims{
-			IssuedAt:  now.Unix(),
-			ExpiresAt: now.Add(ts.expiration).Unix(),
-			NotBefore: now.Unix(),
-			Issuer:    ts.issuer,
-			Subject:   userID.String(),
-		},
+// SignToken signs claims with the active key, adding the issuer, audience and not-before
+// claims and naming the key in the kid header
+func (ts *TokenService) SignToken(claims map[string]any) (string, error) {
+	signed := jwt.MapClaims{}
+	for name, value := range claims {
+		signed[name] = value
 	}
+	signed["iss"] = ts.issuer
+	signed["aud"] = ts.audience
+	signed["nbf"] = time.Now().Unix()
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(ts.signingKey)
-}
+	key := ts.keyring.Active()
+	token := jwt.NewWithClaims(key.method(), signed)
+	token.Header["kid"] = key.ID
 
-// GenerateRefreshToken creates a new refresh token for the user
-func (ts *TokenService) GenerateRefreshToken(userID uuid.UUID) (string, error) {
-	now := time.Now()
-	claims := &jwt.StandardClaims{
-		IssuedAt:  now.Unix(),
-		ExpiresAt: now.Add(ts.refreshExpiration).Unix(),
-		NotBefore: now.Unix(),
-		Issuer:    ts.issuer,
-		Subject:   userID.String(),
+	tokenString, err := token.SignedString(key.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(ts.signingKey)
+	return tokenString, nil
 }
 
-// ValidateToken validates and parses a JWT token
-func (ts *TokenService) ValidateToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return ts.signingKey, nil
-	})
-
+// VerifyToken checks a token's signature against the key its kid names, and its issuer,
+// audience and validity window, and returns its claims. An expired token's claims are returned
+// with ErrExpiredToken, as its signature is only checked first.
+func (ts *TokenService) VerifyToken(tokenString string) (map[string]any, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, ts.verificationKey,
+		jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmES256}),
+		jwt.WithIssuer(ts.issuer),
+		jwt.WithAudience(ts.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
 	if err != nil {
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				return nil, ErrExpiredToken
-			}
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return claims, ErrExpiredToken
 		}
-		return nil, ErrInvalidToken
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
 	}
 
-	claims, ok := token.Claims.(*TokenClaims)
-	if !ok || !token.Valid {
+	// The parser only checks nbf when it is present; every token we sign carries it
+	if nbf, err := claims.GetNotBefore(); err != nil || nbf == nil {
 		return nil, ErrTokenClaims
 	}
 
 	return claims, nil
 }
 
-// ValidateRefreshToken validates a refresh token and returns the user ID
-func (ts *TokenService) ValidateRefreshToken(tokenString string) (uuid.UUID, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return ts.signingKey, nil
-	})
+// PublicKeys returns every key tokens are currently accepted from
+func (ts *TokenService) PublicKeys() *interfaces.JSONWebKeySet {
+	return ts.keyring.JWKS()
+}
 
-	if err != nil {
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				return uuid.Nil, ErrExpiredToken
-			}
-		}
-		return uuid.Nil, ErrInvalidToken
+// verificationKey returns the public key of the keyring key a token's kid names
+func (ts *TokenService) verificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ts.keyring.Key(kid)
+	if !ok {
+		return nil, ErrUnknownKey
 	}
 
-	claims, ok := token.Claims.(*jwt.StandardClaims)
-	if !ok || !token.Valid {
-		return uuid.Nil, ErrTokenClaims
+	// A key only verifies tokens of the algorithm it signs with
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
 	}
 
-	userID, err := uuid.Parse(claims.Subject)
-	if err != nil {
-		return uuid.Nil, ErrTokenClaims
+	return key.private.Public(), nil
+}
+
+// GenerateRefreshToken creates a new opaque refresh token and the hash it is stored under. The
+// token carries no claims: it is only valid while its hash is stored.
+func (ts *TokenService) GenerateRefreshToken() (string, []byte, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	return userID, nil
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, ts.HashRefreshToken(token), nil
 }
 
-// TokenPair represents an access and refresh token pair
-type TokenPair struct {
-	AccessToken  string    `json:"access_token"`
-	RefreshToken string    `json:"refresh_token"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	TokenType    string    `json:"token_type"`
+// HashRefreshToken returns the hash a refresh token is stored and looked up by. Tokens are
+// random, so a plain SHA-256 cannot be reversed by guessing.
+func (ts *TokenService) HashRefreshToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
 }
 
-// GenerateTokenPair creates both access and refresh tokens
-func (ts *TokenService) GenerateTokenPair(userID uuid.UUID, username, email string) (*TokenPair, error) {
-	accessToken, err := ts.GenerateToken(userID, username, email)
-	if err != nil {
-		return nil, err
-	}
-
-	refreshToken, err := ts.GenerateRefreshToken(userID)
-	if err != nil {
-		return nil, err
-	}
-
-	return &TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    time.Now().Add(ts.expiration),
-		TokenType:    "Bearer",
-	}, nil
+// RefreshExpiration returns how long a refresh token stays usable
+func (ts *TokenService) RefreshExpiration() time.Duration {
+	return ts.refreshExpiration
 }
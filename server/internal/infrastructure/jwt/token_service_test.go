@@ -0,0 +1,170 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTokenService creates a token service over a keyring of keys, signing with the first
+func newTestTokenService(t *testing.T, keys ...*Key) *TokenService {
+	t.Helper()
+
+	keyring, err := NewKeyring(keys[0].ID, keys...)
+	require.NoError(t, err)
+	return NewTokenService(keyring, "2fair.test", "2fair-api", 7*24*time.Hour)
+}
+
+// accessClaims returns the claims of an access token valid for an hour
+func accessClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"sub": "user",
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestTokenService_SignAndVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmEdDSA, AlgorithmES256} {
+		t.Run(algorithm, func(t *testing.T) {
+			key, err := GenerateKey("key-1", algorithm)
+			require.NoError(t, err)
+			ts := newTestTokenService(t, key)
+
+			token, err := ts.SignToken(accessClaims())
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, parsed.Method.Alg())
+			assert.Equal(t, "key-1", parsed.Header["kid"])
+
+			claims, err := ts.VerifyToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user", claims["sub"])
+			assert.Equal(t, "2fair.test", claims["iss"])
+			assert.Equal(t, "2fair-api", claims["aud"])
+			assert.Contains(t, claims, "nbf")
+		})
+	}
+}
+
+func TestTokenService_Rotation(t *testing.T) {
+	oldKey, err := GenerateKey("old", AlgorithmEdDSA)
+	require.NoError(t, err)
+	newKey, err := GenerateKey("new", AlgorithmES256)
+	require.NoError(t, err)
+
+	before := newTestTokenService(t, oldKey, newKey)
+	token, err := before.SignToken(accessClaims())
+	require.NoError(t, err)
+
+	// Tokens signed with the old key still verify once the new key is active
+	after := newTestTokenService(t, newKey, oldKey)
+	_, err = after.VerifyToken(token)
+	assert.NoError(t, err)
+
+	// ...and stop once the old key is removed
+	retired := newTestTokenService(t, newKey)
+	_, err = retired.VerifyToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestTokenService_VerifyRejects(t *testing.T) {
+	key, err := GenerateKey("key-1", AlgorithmEdDSA)
+	require.NoError(t, err)
+	ts := newTestTokenService(t, key)
+
+	// sign signs raw claims with the keyring key, without the claims SignToken adds
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString(key.private)
+		require.NoError(t, err)
+		return signed
+	}
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": "2fair.test",
+			"aud": "2fair-api",
+			"iat": now.Unix(),
+			"nbf": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+	_, err = ts.VerifyToken(sign(valid()))
+	require.NoError(t, err)
+
+	t.Run("expired", func(t *testing.T) {
+		claims := valid()
+		claims["exp"] = now.Add(-time.Minute).Unix()
+		verified, err := ts.VerifyToken(sign(claims))
+		assert.ErrorIs(t, err, ErrExpiredToken)
+		assert.Equal(t, "2fair.test", verified["iss"], "the claims of an expired token are still returned")
+	})
+
+	t.Run("not yet valid", func(t *testing.T) {
+		claims := valid()
+		claims["nbf"] = now.Add(time.Minute).Unix()
+		_, err := ts.VerifyToken(sign(claims))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("missing nbf", func(t *testing.T) {
+		claims := valid()
+		delete(claims, "nbf")
+		_, err := ts.VerifyToken(sign(claims))
+		assert.ErrorIs(t, err, ErrTokenClaims)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		claims := valid()
+		claims["iss"] = "someone-else"
+		_, err := ts.VerifyToken(sign(claims))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("wrong audience", func(t *testing.T) {
+		claims := valid()
+		claims["aud"] = "another-api"
+		_, err := ts.VerifyToken(sign(claims))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("missing kid", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, valid()).SignedString(key.private)
+		require.NoError(t, err)
+		_, err = ts.VerifyToken(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, valid())
+		token.Header["kid"] = key.ID
+		signed, err := token.SignedString([]byte("static-secret"))
+		require.NoError(t, err)
+		_, err = ts.VerifyToken(signed)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestTokenService_RefreshToken(t *testing.T) {
+	key, err := GenerateKey("key-1", AlgorithmEdDSA)
+	require.NoError(t, err)
+	ts := newTestTokenService(t, key)
+
+	token, hash, err := ts.GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.Equal(t, hash, ts.HashRefreshToken(token))
+
+	other, _, err := ts.GenerateRefreshToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+	assert.Equal(t, 7*24*time.Hour, ts.RefreshExpiration())
+}
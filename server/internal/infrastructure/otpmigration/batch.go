@@ -0,0 +1,97 @@
+package otpmigration
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+var ErrIncompleteBatch = errors.New("incomplete migration batch")
+
+// Batch collects the QR codes of a multi-part export until every part has been scanned
+type Batch struct {
+	id    int
+	size  int
+	parts []*Payload
+}
+
+// NewBatch creates an empty batch collector
+func NewBatch() *Batch {
+	return &Batch{}
+}
+
+// Add adds a decoded payload to the batch. Scanning the same part twice is harmless.
+func (b *Batch) Add(payload *Payload) error {
+	// Payloads need not come from Decode, so their batch fields are checked again
+	if payload.BatchSize < 1 || payload.BatchSize > MaxBatchSize {
+		return fmt.Errorf("%w: batch size %d out of range", ErrInvalidPayload, payload.BatchSize)
+	}
+	if payload.BatchIndex < 0 || payload.BatchIndex >= payload.BatchSize {
+		return fmt.Errorf("%w: batch index %d out of range for batch size %d", ErrInvalidPayload, payload.BatchIndex, payload.BatchSize)
+	}
+
+	if b.parts == nil {
+		b.id = payload.BatchID
+		b.size = payload.BatchSize
+		b.parts = make([]*Payload, payload.BatchSize)
+	}
+
+	if payload.BatchID != b.id {
+		return fmt.Errorf("%w: payload belongs to batch %d, expected %d", ErrInvalidPayload, payload.BatchID, b.id)
+	}
+	if payload.BatchSize != b.size {
+		return fmt.Errorf("%w: batch size %d does not match %d", ErrInvalidPayload, payload.BatchSize, b.size)
+	}
+
+	b.parts[payload.BatchIndex] = payload
+	return nil
+}
+
+// Missing returns the zero-based indices of the parts that have not been added yet
+func (b *Batch) Missing() []int {
+	missing := []int{}
+	for i, part := range b.parts {
+		if part == nil {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// Complete reports whether every part of the batch has been added
+func (b *Batch) Complete() bool {
+	return b.parts != nil && len(b.Missing()) == 0
+}
+
+// OTPs returns the entries of all parts in batch order, failing if any part is missing
+func (b *Batch) OTPs() ([]*interfaces.TOTPConfig, error) {
+	if b.parts == nil {
+		return nil, fmt.Errorf("%w: no payloads added", ErrIncompleteBatch)
+	}
+	if missing := b.Missing(); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing parts %v of %d", ErrIncompleteBatch, missing, b.size)
+	}
+
+	otps := []*interfaces.TOTPConfig{}
+	for _, part := range b.parts {
+		otps = append(otps, part.OTPs...)
+	}
+	return otps, nil
+}
+
+// ParseURLs decodes every QR code of an export and returns all entries once the batch is complete
+func ParseURLs(rawURLs []string) ([]*interfaces.TOTPConfig, error) {
+	batch := NewBatch()
+	for i, rawURL := range rawURLs {
+		payload, err := ParseURL(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("QR code %d: %w", i+1, err)
+		}
+		if err := batch.Add(payload); err != nil {
+			return nil, fmt.Errorf("QR code %d: %w", i+1, err)
+		}
+	}
+
+	return batch.OTPs()
+}
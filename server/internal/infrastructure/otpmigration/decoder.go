@@ -0,0 +1,302 @@
+// Package otpmigration decodes Google Authenticator "otpauth-migration://offline?data=..." export
+// payloads into TOTP configurations. It has no server dependencies so it can also be built to WASM
+// for client-side use.
+package otpmigration
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+const (
+	migrationScheme = "otpauth-migration"
+	migrationHost   = "offline"
+
+	defaultPeriod = 30
+
+	// MaxBatchSize bounds the number of QR codes an export may claim; Google Authenticator
+	// splits large exports into a handful of codes
+	MaxBatchSize = 100
+)
+
+var (
+	ErrInvalidURL     = errors.New("invalid otpauth-migration URL")
+	ErrInvalidPayload = errors.New("invalid migration payload")
+)
+
+// MigrationPayload field numbers
+const (
+	fieldOTPParameters protowire.Number = 1
+	fieldVersion       protowire.Number = 2
+	fieldBatchSize     protowire.Number = 3
+	fieldBatchIndex    protowire.Number = 4
+	fieldBatchID       protowire.Number = 5
+)
+
+// OtpParameters field numbers
+const (
+	fieldSecret    protowire.Number = 1
+	fieldName      protowire.Number = 2
+	fieldIssuer    protowire.Number = 3
+	fieldAlgorithm protowire.Number = 4
+	fieldDigits    protowire.Number = 5
+	fieldType      protowire.Number = 6
+	fieldCounter   protowire.Number = 7
+)
+
+// Enum values from the MigrationPayload schema
+const (
+	algorithmUnspecified = 0
+	algorithmSHA1        = 1
+	algorithmSHA256      = 2
+	algorithmSHA512      = 3
+	algorithmMD5         = 4
+
+	digitsUnspecified = 0
+	digitsSix         = 1
+	digitsEight       = 2
+
+	typeUnspecified = 0
+	typeHOTP        = 1
+	typeTOTP        = 2
+)
+
+// Payload is a single decoded export QR code, which may be one batch of several
+type Payload struct {
+	Version    int
+	BatchSize  int
+	BatchIndex int
+	BatchID    int
+	OTPs       []*interfaces.TOTPConfig
+}
+
+// ParseURL decodes an otpauth-migration://offline?data=... URL
+func ParseURL(rawURL string) (*Payload, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+
+	if !strings.EqualFold(u.Scheme, migrationScheme) || !strings.EqualFold(u.Host, migrationHost) {
+		return nil, fmt.Errorf("%w: expected %s://%s", ErrInvalidURL, migrationScheme, migrationHost)
+	}
+
+	data := u.Query().Get("data")
+	if data == "" {
+		return nil, fmt.Errorf("%w: data parameter is required", ErrInvalidURL)
+	}
+
+	raw, err := decodeBase64(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: data is not valid base64", ErrInvalidURL)
+	}
+
+	return Decode(raw)
+}
+
+// Decode parses a serialized MigrationPayload protobuf message
+func Decode(data []byte) (*Payload, error) {
+	payload := &Payload{
+		BatchSize: 1,
+		OTPs:      []*interfaces.TOTPConfig{},
+	}
+
+	err := walkMessage(data, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch num {
+		case fieldOTPParameters:
+			if typ != protowire.BytesType {
+				return fmt.Errorf("otp_parameters has wire type %d", typ)
+			}
+			config, err := decodeOTPParameters(value)
+			if err != nil {
+				return fmt.Errorf("entry %d: %w", len(payload.OTPs), err)
+			}
+			payload.OTPs = append(payload.OTPs, config)
+		case fieldVersion, fieldBatchSize, fieldBatchIndex, fieldBatchID:
+			if typ != protowire.VarintType {
+				return fmt.Errorf("field %d has wire type %d", num, typ)
+			}
+			v := int(int32(varint))
+			switch num {
+			case fieldVersion:
+				payload.Version = v
+			case fieldBatchSize:
+				payload.BatchSize = v
+			case fieldBatchIndex:
+				payload.BatchIndex = v
+			case fieldBatchID:
+				payload.BatchID = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.BatchSize > MaxBatchSize {
+		return nil, fmt.Errorf("%w: batch size %d exceeds %d", ErrInvalidPayload, payload.BatchSize, MaxBatchSize)
+	}
+	if payload.BatchSize < 1 || payload.BatchIndex < 0 || payload.BatchIndex >= payload.BatchSize {
+		return nil, fmt.Errorf("%w: batch index %d out of range for batch size %d", ErrInvalidPayload, payload.BatchIndex, payload.BatchSize)
+	}
+
+	return payload, nil
+}
+
+// decodeOTPParameters converts a single OtpParameters message into a TOTP configuration
+func decodeOTPParameters(data []byte) (*interfaces.TOTPConfig, error) {
+	var (
+		secret            []byte
+		name, issuer      string
+		algorithm, digits uint64
+		otpType           uint64
+		counter           uint64
+	)
+
+	err := walkMessage(data, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch num {
+		case fieldSecret, fieldName, fieldIssuer:
+			if typ != protowire.BytesType {
+				return fmt.Errorf("field %d has wire type %d", num, typ)
+			}
+			switch num {
+			case fieldSecret:
+				secret = value
+			case fieldName:
+				name = string(value)
+			case fieldIssuer:
+				issuer = string(value)
+			}
+		case fieldAlgorithm, fieldDigits, fieldType, fieldCounter:
+			if typ != protowire.VarintType {
+				return fmt.Errorf("field %d has wire type %d", num, typ)
+			}
+			switch num {
+			case fieldAlgorithm:
+				algorithm = varint
+			case fieldDigits:
+				digits = varint
+			case fieldType:
+				otpType = varint
+			case fieldCounter:
+				counter = varint
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("%w: secret is empty", ErrInvalidPayload)
+	}
+
+	config := &interfaces.TOTPConfig{
+		Secret: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret),
+	}
+
+	// Names are exported as "Issuer:account" when the entry was added from an otpauth:// label
+	labelIssuer, account, found := strings.Cut(name, ":")
+	if !found {
+		labelIssuer, account = "", name
+	}
+	config.AccountName = strings.TrimSpace(account)
+	config.Issuer = strings.TrimSpace(issuer)
+	if config.Issuer == "" {
+		config.Issuer = strings.TrimSpace(labelIssuer)
+	}
+
+	switch algorithm {
+	case algorithmUnspecified, algorithmSHA1:
+		config.Algorithm = "SHA1"
+	case algorithmSHA256:
+		config.Algorithm = "SHA256"
+	case algorithmSHA512:
+		config.Algorithm = "SHA512"
+	case algorithmMD5:
+		return nil, fmt.Errorf("%w: MD5 entries are not supported", ErrInvalidPayload)
+	default:
+		return nil, fmt.Errorf("%w: unknown algorithm %d", ErrInvalidPayload, algorithm)
+	}
+
+	switch digits {
+	case digitsUnspecified, digitsSix:
+		config.Digits = 6
+	case digitsEight:
+		config.Digits = 8
+	default:
+		return nil, fmt.Errorf("%w: unknown digit count %d", ErrInvalidPayload, digits)
+	}
+
+	switch otpType {
+	case typeUnspecified, typeTOTP:
+		config.Type = "totp"
+		config.Period = defaultPeriod // The export format has no period; Google Authenticator only supports 30s
+	case typeHOTP:
+		config.Type = "hotp"
+		config.Counter = counter
+	default:
+		return nil, fmt.Errorf("%w: unknown OTP type %d", ErrInvalidPayload, otpType)
+	}
+
+	return config, nil
+}
+
+// walkMessage iterates over the fields of a protobuf message, skipping unknown ones
+func walkMessage(data []byte, visit func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		var (
+			value  []byte
+			varint uint64
+		)
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if err := visit(num, typ, value, varint); err != nil {
+			if errors.Is(err, ErrInvalidPayload) {
+				return err
+			}
+			return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeBase64 accepts standard and URL-safe base64, with or without padding.
+// QR scanners are inconsistent about unescaping "+" in the data parameter, so spaces are restored.
+func decodeBase64(data string) ([]byte, error) {
+	data = strings.ReplaceAll(data, " ", "+")
+	data = strings.TrimRight(data, "=")
+
+	if strings.ContainsAny(data, "-_") {
+		return base64.RawURLEncoding.DecodeString(data)
+	}
+	return base64.RawStdEncoding.DecodeString(data)
+}
@@ -0,0 +1,200 @@
+package otpmigration
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+type testOTP struct {
+	secret    []byte
+	name      string
+	issuer    string
+	algorithm uint64
+	digits    uint64
+	otpType   uint64
+	counter   uint64
+}
+
+// encodePayload builds a MigrationPayload message the way Google Authenticator exports it
+func encodePayload(otps []testOTP, batchSize, batchIndex, batchID int) []byte {
+	var data []byte
+	for _, otp := range otps {
+		var params []byte
+		params = protowire.AppendTag(params, fieldSecret, protowire.BytesType)
+		params = protowire.AppendBytes(params, otp.secret)
+		params = protowire.AppendTag(params, fieldName, protowire.BytesType)
+		params = protowire.AppendString(params, otp.name)
+		params = protowire.AppendTag(params, fieldIssuer, protowire.BytesType)
+		params = protowire.AppendString(params, otp.issuer)
+		params = protowire.AppendTag(params, fieldAlgorithm, protowire.VarintType)
+		params = protowire.AppendVarint(params, otp.algorithm)
+		params = protowire.AppendTag(params, fieldDigits, protowire.VarintType)
+		params = protowire.AppendVarint(params, otp.digits)
+		params = protowire.AppendTag(params, fieldType, protowire.VarintType)
+		params = protowire.AppendVarint(params, otp.otpType)
+		params = protowire.AppendTag(params, fieldCounter, protowire.VarintType)
+		params = protowire.AppendVarint(params, otp.counter)
+
+		data = protowire.AppendTag(data, fieldOTPParameters, protowire.BytesType)
+		data = protowire.AppendBytes(data, params)
+	}
+
+	data = protowire.AppendTag(data, fieldVersion, protowire.VarintType)
+	data = protowire.AppendVarint(data, 1)
+	data = protowire.AppendTag(data, fieldBatchSize, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(batchSize))
+	data = protowire.AppendTag(data, fieldBatchIndex, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(batchIndex))
+	data = protowire.AppendTag(data, fieldBatchID, protowire.VarintType)
+	data = protowire.AppendVarint(data, uint64(batchID))
+	return data
+}
+
+func migrationURL(data []byte) string {
+	return "otpauth-migration://offline?data=" + url.QueryEscape(base64.StdEncoding.EncodeToString(data))
+}
+
+func TestParseURL_ReferenceVector(t *testing.T) {
+	// Single-entry export from the Google Authenticator key URI documentation
+	payload, err := ParseURL("otpauth-migration://offline?data=CjEKCkhlbGxvId6tvu8SGEV4YW1wbGU6YWxpY2VAZ29vZ2xlLmNvbRoHRXhhbXBsZTAC")
+	require.NoError(t, err)
+
+	require.Len(t, payload.OTPs, 1)
+	assert.Equal(t, interfaces.TOTPConfig{
+		Type:        "totp",
+		Secret:      "JBSWY3DPEHPK3PXP",
+		Issuer:      "Example",
+		AccountName: "alice@google.com",
+		Algorithm:   "SHA1",
+		Digits:      6,
+		Period:      30,
+	}, *payload.OTPs[0])
+	assert.Equal(t, 1, payload.BatchSize)
+	assert.Equal(t, 0, payload.BatchIndex)
+}
+
+func TestDecode_Parameters(t *testing.T) {
+	data := encodePayload([]testOTP{
+		{secret: []byte("12345678901234567890"), name: "bob", issuer: "GitHub", algorithm: algorithmSHA256, digits: digitsEight, otpType: typeTOTP},
+		{secret: []byte("12345678901234567890"), name: "Bank:carol", algorithm: algorithmSHA512, digits: digitsSix, otpType: typeHOTP, counter: 42},
+	}, 1, 0, 7)
+
+	payload, err := Decode(data)
+	require.NoError(t, err)
+	require.Len(t, payload.OTPs, 2)
+
+	assert.Equal(t, interfaces.TOTPConfig{
+		Type:        "totp",
+		Secret:      "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Issuer:      "GitHub",
+		AccountName: "bob",
+		Algorithm:   "SHA256",
+		Digits:      8,
+		Period:      30,
+	}, *payload.OTPs[0])
+
+	// Issuer falls back to the label prefix and HOTP entries carry their counter
+	assert.Equal(t, interfaces.TOTPConfig{
+		Type:        "hotp",
+		Secret:      "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Issuer:      "Bank",
+		AccountName: "carol",
+		Algorithm:   "SHA512",
+		Digits:      6,
+		Counter:     42,
+	}, *payload.OTPs[1])
+	assert.Equal(t, 7, payload.BatchID)
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "truncated message", data: []byte{0x0a, 0x10, 0x01}},
+		{name: "empty secret", data: encodePayload([]testOTP{{name: "x", otpType: typeTOTP}}, 1, 0, 1)},
+		{name: "md5 algorithm", data: encodePayload([]testOTP{{secret: []byte("k"), algorithm: algorithmMD5}}, 1, 0, 1)},
+		{name: "unknown digits", data: encodePayload([]testOTP{{secret: []byte("k"), digits: 9}}, 1, 0, 1)},
+		{name: "unknown type", data: encodePayload([]testOTP{{secret: []byte("k"), otpType: 5}}, 1, 0, 1)},
+		{name: "batch index out of range", data: encodePayload(nil, 2, 2, 1)},
+		{name: "batch too large", data: encodePayload(nil, MaxBatchSize+1, 0, 1)},
+		{name: "huge batch", data: encodePayload(nil, 1<<30, 0, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			assert.ErrorIs(t, err, ErrInvalidPayload)
+		})
+	}
+}
+
+func TestParseURL_Errors(t *testing.T) {
+	for _, rawURL := range []string{
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP",
+		"otpauth-migration://online?data=CgA",
+		"otpauth-migration://offline",
+		"otpauth-migration://offline?data=%%%",
+	} {
+		_, err := ParseURL(rawURL)
+		assert.ErrorIs(t, err, ErrInvalidURL, rawURL)
+	}
+}
+
+func TestParseURLs_Batches(t *testing.T) {
+	first := migrationURL(encodePayload([]testOTP{{secret: []byte("first"), name: "a", otpType: typeTOTP}}, 2, 0, 99))
+	second := migrationURL(encodePayload([]testOTP{{secret: []byte("second"), name: "b", otpType: typeTOTP}}, 2, 1, 99))
+
+	// Parts may be scanned in any order; entries come back in batch order
+	otps, err := ParseURLs([]string{second, first})
+	require.NoError(t, err)
+	require.Len(t, otps, 2)
+	assert.Equal(t, "a", otps[0].AccountName)
+	assert.Equal(t, "b", otps[1].AccountName)
+
+	_, err = ParseURLs([]string{first})
+	assert.ErrorIs(t, err, ErrIncompleteBatch)
+
+	other := migrationURL(encodePayload([]testOTP{{secret: []byte("other"), name: "c"}}, 2, 1, 100))
+	_, err = ParseURLs([]string{first, other})
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+}
+
+func TestBatch_Missing(t *testing.T) {
+	batch := NewBatch()
+	assert.False(t, batch.Complete())
+
+	payload, err := Decode(encodePayload([]testOTP{{secret: []byte("k"), name: "a"}}, 3, 1, 5))
+	require.NoError(t, err)
+	require.NoError(t, batch.Add(payload))
+
+	assert.Equal(t, []int{0, 2}, batch.Missing())
+	assert.False(t, batch.Complete())
+}
+
+func TestBatch_AddRejectsOutOfRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload *Payload
+	}{
+		{name: "negative index", payload: &Payload{BatchSize: 2, BatchIndex: -1}},
+		{name: "index past size", payload: &Payload{BatchSize: 2, BatchIndex: 2}},
+		{name: "zero size", payload: &Payload{BatchSize: 0}},
+		{name: "size too large", payload: &Payload{BatchSize: MaxBatchSize + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := NewBatch()
+			assert.ErrorIs(t, batch.Add(tt.payload), ErrInvalidPayload)
+			assert.False(t, batch.Complete())
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package totp
+
+import (
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// Key URI format constants (https://github.com/google/google-authenticator/wiki/Key-Uri-Format)
+const (
+	otpauthScheme = "otpauth"
+
+	TypeTOTP = "totp"
+	TypeHOTP = "hotp"
+
+	DefaultAlgorithm = "SHA1"
+	DefaultDigits    = 6
+	DefaultPeriod    = 30
+
+	MinDigits = 6
+	MaxDigits = 10
+)
+
+// parseOTPAuthURL parses an otpauth:// URL into a TOTP configuration.
+// When requireSecret is false the URL must not carry a secret at all, which is
+// how clients submit entries whose secret has already been encrypted locally.
+func parseOTPAuthURL(rawURL string, requireSecret bool) (*interfaces.TOTPConfig, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", entities.ErrInvalidOTPAuthURL, err)
+	}
+
+	if !strings.EqualFold(u.Scheme, otpauthScheme) {
+		return nil, fmt.Errorf("%w: scheme must be %q, got %q", entities.ErrInvalidOTPAuthURL, otpauthScheme, u.Scheme)
+	}
+
+	// Authenticators that support Steam Guard and Yandex.Key export them with their own type
+	otpType, scheme := strings.ToLower(u.Host), ""
+	switch otpType {
+	case TypeTOTP, TypeHOTP:
+	case entities.OTPTypeSteam, entities.OTPTypeYandex:
+		otpType, scheme = TypeTOTP, otpType
+	default:
+		return nil, fmt.Errorf("%w: type must be %q or %q, got %q", entities.ErrInvalidOTPAuthURL, TypeTOTP, TypeHOTP, u.Host)
+	}
+
+	// Decode the label ourselves so an escaped separator (%3A) is treated the same as a literal one
+	rawLabel := strings.TrimPrefix(u.EscapedPath(), "/")
+	label, err := url.PathUnescape(rawLabel)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed label: %v", entities.ErrInvalidOTPAuthURL, err)
+	}
+
+	labelIssuer, accountName := splitLabel(label)
+	if accountName == "" {
+		return nil, fmt.Errorf("%w: label must contain an account name", entities.ErrInvalidOTPAuthURL)
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed query: %v", entities.ErrInvalidOTPAuthURL, err)
+	}
+
+	// Some exporters mark Steam entries on a plain totp URL instead
+	if otpType == TypeTOTP && strings.EqualFold(query.Get("encoder"), entities.OTPTypeSteam) {
+		scheme = entities.OTPTypeSteam
+	}
+
+	config := &interfaces.TOTPConfig{
+		Type:        otpType,
+		AccountName: accountName,
+		Algorithm:   DefaultAlgorithm,
+		Digits:      DefaultDigits,
+		Scheme:      scheme,
+	}
+
+	// The issuer parameter takes precedence over the label prefix. Authenticators disagree on
+	// mismatches, so we follow Google Authenticator and keep the parameter.
+	config.Issuer = strings.TrimSpace(query.Get("issuer"))
+	if config.Issuer == "" {
+		config.Issuer = labelIssuer
+	}
+
+	secret, hasSecret := query["secret"]
+	switch {
+	case requireSecret && (!hasSecret || strings.TrimSpace(secret[0]) == ""):
+		return nil, fmt.Errorf("%w: secret parameter is required", entities.ErrInvalidOTPAuthURL)
+	case !requireSecret && hasSecret:
+		return nil, fmt.Errorf("%w: secret parameter must be stripped before upload", entities.ErrInvalidOTPAuthURL)
+	case hasSecret:
+		normalized, err := normalizeSecret(secret[0])
+		if err != nil {
+			return nil, err
+		}
+		config.Secret = normalized
+	}
+
+	switch scheme {
+	case entities.OTPTypeSteam:
+		// Steam codes have a fixed format whatever the URL claims
+		config.Digits = steamDigits
+	case entities.OTPTypeYandex:
+		config.Algorithm, config.Digits = "SHA256", yandexDigits
+	default:
+		if value := query.Get("algorithm"); value != "" {
+			algorithm, err := normalizeAlgorithm(value)
+			if err != nil {
+				return nil, err
+			}
+			config.Algorithm = algorithm
+		}
+
+		if value := query.Get("digits"); value != "" {
+			digits, err := strconv.Atoi(value)
+			if err != nil || digits < MinDigits || digits > MaxDigits {
+				return nil, fmt.Errorf("%w: digits must be between %d and %d, got %q", entities.ErrInvalidOTPAuthURL, MinDigits, MaxDigits, value)
+			}
+			config.Digits = digits
+		}
+	}
+
+	switch otpType {
+	case TypeTOTP:
+		config.Period = DefaultPeriod
+		if value := query.Get("period"); value != "" {
+			period, err := strconv.Atoi(value)
+			if err != nil || period <= 0 {
+				return nil, fmt.Errorf("%w: period must be a positive integer, got %q", entities.ErrInvalidOTPAuthURL, value)
+			}
+			config.Period = period
+		}
+	case TypeHOTP:
+		value := query.Get("counter")
+		if value == "" {
+			return nil, fmt.Errorf("%w: counter parameter is required for hotp", entities.ErrInvalidOTPAuthURL)
+		}
+		counter, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: counter must be a non-negative integer, got %q", entities.ErrInvalidOTPAuthURL, value)
+		}
+		config.Counter = counter
+	}
+
+	if value := query.Get("image"); value != "" {
+		image, err := url.Parse(value)
+		if err != nil || (image.Scheme != "https" && image.Scheme != "http") || image.Host == "" {
+			return nil, fmt.Errorf("%w: image must be an absolute http(s) URL", entities.ErrInvalidOTPAuthURL)
+		}
+		config.Image = image.String()
+	}
+
+	return config, nil
+}
+
+// formatOTPAuthURL renders a TOTP configuration as an otpauth:// URL that parseOTPAuthURL accepts
+func formatOTPAuthURL(config *interfaces.TOTPConfig) string {
+	otpType := strings.ToLower(config.Type)
+	if otpType == "" {
+		otpType = TypeTOTP
+	}
+	scheme := strings.ToLower(config.Scheme)
+	if otpType == TypeTOTP && (scheme == entities.OTPTypeSteam || scheme == entities.OTPTypeYandex) {
+		otpType = scheme
+	}
+	algorithm := config.Algorithm
+	if algorithm == "" {
+		algorithm = DefaultAlgorithm
+	}
+	digits := config.Digits
+	if digits == 0 {
+		digits = DefaultDigits
+	}
+
+	// An issuer containing the separator cannot be expressed as a label prefix, so it only
+	// travels in the issuer parameter
+	label := escapeLabelPart(config.AccountName)
+	if config.Issuer != "" && !strings.Contains(config.Issuer, ":") {
+		label = escapeLabelPart(config.Issuer) + ":" + label
+	}
+
+	params := []string{}
+	addParam := func(key, value string) {
+		params = append(params, key+"="+strings.ReplaceAll(url.QueryEscape(value), "+", "%20"))
+	}
+
+	if config.Secret != "" {
+		addParam("secret", config.Secret)
+	}
+	if config.Issuer != "" {
+		addParam("issuer", config.Issuer)
+	}
+	if otpType != entities.OTPTypeSteam && otpType != entities.OTPTypeYandex {
+		addParam("algorithm", strings.ToUpper(algorithm))
+		addParam("digits", strconv.Itoa(digits))
+	}
+
+	if otpType == TypeHOTP {
+		addParam("counter", strconv.FormatUint(config.Counter, 10))
+	} else {
+		period := config.Period
+		if period == 0 {
+			period = DefaultPeriod
+		}
+		addParam("period", strconv.Itoa(period))
+	}
+
+	if config.Image != "" {
+		addParam("image", config.Image)
+	}
+
+	return fmt.Sprintf("%s://%s/%s?%s", otpauthScheme, otpType, label, strings.Join(params, "&"))
+}
+
+// splitLabel splits an "Issuer:account" label into its issuer prefix and account name
+func splitLabel(label string) (string, string) {
+	issuer, account, found := strings.Cut(label, ":")
+	if !found {
+		return "", strings.TrimSpace(label)
+	}
+	return strings.TrimSpace(issuer), strings.TrimSpace(account)
+}
+
+// escapeLabelPart percent-encodes a label component, including the ":" separator
+func escapeLabelPart(part string) string {
+	return strings.ReplaceAll(url.PathEscape(part), ":", "%3A")
+}
+
+// normalizeSecret uppercases a base32 secret, strips whitespace and padding, and checks it decodes
+func normalizeSecret(secret string) (string, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	normalized = strings.TrimRight(normalized, "=")
+
+	if normalized == "" {
+		return "", fmt.Errorf("%w: secret parameter is required", entities.ErrInvalidOTPAuthURL)
+	}
+	if _, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized); err != nil {
+		return "", fmt.Errorf("%w: secret is not valid base32", entities.ErrInvalidOTPAuthURL)
+	}
+
+	return normalized, nil
+}
+
+// normalizeAlgorithm maps an algorithm parameter to the canonical SHA1/SHA256/SHA512 names
+func normalizeAlgorithm(algorithm string) (string, error) {
+	switch strings.ToUpper(strings.ReplaceAll(algorithm, "-", "")) {
+	case "SHA1":
+		return "SHA1", nil
+	case "SHA256":
+		return "SHA256", nil
+	case "SHA512":
+		return "SHA512", nil
+	default:
+		return "", fmt.Errorf("%w: unsupported algorithm %q", entities.ErrInvalidOTPAuthURL, algorithm)
+	}
+}
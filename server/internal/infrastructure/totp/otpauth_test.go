@@ -0,0 +1,251 @@
+package totp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+func TestParseOTPAuthURL_Success(t *testing.T) {
+	service := NewTOTPService()
+
+	tests := []struct {
+		name     string
+		url      string
+		expected interfaces.TOTPConfig
+	}{
+		{
+			name: "minimal totp uses defaults",
+			url:  "otpauth://totp/alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				AccountName: "alice@example.com",
+				Algorithm:   "SHA1",
+				Digits:      6,
+				Period:      30,
+			},
+		},
+		{
+			name: "issuer from label prefix",
+			url:  "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "Example",
+				AccountName: "alice@example.com",
+				Algorithm:   "SHA1",
+				Digits:      6,
+				Period:      30,
+			},
+		},
+		{
+			name: "issuer parameter takes precedence over label prefix",
+			url:  "otpauth://totp/Old%20Name:alice?secret=JBSWY3DPEHPK3PXP&issuer=New%20Name",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "New Name",
+				AccountName: "alice",
+				Algorithm:   "SHA1",
+				Digits:      6,
+				Period:      30,
+			},
+		},
+		{
+			name: "percent-encoded separator and spaces",
+			url:  "otpauth://totp/ACME%20Co%3A%20john.doe%40email.com?secret=jbsw%20y3dp%20ehpk%203pxp&algorithm=sha256&digits=8&period=60",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "ACME Co",
+				AccountName: "john.doe@email.com",
+				Algorithm:   "SHA256",
+				Digits:      8,
+				Period:      60,
+			},
+		},
+		{
+			name: "hotp with counter and image",
+			url:  "otpauth://HOTP/Example:alice?secret=JBSWY3DPEHPK3PXP&counter=42&algorithm=SHA512&image=https%3A%2F%2Fexample.com%2Flogo.png",
+			expected: interfaces.TOTPConfig{
+				Type:        "hotp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "Example",
+				AccountName: "alice",
+				Algorithm:   "SHA512",
+				Digits:      6,
+				Counter:     42,
+				Image:       "https://example.com/logo.png",
+			},
+		},
+		{
+			name: "ten digit codes",
+			url:  "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=10",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				AccountName: "alice",
+				Algorithm:   "SHA1",
+				Digits:      10,
+				Period:      30,
+			},
+		},
+		{
+			name: "steam type",
+			url:  "otpauth://steam/Steam:gaben?secret=JBSWY3DPEHPK3PXP&issuer=Steam",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Scheme:      "steam",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "Steam",
+				AccountName: "gaben",
+				Algorithm:   "SHA1",
+				Digits:      5,
+				Period:      30,
+			},
+		},
+		{
+			name: "steam encoder parameter",
+			url:  "otpauth://totp/Steam:gaben?secret=JBSWY3DPEHPK3PXP&digits=5&encoder=steam",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Scheme:      "steam",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "Steam",
+				AccountName: "gaben",
+				Algorithm:   "SHA1",
+				Digits:      5,
+				Period:      30,
+			},
+		},
+		{
+			name: "yandex type",
+			url:  "otpauth://yandex/Yandex:ivan?secret=JBSWY3DPEHPK3PXP",
+			expected: interfaces.TOTPConfig{
+				Type:        "totp",
+				Scheme:      "yandex",
+				Secret:      "JBSWY3DPEHPK3PXP",
+				Issuer:      "Yandex",
+				AccountName: "ivan",
+				Algorithm:   "SHA256",
+				Digits:      8,
+				Period:      30,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := service.ParseOTPAuthURL(tt.url)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *config)
+		})
+	}
+}
+
+func TestParseOTPAuthURL_Errors(t *testing.T) {
+	service := NewTOTPService()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "wrong scheme", url: "https://totp/alice?secret=JBSWY3DPEHPK3PXP"},
+		{name: "unknown type", url: "otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP"},
+		{name: "missing account name", url: "otpauth://totp/Example:?secret=JBSWY3DPEHPK3PXP"},
+		{name: "missing secret", url: "otpauth://totp/alice"},
+		{name: "invalid base32 secret", url: "otpauth://totp/alice?secret=NOT-BASE32!"},
+		{name: "unsupported algorithm", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5"},
+		{name: "digits out of range", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=4"},
+		{name: "too many digits", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=11"},
+		{name: "non-numeric period", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=abc"},
+		{name: "zero period", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0"},
+		{name: "hotp without counter", url: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP"},
+		{name: "negative counter", url: "otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1"},
+		{name: "relative image", url: "otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&image=logo.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := service.ParseOTPAuthURL(tt.url)
+			assert.Nil(t, config)
+			assert.ErrorIs(t, err, entities.ErrInvalidOTPAuthURL)
+		})
+	}
+}
+
+func TestParseOTPAuthParams(t *testing.T) {
+	service := NewTOTPService()
+
+	config, err := service.ParseOTPAuthParams("otpauth://totp/Example:alice?issuer=Example&digits=8")
+	require.NoError(t, err)
+	assert.Equal(t, "Example", config.Issuer)
+	assert.Equal(t, "alice", config.AccountName)
+	assert.Equal(t, 8, config.Digits)
+	assert.Empty(t, config.Secret)
+
+	// A secret must never reach the server in plaintext alongside an encrypted payload
+	_, err = service.ParseOTPAuthParams("otpauth://totp/Example:alice?secret=JBSWY3DPEHPK3PXP")
+	assert.ErrorIs(t, err, entities.ErrInvalidOTPAuthURL)
+}
+
+func TestGenerateOTPAuthURL_RoundTrip(t *testing.T) {
+	service := NewTOTPService()
+
+	configs := []interfaces.TOTPConfig{
+		{
+			Type:        "totp",
+			Secret:      "JBSWY3DPEHPK3PXP",
+			Issuer:      "ACME Co: Staging",
+			AccountName: "john doe+test@example.com",
+			Algorithm:   "SHA256",
+			Digits:      8,
+			Period:      60,
+		},
+		{
+			Type:        "hotp",
+			Secret:      "GEZDGNBVGY3TQOJQ",
+			Issuer:      "Ünïcode & Co",
+			AccountName: "alice/ops?#",
+			Algorithm:   "SHA1",
+			Digits:      6,
+			Counter:     7,
+			Image:       "https://example.com/logo.png?size=64&theme=dark",
+		},
+		{
+			Type:        "totp",
+			Scheme:      "steam",
+			Secret:      "JBSWY3DPEHPK3PXP",
+			Issuer:      "Steam",
+			AccountName: "gaben",
+			Algorithm:   "SHA1",
+			Digits:      5,
+			Period:      30,
+		},
+	}
+
+	for _, original := range configs {
+		url := service.GenerateOTPAuthURL(&original)
+
+		parsed, err := service.ParseOTPAuthURL(url)
+		require.NoError(t, err, url)
+		assert.Equal(t, original, *parsed, url)
+	}
+}
+
+func TestGenerateOTPAuthURL_Defaults(t *testing.T) {
+	service := NewTOTPService()
+
+	url := service.GenerateOTPAuthURL(&interfaces.TOTPConfig{
+		Secret:      "JBSWY3DPEHPK3PXP",
+		Issuer:      "Example",
+		AccountName: "alice@example.com",
+	})
+
+	assert.Equal(t, "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&algorithm=SHA1&digits=6&period=30", url)
+}
@@ -0,0 +1,219 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+const (
+	// steamAlphabet is the character set of Steam Guard codes (no vowels or look-alikes)
+	steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+	steamDigits   = 5
+
+	yandexDigits    = 8
+	yandexSecretLen = 16 // Exported secrets may carry a PIN length and checksum after the key
+)
+
+// builtinSchemes returns the schemes every TOTP service starts with
+func builtinSchemes() []interfaces.OTPScheme {
+	return []interfaces.OTPScheme{standardScheme{}, steamScheme{}, yandexScheme{}}
+}
+
+// standardScheme produces the decimal codes of RFC 4226 and RFC 6238
+type standardScheme struct{}
+
+func (standardScheme) Name() string { return entities.OTPTypeStandard }
+
+func (standardScheme) Normalize(params *interfaces.OTPParams) error {
+	if params.Algorithm == "" {
+		params.Algorithm = DefaultAlgorithm
+	}
+	if params.Digits == 0 {
+		params.Digits = DefaultDigits
+	}
+	switch params.Algorithm {
+	case "SHA1", "SHA256", "SHA512":
+	default:
+		return fmt.Errorf("unsupported algorithm: %s", params.Algorithm)
+	}
+	if params.Digits < MinDigits || params.Digits > MaxDigits {
+		return fmt.Errorf("digits must be between %d and %d, got %d", MinDigits, MaxDigits, params.Digits)
+	}
+	return normalizeTiming(params)
+}
+
+func (standardScheme) Generate(params *interfaces.OTPParams, movingFactor uint64) (string, error) {
+	key, err := decodeSecret(params.Secret)
+	if err != nil {
+		return "", err
+	}
+	sum, err := hmacSum(params.Algorithm, key, movingFactor)
+	if err != nil {
+		return "", err
+	}
+
+	// Use 64-bit arithmetic: 10^10 does not fit in a uint32
+	code := uint64(dynamicTruncate(sum)) % pow10(params.Digits)
+	return fmt.Sprintf("%0*d", params.Digits, code), nil
+}
+
+// steamScheme produces Steam Guard mobile authenticator codes
+type steamScheme struct{}
+
+func (steamScheme) Name() string { return entities.OTPTypeSteam }
+
+func (steamScheme) Normalize(params *interfaces.OTPParams) error {
+	if params.Algorithm == "" {
+		params.Algorithm = DefaultAlgorithm
+	}
+	if params.Digits == 0 {
+		params.Digits = steamDigits
+	}
+	if params.Algorithm != DefaultAlgorithm || params.Digits != steamDigits {
+		return fmt.Errorf("steam codes are always %d characters using %s", steamDigits, DefaultAlgorithm)
+	}
+	return normalizeTiming(params)
+}
+
+func (steamScheme) Generate(params *interfaces.OTPParams, movingFactor uint64) (string, error) {
+	key, err := decodeSecret(params.Secret)
+	if err != nil {
+		return "", err
+	}
+	sum, err := hmacSum(DefaultAlgorithm, key, movingFactor)
+	if err != nil {
+		return "", err
+	}
+
+	value := dynamicTruncate(sum)
+	code := make([]byte, steamDigits)
+	for i := range code {
+		code[i] = steamAlphabet[value%uint32(len(steamAlphabet))]
+		value /= uint32(len(steamAlphabet))
+	}
+	return string(code), nil
+}
+
+// yandexScheme produces Yandex.Key codes. The HMAC key is SHA-256(PIN || secret), so codes can
+// only be generated where the PIN is known.
+type yandexScheme struct{}
+
+func (yandexScheme) Name() string { return entities.OTPTypeYandex }
+
+func (yandexScheme) Normalize(params *interfaces.OTPParams) error {
+	if params.Algorithm == "" {
+		params.Algorithm = "SHA256"
+	}
+	if params.Digits == 0 {
+		params.Digits = yandexDigits
+	}
+	if params.Algorithm != "SHA256" || params.Digits != yandexDigits {
+		return fmt.Errorf("yandex codes are always %d letters using SHA256", yandexDigits)
+	}
+	return normalizeTiming(params)
+}
+
+func (yandexScheme) Generate(params *interfaces.OTPParams, movingFactor uint64) (string, error) {
+	if params.PIN == "" {
+		return "", fmt.Errorf("yandex codes require the PIN")
+	}
+	secret, err := decodeSecret(params.Secret)
+	if err != nil {
+		return "", err
+	}
+	if len(secret) < yandexSecretLen {
+		return "", fmt.Errorf("yandex secret must be at least %d bytes, got %d", yandexSecretLen, len(secret))
+	}
+
+	derived := sha256.Sum256(append([]byte(params.PIN), secret[:yandexSecretLen]...))
+	key := derived[:]
+	if key[0] == 0 {
+		key = key[1:]
+	}
+
+	sum, err := hmacSum("SHA256", key, movingFactor)
+	if err != nil {
+		return "", err
+	}
+
+	// Like RFC 4226 dynamic truncation, but reading 63 bits
+	offset := sum[len(sum)-1] & 0xf
+	value := (binary.BigEndian.Uint64(sum[offset:offset+8]) & 0x7fffffffffffffff) % pow26(yandexDigits)
+
+	code := make([]byte, yandexDigits)
+	for i := len(code) - 1; i >= 0; i-- {
+		code[i] = byte('a' + value%26)
+		value /= 26
+	}
+	return string(code), nil
+}
+
+// normalizeTiming applies the default period and checks the time parameters
+func normalizeTiming(params *interfaces.OTPParams) error {
+	if params.Period == 0 {
+		params.Period = DefaultPeriod
+	}
+	if params.Period < 0 {
+		return fmt.Errorf("period must be positive, got %d", params.Period)
+	}
+	if params.T0 < 0 {
+		return fmt.Errorf("t0 must not be negative, got %d", params.T0)
+	}
+	return nil
+}
+
+// decodeSecret decodes a base32 secret, tolerating lowercase, spaces and missing padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return key, nil
+}
+
+// hmacSum computes the HMAC of the big-endian moving factor
+func hmacSum(algorithm string, key []byte, movingFactor uint64) ([]byte, error) {
+	var mac hash.Hash
+	switch algorithm {
+	case "SHA1":
+		mac = hmac.New(sha1.New, key)
+	case "SHA256":
+		mac = hmac.New(sha256.New, key)
+	case "SHA512":
+		mac = hmac.New(sha512.New, key)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	counterBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(counterBytes, movingFactor)
+	mac.Write(counterBytes)
+	return mac.Sum(nil), nil
+}
+
+// dynamicTruncate extracts the 31-bit value of RFC 4226 section 5.3
+func dynamicTruncate(sum []byte) uint32 {
+	offset := sum[len(sum)-1] & 0xf
+	return binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+}
+
+// pow26 calculates 26^n
+func pow26(n int) uint64 {
+	result := uint64(1)
+	for i := 0; i < n; i++ {
+		result *= 26
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+func TestStandardScheme_TenDigits(t *testing.T) {
+	service := NewTOTPService()
+
+	// RFC 4226 Appendix D truncated values, which fit in 10 digits unreduced
+	expected := map[uint64]string{
+		0: "1284755224",
+		1: "1094287082",
+		2: "0137359152",
+		3: "1726969429",
+	}
+
+	for counter, want := range expected {
+		code, err := service.GenerateCounterCodeForParams(&interfaces.OTPParams{Secret: rfcSecret, Digits: 10}, counter)
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "counter %d", counter)
+	}
+
+	code, err := service.GenerateHOTPCode(rfcSecret, "SHA1", 7, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "4287082", code)
+}
+
+func TestStandardScheme_CustomT0(t *testing.T) {
+	service := NewTOTPService()
+
+	// Shifting T0 and the timestamp together reproduces the RFC 6238 vector for t=59
+	params := &interfaces.OTPParams{Secret: rfcSecret, Digits: 8, T0: 1000}
+	current, _, currentExpiry, _, err := service.GenerateCodesForParams(params, time.Unix(1059, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", current)
+	assert.Equal(t, int64(1060), currentExpiry.Unix())
+
+	_, _, _, _, err = service.GenerateCodesForParams(params, time.Unix(999, 0))
+	assert.Error(t, err)
+}
+
+func TestSteamScheme_ReferenceVectors(t *testing.T) {
+	service := NewTOTPService()
+	secret := base32.StdEncoding.EncodeToString([]byte("superdupersecret"))
+
+	tests := []struct {
+		timestamp int64
+		want      string
+	}{
+		{timestamp: 3000029, want: "94R9D"},
+		{timestamp: 3000030, want: "YRGQJ"},
+	}
+
+	for _, tt := range tests {
+		params := &interfaces.OTPParams{Type: entities.OTPTypeSteam, Secret: secret}
+		code, _, _, _, err := service.GenerateCodesForParams(params, time.Unix(tt.timestamp, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "timestamp %d", tt.timestamp)
+		assert.Equal(t, 5, params.Digits)
+	}
+}
+
+func TestYandexScheme_ReferenceVectors(t *testing.T) {
+	service := NewTOTPService()
+
+	tests := []struct {
+		pin       string
+		secret    string
+		timestamp int64
+		want      string
+	}{
+		{pin: "5239", secret: "6SB2IKNM6OBZPAVBVTOHDKS4FAAAAAAADFUTQMBTRY", timestamp: 1641559648, want: "umozdicq"},
+		{pin: "7586", secret: "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", timestamp: 1581064020, want: "oactmacq"},
+		{pin: "7586", secret: "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI", timestamp: 1581090810, want: "wemdwrix"},
+		{pin: "5210481216086702", secret: "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", timestamp: 1581091469, want: "dfrpywob"},
+		{pin: "5210481216086702", secret: "JBGSAU4G7IEZG6OY4UAXX62JU4AAAAAAHTSG4HXU3M", timestamp: 1581093059, want: "vunyprpd"},
+	}
+
+	for _, tt := range tests {
+		params := &interfaces.OTPParams{Type: entities.OTPTypeYandex, Secret: tt.secret, PIN: tt.pin}
+		code, _, _, _, err := service.GenerateCodesForParams(params, time.Unix(tt.timestamp, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "timestamp %d", tt.timestamp)
+	}
+
+	_, _, _, _, err := service.GenerateCodesForParams(&interfaces.OTPParams{
+		Type:   entities.OTPTypeYandex,
+		Secret: tests[0].secret,
+	}, time.Unix(tests[0].timestamp, 0))
+	assert.Error(t, err, "codes cannot be generated without the PIN")
+}
+
+func TestNormalizeParams(t *testing.T) {
+	service := NewTOTPService()
+
+	params := &interfaces.OTPParams{}
+	require.NoError(t, service.NormalizeParams(params))
+	assert.Equal(t, interfaces.OTPParams{Type: entities.OTPTypeStandard, Algorithm: "SHA1", Digits: 6, Period: 30}, *params)
+
+	params = &interfaces.OTPParams{Type: "Yandex"}
+	require.NoError(t, service.NormalizeParams(params))
+	assert.Equal(t, interfaces.OTPParams{Type: entities.OTPTypeYandex, Algorithm: "SHA256", Digits: 8, Period: 30}, *params)
+
+	for _, invalid := range []*interfaces.OTPParams{
+		{Digits: 5},
+		{Digits: 11},
+		{Type: entities.OTPTypeSteam, Digits: 6},
+		{Type: entities.OTPTypeYandex, Algorithm: "SHA1"},
+		{Period: -30},
+		{T0: -1},
+	} {
+		assert.Error(t, service.NormalizeParams(invalid), "%+v", *invalid)
+	}
+
+	err := service.NormalizeParams(&interfaces.OTPParams{Type: "motp"})
+	assert.ErrorIs(t, err, entities.ErrUnsupportedOTPType)
+}
+
+// reverseScheme is a test scheme that renders the standard code backwards
+type reverseScheme struct{ standardScheme }
+
+func (reverseScheme) Name() string { return "reverse" }
+
+func (s reverseScheme) Generate(params *interfaces.OTPParams, movingFactor uint64) (string, error) {
+	code, err := s.standardScheme.Generate(params, movingFactor)
+	if err != nil {
+		return "", err
+	}
+	reversed := []byte(code)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return string(reversed), nil
+}
+
+func TestRegisterScheme(t *testing.T) {
+	service := NewTOTPService()
+	assert.Equal(t, []string{"standard", "steam", "yandex"}, service.Schemes())
+
+	require.NoError(t, service.RegisterScheme(reverseScheme{}))
+	assert.Error(t, service.RegisterScheme(reverseScheme{}), "names must be unique")
+	assert.Contains(t, service.Schemes(), "reverse")
+
+	code, err := service.GenerateCounterCodeForParams(&interfaces.OTPParams{Type: "reverse", Secret: rfcSecret}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "422557", code)
+}
@@ -2,40 +2,91 @@ package totp
 
 import (
 	"crypto/hmac"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/base32"
-	"encoding/binary"
 	"fmt"
-	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
-	"hash"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 )
 
-type totpService struct{}
+type totpService struct {
+	mu      sync.RWMutex
+	schemes map[string]interfaces.OTPScheme
+}
 
-// NewTOTPService creates a new TOTP service
+// NewTOTPService creates a new TOTP service with the standard, Steam and Yandex schemes registered
 func NewTOTPService() interfaces.TOTPService {
-	return &totpService{}
+	t := &totpService{schemes: make(map[string]interfaces.OTPScheme)}
+	for _, scheme := range builtinSchemes() {
+		t.schemes[scheme.Name()] = scheme
+	}
+	return t
 }
 
-// GenerateCodesForTime generates current and next TOTP codes for a specific time
-func (t *totpService) GenerateCodesForTime(secret string, algorithm string, digits int, period int, timestamp time.Time) (string, string, time.Time, time.Time, error) {
+// RegisterScheme adds a code scheme; names must be unique
+func (t *totpService) RegisterScheme(scheme interfaces.OTPScheme) error {
+	name := strings.ToLower(scheme.Name())
+	if name == "" {
+		return fmt.Errorf("scheme name is required")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.schemes[name]; exists {
+		return fmt.Errorf("scheme %q is already registered", name)
+	}
+	t.schemes[name] = scheme
+	return nil
+}
+
+// Schemes returns the names of all registered schemes
+func (t *totpService) Schemes() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, 0, len(t.schemes))
+	for name := range t.schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NormalizeParams applies the defaults of the params' scheme and validates them
+func (t *totpService) NormalizeParams(params *interfaces.OTPParams) error {
+	_, err := t.normalize(params)
+	return err
+}
+
+// GenerateCodesForParams generates current and next time-based codes for any registered scheme
+func (t *totpService) GenerateCodesForParams(params *interfaces.OTPParams, timestamp time.Time) (string, string, time.Time, time.Time, error) {
+	scheme, err := t.normalize(params)
+	if err != nil {
+		return "", "", time.Time{}, time.Time{}, err
+	}
+
+	elapsed := timestamp.Unix() - params.T0
+	if elapsed < 0 {
+		return "", "", time.Time{}, time.Time{}, fmt.Errorf("timestamp is before t0")
+	}
+
 	// Calculate current time window
-	timeWindow := timestamp.Unix() / int64(period)
-	currentExpiry := time.Unix((timeWindow+1)*int64(period), 0)
-	nextExpiry := time.Unix((timeWindow+2)*int64(period), 0)
+	timeWindow := elapsed / int64(params.Period)
+	currentExpiry := time.Unix(params.T0+(timeWindow+1)*int64(params.Period), 0)
+	nextExpiry := time.Unix(params.T0+(timeWindow+2)*int64(params.Period), 0)
 
 	// Generate current code
-	currentCode, err := t.generateTOTPCode(secret, algorithm, digits, timeWindow)
+	currentCode, err := scheme.Generate(params, uint64(timeWindow))
 	if err != nil {
 		return "", "", time.Time{}, time.Time{}, fmt.Errorf("failed to generate current code: %w", err)
 	}
 
 	// Generate next code
-	nextCode, err := t.generateTOTPCode(secret, algorithm, digits, timeWindow+1)
+	nextCode, err := scheme.Generate(params, uint64(timeWindow+1))
 	if err != nil {
 		return "", "", time.Time{}, time.Time{}, fmt.Errorf("failed to generate next code: %w", err)
 	}
@@ -43,10 +94,49 @@ func (t *totpService) GenerateCodesForTime(secret string, algorithm string, digi
 	return currentCode, nextCode, currentExpiry, nextExpiry, nil
 }
 
+// GenerateCounterCodeForParams generates a counter-based code for any registered scheme
+func (t *totpService) GenerateCounterCodeForParams(params *interfaces.OTPParams, counter uint64) (string, error) {
+	scheme, err := t.normalize(params)
+	if err != nil {
+		return "", err
+	}
+	return scheme.Generate(params, counter)
+}
+
+// normalize looks up the scheme of params and applies its defaults
+func (t *totpService) normalize(params *interfaces.OTPParams) (interfaces.OTPScheme, error) {
+	params.Type = strings.ToLower(params.Type)
+	if params.Type == "" {
+		params.Type = entities.OTPTypeStandard
+	}
+
+	t.mu.RLock()
+	scheme, ok := t.schemes[params.Type]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", entities.ErrUnsupportedOTPType, params.Type)
+	}
+
+	if err := scheme.Normalize(params); err != nil {
+		return nil, fmt.Errorf("invalid %s parameters: %w", params.Type, err)
+	}
+	return scheme, nil
+}
+
+// GenerateCodesForTime generates current and next TOTP codes for a specific time
+func (t *totpService) GenerateCodesForTime(secret string, algorithm string, digits int, period int, timestamp time.Time) (string, string, time.Time, time.Time, error) {
+	return t.GenerateCodesForParams(&interfaces.OTPParams{
+		Secret:    secret,
+		Algorithm: algorithm,
+		Digits:    digits,
+		Period:    period,
+	}, timestamp)
+}
+
 // GenerateCode generates a single TOTP code for a specific time
 func (t *totpService) GenerateCode(secret string, algorithm string, digits int, period int, timestamp time.Time) (string, error) {
 	timeWindow := timestamp.Unix() / int64(period)
-	return t.generateTOTPCode(secret, algorithm, digits, timeWindow)
+	return t.generateOTPCode(secret, algorithm, digits, uint64(timeWindow))
 }
 
 // ValidateCode validates a TOTP code against the expected value with time tolerance
@@ -57,7 +147,7 @@ func (t *totpService) ValidateCode(secret string, algorithm string, digits int,
 	// Check within tolerance window
 	for i := -tolerance; i <= tolerance; i++ {
 		window := currentWindow + int64(i)
-		expectedCode, err := t.generateTOTPCode(secret, algorithm, digits, window)
+		expectedCode, err := t.generateOTPCode(secret, algorithm, digits, uint64(window))
 		if err != nil {
 			return false, fmt.Errorf("failed to generate code for validation: %w", err)
 		}
@@ -69,80 +159,54 @@ func (t *totpService) ValidateCode(secret string, algorithm string, digits int,
 	return false, nil
 }
 
-// generateTOTPCode generates a TOTP code for a specific time window
-func (t *totpService) generateTOTPCode(secret string, algorithm string, digits int, timeWindow int64) (string, error) {
-	// Normalize secret
-	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
-
-	// Decode base32 secret
-	secretBytes, err := base32.StdEncoding.DecodeString(secret)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode secret: %w", err)
-	}
+// GenerateHOTPCode generates a single HOTP code for a specific counter value
+func (t *totpService) GenerateHOTPCode(secret string, algorithm string, digits int, counter uint64) (string, error) {
+	return t.generateOTPCode(secret, algorithm, digits, counter)
+}
 
-	// Convert time window to byte array
-	timeBytes := make([]byte, 8)
-	binary.BigEndian.PutUint64(timeBytes, uint64(timeWindow))
-
-	// Create HMAC hash
-	var hasher hash.Hash
-	switch algorithm {
-	case "SHA1":
-		hasher = hmac.New(sha1.New, secretBytes)
-	case "SHA256":
-		hasher = hmac.New(sha256.New, secretBytes)
-	case "SHA512":
-		hasher = hmac.New(sha512.New, secretBytes)
-	default:
-		return "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+// ValidateHOTPCode validates an HOTP code against the counter and up to lookAhead following values.
+// On a match it returns the counter value to persist next, i.e. one past the matching counter.
+func (t *totpService) ValidateHOTPCode(secret string, algorithm string, digits int, counter uint64, code string, lookAhead int) (bool, uint64, error) {
+	for i := 0; i <= lookAhead; i++ {
+		expectedCode, err := t.generateOTPCode(secret, algorithm, digits, counter+uint64(i))
+		if err != nil {
+			return false, counter, fmt.Errorf("failed to generate code for validation: %w", err)
+		}
+		if hmac.Equal([]byte(expectedCode), []byte(code)) {
+			return true, counter + uint64(i) + 1, nil
+		}
 	}
 
-	hasher.Write(timeBytes)
-	hash := hasher.Sum(nil)
-
-	// Dynamic truncation
-	offset := hash[len(hash)-1] & 0xf
-	truncatedHash := binary.BigEndian.Uint32(hash[offset:offset+4]) & 0x7fffffff
-
-	// Generate code with specified digits
-	code := truncatedHash % uint32(pow10(digits))
+	return false, counter, nil
+}
 
-	// Format with leading zeros
-	format := fmt.Sprintf("%%0%dd", digits)
-	return fmt.Sprintf(format, code), nil
+// generateOTPCode generates an HOTP code (RFC 4226) for a moving factor; TOTP uses the time window
+func (t *totpService) generateOTPCode(secret string, algorithm string, digits int, movingFactor uint64) (string, error) {
+	return standardScheme{}.Generate(&interfaces.OTPParams{
+		Secret:    secret,
+		Algorithm: algorithm,
+		Digits:    digits,
+	}, movingFactor)
 }
 
 // ParseOTPAuthURL parses an otpauth:// URL and extracts TOTP configuration
 func (t *totpService) ParseOTPAuthURL(urlStr string) (*interfaces.TOTPConfig, error) {
-	// This is a simplified implementation
-	// In a real implementation, you would properly parse the URL
-	// For now, return an error since this feature is marked as TODO in the handler
-	return nil, fmt.Errorf("OTP auth URL parsing not implemented")
+	return parseOTPAuthURL(urlStr, true)
+}
+
+// ParseOTPAuthParams parses an otpauth:// URL whose secret was stripped client-side
+func (t *totpService) ParseOTPAuthParams(urlStr string) (*interfaces.TOTPConfig, error) {
+	return parseOTPAuthURL(urlStr, false)
 }
 
 // GenerateOTPAuthURL generates an otpauth:// URL from TOTP configuration
 func (t *totpService) GenerateOTPAuthURL(config *interfaces.TOTPConfig) string {
-	// Set defaults
-	algorithm := config.Algorithm
-	if algorithm == "" {
-		algorithm = "SHA1"
-	}
-	digits := config.Digits
-	if digits == 0 {
-		digits = 6
-	}
-	period := config.Period
-	if period == 0 {
-		period = 30
-	}
-
-	return fmt.Sprintf("otpauth://totp/%s:%s?secret=%s&issuer=%s&algorithm=%s&digits=%d&period=%d",
-		config.Issuer, config.AccountName, config.Secret, config.Issuer, algorithm, digits, period)
+	return formatOTPAuthURL(config)
 }
 
 // pow10 calculates 10^n
-func pow10(n int) int {
-	result := 1
+func pow10(n int) uint64 {
+	result := uint64(1)
 	for i := 0; i < n; i++ {
 		result *= 10
 	}
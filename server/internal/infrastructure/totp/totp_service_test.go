@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the RFC 4226 / RFC 6238 test secret "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateHOTPCode_RFC4226Vectors(t *testing.T) {
+	service := NewTOTPService()
+
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, want := range expected {
+		code, err := service.GenerateHOTPCode(rfcSecret, "SHA1", 6, uint64(counter))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "counter %d", counter)
+	}
+}
+
+func TestGenerateCode_RFC6238Vector(t *testing.T) {
+	service := NewTOTPService()
+
+	code, err := service.GenerateCode(rfcSecret, "SHA1", 8, 30, time.Unix(59, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "94287082", code)
+}
+
+func TestValidateHOTPCode_LookAhead(t *testing.T) {
+	service := NewTOTPService()
+
+	// Code for counter 3 is accepted from counter 1 with a look-ahead of 2
+	valid, next, err := service.ValidateHOTPCode(rfcSecret, "SHA1", 6, 1, "969429", 2)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, uint64(4), next)
+
+	// Outside the look-ahead window the counter is left untouched
+	valid, next, err = service.ValidateHOTPCode(rfcSecret, "SHA1", 6, 1, "338314", 2)
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, uint64(1), next)
+
+	// Codes for counters that were already used are rejected
+	valid, _, err = service.ValidateHOTPCode(rfcSecret, "SHA1", 6, 1, "755224", 2)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
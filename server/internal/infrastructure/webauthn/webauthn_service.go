@@ -407,15 +407,15 @@ func (w *webAuthnService) GetUserCredentials(ctx context.Context, userID string)
 	return credentials, nil
 }
 
-// DeleteCredential deletes a WebAuthn credential
-func (w *webAuthnService) DeleteCredential(ctx context.Context, userID string, credentialID []byte) error {
+// DeleteCredential deletes a WebAuthn credential unless it holds the last wrap of the vault key
+func (w *webAuthnService) DeleteCredential(ctx context.Context, userID string, id uuid.UUID) error {
 	// Convert string userID to UUID
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	if err := w.credRepo.Delete(ctx, credentialID, userUUID); err != nil {
+	if err := w.credRepo.DeleteByID(ctx, id, userUUID); err != nil {
 		return fmt.Errorf("failed to delete WebAuthn credential: %w", err)
 	}
 
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminHandler handles the admin account management endpoints
+type AdminHandler struct {
+	adminService interfaces.AdminService
+	eventService interfaces.VaultEventService
+	auditService interfaces.AuditService
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(adminService interfaces.AdminService, eventService interfaces.VaultEventService, auditService interfaces.AuditService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		eventService: eventService,
+		auditService: auditService,
+	}
+}
+
+// ListUsers lists and searches users
+// @Summary List users
+// @Description Returns users newest first, optionally searched by username, email or display name and filtered by role and status. The cursor of the next page, if any, is returned in X-Next-Cursor.
+// @Tags admin
+// @Produce json
+// @Param q query string false "Case-insensitive substring of the username, email or display name"
+// @Param role query string false "user or admin"
+// @Param status query string false "active or suspended"
+// @Param limit query int false "Page size (default 50, at most 200)"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Success 200 {array} entities.User
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page; absent on the last page"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	filter := entities.UserFilter{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Cursor: c.Query("cursor"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			respondBadRequest(c, "Invalid limit", err.Error())
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	page, err := h.adminService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidUserFilter) || errors.Is(err, entities.ErrInvalidCursor) {
+			respondBadRequest(c, "Invalid listing parameters", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to list users", err.Error())
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// GetUser returns one user
+// @Summary Get a user
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} entities.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	user, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		respondAdminError(c, "Failed to get user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SuspendUser suspends an account
+// @Summary Suspend a user
+// @Description Deactivates the account and revokes every token it holds. The user's devices get a session.revoked event and the user can no longer sign in until reactivated.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	h.manageUser(c, "suspended", entities.AuditActionAccountSuspended, func(adminID, userID uuid.UUID) error {
+		return h.adminService.SuspendUser(c.Request.Context(), adminID, userID)
+	})
+}
+
+// ReactivateUser reactivates a suspended account
+// @Summary Reactivate a user
+// @Description Lets a suspended user sign in again. Tokens revoked by the suspension stay revoked.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+func (h *AdminHandler) ReactivateUser(c *gin.Context) {
+	h.manageUser(c, "reactivated", entities.AuditActionAccountReactivated, func(_, userID uuid.UUID) error {
+		return h.adminService.ReactivateUser(c.Request.Context(), userID)
+	})
+}
+
+// LogoutUser signs a user out everywhere
+// @Summary Force-logout a user
+// @Description Revokes every token the user holds and sends their devices a session.revoked event. The user can sign in again.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/users/{id}/logout [post]
+func (h *AdminHandler) LogoutUser(c *gin.Context) {
+	h.manageUser(c, "logged out", entities.AuditActionAccountLoggedOut, func(_, userID uuid.UUID) error {
+		return h.adminService.ForceLogout(c.Request.Context(), userID)
+	})
+}
+
+// GetStats returns aggregate counts
+// @Summary Get instance stats
+// @Description Returns user, vault entry and passkey counts. New and signed-in users are counted over the last windowDays days.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} entities.UserStats
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/admin/stats [get]
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminService.GetStats(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, "Failed to get stats", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// manageUser applies an account action to the user in the path, then records it in the user's
+// audit log and, unless it is a reactivation, ends their sessions on other devices
+func (h *AdminHandler) manageUser(c *gin.Context, done, action string, apply func(adminID, userID uuid.UUID) error) {
+	// Get authenticated admin ID
+	adminID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	userID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	if err := apply(adminID, userID); err != nil {
+		respondAdminError(c, "Failed to update user", err)
+		return
+	}
+
+	recordAudit(c, h.auditService, &userID, action, entities.AuditResourceUser, &userID, map[string]string{"admin_id": adminID.String()})
+	if action != entities.AuditActionAccountReactivated {
+		if err := h.eventService.Publish(c.Request.Context(), userID, entities.VaultEventSessionRevoked, nil); err != nil {
+			slog.Warn("Failed to publish session revoked event", "user_id", userID, "error", err)
+		}
+	}
+
+	respondWithSuccess(c, http.StatusOK, "User "+done)
+}
+
+// respondAdminError maps admin service errors to responses
+func respondAdminError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrUserNotFound):
+		respondNotFound(c, "User not found", err.Error())
+	case errors.Is(err, entities.ErrCannotSuspendSelf):
+		respondWithError(c, http.StatusConflict, "Admins cannot suspend their own account", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
@@ -1,30 +1,56 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/markbates/goth/gothic"
 )
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	authService interfaces.AuthService
-	config      *config.Config
+	authService    interfaces.AuthService
+	sessionService interfaces.SessionService
+	eventService   interfaces.VaultEventService
+	auditService   interfaces.AuditService
+	config         *config.Config
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService interfaces.AuthService, cfg *config.Config) *AuthHandler {
+func NewAuthHandler(authService interfaces.AuthService, sessionService interfaces.SessionService, eventService interfaces.VaultEventService, auditService interfaces.AuditService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		config:      cfg,
+		authService:    authService,
+		sessionService: sessionService,
+		eventService:   eventService,
+		auditService:   auditService,
+		config:         cfg,
 	}
 }
 
+// jwksMaxAge is how long clients may cache the JWKS. A new signing key is published at least
+// this long before it becomes active.
+const jwksMaxAge = time.Hour
+
+// JWKS serves the public keys access tokens are signed with
+// @Summary JSON Web Key Set
+// @Description Returns the public keys access tokens may be verified with, matched to a token by its kid header. Keys are Ed25519 (EdDSA) or P-256 (ES256). A key is published before it signs tokens and stays published until the tokens it signed have expired.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} interfaces.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(jwksMaxAge.Seconds())))
+	c.JSON(http.StatusOK, h.authService.PublicKeys())
+}
+
 // OAuthLogin initiates OAuth login flow
 // @Summary Start OAuth login
 // @Description Initiates OAuth login flow with the specified provider
@@ -75,6 +101,7 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 	if err != nil {
 		// Log the actual error for debugging
 		fmt.Printf("OAuth CompleteUserAuth error: %v\n", err)
+		recordAudit(c, h.auditService, nil, entities.AuditActionAuthFailed, entities.AuditResourceUser, nil, map[string]string{"method": "oauth", "provider": provider})
 		c.JSON(http.StatusBadRequest, gin.H{"error": "OAuth authentication failed", "details": err.Error()})
 		return
 	}
@@ -122,6 +149,11 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 	// Register or login user
 	fmt.Printf("Attempting to register/login user with data: %+v\n", oauthData)
 	user, err := h.authService.RegisterOrLoginUser(c.Request.Context(), oauthData)
+	if errors.Is(err, entities.ErrUserSuspended) {
+		recordAudit(c, h.auditService, nil, entities.AuditActionAuthFailed, entities.AuditResourceUser, nil, map[string]string{"method": "oauth", "provider": provider, "reason": "suspended"})
+		c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		return
+	}
 	if err != nil {
 		// Log the actual registration error
 		fmt.Printf("RegisterOrLoginUser error: %v\n", err)
@@ -131,27 +163,27 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 
 	fmt.Printf("User registered/logged in successfully: %+v\n", user)
 
-	// Generate JWT token
-	token, err := h.authService.GenerateJWT(user)
+	// Start a session for this device and issue the token for it
+	session, err := h.sessionService.CreateSession(c.Request.Context(), user.ID)
+	if err != nil {
+		fmt.Printf("CreateSession error: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session", "details": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.IssueTokens(c.Request.Context(), user, session.ID)
 	if err != nil {
-		// Log JWT generation error
-		fmt.Printf("GenerateJWT error: %v\n", err)
+		// Log token generation error
+		fmt.Printf("IssueTokens error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token", "details": err.Error()})
 		return
 	}
 
 	fmt.Printf("JWT token generated successfully\n")
+	recordAudit(c, h.auditService, &user.ID, entities.AuditActionLogin, entities.AuditResourceUser, &user.ID, map[string]string{"method": "oauth", "provider": provider, "session_id": session.ID.String()})
 
-	// Set token in cookie
-	c.SetCookie(
-		"auth_token",
-		token,
-		int(24*time.Hour.Seconds()), // 24 hours
-		"/",
-		"",
-		h.config.IsProduction(), // Secure flag: true in production (HTTPS), false in development
-		true,                    // HTTP-only
-	)
+	// Set tokens in cookies
+	h.setTokenCookies(c, tokens)
 
 	// Redirect back to frontend app (no token in URL - cookie is sufficient)
 	redirectURL := fmt.Sprintf("%s/app", h.config.Frontend.URL)
@@ -161,75 +193,87 @@ func (h *AuthHandler) OAuthCallback(c *gin.Context) {
 
 // Logout handles user logout
 // @Summary Logout user
-// @Description Logs out the current user
+// @Description Ends the current session, so its token stops working, and notifies the user's other devices with a session.revoked event. Once the access token has expired the session is found from the refresh_token cookie.
 // @Tags auth
 // @Success 200 {object} map[string]string
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// Clear auth cookie
-	c.SetCookie(
-		"auth_token",
-		"",
-		-1,
-		"/",
-		"",
-		h.config.IsProduction(), // Secure flag: true in production (HTTPS), false in development
-		true,                    // HTTP-only
-	)
+	// End the session and tell the user's other devices about it. Once the access token has
+	// expired the refresh token, whose cookie is scoped to this path, still names the session.
+	userID, err := getUserIDFromContext(c)
+	sessionID, sessionErr := getSessionIDFromContext(c)
+	if err != nil || sessionErr != nil {
+		userID, sessionID, err = h.refreshTokenSession(c)
+	}
+	if err == nil {
+		if err := revokeSession(c, h.sessionService, h.eventService, h.auditService, userID, sessionID); err != nil {
+			slog.Warn("Failed to end session on logout", "user_id", userID, "session_id", sessionID, "error", err)
+		}
+	}
+
+	// Clear auth cookies
+	h.clearTokenCookies(c)
 
 	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
 
-// RefreshToken refreshes the JWT token
-// @Summary Refresh JWT token
-// @Description Refreshes the JWT token if it's close to expiry
+// RefreshTokenRequest represents the request to refresh tokens without the refresh cookie
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for new tokens
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token, from the refresh_token cookie or the request body, for a new access token and a new refresh token. Each refresh token works once: presenting a used one again revokes its session, signing out every holder of it.
 // @Tags auth
-// @Success 200 {object} map[string]interface{}
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest false "Refresh token, when not sent as a cookie"
+// @Success 200 {object} interfaces.TokenPair
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	// Get token from cookie or header
-	token, err := c.Cookie("auth_token")
-	if err != nil {
-		// Try Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "no token provided"})
-			return
-		}
-
-		// Extract token from "Bearer <token>"
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			token = authHeader[7:]
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token format"})
+	// Get the refresh token from its cookie or the body
+	refreshToken, err := c.Cookie(refreshTokenCookie)
+	if err != nil || refreshToken == "" {
+		var req RefreshTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "no refresh token provided"})
 			return
 		}
+		refreshToken = req.RefreshToken
 	}
 
-	// Refresh token
-	newToken, err := h.authService.RefreshJWT(token)
+	tokens, err := h.authService.RefreshTokens(c.Request.Context(), refreshToken)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "failed to refresh token"})
+		switch {
+		case errors.Is(err, entities.ErrUserSuspended):
+			c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		case errors.Is(err, entities.ErrRefreshTokenReused):
+			// Already recorded as auth.refresh_reused, along with the revoked session
+			h.clearTokenCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "failed to refresh token"})
+		case errors.Is(err, entities.ErrInvalidRefreshToken), errors.Is(err, entities.ErrUserNotFound),
+			errors.Is(err, entities.ErrSessionNotFound), errors.Is(err, entities.ErrSessionExpired):
+			recordAudit(c, h.auditService, nil, entities.AuditActionAuthFailed, entities.AuditResourceUser, nil, map[string]string{"method": "refresh"})
+			h.clearTokenCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "failed to refresh token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		}
 		return
 	}
+	if claims, err := h.authService.ValidateJWT(tokens.AccessToken); err == nil {
+		if userID, err := uuid.Parse(claims.UserID); err == nil {
+			recordAudit(c, h.auditService, &userID, entities.AuditActionTokenRefreshed, entities.AuditResourceUser, &userID, nil)
+		}
+	}
 
-	// Set new token in cookie
-	c.SetCookie(
-		"auth_token",
-		newToken,
-		int(24*time.Hour.Seconds()),
-		"/",
-		"",
-		h.config.IsProduction(), // Secure flag: true in production (HTTPS), false in development
-		true,                    // HTTP-only
-	)
+	h.setTokenCookies(c, tokens)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "token refreshed successfully",
-		"token":   newToken,
-	})
+	c.JSON(http.StatusOK, tokens)
 }
 
 // GetProfile returns the current user's profile
@@ -258,6 +302,7 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 		"id":       claims.UserID,
 		"username": claims.Username,
 		"email":    claims.Email,
+		"role":     claims.Role,
 	})
 }
 
@@ -287,3 +332,38 @@ func (h *AuthHandler) GetProviders(c *gin.Context) {
 		"providers": providers,
 	})
 }
+
+// Token cookies. The refresh cookie is only sent to the auth endpoints that use it.
+const (
+	accessTokenCookie  = "auth_token"
+	refreshTokenCookie = "refresh_token"
+	refreshCookiePath  = "/api/v1/auth"
+)
+
+// setTokenCookies stores a token pair in HTTP-only cookies that expire with the tokens
+func (h *AuthHandler) setTokenCookies(c *gin.Context, tokens *interfaces.TokenPair) {
+	secure := h.config.IsProduction() // Secure flag: true in production (HTTPS), false in development
+	c.SetCookie(accessTokenCookie, tokens.AccessToken, int(time.Until(tokens.ExpiresAt).Seconds()), "/", "", secure, true)
+	c.SetCookie(refreshTokenCookie, tokens.RefreshToken, int(time.Until(tokens.RefreshExpiresAt).Seconds()), refreshCookiePath, "", secure, true)
+}
+
+// clearTokenCookies removes both token cookies
+func (h *AuthHandler) clearTokenCookies(c *gin.Context) {
+	secure := h.config.IsProduction()
+	c.SetCookie(accessTokenCookie, "", -1, "/", "", secure, true)
+	c.SetCookie(refreshTokenCookie, "", -1, refreshCookiePath, "", secure, true)
+}
+
+// refreshTokenSession returns the user and session named by the refresh token cookie
+func (h *AuthHandler) refreshTokenSession(c *gin.Context) (uuid.UUID, uuid.UUID, error) {
+	refreshToken, err := c.Cookie(refreshTokenCookie)
+	if err != nil || refreshToken == "" {
+		return uuid.Nil, uuid.Nil, entities.ErrInvalidRefreshToken
+	}
+
+	token, err := h.authService.LookupRefreshToken(c.Request.Context(), refreshToken)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return token.UserID, token.SessionID, nil
+}
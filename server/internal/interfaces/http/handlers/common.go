@@ -96,6 +96,16 @@ func getUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
 	return userID, nil
 }
 
+// getSessionIDFromContext extracts the ID of the sign-in session the request's token was issued for
+func getSessionIDFromContext(c *gin.Context) (uuid.UUID, error) {
+	sessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid session ID format: %w", err)
+	}
+
+	return sessionID, nil
+}
+
 // requireUserID is a helper that gets user ID from context and handles errors
 func requireUserID(c *gin.Context) (uuid.UUID, bool) {
 	userID, err := getUserIDFromContext(c)
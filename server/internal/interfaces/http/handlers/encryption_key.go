@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EncryptionKeyHandler handles the wrapped vault key endpoints
+type EncryptionKeyHandler struct {
+	keyService interfaces.EncryptionKeyService
+}
+
+// NewEncryptionKeyHandler creates a new encryption key handler
+func NewEncryptionKeyHandler(keyService interfaces.EncryptionKeyService) *EncryptionKeyHandler {
+	return &EncryptionKeyHandler{
+		keyService: keyService,
+	}
+}
+
+// AddKeyRequest represents a passkey's wrap of the vault key
+type AddKeyRequest struct {
+	CredentialID string `json:"credential_id" binding:"required"`
+	WrappedDEK   string `json:"wrapped_dek" binding:"required"` // Base64
+	Salt         string `json:"salt" binding:"required"`        // Base64
+	KeyVersion   int    `json:"key_version"`                    // Omitted for the current key
+}
+
+// GetKeys lists the wraps of the vault key
+// @Summary List wrapped vault keys
+// @Description Lists the active wrap of the vault key held by each of the authenticated user's passkeys. Wraps and salts are base64 encoded.
+// @Tags vault-keys
+// @Produce json
+// @Success 200 {array} entities.UserEncryptionKey
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/keys [get]
+func (h *EncryptionKeyHandler) GetKeys(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	keys, err := h.keyService.ListKeys(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve wrapped keys", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// GetKey returns the wrap held by a passkey
+// @Summary Get a passkey's wrapped vault key
+// @Description Returns the active wrap of the vault key held by one passkey. After an assertion the device unwraps it with the KEK derived from the passkey's PRF output.
+// @Tags vault-keys
+// @Produce json
+// @Param credentialId path string true "Credential ID as returned by the credential list"
+// @Success 200 {object} entities.UserEncryptionKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/keys/{credentialId} [get]
+func (h *EncryptionKeyHandler) GetKey(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	credentialID, ok := parseUUIDParam(c, "credentialId")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	key, err := h.keyService.GetKey(c.Request.Context(), userID, credentialID)
+	if err != nil {
+		respondEncryptionKeyError(c, "Failed to retrieve wrapped key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// AddKey stores a passkey's wrap of the vault key
+// @Summary Add a wrapped vault key
+// @Description Stores a passkey's wrap of the vault key. The first wrap a user uploads starts key version 1 and should wrap the key the vault is already encrypted with. To let a newly registered passkey unlock the vault, unlock the DEK with an existing passkey, wrap it with the new passkey's KEK and upload it here. During a key rotation, key_version may name the rotation's new version to wrap the new key as well. A passkey holds one wrap per key version; uploading another returns 409.
+// @Tags vault-keys
+// @Accept json
+// @Produce json
+// @Param key body AddKeyRequest true "Wrapped DEK for a passkey"
+// @Success 201 {object} entities.UserEncryptionKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/keys [post]
+func (h *EncryptionKeyHandler) AddKey(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req AddKeyRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	credentialID, err := uuid.Parse(req.CredentialID)
+	if err != nil {
+		respondBadRequest(c, "Invalid credential ID", err.Error())
+		return
+	}
+	wrappedDEK, err := base64.StdEncoding.DecodeString(req.WrappedDEK)
+	if err != nil {
+		respondBadRequest(c, "Invalid wrapped key", err.Error())
+		return
+	}
+	salt, err := base64.StdEncoding.DecodeString(req.Salt)
+	if err != nil {
+		respondBadRequest(c, "Invalid salt", err.Error())
+		return
+	}
+
+	key := entities.NewUserEncryptionKey(userID, credentialID, req.KeyVersion, wrappedDEK, salt)
+	if err := h.keyService.AddKey(c.Request.Context(), userID, key); err != nil {
+		respondEncryptionKeyError(c, "Failed to add wrapped key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// respondEncryptionKeyError maps encryption key service errors to HTTP responses
+func respondEncryptionKeyError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidEncryptionKey), errors.Is(err, entities.ErrCredentialNotFound):
+		respondBadRequest(c, "Invalid wrapped key", err.Error())
+	case errors.Is(err, entities.ErrKeyNotFound):
+		respondNotFound(c, "Wrapped key not found", err.Error())
+	case errors.Is(err, entities.ErrKeyExists):
+		respondWithError(c, http.StatusConflict, "Passkey already holds a wrapped key", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// formatETag renders an entry revision as a strong entity tag
+func formatETag(revision int64) string {
+	return `"` + strconv.FormatInt(revision, 10) + `"`
+}
+
+// setETag sets the ETag header to an entry's revision
+func setETag(c *gin.Context, revision int64) {
+	c.Header("ETag", formatETag(revision))
+}
+
+// requireIfMatch parses the If-Match header that conditional writes must send. It returns the
+// expected revision, or 0 for "*", which matches whatever version is current. A missing header is
+// answered with 428 and a header that is not an entry ETag with 400.
+func requireIfMatch(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		respondWithError(c, http.StatusPreconditionRequired, "If-Match header is required",
+			"send the ETag of the version being changed so that edits from other devices are not overwritten")
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// Weak tags never match under the strong comparison If-Match requires
+	if strings.HasPrefix(header, "W/") {
+		respondWithError(c, http.StatusPreconditionFailed, "Precondition failed", "weak ETags cannot be used with If-Match")
+		return 0, false
+	}
+
+	revision, err := strconv.ParseInt(strings.Trim(header, `"`), 10, 64)
+	if err != nil || revision < 1 {
+		respondBadRequest(c, "Invalid If-Match header", "expected an ETag returned by the API")
+		return 0, false
+	}
+
+	return revision, true
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		revision int64
+		status   int // 0 when the header is accepted
+	}{
+		{"missing", "", 0, http.StatusPreconditionRequired},
+		{"blank", "   ", 0, http.StatusPreconditionRequired},
+		{"any version", "*", 0, 0},
+		{"quoted", `"3"`, 3, 0},
+		{"unquoted", "3", 3, 0},
+		{"surrounding space", ` "12" `, 12, 0},
+		{"weak", `W/"3"`, 0, http.StatusPreconditionFailed},
+		{"weak unquoted", "W/3", 0, http.StatusPreconditionFailed},
+		{"not numeric", `"abc"`, 0, http.StatusBadRequest},
+		{"zero", `"0"`, 0, http.StatusBadRequest},
+		{"negative", `"-1"`, 0, http.StatusBadRequest},
+		{"list", `"3", "4"`, 0, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/otp/id", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			revision, ok := requireIfMatch(c)
+			if tt.status == 0 {
+				assert.True(t, ok)
+				assert.Equal(t, tt.revision, revision)
+				assert.False(t, c.IsAborted())
+				return
+			}
+
+			assert.False(t, ok)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
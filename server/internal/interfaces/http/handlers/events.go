@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/interfaces/http/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// eventStreamHeartbeat is how often an idle event stream sends a comment, so proxies and load
+// balancers do not close it. The stream's token is checked again at the same interval.
+const eventStreamHeartbeat = 25 * time.Second
+
+// EventHandler handles the real-time vault event stream
+type EventHandler struct {
+	eventService interfaces.VaultEventService
+	authService  interfaces.AuthService
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(eventService interfaces.VaultEventService, authService interfaces.AuthService) *EventHandler {
+	return &EventHandler{
+		eventService: eventService,
+		authService:  authService,
+	}
+}
+
+// Stream pushes vault events to the device as Server-Sent Events
+// @Summary Stream vault events
+// @Description Streams changes made on the user's other devices as Server-Sent Events: entry.created, entry.updated, entry.inactivated, credential.added, session.revoked and key.rotated. Events carry no entry content; fetch entry changes with delta sync. Each event's id is a cursor; reconnect with it in Last-Event-ID or the cursor query parameter to resume without missing events. Without a cursor the stream starts at the current moment. The stream closes when its session is revoked, or when access is lost, which is checked at every heartbeat.
+// @Tags events
+// @Produce text/event-stream
+// @Param cursor query string false "Resume after this event"
+// @Param device_id query string false "Skip events made by this device; defaults to the X-Device-ID header"
+// @Success 200 {object} entities.VaultEvent
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/events [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		respondUnauthorized(c, "User not authenticated")
+		return
+	}
+
+	// Browsers resend the last event id on their own when an EventSource reconnects
+	cursor := c.Query("cursor")
+	if cursor == "" {
+		cursor = c.GetHeader("Last-Event-ID")
+	}
+
+	// EventSource cannot set headers, so the device may identify itself in the query instead
+	deviceID := c.Query("device_id")
+	if deviceID == "" {
+		deviceID = entities.RequestInfoFromContext(c.Request.Context()).DeviceID
+	}
+
+	events, err := h.eventService.Subscribe(c.Request.Context(), userID, deviceID, cursor)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidCursor) {
+			respondBadRequest(c, "Invalid cursor", "reconnect without a cursor and sync to catch up")
+			return
+		}
+		respondInternalError(c, "Failed to subscribe to events", err.Error())
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		respondInternalError(c, "Streaming not supported", err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case event, open := <-events:
+			if !open {
+				return // The device reconnects with its last cursor
+			}
+			if err := writeServerSentEvent(c.Writer, event); err != nil {
+				return
+			}
+			// The device still gets the event that signs it out
+			if endsSession(event, claims.SessionID) {
+				c.Writer.Flush()
+				return
+			}
+		case <-heartbeat.C:
+			// The stream outlives the check made when it opened: stop once the session has
+			// ended, the token is revoked or the account is suspended
+			if err := h.authService.VerifyAccess(c.Request.Context(), claims); err != nil {
+				return
+			}
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// endsSession reports whether event revokes the session with the given ID. A revocation that
+// names no session, from an admin signing the user out or suspending them, ends every session.
+func endsSession(event *entities.VaultEvent, sessionID string) bool {
+	if event.Type != entities.VaultEventSessionRevoked {
+		return false
+	}
+	return event.EntityID == nil || event.EntityID.String() == sessionID
+}
+
+// writeServerSentEvent writes an event in the text/event-stream format
+func writeServerSentEvent(w gin.ResponseWriter, event *entities.VaultEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Cursor, event.Type, data)
+	return err
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// FolderHandler handles vault folder endpoints
+type FolderHandler struct {
+	folderService interfaces.FolderService
+}
+
+// NewFolderHandler creates a new folder handler
+func NewFolderHandler(folderService interfaces.FolderService) *FolderHandler {
+	return &FolderHandler{
+		folderService: folderService,
+	}
+}
+
+// FolderRequest represents the request body for creating or updating a folder
+type FolderRequest struct {
+	Name     string  `json:"name" binding:"required"`
+	ParentID *string `json:"parent_id"` // Omitted or empty for the top level
+}
+
+// GetFolders lists the user's folders
+// @Summary List folders
+// @Description Lists all of the authenticated user's folders as a flat list. Nesting is expressed through parentId; entryCount counts the entries directly in each folder.
+// @Tags folders
+// @Produce json
+// @Success 200 {array} entities.Folder
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/folders [get]
+func (h *FolderHandler) GetFolders(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	folders, err := h.folderService.ListFolders(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve folders", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, folders)
+}
+
+// CreateFolder creates a folder
+// @Summary Create a folder
+// @Description Creates a folder at the top level or inside parent_id. Sibling folders must have distinct names.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param folder body FolderRequest true "Folder name and parent"
+// @Success 201 {object} entities.Folder
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/folders [post]
+func (h *FolderHandler) CreateFolder(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req FolderRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	parentID, err := parseFolderRef(req.ParentID)
+	if err != nil {
+		respondBadRequest(c, "Invalid parent folder ID", err.Error())
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(c.Request.Context(), userID, req.Name, parentID)
+	if err != nil {
+		respondFolderError(c, "Failed to create folder", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, folder)
+}
+
+// UpdateFolder renames or moves a folder
+// @Summary Rename or move a folder
+// @Description Renames a folder and places it under parent_id (omitted or empty for the top level). A folder cannot be moved into its own subtree.
+// @Tags folders
+// @Accept json
+// @Produce json
+// @Param id path string true "Folder ID"
+// @Param folder body FolderRequest true "Folder name and parent"
+// @Success 200 {object} entities.Folder
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/folders/{id} [put]
+func (h *FolderHandler) UpdateFolder(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate folder ID from URL
+	folderID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	var req FolderRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	parentID, err := parseFolderRef(req.ParentID)
+	if err != nil {
+		respondBadRequest(c, "Invalid parent folder ID", err.Error())
+		return
+	}
+
+	folder, err := h.folderService.UpdateFolder(c.Request.Context(), folderID, userID, req.Name, parentID)
+	if err != nil {
+		respondFolderError(c, "Failed to update folder", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, folder)
+}
+
+// DeleteFolder deletes a folder
+// @Summary Delete a folder
+// @Description Deletes a folder. Its entries and subfolders move up to the folder's parent; no entry is deleted.
+// @Tags folders
+// @Param id path string true "Folder ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/folders/{id} [delete]
+func (h *FolderHandler) DeleteFolder(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate folder ID from URL
+	folderID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	if err := h.folderService.DeleteFolder(c.Request.Context(), folderID, userID); err != nil {
+		respondFolderError(c, "Failed to delete folder", err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, "Folder deleted successfully")
+}
+
+// respondFolderError maps folder service errors to HTTP responses
+func respondFolderError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidFolder):
+		respondBadRequest(c, "Invalid folder", err.Error())
+	case errors.Is(err, entities.ErrFolderNotFound):
+		respondNotFound(c, "Folder not found", err.Error())
+	case errors.Is(err, entities.ErrFolderExists):
+		respondWithError(c, http.StatusConflict, "Folder already exists", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
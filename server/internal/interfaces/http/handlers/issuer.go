@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// Issuer search page sizes
+const (
+	defaultIssuerSearchLimit = 10
+	maxIssuerSearchLimit     = 50
+)
+
+// IssuerHandler handles the issuer catalog endpoints
+type IssuerHandler struct {
+	catalog interfaces.IssuerCatalog
+}
+
+// NewIssuerHandler creates a new issuer handler
+func NewIssuerHandler(catalog interfaces.IssuerCatalog) *IssuerHandler {
+	return &IssuerHandler{
+		catalog: catalog,
+	}
+}
+
+// SearchIssuers autocompletes issuer names from the catalog
+// @Summary Search the issuer catalog
+// @Description Returns known issuers whose name, alias or domain matches the query: exact matches first, then prefix and substring matches. Each entry carries its canonical name, icon URL and default code parameters.
+// @Tags issuers
+// @Produce json
+// @Param q query string false "Partial issuer name, alias or domain"
+// @Param limit query int false "Maximum results (default 10, at most 50)"
+// @Success 200 {array} entities.Issuer
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /api/v1/issuers [get]
+func (h *IssuerHandler) SearchIssuers(c *gin.Context) {
+	limit := defaultIssuerSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondBadRequest(c, "Invalid limit", "limit must be a positive integer")
+			return
+		}
+		limit = min(parsed, maxIssuerSearchLimit)
+	}
+
+	c.JSON(http.StatusOK, h.catalog.Search(c.Query("q"), limit))
+}
+
+// LookupIssuer resolves an issuer name to its catalog entry
+// @Summary Look up an issuer
+// @Description Resolves an issuer name, alias or domain to its catalog entry. Matching ignores case, spaces and punctuation.
+// @Tags issuers
+// @Produce json
+// @Param name query string true "Issuer name, alias or domain"
+// @Success 200 {object} entities.Issuer
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/issuers/lookup [get]
+func (h *IssuerHandler) LookupIssuer(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		respondBadRequest(c, "Invalid request format", "name is required")
+		return
+	}
+
+	issuer, ok := h.catalog.Lookup(name)
+	if !ok {
+		respondNotFound(c, "Issuer not found", "issuer is not in the catalog")
+		return
+	}
+
+	c.JSON(http.StatusOK, issuer)
+}
+
+// GetIcon serves a bundled issuer icon
+// @Summary Get an issuer icon
+// @Description Serves a bundled issuer icon. Icons are static assets and need no authentication.
+// @Tags issuers
+// @Produce image/svg+xml
+// @Param file path string true "Icon file name"
+// @Success 200 {file} binary
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/issuers/icons/{file} [get]
+func (h *IssuerHandler) GetIcon(c *gin.Context) {
+	data, contentType, ok := h.catalog.Icon(c.Param("file"))
+	if !ok {
+		respondNotFound(c, "Icon not found", "no bundled icon with that name")
+		return
+	}
+
+	// Icons ship with the server binary, so they only change on upgrade
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Data(http.StatusOK, contentType, data)
+}
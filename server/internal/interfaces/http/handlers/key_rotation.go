@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// KeyRotationHandler handles the vault key rotation endpoints
+type KeyRotationHandler struct {
+	rotationService interfaces.KeyRotationService
+	eventService    interfaces.VaultEventService
+}
+
+// NewKeyRotationHandler creates a new key rotation handler
+func NewKeyRotationHandler(rotationService interfaces.KeyRotationService, eventService interfaces.VaultEventService) *KeyRotationHandler {
+	return &KeyRotationHandler{
+		rotationService: rotationService,
+		eventService:    eventService,
+	}
+}
+
+// StartKeyRotationRequest represents the request body for starting a key rotation
+type StartKeyRotationRequest struct {
+	Keys []WrappedKeyRequest `json:"keys" binding:"required,min=1"`
+}
+
+// WrappedKeyRequest represents the new DEK wrapped for one of the user's credentials
+type WrappedKeyRequest struct {
+	CredentialID string `json:"credential_id" binding:"required"`
+	WrappedDEK   string `json:"wrapped_dek" binding:"required"` // Base64
+	Salt         string `json:"salt" binding:"required"`        // Base64
+}
+
+// StageKeyRotationRequest represents a chunk of re-encrypted secrets
+type StageKeyRotationRequest struct {
+	Entries []StageKeyRotationEntryRequest `json:"entries" binding:"required,min=1"`
+}
+
+// StageKeyRotationEntryRequest represents one entry's secret re-encrypted with the new key
+type StageKeyRotationEntryRequest struct {
+	ID       string `json:"id" binding:"required"`
+	Revision int64  `json:"revision" binding:"required"` // The revision the secret was listed at
+	Secret   string `json:"secret" binding:"required"`   // Client-encrypted, encrypted with the rotation's new key
+}
+
+// StartRotation begins a key rotation
+// @Summary Start a key rotation
+// @Description Starts moving the vault to the next key version. The new DEK is wrapped on the device for each of the user's credentials; the wraps stay inactive until the rotation commits. Only one rotation per user can be in progress.
+// @Tags key-rotation
+// @Accept json
+// @Produce json
+// @Param rotation body StartKeyRotationRequest true "New DEK wrapped per credential"
+// @Success 201 {object} entities.KeyRotation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation [post]
+func (h *KeyRotationHandler) StartRotation(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req StartKeyRotationRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	keys := make([]*entities.UserEncryptionKey, 0, len(req.Keys))
+	for i, item := range req.Keys {
+		credentialID, err := uuid.Parse(item.CredentialID)
+		if err != nil {
+			respondBadRequest(c, "Invalid credential ID", fmt.Sprintf("key %d: %v", i, err))
+			return
+		}
+		wrappedDEK, err := base64.StdEncoding.DecodeString(item.WrappedDEK)
+		if err != nil {
+			respondBadRequest(c, "Invalid wrapped key", fmt.Sprintf("key %d: %v", i, err))
+			return
+		}
+		salt, err := base64.StdEncoding.DecodeString(item.Salt)
+		if err != nil {
+			respondBadRequest(c, "Invalid salt", fmt.Sprintf("key %d: %v", i, err))
+			return
+		}
+
+		keys = append(keys, entities.NewUserEncryptionKey(userID, credentialID, 0, wrappedDEK, salt))
+	}
+
+	rotation, err := h.rotationService.StartRotation(c.Request.Context(), userID, keys)
+	if err != nil {
+		respondKeyRotationError(c, "Failed to start key rotation", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rotation)
+}
+
+// GetRotation returns the rotation in progress
+// @Summary Get key rotation progress
+// @Description Returns the rotation in progress with the number of entries below the new key version and how many of them have a re-encrypted secret staged. A device that crashed midway resumes from here.
+// @Tags key-rotation
+// @Produce json
+// @Success 200 {object} entities.KeyRotation
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation [get]
+func (h *KeyRotationHandler) GetRotation(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	rotation, err := h.rotationService.GetRotation(c.Request.Context(), userID)
+	if err != nil {
+		respondKeyRotationError(c, "Failed to retrieve key rotation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+// GetPendingEntries lists entries still to be re-encrypted
+// @Summary List entries pending re-encryption
+// @Description Returns entries that are still encrypted with an older key version and have nothing staged from their current revision, trashed entries included. Each comes with its current secret, key version and revision. Stage the re-encrypted secrets and fetch again until the list is empty; an entry edited after it was staged shows up again.
+// @Tags key-rotation
+// @Produce json
+// @Param limit query int false "Entries to return (default and maximum 100)"
+// @Success 200 {array} entities.KeyRotationEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation/entries [get]
+func (h *KeyRotationHandler) GetPendingEntries(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondBadRequest(c, "Invalid limit", "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	entries, err := h.rotationService.ListPendingEntries(c.Request.Context(), userID, limit)
+	if err != nil {
+		respondKeyRotationError(c, "Failed to retrieve pending entries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// StageEntries stores a chunk of re-encrypted secrets
+// @Summary Stage re-encrypted entries
+// @Description Stores up to 100 secrets re-encrypted with the new key, each tied to the revision it was listed at. Staging an entry again replaces its earlier ciphertext. Entries that changed since that revision or are not pending are returned in rejected; fetch them again and re-encrypt their current secret. Nothing is visible to other devices until the rotation commits.
+// @Tags key-rotation
+// @Accept json
+// @Produce json
+// @Param entries body StageKeyRotationRequest true "Re-encrypted secrets"
+// @Success 200 {object} entities.KeyRotationStageResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation/entries [post]
+func (h *KeyRotationHandler) StageEntries(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req StageKeyRotationRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	entries := make([]*entities.KeyRotationEntry, 0, len(req.Entries))
+	for i, item := range req.Entries {
+		id, err := uuid.Parse(item.ID)
+		if err != nil {
+			respondBadRequest(c, "Invalid entry ID", fmt.Sprintf("entry %d: %v", i, err))
+			return
+		}
+		if _, err := entities.ParseSecretEnvelope(item.Secret); err != nil {
+			respondBadRequest(c, "Invalid secret", fmt.Sprintf("entry %d: %v", i, err))
+			return
+		}
+
+		entries = append(entries, &entities.KeyRotationEntry{
+			ID:       id,
+			Revision: item.Revision,
+			Secret:   item.Secret,
+		})
+	}
+
+	result, err := h.rotationService.StageEntries(c.Request.Context(), userID, entries)
+	if err != nil {
+		if errors.Is(err, entities.ErrOTPBatchTooLarge) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Too many entries", err.Error())
+			return
+		}
+		respondKeyRotationError(c, "Failed to stage entries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CommitRotation completes the rotation
+// @Summary Commit a key rotation
+// @Description Swaps every staged secret in and makes the new key version the active one, all in one transaction. Fails with 409 while any entry is still pending, leaving the vault untouched. Keys of older versions stay stored but inactive so older revisions can still be decrypted. Other devices receive a key.rotated event and should fetch the new wrapped key.
+// @Tags key-rotation
+// @Produce json
+// @Success 200 {object} entities.KeyRotation
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation/commit [post]
+func (h *KeyRotationHandler) CommitRotation(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	rotation, err := h.rotationService.CommitRotation(c.Request.Context(), userID)
+	if err != nil {
+		respondKeyRotationError(c, "Failed to commit key rotation", err)
+		return
+	}
+
+	// Tell the user's other devices to switch to the new key
+	if err := h.eventService.Publish(c.Request.Context(), userID, entities.VaultEventKeyRotated, nil); err != nil {
+		slog.Warn("Failed to publish key rotated event", "user_id", userID, "error", err)
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+// AbortRotation discards the rotation in progress
+// @Summary Abort a key rotation
+// @Description Discards the rotation in progress, its staged secrets and the new wrapped keys. The vault stays on its current key.
+// @Tags key-rotation
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/key-rotation [delete]
+func (h *KeyRotationHandler) AbortRotation(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	if err := h.rotationService.AbortRotation(c.Request.Context(), userID); err != nil {
+		respondKeyRotationError(c, "Failed to abort key rotation", err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, "Key rotation aborted")
+}
+
+// respondKeyRotationError maps key rotation service errors to HTTP responses
+func respondKeyRotationError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrKeyRotationNotFound):
+		respondNotFound(c, "No key rotation in progress", err.Error())
+	case errors.Is(err, entities.ErrKeyRotationInProgress):
+		respondWithError(c, http.StatusConflict, "A key rotation is already in progress", err.Error())
+	case errors.Is(err, entities.ErrKeyRotationIncomplete):
+		respondWithError(c, http.StatusConflict, "Entries are still pending re-encryption", err.Error())
+	case errors.Is(err, entities.ErrInvalidEncryptionKey), errors.Is(err, entities.ErrCredentialNotFound):
+		respondBadRequest(c, "Invalid wrapped key", err.Error())
+	case errors.Is(err, entities.ErrInvalidTOTPSeed):
+		respondBadRequest(c, "Invalid entry", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
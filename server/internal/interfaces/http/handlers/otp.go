@@ -1,63 +1,124 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // OTPHandler handles OTP/TOTP vault endpoints
 type OTPHandler struct {
-	otpService interfaces.OTPService
-	// totpService interfaces.TOTPService // TODO: Re-enable when TOTP service is implemented
+	otpService  interfaces.OTPService
+	totpService interfaces.TOTPService
+	config      *config.Config
 }
 
 // NewOTPHandler creates a new OTP handler
-func NewOTPHandler(otpService interfaces.OTPService) *OTPHandler {
+func NewOTPHandler(otpService interfaces.OTPService, totpService interfaces.TOTPService, cfg *config.Config) *OTPHandler {
 	return &OTPHandler{
-		otpService: otpService,
-		// totpService: totpService, // TODO: Re-enable when TOTP service is implemented
-	}
-}
-
-// setOTPDefaults sets default values for OTP request fields
-func setOTPDefaults(algorithm *string, digits *int, period *int) {
-	if *algorithm == "" {
-		*algorithm = "SHA1"
-	}
-	if *digits == 0 {
-		*digits = 6
-	}
-	if *period == 0 {
-		*period = 30
+		otpService:  otpService,
+		totpService: totpService,
+		config:      cfg,
 	}
 }
 
 // CreateOTPRequest represents the request body for creating a new OTP
+// When OTPAuthURL is set it must have its secret stripped client-side; its parameters fill
+// any field left empty in the request.
 type CreateOTPRequest struct {
-	Issuer     string `json:"issuer" binding:"required"`
-	Label      string `json:"label" binding:"required"`
-	Secret     string `json:"secret" binding:"required"` // Client-encrypted: "ciphertext.iv.authTag"
-	Period     int    `json:"period"`
-	Algorithm  string `json:"algorithm"`
-	Digits     int    `json:"digits"`
-	OTPAuthURL string `json:"otpauth_url"` // Alternative to individual fields (not implemented)
+	Issuer     string   `json:"issuer"`
+	Label      string   `json:"label"`
+	Secret     string   `json:"secret" binding:"required"` // Client-encrypted: "v1.suite.keyVersion.iv.authTag.ciphertext"
+	Period     int      `json:"period"`
+	Algorithm  string   `json:"algorithm"`
+	Digits     int      `json:"digits"`
+	Method     string   `json:"method"`  // "TOTP" (default) or "HOTP"
+	Counter    int64    `json:"counter"` // Initial HOTP counter
+	Type       string   `json:"type"`    // Code scheme: "standard" (default), "steam" or "yandex"
+	T0         int64    `json:"t0"`      // Unix time TOTP steps are counted from
+	Tags       []string `json:"tags"`
+	FolderID   *string  `json:"folder_id"`   // Omitted or empty for the top level
+	OTPAuthURL string   `json:"otpauth_url"` // otpauth:// URL without its secret parameter
+}
+
+// OTPBatchRequest represents the request body for an atomic batch of vault operations
+type OTPBatchRequest struct {
+	Operations []OTPBatchOperationRequest `json:"operations" binding:"required,min=1"`
+}
+
+// OTPBatchOperationRequest represents a single create, update or inactivate operation
+type OTPBatchOperationRequest struct {
+	Op        string   `json:"op"` // "create", "update" or "inactivate"
+	ID        string   `json:"id"` // Required for update and inactivate
+	Issuer    string   `json:"issuer"`
+	Label     string   `json:"label"`
+	Secret    string   `json:"secret"` // Client-encrypted: "v1.suite.keyVersion.iv.authTag.ciphertext"
+	Period    int      `json:"period"`
+	Algorithm string   `json:"algorithm"`
+	Digits    int      `json:"digits"`
+	Method    string   `json:"method"`
+	Counter   int64    `json:"counter"`
+	Type      string   `json:"type"`
+	T0        int64    `json:"t0"`
+	Tags      []string `json:"tags"`      // Omitted on update to keep the current tags
+	FolderID  *string  `json:"folder_id"` // Omitted on update to keep the current folder; empty for the top level
+
+	// Required for update and inactivate: the entry revision the change is based on, or "*" (or 0)
+	// to apply it whatever the current revision is
+	Revision json.RawMessage `json:"revision,omitempty" swaggertype:"string" example:"3"`
+}
+
+// OTPBatchResponse represents the per-operation results of a batch
+type OTPBatchResponse struct {
+	Error   string                     `json:"error,omitempty"`
+	Results []*entities.OTPBatchResult `json:"results"`
+}
+
+// AdvanceCounterResponse represents the counter reserved for an HOTP code
+type AdvanceCounterResponse struct {
+	ID          string `json:"id"`
+	Counter     int64  `json:"counter"`     // Counter value to generate the code with
+	NextCounter int64  `json:"nextCounter"` // Counter value now persisted for the next request
 }
 
 // UpdateOTPRequest represents the request body for updating an OTP
 type UpdateOTPRequest struct {
-	Issuer    string `json:"issuer" binding:"required"`
-	Label     string `json:"label" binding:"required"`
-	Secret    string `json:"secret" binding:"required"` // Client-encrypted: "ciphertext.iv.authTag"
-	Period    int    `json:"period"`
-	Algorithm string `json:"algorithm"`
-	Digits    int    `json:"digits"`
+	Issuer    string   `json:"issuer" binding:"required"`
+	Label     string   `json:"label" binding:"required"`
+	Secret    string   `json:"secret" binding:"required"` // Client-encrypted: "v1.suite.keyVersion.iv.authTag.ciphertext"
+	Period    int      `json:"period"`
+	Algorithm string   `json:"algorithm"`
+	Digits    int      `json:"digits"`
+	Type      string   `json:"type"`
+	T0        int64    `json:"t0"`
+	Tags      []string `json:"tags"`      // Omitted to keep the current tags
+	FolderID  *string  `json:"folder_id"` // Omitted to keep the current folder; empty for the top level
+}
+
+// RenameTagRequest represents the request body for renaming or merging a tag
+type RenameTagRequest struct {
+	From string `json:"from" binding:"required"`
+	To   string `json:"to" binding:"required"`
+}
+
+// RenameTagResponse reports how many entries a tag rename changed
+type RenameTagResponse struct {
+	Tag     string `json:"tag"`
+	Updated int64  `json:"updated"`
 }
 
 // CreateOTP creates a new OTP entry
 // @Summary Create a new encrypted TOTP entry
-// @Description Creates a new TOTP entry with client-side encrypted secret. The secret field should contain pre-encrypted data in format "ciphertext.iv.authTag". Server never sees plaintext TOTP secrets.
+// @Description Creates a new TOTP entry with client-side encrypted secret. The secret field must be a secret envelope, "v1.<suite>.<keyVersion>.<iv>.<authTag>.<ciphertext>", encrypted with the current vault key version; malformed envelopes are rejected with 400. Server never sees plaintext TOTP secrets. Issuer, label and parameters may instead be supplied as an otpauth:// URL with its secret parameter removed.
 // @Tags otp
 // @Accept json
 // @Produce json
@@ -65,6 +126,7 @@ type UpdateOTPRequest struct {
 // @Success 201 {object} entities.OTP
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/otp [post]
 func (h *OTPHandler) CreateOTP(c *gin.Context) {
@@ -80,36 +142,44 @@ func (h *OTPHandler) CreateOTP(c *gin.Context) {
 		return // Error already handled by bindJSONWithValidation
 	}
 
-	// Check for unimplemented OTP auth URL feature
+	// Merge parameters from the otpauth:// URL, if provided
 	if req.OTPAuthURL != "" {
-		respondBadRequest(c, "OTP auth URL parsing not implemented yet")
-		return
+		config, err := h.totpService.ParseOTPAuthParams(req.OTPAuthURL)
+		if err != nil {
+			respondBadRequest(c, "Invalid OTP auth URL", err.Error())
+			return
+		}
 
-		/*
-			config, err := h.totpService.ParseOTPAuthURL(req.OTPAuthURL)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP auth URL", "details": err.Error()})
-				return
-			}
+		applyOTPAuthConfig(&req, config)
+	}
 
-			// Override request fields with parsed values
-			req.Secret = config.Secret
-			req.Issuer = config.Issuer
-			if req.Label == "" {
-				req.Label = config.AccountName
-			}
-			req.Algorithm = config.Algorithm
-			req.Digits = config.Digits
-			req.Period = config.Period
-		*/
+	if req.Issuer == "" || req.Label == "" {
+		respondBadRequest(c, "Invalid request format", "issuer and label are required")
+		return
+	}
+
+	folderID, err := parseFolderRef(req.FolderID)
+	if err != nil {
+		respondBadRequest(c, "Invalid folder ID", err.Error())
+		return
 	}
 
-	// Set default values
-	setOTPDefaults(&req.Algorithm, &req.Digits, &req.Period)
+	if _, err := entities.ParseSecretEnvelope(req.Secret); err != nil {
+		respondBadRequest(c, "Invalid secret", err.Error())
+		return
+	}
 
-	// Create OTP through service
-	otp, err := h.otpService.CreateOTP(c.Request.Context(), userID, req.Issuer, req.Label, req.Secret, req.Period, req.Algorithm, req.Digits)
+	// Create OTP through service; the entry's scheme supplies defaults for omitted parameters
+	otp, err := h.otpService.CreateOTP(c.Request.Context(), userID, req.Issuer, req.Label, req.Secret, req.Period, req.Algorithm, req.Digits, req.Method, req.Counter, req.Type, req.T0, req.Tags, folderID)
 	if err != nil {
+		if errors.Is(err, entities.ErrInvalidTOTPSeed) {
+			respondBadRequest(c, "Invalid OTP entry", err.Error())
+			return
+		}
+		if errors.Is(err, entities.ErrFolderNotFound) {
+			respondNotFound(c, "Folder not found", err.Error())
+			return
+		}
 		respondInternalError(c, "Failed to create OTP", err.Error())
 		return
 	}
@@ -117,14 +187,192 @@ func (h *OTPHandler) CreateOTP(c *gin.Context) {
 	c.JSON(http.StatusCreated, otp)
 }
 
-// GetOTPs retrieves all OTPs for the authenticated user
-// @Summary List all TOTP entries
-// @Description Retrieves all TOTP entries for the authenticated user. Secrets are returned in encrypted format and must be decrypted client-side.
+// applyOTPAuthConfig fills empty request fields from a parsed otpauth:// URL
+func applyOTPAuthConfig(req *CreateOTPRequest, config *interfaces.TOTPConfig) {
+	if req.Issuer == "" {
+		req.Issuer = config.Issuer
+	}
+	if req.Label == "" {
+		req.Label = config.AccountName
+	}
+	if req.Algorithm == "" {
+		req.Algorithm = config.Algorithm
+	}
+	if req.Digits == 0 {
+		req.Digits = config.Digits
+	}
+	if req.Period == 0 {
+		req.Period = config.Period
+	}
+	if req.Method == "" {
+		req.Method = strings.ToUpper(config.Type)
+		req.Counter = int64(config.Counter)
+	}
+	if req.Type == "" {
+		req.Type = config.Scheme
+	}
+}
+
+// parseFolderRef parses an optional folder reference. Nil stays nil; an empty string is the
+// top level, reported as uuid.Nil.
+func parseFolderRef(ref *string) (*uuid.UUID, error) {
+	if ref == nil {
+		return nil, nil
+	}
+	if *ref == "" {
+		topLevel := uuid.Nil
+		return &topLevel, nil
+	}
+
+	id, err := uuid.Parse(*ref)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// parseBatchRevision parses the revision a batch operation is based on: a non-negative number,
+// or "*" for 0. A missing revision stays nil.
+func parseBatchRevision(raw json.RawMessage) (*int64, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var revision int64
+	if string(raw) != `"*"` {
+		parsed, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || parsed < 0 {
+			return nil, errors.New(`revision must be a non-negative integer or "*"`)
+		}
+		revision = parsed
+	}
+	return &revision, nil
+}
+
+// ApplyBatch applies mixed create/update/inactivate operations atomically
+// @Summary Apply a batch of vault operations
+// @Description Applies create, update and inactivate operations in a single transaction. Either every operation is applied or none is; the response reports the outcome of each operation by index.
+// @Tags otp
+// @Accept json
+// @Produce json
+// @Param batch body OTPBatchRequest true "Operations to apply"
+// @Success 200 {object} OTPBatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 412 {object} OTPBatchResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 422 {object} OTPBatchResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/batch [post]
+func (h *OTPHandler) ApplyBatch(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Bound the request body before decoding it
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.Vault.BatchMaxBodyBytes)
+
+	var req OTPBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Batch request too large", err.Error())
+			return
+		}
+		respondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	operations := make([]*entities.OTPBatchOperation, 0, len(req.Operations))
+	for i, item := range req.Operations {
+		var id uuid.UUID
+		if item.ID != "" {
+			parsed, err := uuid.Parse(item.ID)
+			if err != nil {
+				respondBadRequest(c, "Invalid operation ID", fmt.Sprintf("operation %d: %v", i, err))
+				return
+			}
+			id = parsed
+		}
+
+		folderID, err := parseFolderRef(item.FolderID)
+		if err != nil {
+			respondBadRequest(c, "Invalid folder ID", fmt.Sprintf("operation %d: %v", i, err))
+			return
+		}
+
+		revision, err := parseBatchRevision(item.Revision)
+		if err != nil {
+			respondBadRequest(c, "Invalid operation revision", fmt.Sprintf("operation %d: %v", i, err))
+			return
+		}
+
+		operations = append(operations, &entities.OTPBatchOperation{
+			Type:      strings.ToLower(item.Op),
+			ID:        id,
+			Revision:  revision,
+			Issuer:    item.Issuer,
+			Label:     item.Label,
+			Secret:    item.Secret,
+			Period:    item.Period,
+			Algorithm: item.Algorithm,
+			Digits:    item.Digits,
+			Method:    item.Method,
+			Counter:   item.Counter,
+			OTPType:   item.Type,
+			T0:        item.T0,
+			Tags:      item.Tags,
+			FolderID:  folderID,
+		})
+	}
+
+	results, err := h.otpService.ApplyOTPBatch(c.Request.Context(), userID, operations)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrOTPBatchTooLarge):
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Batch request too large", err.Error())
+		case errors.Is(err, entities.ErrOTPBatchFailed):
+			// A stale revision fails the batch like a conditional write fails a single update
+			status := http.StatusUnprocessableEntity
+			for _, result := range results {
+				if result.Status == entities.OTPBatchStatusPreconditionFailed {
+					status = http.StatusPreconditionFailed
+				}
+			}
+			c.JSON(status, OTPBatchResponse{
+				Error:   "Batch failed; no changes were applied",
+				Results: results,
+			})
+		default:
+			respondInternalError(c, "Failed to apply batch", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, OTPBatchResponse{Results: results})
+}
+
+// GetOTPs retrieves OTPs for the authenticated user
+// @Summary List TOTP entries
+// @Description Retrieves the authenticated user's TOTP entries, optionally filtered, searched, sorted and paginated. Without a limit every matching entry is returned. The total match count is returned in X-Total-Count and the cursor of the next page, if any, in X-Next-Cursor. Secrets are returned in encrypted format and must be decrypted client-side.
 // @Tags otp
 // @Accept json
 // @Produce json
+// @Param tag query string false "Only entries carrying this tag"
+// @Param folder query string false "Only entries in this folder or its subfolders"
+// @Param q query string false "Fuzzy search over issuer, label and tags"
+// @Param sort query string false "relevance, created, updated, issuer, label or most-used"
+// @Param order query string false "asc or desc; defaults to the sort key's natural order"
+// @Param limit query int false "Page size (at most 500)"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
 // @Success 200 {array} entities.OTP
+// @Header 200 {integer} X-Total-Count "Entries matching the filter"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page; absent on the last page"
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/otp [get]
 func (h *OTPHandler) GetOTPs(c *gin.Context) {
@@ -134,18 +382,66 @@ func (h *OTPHandler) GetOTPs(c *gin.Context) {
 		return // Error already handled by requireUserID
 	}
 
+	filter := entities.OTPFilter{
+		Tag:    c.Query("tag"),
+		Query:  c.Query("q"),
+		Sort:   c.Query("sort"),
+		Order:  strings.ToLower(c.Query("order")),
+		Cursor: c.Query("cursor"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			respondBadRequest(c, "Invalid limit", err.Error())
+			return
+		}
+		filter.Limit = parsed
+	}
+	if folder := c.Query("folder"); folder != "" {
+		folderID, err := uuid.Parse(folder)
+		if err != nil {
+			respondBadRequest(c, "Invalid folder ID", err.Error())
+			return
+		}
+		filter.FolderID = &folderID
+	}
+
 	// Retrieve OTPs through service
-	otps, err := h.otpService.ListOTPs(c.Request.Context(), userID)
+	page, err := h.otpService.ListOTPs(c.Request.Context(), userID, filter)
 	if err != nil {
-		respondInternalError(c, "Failed to retrieve OTPs", err.Error())
+		switch {
+		case errors.Is(err, entities.ErrInvalidOTPFilter), errors.Is(err, entities.ErrInvalidCursor):
+			respondBadRequest(c, "Invalid listing parameters", err.Error())
+		case errors.Is(err, entities.ErrFolderNotFound):
+			respondNotFound(c, "Folder not found", err.Error())
+		default:
+			respondInternalError(c, "Failed to retrieve OTPs", err.Error())
+		}
 		return
 	}
 
-	// Frontend expects a direct array, not wrapped in "otps"
-	c.JSON(http.StatusOK, otps)
+	// Frontend expects a direct array, not wrapped in "otps"; paging travels in headers
+	c.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Items)
 }
 
 // GetOTP retrieves a specific OTP by ID
+// @Summary Get a TOTP entry
+// @Description Returns a single entry. The ETag header carries the entry's revision; send it back in If-Match when updating or inactivating the entry. A matching If-None-Match returns 304.
+// @Tags otp
+// @Produce json
+// @Param id path string true "OTP ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} entities.OTP
+// @Header 200 {string} ETag "Entry revision"
+// @Success 304 "Not modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/otp/{id} [get]
 func (h *OTPHandler) GetOTP(c *gin.Context) {
 	// Get authenticated user ID
 	userID, ok := requireUserID(c)
@@ -166,6 +462,12 @@ func (h *OTPHandler) GetOTP(c *gin.Context) {
 		return
 	}
 
+	setETag(c, otp.Revision)
+	if c.GetHeader("If-None-Match") == formatETag(otp.Revision) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, otp)
 }
 
@@ -174,16 +476,20 @@ func (h *OTPHandler) GetOTP(c *gin.Context) {
 
 // UpdateOTP updates an existing OTP entry
 // @Summary Update an encrypted TOTP entry
-// @Description Updates an existing TOTP entry with client-side encrypted secret. The secret field should contain pre-encrypted data in format "ciphertext.iv.authTag".
+// @Description Updates an existing TOTP entry with client-side encrypted secret. The secret field must be a secret envelope, "v1.<suite>.<keyVersion>.<iv>.<authTag>.<ciphertext>", encrypted with the current vault key version; malformed envelopes are rejected with 400. If-Match must carry the ETag of the version being edited ("*" skips the check); if the entry has changed since, nothing is written and 412 is returned.
 // @Tags otp
 // @Accept json
 // @Produce json
 // @Param id path string true "OTP ID"
+// @Param If-Match header string true "ETag of the version being edited"
 // @Param otp body UpdateOTPRequest true "Updated OTP details with encrypted secret"
 // @Success 200 {object} entities.OTP
+// @Header 200 {string} ETag "New entry revision"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/otp/{id} [put]
 func (h *OTPHandler) UpdateOTP(c *gin.Context) {
@@ -199,34 +505,156 @@ func (h *OTPHandler) UpdateOTP(c *gin.Context) {
 		return // Error already handled by parseUUIDParam
 	}
 
+	expectedRevision, ok := requireIfMatch(c)
+	if !ok {
+		return // Error already handled by requireIfMatch
+	}
+
 	// Bind and validate JSON request
 	var req UpdateOTPRequest
 	if !bindJSONWithValidation(c, &req) {
 		return // Error already handled by bindJSONWithValidation
 	}
 
-	// Set default values
-	setOTPDefaults(&req.Algorithm, &req.Digits, &req.Period)
+	folderID, err := parseFolderRef(req.FolderID)
+	if err != nil {
+		respondBadRequest(c, "Invalid folder ID", err.Error())
+		return
+	}
+
+	if _, err := entities.ParseSecretEnvelope(req.Secret); err != nil {
+		respondBadRequest(c, "Invalid secret", err.Error())
+		return
+	}
 
-	// Update OTP through service
-	otp, err := h.otpService.UpdateOTP(c.Request.Context(), otpID, userID, req.Issuer, req.Label, req.Secret, req.Period, req.Algorithm, req.Digits)
+	// Update OTP through service; the entry's scheme supplies defaults for omitted parameters
+	otp, err := h.otpService.UpdateOTP(c.Request.Context(), otpID, userID, req.Issuer, req.Label, req.Secret, req.Period, req.Algorithm, req.Digits, req.Type, req.T0, req.Tags, folderID, expectedRevision)
 	if err != nil {
+		if errors.Is(err, entities.ErrInvalidTOTPSeed) {
+			respondBadRequest(c, "Invalid OTP entry", err.Error())
+			return
+		}
+		if errors.Is(err, entities.ErrTOTPSeedNotFound) {
+			respondNotFound(c, "OTP not found", err.Error())
+			return
+		}
+		if errors.Is(err, entities.ErrFolderNotFound) {
+			respondNotFound(c, "Folder not found", err.Error())
+			return
+		}
+		if errors.Is(err, entities.ErrRevisionMismatch) {
+			respondWithError(c, http.StatusPreconditionFailed, "OTP was modified by another request", err.Error())
+			return
+		}
 		respondInternalError(c, "Failed to update OTP", err.Error())
 		return
 	}
 
+	setETag(c, otp.Revision)
+
+	c.JSON(http.StatusOK, otp)
+}
+
+// GetRevisions lists an entry's past versions
+// @Summary List revisions of a TOTP entry
+// @Description Returns the versions an entry had before each change, newest first. Every revision keeps the encrypted secret and metadata as they were, plus the device and request that replaced them.
+// @Tags otp
+// @Produce json
+// @Param id path string true "OTP ID"
+// @Success 200 {array} entities.OTPRevision
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id}/revisions [get]
+func (h *OTPHandler) GetRevisions(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP ID from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	revisions, err := h.otpService.ListRevisions(c.Request.Context(), otpID, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrTOTPSeedNotFound) {
+			respondNotFound(c, "OTP not found", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to list revisions", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// RevertOTP restores an entry to one of its revisions
+// @Summary Revert a TOTP entry
+// @Description Restores the encrypted secret and metadata of an entry from one of its revisions. The current version is recorded as a new revision first, so a revert can itself be undone. HOTP counters are never rewound. Revisions encrypted with a vault key that has since been rotated out cannot be reverted to (409).
+// @Tags otp
+// @Produce json
+// @Param id path string true "OTP ID"
+// @Param revisionId path string true "Revision ID"
+// @Success 200 {object} entities.OTP
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id}/revisions/{revisionId}/revert [post]
+func (h *OTPHandler) RevertOTP(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP and revision IDs from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+	revisionID, ok := parseUUIDParam(c, "revisionId")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	otp, err := h.otpService.RevertOTP(c.Request.Context(), otpID, userID, revisionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTOTPSeedNotFound):
+			respondNotFound(c, "OTP not found", err.Error())
+		case errors.Is(err, entities.ErrRevisionNotFound):
+			respondNotFound(c, "Revision not found", err.Error())
+		case errors.Is(err, entities.ErrRevisionKeyRetired):
+			respondWithError(c, http.StatusConflict, "Revision is encrypted with a retired vault key", err.Error())
+		default:
+			respondInternalError(c, "Failed to revert OTP", err.Error())
+		}
+		return
+	}
+
+	setETag(c, otp.Revision)
 	c.JSON(http.StatusOK, otp)
 }
 
 // InactivateOTP soft deletes an OTP entry
 // @Summary Inactivate a TOTP entry
-// @Description Soft deletes a TOTP entry by marking it as inactive. The encrypted secret is permanently removed.
+// @Description Moves a TOTP entry to the trash. It can be restored until the retention period passes, after which it is permanently deleted. If-Match must carry the entry's ETag ("*" skips the check); 412 is returned if the entry has changed since.
 // @Tags otp
 // @Param id path string true "OTP ID"
+// @Param If-Match header string true "ETag of the version being inactivated"
 // @Success 200 {object} SuccessResponse
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 428 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/otp/{id}/inactivate [post]
 func (h *OTPHandler) InactivateOTP(c *gin.Context) {
@@ -242,12 +670,272 @@ func (h *OTPHandler) InactivateOTP(c *gin.Context) {
 		return // Error already handled by parseUUIDParam
 	}
 
+	expectedRevision, ok := requireIfMatch(c)
+	if !ok {
+		return // Error already handled by requireIfMatch
+	}
+
 	// Delete OTP through service
-	err := h.otpService.DeleteOTP(c.Request.Context(), otpID, userID)
+	err := h.otpService.DeleteOTP(c.Request.Context(), otpID, userID, expectedRevision)
 	if err != nil {
-		respondInternalError(c, "Failed to inactivate OTP", err.Error())
+		switch {
+		case errors.Is(err, entities.ErrTOTPSeedNotFound):
+			respondNotFound(c, "OTP not found", err.Error())
+		case errors.Is(err, entities.ErrRevisionMismatch):
+			respondWithError(c, http.StatusPreconditionFailed, "OTP was modified by another request", err.Error())
+		default:
+			respondInternalError(c, "Failed to inactivate OTP", err.Error())
+		}
 		return
 	}
 
 	respondWithSuccess(c, http.StatusOK, "OTP inactivated successfully")
 }
+
+// GetTrash lists the user's trashed entries
+// @Summary List trashed TOTP entries
+// @Description Returns inactivated entries, most recently deleted first. Each entry carries DeletedAt and, when a retention period is configured, PurgeAt: the time it will be permanently deleted.
+// @Tags otp
+// @Produce json
+// @Success 200 {array} entities.OTP
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/trash [get]
+func (h *OTPHandler) GetTrash(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	otps, err := h.otpService.ListTrash(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to list trash", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, otps)
+}
+
+// RestoreOTP moves a trashed entry back into the vault
+// @Summary Restore a TOTP entry
+// @Description Restores an inactivated entry from the trash.
+// @Tags otp
+// @Produce json
+// @Param id path string true "OTP ID"
+// @Success 200 {object} entities.OTP
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id}/restore [post]
+func (h *OTPHandler) RestoreOTP(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP ID from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	otp, err := h.otpService.RestoreOTP(c.Request.Context(), otpID, userID)
+	if err != nil {
+		if errors.Is(err, entities.ErrTOTPSeedNotFound) {
+			respondNotFound(c, "OTP not found in trash", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to restore OTP", err.Error())
+		return
+	}
+
+	setETag(c, otp.Revision)
+	c.JSON(http.StatusOK, otp)
+}
+
+// PurgeOTP permanently deletes a trashed entry
+// @Summary Permanently delete a TOTP entry
+// @Description Permanently deletes an entry from the trash. Only inactivated entries can be deleted; this cannot be undone.
+// @Tags otp
+// @Param id path string true "OTP ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id} [delete]
+func (h *OTPHandler) PurgeOTP(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP ID from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	if err := h.otpService.PurgeOTP(c.Request.Context(), otpID, userID); err != nil {
+		if errors.Is(err, entities.ErrTOTPSeedNotFound) {
+			respondNotFound(c, "OTP not found in trash", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to delete OTP", err.Error())
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, "OTP permanently deleted")
+}
+
+// RecordUse counts a use of an entry's code
+// @Summary Record a code use
+// @Description Records that a code of the entry was used (for example copied), for sorting by most used. Codes are generated client-side, so clients report uses; reserving an HOTP code counts automatically.
+// @Tags otp
+// @Param id path string true "OTP ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id}/use [post]
+func (h *OTPHandler) RecordUse(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP ID from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	if err := h.otpService.RecordUse(c.Request.Context(), otpID, userID); err != nil {
+		if errors.Is(err, entities.ErrTOTPSeedNotFound) {
+			respondNotFound(c, "OTP not found", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to record use", err.Error())
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, "Use recorded")
+}
+
+// AdvanceCounter reserves the next code of an HOTP entry
+// @Summary Advance an HOTP counter
+// @Description Atomically reserves the current counter of an HOTP entry and increments it, so that no two devices ever generate the same code. The client generates the code locally using the returned counter.
+// @Tags otp
+// @Produce json
+// @Param id path string true "OTP ID"
+// @Success 200 {object} AdvanceCounterResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/otp/{id}/counter [post]
+func (h *OTPHandler) AdvanceCounter(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Parse and validate OTP ID from URL
+	otpID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	counter, err := h.otpService.AdvanceCounter(c.Request.Context(), otpID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrTOTPSeedNotFound):
+			respondNotFound(c, "OTP not found", err.Error())
+		case errors.Is(err, entities.ErrNotHOTP):
+			respondBadRequest(c, "OTP entry is not counter-based", err.Error())
+		default:
+			respondInternalError(c, "Failed to advance counter", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, AdvanceCounterResponse{
+		ID:          otpID.String(),
+		Counter:     counter - 1,
+		NextCounter: counter,
+	})
+}
+
+// GetTags lists the user's tags
+// @Summary List tags
+// @Description Lists every tag used on the authenticated user's active entries with the number of entries carrying it.
+// @Tags otp
+// @Produce json
+// @Success 200 {array} entities.TagCount
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tags [get]
+func (h *OTPHandler) GetTags(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	tags, err := h.otpService.ListTags(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve tags", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// RenameTag renames a tag on every entry carrying it
+// @Summary Rename or merge a tag
+// @Description Renames a tag across all of the user's entries. Renaming to a tag that already exists merges the two.
+// @Tags otp
+// @Accept json
+// @Produce json
+// @Param rename body RenameTagRequest true "Current and new tag name"
+// @Success 200 {object} RenameTagResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/tags/rename [post]
+func (h *OTPHandler) RenameTag(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req RenameTagRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	updated, err := h.otpService.RenameTag(c.Request.Context(), userID, req.From, req.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrInvalidTag):
+			respondBadRequest(c, "Invalid tag", err.Error())
+		case errors.Is(err, entities.ErrTagNotFound):
+			respondNotFound(c, "Tag not found", err.Error())
+		default:
+			respondInternalError(c, "Failed to rename tag", err.Error())
+		}
+		return
+	}
+
+	tag, _ := entities.NormalizeTag(req.To)
+	c.JSON(http.StatusOK, RenameTagResponse{Tag: tag, Updated: updated})
+}
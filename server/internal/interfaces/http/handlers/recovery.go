@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RecoveryHandler handles the recovery kit endpoints
+type RecoveryHandler struct {
+	recoveryService interfaces.RecoveryService
+}
+
+// NewRecoveryHandler creates a new recovery handler
+func NewRecoveryHandler(recoveryService interfaces.RecoveryService) *RecoveryHandler {
+	return &RecoveryHandler{
+		recoveryService: recoveryService,
+	}
+}
+
+// RecoveryKitRequest represents a recovery kit made on the device
+type RecoveryKitRequest struct {
+	RecoveryCode    string `json:"recovery_code" binding:"required"`    // Derived from the recovery secret; never the secret itself
+	EncryptedBackup string `json:"encrypted_backup" binding:"required"` // Base64: the DEK wrapped with the key derived from the recovery secret
+}
+
+// RedeemRecoveryKitRequest represents a recovery code presented to unlock the vault
+type RedeemRecoveryKitRequest struct {
+	RecoveryCode string `json:"recovery_code" binding:"required"`
+}
+
+// ReregisterRequest completes a recovery with a newly registered passkey
+type ReregisterRequest struct {
+	RecoveryCode string             `json:"recovery_code" binding:"required"`
+	CredentialID string             `json:"credential_id" binding:"required"`
+	WrappedDEK   string             `json:"wrapped_dek" binding:"required"` // Base64
+	Salt         string             `json:"salt" binding:"required"`        // Base64
+	NewKit       RecoveryKitRequest `json:"new_kit" binding:"required"`     // Replaces the spent kit
+}
+
+// GetKit returns the user's recovery kit
+// @Summary Get the recovery kit
+// @Description Returns the user's usable recovery kit: the key version it wraps and, while redemption is locked after too many failed attempts, until when. The wrapped key itself is only returned by redeeming the kit. A kit made before a key rotation wraps a key the vault no longer uses and must be regenerated.
+// @Tags recovery
+// @Produce json
+// @Success 200 {object} entities.RecoveryKit
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/recovery [get]
+func (h *RecoveryHandler) GetKit(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	kit, err := h.recoveryService.GetKit(c.Request.Context(), userID)
+	if err != nil {
+		respondRecoveryError(c, "Failed to retrieve recovery kit", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, kit)
+}
+
+// CreateKit stores the user's first recovery kit
+// @Summary Generate a recovery kit
+// @Description Stores a recovery kit made on the device. The device generates a recovery secret for the user to keep offline, wraps the current DEK with a key derived from it and derives a separate recovery code from it; only the wrap and the code are sent. The server keeps an Argon2id hash of the code. Fails with 409 if the user already has a kit; regenerate it instead.
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param kit body RecoveryKitRequest true "Recovery code and wrapped DEK"
+// @Success 201 {object} entities.RecoveryKit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/recovery [post]
+func (h *RecoveryHandler) CreateKit(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req RecoveryKitRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	encryptedBackup, err := base64.StdEncoding.DecodeString(req.EncryptedBackup)
+	if err != nil {
+		respondBadRequest(c, "Invalid recovery kit", err.Error())
+		return
+	}
+
+	kit, err := h.recoveryService.CreateKit(c.Request.Context(), userID, req.RecoveryCode, encryptedBackup)
+	if err != nil {
+		respondRecoveryError(c, "Failed to create recovery kit", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, kit)
+}
+
+// RegenerateKit replaces the user's recovery kit
+// @Summary Regenerate the recovery kit
+// @Description Replaces the user's recovery kit with a new one made the same way. The old recovery code stops working immediately. Regenerate after a key rotation, since the old kit wraps a key the vault no longer uses.
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param kit body RecoveryKitRequest true "Recovery code and wrapped DEK"
+// @Success 201 {object} entities.RecoveryKit
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/recovery/regenerate [post]
+func (h *RecoveryHandler) RegenerateKit(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req RecoveryKitRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	encryptedBackup, err := base64.StdEncoding.DecodeString(req.EncryptedBackup)
+	if err != nil {
+		respondBadRequest(c, "Invalid recovery kit", err.Error())
+		return
+	}
+
+	kit, err := h.recoveryService.RegenerateKit(c.Request.Context(), userID, req.RecoveryCode, encryptedBackup)
+	if err != nil {
+		respondRecoveryError(c, "Failed to regenerate recovery kit", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, kit)
+}
+
+// RedeemKit checks a recovery code and returns the wrapped key
+// @Summary Redeem the recovery kit
+// @Description Checks a recovery code and returns the DEK wrapped with the key derived from the recovery secret. The device unwraps it, registers a new passkey and completes the recovery with the reregister endpoint; the kit stays usable until then. Failed attempts are counted per kit, and too many in a row lock it for a while with 429.
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param code body RedeemRecoveryKitRequest true "Recovery code"
+// @Success 200 {object} entities.RecoveryRedemption
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/recovery/redeem [post]
+func (h *RecoveryHandler) RedeemKit(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req RedeemRecoveryKitRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	redemption, err := h.recoveryService.RedeemKit(c.Request.Context(), userID, req.RecoveryCode)
+	if err != nil {
+		respondRecoveryError(c, "Failed to redeem recovery kit", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, redemption)
+}
+
+// Reregister completes a recovery with a new passkey
+// @Summary Re-register a passkey after recovery
+// @Description Completes a recovery. Register a new passkey first, then send the recovery code again with the new passkey's wrap of the DEK unlocked by the kit and a new kit to replace it. The wrap is stored for the current key version, the old kit is spent and the new one takes its place, all in one transaction. Lost passkeys can then be deleted.
+// @Tags recovery
+// @Accept json
+// @Produce json
+// @Param reregistration body ReregisterRequest true "Recovery code, new passkey's wrapped DEK and replacement kit"
+// @Success 201 {object} entities.RecoveryReregistration
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/recovery/reregister [post]
+func (h *RecoveryHandler) Reregister(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req ReregisterRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	credentialID, err := uuid.Parse(req.CredentialID)
+	if err != nil {
+		respondBadRequest(c, "Invalid credential ID", err.Error())
+		return
+	}
+	wrappedDEK, err := base64.StdEncoding.DecodeString(req.WrappedDEK)
+	if err != nil {
+		respondBadRequest(c, "Invalid wrapped key", err.Error())
+		return
+	}
+	salt, err := base64.StdEncoding.DecodeString(req.Salt)
+	if err != nil {
+		respondBadRequest(c, "Invalid salt", err.Error())
+		return
+	}
+	encryptedBackup, err := base64.StdEncoding.DecodeString(req.NewKit.EncryptedBackup)
+	if err != nil {
+		respondBadRequest(c, "Invalid recovery kit", err.Error())
+		return
+	}
+
+	key := entities.NewUserEncryptionKey(userID, credentialID, 0, wrappedDEK, salt)
+	result, err := h.recoveryService.Reregister(c.Request.Context(), userID, req.RecoveryCode, key, req.NewKit.RecoveryCode, encryptedBackup)
+	if err != nil {
+		respondRecoveryError(c, "Failed to complete recovery", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// respondRecoveryError maps recovery service errors to HTTP responses
+func respondRecoveryError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidBackup):
+		respondBadRequest(c, "Invalid recovery kit", err.Error())
+	case errors.Is(err, entities.ErrInvalidEncryptionKey), errors.Is(err, entities.ErrCredentialNotFound):
+		respondBadRequest(c, "Invalid wrapped key", err.Error())
+	case errors.Is(err, entities.ErrBackupNotFound):
+		respondNotFound(c, "No recovery kit", err.Error())
+	case errors.Is(err, entities.ErrRecoveryFailed):
+		respondWithError(c, http.StatusForbidden, "Invalid recovery code", err.Error())
+	case errors.Is(err, entities.ErrRecoveryLocked):
+		respondWithError(c, http.StatusTooManyRequests, "Too many failed recovery attempts", err.Error())
+	case errors.Is(err, entities.ErrRecoveryKitExists):
+		respondWithError(c, http.StatusConflict, "A recovery kit already exists", err.Error())
+	case errors.Is(err, entities.ErrKeyExists):
+		respondWithError(c, http.StatusConflict, "Passkey already holds a wrapped key", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
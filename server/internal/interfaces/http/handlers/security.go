@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SecurityHandler handles the user's security activity endpoints
+type SecurityHandler struct {
+	auditService interfaces.AuditService
+}
+
+// NewSecurityHandler creates a new security handler
+func NewSecurityHandler(auditService interfaces.AuditService) *SecurityHandler {
+	return &SecurityHandler{
+		auditService: auditService,
+	}
+}
+
+// GetActivity lists the user's security activity
+// @Summary List security activity
+// @Description Returns the user's audit log, newest first: sign-ins, failed authentications, token refreshes, passkey changes, vault entry changes, revoked sessions and admin actions on the account, each with the IP address, user agent and request ID it came from. The cursor of the next page, if any, is returned in X-Next-Cursor.
+// @Tags security
+// @Produce json
+// @Param action query string false "An action such as passkey.deleted, or a category: auth, passkey, entry, account or session"
+// @Param since query string false "RFC 3339 time; only entries at or after it"
+// @Param until query string false "RFC 3339 time; only entries before it"
+// @Param limit query int false "Page size (default 50, at most 200)"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Success 200 {array} entities.AuditLog
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page; absent on the last page"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/security/activity [get]
+func (h *SecurityHandler) GetActivity(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	filter := entities.AuditLogFilter{
+		Action: c.Query("action"),
+		Cursor: c.Query("cursor"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			respondBadRequest(c, "Invalid limit", err.Error())
+			return
+		}
+		filter.Limit = parsed
+	}
+	if filter.Since, ok = parseTimeQuery(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = parseTimeQuery(c, "until"); !ok {
+		return
+	}
+
+	page, err := h.auditService.ListActivity(c.Request.Context(), userID, filter)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidAuditFilter) || errors.Is(err, entities.ErrInvalidCursor) {
+			respondBadRequest(c, "Invalid listing parameters", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to list security activity", err.Error())
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter and handles errors
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		respondBadRequest(c, fmt.Sprintf("Invalid %s", name), err.Error())
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// recordAudit logs an action in the audit log. Failing to record it is logged and does not fail
+// the request.
+func recordAudit(c *gin.Context, auditService interfaces.AuditService, userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, metadata map[string]string) {
+	if err := auditService.Record(c.Request.Context(), userID, action, resourceType, resourceID, metadata); err != nil {
+		slog.Warn("Failed to record audit log", "action", action, "user_id", userID, "error", err)
+	}
+}
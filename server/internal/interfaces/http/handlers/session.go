@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionHandler handles the user's sign-in session endpoints
+type SessionHandler struct {
+	sessionService interfaces.SessionService
+	eventService   interfaces.VaultEventService
+	auditService   interfaces.AuditService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService interfaces.SessionService, eventService interfaces.VaultEventService, auditService interfaces.AuditService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		eventService:   eventService,
+		auditService:   auditService,
+	}
+}
+
+// RenameSessionRequest represents the request to rename a session
+type RenameSessionRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ListSessions lists the user's signed-in devices
+// @Summary List sessions
+// @Description Returns the user's live sessions, most recently active first, with the device, IP address and user agent each was last used from. The session making the request has current set.
+// @Tags sessions
+// @Produce json
+// @Success 200 {array} entities.DeviceSession
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, sessionID, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to list sessions", err.Error())
+		return
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == sessionID
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// Heartbeat keeps the current session alive
+// @Summary Session heartbeat
+// @Description Records activity on the current session, which every authenticated request also does, and returns it. Clients that sit idle but should stay signed in call this periodically; idleExpiresAt says when the session would otherwise time out.
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} entities.DeviceSession
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sessions/heartbeat [post]
+func (h *SessionHandler) Heartbeat(c *gin.Context) {
+	userID, sessionID, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	// The auth middleware already recorded the activity
+	session, err := h.sessionService.GetSession(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		respondSessionError(c, "Failed to get session", err)
+		return
+	}
+	session.Current = true
+
+	c.JSON(http.StatusOK, session)
+}
+
+// RenameSession renames one of the user's sessions
+// @Summary Rename a session
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path string true "Session ID"
+// @Param request body RenameSessionRequest true "New device name"
+// @Success 200 {object} entities.DeviceSession
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sessions/{id} [put]
+func (h *SessionHandler) RenameSession(c *gin.Context) {
+	userID, currentID, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	var req RenameSessionRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	session, err := h.sessionService.RenameSession(c.Request.Context(), userID, sessionID, req.Name)
+	if err != nil {
+		respondSessionError(c, "Failed to rename session", err)
+		return
+	}
+	session.Current = session.ID == currentID
+
+	c.JSON(http.StatusOK, session)
+}
+
+// RevokeSession signs one of the user's devices out
+// @Summary Revoke a session
+// @Description Ends the session, so its token stops working, and sends the user's devices a session.revoked event naming it. Revoking the current session signs this device out.
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} SuccessResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, _, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	if err := revokeSession(c, h.sessionService, h.eventService, h.auditService, userID, sessionID); err != nil {
+		respondSessionError(c, "Failed to revoke session", err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, "Session revoked")
+}
+
+// RevokeOtherSessions signs every other device out
+// @Summary Revoke all other sessions
+// @Description Ends every session except the current one and sends a session.revoked event for each. The IDs of the revoked sessions are returned in data.
+// @Tags sessions
+// @Produce json
+// @Success 200 {object} SuccessResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sessions/revoke-others [post]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, sessionID, ok := requireSession(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.sessionService.DeleteOtherSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		respondInternalError(c, "Failed to revoke sessions", err.Error())
+		return
+	}
+	for _, id := range revoked {
+		sessionRevoked(c, h.eventService, h.auditService, userID, id)
+	}
+
+	respondWithSuccess(c, http.StatusOK, "Other sessions revoked", revoked)
+}
+
+// requireSession gets the user and session IDs of the request's token and handles errors
+func requireSession(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	sessionID, err := getSessionIDFromContext(c)
+	if err != nil {
+		respondUnauthorized(c, "Session not authenticated")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userID, sessionID, true
+}
+
+// revokeSession ends one of the user's sessions and announces it
+func revokeSession(c *gin.Context, sessionService interfaces.SessionService, eventService interfaces.VaultEventService, auditService interfaces.AuditService, userID, sessionID uuid.UUID) error {
+	if err := sessionService.DeleteSession(c.Request.Context(), userID, sessionID); err != nil {
+		return err
+	}
+
+	sessionRevoked(c, eventService, auditService, userID, sessionID)
+	return nil
+}
+
+// sessionRevoked records a revoked session in the user's audit log and tells their devices, so
+// the revoked one can sign out straight away. Both are best-effort.
+func sessionRevoked(c *gin.Context, eventService interfaces.VaultEventService, auditService interfaces.AuditService, userID, sessionID uuid.UUID) {
+	recordAudit(c, auditService, &userID, entities.AuditActionSessionRevoked, entities.AuditResourceSession, &sessionID, nil)
+	if err := eventService.Publish(c.Request.Context(), userID, entities.VaultEventSessionRevoked, &sessionID); err != nil {
+		slog.Warn("Failed to publish session revoked event", "user_id", userID, "session_id", sessionID, "error", err)
+	}
+}
+
+// respondSessionError maps session service errors to responses
+func respondSessionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidSessionName):
+		respondBadRequest(c, "Invalid session name", err.Error())
+	case errors.Is(err, entities.ErrSessionNotFound), errors.Is(err, entities.ErrSessionExpired):
+		respondNotFound(c, "Session not found", err.Error())
+	default:
+		respondInternalError(c, message, err.Error())
+	}
+}
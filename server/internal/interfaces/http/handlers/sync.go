@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SyncHandler handles the delta sync endpoints
+type SyncHandler struct {
+	syncService interfaces.SyncService
+	config      *config.Config
+}
+
+// NewSyncHandler creates a new sync handler
+func NewSyncHandler(syncService interfaces.SyncService, cfg *config.Config) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+		config:      cfg,
+	}
+}
+
+// SyncRequest represents the request body for a delta sync
+type SyncRequest struct {
+	DeviceFingerprint string `json:"device_fingerprint"` // Defaults to the X-Device-ID header
+	DeviceName        string `json:"device_name"`        // Omitted to keep the current name
+	Cursor            string `json:"cursor"`             // From the previous sync; omitted for a full sync
+}
+
+// PushRequest represents the request body for pushing offline edits
+type PushRequest struct {
+	Changes []SyncChangeRequest `json:"changes" binding:"required,min=1"`
+}
+
+// SyncChangeRequest represents one offline edit and the revision it was made against
+type SyncChangeRequest struct {
+	Op           string                `json:"op"` // "update" or "delete"
+	ID           string                `json:"id"`
+	BaseRevision int64                 `json:"base_revision"` // The entry's Revision when the device last saw it
+	Entry        *SyncEntryVersionBody `json:"entry"`         // Required for update
+}
+
+// SyncEntryVersionBody is the full content of an entry: omitted tags and folder clear them
+type SyncEntryVersionBody struct {
+	Issuer     string   `json:"issuer"`
+	Label      string   `json:"label"`
+	Secret     string   `json:"secret"`      // Client-encrypted: "v1.suite.keyVersion.iv.authTag.ciphertext"
+	KeyVersion int      `json:"key_version"` // Optional; must match the key version in Secret
+	Period     int      `json:"period"`
+	Algorithm  string   `json:"algorithm"`
+	Digits     int      `json:"digits"`
+	Type       string   `json:"type"`
+	T0         int64    `json:"t0"`
+	Tags       []string `json:"tags"`
+	FolderID   *string  `json:"folder_id"`
+}
+
+// PushResponse represents the per-change results of a push
+type PushResponse struct {
+	Results []*entities.SyncChangeResult `json:"results"`
+}
+
+// ResolveConflictRequest represents the request body for resolving a conflict
+type ResolveConflictRequest struct {
+	Resolution string                `json:"resolution" binding:"required"` // "server", "client" or "merged"
+	Revision   int64                 `json:"revision"`                      // Entry revision the choice was made against; defaults to the conflict's server revision
+	Entry      *SyncEntryVersionBody `json:"entry"`                         // Required for merged
+}
+
+// ResolveConflictResponse represents a resolved conflict and the entry it left behind
+type ResolveConflictResponse struct {
+	Conflict *entities.SyncConflict `json:"conflict"`
+	OTP      *entities.OTP          `json:"otp,omitempty"` // Absent when the entry ended up in the trash
+}
+
+// toVersion converts the request body to an entry version
+func (b *SyncEntryVersionBody) toVersion() (*entities.OTPVersion, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	folderID, err := parseFolderRef(b.FolderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.OTPVersion{
+		Issuer:     b.Issuer,
+		Label:      b.Label,
+		Secret:     b.Secret,
+		KeyVersion: b.KeyVersion,
+		Period:     b.Period,
+		Algorithm:  b.Algorithm,
+		Digits:     b.Digits,
+		Type:       b.Type,
+		T0:         b.T0,
+		Tags:       b.Tags,
+		FolderID:   folderID,
+	}, nil
+}
+
+// Sync returns the vault changes since the device's last sync
+// @Summary Sync vault changes
+// @Description Returns the entries created, updated and deleted since cursor, each at most once and in its current state, plus the cursor to send next time. Without a cursor the whole vault is returned as created. Deleted entries are tombstones; purged is set once an entry is gone for good. Send the same fingerprint as X-Device-ID on writes so changes are attributed to the device.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param sync body SyncRequest true "Device and cursor"
+// @Success 200 {object} entities.SyncChanges
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync [post]
+func (h *SyncHandler) Sync(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	var req SyncRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	fingerprint := req.DeviceFingerprint
+	if fingerprint == "" {
+		fingerprint = entities.RequestInfoFromContext(c.Request.Context()).DeviceID
+	}
+
+	changes, err := h.syncService.Sync(c.Request.Context(), userID, fingerprint, req.DeviceName, req.Cursor)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrInvalidDevice):
+			respondBadRequest(c, "Invalid device", err.Error())
+		case errors.Is(err, entities.ErrInvalidCursor):
+			respondBadRequest(c, "Invalid cursor", "sync again without a cursor to fetch the whole vault")
+		default:
+			respondInternalError(c, "Failed to sync", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// GetDevices lists the devices that sync the vault
+// @Summary List syncing devices
+// @Description Lists the devices that have synced the authenticated user's vault, most recently synced first.
+// @Tags sync
+// @Produce json
+// @Success 200 {array} entities.SyncDevice
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/devices [get]
+func (h *SyncHandler) GetDevices(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	devices, err := h.syncService.ListDevices(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve devices", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// Push applies edits made offline
+// @Summary Push offline edits
+// @Description Applies edits a device made while offline. Each change carries the revision of the entry it was made against. A change whose entry is still at that revision is applied; one whose entry has changed since is stored as a conflict together with the server version and nothing is overwritten. Changes are applied independently and each gets a result: applied, conflict or failed. Secrets stay encrypted; the server never reads them.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param changes body PushRequest true "Changes with their base revisions"
+// @Success 200 {object} PushResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/push [post]
+func (h *SyncHandler) Push(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Bound the request body before decoding it
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.Vault.BatchMaxBodyBytes)
+
+	var req PushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Push request too large", err.Error())
+			return
+		}
+		respondBadRequest(c, "Invalid request format", err.Error())
+		return
+	}
+
+	changes := make([]*entities.SyncChange, 0, len(req.Changes))
+	for i, item := range req.Changes {
+		id, err := uuid.Parse(item.ID)
+		if err != nil {
+			respondBadRequest(c, "Invalid change ID", fmt.Sprintf("change %d: %v", i, err))
+			return
+		}
+
+		version, err := item.Entry.toVersion()
+		if err != nil {
+			respondBadRequest(c, "Invalid folder ID", fmt.Sprintf("change %d: %v", i, err))
+			return
+		}
+
+		changes = append(changes, &entities.SyncChange{
+			Type:         strings.ToLower(item.Op),
+			ID:           id,
+			BaseRevision: item.BaseRevision,
+			Version:      version,
+		})
+	}
+
+	results, err := h.syncService.PushChanges(c.Request.Context(), userID, changes)
+	if err != nil {
+		if errors.Is(err, entities.ErrOTPBatchTooLarge) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Push request too large", err.Error())
+			return
+		}
+		respondInternalError(c, "Failed to push changes", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, PushResponse{Results: results})
+}
+
+// GetConflicts lists unresolved conflicts
+// @Summary List sync conflicts
+// @Description Lists the authenticated user's unresolved conflicts, oldest first. Each holds the server version of the entry when the conflicting change arrived and the version the device pushed, both with encrypted secrets.
+// @Tags sync
+// @Produce json
+// @Success 200 {array} entities.SyncConflict
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/conflicts [get]
+func (h *SyncHandler) GetConflicts(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	conflicts, err := h.syncService.ListConflicts(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve conflicts", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
+
+// ResolveConflict settles a conflict
+// @Summary Resolve a sync conflict
+// @Description Settles a conflict by keeping the server version, applying the version the device pushed, or applying a version merged on the device from both. Merging happens client-side: the device decrypts both versions and sends the merged entry re-encrypted, so the server never reads plaintext. A resolution decided against an entry that has changed since is rejected with 412.
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param id path string true "Conflict ID"
+// @Param resolution body ResolveConflictRequest true "Chosen or merged version"
+// @Success 200 {object} ResolveConflictResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sync/conflicts/{id}/resolve [post]
+func (h *SyncHandler) ResolveConflict(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	conflictID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return // Error already handled by parseUUIDParam
+	}
+
+	var req ResolveConflictRequest
+	if !bindJSONWithValidation(c, &req) {
+		return // Error already handled by bindJSONWithValidation
+	}
+
+	version, err := req.Entry.toVersion()
+	if err != nil {
+		respondBadRequest(c, "Invalid folder ID", err.Error())
+		return
+	}
+
+	conflict, otp, err := h.syncService.ResolveConflict(c.Request.Context(), userID, conflictID, &entities.SyncResolution{
+		Choice:           strings.ToLower(req.Resolution),
+		Version:          version,
+		ExpectedRevision: req.Revision,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrInvalidOperation), errors.Is(err, entities.ErrInvalidTOTPSeed):
+			respondBadRequest(c, "Invalid resolution", err.Error())
+		case errors.Is(err, entities.ErrConflictNotFound):
+			respondNotFound(c, "Conflict not found", err.Error())
+		case errors.Is(err, entities.ErrConflictResolved):
+			respondWithError(c, http.StatusConflict, "Conflict already resolved", err.Error())
+		case errors.Is(err, entities.ErrRevisionMismatch):
+			respondWithError(c, http.StatusPreconditionFailed, "OTP was modified after the resolution was decided", err.Error())
+		default:
+			respondInternalError(c, "Failed to resolve conflict", err.Error())
+		}
+		return
+	}
+
+	if otp != nil {
+		setETag(c, otp.Revision)
+	}
+
+	c.JSON(http.StatusOK, ResolveConflictResponse{Conflict: conflict, OTP: otp})
+}
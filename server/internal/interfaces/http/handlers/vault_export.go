@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/gin-gonic/gin"
+)
+
+// VaultExportHandler handles vault export and import
+type VaultExportHandler struct {
+	exportService interfaces.VaultExportService
+	config        *config.Config
+}
+
+// NewVaultExportHandler creates a new vault export handler
+func NewVaultExportHandler(exportService interfaces.VaultExportService, cfg *config.Config) *VaultExportHandler {
+	return &VaultExportHandler{
+		exportService: exportService,
+		config:        cfg,
+	}
+}
+
+// Export streams the user's vault as a .2fair file
+// @Summary Export the vault
+// @Description Streams the vault as a versioned .2fair file: every active entry with its encrypted secret and metadata, the folders, and the current vault key wrapped for each passkey. A manifest after the content carries its SHA-256 hash, so a truncated or altered file fails to import. The server decrypts nothing; the file is useless without one of the user's passkeys. Fails with 409 before anything is sent if an entry's secret could not be migrated to the secret envelope format.
+// @Tags vault
+// @Produce json
+// @Success 200 {file} file ".2fair export"
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/export [get]
+func (h *VaultExportHandler) Export(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	filename := fmt.Sprintf("2fair-vault-%s%s", time.Now().UTC().Format("2006-01-02"), entities.VaultExportExtension)
+	c.Header("Content-Type", "application/json")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	err := h.exportService.Export(c.Request.Context(), userID, c.Writer)
+	if err == nil {
+		return
+	}
+
+	// Once the file has started, the missing manifest marks it as incomplete
+	if c.Writer.Written() {
+		slog.Warn("Vault export interrupted", "user_id", userID, "error", err)
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Disposition")
+	c.Writer.Header().Del("Cache-Control")
+	if errors.Is(err, entities.ErrInvalidTOTPSeed) {
+		respondWithError(c, http.StatusConflict, "An entry cannot be exported; save it again first", err.Error())
+		return
+	}
+	respondInternalError(c, "Failed to export vault", err.Error())
+}
+
+// Import restores a .2fair file into the user's vault
+// @Summary Import a vault export
+// @Description Restores a .2fair file sent as the request body. The content hash and every entry are verified before anything is written, and the whole import is one transaction. mode=merge (the default) adds the file's entries the vault does not have and keeps everything else; mode=replace overwrites entries the vault already has, restoring trashed ones, and moves the vault's other entries to the trash. Folders are matched by name under the same parent and created when missing. The file must be encrypted with the vault's current key version; a vault without keys takes the file's, provided one of the user's passkeys holds a wrap in it. Wraps in the file for passkeys that hold none yet are restored.
+// @Tags vault
+// @Accept json
+// @Produce json
+// @Param mode query string false "merge or replace" Enums(merge, replace)
+// @Param file body object true ".2fair export file"
+// @Success 200 {object} entities.VaultImportResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/vault/import [post]
+func (h *VaultExportHandler) Import(c *gin.Context) {
+	// Get authenticated user ID
+	userID, ok := requireUserID(c)
+	if !ok {
+		return // Error already handled by requireUserID
+	}
+
+	// Bound the request body before decoding it
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.config.Vault.ImportMaxBodyBytes)
+
+	export, err := entities.ReadVaultExport(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, "Export file too large", err.Error())
+			return
+		}
+		respondBadRequest(c, "Invalid export file", err.Error())
+		return
+	}
+
+	result, err := h.exportService.Import(c.Request.Context(), userID, export, c.Query("mode"))
+	if err != nil {
+		respondVaultImportError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondVaultImportError maps vault import errors to HTTP responses
+func respondVaultImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, entities.ErrInvalidVaultExport):
+		respondBadRequest(c, "Invalid export file", err.Error())
+	case errors.Is(err, entities.ErrInvalidTOTPSeed):
+		respondBadRequest(c, "Invalid entry", err.Error())
+	case errors.Is(err, entities.ErrVaultKeyMismatch):
+		respondWithError(c, http.StatusConflict, "The export cannot be unlocked with this vault's key", err.Error())
+	case errors.Is(err, entities.ErrKeyRotationInProgress):
+		respondWithError(c, http.StatusConflict, "Finish or abort the key rotation first", err.Error())
+	case errors.Is(err, entities.ErrFolderExists), errors.Is(err, entities.ErrKeyExists):
+		respondWithError(c, http.StatusConflict, "The vault changed during the import; try again", err.Error())
+	default:
+		respondInternalError(c, "Failed to import vault", err.Error())
+	}
+}
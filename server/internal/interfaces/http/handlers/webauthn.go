@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/base64"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -18,13 +19,15 @@ import (
 type WebAuthnHandler struct {
 	webAuthnService interfaces.WebAuthnService
 	userRepo        interfaces.AuthService // Use auth service to get user info
+	auditService    interfaces.AuditService
 }
 
 // NewWebAuthnHandler creates a new WebAuthn handler
-func NewWebAuthnHandler(webAuthnService interfaces.WebAuthnService, authService interfaces.AuthService) *WebAuthnHandler {
+func NewWebAuthnHandler(webAuthnService interfaces.WebAuthnService, authService interfaces.AuthService, auditService interfaces.AuditService) *WebAuthnHandler {
 	return &WebAuthnHandler{
 		webAuthnService: webAuthnService,
 		userRepo:        authService,
+		auditService:    auditService,
 	}
 }
 
@@ -141,6 +144,7 @@ func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
 
 	// Clean up session data
 	delete(sessionStore, sessionKey)
+	recordAudit(c, h.auditService, &userID, entities.AuditActionPasskeyRegistered, entities.AuditResourceCredential, &credential.ID, nil)
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -258,12 +262,14 @@ func (h *WebAuthnHandler) FinishAssertion(c *gin.Context) {
 	// Finish assertion using the HTTP request directly
 	credential, prfOutput, err := h.webAuthnService.FinishAssertion(c.Request.Context(), user, sessionData, c.Request)
 	if err != nil {
+		recordAudit(c, h.auditService, &userID, entities.AuditActionAuthFailed, entities.AuditResourceUser, &userID, map[string]string{"method": "passkey"})
 		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to complete assertion", "details": err.Error()})
 		return
 	}
 
 	// Clean up session data
 	delete(sessionStore, sessionKey)
+	recordAudit(c, h.auditService, &userID, entities.AuditActionPasskeyVerified, entities.AuditResourceCredential, &credential.ID, nil)
 
 	response := gin.H{
 		"success": true,
@@ -333,15 +339,17 @@ func (h *WebAuthnHandler) GetCredentials(c *gin.Context) {
 
 // DeleteCredential deletes a WebAuthn credential
 // @Summary Delete WebAuthn credential
-// @Description Deletes a specific WebAuthn credential for the current user
+// @Description Deletes a specific WebAuthn credential for the current user. The last credential holding a wrap of the vault key cannot be deleted: add a wrap for another passkey first.
 // @Tags webauthn
 // @Security BearerAuth
-// @Param credentialId path string true "Credential ID"
+// @Param id path string true "Credential ID as returned by the credential list"
 // @Success 200 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
-// @Router /v1/webauthn/credentials/{credentialId} [delete]
+// @Router /v1/webauthn/credentials/{id} [delete]
 func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
 	// Get current user from JWT claims
 	claims, exists := middleware.GetCurrentUser(c)
@@ -350,19 +358,30 @@ func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
 		return
 	}
 
-	credentialID := c.Param("credentialId")
-	if credentialID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "credential ID is required"})
+	credentialID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid credential ID"})
 		return
 	}
 
 	// Delete credential
-	err := h.webAuthnService.DeleteCredential(c.Request.Context(), claims.UserID, []byte(credentialID))
+	err = h.webAuthnService.DeleteCredential(c.Request.Context(), claims.UserID, credentialID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete credential"})
+		switch {
+		case errors.Is(err, entities.ErrLastKeyCredential):
+			c.JSON(http.StatusConflict, gin.H{"error": "credential holds the last wrapped vault key; add a wrap for another passkey first"})
+		case errors.Is(err, entities.ErrCredentialNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "credential not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete credential"})
+		}
 		return
 	}
 
+	if userID, err := uuid.Parse(claims.UserID); err == nil {
+		recordAudit(c, h.auditService, &userID, entities.AuditActionPasskeyDeleted, entities.AuditResourceCredential, &credentialID, nil)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "WebAuthn credential deleted successfully",
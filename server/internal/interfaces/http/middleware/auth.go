@@ -1,49 +1,51 @@
 package middleware
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
 	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// authFailureInterval is how often one client address may log the same failed token. Clients
+// retry with the token they hold, so every failure would otherwise be logged many times over.
+const authFailureInterval = time.Minute
+
+// maxTrackedAuthFailures bounds the failures remembered for deduplication
+const maxTrackedAuthFailures = 10000
+
 // AuthMiddleware provides JWT authentication middleware
 type AuthMiddleware struct {
-	authService interfaces.AuthService
+	authService  interfaces.AuthService
+	auditService interfaces.AuditService
+
+	mu       sync.Mutex
+	failures map[string]time.Time // When each client address, user and reason was last logged
 }
 
 // NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(authService interfaces.AuthService) *AuthMiddleware {
+func NewAuthMiddleware(authService interfaces.AuthService, auditService interfaces.AuditService) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		authService:  authService,
+		auditService: auditService,
+		failures:     make(map[string]time.Time),
 	}
 }
 
 // RequireAuth middleware that requires authentication
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := m.extractToken(c)
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
-			c.Abort()
-			return
-		}
-
-		// Validate token
-		claims, err := m.authService.ValidateJWT(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+		if _, ok := m.authenticate(c); !ok {
 			return
 		}
 
-		// Set user in context
-		c.Set("user", claims)
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-
 		c.Next()
 	}
 }
@@ -66,16 +68,147 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 			return
 		}
 
-		// Set user in context
-		c.Set("user", claims)
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
+		// Suspended accounts, revoked tokens and ended sessions also continue without authentication
+		if err := m.authService.VerifyAccess(c.Request.Context(), claims); err != nil {
+			c.Next()
+			return
+		}
+
+		setUser(c, claims)
+
+		c.Next()
+	}
+}
+
+// RequireAdmin middleware that requires an authenticated admin
+func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := m.authenticate(c)
+		if !ok {
+			return
+		}
+
+		// Role changes revoke the user's tokens, so a token that passed VerifyAccess carries the
+		// current role
+		if claims.Role != entities.UserRoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// authenticate validates the request's token against the current account and sets the user in
+// context. It aborts the request and returns false when the request is not authenticated.
+func (m *AuthMiddleware) authenticate(c *gin.Context) (*interfaces.JWTClaims, bool) {
+	token := m.extractToken(c)
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		c.Abort()
+		return nil, false
+	}
+
+	// Validate token. Only an expired token's claims can be trusted to name the user it failed for.
+	claims, err := m.authService.ValidateJWT(token)
+	if err != nil {
+		var expiredFor *uuid.UUID
+		if errors.Is(err, entities.ErrTokenExpired) && claims != nil {
+			if userID, err := uuid.Parse(claims.UserID); err == nil {
+				expiredFor = &userID
+			}
+		}
+		if expiredFor != nil {
+			m.recordFailure(c, expiredFor, "expired")
+		} else {
+			m.recordFailure(c, nil, "invalid_token")
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		c.Abort()
+		return nil, false
+	}
+
+	// Reject suspended accounts, revoked tokens and ended sessions even before the token expires
+	if err := m.authService.VerifyAccess(c.Request.Context(), claims); err != nil {
+		userID, _ := uuid.Parse(claims.UserID)
+		switch {
+		case errors.Is(err, entities.ErrUserSuspended):
+			m.recordFailure(c, &userID, "suspended")
+			c.JSON(http.StatusForbidden, gin.H{"error": "account suspended"})
+		case errors.Is(err, entities.ErrTokenRevoked):
+			m.recordFailure(c, &userID, "revoked")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		case errors.Is(err, entities.ErrSessionNotFound), errors.Is(err, entities.ErrSessionExpired):
+			m.recordFailure(c, &userID, "session_ended")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		case errors.Is(err, entities.ErrUserNotFound):
+			// The account is gone, so there is no user to attribute the failure to
+			m.recordFailure(c, nil, "user_not_found")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify token"})
+		}
+		c.Abort()
+		return nil, false
+	}
+
+	setUser(c, claims)
+	return claims, true
+}
+
+// recordFailure logs a rejected token as a failed authentication, once per authFailureInterval
+// for each client address, user and reason
+func (m *AuthMiddleware) recordFailure(c *gin.Context, userID *uuid.UUID, reason string) {
+	key := c.ClientIP() + " " + reason
+	if userID != nil {
+		key += " " + userID.String()
+	}
+	if !m.claimFailure(key, time.Now()) {
+		return
+	}
+
+	metadata := map[string]string{"method": "token", "reason": reason}
+	if err := m.auditService.Record(c.Request.Context(), userID, entities.AuditActionAuthFailed, entities.AuditResourceUser, userID, metadata); err != nil {
+		slog.Warn("Failed to record audit log", "action", entities.AuditActionAuthFailed, "user_id", userID, "error", err)
+	}
+}
+
+// claimFailure reports whether the failure under key should be logged at now, and remembers it
+func (m *AuthMiddleware) claimFailure(key string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if last, ok := m.failures[key]; ok && now.Sub(last) < authFailureInterval {
+		return false
+	}
+
+	// Forget failures that no longer suppress anything before the map grows without bound
+	if len(m.failures) >= maxTrackedAuthFailures {
+		for k, last := range m.failures {
+			if now.Sub(last) >= authFailureInterval {
+				delete(m.failures, k)
+			}
+		}
+		if len(m.failures) >= maxTrackedAuthFailures {
+			return false
+		}
+	}
+
+	m.failures[key] = now
+	return true
+}
+
+// setUser sets the authenticated user in context
+func setUser(c *gin.Context, claims *interfaces.JWTClaims) {
+	c.Set("user", claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("session_id", claims.SessionID)
+}
+
 // extractToken extracts JWT token from request
 func (m *AuthMiddleware) extractToken(c *gin.Context) string {
 	// Try to get token from Authorization header
@@ -130,35 +263,3 @@ func GetCurrentUserID(c *gin.Context) (string, bool) {
 
 	return userIDStr, true
 }
-
-// RequireAdmin middleware that requires admin authentication (placeholder)
-func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// First check if user is authenticated
-		token := m.extractToken(c)
-		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
-			c.Abort()
-			return
-		}
-
-		// Validate token
-		claims, err := m.authService.ValidateJWT(token)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
-			return
-		}
-
-		// TODO: Add admin role checking logic here
-		// For now, just check if user is authenticated
-
-		// Set user in context
-		c.Set("user", claims)
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-
-		c.Next()
-	}
-}
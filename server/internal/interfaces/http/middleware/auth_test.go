@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+)
+
+// fakeAuthService accepts the tokens it holds claims for and fails VerifyAccess with accessErr
+type fakeAuthService struct {
+	interfaces.AuthService
+	claims    map[string]*interfaces.JWTClaims
+	expired   map[string]*interfaces.JWTClaims // Tokens past their expiry, with the claims they name
+	accessErr error
+}
+
+func (s *fakeAuthService) ValidateJWT(token string) (*interfaces.JWTClaims, error) {
+	if claims, ok := s.expired[token]; ok {
+		return claims, fmt.Errorf("failed to parse JWT token: %w", entities.ErrTokenExpired)
+	}
+	claims, ok := s.claims[token]
+	if !ok {
+		return nil, errors.New("token signature is invalid")
+	}
+	return claims, nil
+}
+
+func (s *fakeAuthService) VerifyAccess(context.Context, *interfaces.JWTClaims) error {
+	return s.accessErr
+}
+
+// recordedFailure is an auth.failed entry a fakeAuditService was asked to record
+type recordedFailure struct {
+	userID *uuid.UUID
+	reason string
+}
+
+// fakeAuditService records the failed authentications it is given
+type fakeAuditService struct {
+	interfaces.AuditService
+	failures []recordedFailure
+}
+
+func (s *fakeAuditService) Record(_ context.Context, userID *uuid.UUID, action, _ string, _ *uuid.UUID, metadata map[string]string) error {
+	if action == entities.AuditActionAuthFailed {
+		s.failures = append(s.failures, recordedFailure{userID: userID, reason: metadata["reason"]})
+	}
+	return nil
+}
+
+// serve runs a request with token through handler and reports the status and whether the route ran
+func serve(handler gin.HandlerFunc, token string) (int, bool) {
+	gin.SetMode(gin.TestMode)
+
+	reached := false
+	router := gin.New()
+	router.GET("/", handler, func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(w, req)
+	return w.Code, reached
+}
+
+func TestRequireAuth(t *testing.T) {
+	claims := &interfaces.JWTClaims{UserID: uuid.NewString(), Role: entities.UserRoleUser, SessionID: uuid.NewString()}
+
+	tests := []struct {
+		name      string
+		token     string
+		accessErr error
+		status    int
+	}{
+		{name: "valid", token: "user", status: http.StatusOK},
+		{name: "missing token", status: http.StatusUnauthorized},
+		{name: "bad token", token: "forged", status: http.StatusUnauthorized},
+		{name: "suspended user", token: "user", accessErr: entities.ErrUserSuspended, status: http.StatusForbidden},
+		{name: "revoked token version", token: "user", accessErr: entities.ErrTokenRevoked, status: http.StatusUnauthorized},
+		{name: "deleted user", token: "user", accessErr: entities.ErrUserNotFound, status: http.StatusUnauthorized},
+		{name: "ended session", token: "user", accessErr: entities.ErrSessionExpired, status: http.StatusUnauthorized},
+		{name: "verification failure", token: "user", accessErr: errors.New("database is down"), status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewAuthMiddleware(&fakeAuthService{claims: map[string]*interfaces.JWTClaims{"user": claims}, accessErr: tt.accessErr}, &fakeAuditService{})
+
+			status, reached := serve(m.RequireAuth(), tt.token)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.status == http.StatusOK, reached)
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	m := NewAuthMiddleware(&fakeAuthService{claims: map[string]*interfaces.JWTClaims{
+		"admin": {UserID: uuid.NewString(), Role: entities.UserRoleAdmin, SessionID: uuid.NewString()},
+		"user":  {UserID: uuid.NewString(), Role: entities.UserRoleUser, SessionID: uuid.NewString()},
+	}}, &fakeAuditService{})
+
+	status, reached := serve(m.RequireAdmin(), "admin")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, reached)
+
+	status, reached = serve(m.RequireAdmin(), "user")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.False(t, reached)
+
+	status, reached = serve(m.RequireAdmin(), "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.False(t, reached)
+
+	// An admin whose tokens were revoked, e.g. by losing the role, is not let through
+	revoked := NewAuthMiddleware(&fakeAuthService{
+		claims:    map[string]*interfaces.JWTClaims{"admin": {UserID: uuid.NewString(), Role: entities.UserRoleAdmin, SessionID: uuid.NewString()}},
+		accessErr: entities.ErrTokenRevoked,
+	}, &fakeAuditService{})
+	status, reached = serve(revoked.RequireAdmin(), "admin")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.False(t, reached)
+}
+
+func TestAuthenticate_RecordsFailures(t *testing.T) {
+	userID := uuid.New()
+	claims := &interfaces.JWTClaims{UserID: userID.String(), Role: entities.UserRoleUser, SessionID: uuid.NewString()}
+
+	tests := []struct {
+		name      string
+		token     string
+		accessErr error
+		failure   *recordedFailure // nil when nothing is recorded
+	}{
+		{name: "valid", token: "user"},
+		{name: "missing token", token: ""},
+		{name: "bad token", token: "forged", failure: &recordedFailure{reason: "invalid_token"}},
+		{name: "expired token", token: "expired", failure: &recordedFailure{userID: &userID, reason: "expired"}},
+		{name: "revoked token version", token: "user", accessErr: entities.ErrTokenRevoked, failure: &recordedFailure{userID: &userID, reason: "revoked"}},
+		{name: "suspended user", token: "user", accessErr: entities.ErrUserSuspended, failure: &recordedFailure{userID: &userID, reason: "suspended"}},
+		{name: "ended session", token: "user", accessErr: entities.ErrSessionNotFound, failure: &recordedFailure{userID: &userID, reason: "session_ended"}},
+		{name: "deleted user", token: "user", accessErr: entities.ErrUserNotFound, failure: &recordedFailure{reason: "user_not_found"}},
+		{name: "verification failure", token: "user", accessErr: errors.New("database is down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &fakeAuditService{}
+			m := NewAuthMiddleware(&fakeAuthService{
+				claims:    map[string]*interfaces.JWTClaims{"user": claims},
+				expired:   map[string]*interfaces.JWTClaims{"expired": claims},
+				accessErr: tt.accessErr,
+			}, audit)
+
+			// Clients retry with the token they hold; the failure is logged once
+			serve(m.RequireAuth(), tt.token)
+			serve(m.RequireAuth(), tt.token)
+
+			if tt.failure == nil {
+				assert.Empty(t, audit.failures)
+				return
+			}
+			assert.Equal(t, []recordedFailure{*tt.failure}, audit.failures)
+		})
+	}
+}
+
+func TestClaimFailure(t *testing.T) {
+	m := NewAuthMiddleware(&fakeAuthService{}, &fakeAuditService{})
+	now := time.Now()
+
+	assert.True(t, m.claimFailure("192.0.2.1 revoked", now))
+	assert.False(t, m.claimFailure("192.0.2.1 revoked", now.Add(authFailureInterval-time.Second)))
+	assert.True(t, m.claimFailure("192.0.2.2 revoked", now), "other addresses are logged separately")
+	assert.True(t, m.claimFailure("192.0.2.1 revoked", now.Add(authFailureInterval)))
+
+	// Once full, failures that no longer suppress anything are forgotten to make room
+	for i := len(m.failures); i < maxTrackedAuthFailures; i++ {
+		m.failures[uuid.NewString()] = now
+	}
+	later := now.Add(2 * authFailureInterval)
+	assert.True(t, m.claimFailure("192.0.2.3 revoked", later))
+	assert.Len(t, m.failures, 1)
+}
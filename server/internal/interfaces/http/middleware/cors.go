@@ -14,8 +14,8 @@ func CORS(cfg *config.Config) gin.HandlerFunc {
 	corsConfig := cors.Config{
 		AllowOrigins:     cfg.Security.CORSOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Device-ID"},
-		ExposeHeaders:    []string{"X-Request-ID", "X-Rate-Limit-Remaining", "X-Rate-Limit-Reset"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Device-ID", "If-Match", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"X-Request-ID", "X-Rate-Limit-Remaining", "X-Rate-Limit-Reset", "X-Total-Count", "X-Next-Cursor", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
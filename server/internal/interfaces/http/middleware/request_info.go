@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+)
+
+// maxDeviceIDLength bounds the client-supplied X-Device-ID header
+const maxDeviceIDLength = 255
+
+// RequestInfo records the calling device and request in the request context, where services
+// read it with entities.RequestInfoFromContext. It must run after the request ID middleware.
+func RequestInfo() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		deviceID := c.GetHeader("X-Device-ID")
+		if len(deviceID) > maxDeviceIDLength {
+			deviceID = deviceID[:maxDeviceIDLength]
+		}
+
+		info := entities.RequestInfo{
+			DeviceID:  deviceID,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("requestID"),
+		}
+		c.Request = c.Request.WithContext(entities.ContextWithRequestInfo(c.Request.Context(), info))
+
+		c.Next()
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,21 +16,34 @@ import (
 	"github.com/markbates/goth/providers/google"
 
 	appServices "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/backup"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/crypto"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
 	database_adapters "github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/issuers"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/jwt"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/totp"
 	"github.com/bug-breeder/2fair/server/internal/infrastructure/webauthn"
 	"github.com/bug-breeder/2fair/server/internal/interfaces/http/handlers"
 	"github.com/bug-breeder/2fair/server/internal/interfaces/http/middleware"
 )
 
+// issuerIconBaseURL is the path bundled issuer icons are served under
+const issuerIconBaseURL = "/api/v1/issuers/icons/"
+
 // Server represents the HTTP server
 type Server struct {
 	httpServer *http.Server
 	config     *config.Config
 	db         *database.DB
+
+	// Background jobs run until Stop
+	trashPurger     *appServices.TrashPurger
+	backupScheduler *appServices.BackupScheduler
+	eventHub        *appServices.VaultEventHub
+	jobsCancel      context.CancelFunc
+	jobs            sync.WaitGroup
 }
 
 // NewServer creates a new HTTP server
@@ -53,26 +67,62 @@ func NewServer(cfg *config.Config, db *database.DB) *Server {
 	// Add custom middleware for request ID, logging, etc.
 	router.Use(RequestID())
 	router.Use(Logger())
+	router.Use(middleware.RequestInfo())
 
 	// Initialize repositories
 	userRepo := database_adapters.NewUserRepository(db)
 	credRepo := database_adapters.NewWebAuthnCredentialRepository(db)
 	cryptoService := crypto.NewCryptoService()
 	otpRepo := database_adapters.NewOTPRepository(db, cryptoService)
+	folderRepo := database_adapters.NewFolderRepository(db)
+	syncRepo := database_adapters.NewSyncRepository(db)
+	vaultEventRepo := database_adapters.NewVaultEventRepository(db)
+	keyRepo := database_adapters.NewEncryptionKeyRepository(db)
+	keyRotationRepo := database_adapters.NewKeyRotationRepository(db)
+	recoveryKitRepo := database_adapters.NewRecoveryKitRepository(db)
+	vaultImportRepo := database_adapters.NewVaultImportRepository(db)
+	auditLogRepo := database_adapters.NewAuditLogRepository(db)
+	sessionRepo := database_adapters.NewSessionRepository(db)
+	refreshTokenRepo := database_adapters.NewRefreshTokenRepository(db)
 
 	// Initialize infrastructure services
 	totpService := totp.NewTOTPService()
+	keyring, err := loadKeyring(cfg)
+	if err != nil {
+		slog.Error("Failed to load JWT signing keys", "error", err)
+		return nil
+	}
+	tokenService := jwt.NewTokenService(keyring, cfg.JWT.Issuer, cfg.JWT.Audience, cfg.JWT.RefreshTime)
+	issuerCatalog, err := issuers.NewCatalog(issuerIconBaseURL)
+	if err != nil {
+		slog.Error("Failed to load issuer catalog", "error", err)
+		return nil
+	}
 
 	// Initialize domain services
+	eventHub := appServices.NewVaultEventHub(vaultEventRepo, database_adapters.NewVaultEventListener(db))
+	sessionService := appServices.NewSessionService(sessionRepo, cfg.Session.IdleTimeout, cfg.Session.AbsoluteTimeout, cfg.Session.ActivityInterval)
 	authService := appServices.NewAuthService(
 		userRepo,
-		cfg.JWT.SigningKey,
+		sessionService,
+		refreshTokenRepo,
+		tokenService,
+		tokenService,
+		eventHub,
 		cfg.JWT.ExpirationTime,
 		fmt.Sprintf("http://%s", cfg.GetServerAddress()), // Server URL for OAuth callbacks
 	)
 
 	// Initialize OTP service
-	otpService := appServices.NewOTPService(otpRepo, cryptoService, totpService)
+	otpService := appServices.NewOTPService(otpRepo, folderRepo, keyRepo, cryptoService, totpService, issuerCatalog, cfg.Vault.BatchMaxOperations, cfg.Vault.TrashRetention)
+	folderService := appServices.NewFolderService(folderRepo)
+	syncService := appServices.NewSyncService(syncRepo, keyRepo, totpService, issuerCatalog, cfg.Vault.BatchMaxOperations)
+	keyRotationService := appServices.NewKeyRotationService(keyRotationRepo, keyRepo, credRepo)
+	encryptionKeyService := appServices.NewEncryptionKeyService(keyRepo, keyRotationRepo, credRepo)
+	recoveryService := appServices.NewRecoveryService(recoveryKitRepo, keyRepo, credRepo, cryptoService, cfg.Vault.RecoveryMaxAttempts, cfg.Vault.RecoveryLockout)
+	vaultExportService := appServices.NewVaultExportService(otpRepo, folderRepo, keyRepo, credRepo, keyRotationRepo, vaultImportRepo, totpService)
+	auditService := appServices.NewAuditService(auditLogRepo)
+	adminService := appServices.NewAdminService(userRepo, sessionService)
 
 	// Initialize WebAuthn service
 	webAuthnService, err := webauthn.NewWebAuthnService(
@@ -88,16 +138,27 @@ func NewServer(cfg *config.Config, db *database.DB) *Server {
 	}
 
 	// Initialize middleware
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, auditService)
 
 	// Create handlers
 	healthHandler := handlers.NewHealthHandler(db)
-	authHandler := handlers.NewAuthHandler(authService, cfg)
-	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService, authService)
-	otpHandler := handlers.NewOTPHandler(otpService)
+	authHandler := handlers.NewAuthHandler(authService, sessionService, eventHub, auditService, cfg)
+	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService, authService, auditService)
+	otpHandler := handlers.NewOTPHandler(otpService, totpService, cfg)
+	folderHandler := handlers.NewFolderHandler(folderService)
+	issuerHandler := handlers.NewIssuerHandler(issuerCatalog)
+	syncHandler := handlers.NewSyncHandler(syncService, cfg)
+	eventHandler := handlers.NewEventHandler(eventHub, authService)
+	keyRotationHandler := handlers.NewKeyRotationHandler(keyRotationService, eventHub)
+	encryptionKeyHandler := handlers.NewEncryptionKeyHandler(encryptionKeyService)
+	recoveryHandler := handlers.NewRecoveryHandler(recoveryService)
+	vaultExportHandler := handlers.NewVaultExportHandler(vaultExportService, cfg)
+	securityHandler := handlers.NewSecurityHandler(auditService)
+	adminHandler := handlers.NewAdminHandler(adminService, eventHub, auditService)
+	sessionHandler := handlers.NewSessionHandler(sessionService, eventHub, auditService)
 
 	// Setup routes
-	setupRoutes(router, healthHandler, authHandler, webAuthnHandler, otpHandler, authMiddleware)
+	setupRoutes(router, healthHandler, authHandler, webAuthnHandler, otpHandler, folderHandler, issuerHandler, syncHandler, eventHandler, keyRotationHandler, encryptionKeyHandler, recoveryHandler, vaultExportHandler, securityHandler, sessionHandler, adminHandler, authMiddleware)
 
 	// Create HTTP server
 	httpServer := &http.Server{
@@ -108,11 +169,42 @@ func NewServer(cfg *config.Config, db *database.DB) *Server {
 		MaxHeaderBytes: cfg.Server.MaxHeaderBytes,
 	}
 
-	return &Server{
+	server := &Server{
 		httpServer: httpServer,
 		config:     cfg,
 		db:         db,
+		eventHub:   eventHub,
 	}
+	if cfg.Vault.TrashRetention > 0 {
+		server.trashPurger = appServices.NewTrashPurger(otpService, cfg.Vault.TrashPurgeInterval)
+	}
+	if cfg.Backup.Enabled {
+		backupArchiver, backupStore, err := backup.NewFromConfig(cfg.Backup)
+		if err != nil {
+			slog.Error("Failed to initialize backups", "error", err)
+			return nil
+		}
+		backupService := appServices.NewBackupService(database_adapters.NewBackupRepository(db), backupArchiver, backupStore, cfg.Backup.RetentionCount, cfg.Backup.RetentionAge)
+		server.backupScheduler = appServices.NewBackupScheduler(backupService, cfg.Backup.Interval)
+	}
+
+	return server
+}
+
+// loadKeyring loads the JWT signing keys, or makes a temporary key when none are configured
+func loadKeyring(cfg *config.Config) (*jwt.Keyring, error) {
+	if cfg.JWT.KeysDir == "" {
+		slog.Warn("JWT_KEYS_DIR is not set; signing tokens with a temporary key that is lost on restart")
+		return jwt.NewEphemeralKeyring()
+	}
+
+	keyring, err := jwt.LoadKeyring(cfg.JWT.KeysDir, cfg.JWT.ActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Loaded JWT signing keys", "active_key_id", keyring.Active().ID)
+
+	return keyring, nil
 }
 
 // configureOAuthProviders sets up OAuth providers
@@ -168,6 +260,8 @@ func configureOAuthProviders(cfg *config.Config) {
 func (s *Server) Start() error {
 	slog.Info("Starting HTTP server", "address", s.config.GetServerAddress())
 
+	s.startJobs()
+
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)
 	}
@@ -179,16 +273,61 @@ func (s *Server) Start() error {
 func (s *Server) Stop(ctx context.Context) error {
 	slog.Info("Stopping HTTP server")
 
-	return s.httpServer.Shutdown(ctx)
+	// Stopping the event hub ends open event streams, which Shutdown would otherwise wait on
+	s.stopJobs()
+	err := s.httpServer.Shutdown(ctx)
+
+	return err
+}
+
+// startJobs launches the background jobs
+func (s *Server) startJobs() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.jobsCancel = cancel
+
+	if s.trashPurger != nil {
+		s.jobs.Add(1)
+		go func() {
+			defer s.jobs.Done()
+			s.trashPurger.Run(ctx)
+		}()
+	}
+
+	if s.backupScheduler != nil {
+		s.jobs.Add(1)
+		go func() {
+			defer s.jobs.Done()
+			s.backupScheduler.Run(ctx)
+		}()
+	}
+
+	if s.eventHub != nil {
+		s.jobs.Add(1)
+		go func() {
+			defer s.jobs.Done()
+			s.eventHub.Run(ctx)
+		}()
+	}
+}
+
+// stopJobs cancels the background jobs and waits for them to finish
+func (s *Server) stopJobs() {
+	if s.jobsCancel != nil {
+		s.jobsCancel()
+	}
+	s.jobs.Wait()
 }
 
 // setupRoutes configures all the routes for the application
-func setupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, authHandler *handlers.AuthHandler, webAuthnHandler *handlers.WebAuthnHandler, otpHandler *handlers.OTPHandler, authMiddleware *middleware.AuthMiddleware) {
+func setupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, authHandler *handlers.AuthHandler, webAuthnHandler *handlers.WebAuthnHandler, otpHandler *handlers.OTPHandler, folderHandler *handlers.FolderHandler, issuerHandler *handlers.IssuerHandler, syncHandler *handlers.SyncHandler, eventHandler *handlers.EventHandler, keyRotationHandler *handlers.KeyRotationHandler, encryptionKeyHandler *handlers.EncryptionKeyHandler, recoveryHandler *handlers.RecoveryHandler, vaultExportHandler *handlers.VaultExportHandler, securityHandler *handlers.SecurityHandler, sessionHandler *handlers.SessionHandler, adminHandler *handlers.AdminHandler, authMiddleware *middleware.AuthMiddleware) {
 	// Health check endpoints
 	router.GET("/health", healthHandler.Health)
 	router.GET("/health/ready", healthHandler.Ready)
 	router.GET("/health/live", healthHandler.Live)
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	// Public API routes
 	v1 := router.Group("/v1")
 	{
@@ -227,13 +366,32 @@ func setupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, auth
 
 				// Token management
 				auth.POST("/refresh", authHandler.RefreshToken)
-				auth.POST("/logout", authHandler.Logout)
+				auth.POST("/logout", authMiddleware.OptionalAuth(), authHandler.Logout)
 
 				// Protected routes
 				auth.GET("/profile", authMiddleware.RequireAuth(), authHandler.GetProfile)
 				auth.GET("/me", authMiddleware.RequireAuth(), authHandler.GetProfile)
 			}
 
+			// Issuer icons are static assets referenced from <img> tags, so they are public
+			if issuerHandler != nil {
+				apiv1.GET("/issuers/icons/:file", issuerHandler.GetIcon)
+			}
+
+			// Admin account management (require the admin role)
+			if adminHandler != nil {
+				admin := apiv1.Group("/admin")
+				admin.Use(authMiddleware.RequireAdmin())
+				{
+					admin.GET("/users", adminHandler.ListUsers)
+					admin.GET("/users/:id", adminHandler.GetUser)
+					admin.POST("/users/:id/suspend", adminHandler.SuspendUser)
+					admin.POST("/users/:id/reactivate", adminHandler.ReactivateUser)
+					admin.POST("/users/:id/logout", adminHandler.LogoutUser)
+					admin.GET("/stats", adminHandler.GetStats)
+				}
+			}
+
 			// Protected routes (require authentication)
 			protected := apiv1.Group("")
 			protected.Use(authMiddleware.RequireAuth())
@@ -257,9 +415,98 @@ func setupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, auth
 				// OTP/TOTP vault routes - zero-knowledge architecture
 				if otpHandler != nil {
 					protected.POST("/otp", otpHandler.CreateOTP)
+					protected.POST("/otp/batch", otpHandler.ApplyBatch)
 					protected.GET("/otp", otpHandler.GetOTPs)
+					protected.GET("/otp/:id", otpHandler.GetOTP)
 					protected.PUT("/otp/:id", otpHandler.UpdateOTP)
 					protected.POST("/otp/:id/inactivate", otpHandler.InactivateOTP)
+					protected.DELETE("/otp/:id", otpHandler.PurgeOTP)
+					protected.POST("/otp/:id/counter", otpHandler.AdvanceCounter)
+					protected.POST("/otp/:id/use", otpHandler.RecordUse)
+					protected.GET("/otp/:id/revisions", otpHandler.GetRevisions)
+					protected.POST("/otp/:id/revisions/:revisionId/revert", otpHandler.RevertOTP)
+
+					// Trash
+					protected.GET("/otp/trash", otpHandler.GetTrash)
+					protected.POST("/otp/:id/restore", otpHandler.RestoreOTP)
+
+					// Tags
+					protected.GET("/tags", otpHandler.GetTags)
+					protected.POST("/tags/rename", otpHandler.RenameTag)
+				}
+
+				// Issuer catalog routes
+				if issuerHandler != nil {
+					protected.GET("/issuers", issuerHandler.SearchIssuers)
+					protected.GET("/issuers/lookup", issuerHandler.LookupIssuer)
+				}
+
+				// Folder routes
+				if folderHandler != nil {
+					protected.GET("/folders", folderHandler.GetFolders)
+					protected.POST("/folders", folderHandler.CreateFolder)
+					protected.PUT("/folders/:id", folderHandler.UpdateFolder)
+					protected.DELETE("/folders/:id", folderHandler.DeleteFolder)
+				}
+
+				// Delta sync routes
+				if syncHandler != nil {
+					protected.POST("/sync", syncHandler.Sync)
+					protected.GET("/sync/devices", syncHandler.GetDevices)
+					protected.POST("/sync/push", syncHandler.Push)
+					protected.GET("/sync/conflicts", syncHandler.GetConflicts)
+					protected.POST("/sync/conflicts/:id/resolve", syncHandler.ResolveConflict)
+				}
+
+				// Real-time vault events
+				if eventHandler != nil {
+					protected.GET("/events", eventHandler.Stream)
+				}
+
+				// Wrapped vault keys, one per passkey
+				if encryptionKeyHandler != nil {
+					protected.GET("/vault/keys", encryptionKeyHandler.GetKeys)
+					protected.POST("/vault/keys", encryptionKeyHandler.AddKey)
+					protected.GET("/vault/keys/:credentialId", encryptionKeyHandler.GetKey)
+				}
+
+				// Vault key rotation
+				if keyRotationHandler != nil {
+					protected.POST("/vault/key-rotation", keyRotationHandler.StartRotation)
+					protected.GET("/vault/key-rotation", keyRotationHandler.GetRotation)
+					protected.DELETE("/vault/key-rotation", keyRotationHandler.AbortRotation)
+					protected.GET("/vault/key-rotation/entries", keyRotationHandler.GetPendingEntries)
+					protected.POST("/vault/key-rotation/entries", keyRotationHandler.StageEntries)
+					protected.POST("/vault/key-rotation/commit", keyRotationHandler.CommitRotation)
+				}
+
+				// Recovery kits for regaining vault access without a passkey
+				if recoveryHandler != nil {
+					protected.GET("/vault/recovery", recoveryHandler.GetKit)
+					protected.POST("/vault/recovery", recoveryHandler.CreateKit)
+					protected.POST("/vault/recovery/regenerate", recoveryHandler.RegenerateKit)
+					protected.POST("/vault/recovery/redeem", recoveryHandler.RedeemKit)
+					protected.POST("/vault/recovery/reregister", recoveryHandler.Reregister)
+				}
+
+				// Portable .2fair export and restore
+				if vaultExportHandler != nil {
+					protected.GET("/vault/export", vaultExportHandler.Export)
+					protected.POST("/vault/import", vaultExportHandler.Import)
+				}
+
+				// Security audit log
+				if securityHandler != nil {
+					protected.GET("/security/activity", securityHandler.GetActivity)
+				}
+
+				// Signed-in devices
+				if sessionHandler != nil {
+					protected.GET("/sessions", sessionHandler.ListSessions)
+					protected.POST("/sessions/heartbeat", sessionHandler.Heartbeat)
+					protected.POST("/sessions/revoke-others", sessionHandler.RevokeOtherSessions)
+					protected.PUT("/sessions/:id", sessionHandler.RenameSession)
+					protected.DELETE("/sessions/:id", sessionHandler.RevokeSession)
 				}
 				// NOTE: /codes endpoint intentionally removed
 				// TOTP code generation happens client-side for zero-knowledge
@@ -277,10 +524,54 @@ func setupRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler, auth
 							"totp_vault": "enabled",
 							"encryption": "enabled",
 							"api_endpoints": gin.H{
-								"create_otp": "POST /api/v1/otp",
-								"list_otps":  "GET /api/v1/otp",
-								"update_otp": "PUT /api/v1/otp/:id",
-								"delete_otp": "POST /api/v1/otp/:id/inactivate",
+								"create_otp":              "POST /api/v1/otp",
+								"list_otps":               "GET /api/v1/otp",
+								"get_otp":                 "GET /api/v1/otp/:id",
+								"update_otp":              "PUT /api/v1/otp/:id",
+								"delete_otp":              "POST /api/v1/otp/:id/inactivate",
+								"list_trash":              "GET /api/v1/otp/trash",
+								"restore_otp":             "POST /api/v1/otp/:id/restore",
+								"purge_otp":               "DELETE /api/v1/otp/:id",
+								"advance_counter":         "POST /api/v1/otp/:id/counter",
+								"record_use":              "POST /api/v1/otp/:id/use",
+								"list_revisions":          "GET /api/v1/otp/:id/revisions",
+								"revert_otp":              "POST /api/v1/otp/:id/revisions/:revisionId/revert",
+								"list_tags":               "GET /api/v1/tags",
+								"rename_tag":              "POST /api/v1/tags/rename",
+								"search_issuers":          "GET /api/v1/issuers",
+								"lookup_issuer":           "GET /api/v1/issuers/lookup",
+								"list_folders":            "GET /api/v1/folders",
+								"create_folder":           "POST /api/v1/folders",
+								"update_folder":           "PUT /api/v1/folders/:id",
+								"delete_folder":           "DELETE /api/v1/folders/:id",
+								"sync":                    "POST /api/v1/sync",
+								"list_devices":            "GET /api/v1/sync/devices",
+								"push_changes":            "POST /api/v1/sync/push",
+								"list_conflicts":          "GET /api/v1/sync/conflicts",
+								"resolve_conflict":        "POST /api/v1/sync/conflicts/:id/resolve",
+								"stream_events":           "GET /api/v1/events",
+								"list_keys":               "GET /api/v1/vault/keys",
+								"add_key":                 "POST /api/v1/vault/keys",
+								"get_key":                 "GET /api/v1/vault/keys/:credentialId",
+								"start_rotation":          "POST /api/v1/vault/key-rotation",
+								"get_rotation":            "GET /api/v1/vault/key-rotation",
+								"abort_rotation":          "DELETE /api/v1/vault/key-rotation",
+								"pending_entries":         "GET /api/v1/vault/key-rotation/entries",
+								"stage_entries":           "POST /api/v1/vault/key-rotation/entries",
+								"commit_rotation":         "POST /api/v1/vault/key-rotation/commit",
+								"get_recovery_kit":        "GET /api/v1/vault/recovery",
+								"create_recovery_kit":     "POST /api/v1/vault/recovery",
+								"regenerate_recovery_kit": "POST /api/v1/vault/recovery/regenerate",
+								"redeem_recovery_kit":     "POST /api/v1/vault/recovery/redeem",
+								"reregister_passkey":      "POST /api/v1/vault/recovery/reregister",
+								"export_vault":            "GET /api/v1/vault/export",
+								"import_vault":            "POST /api/v1/vault/import",
+								"security_activity":       "GET /api/v1/security/activity",
+								"list_sessions":           "GET /api/v1/sessions",
+								"session_heartbeat":       "POST /api/v1/sessions/heartbeat",
+								"rename_session":          "PUT /api/v1/sessions/:id",
+								"revoke_session":          "DELETE /api/v1/sessions/:id",
+								"revoke_other_sessions":   "POST /api/v1/sessions/revoke-others",
 							},
 						},
 					})
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	application "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+// AuditLogRepositoryTestSuite checks the audit chain against PostgreSQL
+type AuditLogRepositoryTestSuite struct {
+	IntegrationTestSuite
+	auditRepo    interfaces.AuditLogRepository
+	auditService interfaces.AuditService
+	user         *entities.User
+}
+
+func TestAuditLogRepositorySuite(t *testing.T) {
+	suite.Run(t, new(AuditLogRepositoryTestSuite))
+}
+
+// SetupSuite starts PostgreSQL, skipping when Docker is not available
+func (suite *AuditLogRepositoryTestSuite) SetupSuite() {
+	suite.skipWithoutDocker()
+	suite.IntegrationTestSuite.SetupSuite()
+
+	suite.auditRepo = database.NewAuditLogRepository(suite.DB)
+	suite.auditService = application.NewAuditService(suite.auditRepo)
+}
+
+// SetupTest empties the database and creates the user entries are about
+func (suite *AuditLogRepositoryTestSuite) SetupTest() {
+	suite.IntegrationTestSuite.SetupTest()
+	suite.user = suite.StoreTestUser("alice")
+}
+
+// record appends an entry about the suite's user
+func (suite *AuditLogRepositoryTestSuite) record(ctx context.Context, attempt int) error {
+	return suite.auditService.Record(ctx, &suite.user.ID, entities.AuditActionAuthFailed, entities.AuditResourceUser, &suite.user.ID,
+		map[string]string{"reason": "revoked", "attempt": fmt.Sprint(attempt)})
+}
+
+func (suite *AuditLogRepositoryTestSuite) TestAppend_ChainsEntries() {
+	ctx := entities.ContextWithRequestInfo(context.Background(), entities.RequestInfo{IPAddress: "203.0.113.7", UserAgent: "Firefox"})
+	for i := 0; i < 3; i++ {
+		suite.Require().NoError(suite.record(ctx, i))
+	}
+
+	entries, err := suite.auditRepo.ListChain(context.Background(), 0, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(entries, 3)
+	suite.Empty(entries[0].PrevHash)
+	for i, entry := range entries {
+		suite.Equal(int64(i+1), entry.Sequence)
+		suite.Equal(entry.ComputeHash(), entry.Hash, "entry %d hashes the same once read back", entry.Sequence)
+		if i > 0 {
+			suite.Equal(entries[i-1].Hash, entry.PrevHash)
+		}
+	}
+}
+
+func (suite *AuditLogRepositoryTestSuite) TestAppend_ConcurrentEntriesChain() {
+	const appends = 20
+
+	// Each append reads the chain head; without the chain lock two would claim the same sequence
+	var wg sync.WaitGroup
+	errs := make(chan error, appends)
+	for i := 0; i < appends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- suite.record(context.Background(), i)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		suite.Require().NoError(err)
+	}
+
+	last, err := suite.auditService.VerifyChain(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().NotNil(last)
+	suite.Equal(int64(appends), last.Sequence)
+}
+
+func (suite *AuditLogRepositoryTestSuite) TestVerifyChain_DetectsEdits() {
+	for i := 0; i < 3; i++ {
+		suite.Require().NoError(suite.record(context.Background(), i))
+	}
+
+	_, err := suite.DB.Pool.Exec(context.Background(), `UPDATE audit_logs SET action = $1 WHERE sequence = 2`, entities.AuditActionLogin)
+	suite.Require().NoError(err)
+
+	last, err := suite.auditService.VerifyChain(context.Background())
+	suite.ErrorIs(err, entities.ErrAuditChainBroken)
+	suite.Require().NotNil(last)
+	suite.Equal(int64(1), last.Sequence)
+}
@@ -0,0 +1,130 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/suite"
+
+	appServices "github.com/bug-breeder/2fair/server/internal/application/usecases"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/backup"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/config"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+// BackupTestSuite takes and restores backups of a PostgreSQL container through a MinIO container
+type BackupTestSuite struct {
+	IntegrationTestSuite
+	minio         *dockertest.Resource
+	backupService interfaces.BackupService
+}
+
+func TestBackupSuite(t *testing.T) {
+	suite.Run(t, new(BackupTestSuite))
+}
+
+// SetupSuite starts PostgreSQL and MinIO, skipping when Docker is not available
+func (suite *BackupTestSuite) SetupSuite() {
+	suite.skipWithoutDocker()
+	suite.IntegrationTestSuite.SetupSuite()
+
+	minio, err := suite.pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "bitnami/minio",
+		Tag:        "latest",
+		Env: []string{
+			"MINIO_ROOT_USER=testuser",
+			"MINIO_ROOT_PASSWORD=testpassword",
+			"MINIO_DEFAULT_BUCKETS=backups",
+		},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		suite.T().Fatalf("Could not start MinIO container: %s", err)
+	}
+	suite.minio = minio
+	if err := minio.Expire(120); err != nil {
+		suite.T().Fatalf("Could not set container expiration: %s", err)
+	}
+
+	endpoint := fmt.Sprintf("http://localhost:%s", minio.GetPort("9000/tcp"))
+	if err := suite.pool.Retry(func() error {
+		resp, err := http.Get(endpoint + "/minio/health/ready")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("MinIO is not ready: %s", resp.Status)
+		}
+		return nil
+	}); err != nil {
+		suite.T().Fatalf("Could not connect to MinIO container: %s", err)
+	}
+
+	archiver, store, err := backup.NewFromConfig(config.BackupConfig{
+		EncryptionKey: base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		Target:        config.BackupTargetS3,
+		S3: config.BackupS3Config{
+			Endpoint:        endpoint,
+			Region:          "us-east-1",
+			Bucket:          "backups",
+			Prefix:          "2fair/",
+			AccessKeyID:     "testuser",
+			SecretAccessKey: "testpassword",
+			PathStyle:       true,
+		},
+	})
+	if err != nil {
+		suite.T().Fatalf("Could not create backup store: %s", err)
+	}
+	suite.backupService = appServices.NewBackupService(database.NewBackupRepository(suite.DB), archiver, store, 1, 0)
+}
+
+// TearDownSuite removes the MinIO container as well as PostgreSQL
+func (suite *BackupTestSuite) TearDownSuite() {
+	if suite.pool != nil && suite.minio != nil {
+		if err := suite.pool.Purge(suite.minio); err != nil {
+			suite.T().Logf("Could not purge MinIO container: %s", err)
+		}
+	}
+	suite.IntegrationTestSuite.TearDownSuite()
+}
+
+func (suite *BackupTestSuite) TestCreateAndRestore() {
+	ctx := context.Background()
+	_, err := suite.DB.Pool.Exec(ctx, `INSERT INTO users (username, email, display_name) VALUES ('alice', 'alice@example.com', 'Alice')`)
+	suite.Require().NoError(err)
+
+	info, err := suite.backupService.Create(ctx)
+	suite.Require().NoError(err)
+
+	manifest, err := suite.backupService.Verify(ctx, info.Name)
+	suite.Require().NoError(err)
+	suite.Assert().NotZero(manifest.Header.SchemaVersion)
+
+	_, err = suite.DB.Pool.Exec(ctx, `UPDATE users SET display_name = 'Mallory'`)
+	suite.Require().NoError(err)
+	_, err = suite.DB.Pool.Exec(ctx, `INSERT INTO users (username, email, display_name) VALUES ('bob', 'bob@example.com', 'Bob')`)
+	suite.Require().NoError(err)
+
+	_, err = suite.backupService.Restore(ctx, info.Name)
+	suite.Require().NoError(err)
+
+	var count int
+	var displayName string
+	suite.Require().NoError(suite.DB.Pool.QueryRow(ctx, `SELECT COUNT(*), MAX(display_name) FROM users`).Scan(&count, &displayName))
+	suite.Assert().Equal(1, count)
+	suite.Assert().Equal("Alice", displayName)
+
+	backups, err := suite.backupService.List(ctx)
+	suite.Require().NoError(err)
+	suite.Assert().Len(backups, 1)
+}
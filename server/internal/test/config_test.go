@@ -31,8 +31,9 @@ func TestConfigLoad_Success(t *testing.T) {
 	assert.Equal(t, "2fair_test", cfg.Database.Name)
 
 	// Test JWT configuration
-	assert.Equal(t, "test-signing-key", cfg.JWT.SigningKey)
-	assert.Equal(t, 1*time.Hour, cfg.JWT.ExpirationTime)
+	assert.Equal(t, "test-keys", cfg.JWT.KeysDir)
+	assert.Equal(t, 15*time.Minute, cfg.JWT.ExpirationTime)
+	assert.Equal(t, 7*24*time.Hour, cfg.JWT.RefreshTime)
 
 	// Test WebAuthn configuration
 	assert.Equal(t, "2FAir Test", cfg.WebAuthn.RPDisplayName)
@@ -43,6 +44,10 @@ func TestConfigLoad_Success(t *testing.T) {
 	assert.Equal(t, "test-session-secret", cfg.OAuth.SessionSecret)
 	assert.Equal(t, "test-google-client-id", cfg.OAuth.Google.ClientID)
 	assert.Equal(t, "test-github-client-id", cfg.OAuth.GitHub.ClientID)
+
+	// Test Vault configuration defaults
+	assert.Equal(t, 500, cfg.Vault.BatchMaxOperations)
+	assert.Equal(t, int64(2<<20), cfg.Vault.BatchMaxBodyBytes)
 }
 
 func TestConfigLoad_MissingRequiredFields(t *testing.T) {
@@ -51,11 +56,6 @@ func TestConfigLoad_MissingRequiredFields(t *testing.T) {
 		missingVar  string
 		expectedErr string
 	}{
-		{
-			name:        "missing JWT signing key",
-			missingVar:  "JWT_SIGNING_KEY",
-			expectedErr: "JWT_SIGNING_KEY is required",
-		},
 		{
 			name:        "missing OAuth session secret",
 			missingVar:  "OAUTH_SESSION_SECRET",
@@ -103,6 +103,15 @@ func TestConfigLoad_ProductionValidation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, cfg)
 	assert.Contains(t, err.Error(), "DB_PASSWORD is required in production")
+
+	// Production also needs signing keys
+	os.Setenv("DB_PASSWORD", "test-password")
+	os.Unsetenv("JWT_KEYS_DIR")
+
+	cfg, err = config.Load()
+	assert.Error(t, err)
+	assert.Nil(t, cfg)
+	assert.Contains(t, err.Error(), "JWT_KEYS_DIR is required in production")
 }
 
 func TestConfigLoad_CustomValues(t *testing.T) {
@@ -167,7 +176,7 @@ func setTestEnvVars(t *testing.T) map[string]string {
 
 	// Store original values
 	vars := []string{
-		"JWT_SIGNING_KEY",
+		"JWT_KEYS_DIR",
 		"OAUTH_SESSION_SECRET",
 		"WEBAUTHN_RP_ID",
 		"WEBAUTHN_RP_DISPLAY_NAME",
@@ -192,7 +201,7 @@ func setTestEnvVars(t *testing.T) map[string]string {
 	}
 
 	// Set test values
-	os.Setenv("JWT_SIGNING_KEY", "test-signing-key")
+	os.Setenv("JWT_KEYS_DIR", "test-keys")
 	os.Setenv("OAUTH_SESSION_SECRET", "test-session-secret")
 	os.Setenv("WEBAUTHN_RP_ID", "localhost")
 	os.Setenv("WEBAUTHN_RP_DISPLAY_NAME", "2FAir Test")
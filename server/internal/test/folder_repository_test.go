@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+// FolderRepositoryTestSuite checks the folder tree against PostgreSQL
+type FolderRepositoryTestSuite struct {
+	IntegrationTestSuite
+	folderRepo interfaces.FolderRepository
+	otpRepo    interfaces.OTPRepository
+	user       *entities.User
+}
+
+func TestFolderRepositorySuite(t *testing.T) {
+	suite.Run(t, new(FolderRepositoryTestSuite))
+}
+
+// SetupSuite starts PostgreSQL, skipping when Docker is not available
+func (suite *FolderRepositoryTestSuite) SetupSuite() {
+	suite.skipWithoutDocker()
+	suite.IntegrationTestSuite.SetupSuite()
+
+	suite.folderRepo = database.NewFolderRepository(suite.DB)
+	suite.otpRepo = database.NewOTPRepository(suite.DB, nil)
+}
+
+// SetupTest empties the database and creates the folders' owner
+func (suite *FolderRepositoryTestSuite) SetupTest() {
+	suite.IntegrationTestSuite.SetupTest()
+	suite.user = suite.StoreTestUser("alice")
+}
+
+// storeFolder creates a folder under parent, at the top level when parent is nil
+func (suite *FolderRepositoryTestSuite) storeFolder(name string, parent *entities.Folder) *entities.Folder {
+	var parentID *uuid.UUID
+	if parent != nil {
+		parentID = &parent.ID
+	}
+	folder := entities.NewFolder(suite.user.ID, name, parentID)
+	suite.Require().NoError(suite.folderRepo.Create(context.Background(), folder))
+	return folder
+}
+
+// storeEntry creates an entry in folder
+func (suite *FolderRepositoryTestSuite) storeEntry(label string, folder *entities.Folder) *entities.OTP {
+	otp := &entities.OTP{
+		UserID:    suite.user.ID,
+		Issuer:    "GitHub",
+		Label:     label,
+		Period:    30,
+		Algorithm: "SHA1",
+		Digits:    6,
+		Method:    entities.OTPMethodTOTP,
+		Type:      entities.OTPTypeStandard,
+		Tags:      []string{},
+		FolderID:  &folder.ID,
+	}
+	suite.Require().NoError(suite.otpRepo.Create(context.Background(), otp, testEnvelope(1, label)))
+	return otp
+}
+
+func (suite *FolderRepositoryTestSuite) TestNesting() {
+	ctx := context.Background()
+	work := suite.storeFolder("Work", nil)
+	cloud := suite.storeFolder("Cloud", work)
+	aws := suite.storeFolder("AWS", cloud)
+	personal := suite.storeFolder("Personal", nil)
+	suite.storeEntry("alice", cloud)
+
+	folders, err := suite.folderRepo.GetByUserID(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(folders, 4)
+	for _, folder := range folders {
+		if folder.ID == cloud.ID {
+			suite.Assert().Equal(&work.ID, folder.ParentID)
+			suite.Assert().Equal(int64(1), folder.EntryCount)
+		}
+	}
+
+	tests := []struct {
+		root, folder *entities.Folder
+		inSubtree    bool
+	}{
+		{work, work, true},
+		{work, cloud, true},
+		{work, aws, true},
+		{cloud, work, false},
+		{aws, cloud, false},
+		{work, personal, false},
+	}
+	for _, tt := range tests {
+		inSubtree, err := suite.folderRepo.IsInSubtree(ctx, tt.root.ID, tt.folder.ID)
+		suite.Require().NoError(err)
+		suite.Assert().Equal(tt.inSubtree, inSubtree, "%s in %s", tt.folder.Name, tt.root.Name)
+	}
+}
+
+func (suite *FolderRepositoryTestSuite) TestSiblingNames() {
+	ctx := context.Background()
+	work := suite.storeFolder("Work", nil)
+	suite.storeFolder("Cloud", work)
+
+	err := suite.folderRepo.Create(ctx, entities.NewFolder(suite.user.ID, "work", nil))
+	suite.Assert().ErrorIs(err, entities.ErrFolderExists, "sibling names are unique ignoring case")
+	err = suite.folderRepo.Create(ctx, entities.NewFolder(suite.user.ID, "CLOUD", &work.ID))
+	suite.Assert().ErrorIs(err, entities.ErrFolderExists)
+
+	// The same name is fine under another parent, or for another user
+	suite.storeFolder("Cloud", nil)
+	bob := suite.StoreTestUser("bob")
+	suite.Require().NoError(suite.folderRepo.Create(ctx, entities.NewFolder(bob.ID, "Work", nil)))
+
+	// Moving a folder next to one with its name collides too
+	personal := suite.storeFolder("Personal", work)
+	personal.Move("Cloud", &work.ID)
+	suite.Assert().ErrorIs(suite.folderRepo.Update(ctx, personal), entities.ErrFolderExists)
+}
+
+func (suite *FolderRepositoryTestSuite) TestOwnership() {
+	ctx := context.Background()
+	work := suite.storeFolder("Work", nil)
+	bob := suite.StoreTestUser("bob")
+
+	_, err := suite.folderRepo.GetByID(ctx, work.ID, bob.ID)
+	suite.Assert().ErrorIs(err, entities.ErrFolderNotFound)
+
+	work.UserID = bob.ID
+	work.Move("Taken", nil)
+	suite.Assert().ErrorIs(suite.folderRepo.Update(ctx, work), entities.ErrFolderNotFound)
+	suite.Assert().ErrorIs(suite.folderRepo.Delete(ctx, work.ID, bob.ID), entities.ErrFolderNotFound)
+
+	stored, err := suite.folderRepo.GetByID(ctx, work.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal("Work", stored.Name)
+}
+
+func (suite *FolderRepositoryTestSuite) TestDelete_MovesContentsUp() {
+	ctx := context.Background()
+	work := suite.storeFolder("Work", nil)
+	cloud := suite.storeFolder("Cloud", work)
+	aws := suite.storeFolder("AWS", cloud)
+	inCloud := suite.storeEntry("in cloud", cloud)
+	inAWS := suite.storeEntry("in aws", aws)
+
+	suite.Require().NoError(suite.folderRepo.Delete(ctx, cloud.ID, suite.user.ID))
+
+	_, err := suite.folderRepo.GetByID(ctx, cloud.ID, suite.user.ID)
+	suite.Assert().ErrorIs(err, entities.ErrFolderNotFound)
+	moved, err := suite.folderRepo.GetByID(ctx, aws.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&work.ID, moved.ParentID, "subfolders move up to the deleted folder's parent")
+
+	otp, err := suite.otpRepo.GetByID(ctx, inCloud.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&work.ID, otp.FolderID, "entries move up to the deleted folder's parent")
+	suite.Assert().Greater(otp.Revision, inCloud.Revision)
+	otp, err = suite.otpRepo.GetByID(ctx, inAWS.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&aws.ID, otp.FolderID, "entries in subfolders stay put")
+
+	// Deleting a top-level folder moves its contents to the top level
+	suite.Require().NoError(suite.folderRepo.Delete(ctx, work.ID, suite.user.ID))
+	otp, err = suite.otpRepo.GetByID(ctx, inCloud.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Nil(otp.FolderID)
+	moved, err = suite.folderRepo.GetByID(ctx, aws.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Nil(moved.ParentID)
+}
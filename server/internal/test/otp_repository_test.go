@@ -0,0 +1,257 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+// OTPRepositoryTestSuite checks vault entry changes against PostgreSQL
+type OTPRepositoryTestSuite struct {
+	IntegrationTestSuite
+	otpRepo  interfaces.OTPRepository
+	syncRepo interfaces.SyncRepository
+	user     *entities.User
+}
+
+func TestOTPRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OTPRepositoryTestSuite))
+}
+
+// SetupSuite starts PostgreSQL, skipping when Docker is not available
+func (suite *OTPRepositoryTestSuite) SetupSuite() {
+	suite.skipWithoutDocker()
+	suite.IntegrationTestSuite.SetupSuite()
+
+	suite.otpRepo = database.NewOTPRepository(suite.DB, nil)
+	suite.syncRepo = database.NewSyncRepository(suite.DB)
+}
+
+// SetupTest empties the database and creates the vault's owner
+func (suite *OTPRepositoryTestSuite) SetupTest() {
+	suite.IntegrationTestSuite.SetupTest()
+	suite.user = suite.StoreTestUser("alice")
+}
+
+// testEnvelope returns a secret envelope encrypted with keyVersion
+func testEnvelope(keyVersion int, ciphertext string) *entities.SecretEnvelope {
+	return &entities.SecretEnvelope{
+		Format:     entities.SecretEnvelopeFormat,
+		Suite:      entities.CipherSuiteAES256GCM,
+		KeyVersion: keyVersion,
+		IV:         bytes.Repeat([]byte{1}, 12),
+		AuthTag:    bytes.Repeat([]byte{2}, 16),
+		Ciphertext: []byte(ciphertext),
+	}
+}
+
+// storeOTP creates an entry encrypted with keyVersion
+func (suite *OTPRepositoryTestSuite) storeOTP(keyVersion int) *entities.OTP {
+	otp := &entities.OTP{
+		UserID:    suite.user.ID,
+		Issuer:    "GitHub",
+		Label:     "alice",
+		Period:    30,
+		Algorithm: "SHA1",
+		Digits:    6,
+		Method:    entities.OTPMethodTOTP,
+		Type:      entities.OTPTypeStandard,
+		Tags:      []string{},
+	}
+	suite.Require().NoError(suite.otpRepo.Create(context.Background(), otp, testEnvelope(keyVersion, "first")))
+	return otp
+}
+
+// updateOTP re-saves an entry with a new label and secret encrypted with keyVersion
+func (suite *OTPRepositoryTestSuite) updateOTP(otp *entities.OTP, label string, keyVersion int) {
+	otp.Label = label
+	suite.Require().NoError(suite.otpRepo.Update(context.Background(), otp, testEnvelope(keyVersion, label), 0))
+}
+
+func (suite *OTPRepositoryTestSuite) TestRevert() {
+	ctx := context.Background()
+	otp := suite.storeOTP(1)
+	suite.updateOTP(otp, "second", 1)
+	suite.updateOTP(otp, "third", 1)
+
+	revisions, err := suite.otpRepo.ListRevisions(ctx, otp.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(revisions, 2)
+	suite.Assert().Equal("second", revisions[0].Label)
+
+	reverted, err := suite.otpRepo.Revert(ctx, otp.ID, suite.user.ID, revisions[0].ID, 1)
+	suite.Require().NoError(err)
+	suite.Assert().Equal("second", reverted.Label)
+	suite.Assert().Greater(reverted.Revision, otp.Revision)
+
+	// The replaced version is kept, so the revert can itself be undone
+	revisions, err = suite.otpRepo.ListRevisions(ctx, otp.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(revisions, 3)
+	suite.Assert().Equal("third", revisions[0].Label)
+	suite.Assert().Equal(entities.OTPRevisionRevert, revisions[0].Reason)
+
+	_, err = suite.otpRepo.Revert(ctx, otp.ID, suite.user.ID, uuid.New(), 1)
+	suite.Assert().ErrorIs(err, entities.ErrRevisionNotFound)
+
+	// Revisions of other entries do not apply
+	other := suite.storeOTP(1)
+	_, err = suite.otpRepo.Revert(ctx, other.ID, suite.user.ID, revisions[0].ID, 1)
+	suite.Assert().ErrorIs(err, entities.ErrRevisionNotFound)
+}
+
+func (suite *OTPRepositoryTestSuite) TestRevert_RetiredKey() {
+	ctx := context.Background()
+	otp := suite.storeOTP(1)
+	// A key rotation re-encrypts the entry; the version it replaced stays on key 1
+	suite.updateOTP(otp, "rotated", 2)
+	suite.updateOTP(otp, "edited", 2)
+
+	revisions, err := suite.otpRepo.ListRevisions(ctx, otp.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(revisions, 2)
+	suite.Require().Equal(1, revisions[1].KeyVersion)
+
+	_, err = suite.otpRepo.Revert(ctx, otp.ID, suite.user.ID, revisions[1].ID, 2)
+	suite.Assert().ErrorIs(err, entities.ErrRevisionKeyRetired)
+
+	current, err := suite.otpRepo.GetByID(ctx, otp.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal("edited", current.Label, "a refused revert changes nothing")
+	suite.Assert().Equal(2, current.KeyVersion)
+
+	reverted, err := suite.otpRepo.Revert(ctx, otp.ID, suite.user.ID, revisions[0].ID, 2)
+	suite.Require().NoError(err)
+	suite.Assert().Equal("rotated", reverted.Label)
+	suite.Assert().Equal(2, reverted.KeyVersion)
+}
+
+func (suite *OTPRepositoryTestSuite) TestResolveConflict_ClientKeyVersion() {
+	ctx := context.Background()
+	otp := suite.storeOTP(1)
+	base := otp.Revision
+	suite.updateOTP(otp, "server", 1)
+
+	// A device edited the entry offline before the key rotated
+	client := &entities.OTPVersion{
+		Issuer: "GitHub", Label: "client", Secret: testEnvelope(1, "client").String(), KeyVersion: 1,
+		Algorithm: "SHA1", Digits: 6, Period: 30, Type: entities.OTPTypeStandard, Tags: []string{},
+	}
+	_, conflict, err := suite.syncRepo.ApplyChange(ctx, suite.user.ID, &entities.SyncChange{
+		Type: entities.SyncChangeUpdate, ID: otp.ID, BaseRevision: base, Version: client,
+	})
+	suite.Require().NoError(err)
+	suite.Require().NotNil(conflict)
+
+	_, _, err = suite.syncRepo.ResolveConflict(ctx, suite.user.ID, conflict.ID,
+		&entities.SyncResolution{Choice: entities.SyncResolutionClient, KeyVersion: 2})
+	suite.Assert().ErrorIs(err, entities.ErrInvalidOperation)
+
+	conflicts, err := suite.syncRepo.ListConflicts(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Len(conflicts, 1, "the conflict stays open")
+
+	_, resolved, err := suite.syncRepo.ResolveConflict(ctx, suite.user.ID, conflict.ID,
+		&entities.SyncResolution{Choice: entities.SyncResolutionClient, KeyVersion: 1})
+	suite.Require().NoError(err)
+	suite.Assert().Equal("client", resolved.Label)
+}
+
+func (suite *OTPRepositoryTestSuite) TestChanges() {
+	ctx := context.Background()
+	updated := suite.storeOTP(1)
+	deleted := suite.storeOTP(1)
+	purged := suite.storeOTP(1)
+
+	full, err := suite.syncRepo.Changes(ctx, suite.user.ID, "")
+	suite.Require().NoError(err)
+	suite.Assert().True(full.Full)
+	suite.Assert().Len(full.Created, 3)
+	suite.Assert().Empty(full.Updated)
+	suite.Assert().Empty(full.Deleted)
+
+	// Nothing changed since the cursor
+	none, err := suite.syncRepo.Changes(ctx, suite.user.ID, full.Cursor)
+	suite.Require().NoError(err)
+	suite.Assert().False(none.Full)
+	suite.Assert().Empty(none.Created)
+	suite.Assert().Empty(none.Updated)
+	suite.Assert().Empty(none.Deleted)
+	suite.Assert().Equal(full.Cursor, none.Cursor)
+
+	suite.updateOTP(updated, "second", 1)
+	suite.updateOTP(updated, "third", 1)
+	suite.Require().NoError(suite.otpRepo.Delete(ctx, deleted.ID, suite.user.ID, 0))
+	suite.Require().NoError(suite.otpRepo.Delete(ctx, purged.ID, suite.user.ID, 0))
+	suite.Require().NoError(suite.otpRepo.Purge(ctx, purged.ID, suite.user.ID))
+	created := suite.storeOTP(1)
+	transient := suite.storeOTP(1)
+	suite.Require().NoError(suite.otpRepo.Delete(ctx, transient.ID, suite.user.ID, 0))
+
+	delta, err := suite.syncRepo.Changes(ctx, suite.user.ID, full.Cursor)
+	suite.Require().NoError(err)
+	suite.Assert().False(delta.Full)
+	suite.Assert().NotEqual(full.Cursor, delta.Cursor)
+
+	suite.Require().Len(delta.Created, 1, "an entry created and trashed since the cursor is left out")
+	suite.Assert().Equal(created.ID, delta.Created[0].ID)
+	suite.Require().Len(delta.Updated, 1, "changes are collapsed to one per entry")
+	suite.Assert().Equal(updated.ID, delta.Updated[0].ID)
+	suite.Assert().Equal("third", delta.Updated[0].Label)
+
+	tombstones := make(map[uuid.UUID]*entities.OTPTombstone)
+	for _, tombstone := range delta.Deleted {
+		tombstones[tombstone.ID] = tombstone
+	}
+	suite.Require().Len(tombstones, 2)
+	suite.Require().Contains(tombstones, deleted.ID)
+	suite.Assert().False(tombstones[deleted.ID].Purged)
+	suite.Assert().NotNil(tombstones[deleted.ID].DeletedAt)
+	suite.Require().Contains(tombstones, purged.ID)
+	suite.Assert().True(tombstones[purged.ID].Purged)
+
+	// Restoring a trashed entry hands it back as created
+	_, err = suite.otpRepo.Restore(ctx, deleted.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	restored, err := suite.syncRepo.Changes(ctx, suite.user.ID, delta.Cursor)
+	suite.Require().NoError(err)
+	suite.Require().Len(restored.Created, 1)
+	suite.Assert().Equal(deleted.ID, restored.Created[0].ID)
+	suite.Assert().Empty(restored.Updated)
+	suite.Assert().Empty(restored.Deleted)
+}
+
+func (suite *OTPRepositoryTestSuite) TestChanges_Scope() {
+	ctx := context.Background()
+	full, err := suite.syncRepo.Changes(ctx, suite.user.ID, "")
+	suite.Require().NoError(err)
+
+	// Another user's changes do not reach this vault
+	bob := suite.StoreTestUser("bob")
+	otp := &entities.OTP{
+		UserID: bob.ID, Issuer: "GitHub", Label: "bob", Period: 30, Algorithm: "SHA1", Digits: 6,
+		Method: entities.OTPMethodTOTP, Type: entities.OTPTypeStandard, Tags: []string{},
+	}
+	suite.Require().NoError(suite.otpRepo.Create(ctx, otp, testEnvelope(1, "bob")))
+
+	delta, err := suite.syncRepo.Changes(ctx, suite.user.ID, full.Cursor)
+	suite.Require().NoError(err)
+	suite.Assert().Empty(delta.Created)
+	suite.Assert().Equal(full.Cursor, delta.Cursor)
+
+	// Bob's cursor is ahead of Alice's vault
+	bobChanges, err := suite.syncRepo.Changes(ctx, bob.ID, "")
+	suite.Require().NoError(err)
+	_, err = suite.syncRepo.Changes(ctx, suite.user.ID, bobChanges.Cursor)
+	suite.Assert().ErrorIs(err, entities.ErrInvalidCursor)
+
+	_, err = suite.syncRepo.Changes(ctx, suite.user.ID, "not a cursor")
+	suite.Assert().ErrorIs(err, entities.ErrInvalidCursor)
+}
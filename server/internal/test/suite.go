@@ -95,6 +95,16 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 	suite.T().Log("Integration test suite setup complete")
 }
 
+// skipWithoutDocker skips the suite in short mode or when Docker is not available
+func (suite *IntegrationTestSuite) skipWithoutDocker() {
+	if testing.Short() {
+		suite.T().Skip("Skipping integration tests in short mode")
+	}
+	if pool, err := dockertest.NewPool(""); err != nil || pool.Client.Ping() != nil {
+		suite.T().Skip("Skipping integration tests without Docker")
+	}
+}
+
 // TearDownSuite runs after all tests in the suite
 func (suite *IntegrationTestSuite) TearDownSuite() {
 	if suite.DB != nil {
@@ -130,7 +140,6 @@ func (suite *IntegrationTestSuite) setupTestConfig() {
 	os.Setenv("DB_PASSWORD", "testpassword")
 	os.Setenv("DB_NAME", "testdb")
 	os.Setenv("DB_SSL_MODE", "disable")
-	os.Setenv("JWT_SIGNING_KEY", "test-jwt-key-for-integration-tests")
 	os.Setenv("OAUTH_SESSION_SECRET", "test-oauth-session-secret")
 	os.Setenv("WEBAUTHN_RP_ID", "localhost")
 	os.Setenv("WEBAUTHN_RP_DISPLAY_NAME", "2FAir Test")
@@ -187,6 +196,13 @@ func (suite *IntegrationTestSuite) CreateTestUser(username, email, displayName s
 	return user
 }
 
+// StoreTestUser creates a user in the database
+func (suite *IntegrationTestSuite) StoreTestUser(username string) *entities.User {
+	user := entities.NewUser(username, username+"@example.com", username)
+	suite.Require().NoError(database.NewUserRepository(suite.DB).Create(context.Background(), user))
+	return user
+}
+
 // AssertDatabaseConnection verifies the database connection is working
 func (suite *IntegrationTestSuite) AssertDatabaseConnection() {
 	ctx := context.Background()
@@ -201,7 +217,6 @@ func (suite *IntegrationTestSuite) AssertDatabaseConnection() {
 // GetTestConfig returns a configuration suitable for testing
 func GetTestConfig() *config.Config {
 	// Set test environment variables
-	os.Setenv("JWT_SIGNING_KEY", "test-jwt-key")
 	os.Setenv("OAUTH_SESSION_SECRET", "test-oauth-session-secret")
 	os.Setenv("WEBAUTHN_RP_ID", "localhost")
 	os.Setenv("WEBAUTHN_RP_DISPLAY_NAME", "2FAir Test")
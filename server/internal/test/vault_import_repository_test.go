@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/bug-breeder/2fair/server/internal/domain/entities"
+	"github.com/bug-breeder/2fair/server/internal/domain/interfaces"
+	"github.com/bug-breeder/2fair/server/internal/infrastructure/database"
+)
+
+// VaultImportRepositoryTestSuite checks restoring vault exports against PostgreSQL
+type VaultImportRepositoryTestSuite struct {
+	IntegrationTestSuite
+	importRepo interfaces.VaultImportRepository
+	otpRepo    interfaces.OTPRepository
+	folderRepo interfaces.FolderRepository
+	keyRepo    interfaces.EncryptionKeyRepository
+	user       *entities.User
+}
+
+func TestVaultImportRepositorySuite(t *testing.T) {
+	suite.Run(t, new(VaultImportRepositoryTestSuite))
+}
+
+// SetupSuite starts PostgreSQL, skipping when Docker is not available
+func (suite *VaultImportRepositoryTestSuite) SetupSuite() {
+	suite.skipWithoutDocker()
+	suite.IntegrationTestSuite.SetupSuite()
+
+	suite.importRepo = database.NewVaultImportRepository(suite.DB)
+	suite.otpRepo = database.NewOTPRepository(suite.DB, nil)
+	suite.folderRepo = database.NewFolderRepository(suite.DB)
+	suite.keyRepo = database.NewEncryptionKeyRepository(suite.DB)
+}
+
+// SetupTest empties the database and creates the vault's owner
+func (suite *VaultImportRepositoryTestSuite) SetupTest() {
+	suite.IntegrationTestSuite.SetupTest()
+	suite.user = suite.StoreTestUser("alice")
+}
+
+// storeEntry creates an entry in the user's vault
+func (suite *VaultImportRepositoryTestSuite) storeEntry(userID uuid.UUID, label string) *entities.OTP {
+	otp := &entities.OTP{
+		UserID:    userID,
+		Issuer:    "GitHub",
+		Label:     label,
+		Period:    30,
+		Algorithm: "SHA1",
+		Digits:    6,
+		Method:    entities.OTPMethodTOTP,
+		Type:      entities.OTPTypeStandard,
+		Tags:      []string{},
+	}
+	suite.Require().NoError(suite.otpRepo.Create(context.Background(), otp, testEnvelope(1, label)))
+	return otp
+}
+
+// exportedEntry returns an entry as an export holds it
+func exportedEntry(id uuid.UUID, label string, folderID *uuid.UUID) *entities.VaultExportEntry {
+	return &entities.VaultExportEntry{
+		ID:        id,
+		Issuer:    "GitHub",
+		Label:     label,
+		Secret:    testEnvelope(1, label).String(),
+		Algorithm: "SHA1",
+		Digits:    6,
+		Period:    30,
+		Method:    entities.OTPMethodTOTP,
+		Type:      entities.OTPTypeStandard,
+		Tags:      []string{},
+		FolderID:  folderID,
+	}
+}
+
+// activeLabels returns the labels of the user's active entries by ID
+func (suite *VaultImportRepositoryTestSuite) activeLabels() map[uuid.UUID]string {
+	otps, err := suite.otpRepo.GetByUserID(context.Background(), suite.user.ID)
+	suite.Require().NoError(err)
+	labels := make(map[uuid.UUID]string, len(otps))
+	for _, otp := range otps {
+		labels[otp.ID] = otp.Label
+	}
+	return labels
+}
+
+func (suite *VaultImportRepositoryTestSuite) TestImport_Merge() {
+	ctx := context.Background()
+	kept := suite.storeEntry(suite.user.ID, "kept")
+	work := entities.NewFolder(suite.user.ID, "Work", nil)
+	suite.Require().NoError(suite.folderRepo.Create(ctx, work))
+
+	// The file's "work" folder is the vault's "Work"; its "Cloud" subfolder is new
+	fileWork := &entities.VaultExportFolder{ID: uuid.New(), Name: "work"}
+	fileCloud := &entities.VaultExportFolder{ID: uuid.New(), ParentID: &fileWork.ID, Name: "Cloud"}
+	added := uuid.New()
+
+	result, err := suite.importRepo.Import(ctx, suite.user.ID, &entities.VaultImport{
+		Mode:    entities.VaultImportMerge,
+		Folders: []*entities.VaultExportFolder{fileWork, fileCloud},
+		Entries: []*entities.VaultExportEntry{
+			exportedEntry(kept.ID, "changed in the file", nil),
+			exportedEntry(added, "added", &fileCloud.ID),
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&entities.VaultImportResult{Mode: entities.VaultImportMerge, Created: 1, Skipped: 1, FoldersCreated: 1}, result)
+
+	suite.Assert().Equal(map[uuid.UUID]string{kept.ID: "kept", added: "added"}, suite.activeLabels(), "merging keeps the vault's copy")
+
+	folders, err := suite.folderRepo.GetByUserID(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(folders, 2)
+	var cloud *entities.Folder
+	for _, folder := range folders {
+		if folder.Name == "Cloud" {
+			cloud = folder
+		}
+	}
+	suite.Require().NotNil(cloud)
+	suite.Assert().Equal(&work.ID, cloud.ParentID)
+
+	otp, err := suite.otpRepo.GetByID(ctx, added, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&cloud.ID, otp.FolderID)
+}
+
+func (suite *VaultImportRepositoryTestSuite) TestImport_Replace() {
+	ctx := context.Background()
+	replaced := suite.storeEntry(suite.user.ID, "before")
+	trashed := suite.storeEntry(suite.user.ID, "not in the file")
+	added := uuid.New()
+
+	result, err := suite.importRepo.Import(ctx, suite.user.ID, &entities.VaultImport{
+		Mode: entities.VaultImportReplace,
+		Entries: []*entities.VaultExportEntry{
+			exportedEntry(replaced.ID, "from the file", nil),
+			exportedEntry(added, "added", nil),
+		},
+	})
+	suite.Require().NoError(err)
+	suite.Assert().Equal(&entities.VaultImportResult{Mode: entities.VaultImportReplace, Created: 1, Replaced: 1, Trashed: 1}, result)
+
+	suite.Assert().Equal(map[uuid.UUID]string{replaced.ID: "from the file", added: "added"}, suite.activeLabels())
+
+	trash, err := suite.otpRepo.ListTrash(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(trash, 1)
+	suite.Assert().Equal(trashed.ID, trash[0].ID)
+
+	// The overwritten version is kept as a revision
+	revisions, err := suite.otpRepo.ListRevisions(ctx, replaced.ID, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(revisions, 1)
+	suite.Assert().Equal("before", revisions[0].Label)
+	suite.Assert().Equal(entities.OTPRevisionImport, revisions[0].Reason)
+}
+
+func (suite *VaultImportRepositoryTestSuite) TestImport_TakenID() {
+	ctx := context.Background()
+	bob := suite.StoreTestUser("bob")
+	theirs := suite.storeEntry(bob.ID, "bob's")
+
+	result, err := suite.importRepo.Import(ctx, suite.user.ID, &entities.VaultImport{
+		Mode:    entities.VaultImportMerge,
+		Entries: []*entities.VaultExportEntry{exportedEntry(theirs.ID, "alice's", nil)},
+	})
+	suite.Require().NoError(err)
+	suite.Assert().Equal(1, result.Created)
+
+	labels := suite.activeLabels()
+	suite.Require().Len(labels, 1)
+	suite.Assert().NotContains(labels, theirs.ID, "the entry gets a new ID")
+
+	otp, err := suite.otpRepo.GetByID(ctx, theirs.ID, bob.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal("bob's", otp.Label, "another user's entry is untouched")
+}
+
+func (suite *VaultImportRepositoryTestSuite) TestImport_RestoresKeys() {
+	ctx := context.Background()
+	credential := entities.NewWebAuthnCredential(suite.user.ID, []byte("credential"), []byte("public key"))
+	suite.Require().NoError(database.NewWebAuthnCredentialRepository(suite.DB).Create(ctx, credential))
+
+	result, err := suite.importRepo.Import(ctx, suite.user.ID, &entities.VaultImport{
+		Mode: entities.VaultImportMerge,
+		Keys: []*entities.UserEncryptionKey{entities.NewUserEncryptionKey(suite.user.ID, credential.ID, 3, []byte("wrapped"), []byte("salt"))},
+	})
+	suite.Require().NoError(err)
+	suite.Assert().Equal(1, result.KeysRestored)
+
+	version, err := suite.keyRepo.GetLatestVersion(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Assert().Equal(3, version, "the vault takes the file's key version")
+}